@@ -0,0 +1,282 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/drand/drand/v2/common/chain"
+	"github.com/drand/go-clients/drand"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+)
+
+// fixtureVariant 是每個隨機信標和遊戲局號組合生成的一種情況
+type fixtureVariant struct {
+	name          string
+	contributions [][]byte
+}
+
+// fixtureVariants 覆蓋沒有貢獻和有多個貢獻兩種情況，貢獻固定不變以保證輸出確定
+var fixtureVariants = []fixtureVariant{
+	{"plain", nil},
+	{"contributions", [][]byte{[]byte("seat-1 commitment"), []byte("seat-2 commitment")}},
+}
+
+// fixture 是一個測試數據文件的內容
+// 字節欄位以十六進制編碼；proof 與庫的 ShuffleProof JSON 格式相同，其中的字節欄位為 base64
+type fixture struct {
+	Name          string                     `json:"name"`
+	Beacon        json.RawMessage            `json:"beacon"` // drand 中繼 /public/<輪次> 的格式
+	SessionID     string                     `json:"session_id"`
+	Contributions []string                   `json:"contributions"`
+	Deck          []string                   `json:"deck"`       // CardToString 的寫法，如 "黑桃A"
+	DeckCodes     []string                   `json:"deck_codes"` // CardCode 的寫法，如 "As"
+	DeckEncoded   string                     `json:"deck_encoded"`
+	DeckDigest    string                     `json:"deck_digest"`
+	DeckCBOR      string                     `json:"deck_cbor"`
+	Proof         *drandshuffle.ShuffleProof `json:"proof"`
+	ProofDigest   string                     `json:"proof_digest"`
+}
+
+// fixtureManifest 是 manifest.json 的內容，列出所有測試數據文件及其 SHA-256，供使用方確認文件完整
+type fixtureManifest struct {
+	Algorithm string          `json:"algorithm"`
+	ChainHash string          `json:"chain_hash"`
+	Files     []manifestEntry `json:"files"`
+}
+
+// manifestEntry 是 manifest.json 中的一個文件
+type manifestEntry struct {
+	File   string `json:"file"`
+	SHA256 string `json:"sha256"`
+}
+
+// genFixtures 從記錄的隨機信標生成測試數據
+// 相同的輸入總是產生逐字節相同的輸出，輸出中不含時間戳等隨運行環境變化的內容
+func genFixtures(args []string) error {
+	fs := flag.NewFlagSet("gen-fixtures", flag.ContinueOnError)
+	rounds := fs.String("rounds", "", "記錄的隨機信標文件，格式見 drandshuffle.ParseBeaconJSON（必填）")
+	out := fs.String("out", "", "輸出目錄，不存在時創建（必填）")
+	chainName := fs.String("chain", "quicknet", "隨機信標所屬的內置網絡，見 drandshuffle.Chains")
+	chainInfoPath := fs.String("chain-info", "", "中繼 /info 返回的鏈信息文件；設定時先驗證所有隨機信標的簽名，證明中的 round_time 也由它推算")
+	sessions := fs.String("sessions", "fixture_0,fixture:1", "以逗號分隔的遊戲局號，每個隨機信標都與每個局號組合")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *rounds == "" || *out == "" {
+		fs.Usage()
+		return errors.New("必須指定 --rounds 和 --out")
+	}
+	// 文件名中的 ":" 換成 "-"，換算後相同的局號會寫到同一個文件
+	sessionIDs := strings.Split(*sessions, ",")
+	names := make(map[string]bool, len(sessionIDs))
+	for _, id := range sessionIDs {
+		if err := drandshuffle.ValidateSessionID(id); err != nil {
+			return err
+		}
+		name := fixtureSessionName(id)
+		if names[name] {
+			return fmt.Errorf("遊戲局號 %s 與其他局號的文件名相同", id)
+		}
+		names[name] = true
+	}
+
+	data, err := os.ReadFile(*rounds)
+	if err != nil {
+		return fmt.Errorf("無法讀取隨機信標文件: %w", err)
+	}
+	beacons, err := drandshuffle.ParseBeaconJSON(data)
+	if err != nil {
+		return err
+	}
+	slices.SortFunc(beacons, func(a, b drandshuffle.Beacon) int { return cmp.Compare(a.Round, b.Round) })
+	beacons = slices.CompactFunc(beacons, func(a, b drandshuffle.Beacon) bool { return a.Round == b.Round })
+
+	opts := []drandshuffle.Option{drandshuffle.WithChain(*chainName)}
+	var info *chain.Info
+	if *chainInfoPath != "" {
+		f, err := os.Open(*chainInfoPath)
+		if err != nil {
+			return fmt.Errorf("無法讀取鏈信息文件: %w", err)
+		}
+		info, err = drandshuffle.ReadChainInfo(f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		verifier, err := drandshuffle.NewVerifier(info)
+		if err != nil {
+			return fmt.Errorf("無法創建驗證器: %w", err)
+		}
+		for _, beacon := range beacons {
+			if err := verifier.Verify(beacon); err != nil {
+				return fmt.Errorf("輪次 %d 的隨機信標驗證失敗: %w", beacon.Round, err)
+			}
+		}
+		opts = []drandshuffle.Option{drandshuffle.WithChainInfo(info)}
+	}
+
+	dm, err := drandshuffle.NewDrandManagerWithClient(newRecordedClient(beacons, info), opts...)
+	if err != nil {
+		return err
+	}
+	defer dm.Close()
+	client := drandshuffle.NewClientWithManager(dm)
+
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		return fmt.Errorf("無法創建輸出目錄: %w", err)
+	}
+	manifest := fixtureManifest{Algorithm: drandshuffle.ProofAlgorithm, ChainHash: dm.Config().ChainHash}
+	for _, beacon := range beacons {
+		for _, sessionID := range sessionIDs {
+			for _, variant := range fixtureVariants {
+				fx, err := newFixture(client, beacon, sessionID, variant)
+				if err != nil {
+					return err
+				}
+				file := fx.Name + ".json"
+				content, err := json.MarshalIndent(fx, "", "  ")
+				if err != nil {
+					return err
+				}
+				if err := writeFixtureFile(filepath.Join(*out, file), content); err != nil {
+					return err
+				}
+				sum := sha256.Sum256(append(content, '\n'))
+				manifest.Files = append(manifest.Files, manifestEntry{File: file, SHA256: hex.EncodeToString(sum[:])})
+			}
+		}
+	}
+
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFixtureFile(filepath.Join(*out, "manifest.json"), content); err != nil {
+		return err
+	}
+	fmt.Printf("已生成 %d 個測試數據文件到 %s\n", len(manifest.Files), *out)
+	return nil
+}
+
+// newFixture 以 ShuffleBuilder 洗牌並生成證明，寫出前先用 VerifyProof 確認結果可以被驗證
+func newFixture(client *drandshuffle.Client, beacon drandshuffle.Beacon, sessionID string, variant fixtureVariant) (*fixture, error) {
+	result, err := client.NewShuffle().
+		Session(sessionID).
+		Round(beacon.Round).
+		WithContributions(variant.contributions...).
+		WithProof().
+		Do(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("無法以輪次 %d 和遊戲局號 %s 洗牌: %w", beacon.Round, sessionID, err)
+	}
+	if err := drandshuffle.VerifyProof(result.Proof, nil, result.Deck); err != nil {
+		return nil, fmt.Errorf("生成的證明無法通過驗證: %w", err)
+	}
+	beaconJSON, err := drandshuffle.EncodeBeaconJSON(beacon)
+	if err != nil {
+		return nil, err
+	}
+
+	fx := &fixture{
+		Name:          fmt.Sprintf("%d_%s_%s", beacon.Round, fixtureSessionName(sessionID), variant.name),
+		Beacon:        beaconJSON,
+		SessionID:     sessionID,
+		Contributions: []string{},
+		DeckEncoded:   drandshuffle.EncodeDeck(result.Deck),
+		DeckDigest:    drandshuffle.DeckDigest(result.Deck),
+		DeckCBOR:      hex.EncodeToString(drandshuffle.EncodeDeckCBOR(result.Deck)),
+		Proof:         result.Proof,
+		ProofDigest:   result.Proof.Digest(),
+	}
+	for _, c := range variant.contributions {
+		fx.Contributions = append(fx.Contributions, hex.EncodeToString(c))
+	}
+	for _, card := range result.Deck {
+		code, err := drandshuffle.CardCode(card)
+		if err != nil {
+			return nil, err
+		}
+		fx.Deck = append(fx.Deck, drandshuffle.CardToString(card))
+		fx.DeckCodes = append(fx.DeckCodes, code)
+	}
+	return fx, nil
+}
+
+// fixtureSessionName 返回遊戲局號在文件名中的寫法，Windows 的文件名不允許 ":"
+func fixtureSessionName(sessionID string) string {
+	return strings.ReplaceAll(sessionID, ":", "-")
+}
+
+// writeFixtureFile 寫出 JSON 並以換行結尾
+func writeFixtureFile(path string, content []byte) error {
+	if err := os.WriteFile(path, append(content, '\n'), 0o644); err != nil {
+		return fmt.Errorf("無法寫入 %s: %w", path, err)
+	}
+	return nil
+}
+
+// recordedClient 是只提供記錄的隨機信標的 drand.Client，不發出任何網絡請求
+type recordedClient struct {
+	beacons map[uint64]drandshuffle.Beacon
+	latest  uint64
+	info    *chain.Info
+}
+
+// newRecordedClient 以記錄的隨機信標創建 recordedClient，info 可以為 nil
+func newRecordedClient(beacons []drandshuffle.Beacon, info *chain.Info) *recordedClient {
+	c := &recordedClient{beacons: make(map[uint64]drandshuffle.Beacon, len(beacons)), info: info}
+	for _, b := range beacons {
+		c.beacons[b.Round] = b
+		c.latest = max(c.latest, b.Round)
+	}
+	return c
+}
+
+// Get 返回記錄的隨機信標，round 為 0 時返回輪次最大的一個
+func (c *recordedClient) Get(_ context.Context, round uint64) (drand.Result, error) {
+	if round == 0 {
+		round = c.latest
+	}
+	b, ok := c.beacons[round]
+	if !ok {
+		return nil, fmt.Errorf("%w: 記錄中沒有輪次 %d", drandshuffle.ErrRoundNotFound, round)
+	}
+	return b, nil
+}
+
+// Watch 返回已關閉的通道，記錄的隨機信標不會更新
+func (c *recordedClient) Watch(context.Context) <-chan drand.Result {
+	ch := make(chan drand.Result)
+	close(ch)
+	return ch
+}
+
+// Info 返回 --chain-info 指定的鏈信息
+func (c *recordedClient) Info(context.Context) (*chain.Info, error) {
+	if c.info == nil {
+		return nil, errors.New("沒有指定鏈信息")
+	}
+	return c.info, nil
+}
+
+// RoundAt 返回記錄中最大的輪次
+func (c *recordedClient) RoundAt(time.Time) uint64 {
+	return c.latest
+}
+
+// Close 不做任何事
+func (c *recordedClient) Close() error {
+	return nil
+}
@@ -0,0 +1,64 @@
+// drandshuffle 是 drandshuffle 庫的命令行工具
+//
+//	drandshuffle gen-fixtures --rounds beacons.json --out fixtures/
+//	drandshuffle replay-corpus --corpus tests/testdata/shuffle_corpus.jsonl
+//	drandshuffle soak --duration 24h
+//	drandshuffle verify-snippet --round 1000 --session game_1 --lang python > verify.py
+//	drandshuffle export-state --sessions-db sessions.db --audit audit.jsonl --out state.json
+//	drandshuffle import-state --in state.json --sessions-db new/sessions.db --audit new/audit.jsonl
+//	drandshuffle report --sessions-db sessions.db --from 2026-09-01 --to 2026-10-01 --key report.key --out report.json
+//	drandshuffle verify-report --in report.json --pub report.pub
+//
+// 各子命令的參數見 drandshuffle <子命令> -h
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// command 是一個子命令，run 接收子命令之後的參數
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+// commands 是可用的子命令，按此順序列在用法說明中
+var commands = []command{
+	{"gen-fixtures", "從記錄的隨機信標生成確定性的牌組和證明測試數據，供其他語言的實現核對驗證算法", genFixtures},
+	{"replay-corpus", "以當前的洗牌算法重放語料並與記錄的結果比較，防止意外改變洗牌的派生方式", replayCorpus},
+	{"verify-snippet", "輸出指定輪次和遊戲局號的洗牌推導，作為偽代碼和可直接運行的 Python、JavaScript 示例", verifySnippet},
+	{"export-state", "導出服務的隨機信標、牌局和審計記錄尾部為一致的狀態文件，用於藍綠升級", exportState},
+	{"import-state", "將 export-state 的狀態文件導入新服務，已存在的項目保持原樣，可以重複導入", importState},
+	{"report", "統計一段時間內使用的輪次、發出的牌局、驗證通過率和均勻性，輸出以 Ed25519 簽名的監管報告", generateReport},
+	{"verify-report", "以運營方的公鑰驗證 report 輸出的簽名報告並輸出摘要", verifyReport},
+	{"soak", "長時間持續獲取隨機信標、洗牌並驗證，定期輸出記憶體和錯誤統計，用於上線前的穩定性測試", soak},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	for _, c := range commands {
+		if c.name == os.Args[1] {
+			if err := c.run(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "drandshuffle %s: %v\n", c.name, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+	usage()
+	os.Exit(2)
+}
+
+// usage 輸出可用的子命令
+func usage() {
+	fmt.Fprintln(os.Stderr, "用法: drandshuffle <子命令> [參數]")
+	fmt.Fprintln(os.Stderr)
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-14s %s\n", c.name, c.usage)
+	}
+}
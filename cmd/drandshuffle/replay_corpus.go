@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle/drandshuffletest"
+)
+
+// replayCorpus 以當前的洗牌算法重放語料並與記錄的結果比較，有不一致時返回錯誤
+// --update 時不比較，而是以當前的結果覆寫語料，用於加入新用例或確認有意的算法變更
+func replayCorpus(args []string) error {
+	fs := flag.NewFlagSet("replay-corpus", flag.ContinueOnError)
+	corpus := fs.String("corpus", "", "JSON Lines 格式的語料文件，格式見 drandshuffletest.CorpusCase（必填）")
+	update := fs.Bool("update", false, "以當前算法的結果覆寫語料中記錄的結果")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *corpus == "" {
+		fs.Usage()
+		return errors.New("必須指定 --corpus")
+	}
+
+	data, err := os.ReadFile(*corpus)
+	if err != nil {
+		return fmt.Errorf("無法讀取語料: %w", err)
+	}
+	cases, err := drandshuffletest.ReadCorpus(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	if *update {
+		for i := range cases {
+			if cases[i].Expected, err = cases[i].Shuffle(); err != nil {
+				return err
+			}
+		}
+		var buf bytes.Buffer
+		if err := drandshuffletest.WriteCorpus(&buf, cases); err != nil {
+			return err
+		}
+		if err := os.WriteFile(*corpus, buf.Bytes(), 0o644); err != nil {
+			return fmt.Errorf("無法寫入語料: %w", err)
+		}
+		fmt.Printf("已更新 %d 個用例的結果\n", len(cases))
+		return nil
+	}
+
+	mismatches, err := drandshuffletest.ReplayCorpus(cases)
+	if err != nil {
+		return err
+	}
+	for _, m := range mismatches {
+		fmt.Println(m)
+	}
+	if len(mismatches) > 0 {
+		return fmt.Errorf("%d 個用例中有 %d 個與記錄的結果不一致", len(cases), len(mismatches))
+	}
+	fmt.Printf("%d 個用例全部一致\n", len(cases))
+	return nil
+}
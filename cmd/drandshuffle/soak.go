@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+)
+
+// soakHistoryDepth 是浸泡測試回溯歷史輪次的範圍，大於默認的緩存大小，使緩存持續淘汰和重新獲取
+const soakHistoryDepth = 200
+
+// soakStats 是浸泡測試的累計統計
+type soakStats struct {
+	start      time.Time
+	shuffles   int            // 成功洗牌的次數，包括最新輪次和歷史輪次
+	verified   int            // 通過簽名和牌組驗證的次數
+	mismatches int            // 驗證不一致的次數，不為 0 表示有缺陷
+	errors     map[string]int // 按 drandshuffle.Category 分類的錯誤次數
+	lastErr    error
+	startHeap  uint64
+	peakHeap   uint64
+}
+
+// soak 長時間持續獲取隨機信標、洗牌並驗證，定期輸出記憶體和錯誤統計
+// 用於上線前確認後台獲取和緩存在長時間運行下保持穩定；收到中斷信號時提前結束並輸出總結，
+// 有驗證不一致時返回錯誤
+func soak(args []string) error {
+	fs := flag.NewFlagSet("soak", flag.ContinueOnError)
+	duration := fs.Duration("duration", 24*time.Hour, "運行時間")
+	interval := fs.Duration("interval", time.Second, "每次洗牌的間隔")
+	report := fs.Duration("report", time.Minute, "輸出統計的間隔")
+	configPath := fs.String("config", "", "配置文件，格式見 drandshuffle.LoadConfig；未指定時使用默認配置和環境變量")
+	relays := fs.String("relays", "", "以逗號分隔的中繼地址，覆蓋配置中的地址")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *duration <= 0 || *interval <= 0 || *report <= 0 {
+		fs.Usage()
+		return errors.New("--duration、--interval 和 --report 必須大於 0")
+	}
+
+	cfg, err := drandshuffle.LoadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	if *relays != "" {
+		cfg.URLs = strings.Split(*relays, ",")
+	}
+	dm, err := drandshuffle.NewDrandManager(drandshuffle.WithConfig(cfg))
+	if err != nil {
+		return err
+	}
+	defer dm.Close()
+	info := dm.ChainInfo()
+	if info == nil {
+		return errors.New("無法取得鏈信息，無法驗證隨機信標的簽名")
+	}
+	verifier, err := drandshuffle.NewVerifier(info)
+	if err != nil {
+		return fmt.Errorf("無法創建驗證器: %w", err)
+	}
+	client := drandshuffle.NewClientWithManager(dm)
+	dm.StartBackgroundFetching()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, *duration)
+	defer cancel()
+
+	stats := &soakStats{start: time.Now(), errors: make(map[string]int)}
+	stats.startHeap = heapInUse()
+	stats.peakHeap = stats.startHeap
+	fmt.Printf("開始浸泡測試，鏈 %s，運行 %s\n", dm.Config().ChainHash, *duration)
+
+	tick := time.NewTicker(*interval)
+	defer tick.Stop()
+	reportTick := time.NewTicker(*report)
+	defer reportTick.Stop()
+	for n := 0; ; n++ {
+		select {
+		case <-ctx.Done():
+			fmt.Println("浸泡測試結束")
+			stats.print(os.Stdout, dm)
+			if stats.mismatches > 0 {
+				return fmt.Errorf("%d 次驗證不一致，最近一次: %v", stats.mismatches, stats.lastErr)
+			}
+			return nil
+		case <-reportTick.C:
+			stats.print(os.Stdout, dm)
+		case <-tick.C:
+			stats.runOnce(ctx, client, verifier, n)
+		}
+	}
+}
+
+// runOnce 以最新輪次和一個較早的歷史輪次各洗牌一次並驗證
+func (s *soakStats) runOnce(ctx context.Context, client *drandshuffle.Client, verifier *drandshuffle.Verifier, n int) {
+	sessionID := fmt.Sprintf("soak_%d", n)
+	result, err := client.NewShuffle().Session(sessionID).WithProof().Do(ctx)
+	if err != nil {
+		s.recordError(ctx, err)
+		return
+	}
+	s.check(verifier, result)
+
+	if result.Round <= soakHistoryDepth {
+		return
+	}
+	round := result.Round - uint64(n%soakHistoryDepth) - 1
+	result, err = client.NewShuffle().Session(sessionID).Round(round).WithProof().Do(ctx)
+	if err != nil {
+		s.recordError(ctx, err)
+		return
+	}
+	s.check(verifier, result)
+}
+
+// check 以鏈的公鑰驗證隨機信標，再重新洗牌比對牌組
+func (s *soakStats) check(verifier *drandshuffle.Verifier, result *drandshuffle.ShuffleResult) {
+	s.shuffles++
+	if err := verifier.Verify(result.Proof.Beacon()); err != nil {
+		s.mismatches++
+		s.lastErr = fmt.Errorf("輪次 %d 的隨機信標驗證失敗: %w", result.Round, err)
+		return
+	}
+	if err := drandshuffle.VerifyProof(result.Proof, drandshuffle.Poker52, result.Deck); err != nil {
+		s.mismatches++
+		s.lastErr = fmt.Errorf("輪次 %d 遊戲局 %s 的牌組驗證失敗: %w", result.Round, result.Proof.SessionID, err)
+		return
+	}
+	s.verified++
+}
+
+// recordError 按類別記錄洗牌失敗，結束時取消的請求不計入
+func (s *soakStats) recordError(ctx context.Context, err error) {
+	if ctx.Err() != nil {
+		return
+	}
+	s.errors[drandshuffle.Category(err).String()]++
+	s.lastErr = err
+}
+
+// print 輸出一行累計統計
+func (s *soakStats) print(w io.Writer, dm *drandshuffle.DrandManager) {
+	heap := heapInUse()
+	s.peakHeap = max(s.peakHeap, heap)
+	health := dm.Health()
+
+	categories := make([]string, 0, len(s.errors))
+	for category, count := range s.errors {
+		categories = append(categories, fmt.Sprintf("%s=%d", category, count))
+	}
+	sort.Strings(categories)
+	errs := "無"
+	if len(categories) > 0 {
+		errs = strings.Join(categories, " ")
+	}
+
+	fmt.Fprintf(w, "[%s] 洗牌 %d 次，驗證通過 %d 次，不一致 %d 次，錯誤 %s；最新輪次 %d（%s 前發布），連續獲取失敗 %d 次，缺失 %d 輪；堆 %.1f MiB（開始 %.1f，峰值 %.1f），goroutine %d\n",
+		time.Since(s.start).Round(time.Second), s.shuffles, s.verified, s.mismatches, errs,
+		health.LatestRound, health.LatestAge.Round(time.Millisecond), health.ConsecutiveFailures, health.MissedRounds,
+		mebibytes(heap), mebibytes(s.startHeap), mebibytes(s.peakHeap), runtime.NumGoroutine())
+	if s.lastErr != nil {
+		fmt.Fprintf(w, "  最近的錯誤: %v\n", s.lastErr)
+	}
+}
+
+// heapInUse 在垃圾回收後返回使用中的堆記憶體，使各次讀數可以互相比較
+func heapInUse() uint64 {
+	runtime.GC()
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return m.HeapInuse
+}
+
+// mebibytes 將字節數換算為 MiB
+func mebibytes(n uint64) float64 {
+	return float64(n) / (1 << 20)
+}
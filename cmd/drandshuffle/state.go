@@ -0,0 +1,350 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/audit"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/sqlstore"
+)
+
+// stateFormat 是狀態文件的格式標識，格式不兼容地改變時提升版本
+const stateFormat = "drandshuffle-state/v1"
+
+// stateFile 是 export-state 輸出的狀態文件
+// digest 是其餘欄位（digest 為空時）的 JSON 編碼的 SHA-256，導入時檢查，防止傳輸中截斷或損壞
+type stateFile struct {
+	Format     string            `json:"format"`
+	ExportedAt time.Time         `json:"exported_at"`
+	Beacons    []json.RawMessage `json:"beacons"` // drand 中繼 /public/<輪次> 的格式，按輪次排序
+	Sessions   []stateSession    `json:"sessions"`
+	AuditTail  []audit.Record    `json:"audit_tail"` // 審計記錄文件最後的記錄，按寫入順序
+	Digest     string            `json:"digest"`
+}
+
+// stateSession 是狀態文件中的一局，牌組以 drandshuffle.EncodeDeck 編碼
+type stateSession struct {
+	SessionID    string                     `json:"session_id"`
+	Round        uint64                     `json:"round"`
+	Deck         string                     `json:"deck"`
+	Proof        *drandshuffle.ShuffleProof `json:"proof,omitempty"`
+	CreatedAt    time.Time                  `json:"created_at"`
+	Tenant       string                     `json:"tenant,omitempty"`
+	Verification string                     `json:"verification"`
+}
+
+// stateSources 是服務保存狀態的位置，與服務的參數對應，為空的位置不導出或導入
+type stateSources struct {
+	sessionsDB string // 服務的 -sessions-db，保存牌局和隨機信標的 SQLite 數據庫
+	beacons    string // drandshuffle.FileBeaconStore 的文件
+	audit      string // audit.FileStore 的文件
+}
+
+// register 註冊指定狀態位置的參數
+func (s *stateSources) register(fs *flag.FlagSet) {
+	fs.StringVar(&s.sessionsDB, "sessions-db", "", "牌局和隨機信標的 SQLite 數據庫，與服務的 -sessions-db 相同")
+	fs.StringVar(&s.beacons, "beacons", "", "FileBeaconStore 的信標文件")
+	fs.StringVar(&s.audit, "audit", "", "audit.FileStore 的審計記錄文件")
+}
+
+// empty 報告是否沒有指定任何狀態位置
+func (s *stateSources) empty() bool {
+	return s.sessionsDB == "" && s.beacons == "" && s.audit == ""
+}
+
+// exportState 導出服務的隨機信標緩存、牌局和審計記錄的尾部為一個狀態文件，用於藍綠升級時遷移到新的服務
+// 數據庫中的信標和牌局在同一個事務中讀取；信標文件和審計記錄文件是只追加的，讀取到當時的末尾，
+// 忽略寫入中的最後一行。服務運行中也可以導出，切換前在舊服務停止寫入後再導出一次即可補上差額
+func exportState(args []string) error {
+	fs := flag.NewFlagSet("export-state", flag.ContinueOnError)
+	var src stateSources
+	src.register(fs)
+	auditTail := fs.Int("audit-tail", 1000, "導出審計記錄文件最後的記錄數量，0 表示全部")
+	out := fs.String("out", "", "輸出的狀態文件，為空時寫到標準輸出")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if src.empty() {
+		fs.Usage()
+		return errors.New("至少需要指定 --sessions-db、--beacons 或 --audit 之一")
+	}
+	ctx := context.Background()
+
+	state := stateFile{Format: stateFormat, ExportedAt: time.Now().UTC(), Beacons: []json.RawMessage{}, Sessions: []stateSession{}, AuditTail: []audit.Record{}}
+	var beacons []drandshuffle.Beacon
+	if src.sessionsDB != "" {
+		store, db, err := openSessionsDB(ctx, src.sessionsDB)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		snapshot, err := store.Snapshot(ctx)
+		if err != nil {
+			return err
+		}
+		beacons = append(beacons, snapshot.Beacons...)
+		for _, s := range snapshot.Sessions {
+			state.Sessions = append(state.Sessions, stateSession{
+				SessionID:    s.SessionID,
+				Round:        s.Round,
+				Deck:         drandshuffle.EncodeDeck(s.Deck),
+				Proof:        s.Proof,
+				CreatedAt:    s.CreatedAt,
+				Tenant:       s.Tenant,
+				Verification: string(s.Verification),
+			})
+		}
+	}
+	if src.beacons != "" {
+		saved, err := drandshuffle.NewFileBeaconStore(src.beacons).Load()
+		if err != nil {
+			return err
+		}
+		beacons = append(beacons, saved...)
+	}
+	for _, beacon := range uniqueBeacons(beacons) {
+		data, err := drandshuffle.EncodeBeaconJSON(beacon)
+		if err != nil {
+			return err
+		}
+		state.Beacons = append(state.Beacons, data)
+	}
+	if src.audit != "" {
+		records, err := readAuditFile(src.audit)
+		if err != nil {
+			return err
+		}
+		if *auditTail > 0 && len(records) > *auditTail {
+			records = records[len(records)-*auditTail:]
+		}
+		state.AuditTail = append(state.AuditTail, records...)
+	}
+
+	digest, err := state.digest()
+	if err != nil {
+		return err
+	}
+	state.Digest = digest
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("無法編碼狀態文件: %w", err)
+	}
+	data = append(data, '\n')
+	if *out == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	// 狀態文件包含已發出的牌組，只允許所有者讀取
+	if err := os.WriteFile(*out, data, 0o600); err != nil {
+		return fmt.Errorf("無法寫入狀態文件: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "已導出 %d 個隨機信標、%d 局牌局和 %d 條審計記錄到 %s\n", len(state.Beacons), len(state.Sessions), len(state.AuditTail), *out)
+	return nil
+}
+
+// importState 將 export-state 的狀態文件導入新服務的狀態位置
+// 已存在的輪次、遊戲局號和審計記錄保持原樣，因此可以重複導入，或先導入一次完整快照、切換前再導入一次增量
+func importState(args []string) error {
+	fs := flag.NewFlagSet("import-state", flag.ContinueOnError)
+	var dst stateSources
+	dst.register(fs)
+	in := fs.String("in", "", "export-state 輸出的狀態文件（必填）")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *in == "" || dst.empty() {
+		fs.Usage()
+		return errors.New("必須指定 --in，以及 --sessions-db、--beacons 或 --audit 之一")
+	}
+	ctx := context.Background()
+
+	data, err := os.ReadFile(*in)
+	if err != nil {
+		return fmt.Errorf("無法讀取狀態文件: %w", err)
+	}
+	var state stateFile
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("無法解析狀態文件: %w", err)
+	}
+	if state.Format != stateFormat {
+		return fmt.Errorf("不支持的狀態文件格式 %q，預期為 %q", state.Format, stateFormat)
+	}
+	if digest, err := state.digest(); err != nil {
+		return err
+	} else if digest != state.Digest {
+		return errors.New("狀態文件的摘要不符，文件可能被截斷或修改")
+	}
+
+	var beacons []drandshuffle.Beacon
+	for i, raw := range state.Beacons {
+		parsed, err := drandshuffle.ParseBeaconJSON(raw)
+		if err != nil || len(parsed) != 1 {
+			return fmt.Errorf("狀態文件的第 %d 個隨機信標無效: %v", i+1, err)
+		}
+		beacons = append(beacons, parsed[0])
+	}
+
+	if dst.sessionsDB != "" {
+		store, db, err := openSessionsDB(ctx, dst.sessionsDB)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		snapshot := &sqlstore.Snapshot{Beacons: beacons}
+		for _, s := range state.Sessions {
+			deck, err := drandshuffle.DecodeDeck(s.Deck)
+			if err != nil {
+				return fmt.Errorf("遊戲局號 %s 的牌組無效: %w", s.SessionID, err)
+			}
+			snapshot.Sessions = append(snapshot.Sessions, sqlstore.Session{
+				SessionID:    s.SessionID,
+				Round:        s.Round,
+				Deck:         deck,
+				Proof:        s.Proof,
+				CreatedAt:    s.CreatedAt,
+				Tenant:       s.Tenant,
+				Verification: sqlstore.Verification(s.Verification),
+			})
+		}
+		result, err := store.Restore(ctx, snapshot)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "數據庫: 新增 %d 個隨機信標、%d 局牌局\n", result.Beacons, result.Sessions)
+	}
+
+	if dst.beacons != "" {
+		n, err := importBeaconFile(dst.beacons, beacons)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "信標文件: 新增 %d 個隨機信標\n", n)
+	}
+
+	if dst.audit != "" {
+		n, err := importAuditFile(dst.audit, state.AuditTail)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "審計記錄文件: 新增 %d 條審計記錄\n", n)
+	}
+	return nil
+}
+
+// digest 計算狀態文件除 digest 之外的內容的摘要
+func (s stateFile) digest() (string, error) {
+	s.Digest = ""
+	data, err := json.Marshal(s)
+	if err != nil {
+		return "", fmt.Errorf("無法編碼狀態文件: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// openSessionsDB 打開 SQLite 數據庫並完成遷移
+func openSessionsDB(ctx context.Context, path string) (*sqlstore.Store, *sql.DB, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("無法打開牌局數據庫: %w", err)
+	}
+	store := sqlstore.New(db, sqlstore.SQLite)
+	if err := store.Migrate(ctx); err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	return store, db, nil
+}
+
+// uniqueBeacons 按輪次排序並去除重複的輪次
+func uniqueBeacons(beacons []drandshuffle.Beacon) []drandshuffle.Beacon {
+	slices.SortStableFunc(beacons, func(a, b drandshuffle.Beacon) int { return cmp.Compare(a.Round, b.Round) })
+	return slices.CompactFunc(beacons, func(a, b drandshuffle.Beacon) bool { return a.Round == b.Round })
+}
+
+// importBeaconFile 將文件中尚未保存的輪次追加到信標文件，返回新增的數量
+func importBeaconFile(path string, beacons []drandshuffle.Beacon) (int, error) {
+	store := drandshuffle.NewFileBeaconStore(path)
+	saved, err := store.Load()
+	if err != nil {
+		return 0, err
+	}
+	have := make(map[uint64]bool, len(saved))
+	for _, beacon := range saved {
+		have[beacon.Round] = true
+	}
+	var missing []drandshuffle.Beacon
+	for _, beacon := range beacons {
+		if !have[beacon.Round] {
+			missing = append(missing, beacon)
+		}
+	}
+	return len(missing), store.SaveBatch(missing)
+}
+
+// importAuditFile 將審計記錄文件中沒有的記錄按順序追加到文件，返回新增的數量
+func importAuditFile(path string, records []audit.Record) (int, error) {
+	existing, err := readAuditFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return 0, err
+	}
+	have := make(map[string]bool, len(existing))
+	for _, record := range existing {
+		key, err := json.Marshal(record)
+		if err != nil {
+			return 0, err
+		}
+		have[string(key)] = true
+	}
+
+	store, err := audit.OpenFileStore(path)
+	if err != nil {
+		return 0, err
+	}
+	defer store.Close()
+	n := 0
+	for _, record := range records {
+		key, err := json.Marshal(record)
+		if err != nil {
+			return n, err
+		}
+		if have[string(key)] {
+			continue
+		}
+		if err := store.Append(record); err != nil {
+			return n, err
+		}
+		have[string(key)] = true
+		n++
+	}
+	return n, nil
+}
+
+// readAuditFile 讀取審計記錄文件，忽略寫入中、尚未以換行結束的最後一行
+func readAuditFile(path string) ([]audit.Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("無法打開審計記錄文件: %w", err)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("無法讀取審計記錄文件: %w", err)
+	}
+	return audit.ReadJSONLines(bytes.NewReader(data[:bytes.LastIndexByte(data, '\n')+1]))
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+)
+
+// snippetData 是驗證示例模板的參數，全部取自庫的常量和實際的洗牌結果
+type snippetData struct {
+	Algorithm     string
+	ChainHash     string
+	Round         uint64
+	Randomness    string   // 十六進制
+	BeaconURL     string   // 取得該輪隨機信標的中繼地址
+	WindowBytes   int      // 每一步讀取的大端序整數的字節數
+	Deck          []string // 洗牌前的牌組，CardCode 的寫法
+	Expected      []string // 洗牌的結果，CardCode 的寫法
+	SessionQuoted string   // 以雙引號轉義的遊戲局號，Python 和 JavaScript 的字面量都可以直接使用
+}
+
+// snippetTemplates 是各語言的驗證示例，按 --lang all 時輸出的順序排列
+var snippetTemplates = []struct {
+	lang  string
+	title string
+	text  string
+}{
+	{"pseudo", "偽代碼", pseudoSnippet},
+	{"python", "Python 3", pythonSnippet},
+	{"js", "JavaScript (Node.js)", jsSnippet},
+}
+
+// verifySnippet 輸出指定輪次和遊戲局號的洗牌推導過程，作為偽代碼和可直接運行的 Python、JavaScript 示例
+// 供合作方獨立實現驗證器時對照；示例內嵌該輪的隨機性和本實現的結果，運行時會比對兩者
+func verifySnippet(args []string) error {
+	fs := flag.NewFlagSet("verify-snippet", flag.ContinueOnError)
+	round := fs.Uint64("round", 0, "輪次號碼（必填）")
+	sessionID := fs.String("session", "", "遊戲局號（必填）")
+	lang := fs.String("lang", "all", "輸出的語言：pseudo、python、js 或 all；單一語言時只輸出代碼，可以直接重定向到文件")
+	chainName := fs.String("chain", "quicknet", "隨機信標所屬的內置網絡，見 drandshuffle.Chains")
+	randomnessHex := fs.String("randomness", "", "該輪的隨機性（十六進制）；指定時不連接中繼")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *round == 0 || *sessionID == "" {
+		fs.Usage()
+		return errors.New("必須指定 --round 和 --session")
+	}
+	if err := drandshuffle.ValidateSessionID(*sessionID); err != nil {
+		return err
+	}
+	if !validSnippetLang(*lang) {
+		return fmt.Errorf("未知的語言 %q，可用 pseudo、python、js 或 all", *lang)
+	}
+	preset, err := drandshuffle.LookupChain(*chainName)
+	if err != nil {
+		return err
+	}
+
+	var randomness []byte
+	if *randomnessHex != "" {
+		if randomness, err = hex.DecodeString(*randomnessHex); err != nil {
+			return fmt.Errorf("--randomness 不是有效的十六進制: %w", err)
+		}
+	} else {
+		dm, err := drandshuffle.NewDrandManager(drandshuffle.WithChain(*chainName))
+		if err != nil {
+			return err
+		}
+		defer dm.Close()
+		if randomness, err = dm.GetRandomnessByRound(*round); err != nil {
+			return err
+		}
+	}
+
+	data := snippetData{
+		Algorithm:     drandshuffle.ProofAlgorithm,
+		ChainHash:     preset.ChainHash,
+		Round:         *round,
+		Randomness:    hex.EncodeToString(randomness),
+		BeaconURL:     fmt.Sprintf("%s/%s/public/%d", preset.URLs[0], preset.ChainHash, *round),
+		WindowBytes:   8,
+		SessionQuoted: strconv.Quote(*sessionID),
+	}
+	if data.Deck, err = cardCodes(drandshuffle.Poker52.NewDeck()); err != nil {
+		return err
+	}
+	deck := drandshuffle.NewShuffler(drandshuffle.Poker52).Shuffle(randomness, *sessionID)
+	if data.Expected, err = cardCodes(deck); err != nil {
+		return err
+	}
+
+	return writeSnippets(os.Stdout, *lang, data)
+}
+
+// writeSnippets 按語言輸出示例，all 時每種語言前加上標題
+func writeSnippets(w io.Writer, lang string, data snippetData) error {
+	funcs := template.FuncMap{"quoted": quotedList}
+	for _, s := range snippetTemplates {
+		if lang != "all" && lang != s.lang {
+			continue
+		}
+		if lang == "all" {
+			fmt.Fprintf(w, "==== %s ====\n\n", s.title)
+		}
+		tmpl, err := template.New(s.lang).Funcs(funcs).Parse(s.text)
+		if err != nil {
+			return err
+		}
+		if err := tmpl.Execute(w, data); err != nil {
+			return fmt.Errorf("無法輸出 %s 示例: %w", s.lang, err)
+		}
+		if lang == "all" {
+			fmt.Fprintln(w)
+		}
+	}
+	return nil
+}
+
+// validSnippetLang 報告 lang 是否是 --lang 可用的值
+func validSnippetLang(lang string) bool {
+	if lang == "all" {
+		return true
+	}
+	for _, s := range snippetTemplates {
+		if s.lang == lang {
+			return true
+		}
+	}
+	return false
+}
+
+// cardCodes 將牌組轉為 CardCode 的寫法
+func cardCodes(deck []drandshuffle.Card) ([]string, error) {
+	codes := make([]string, len(deck))
+	for i, card := range deck {
+		code, err := drandshuffle.CardCode(card)
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+	}
+	return codes, nil
+}
+
+// quotedList 將字符串列表寫為以逗號分隔的雙引號字面量
+func quotedList(items []string) string {
+	quoted := make([]string, len(items))
+	for i, item := range items {
+		quoted[i] = strconv.Quote(item)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+const pseudoSnippet = `# {{.Algorithm}} 洗牌推導：輪次 {{.Round}}，遊戲局號 {{.SessionQuoted}}
+# 鏈 {{.ChainHash}}
+
+randomness = hex_decode("{{.Randomness}}")
+    # 該輪隨機信標的 randomness，即 SHA-256(簽名)；可從 {{.BeaconURL}} 取得，並應以鏈的公鑰驗證簽名
+seed = randomness || SHA-256(randomness || utf8({{.SessionQuoted}}))
+    # 共 len(randomness) + 32 字節
+deck = [{{quoted .Deck}}]
+    # 洗牌前的順序：黑桃、紅心、方塊、梅花，各自從 A 到 K
+window = max(1, len(seed) - {{.WindowBytes}})
+for i from len(deck) - 1 down to 1:
+    pos = i mod window
+    j = uint64_big_endian(seed[pos : pos + {{.WindowBytes}}]) mod (i + 1)
+    swap(deck[i], deck[j])
+
+# 預期結果：
+# {{range $i, $c := .Expected}}{{if $i}} {{end}}{{$c}}{{end}}
+`
+
+const pythonSnippet = `#!/usr/bin/env python3
+# {{.Algorithm}} 洗牌的獨立驗證：輪次 {{.Round}}，遊戲局號 {{.SessionQuoted}}
+# 隨機性可從 {{.BeaconURL}} 取得，並應以鏈的公鑰驗證簽名
+import hashlib
+
+RANDOMNESS = bytes.fromhex("{{.Randomness}}")
+SESSION_ID = {{.SessionQuoted}}
+DECK = [{{quoted .Deck}}]
+EXPECTED = [{{quoted .Expected}}]
+
+
+def shuffle(randomness: bytes, session_id: str, deck: list) -> list:
+    seed = randomness + hashlib.sha256(randomness + session_id.encode("utf-8")).digest()
+    deck = list(deck)
+    window = max(1, len(seed) - {{.WindowBytes}})
+    for i in range(len(deck) - 1, 0, -1):
+        pos = i % window
+        j = int.from_bytes(seed[pos:pos + {{.WindowBytes}}], "big") % (i + 1)
+        deck[i], deck[j] = deck[j], deck[i]
+    return deck
+
+
+deck = shuffle(RANDOMNESS, SESSION_ID, DECK)
+print(" ".join(deck))
+assert deck == EXPECTED, "與 drandshuffle 的結果不一致"
+print("與 drandshuffle 的結果一致")
+`
+
+const jsSnippet = `// {{.Algorithm}} 洗牌的獨立驗證：輪次 {{.Round}}，遊戲局號 {{.SessionQuoted}}
+// 隨機性可從 {{.BeaconURL}} 取得，並應以鏈的公鑰驗證簽名
+// 以 node verify.js 運行
+const { createHash } = require("node:crypto");
+const assert = require("node:assert");
+
+const RANDOMNESS = Buffer.from("{{.Randomness}}", "hex");
+const SESSION_ID = {{.SessionQuoted}};
+const DECK = [{{quoted .Deck}}];
+const EXPECTED = [{{quoted .Expected}}];
+
+function shuffle(randomness, sessionId, deck) {
+  const digest = createHash("sha256").update(randomness).update(Buffer.from(sessionId, "utf8")).digest();
+  const seed = Buffer.concat([randomness, digest]);
+  const out = deck.slice();
+  const window = Math.max(1, seed.length - {{.WindowBytes}});
+  for (let i = out.length - 1; i > 0; i--) {
+    const pos = i % window;
+    const j = Number(seed.readBigUInt64BE(pos) % BigInt(i + 1));
+    [out[i], out[j]] = [out[j], out[i]];
+  }
+  return out;
+}
+
+const deck = shuffle(RANDOMNESS, SESSION_ID, DECK);
+console.log(deck.join(" "));
+assert.deepStrictEqual(deck, EXPECTED, "與 drandshuffle 的結果不一致");
+console.log("與 drandshuffle 的結果一致");
+`
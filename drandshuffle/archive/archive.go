@@ -0,0 +1,207 @@
+// Package archive 將洗牌證明封存為證明包並上傳到長期保存的存儲，例如 S3 或 GCS
+//
+// 證明包以遊戲局號或輪次批次為單位，包含每局的牌組和洗牌證明，以及覆蓋全部內容的摘要。
+// 封存後的證明包可以獨立驗證：重新計算摘要，並用 drandshuffle.VerifyProof 重現每一局的牌組。
+package archive
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+)
+
+// ErrBundleTampered 表示證明包的內容與封存時的摘要不一致
+var ErrBundleTampered = errors.New("archive: bundle tampered")
+
+// Entry 是證明包中的一局
+type Entry struct {
+	SessionID string                     `json:"session_id"`
+	Round     uint64                     `json:"round"`
+	Deck      string                     `json:"deck"` // drandshuffle.EncodeDeck 的編碼
+	Proof     *drandshuffle.ShuffleProof `json:"proof,omitempty"`
+}
+
+// NewEntry 從 ShuffleBuilder.Do 的結果創建證明包中的一局
+func NewEntry(result *drandshuffle.ShuffleResult, sessionID string) Entry {
+	return Entry{
+		SessionID: sessionID,
+		Round:     result.Round,
+		Deck:      drandshuffle.EncodeDeck(result.Deck),
+		Proof:     result.Proof,
+	}
+}
+
+// Bundle 是封存的證明包，序列化為 JSON 後上傳
+type Bundle struct {
+	Name     string    `json:"name"`      // 遊戲局號或批次名稱，也是存儲中的對象名稱
+	SealedAt time.Time `json:"sealed_at"` // 封存時間
+	Entries  []Entry   `json:"entries"`
+	Digest   string    `json:"digest"` // Entries 的 JSON 編碼的 SHA-256 摘要（十六進制）
+}
+
+// Seal 封存證明包，計算覆蓋全部內容的摘要
+func Seal(name string, entries []Entry, sealedAt time.Time) (*Bundle, error) {
+	digest, err := entriesDigest(entries)
+	if err != nil {
+		return nil, err
+	}
+	return &Bundle{Name: name, SealedAt: sealedAt.UTC(), Entries: entries, Digest: digest}, nil
+}
+
+// Verify 檢查證明包的摘要，並用每局的洗牌證明重現牌組
+// template 為 nil 時使用 Poker52；沒有證明的局只檢查摘要
+func (b *Bundle) Verify(template *drandshuffle.DeckTemplate) error {
+	digest, err := entriesDigest(b.Entries)
+	if err != nil {
+		return err
+	}
+	if digest != b.Digest {
+		return fmt.Errorf("%w: 證明包 %s 的摘要不一致", ErrBundleTampered, b.Name)
+	}
+	for _, e := range b.Entries {
+		if e.Proof == nil {
+			continue
+		}
+		deck, err := drandshuffle.DecodeDeck(e.Deck)
+		if err != nil {
+			return fmt.Errorf("無法解析遊戲局號 %s 的牌組: %w", e.SessionID, err)
+		}
+		if err := drandshuffle.VerifyProof(e.Proof, template, deck); err != nil {
+			return fmt.Errorf("遊戲局號 %s 驗證失敗: %w", e.SessionID, err)
+		}
+	}
+	return nil
+}
+
+// entriesDigest 返回各局的 JSON 編碼的 SHA-256 摘要
+func entriesDigest(entries []Entry) (string, error) {
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return "", fmt.Errorf("無法編碼證明包: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Sink 是證明包的歸檔目標
+// 實現應保證 Archive 成功返回時證明包已經持久保存；同名的證明包再次上傳時覆蓋或保留原樣都可以
+type Sink interface {
+	Archive(ctx context.Context, bundle *Bundle) error
+}
+
+// DirSink 將證明包以 JSON 文件寫入本地目錄，文件名為證明包名稱加 .json，適合開發和測試
+type DirSink string
+
+// Archive 將證明包寫入目錄，寫入完成後才以最終名稱出現
+func (d DirSink) Archive(ctx context.Context, bundle *Bundle) error {
+	data, err := json.Marshal(bundle)
+	if err != nil {
+		return fmt.Errorf("無法編碼證明包: %w", err)
+	}
+	if err := os.MkdirAll(string(d), 0o700); err != nil {
+		return fmt.Errorf("無法創建歸檔目錄: %w", err)
+	}
+	path := filepath.Join(string(d), objectName(bundle))
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("無法寫入證明包: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("無法寫入證明包: %w", err)
+	}
+	return nil
+}
+
+// objectName 返回證明包在存儲中的對象名稱
+func objectName(bundle *Bundle) string {
+	return bundle.Name + ".json"
+}
+
+// Archiver 在每局發牌後自動封存並上傳證明包，可以並發使用
+// BatchRounds 為 0 時每局單獨封存；否則按輪次分批，同一批的局封存在一個證明包中，
+// 出現下一批的輪次或調用 Flush 時上傳。批次名稱為 "rounds-<起始輪次>-<結束輪次>"；
+// 輪次屬於已經上傳的批次時，該局以遊戲局號單獨封存，不會覆蓋已歸檔的證明包
+type Archiver struct {
+	Sink        Sink
+	BatchRounds uint64
+	Now         func() time.Time // 返回封存時間，nil 時使用 time.Now
+
+	mu      sync.Mutex
+	batch   uint64
+	pending []Entry
+}
+
+// Add 加入一局；需要上傳時在返回前完成上傳，失敗時返回錯誤，該批的局會保留到下次上傳
+func (a *Archiver) Add(ctx context.Context, entry Entry) error {
+	if err := drandshuffle.ValidateSessionID(entry.SessionID); err != nil {
+		return err
+	}
+	if entry.Round == 0 {
+		return fmt.Errorf("%w: 遊戲局號 %s 沒有輪次", drandshuffle.ErrRoundBeforeGenesis, entry.SessionID)
+	}
+	if a.BatchRounds == 0 {
+		return a.archive(ctx, entry.SessionID, []Entry{entry})
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	batch := (entry.Round - 1) / a.BatchRounds
+	if batch < a.batch {
+		// 該批已經上傳，不覆蓋已歸檔的證明包，單獨封存這一局
+		return a.archive(ctx, entry.SessionID, []Entry{entry})
+	}
+	if len(a.pending) > 0 && batch != a.batch {
+		if err := a.flushLocked(ctx); err != nil {
+			return err
+		}
+	}
+	a.batch = batch
+	a.pending = append(a.pending, entry)
+	return nil
+}
+
+// Flush 封存並上傳尚未上傳的批次，沒有時不做任何事
+func (a *Archiver) Flush(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.flushLocked(ctx)
+}
+
+// flushLocked 上傳當前批次，調用者須持有 a.mu
+func (a *Archiver) flushLocked(ctx context.Context) error {
+	if len(a.pending) == 0 {
+		return nil
+	}
+	first := a.batch*a.BatchRounds + 1
+	name := fmt.Sprintf("rounds-%d-%d", first, first+a.BatchRounds-1)
+	if err := a.archive(ctx, name, a.pending); err != nil {
+		return err
+	}
+	a.pending = nil
+	return nil
+}
+
+// archive 封存並上傳一個證明包
+func (a *Archiver) archive(ctx context.Context, name string, entries []Entry) error {
+	now := time.Now
+	if a.Now != nil {
+		now = a.Now
+	}
+	bundle, err := Seal(name, entries, now())
+	if err != nil {
+		return err
+	}
+	if err := a.Sink.Archive(ctx, bundle); err != nil {
+		return fmt.Errorf("無法上傳證明包 %s: %w", name, err)
+	}
+	return nil
+}
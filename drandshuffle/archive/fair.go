@@ -0,0 +1,238 @@
+package archive
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+)
+
+// FairnessFileExt 是公平性文件的擴展名
+const FairnessFileExt = ".fair"
+
+// FairnessFormatVersion 是 WriteFairnessFile 寫入的公平性文件格式版本
+const FairnessFormatVersion = 1
+
+// ErrInvalidFairnessFile 表示無法解析的公平性文件，例如不是 zip、缺少文件、校驗和不符或格式版本過新
+var ErrInvalidFairnessFile = errors.New("archive: invalid fairness file")
+
+// 公平性文件中各部分的文件名
+const (
+	fairManifestName = "manifest.json"
+	fairBundleName   = "bundle.json"
+	fairMetadataName = "metadata.json"
+	fairReadmeName   = "README.txt"
+	fairFormat       = "drandshuffle.fair"
+	// fairMaxFileSize 限制解壓後每個文件的大小，防止壓縮炸彈
+	fairMaxFileSize = 64 << 20
+)
+
+// fairReadme 是寫入公平性文件的說明，讓收到附件的玩家知道如何驗證
+const fairReadme = `這是 DrandShuffle 的公平性文件（.fair），記錄了可以獨立驗證的完整牌局。
+
+bundle.json    證明包：每局的牌組（drandshuffle.EncodeDeck 編碼）、洗牌證明和覆蓋全部內容的摘要
+metadata.json  牌局的其他信息（桌號、玩家等），只用於展示，不參與驗證
+manifest.json  格式版本、驗證算法和各文件的 SHA-256 校驗和
+
+驗證方法：用 archive.ReadFairnessFile 讀取後調用 Verify，或按證明中的 drand 輪次取得隨機信標，
+以 SHA256(randomness || SHA256(randomness || session_id)) 為種子重新執行 Fisher-Yates 洗牌比對牌組。
+`
+
+// FairnessFile 是可以作為單個附件交換的完整遊戲記錄，包含證明包、牌局信息和驗證算法版本
+type FairnessFile struct {
+	Bundle    *Bundle           // 封存的證明包，見 Seal
+	Metadata  map[string]string // 牌局的其他信息，如桌號、盲注和玩家，不參與驗證
+	Verifier  string            // 驗證所需的洗牌算法標識，默認為 drandshuffle.ProofAlgorithm
+	CreatedAt time.Time         // 文件的創建時間，也用作 zip 中各文件的修改時間
+}
+
+// fairManifest 是 manifest.json 的內容
+type fairManifest struct {
+	Format    string            `json:"format"`
+	Version   int               `json:"version"`
+	Verifier  string            `json:"verifier"`
+	CreatedAt time.Time         `json:"created_at"`
+	Files     map[string]string `json:"files"` // 文件名到 SHA-256 校驗和（十六進制）
+}
+
+// WriteFairnessFile 將公平性文件以 zip 格式寫入 w
+// 校驗和只用於發現損壞；牌組和證明的完整性由證明包的摘要和 Verify 保證，Metadata 不受保護
+func WriteFairnessFile(w io.Writer, f *FairnessFile) error {
+	if f == nil || f.Bundle == nil {
+		return fmt.Errorf("公平性文件缺少證明包")
+	}
+	verifier := f.Verifier
+	if verifier == "" {
+		verifier = drandshuffle.ProofAlgorithm
+	}
+	createdAt := f.CreatedAt.UTC()
+	if f.CreatedAt.IsZero() {
+		createdAt = time.Now().UTC()
+	}
+
+	bundle, err := json.MarshalIndent(f.Bundle, "", "  ")
+	if err != nil {
+		return fmt.Errorf("無法編碼證明包: %w", err)
+	}
+	metadata := f.Metadata
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+	meta, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return fmt.Errorf("無法編碼牌局信息: %w", err)
+	}
+	files := map[string][]byte{
+		fairBundleName:   bundle,
+		fairMetadataName: meta,
+		fairReadmeName:   []byte(fairReadme),
+	}
+
+	manifest := fairManifest{
+		Format:    fairFormat,
+		Version:   FairnessFormatVersion,
+		Verifier:  verifier,
+		CreatedAt: createdAt,
+		Files:     make(map[string]string, len(files)),
+	}
+	for name, data := range files {
+		sum := sha256.Sum256(data)
+		manifest.Files[name] = hex.EncodeToString(sum[:])
+	}
+	if files[fairManifestName], err = json.MarshalIndent(manifest, "", "  "); err != nil {
+		return fmt.Errorf("無法編碼公平性文件清單: %w", err)
+	}
+
+	// 清單寫在最前面，各文件按固定順序寫入，使相同的記錄得到相同的文件
+	names := []string{fairManifestName, fairBundleName, fairMetadataName, fairReadmeName}
+	zw := zip.NewWriter(w)
+	for _, name := range names {
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: createdAt})
+		if err != nil {
+			return fmt.Errorf("無法寫入公平性文件: %w", err)
+		}
+		if _, err := fw.Write(files[name]); err != nil {
+			return fmt.Errorf("無法寫入公平性文件: %w", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("無法寫入公平性文件: %w", err)
+	}
+	return nil
+}
+
+// ReadFairnessFile 讀取 WriteFairnessFile 寫入的公平性文件並核對各文件的校驗和
+// 只檢查文件結構，牌組和證明應再用 FairnessFile.Verify 驗證；格式錯誤時返回包裝 ErrInvalidFairnessFile 的錯誤
+func ReadFairnessFile(r io.ReaderAt, size int64) (*FairnessFile, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: 不是 zip 文件: %w", ErrInvalidFairnessFile, err)
+	}
+
+	contents := make(map[string][]byte, len(zr.File))
+	for _, file := range zr.File {
+		if _, ok := contents[file.Name]; ok {
+			return nil, fmt.Errorf("%w: 重複的文件 %s", ErrInvalidFairnessFile, file.Name)
+		}
+		data, err := readZipFile(file)
+		if err != nil {
+			return nil, err
+		}
+		contents[file.Name] = data
+	}
+
+	var manifest fairManifest
+	data, ok := contents[fairManifestName]
+	if !ok {
+		return nil, fmt.Errorf("%w: 缺少 %s", ErrInvalidFairnessFile, fairManifestName)
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("%w: 無法解析 %s: %w", ErrInvalidFairnessFile, fairManifestName, err)
+	}
+	if manifest.Format != fairFormat {
+		return nil, fmt.Errorf("%w: 未知的格式 %q", ErrInvalidFairnessFile, manifest.Format)
+	}
+	if manifest.Version < 1 || manifest.Version > FairnessFormatVersion {
+		return nil, fmt.Errorf("%w: 不支持的格式版本 %d，請升級驗證工具", ErrInvalidFairnessFile, manifest.Version)
+	}
+
+	names := make([]string, 0, len(manifest.Files))
+	for name := range manifest.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		data, ok := contents[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: 缺少 %s", ErrInvalidFairnessFile, name)
+		}
+		sum := sha256.Sum256(data)
+		if hex.EncodeToString(sum[:]) != manifest.Files[name] {
+			return nil, fmt.Errorf("%w: %s 的校驗和不符", ErrInvalidFairnessFile, name)
+		}
+	}
+
+	f := &FairnessFile{Verifier: manifest.Verifier, CreatedAt: manifest.CreatedAt}
+	for _, name := range []string{fairBundleName, fairMetadataName} {
+		if _, ok := manifest.Files[name]; !ok {
+			return nil, fmt.Errorf("%w: 缺少 %s", ErrInvalidFairnessFile, name)
+		}
+	}
+	if err := json.Unmarshal(contents[fairBundleName], &f.Bundle); err != nil {
+		return nil, fmt.Errorf("%w: 無法解析 %s: %w", ErrInvalidFairnessFile, fairBundleName, err)
+	}
+	if f.Bundle == nil {
+		return nil, fmt.Errorf("%w: %s 為空", ErrInvalidFairnessFile, fairBundleName)
+	}
+	if err := json.Unmarshal(contents[fairMetadataName], &f.Metadata); err != nil {
+		return nil, fmt.Errorf("%w: 無法解析 %s: %w", ErrInvalidFairnessFile, fairMetadataName, err)
+	}
+	return f, nil
+}
+
+// OpenFairnessFile 從路徑讀取公平性文件，見 ReadFairnessFile
+func OpenFairnessFile(path string) (*FairnessFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("無法讀取公平性文件: %w", err)
+	}
+	return ReadFairnessFile(bytes.NewReader(data), int64(len(data)))
+}
+
+// Verify 檢查文件記錄的驗證算法受支持，再用證明包的 Verify 驗證摘要和每一局的牌組
+// template 為 nil 時使用 Poker52
+func (f *FairnessFile) Verify(template *drandshuffle.DeckTemplate) error {
+	if f.Verifier != drandshuffle.ProofAlgorithm {
+		return fmt.Errorf("%w: 不支持的驗證算法 %q", ErrInvalidFairnessFile, f.Verifier)
+	}
+	if f.Bundle == nil {
+		return fmt.Errorf("%w: 缺少證明包", ErrInvalidFairnessFile)
+	}
+	return f.Bundle.Verify(template)
+}
+
+// readZipFile 讀取 zip 中的一個文件，超過 fairMaxFileSize 時返回錯誤
+func readZipFile(file *zip.File) ([]byte, error) {
+	rc, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: 無法打開 %s: %w", ErrInvalidFairnessFile, file.Name, err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, fairMaxFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: 無法讀取 %s: %w", ErrInvalidFairnessFile, file.Name, err)
+	}
+	if len(data) > fairMaxFileSize {
+		return nil, fmt.Errorf("%w: %s 超過 %d 字節", ErrInvalidFairnessFile, file.Name, fairMaxFileSize)
+	}
+	return data, nil
+}
@@ -0,0 +1,182 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ObjectStoreConfig 是 S3 兼容對象存儲的連接配置
+type ObjectStoreConfig struct {
+	Endpoint        string        // 服務地址，如 https://s3.us-east-1.amazonaws.com；為空時按 Region 使用 AWS S3
+	Region          string        // 簽名使用的區域，如 us-east-1
+	Bucket          string        // 存儲桶名稱
+	Prefix          string        // 對象名稱的前綴，如 "proofs/"
+	AccessKeyID     string        // 訪問密鑰 ID
+	SecretAccessKey string        // 訪問密鑰
+	RetainFor       time.Duration // 大於 0 時以合規模式的對象鎖定保留指定時長，存儲桶須啟用對象鎖定
+	HTTPClient      *http.Client  // 為 nil 時使用 http.DefaultClient
+}
+
+// ObjectStoreSink 以 AWS Signature Version 4 簽名的 PUT 請求將證明包上傳到 S3 兼容的對象存儲
+// 對象名稱為 Prefix 加證明包名稱加 .json，請求帶有 Content-MD5，存儲端會校驗內容的完整性
+type ObjectStoreSink struct {
+	config ObjectStoreConfig
+	now    func() time.Time
+}
+
+// 確保 ObjectStoreSink 可以作為 Sink 使用
+var _ Sink = (*ObjectStoreSink)(nil)
+
+// NewS3Sink 創建上傳到 Amazon S3 或其他 S3 兼容存儲（如 MinIO）的 Sink
+// 需要保存七年等長期記錄時，應在啟用對象鎖定的存儲桶上設定 RetainFor，保留期內對象不能被刪除或覆蓋
+func NewS3Sink(config ObjectStoreConfig) (*ObjectStoreSink, error) {
+	if config.Bucket == "" || config.Region == "" || config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, fmt.Errorf("對象存儲配置缺少存儲桶、區域或訪問密鑰")
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = "https://s3." + config.Region + ".amazonaws.com"
+	}
+	if _, err := url.Parse(config.Endpoint); err != nil {
+		return nil, fmt.Errorf("無效的對象存儲地址: %w", err)
+	}
+	return &ObjectStoreSink{config: config, now: time.Now}, nil
+}
+
+// NewGCSSink 創建通過 XML API 上傳到 Google Cloud Storage 的 Sink
+// 訪問密鑰使用 GCS 的 HMAC 密鑰；GCS 不支持按對象的鎖定請求頭，長期保存應在存儲桶上設定保留政策，
+// 因此 RetainFor 會被忽略。Endpoint 為空時使用 https://storage.googleapis.com，Region 為空時使用 auto
+func NewGCSSink(config ObjectStoreConfig) (*ObjectStoreSink, error) {
+	if config.Endpoint == "" {
+		config.Endpoint = "https://storage.googleapis.com"
+	}
+	if config.Region == "" {
+		config.Region = "auto"
+	}
+	config.RetainFor = 0
+	return NewS3Sink(config)
+}
+
+// Archive 將證明包以 JSON 上傳，存儲返回非 2xx 狀態時返回錯誤
+func (s *ObjectStoreSink) Archive(ctx context.Context, bundle *Bundle) error {
+	body, err := json.Marshal(bundle)
+	if err != nil {
+		return fmt.Errorf("無法編碼證明包: %w", err)
+	}
+
+	endpoint := strings.TrimSuffix(s.config.Endpoint, "/")
+	target := endpoint + "/" + escapeKey(s.config.Bucket) + "/" + escapeKey(s.config.Prefix+objectName(bundle))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("無法創建上傳請求: %w", err)
+	}
+	md5sum := md5.Sum(body)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-MD5", base64.StdEncoding.EncodeToString(md5sum[:]))
+	now := s.now().UTC()
+	if s.config.RetainFor > 0 {
+		req.Header.Set("X-Amz-Object-Lock-Mode", "COMPLIANCE")
+		req.Header.Set("X-Amz-Object-Lock-Retain-Until-Date", now.Add(s.config.RetainFor).Format(time.RFC3339))
+	}
+	s.sign(req, body, now)
+
+	client := s.config.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("無法上傳到 %s: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("上傳到 %s 失敗: %s: %s", endpoint, resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// sign 以 AWS Signature Version 4 為請求簽名，簽名覆蓋 Host 和所有已設定的請求頭
+func (s *ObjectStoreSink) sign(req *http.Request, body []byte, now time.Time) {
+	const algorithm = "AWS4-HMAC-SHA256"
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.config.Region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{algorithm, amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.config.SecretAccessKey), date)
+	for _, part := range []string{s.config.Region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		algorithm, s.config.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// escapeKey 按 Signature Version 4 的規則轉義對象名稱：除字母、數字、"-_.~" 和路徑分隔符外都以 %XX 轉義
+func escapeKey(key string) string {
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.IndexByte("-_.~/", c) >= 0 {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+// sha256Hex 返回數據的 SHA-256 摘要（十六進制）
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// hmacSHA256 返回 HMAC-SHA256
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,181 @@
+// Package audit 記錄每次發牌的審計記錄，並導出為 CSV 或 Parquet 文件，供數據倉庫和監管報告使用
+//
+// 審計記錄不保存牌組本身，只保存牌組和證明的摘要；需要核對時可以用輪次和遊戲局號重新洗牌，
+// 再比對 drandshuffle.DeckDigest 的結果。
+//
+// Pipeline 將每條記錄寫入本地存儲，同時在後台發送到遠端收集器，
+// 遊戲服務器被入侵時無法只靠刪改本地文件抹去自己的審計記錄。
+package audit
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+)
+
+// Record 是一次發牌的審計記錄
+type Record struct {
+	Round       uint64    // 使用的輪次號碼
+	SessionID   string    // 遊戲局號
+	DeckDigest  string    // 見 drandshuffle.DeckDigest
+	ProofDigest string    // 見 ShuffleProof.Digest，沒有證明時為空
+	RoundTime   time.Time // 該輪隨機信標的發布時間，未知時為零值
+	DealtAt     time.Time // 發牌時間
+	Note        string    // 審計說明，例如預定的輪次已過、牌局改綁到新輪次的經過；沒有時為空
+}
+
+// NewRecord 從 ShuffleBuilder.Do 的結果創建審計記錄，sessionID 應與洗牌時使用的遊戲局號相同
+func NewRecord(result *drandshuffle.ShuffleResult, sessionID string, dealtAt time.Time) Record {
+	record := Record{
+		Round:      result.Round,
+		SessionID:  sessionID,
+		DeckDigest: drandshuffle.DeckDigest(result.Deck),
+		RoundTime:  result.RoundTime,
+		DealtAt:    dealtAt,
+	}
+	if result.Proof != nil {
+		record.ProofDigest = result.Proof.Digest()
+	}
+	return record
+}
+
+// Log 是保存在記憶體中的審計記錄，按追加的順序保存，可以並發使用
+type Log struct {
+	mu      sync.Mutex
+	records []Record
+}
+
+// Append 追加一條審計記錄
+func (l *Log) Append(record Record) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.records = append(l.records, record)
+}
+
+// Records 返回所有審計記錄的副本
+func (l *Log) Records() []Record {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]Record(nil), l.records...)
+}
+
+// WriteCSV 將審計記錄導出為 CSV
+func (l *Log) WriteCSV(w io.Writer) error {
+	return WriteCSV(w, l.Records())
+}
+
+// WriteParquet 將審計記錄導出為 Parquet
+func (l *Log) WriteParquet(w io.Writer) error {
+	return WriteParquet(w, l.Records())
+}
+
+// csvHeader 是 CSV 導出的標題行，欄位名稱與 Parquet 的列名相同
+var csvHeader = []string{"round", "session_id", "deck_digest", "proof_digest", "round_time", "dealt_at", "note"}
+
+// WriteCSV 將審計記錄寫為帶標題行的 CSV
+// 時間以 UTC 的 RFC 3339 格式輸出，零值時間輸出為空字段
+func WriteCSV(w io.Writer, records []Record) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return fmt.Errorf("無法寫入 CSV: %w", err)
+	}
+	for _, r := range records {
+		row := []string{
+			strconv.FormatUint(r.Round, 10),
+			r.SessionID,
+			r.DeckDigest,
+			r.ProofDigest,
+			formatTime(r.RoundTime),
+			formatTime(r.DealtAt),
+			r.Note,
+		}
+		if err := cw.Write(row); err != nil {
+			return fmt.Errorf("無法寫入 CSV: %w", err)
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("無法寫入 CSV: %w", err)
+	}
+	return nil
+}
+
+// parquetRecord 是審計記錄在 Parquet 文件中的行格式，可選列以指針表示，nil 寫為 null
+type parquetRecord struct {
+	Round       uint64     `parquet:"round"`
+	SessionID   string     `parquet:"session_id"`
+	DeckDigest  string     `parquet:"deck_digest"`
+	ProofDigest *string    `parquet:"proof_digest,optional"`
+	RoundTime   *time.Time `parquet:"round_time,optional"`
+	DealtAt     time.Time  `parquet:"dealt_at"`
+	Note        *string    `parquet:"note,optional"`
+}
+
+// WriteParquet 將審計記錄寫為 Parquet 文件，列名與 CSV 的標題相同
+// 時間列為 UTC 的納秒時間戳，round_time、proof_digest 和 note 為可選列，零值寫為 null
+func WriteParquet(w io.Writer, records []Record) error {
+	rows := make([]parquetRecord, len(records))
+	for i, r := range records {
+		rows[i] = parquetRecord{
+			Round:      r.Round,
+			SessionID:  r.SessionID,
+			DeckDigest: r.DeckDigest,
+			DealtAt:    r.DealtAt,
+		}
+		if r.ProofDigest != "" {
+			rows[i].ProofDigest = &records[i].ProofDigest
+		}
+		if !r.RoundTime.IsZero() {
+			rows[i].RoundTime = &records[i].RoundTime
+		}
+		if r.Note != "" {
+			rows[i].Note = &records[i].Note
+		}
+	}
+	if err := parquet.Write(w, rows); err != nil {
+		return fmt.Errorf("無法寫入 Parquet: %w", err)
+	}
+	return nil
+}
+
+// ReadParquet 讀取 WriteParquet 寫出的審計記錄，時間以 UTC 返回
+func ReadParquet(r io.ReaderAt, size int64) ([]Record, error) {
+	rows, err := parquet.Read[parquetRecord](r, size)
+	if err != nil {
+		return nil, fmt.Errorf("無法讀取 Parquet: %w", err)
+	}
+	records := make([]Record, len(rows))
+	for i, row := range rows {
+		records[i] = Record{
+			Round:      row.Round,
+			SessionID:  row.SessionID,
+			DeckDigest: row.DeckDigest,
+			DealtAt:    row.DealtAt.UTC(),
+		}
+		if row.ProofDigest != nil {
+			records[i].ProofDigest = *row.ProofDigest
+		}
+		if row.RoundTime != nil {
+			records[i].RoundTime = row.RoundTime.UTC()
+		}
+		if row.Note != nil {
+			records[i].Note = *row.Note
+		}
+	}
+	return records, nil
+}
+
+// formatTime 以 UTC 的 RFC 3339 格式輸出時間，零值輸出為空字符串
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
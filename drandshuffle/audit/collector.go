@@ -0,0 +1,239 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+)
+
+// jsonRecord 是審計記錄的 JSON 格式，欄位名稱與 CSV 的標題相同，零值的可選欄位省略
+type jsonRecord struct {
+	Round       uint64     `json:"round"`
+	SessionID   string     `json:"session_id"`
+	DeckDigest  string     `json:"deck_digest"`
+	ProofDigest string     `json:"proof_digest,omitempty"`
+	RoundTime   *time.Time `json:"round_time,omitempty"`
+	DealtAt     time.Time  `json:"dealt_at"`
+	Note        string     `json:"note,omitempty"`
+}
+
+// MarshalJSON 以與 CSV 標題相同的欄位名稱編碼記錄，時間為 UTC 的 RFC 3339 格式
+func (r Record) MarshalJSON() ([]byte, error) {
+	out := jsonRecord{
+		Round:       r.Round,
+		SessionID:   r.SessionID,
+		DeckDigest:  r.DeckDigest,
+		ProofDigest: r.ProofDigest,
+		DealtAt:     r.DealtAt.UTC(),
+		Note:        r.Note,
+	}
+	if !r.RoundTime.IsZero() {
+		roundTime := r.RoundTime.UTC()
+		out.RoundTime = &roundTime
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON 解碼 MarshalJSON 的輸出
+func (r *Record) UnmarshalJSON(data []byte) error {
+	var in jsonRecord
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	*r = Record{
+		Round:       in.Round,
+		SessionID:   in.SessionID,
+		DeckDigest:  in.DeckDigest,
+		ProofDigest: in.ProofDigest,
+		DealtAt:     in.DealtAt.UTC(),
+		Note:        in.Note,
+	}
+	if in.RoundTime != nil {
+		r.RoundTime = in.RoundTime.UTC()
+	}
+	return nil
+}
+
+// FileStore 將審計記錄以 JSON Lines 追加寫入本地文件，每條記錄寫入後調用 fsync，可以並發使用
+// 文件會持續增長，可以用 Compact 或 drandshuffle.StartCompaction 按保留策略刪除舊的記錄
+type FileStore struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+// 確保 FileStore 可以作為 LocalStore 使用，並可以壓縮
+var (
+	_ LocalStore             = (*FileStore)(nil)
+	_ drandshuffle.Compactor = (*FileStore)(nil)
+)
+
+// OpenFileStore 以追加模式打開或創建審計記錄文件
+func OpenFileStore(path string) (*FileStore, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("無法打開審計記錄文件: %w", err)
+	}
+	return &FileStore{path: path, file: file}, nil
+}
+
+// Append 寫入一條記錄並同步到磁盤
+func (s *FileStore) Append(record Record) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("無法編碼審計記錄: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("無法寫入審計記錄文件: %w", err)
+	}
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("無法同步審計記錄文件: %w", err)
+	}
+	return nil
+}
+
+// Close 關閉文件
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// Compact 按保留策略重寫審計記錄文件，MaxAge 同時按記錄的 DealtAt 判斷，MaxEntries 和 MaxBytes 按寫入順序保留最後的記錄
+// 先寫入同一目錄下的臨時文件並 fsync，再以重命名替換原文件並重新打開，崩潰時原文件保持不變；壓縮期間的 Append 會等待壓縮完成
+func (s *FileStore) Compact(ctx context.Context, policy drandshuffle.RetentionPolicy, now time.Time) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.Open(s.path)
+	if err != nil {
+		return 0, fmt.Errorf("無法打開審計記錄文件: %w", err)
+	}
+	records, err := ReadJSONLines(f)
+	f.Close()
+	if err != nil || len(records) == 0 {
+		return 0, err
+	}
+
+	var latest uint64
+	for _, record := range records {
+		if record.Round > latest {
+			latest = record.Round
+		}
+	}
+	from := policy.KeepFrom(latest, now)
+	var lines [][]byte
+	var sizes []int64
+	for _, record := range records {
+		if record.Round < from || policy.Expired(record.DealtAt, now) {
+			continue
+		}
+		line, err := json.Marshal(record)
+		if err != nil {
+			return 0, fmt.Errorf("無法編碼審計記錄: %w", err)
+		}
+		lines = append(lines, append(line, '\n'))
+		sizes = append(sizes, int64(len(line)+1))
+	}
+	lines = lines[len(lines)-policy.KeepLast(sizes):]
+
+	removed := len(records) - len(lines)
+	if removed == 0 {
+		return 0, nil
+	}
+	if err := s.replace(bytes.Join(lines, nil)); err != nil {
+		return 0, fmt.Errorf("無法重寫審計記錄文件: %w", err)
+	}
+	return removed, nil
+}
+
+// replace 以 drandshuffle.ReplaceFile 替換記錄文件的內容，並重新打開追加寫入的文件；調用者持有 s.mu
+func (s *FileStore) replace(data []byte) error {
+	if err := drandshuffle.ReplaceFile(s.path, data); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	s.file.Close()
+	s.file = file
+	return nil
+}
+
+// ReadJSONLines 讀取 FileStore 寫出的 JSON Lines 審計記錄，空行會被忽略
+// 可用於把本地記錄與收集器保存的副本逐條核對，或在 PipelineStats.Dropped 不為 0 時補發
+func ReadJSONLines(r io.Reader) ([]Record, error) {
+	var records []Record
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		var record Record
+		if err := json.Unmarshal(text, &record); err != nil {
+			return nil, fmt.Errorf("第 %d 行不是有效的審計記錄: %w", line, err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("無法讀取審計記錄: %w", err)
+	}
+	return records, nil
+}
+
+// HTTPCollector 以 POST 請求將一批審計記錄以 JSON 數組發送到收集器，收集器返回非 2xx 狀態時視為失敗
+type HTTPCollector struct {
+	URL        string       // 收集器的地址
+	Header     http.Header  // 每個請求附加的請求頭，例如 Authorization
+	HTTPClient *http.Client // 為 nil 時使用 http.DefaultClient
+}
+
+// 確保 HTTPCollector 可以作為 Collector 使用
+var _ Collector = (*HTTPCollector)(nil)
+
+// Send 發送一批記錄
+func (c *HTTPCollector) Send(ctx context.Context, records []Record) error {
+	body, err := json.Marshal(records)
+	if err != nil {
+		return fmt.Errorf("無法編碼審計記錄: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("無法創建發送請求: %w", err)
+	}
+	for name, values := range c.Header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("無法發送到 %s: %w", c.URL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("發送到 %s 失敗: %s: %s", c.URL, resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
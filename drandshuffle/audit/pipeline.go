@@ -0,0 +1,261 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+)
+
+const (
+	// DefaultBatchSize 是 Pipeline 每次發送到收集器的記錄數量上限
+	DefaultBatchSize = 100
+	// DefaultBacklog 是 Pipeline 暫存尚未發送的記錄數量上限
+	DefaultBacklog = 10000
+	// DefaultRetryInterval 是發送失敗後第一次重試的等待時間，之後每次加倍
+	DefaultRetryInterval = time.Second
+	// maxRetryInterval 是重試等待時間的上限
+	maxRetryInterval = time.Minute
+)
+
+// LocalStore 是審計記錄的本地存儲，Append 返回時記錄應已持久化
+type LocalStore interface {
+	Append(record Record) error
+}
+
+// Collector 是接收審計記錄副本的遠端收集器
+// 發送失敗後同一批記錄會重試，響應丟失時收集器可能收到重複的記錄，應按輪次和遊戲局號去重
+type Collector interface {
+	Send(ctx context.Context, records []Record) error
+}
+
+// PipelineStats 是 Pipeline 的發送狀態快照，用於監控
+// Dropped 不為 0 表示遠端缺少部分記錄，應以本地存儲補發
+type PipelineStats struct {
+	Recorded  uint64    // 寫入本地存儲的記錄數
+	Shipped   uint64    // 已發送到收集器的記錄數
+	Pending   int       // 暫存等待發送的記錄數
+	Dropped   uint64    // 暫存已滿時丟棄的最舊記錄數
+	Failures  uint64    // 發送失敗的次數
+	LastError error     // 最近一次發送失敗的錯誤，之後成功時清除
+	LastSent  time.Time // 最近一次成功發送的時間
+}
+
+// pipelineOptions 是 Pipeline 的參數
+type pipelineOptions struct {
+	batchSize     int
+	backlog       int
+	retryInterval time.Duration
+}
+
+// PipelineOption 設定 Pipeline 的參數
+type PipelineOption func(*pipelineOptions)
+
+// WithBatchSize 設定每次發送的記錄數量上限，默認為 DefaultBatchSize
+func WithBatchSize(n int) PipelineOption {
+	return func(o *pipelineOptions) {
+		o.batchSize = n
+	}
+}
+
+// WithBacklog 設定暫存尚未發送的記錄數量上限，默認為 DefaultBacklog
+// 收集器長時間不可用、暫存已滿時丟棄最舊的記錄並計入 PipelineStats.Dropped，發牌不會因此阻塞
+func WithBacklog(n int) PipelineOption {
+	return func(o *pipelineOptions) {
+		o.backlog = n
+	}
+}
+
+// WithRetryInterval 設定發送失敗後第一次重試的等待時間，默認為 DefaultRetryInterval；之後每次加倍，最長一分鐘
+func WithRetryInterval(d time.Duration) PipelineOption {
+	return func(o *pipelineOptions) {
+		o.retryInterval = d
+	}
+}
+
+// Pipeline 將每條審計記錄同步寫入本地存儲，再在後台異步發送到遠端收集器
+// 遊戲服務器被入侵後即使刪改本地的審計記錄，已發送到收集器的副本也不受影響；
+// 收集器不可用時記錄在記憶體中暫存並按指數退避重試。可以並發使用，使用完畢後必須調用 Close
+type Pipeline struct {
+	local  LocalStore
+	remote Collector
+	opts   pipelineOptions
+
+	mu      sync.Mutex
+	backlog []Record
+	stats   PipelineStats
+	closed  bool
+
+	kick      chan struct{}
+	done      chan struct{}
+	exited    chan struct{}
+	closeOnce sync.Once
+}
+
+// NewPipeline 創建寫入 local 並發送到 remote 的 Pipeline，並啟動後台發送
+func NewPipeline(local LocalStore, remote Collector, opts ...PipelineOption) *Pipeline {
+	o := pipelineOptions{batchSize: DefaultBatchSize, backlog: DefaultBacklog, retryInterval: DefaultRetryInterval}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	o.batchSize = max(1, o.batchSize)
+	o.backlog = max(o.batchSize, o.backlog)
+	if o.retryInterval <= 0 {
+		o.retryInterval = DefaultRetryInterval
+	}
+
+	p := &Pipeline{
+		local:  local,
+		remote: remote,
+		opts:   o,
+		kick:   make(chan struct{}, 1),
+		done:   make(chan struct{}),
+		exited: make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+// Record 將記錄寫入本地存儲並加入發送暫存，不等待發送完成
+// 本地寫入失敗時返回錯誤，記錄不會發送；調用者應視為審計失敗處理，例如拒絕繼續發牌
+func (p *Pipeline) Record(record Record) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return fmt.Errorf("%w: 審計管道已關閉", drandshuffle.ErrClosed)
+	}
+	// 持有鎖寫入本地，使本地和遠端的記錄順序一致
+	if err := p.local.Append(record); err != nil {
+		return fmt.Errorf("無法寫入本地審計記錄: %w", err)
+	}
+	p.stats.Recorded++
+	p.backlog = append(p.backlog, record)
+	if over := len(p.backlog) - p.opts.backlog; over > 0 {
+		p.backlog = append(p.backlog[:0], p.backlog[over:]...)
+		p.stats.Dropped += uint64(over)
+	}
+
+	select {
+	case p.kick <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Stats 返回發送狀態的快照
+func (p *Pipeline) Stats() PipelineStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	stats := p.stats
+	stats.Pending = len(p.backlog)
+	return stats
+}
+
+// run 在後台發送暫存的記錄，失敗時按指數退避重試，直到 Close
+func (p *Pipeline) run() {
+	defer close(p.exited)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-p.done
+		cancel()
+	}()
+
+	retry := p.opts.retryInterval
+	for {
+		select {
+		case <-p.kick:
+		case <-p.done:
+			return
+		}
+		for {
+			more, err := p.shipBatch(ctx)
+			if err == nil {
+				retry = p.opts.retryInterval
+				if !more {
+					break
+				}
+				continue
+			}
+			select {
+			case <-time.After(retry):
+			case <-p.done:
+				return
+			}
+			retry = min(2*retry, maxRetryInterval)
+		}
+	}
+}
+
+// shipBatch 發送暫存最前面的一批記錄，成功後才從暫存中移除；暫存為空時返回 false
+func (p *Pipeline) shipBatch(ctx context.Context) (bool, error) {
+	p.mu.Lock()
+	n := min(len(p.backlog), p.opts.batchSize)
+	if n == 0 {
+		p.mu.Unlock()
+		return false, nil
+	}
+	batch := append([]Record(nil), p.backlog[:n]...)
+	dropped := p.stats.Dropped
+	p.mu.Unlock()
+
+	// 收集器的 panic 視為發送失敗，這批記錄留在暫存中重試，不影響發牌和其他記錄
+	var err error
+	if perr := drandshuffle.CatchPanic("audit_collector", func() { err = p.remote.Send(ctx, batch) }); perr != nil {
+		err = perr
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err != nil {
+		if ctx.Err() == nil {
+			p.stats.Failures++
+			p.stats.LastError = err
+		}
+		return false, err
+	}
+	// 發送期間暫存已滿時會從最前面丟棄記錄，這些記錄屬於已發送的一批，不再重複移除
+	if removed := p.stats.Dropped - dropped; removed < uint64(n) {
+		p.backlog = p.backlog[uint64(n)-removed:]
+	}
+	p.stats.Shipped += uint64(n)
+	p.stats.LastError = nil
+	p.stats.LastSent = time.Now()
+	return true, nil
+}
+
+// Flush 等待暫存的記錄全部發送，或 ctx 結束時返回其錯誤
+func (p *Pipeline) Flush(ctx context.Context) error {
+	for {
+		p.mu.Lock()
+		pending := len(p.backlog)
+		p.mu.Unlock()
+		if pending == 0 {
+			return nil
+		}
+		select {
+		case p.kick <- struct{}{}:
+		default:
+		}
+		select {
+		case <-time.After(10 * time.Millisecond):
+		case <-ctx.Done():
+			return fmt.Errorf("仍有 %d 條審計記錄未發送: %w", pending, ctx.Err())
+		}
+	}
+}
+
+// Close 在 ctx 結束前盡量發送暫存的記錄，然後停止後台發送，之後的 Record 返回錯誤
+// 未能發送的記錄仍保存在本地存儲中，返回的錯誤說明剩餘的數量
+func (p *Pipeline) Close(ctx context.Context) error {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+
+	err := p.Flush(ctx)
+	p.closeOnce.Do(func() { close(p.done) })
+	<-p.exited
+	return err
+}
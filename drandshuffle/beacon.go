@@ -0,0 +1,81 @@
+package drandshuffle
+
+import (
+	"sort"
+
+	"github.com/drand/go-clients/drand"
+)
+
+// Beacon 是一輪 drand 隨機信標的獨立副本，不與緩存共享記憶體
+type Beacon struct {
+	Round             uint64 // 輪次號碼
+	Randomness        []byte // 隨機性
+	Signature         []byte // 簽名
+	PreviousSignature []byte // 上一輪的簽名（非鏈式網絡為空）
+}
+
+// newBeacon 從 drand 結果複製出獨立的隨機信標
+func newBeacon(result drand.Result) Beacon {
+	return Beacon{
+		Round:             result.GetRound(),
+		Randomness:        copyBytes(result.GetRandomness()),
+		Signature:         copyBytes(result.GetSignature()),
+		PreviousSignature: copyBytes(result.GetPreviousSignature()),
+	}
+}
+
+// GetRound 返回輪次號碼，使 Beacon 滿足 drand.Result 接口
+func (b Beacon) GetRound() uint64 { return b.Round }
+
+// GetRandomness 返回隨機性
+func (b Beacon) GetRandomness() []byte { return b.Randomness }
+
+// GetSignature 返回簽名
+func (b Beacon) GetSignature() []byte { return b.Signature }
+
+// GetPreviousSignature 返回上一輪的簽名
+func (b Beacon) GetPreviousSignature() []byte { return b.PreviousSignature }
+
+// Snapshot 是某一時刻最新信標和緩存內容的不可變視圖
+// 其中的所有切片都是副本，之後緩存的變化不會影響快照
+type Snapshot struct {
+	Latest Beacon   // 最新的隨機信標，尚未獲取時輪次為 0
+	Recent []Beacon // 緩存中最近的隨機信標，按輪次從新到舊排列
+}
+
+// Snapshot 在一次調用中取得最新信標和緩存中最近 n 輪的隨機信標
+// 只獲取一次讀鎖，適用於需要大量近期信標的批量任務；n 小於等於 0 時返回全部緩存
+func (dm *DrandManager) Snapshot(n int) Snapshot {
+	var snap Snapshot
+	if latest := dm.latestBeacon.Load(); latest != nil {
+		snap.Latest = newBeacon(latest.result)
+	}
+
+	dm.mutex.RLock()
+	results := dm.beaconCache.all()
+	dm.mutex.RUnlock()
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].GetRound() > results[j].GetRound()
+	})
+	if n > 0 && len(results) > n {
+		results = results[:n]
+	}
+
+	snap.Recent = make([]Beacon, len(results))
+	for i, result := range results {
+		snap.Recent[i] = newBeacon(result)
+	}
+	return snap
+}
+
+// Round 在快照中查找指定輪次的隨機信標
+func (s Snapshot) Round(round uint64) (Beacon, bool) {
+	i := sort.Search(len(s.Recent), func(i int) bool {
+		return s.Recent[i].Round <= round
+	})
+	if i < len(s.Recent) && s.Recent[i].Round == round {
+		return s.Recent[i], true
+	}
+	return Beacon{}, false
+}
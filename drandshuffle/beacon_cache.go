@@ -0,0 +1,76 @@
+package drandshuffle
+
+import (
+	"github.com/drand/go-clients/drand"
+)
+
+// beaconCache 是以輪次號碼為鍵的固定容量緩存
+// 使用環形緩衝區按插入順序淘汰最舊的項目，插入和淘汰均為常數時間且不分配記憶體
+// 讀取不修改內部狀態，可以在讀鎖下並發調用；寫入需要由調用者加寫鎖
+type beaconCache struct {
+	slots []cacheSlot
+	index map[uint64]int // 輪次號碼 -> 槽位索引
+	next  int            // 下一個寫入的槽位
+}
+
+// cacheSlot 表示環形緩衝區中的一個槽位
+type cacheSlot struct {
+	round  uint64
+	result drand.Result
+}
+
+// newBeaconCache 創建指定容量的緩存
+func newBeaconCache(capacity int) *beaconCache {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &beaconCache{
+		slots: make([]cacheSlot, capacity),
+		index: make(map[uint64]int, capacity),
+	}
+}
+
+// get 獲取指定輪次的隨機信標
+func (c *beaconCache) get(round uint64) (drand.Result, bool) {
+	i, ok := c.index[round]
+	if !ok {
+		return nil, false
+	}
+	return c.slots[i].result, true
+}
+
+// put 加入隨機信標，緩存已滿時覆蓋最早寫入的項目
+func (c *beaconCache) put(result drand.Result) {
+	round := result.GetRound()
+
+	// 已存在的輪次直接更新
+	if i, ok := c.index[round]; ok {
+		c.slots[i].result = result
+		return
+	}
+
+	// 淘汰槽位中的舊項目
+	slot := &c.slots[c.next]
+	if slot.result != nil {
+		delete(c.index, slot.round)
+	}
+
+	slot.round = round
+	slot.result = result
+	c.index[round] = c.next
+	c.next = (c.next + 1) % len(c.slots)
+}
+
+// all 返回緩存中所有的隨機信標，順序不確定
+func (c *beaconCache) all() []drand.Result {
+	results := make([]drand.Result, 0, len(c.index))
+	for _, i := range c.index {
+		results = append(results, c.slots[i].result)
+	}
+	return results
+}
+
+// len 返回緩存中的項目數量
+func (c *beaconCache) len() int {
+	return len(c.index)
+}
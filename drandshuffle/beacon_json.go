@@ -0,0 +1,94 @@
+package drandshuffle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ParseBeaconJSON 解析 drand 中繼 /<鏈哈希>/public/<輪次> 返回的 JSON 格式隨機信標
+// 輸入可以是單個對象、對象的數組，或每行一個對象（例如 curl 逐輪保存的結果和 FileBeaconStore 的文件）；
+// 字節欄位以十六進制編碼，缺少 randomness 時以簽名的 SHA-256 補上。解析不驗證簽名，
+// 導入前應用 Verifier 驗證；格式錯誤時返回包裝 ErrInvalidBeacon 的輸入錯誤
+func ParseBeaconJSON(data []byte) ([]Beacon, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var records []beaconRecord
+		if err := json.Unmarshal(trimmed, &records); err != nil {
+			return nil, NewInputError(fmt.Errorf("%w: 無法解析 JSON: %w", ErrInvalidBeacon, err))
+		}
+		beacons := make([]Beacon, len(records))
+		for i, record := range records {
+			beacon, err := record.beacon()
+			if err != nil {
+				return nil, NewInputError(fmt.Errorf("%w: 第 %d 個隨機信標: %w", ErrInvalidBeacon, i+1, err))
+			}
+			beacons[i] = beacon
+		}
+		return beacons, nil
+	}
+
+	var beacons []Beacon
+	dec := json.NewDecoder(bytes.NewReader(trimmed))
+	for {
+		var record beaconRecord
+		err := dec.Decode(&record)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, NewInputError(fmt.Errorf("%w: 無法解析 JSON: %w", ErrInvalidBeacon, err))
+		}
+		beacon, err := record.beacon()
+		if err != nil {
+			return nil, NewInputError(fmt.Errorf("%w: 第 %d 個隨機信標: %w", ErrInvalidBeacon, len(beacons)+1, err))
+		}
+		beacons = append(beacons, beacon)
+	}
+	if len(beacons) == 0 {
+		return nil, NewInputError(fmt.Errorf("%w: 沒有隨機信標", ErrInvalidBeacon))
+	}
+	return beacons, nil
+}
+
+// EncodeBeaconJSON 將隨機信標編碼為與 drand 中繼響應相同的 JSON 對象，不含換行
+// 非鏈式網絡的信標沒有 previous_signature 欄位
+func EncodeBeaconJSON(beacon Beacon) ([]byte, error) {
+	line, err := encodeBeaconRecord(beacon)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(line, []byte("\n")), nil
+}
+
+// beacon 將記錄轉換為隨機信標，缺少隨機性時以簽名的 SHA-256 計算
+func (r beaconRecord) beacon() (Beacon, error) {
+	var beacon Beacon
+	var err error
+	beacon.Round = r.Round
+	if beacon.Randomness, err = hex.DecodeString(r.Randomness); err != nil {
+		return Beacon{}, fmt.Errorf("無效的 randomness: %w", err)
+	}
+	if beacon.Signature, err = hex.DecodeString(r.Signature); err != nil {
+		return Beacon{}, fmt.Errorf("無效的 signature: %w", err)
+	}
+	if beacon.PreviousSignature, err = hex.DecodeString(r.PreviousSignature); err != nil {
+		return Beacon{}, fmt.Errorf("無效的 previous_signature: %w", err)
+	}
+	if len(beacon.PreviousSignature) == 0 {
+		// 非鏈式網絡沒有上一輪的簽名，與從中繼獲取的信標一致地使用 nil
+		beacon.PreviousSignature = nil
+	}
+	if len(beacon.Randomness) == 0 && len(beacon.Signature) > 0 {
+		sum := sha256.Sum256(beacon.Signature)
+		beacon.Randomness = sum[:]
+	}
+	if beacon.Round == 0 || len(beacon.Randomness) == 0 {
+		return Beacon{}, fmt.Errorf("記錄缺少輪次或隨機性")
+	}
+	return beacon, nil
+}
@@ -0,0 +1,152 @@
+package drandshuffle
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// BeaconStore 持久化保存已獲取的隨機信標
+// 服務重啟時可以先從保存的信標開始服務，不必等待網絡
+// 保存的信標在獲取時已經過驗證，讀取時不會重新驗證
+type BeaconStore interface {
+	// Load 讀取所有已保存的隨機信標
+	Load() ([]Beacon, error)
+	// Save 保存一個隨機信標
+	Save(beacon Beacon) error
+}
+
+// BatchBeaconStore 是支持一次保存多個隨機信標的存儲
+// WriteBehindStore 會優先使用 SaveBatch，將一批信標合併為一次寫入
+type BatchBeaconStore interface {
+	BeaconStore
+	// SaveBatch 按順序保存多個隨機信標
+	SaveBatch(beacons []Beacon) error
+}
+
+// FileBeaconStore 將隨機信標以每行一條 JSON 記錄的格式追加寫入本地文件
+// 文件是只追加的日誌：每次寫入後都會 fsync，崩潰時最多留下一行不完整的記錄，載入時會被忽略。
+// 文件會隨輪次無限增長，可以用 Compact 或 WithRetention 按保留策略刪除舊的記錄
+type FileBeaconStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// beaconRecord 是隨機信標在文件中的記錄格式，與 drand 中繼響應的 JSON 相同，字節以十六進制編碼
+type beaconRecord struct {
+	Round             uint64 `json:"round"`
+	Randomness        string `json:"randomness"`
+	Signature         string `json:"signature"`
+	PreviousSignature string `json:"previous_signature,omitempty"`
+}
+
+// NewFileBeaconStore 創建使用指定文件的 FileBeaconStore，文件不存在時會在首次保存時創建
+func NewFileBeaconStore(path string) *FileBeaconStore {
+	return &FileBeaconStore{path: path}
+}
+
+// Load 讀取文件中的所有隨機信標，文件不存在時返回空結果
+// 最後一行不完整時（例如寫入過程中崩潰）會被忽略
+func (s *FileBeaconStore) Load() ([]Beacon, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
+
+// load 讀取文件中的所有隨機信標，調用者持有 s.mu
+func (s *FileBeaconStore) load() ([]Beacon, error) {
+	f, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("無法打開信標文件: %w", err)
+	}
+	defer f.Close()
+
+	var beacons []Beacon
+	var pending error
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if pending != nil {
+			// 損壞的記錄不在最後一行，不是寫入中斷造成的
+			return nil, pending
+		}
+		beacon, err := decodeBeaconRecord(scanner.Bytes())
+		if err != nil {
+			pending = fmt.Errorf("無法解析信標文件第 %d 行: %w", line, err)
+			continue
+		}
+		beacons = append(beacons, beacon)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("無法讀取信標文件: %w", err)
+	}
+
+	return beacons, nil
+}
+
+// Save 將隨機信標追加寫入文件
+func (s *FileBeaconStore) Save(beacon Beacon) error {
+	return s.SaveBatch([]Beacon{beacon})
+}
+
+// SaveBatch 將多個隨機信標以一次寫入和一次 fsync 追加到文件
+func (s *FileBeaconStore) SaveBatch(beacons []Beacon) error {
+	if len(beacons) == 0 {
+		return nil
+	}
+
+	var buf []byte
+	for _, beacon := range beacons {
+		line, err := encodeBeaconRecord(beacon)
+		if err != nil {
+			return err
+		}
+		buf = append(buf, line...)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return fmt.Errorf("無法打開信標文件: %w", err)
+	}
+	if _, err := f.Write(buf); err != nil {
+		f.Close()
+		return fmt.Errorf("無法寫入信標文件: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("無法同步信標文件: %w", err)
+	}
+	return f.Close()
+}
+
+// encodeBeaconRecord 將隨機信標編碼為一行 JSON 記錄
+func encodeBeaconRecord(beacon Beacon) ([]byte, error) {
+	line, err := json.Marshal(beaconRecord{
+		Round:             beacon.Round,
+		Randomness:        hex.EncodeToString(beacon.Randomness),
+		Signature:         hex.EncodeToString(beacon.Signature),
+		PreviousSignature: hex.EncodeToString(beacon.PreviousSignature),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("無法編碼隨機信標: %w", err)
+	}
+	return append(line, '\n'), nil
+}
+
+// decodeBeaconRecord 解碼一行 JSON 記錄
+func decodeBeaconRecord(line []byte) (Beacon, error) {
+	var record beaconRecord
+	if err := json.Unmarshal(line, &record); err != nil {
+		return Beacon{}, err
+	}
+	return record.beacon()
+}
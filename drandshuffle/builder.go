@@ -0,0 +1,276 @@
+package drandshuffle
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Latest 作為 ShuffleBuilder.Round 的參數時，使用最新的隨機信標
+const Latest uint64 = 0
+
+// Poker52 是標準52張撲克牌的模板，與 StandardDeckTemplate 相同
+var Poker52 = StandardDeckTemplate
+
+// ProofAlgorithm 是 ShuffleProof 記錄的洗牌算法標識，算法改變時會使用新的標識
+const ProofAlgorithm = "drandshuffle/v1"
+
+// contributionDomain 是混合參與方貢獻時使用的域分隔前綴
+const contributionDomain = "drandshuffle/contributions/v1"
+
+// ShuffleBuilder 以鏈式調用組合洗牌所需的參數，最後調用 Do 執行
+// 未設定的參數使用默認值：標準52張撲克牌、最新輪次、沒有貢獻、不生成證明
+// ShuffleBuilder 不是並發安全的，每次洗牌應使用新的 builder
+type ShuffleBuilder struct {
+	client        *Client
+	template      *DeckTemplate
+	sessionID     string
+	round         uint64
+	contributions [][]byte
+	withProof     bool
+	previous      string
+}
+
+// ShuffleResult 是 ShuffleBuilder.Do 的結果
+type ShuffleResult struct {
+	Deck      []Card        // 洗好的牌組
+	Round     uint64        // 使用的輪次號碼
+	RoundTime time.Time     // 該輪隨機信標的發布時間（UTC），尚未取得鏈信息時為零值，見 DrandManager.RoundTime
+	Proof     *ShuffleProof // 重現和驗證洗牌所需的資料，未調用 WithProof 時為 nil
+}
+
+// ShuffleProof 記錄重現一次洗牌所需的全部輸入，可以交給玩家或審計方獨立驗證
+// RoundTime 只用於展示，由鏈的創世時間和週期推算，不參與洗牌，也不由 VerifyProof 檢查
+// 隨機信標的簽名可以用 Verifier 以鏈的公鑰驗證，牌組可以用 VerifyProof 重新計算比對
+type ShuffleProof struct {
+	Algorithm         string    `json:"algorithm"`
+	ChainHash         string    `json:"chain_hash"`
+	Round             uint64    `json:"round"`
+	RoundTime         time.Time `json:"round_time"`
+	Randomness        []byte    `json:"randomness"`
+	Signature         []byte    `json:"signature,omitempty"`
+	PreviousSignature []byte    `json:"previous_signature,omitempty"`
+	SessionID         string    `json:"session_id"`
+	Contributions     [][]byte  `json:"contributions,omitempty"`
+	DeckSize          int       `json:"deck_size"`
+	Provider          string    `json:"provider,omitempty"`        // 隨機性來源的標識，為空時表示 drand，見 BeaconProvider
+	PreviousDigest    string    `json:"previous_digest,omitempty"` // 同一牌桌上一份證明的 Digest，未串連時為空，見 ShuffleBuilder.ChainAfter
+}
+
+// NewShuffle 創建使用默認 Client（單例 DrandManager）的 ShuffleBuilder
+func NewShuffle() *ShuffleBuilder {
+	return &ShuffleBuilder{template: Poker52}
+}
+
+// NewShuffle 創建使用此 Client 的 ShuffleBuilder
+func (c *Client) NewShuffle() *ShuffleBuilder {
+	return &ShuffleBuilder{client: c, template: Poker52}
+}
+
+// Deck 設定使用的牌組模板，傳入 nil 時使用 Poker52
+func (b *ShuffleBuilder) Deck(template *DeckTemplate) *ShuffleBuilder {
+	if template == nil {
+		template = Poker52
+	}
+	b.template = template
+	return b
+}
+
+// Session 設定遊戲局號，須通過 ValidateSessionID 的檢查
+func (b *ShuffleBuilder) Session(gameSessionID string) *ShuffleBuilder {
+	b.sessionID = gameSessionID
+	return b
+}
+
+// Round 設定使用的輪次號碼，傳入 Latest 時使用最新的隨機信標；嚴格輪次模式下不允許 Latest
+func (b *ShuffleBuilder) Round(round uint64) *ShuffleBuilder {
+	b.round = round
+	return b
+}
+
+// WithContributions 加入參與方提供的額外隨機性，例如玩家在輪次發布前承諾的種子
+// 貢獻按加入的順序與隨機信標混合，順序不同結果也不同；沒有貢獻時結果與 Shuffler.Shuffle 相同
+func (b *ShuffleBuilder) WithContributions(contributions ...[]byte) *ShuffleBuilder {
+	for _, c := range contributions {
+		b.contributions = append(b.contributions, copyBytes(c))
+	}
+	return b
+}
+
+// WithProof 讓 Do 在結果中附帶 ShuffleProof
+func (b *ShuffleBuilder) WithProof() *ShuffleBuilder {
+	b.withProof = true
+	return b
+}
+
+// ChainAfter 將證明串連到同一牌桌的上一份證明之後：previousDigest 為上一局證明的 Digest，記錄在 ShuffleProof.PreviousDigest 中，
+// 每局證明都包含上一局的摘要，任何一局被刪除、替換或修改都會使之後的鏈接不一致，審計方可以用 VerifyProofChain 逐局檢查。
+// 牌桌的第一局傳入空字符串；設定後 Do 總是生成證明。previousDigest 必須是 64 個小寫十六進制字符，否則 Do 返回包裝 ErrInvalidConfig 的錯誤
+func (b *ShuffleBuilder) ChainAfter(previousDigest string) *ShuffleBuilder {
+	b.previous = previousDigest
+	b.withProof = true
+	return b
+}
+
+// Do 獲取隨機信標並執行洗牌
+// 使用最新輪次時取自緩存，不發出網絡請求；指定輪次不在緩存中時從網絡獲取，ctx 取消時不再等待
+func (b *ShuffleBuilder) Do(ctx context.Context) (*ShuffleResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := ValidateSessionID(b.sessionID); err != nil {
+		return nil, err
+	}
+	if b.previous != "" && !isDigest(b.previous) {
+		return nil, NewInputError(fmt.Errorf("%w: 上一份證明的摘要 %q 不是 64 個小寫十六進制字符", ErrInvalidConfig, b.previous))
+	}
+
+	client := b.client
+	if client == nil {
+		var err error
+		if client, err = DefaultClient(); err != nil {
+			return nil, err
+		}
+	}
+	dm := client.manager
+
+	beacon, err := b.beacon(ctx, dm)
+	if err != nil {
+		return nil, err
+	}
+	if err := dm.chargeUsage(ctx, beacon.Round); err != nil {
+		return nil, err
+	}
+
+	randomness := dm.mixContributions(ctx, beacon.Randomness, b.contributions)
+	defer Zeroize(randomness)
+
+	result := &ShuffleResult{
+		Deck:  dm.shuffle(ctx, b.shuffler(), beacon.Round, randomness, b.sessionID),
+		Round: beacon.Round,
+	}
+	result.RoundTime, _ = dm.RoundTime(beacon.Round)
+	if b.round == Latest {
+		dm.observeDealLatency(beacon.Round)
+	}
+
+	if b.withProof {
+		result.Proof = &ShuffleProof{
+			Algorithm:         ProofAlgorithm,
+			ChainHash:         dm.config.ChainHash,
+			Round:             beacon.Round,
+			RoundTime:         result.RoundTime,
+			Randomness:        beacon.Randomness,
+			Signature:         beacon.Signature,
+			PreviousSignature: beacon.PreviousSignature,
+			SessionID:         b.sessionID,
+			Contributions:     b.contributions,
+			DeckSize:          b.template.Len(),
+			Provider:          dm.provider,
+			PreviousDigest:    b.previous,
+		}
+	}
+	return result, nil
+}
+
+// beacon 返回 builder 指定輪次的隨機信標副本
+func (b *ShuffleBuilder) beacon(ctx context.Context, dm *DrandManager) (Beacon, error) {
+	if b.round == Latest {
+		if err := dm.checkLatestAllowed(); err != nil {
+			return Beacon{}, err
+		}
+		latest := dm.latestBeacon.Load()
+		if latest == nil {
+			return Beacon{}, networkError(fmt.Errorf("%w: 尚未獲取任何隨機信標", ErrBeaconUnavailable))
+		}
+		return newBeacon(latest.result), nil
+	}
+
+	result, err := dm.beaconByRound(ctx, b.round)
+	if err != nil {
+		return Beacon{}, fmt.Errorf("無法獲取輪次 %d 的隨機性: %w", b.round, err)
+	}
+	return newBeacon(result), nil
+}
+
+// shuffler 返回 builder 的牌組模板對應的洗牌器，標準牌組重複使用默認洗牌器
+func (b *ShuffleBuilder) shuffler() *Shuffler {
+	if b.template == StandardDeckTemplate {
+		return defaultShuffler
+	}
+	return NewShuffler(b.template)
+}
+
+// mixContributions 混合參與方的貢獻，有貢獻時記錄 span
+func (dm *DrandManager) mixContributions(ctx context.Context, randomness []byte, contributions [][]byte) []byte {
+	if len(contributions) == 0 {
+		return mixContributions(randomness, contributions)
+	}
+	_, span := dm.startSpan(ctx, spanMixContributions)
+	defer span.End()
+	return mixContributions(randomness, contributions)
+}
+
+// mixContributions 將參與方的貢獻與隨機信標混合，返回新分配的隨機性
+// 沒有貢獻時原樣複製；否則為 SHA256(域前綴 || randomness || 每個貢獻的 4 字節長度 || 貢獻)
+func mixContributions(randomness []byte, contributions [][]byte) []byte {
+	if len(contributions) == 0 {
+		return copyBytes(randomness)
+	}
+
+	h := sha256.New()
+	h.Write([]byte(contributionDomain))
+	h.Write(randomness)
+	var length [4]byte
+	for _, c := range contributions {
+		binary.BigEndian.PutUint32(length[:], uint32(len(c)))
+		h.Write(length[:])
+		h.Write(c)
+	}
+	return h.Sum(nil)
+}
+
+// VerifyProof 根據證明中的隨機性、遊戲局號和貢獻重新洗牌，檢查 deck 是否與之一致
+// template 為 nil 時使用 Poker52；只檢查牌組，隨機信標本身的簽名應另外用 Verifier 驗證
+func VerifyProof(proof *ShuffleProof, template *DeckTemplate, deck []Card) error {
+	if proof == nil {
+		return NewInputError(fmt.Errorf("缺少洗牌證明"))
+	}
+	if proof.Algorithm != ProofAlgorithm {
+		return NewInputError(fmt.Errorf("不支持的洗牌算法 %q", proof.Algorithm))
+	}
+	if template == nil {
+		template = Poker52
+	}
+	if template.Len() != proof.DeckSize {
+		return NewVerificationError(fmt.Errorf("%w: 牌組模板有 %d 張牌，證明記錄為 %d 張", ErrDeckMismatch, template.Len(), proof.DeckSize))
+	}
+
+	randomness := mixContributions(proof.Randomness, proof.Contributions)
+	defer Zeroize(randomness)
+	want := NewShuffler(template).Shuffle(randomness, proof.SessionID)
+
+	return compareDecks(deck, want)
+}
+
+// Digest 返回證明的 JSON 編碼的 SHA-256 摘要（十六進制），可以印在手牌歷史或收據上代表整份證明
+// 證明的任何欄位改變都會得到不同的摘要；玩家取得完整證明後可以重新計算比對
+func (p *ShuffleProof) Digest() string {
+	data, _ := json.Marshal(p)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Beacon 返回證明中記錄的隨機信標，可以交給 Verifier 驗證簽名
+func (p *ShuffleProof) Beacon() Beacon {
+	return Beacon{
+		Round:             p.Round,
+		Randomness:        copyBytes(p.Randomness),
+		Signature:         copyBytes(p.Signature),
+		PreviousSignature: copyBytes(p.PreviousSignature),
+	}
+}
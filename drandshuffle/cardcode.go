@@ -0,0 +1,42 @@
+package drandshuffle
+
+import "fmt"
+
+// suitCodes 是標準花色的英文縮寫，與撲克軟件和手牌歷史使用的寫法相同
+var suitCodes = map[string]byte{"黑桃": 's', "紅心": 'h', "方塊": 'd', "梅花": 'c'}
+
+// cardCodes 和 codeCards 是標準牌組與兩字符代碼之間的查找表
+var cardCodes, codeCards = buildCardCodes()
+
+// buildCardCodes 構建標準牌組與兩字符代碼之間的雙向查找表
+func buildCardCodes() (map[Card]string, map[string]Card) {
+	toCode := make(map[Card]string, StandardDeckTemplate.Len())
+	toCard := make(map[string]Card, StandardDeckTemplate.Len())
+	for _, card := range StandardDeckTemplate.cards {
+		value := card.Value
+		if value == "10" {
+			value = "T"
+		}
+		code := value + string(suitCodes[card.Suit])
+		toCode[card] = code
+		toCard[code] = card
+	}
+	return toCode, toCard
+}
+
+// CardCode 返回標準牌的兩字符代碼，如黑桃A為 "As"、紅心10為 "Th"
+// 這是撲克軟件、手牌歷史和牌力計算庫通用的寫法；不在標準牌組中的牌返回包裝 ErrInvalidCard 的輸入錯誤
+func CardCode(card Card) (string, error) {
+	if code, ok := cardCodes[card]; ok {
+		return code, nil
+	}
+	return "", NewInputError(fmt.Errorf("%w: %s 沒有標準代碼", ErrInvalidCard, CardToString(card)))
+}
+
+// ParseCardCode 解析 CardCode 產生的兩字符代碼，花色必須為小寫
+func ParseCardCode(code string) (Card, error) {
+	if card, ok := codeCards[code]; ok {
+		return card, nil
+	}
+	return Card{}, NewInputError(fmt.Errorf("%w: 無效的牌代碼 %q", ErrInvalidCard, code))
+}
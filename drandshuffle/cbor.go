@@ -0,0 +1,590 @@
+package drandshuffle
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"time"
+	"unicode/utf8"
+)
+
+// 緊湊的 CBOR（RFC 8949）編碼，使用第 4.2.1 節的確定性編碼規則：
+// 整數和長度使用最短形式、不使用不定長度、映射的鍵按編碼後的字節順序排列。
+// 相同的證明總是得到相同的字節，可以直接對編碼結果計算摘要；解碼時拒絕任何不符合規範的編碼。
+// 映射以小整數為鍵以節省空間，鍵的定義見各類型的 MarshalCBOR。
+
+// CBOR 主類型（已左移至首字節的高三位）
+const (
+	cborUint   byte = 0 << 5
+	cborNegInt byte = 1 << 5
+	cborBytes  byte = 2 << 5
+	cborText   byte = 3 << 5
+	cborArray  byte = 4 << 5
+	cborMap    byte = 5 << 5
+)
+
+// 洗牌證明映射的鍵
+const (
+	proofKeyAlgorithm = iota + 1
+	proofKeyChainHash
+	proofKeyRound
+	proofKeyRoundTime
+	proofKeyRandomness
+	proofKeySignature
+	proofKeyPreviousSignature
+	proofKeySessionID
+	proofKeyContributions
+	proofKeyDeckSize
+	proofKeyProvider
+	proofKeyPreviousDigest
+)
+
+// 洗牌結果映射的鍵
+const (
+	resultKeyDeck = iota + 1
+	resultKeyRound
+	resultKeyRoundTime
+	resultKeyProof
+)
+
+// standardCardIndexes 是標準牌到其在 StandardDeckTemplate 中位置的查找表
+var standardCardIndexes = buildStandardCardIndexes()
+
+// buildStandardCardIndexes 構建標準牌的位置查找表
+func buildStandardCardIndexes() map[Card]byte {
+	indexes := make(map[Card]byte, StandardDeckTemplate.Len())
+	for i, card := range StandardDeckTemplate.cards {
+		indexes[card] = byte(i)
+	}
+	return indexes
+}
+
+// EncodeDeckCBOR 將牌組編碼為確定性的 CBOR
+// 全部由標準牌組成的牌組（包括空牌組）編碼為字節串，每張牌一個字節，即其在 StandardDeckTemplate 中的位置，
+// 52 張牌只需 54 字節；含有其他牌的牌組編碼為 [花色, 點數] 文本對的數組
+func EncodeDeckCBOR(deck []Card) []byte {
+	var e cborEncoder
+	e.deck(deck)
+	return e.buf
+}
+
+// DecodeDeckCBOR 解析 EncodeDeckCBOR 產生的編碼，格式錯誤或不是規範編碼時返回包裝 ErrInvalidCBOR 的輸入錯誤
+func DecodeDeckCBOR(data []byte) ([]Card, error) {
+	d := cborDecoder{data: data}
+	deck, err := d.deck()
+	if err == nil {
+		err = d.finish(EncodeDeckCBOR(deck))
+	}
+	if err != nil {
+		return nil, NewInputError(fmt.Errorf("無法解析牌組: %w", err))
+	}
+	return deck, nil
+}
+
+// MarshalCBOR 將證明編碼為確定性的 CBOR 映射，大小不到 JSON 編碼的一半
+// 鍵依次為 1 algorithm、2 chain_hash、3 round、4 round_time、5 randomness、6 signature、
+// 7 previous_signature、8 session_id、9 contributions、10 deck_size、11 provider、12 previous_digest。
+// chain_hash 和 previous_digest 為小寫十六進制時以字節串保存；round_time 以 Unix 秒數保存，精確到秒，零值時省略；
+// signature、previous_signature、contributions、provider 和 previous_digest 為空時省略
+func (p *ShuffleProof) MarshalCBOR() ([]byte, error) {
+	var e cborEncoder
+	if err := e.proof(p); err != nil {
+		return nil, NewInputError(err)
+	}
+	return e.buf, nil
+}
+
+// UnmarshalCBOR 解析 MarshalCBOR 產生的編碼，格式錯誤或不是規範編碼時返回包裝 ErrInvalidCBOR 的輸入錯誤
+func (p *ShuffleProof) UnmarshalCBOR(data []byte) error {
+	d := cborDecoder{data: data}
+	proof, err := d.proof()
+	if err == nil {
+		var e cborEncoder
+		if err = e.proof(proof); err == nil {
+			err = d.finish(e.buf)
+		}
+	}
+	if err != nil {
+		return NewInputError(fmt.Errorf("無法解析洗牌證明: %w", err))
+	}
+	*p = *proof
+	return nil
+}
+
+// MarshalCBOR 將洗牌結果連同牌組和證明編碼為確定性的 CBOR 映射，適合作為每手牌的存檔或傳輸格式
+// 鍵依次為 1 牌組（EncodeDeckCBOR 的編碼）、2 round、3 round_time、4 證明（ShuffleProof.MarshalCBOR 的編碼）；
+// round_time 為零值或沒有證明時省略對應的鍵
+func (r *ShuffleResult) MarshalCBOR() ([]byte, error) {
+	var e cborEncoder
+	if err := e.result(r); err != nil {
+		return nil, NewInputError(err)
+	}
+	return e.buf, nil
+}
+
+// UnmarshalCBOR 解析 ShuffleResult.MarshalCBOR 產生的編碼，格式錯誤或不是規範編碼時返回包裝 ErrInvalidCBOR 的輸入錯誤
+func (r *ShuffleResult) UnmarshalCBOR(data []byte) error {
+	d := cborDecoder{data: data}
+	result, err := d.result()
+	if err == nil {
+		var e cborEncoder
+		if err = e.result(result); err == nil {
+			err = d.finish(e.buf)
+		}
+	}
+	if err != nil {
+		return NewInputError(fmt.Errorf("無法解析洗牌結果: %w", err))
+	}
+	*r = *result
+	return nil
+}
+
+// cborEncoder 以確定性規則逐項追加 CBOR 編碼
+type cborEncoder struct {
+	buf []byte
+}
+
+// head 追加數據項的首字節和參數，參數使用最短形式
+func (e *cborEncoder) head(major byte, n uint64) {
+	switch {
+	case n < 24:
+		e.buf = append(e.buf, major|byte(n))
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, major|24, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, major|25), uint16(n))
+	case n <= math.MaxUint32:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, major|26), uint32(n))
+	default:
+		e.buf = binary.BigEndian.AppendUint64(append(e.buf, major|27), n)
+	}
+}
+
+// int 追加有符號整數
+func (e *cborEncoder) int(n int64) {
+	if n < 0 {
+		e.head(cborNegInt, uint64(-1-n))
+		return
+	}
+	e.head(cborUint, uint64(n))
+}
+
+// bytes 追加字節串
+func (e *cborEncoder) bytes(b []byte) {
+	e.head(cborBytes, uint64(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+// text 追加文本串
+func (e *cborEncoder) text(s string) {
+	e.head(cborText, uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+// deck 追加牌組，見 EncodeDeckCBOR
+func (e *cborEncoder) deck(deck []Card) {
+	indexes := make([]byte, len(deck))
+	for i, card := range deck {
+		index, ok := standardCardIndexes[card]
+		if !ok {
+			e.head(cborArray, uint64(len(deck)))
+			for _, card := range deck {
+				e.head(cborArray, 2)
+				e.text(card.Suit)
+				e.text(card.Value)
+			}
+			return
+		}
+		indexes[i] = index
+	}
+	e.bytes(indexes)
+}
+
+// proof 追加洗牌證明映射，見 ShuffleProof.MarshalCBOR
+func (e *cborEncoder) proof(p *ShuffleProof) error {
+	if p == nil {
+		return fmt.Errorf("缺少洗牌證明")
+	}
+	if p.DeckSize < 0 {
+		return fmt.Errorf("無效的牌組張數 %d", p.DeckSize)
+	}
+	for _, s := range []string{p.Algorithm, p.ChainHash, p.SessionID, p.Provider, p.PreviousDigest} {
+		if !utf8.ValidString(s) {
+			return fmt.Errorf("%w: 文本欄位不是有效的 UTF-8", ErrInvalidCBOR)
+		}
+	}
+
+	fields := 6
+	for _, present := range []bool{!p.RoundTime.IsZero(), len(p.Signature) > 0, len(p.PreviousSignature) > 0, len(p.Contributions) > 0, p.Provider != "", p.PreviousDigest != ""} {
+		if present {
+			fields++
+		}
+	}
+	e.head(cborMap, uint64(fields))
+
+	e.head(cborUint, proofKeyAlgorithm)
+	e.text(p.Algorithm)
+	e.head(cborUint, proofKeyChainHash)
+	e.hexText(p.ChainHash)
+	e.head(cborUint, proofKeyRound)
+	e.head(cborUint, p.Round)
+	if !p.RoundTime.IsZero() {
+		e.head(cborUint, proofKeyRoundTime)
+		e.int(p.RoundTime.Unix())
+	}
+	e.head(cborUint, proofKeyRandomness)
+	e.bytes(p.Randomness)
+	if len(p.Signature) > 0 {
+		e.head(cborUint, proofKeySignature)
+		e.bytes(p.Signature)
+	}
+	if len(p.PreviousSignature) > 0 {
+		e.head(cborUint, proofKeyPreviousSignature)
+		e.bytes(p.PreviousSignature)
+	}
+	e.head(cborUint, proofKeySessionID)
+	e.text(p.SessionID)
+	if len(p.Contributions) > 0 {
+		e.head(cborUint, proofKeyContributions)
+		e.head(cborArray, uint64(len(p.Contributions)))
+		for _, c := range p.Contributions {
+			e.bytes(c)
+		}
+	}
+	e.head(cborUint, proofKeyDeckSize)
+	e.head(cborUint, uint64(p.DeckSize))
+	if p.Provider != "" {
+		e.head(cborUint, proofKeyProvider)
+		e.text(p.Provider)
+	}
+	if p.PreviousDigest != "" {
+		e.head(cborUint, proofKeyPreviousDigest)
+		e.hexText(p.PreviousDigest)
+	}
+	return nil
+}
+
+// hexText 追加文本：非空的小寫十六進制以解碼後的字節串保存，其他文本原樣保存
+func (e *cborEncoder) hexText(s string) {
+	if b, err := hex.DecodeString(s); err == nil && len(b) > 0 && hex.EncodeToString(b) == s {
+		e.bytes(b)
+	} else {
+		e.text(s)
+	}
+}
+
+// result 追加洗牌結果映射，見 ShuffleResult.MarshalCBOR
+func (e *cborEncoder) result(r *ShuffleResult) error {
+	if r == nil {
+		return fmt.Errorf("缺少洗牌結果")
+	}
+	fields := 2
+	if !r.RoundTime.IsZero() {
+		fields++
+	}
+	if r.Proof != nil {
+		fields++
+	}
+	e.head(cborMap, uint64(fields))
+
+	e.head(cborUint, resultKeyDeck)
+	e.deck(r.Deck)
+	e.head(cborUint, resultKeyRound)
+	e.head(cborUint, r.Round)
+	if !r.RoundTime.IsZero() {
+		e.head(cborUint, resultKeyRoundTime)
+		e.int(r.RoundTime.Unix())
+	}
+	if r.Proof != nil {
+		e.head(cborUint, resultKeyProof)
+		return e.proof(r.Proof)
+	}
+	return nil
+}
+
+// cborDecoder 從字節切片中逐項讀取 CBOR 數據項
+// 它只檢查結構；是否為規範編碼由 finish 以重新編碼比對的方式檢查
+type cborDecoder struct {
+	data []byte
+	off  int
+}
+
+// head 讀取數據項的主類型和參數，不接受不定長度和保留的附加信息
+func (d *cborDecoder) head() (byte, uint64, error) {
+	if d.off >= len(d.data) {
+		return 0, 0, fmt.Errorf("%w: 數據不完整", ErrInvalidCBOR)
+	}
+	initial := d.data[d.off]
+	d.off++
+	major, info := initial&0xe0, initial&0x1f
+
+	var size int
+	switch {
+	case info < 24:
+		return major, uint64(info), nil
+	case info == 24:
+		size = 1
+	case info == 25:
+		size = 2
+	case info == 26:
+		size = 4
+	case info == 27:
+		size = 8
+	default:
+		return 0, 0, fmt.Errorf("%w: 不支持的附加信息 %d", ErrInvalidCBOR, info)
+	}
+	if len(d.data)-d.off < size {
+		return 0, 0, fmt.Errorf("%w: 數據不完整", ErrInvalidCBOR)
+	}
+	var n uint64
+	for _, b := range d.data[d.off : d.off+size] {
+		n = n<<8 | uint64(b)
+	}
+	d.off += size
+	return major, n, nil
+}
+
+// expect 讀取指定主類型的數據項的參數
+func (d *cborDecoder) expect(major byte) (uint64, error) {
+	got, n, err := d.head()
+	if err != nil {
+		return 0, err
+	}
+	if got != major {
+		return 0, fmt.Errorf("%w: 預期主類型 %d，實際為 %d", ErrInvalidCBOR, major>>5, got>>5)
+	}
+	return n, nil
+}
+
+// length 讀取數組或映射的元素數量，數量不能超過剩餘的字節數
+func (d *cborDecoder) length(major byte) (int, error) {
+	n, err := d.expect(major)
+	if err != nil {
+		return 0, err
+	}
+	if n > uint64(len(d.data)-d.off) {
+		return 0, fmt.Errorf("%w: 數據不完整", ErrInvalidCBOR)
+	}
+	return int(n), nil
+}
+
+// uint 讀取無符號整數
+func (d *cborDecoder) uint() (uint64, error) {
+	return d.expect(cborUint)
+}
+
+// int 讀取有符號整數
+func (d *cborDecoder) int() (int64, error) {
+	major, n, err := d.head()
+	if err != nil {
+		return 0, err
+	}
+	if (major != cborUint && major != cborNegInt) || n > math.MaxInt64 {
+		return 0, fmt.Errorf("%w: 預期整數", ErrInvalidCBOR)
+	}
+	if major == cborNegInt {
+		return -1 - int64(n), nil
+	}
+	return int64(n), nil
+}
+
+// raw 讀取字節串或文本串的內容
+func (d *cborDecoder) raw(major byte) ([]byte, error) {
+	n, err := d.expect(major)
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(len(d.data)-d.off) {
+		return nil, fmt.Errorf("%w: 數據不完整", ErrInvalidCBOR)
+	}
+	b := d.data[d.off : d.off+int(n)]
+	d.off += int(n)
+	return b, nil
+}
+
+// bytes 讀取字節串，空字節串返回 nil
+func (d *cborDecoder) bytes() ([]byte, error) {
+	b, err := d.raw(cborBytes)
+	if err != nil || len(b) == 0 {
+		return nil, err
+	}
+	return copyBytes(b), nil
+}
+
+// text 讀取文本串，內容必須是有效的 UTF-8
+func (d *cborDecoder) text() (string, error) {
+	b, err := d.raw(cborText)
+	if err != nil {
+		return "", err
+	}
+	if !utf8.Valid(b) {
+		return "", fmt.Errorf("%w: 文本不是有效的 UTF-8", ErrInvalidCBOR)
+	}
+	return string(b), nil
+}
+
+// peek 返回下一個數據項的主類型，不移動讀取位置
+func (d *cborDecoder) peek() (byte, error) {
+	if d.off >= len(d.data) {
+		return 0, fmt.Errorf("%w: 數據不完整", ErrInvalidCBOR)
+	}
+	return d.data[d.off] & 0xe0, nil
+}
+
+// finish 檢查數據已全部讀完，且與解碼結果重新編碼的字節完全相同
+func (d *cborDecoder) finish(canonical []byte) error {
+	if d.off != len(d.data) {
+		return fmt.Errorf("%w: 數據末尾有 %d 個多餘的字節", ErrInvalidCBOR, len(d.data)-d.off)
+	}
+	if !bytes.Equal(d.data, canonical) {
+		return fmt.Errorf("%w: 不是規範編碼", ErrInvalidCBOR)
+	}
+	return nil
+}
+
+// deck 讀取牌組，見 EncodeDeckCBOR
+func (d *cborDecoder) deck() ([]Card, error) {
+	major, err := d.peek()
+	if err != nil {
+		return nil, err
+	}
+	if major == cborBytes {
+		indexes, err := d.raw(cborBytes)
+		if err != nil {
+			return nil, err
+		}
+		deck := make([]Card, len(indexes))
+		for i, index := range indexes {
+			if int(index) >= StandardDeckTemplate.Len() {
+				return nil, fmt.Errorf("%w: 第 %d 張牌的位置 %d 超出標準牌組", ErrInvalidCBOR, i+1, index)
+			}
+			deck[i] = StandardDeckTemplate.cards[index]
+		}
+		return deck, nil
+	}
+
+	n, err := d.length(cborArray)
+	if err != nil {
+		return nil, err
+	}
+	deck := make([]Card, n)
+	for i := range deck {
+		if pair, err := d.length(cborArray); err != nil || pair != 2 {
+			return nil, fmt.Errorf("%w: 第 %d 張牌不是 [花色, 點數]", ErrInvalidCBOR, i+1)
+		}
+		if deck[i].Suit, err = d.text(); err != nil {
+			return nil, err
+		}
+		if deck[i].Value, err = d.text(); err != nil {
+			return nil, err
+		}
+	}
+	return deck, nil
+}
+
+// proof 讀取洗牌證明映射，見 ShuffleProof.MarshalCBOR
+func (d *cborDecoder) proof() (*ShuffleProof, error) {
+	n, err := d.length(cborMap)
+	if err != nil {
+		return nil, err
+	}
+	p := &ShuffleProof{}
+	for range n {
+		key, err := d.uint()
+		if err != nil {
+			return nil, err
+		}
+		switch key {
+		case proofKeyAlgorithm:
+			p.Algorithm, err = d.text()
+		case proofKeyChainHash:
+			p.ChainHash, err = d.hexText()
+		case proofKeyRound:
+			p.Round, err = d.uint()
+		case proofKeyRoundTime:
+			var sec int64
+			sec, err = d.int()
+			p.RoundTime = time.Unix(sec, 0).UTC()
+		case proofKeyRandomness:
+			p.Randomness, err = d.bytes()
+		case proofKeySignature:
+			p.Signature, err = d.bytes()
+		case proofKeyPreviousSignature:
+			p.PreviousSignature, err = d.bytes()
+		case proofKeySessionID:
+			p.SessionID, err = d.text()
+		case proofKeyContributions:
+			var count int
+			if count, err = d.length(cborArray); err == nil {
+				p.Contributions = make([][]byte, count)
+				for i := 0; i < count && err == nil; i++ {
+					p.Contributions[i], err = d.bytes()
+				}
+			}
+		case proofKeyDeckSize:
+			var size uint64
+			if size, err = d.uint(); err == nil && size > math.MaxInt32 {
+				err = fmt.Errorf("%w: 無效的牌組張數 %d", ErrInvalidCBOR, size)
+			}
+			p.DeckSize = int(size)
+		case proofKeyProvider:
+			p.Provider, err = d.text()
+		case proofKeyPreviousDigest:
+			p.PreviousDigest, err = d.hexText()
+		default:
+			err = fmt.Errorf("%w: 未知的鍵 %d", ErrInvalidCBOR, key)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// hexText 讀取 cborEncoder.hexText 追加的文本，字節串轉換為小寫十六進制
+func (d *cborDecoder) hexText() (string, error) {
+	major, err := d.peek()
+	if err != nil {
+		return "", err
+	}
+	if major != cborBytes {
+		return d.text()
+	}
+	b, err := d.bytes()
+	return hex.EncodeToString(b), err
+}
+
+// result 讀取洗牌結果映射，見 ShuffleResult.MarshalCBOR
+func (d *cborDecoder) result() (*ShuffleResult, error) {
+	n, err := d.length(cborMap)
+	if err != nil {
+		return nil, err
+	}
+	r := &ShuffleResult{}
+	for range n {
+		key, err := d.uint()
+		if err != nil {
+			return nil, err
+		}
+		switch key {
+		case resultKeyDeck:
+			r.Deck, err = d.deck()
+		case resultKeyRound:
+			r.Round, err = d.uint()
+		case resultKeyRoundTime:
+			var sec int64
+			sec, err = d.int()
+			r.RoundTime = time.Unix(sec, 0).UTC()
+		case resultKeyProof:
+			r.Proof, err = d.proof()
+		default:
+			err = fmt.Errorf("%w: 未知的鍵 %d", ErrInvalidCBOR, key)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
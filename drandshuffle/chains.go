@@ -0,0 +1,140 @@
+package drandshuffle
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/drand/drand/v2/common/chain"
+)
+
+// Chain 是一個 drand 網絡的預設，包含鏈哈希、簽名方案和公共中繼
+type Chain struct {
+	Name      string        // 預設名稱，用於 WithChain、配置文件的 chain 欄位和 DRANDSHUFFLE_CHAIN
+	ChainHash string        // 鏈哈希的十六進制字符串
+	Scheme    string        // 簽名方案，與鏈信息中的 schemeID 相同
+	Period    time.Duration // 兩個隨機信標之間的間隔
+	URLs      []string      // 公共中繼的地址
+}
+
+// mainnetRelays 是 League of Entropy 主網的公共中繼
+var mainnetRelays = []string{"https://api.drand.sh", "https://drand.cloudflare.com"}
+
+// chains 是內置的網絡預設，Chains 按此順序返回
+var chains = []Chain{
+	{
+		Name:      "quicknet",
+		ChainHash: QuicknetChainHash,
+		Scheme:    "bls-unchained-g1-rfc9380",
+		Period:    3 * time.Second,
+		URLs:      mainnetRelays,
+	},
+	{
+		Name:      "mainnet-default",
+		ChainHash: "8990e7a9aaed2ffed73dbd7092123d6f289930540d7651336225dc172e51b2ce",
+		Scheme:    "pedersen-bls-chained",
+		Period:    30 * time.Second,
+		URLs:      mainnetRelays,
+	},
+	{
+		// 測試網的 quicknet-t，與 quicknet 參數相同，供預發布環境使用
+		Name:      "testnet",
+		ChainHash: "cc9c398442737cbd141526600919edd69f1d6f9b4adb67e4d912fbc64341a9a5",
+		Scheme:    "bls-unchained-g1-rfc9380",
+		Period:    3 * time.Second,
+		URLs:      []string{"https://pl-us.testnet.drand.sh", "https://pl-eu.testnet.drand.sh"},
+	},
+	{
+		// fastnet 已由 quicknet 取代並停止產生新的隨機信標，只適合驗證歷史輪次
+		Name:      "fastnet",
+		ChainHash: "dbd506d6ef76e5f386f41c651dcb808c5bcbd75471cc4eafa3f4df7ad4e4c493",
+		Scheme:    "bls-unchained-on-g1",
+		Period:    3 * time.Second,
+		URLs:      mainnetRelays,
+	},
+}
+
+// Chains 返回內置的網絡預設：quicknet、mainnet-default、testnet 和 fastnet
+func Chains() []Chain {
+	out := make([]Chain, len(chains))
+	for i, c := range chains {
+		c.URLs = slices.Clone(c.URLs)
+		out[i] = c
+	}
+	return out
+}
+
+// LookupChain 按名稱返回網絡預設，未知的名稱返回包裝 ErrInvalidConfig 的輸入錯誤
+func LookupChain(name string) (Chain, error) {
+	for _, c := range chains {
+		if c.Name == name {
+			c.URLs = slices.Clone(c.URLs)
+			return c, nil
+		}
+	}
+	return Chain{}, NewInputError(fmt.Errorf("%w: 未知的鏈 %q", ErrInvalidConfig, name))
+}
+
+// ReadChainInfo 解析 drand 的鏈信息文檔，即中繼 /<鏈哈希>/info 返回的 JSON
+// 自建網絡可以用 drand show chain-info 導出該文檔，再通過 WithChainInfo 或配置的 chain_info_file 使用
+func ReadChainInfo(r io.Reader) (*chain.Info, error) {
+	info, err := chain.InfoFromJSON(r)
+	if err != nil {
+		return nil, NewInputError(fmt.Errorf("%w: 無法解析鏈信息: %w", ErrInvalidConfig, err))
+	}
+	return info, nil
+}
+
+// readChainInfoFile 讀取並解析鏈信息文件
+func readChainInfoFile(path string) (*chain.Info, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, NewInputError(fmt.Errorf("%w: 無法讀取鏈信息文件: %w", ErrInvalidConfig, err))
+	}
+	return ReadChainInfo(bytes.NewReader(data))
+}
+
+// resolveChain 應用配置中的網絡預設和鏈信息，返回固定的鏈信息，沒有時返回 nil
+// ChainHash 保持默認值時以預設或鏈信息的哈希取代，已另外設定時必須與之一致；
+// URLs 保持默認的公共中繼時以預設的中繼取代，已另外設定時保留，以便連接自建或內部的中繼
+func (c *Config) resolveChain(info *chain.Info) (*chain.Info, error) {
+	// pinned 記錄已決定鏈哈希的來源，之後的來源必須與之一致
+	var pinned string
+	pin := func(hash, source string) error {
+		custom := c.ChainHash != "" && c.ChainHash != QuicknetChainHash
+		if (pinned != "" || custom) && c.ChainHash != hash {
+			return NewInputError(fmt.Errorf("%w: 鏈哈希 %s 與%s的 %s 不一致", ErrInvalidConfig, c.ChainHash, source, hash))
+		}
+		c.ChainHash, pinned = hash, source
+		return nil
+	}
+
+	if c.Chain != "" {
+		preset, err := LookupChain(c.Chain)
+		if err != nil {
+			return nil, err
+		}
+		if err := pin(preset.ChainHash, "鏈 "+preset.Name); err != nil {
+			return nil, err
+		}
+		if len(c.URLs) == 0 || slices.Equal(c.URLs, mainnetRelays) {
+			c.URLs = preset.URLs
+		}
+	}
+
+	if info == nil && c.ChainInfoFile != "" {
+		var err error
+		if info, err = readChainInfoFile(c.ChainInfoFile); err != nil {
+			return nil, err
+		}
+	}
+	if info != nil {
+		if err := pin(info.HashString(), "鏈信息"); err != nil {
+			return nil, err
+		}
+	}
+	return info, nil
+}
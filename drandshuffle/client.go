@@ -0,0 +1,150 @@
+package drandshuffle
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Client 是洗牌服務的入口，明確持有所使用的 DrandManager
+// 與依賴單例的 GetShuffledDeck 等函數不同，Client 可以按需創建多個，也可以在測試中使用假的隨機信標源
+// 可以被多個 goroutine 並發使用
+type Client struct {
+	manager *DrandManager
+}
+
+// NewClient 使用指定選項創建新的 DrandManager 並包裝為 Client
+func NewClient(opts ...Option) (*Client, error) {
+	dm, err := NewDrandManager(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{manager: dm}, nil
+}
+
+// NewClientWithManager 使用已創建的 DrandManager 創建 Client
+// Client 的 Close 會關閉該 DrandManager
+func NewClientWithManager(dm *DrandManager) *Client {
+	return &Client{manager: dm}
+}
+
+// DefaultClient 返回使用單例 DrandManager 的 Client，即 GetShuffledDeck 等函數使用的 Client
+// 單例在整個進程中共享，不應調用返回的 Client 的 Close
+func DefaultClient() (*Client, error) {
+	dm, err := GetDrandManager()
+	if err != nil {
+		return nil, fmt.Errorf("無法初始化 DrandManager: %w", err)
+	}
+	return &Client{manager: dm}, nil
+}
+
+// Manager 返回 Client 使用的 DrandManager
+func (c *Client) Manager() *DrandManager {
+	return c.manager
+}
+
+// ShuffleLatest 使用最新的隨機信標和遊戲局號洗牌，返回洗好的標準牌組和使用的輪次號碼
+// 最新信標取自緩存，不發出網絡請求；遊戲局號須通過 ValidateSessionID 的檢查
+// 嚴格輪次模式下返回 ErrExplicitRoundRequired，見 WithStrictRounds
+func (c *Client) ShuffleLatest(ctx context.Context, gameSessionID string) ([]Card, uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+	if err := ValidateSessionID(gameSessionID); err != nil {
+		return nil, 0, err
+	}
+	return c.manager.shuffledDeck(ctx, gameSessionID)
+}
+
+// ShuffleAtRound 使用指定輪次的隨機信標和遊戲局號洗牌，返回洗好的標準牌組
+// 輪次不在緩存中時從網絡獲取，ctx 取消時不再等待；遊戲局號須通過 ValidateSessionID 的檢查
+// 輪次為 0 時返回 ErrRoundBeforeGenesis，尚未發布時返回 ErrFutureRound，可用時間見 FutureRoundError
+func (c *Client) ShuffleAtRound(ctx context.Context, round uint64, gameSessionID string) ([]Card, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := ValidateSessionID(gameSessionID); err != nil {
+		return nil, err
+	}
+	return c.manager.shuffledDeckByRound(ctx, round, gameSessionID)
+}
+
+// Verify 檢查 deck 是否是使用指定輪次的隨機信標和遊戲局號洗出的標準牌組
+// 已取得鏈信息時同時驗證該輪信標的簽名；不一致時返回包裝 ErrDeckMismatch 的錯誤
+func (c *Client) Verify(ctx context.Context, round uint64, gameSessionID string, deck []Card) error {
+	randomness, err := c.verifiedRandomness(ctx, round)
+	if err != nil {
+		return err
+	}
+	return compareDecks(deck, defaultShuffler.Shuffle(randomness, gameSessionID))
+}
+
+// verifiedRandomness 返回輪次的隨機性，已取得鏈信息時先驗證該輪信標的簽名
+func (c *Client) verifiedRandomness(ctx context.Context, round uint64) ([]byte, error) {
+	beacon, err := c.manager.beaconByRound(ctx, round)
+	if err != nil {
+		return nil, fmt.Errorf("無法獲取輪次 %d 的隨機信標: %w", round, err)
+	}
+
+	// 鏈信息不含公鑰時（例如測試用的信標源）無法驗證簽名，只比對牌組
+	if info := c.manager.ChainInfo(); info != nil && info.PublicKey != nil {
+		verifier, err := NewVerifier(info)
+		if err != nil {
+			return nil, fmt.Errorf("無法創建驗證器: %w", err)
+		}
+		if err := verifier.Verify(newBeacon(beacon)); err != nil {
+			return nil, fmt.Errorf("輪次 %d 的隨機信標驗證失敗: %w", round, err)
+		}
+	}
+	return beacon.GetRandomness(), nil
+}
+
+// compareDecks 逐張比對牌組，不一致時返回包裝 ErrDeckMismatch 的驗證錯誤
+func compareDecks(deck, want []Card) error {
+	if len(deck) != len(want) {
+		return NewVerificationError(fmt.Errorf("%w: 牌組有 %d 張牌，預期為 %d 張", ErrDeckMismatch, len(deck), len(want)))
+	}
+	for i := range want {
+		if deck[i] != want[i] {
+			return NewVerificationError(fmt.Errorf("%w: 第 %d 張牌為 %s，預期為 %s", ErrDeckMismatch, i+1, deck[i], want[i]))
+		}
+	}
+	return nil
+}
+
+// Subscribe 開始後台獲取隨機信標，並返回接收新信標的通道，見 DrandManager.Subscribe
+func (c *Client) Subscribe(ctx context.Context) <-chan Beacon {
+	ch := c.manager.Subscribe(ctx)
+	c.manager.StartBackgroundFetching()
+	return ch
+}
+
+// RoundTime 返回輪次的發布時間，見 DrandManager.RoundTime
+func (c *Client) RoundTime(round uint64) (time.Time, bool) {
+	return c.manager.RoundTime(round)
+}
+
+// CardName 返回牌在配置的語言中的顯示名稱，見 CardName
+func (c *Client) CardName(card Card) string {
+	return cardName(c.manager.translator, card, c.manager.Locale())
+}
+
+// ErrorMessage 返回錯誤在配置的語言中適合展示給最終用戶的消息，見 ErrorMessage
+func (c *Client) ErrorMessage(err error) string {
+	return errorMessage(c.manager.translator, err, c.manager.Locale())
+}
+
+// VerifyMessage 返回驗證結果在配置的語言中適合展示給玩家的說明，見 VerifyMessage
+func (c *Client) VerifyMessage(err error, round uint64, sessionID string) string {
+	return verifyMessage(c.manager.translator, err, round, sessionID, c.manager.Locale())
+}
+
+// Health 返回獲取最新隨機信標的狀態快照
+func (c *Client) Health() Health {
+	return c.manager.Health()
+}
+
+// Close 關閉 Client 使用的 DrandManager
+func (c *Client) Close() {
+	c.manager.Close()
+}
@@ -0,0 +1,25 @@
+package drandshuffle
+
+import "time"
+
+// Clock 提供當前時間和定時等待
+// DrandManager 用它推算輪次的發佈時間、信標年齡和輪詢間隔；默認使用系統時鐘，
+// 測試可以通過 WithClock 注入 drandshuffletest.Clock，不需要真正等待
+// 實現必須可以被多個 goroutine 並發使用
+type Clock interface {
+	// Now 返回當前時間
+	Now() time.Time
+	// After 返回在時鐘前進 d 之後接收到當時時間的通道
+	After(d time.Duration) <-chan time.Time
+}
+
+// systemClock 是使用 time 包的系統時鐘
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// since 返回從 t 到時鐘當前時間經過的時間
+func (dm *DrandManager) since(t time.Time) time.Duration {
+	return dm.clock.Now().Sub(t)
+}
@@ -0,0 +1,167 @@
+package drandshuffle
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"time"
+)
+
+// QuicknetChainHash 是 drand quicknet 鏈的哈希值，quicknet 每 3 秒產生一個隨機信標
+const QuicknetChainHash = "52db9ba70e0cc0f6eaf7803dd07447a1f5477735fd3f661792ba94600c84e971"
+
+const (
+	// defaultConnectTimeout 連接中繼並取得鏈信息的默認超時
+	defaultConnectTimeout = 10 * time.Second
+	// defaultFetchTimeout 單次獲取隨機信標的默認超時
+	defaultFetchTimeout = 5 * time.Second
+	// defaultCacheSize 緩存默認保留的隨機信標數量上限
+	defaultCacheSize = 100
+	// defaultFetchConcurrency 批量獲取輪次時默認的工作協程數量
+	defaultFetchConcurrency = 8
+)
+
+// Config 是 DrandManager 的配置
+// 應以 DefaultConfig 的返回值為基礎修改，再通過 WithConfig 傳入；各個 With 選項修改的也是同一份配置
+// 也可以通過 LoadConfig 從配置文件和環境變量載入，欄位在文件中使用 yaml/toml 標籤中的名稱
+type Config struct {
+	// ChainHash 是鏈哈希的十六進制字符串，默認為 quicknet
+	ChainHash string `yaml:"chain_hash" toml:"chain_hash"`
+	// URLs 是 drand 中繼的地址，按順序嘗試連接
+	URLs []string `yaml:"urls" toml:"urls"`
+	// Chain 是內置網絡預設的名稱，如 testnet，見 Chains；設定時以預設的鏈哈希和中繼取代默認值
+	Chain string `yaml:"chain,omitempty" toml:"chain,omitempty"`
+	// ChainInfoFile 是自定義鏈信息文檔（JSON）的路徑，見 WithChainInfo
+	ChainInfoFile string `yaml:"chain_info_file,omitempty" toml:"chain_info_file,omitempty"`
+
+	// 各項超時只在調用者的 ctx 沒有截止時間時使用；ctx 帶有截止時間時以 ctx 為準，可以比默認值更長或更短
+
+	// ConnectTimeout 是連接中繼並取得鏈信息的超時
+	ConnectTimeout time.Duration `yaml:"connect_timeout" toml:"connect_timeout"`
+	// FetchTimeout 是單次獲取隨機信標的默認超時，未單獨設定的獲取操作都使用此值
+	FetchTimeout time.Duration `yaml:"fetch_timeout" toml:"fetch_timeout"`
+	// FetchLatestTimeout 是獲取最新隨機信標的超時，為 0 時使用 FetchTimeout
+	// 發牌路徑通常需要 1 到 2 秒的嚴格預算
+	FetchLatestTimeout time.Duration `yaml:"fetch_latest_timeout,omitempty" toml:"fetch_latest_timeout,omitempty"`
+	// FetchByRoundTimeout 是獲取指定輪次的超時，為 0 時使用 FetchTimeout
+	// 審計回填等批量任務可以放寬到 30 秒
+	FetchByRoundTimeout time.Duration `yaml:"fetch_by_round_timeout,omitempty" toml:"fetch_by_round_timeout,omitempty"`
+	// InitTimeout 是創建 DrandManager 時連接中繼、取得鏈信息和初始隨機信標的總超時
+	// 為 0 時不限制總時間，各步驟分別受 ConnectTimeout 和 FetchLatestTimeout 限制
+	InitTimeout time.Duration `yaml:"init_timeout,omitempty" toml:"init_timeout,omitempty"`
+
+	// CacheSize 是緩存保留的隨機信標數量上限
+	CacheSize int `yaml:"cache_size" toml:"cache_size"`
+	// FetchConcurrency 是批量獲取輪次時的工作協程數量
+	FetchConcurrency int `yaml:"fetch_concurrency" toml:"fetch_concurrency"`
+
+	// Hedged 為 true 時，獲取最新隨機信標會同時請求所有中繼
+	Hedged bool `yaml:"hedged" toml:"hedged"`
+	// WarmStart 為 true 時，創建時不等待網絡，先使用保存的信標服務，見 WithWarmStart
+	WarmStart bool `yaml:"warm_start" toml:"warm_start"`
+	// StrictRounds 為 true 時拒絕使用最新隨機信標的洗牌，調用者必須綁定預先承諾的輪次，見 WithStrictRounds
+	StrictRounds bool `yaml:"strict_rounds" toml:"strict_rounds"`
+
+	// Locale 是 CardName、ErrorMessage 等顯示文字使用的語言，為空時使用 DefaultLocale（繁體中文）
+	// 日誌和錯誤的 Error() 文字不受影響
+	Locale Locale `yaml:"locale" toml:"locale"`
+
+	// Logger 是日誌記錄器，為 nil 時不輸出日誌
+	Logger *slog.Logger `yaml:"-" toml:"-"`
+	// DealLatencyBuckets 是發牌延遲直方圖的桶上限，為空時使用默認值
+	DealLatencyBuckets []time.Duration `yaml:"deal_latency_buckets,omitempty" toml:"deal_latency_buckets,omitempty"`
+}
+
+// DefaultConfig 返回連接 quicknet 公共中繼的默認配置
+func DefaultConfig() Config {
+	return Config{
+		ChainHash:        QuicknetChainHash,
+		URLs:             slices.Clone(mainnetRelays),
+		ConnectTimeout:   defaultConnectTimeout,
+		FetchTimeout:     defaultFetchTimeout,
+		CacheSize:        defaultCacheSize,
+		FetchConcurrency: defaultFetchConcurrency,
+		Locale:           DefaultLocale,
+	}
+}
+
+// Validate 檢查配置是否有效，返回的錯誤包裝 ErrInvalidConfig 並列出所有問題
+func (c Config) Validate() error {
+	var errs []error
+
+	if hash, err := hex.DecodeString(c.ChainHash); err != nil || len(hash) != 32 {
+		errs = append(errs, fmt.Errorf("無效的鏈哈希 %q", c.ChainHash))
+	}
+	if len(c.URLs) == 0 {
+		errs = append(errs, fmt.Errorf("至少需要一個中繼 URL"))
+	}
+	for _, raw := range c.URLs {
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("無效的中繼 URL %q", raw))
+		}
+	}
+	if c.ConnectTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("連接超時必須大於 0"))
+	}
+	if c.FetchTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("獲取超時必須大於 0"))
+	}
+	if c.FetchLatestTimeout < 0 || c.FetchByRoundTimeout < 0 || c.InitTimeout < 0 {
+		errs = append(errs, fmt.Errorf("各操作的超時不能為負數"))
+	}
+	if c.CacheSize < 1 {
+		errs = append(errs, fmt.Errorf("緩存容量必須至少為 1"))
+	}
+	if c.FetchConcurrency < 1 {
+		errs = append(errs, fmt.Errorf("工作協程數量必須至少為 1"))
+	}
+	if c.Locale != "" && !slices.Contains(Locales(), c.Locale) {
+		errs = append(errs, fmt.Errorf("不支持的語言 %q", c.Locale))
+	}
+	for _, bound := range c.DealLatencyBuckets {
+		if bound <= 0 {
+			errs = append(errs, fmt.Errorf("延遲直方圖的桶上限必須大於 0"))
+			break
+		}
+	}
+
+	if len(errs) > 0 {
+		return NewInputError(fmt.Errorf("%w: %w", ErrInvalidConfig, errors.Join(errs...)))
+	}
+	return nil
+}
+
+// fetchLatestTimeout 返回獲取最新隨機信標的超時
+func (c Config) fetchLatestTimeout() time.Duration {
+	if c.FetchLatestTimeout > 0 {
+		return c.FetchLatestTimeout
+	}
+	return c.FetchTimeout
+}
+
+// fetchByRoundTimeout 返回獲取指定輪次的超時
+func (c Config) fetchByRoundTimeout() time.Duration {
+	if c.FetchByRoundTimeout > 0 {
+		return c.FetchByRoundTimeout
+	}
+	return c.FetchTimeout
+}
+
+// withDefaultTimeout 在 ctx 沒有截止時間時加上默認超時，ctx 已有截止時間時以 ctx 為準
+func withDefaultTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+// chainHashBytes 返回解碼後的鏈哈希，調用前配置應已通過 Validate
+func (c Config) chainHashBytes() []byte {
+	hash, _ := hex.DecodeString(c.ChainHash)
+	return hash
+}
@@ -0,0 +1,171 @@
+package drandshuffle
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// 覆蓋配置的環境變量
+const (
+	// EnvURLs 以逗號分隔的中繼地址
+	EnvURLs = "DRANDSHUFFLE_URLS"
+	// EnvChainHash 鏈哈希的十六進制字符串
+	EnvChainHash = "DRANDSHUFFLE_CHAIN_HASH"
+	// EnvChain 內置網絡預設的名稱，如 testnet
+	EnvChain = "DRANDSHUFFLE_CHAIN"
+	// EnvChainInfoFile 自定義鏈信息文檔的路徑
+	EnvChainInfoFile = "DRANDSHUFFLE_CHAIN_INFO_FILE"
+	// EnvCacheSize 緩存保留的隨機信標數量上限
+	EnvCacheSize = "DRANDSHUFFLE_CACHE_SIZE"
+	// EnvConnectTimeout 連接超時，格式同 time.ParseDuration，如 10s
+	EnvConnectTimeout = "DRANDSHUFFLE_CONNECT_TIMEOUT"
+	// EnvFetchTimeout 獲取超時，格式同 time.ParseDuration，如 5s
+	EnvFetchTimeout = "DRANDSHUFFLE_FETCH_TIMEOUT"
+	// EnvFetchLatestTimeout 獲取最新隨機信標的超時，如 2s
+	EnvFetchLatestTimeout = "DRANDSHUFFLE_FETCH_LATEST_TIMEOUT"
+	// EnvFetchByRoundTimeout 獲取指定輪次的超時，如 30s
+	EnvFetchByRoundTimeout = "DRANDSHUFFLE_FETCH_BY_ROUND_TIMEOUT"
+	// EnvInitTimeout 創建時初始化的總超時，如 15s
+	EnvInitTimeout = "DRANDSHUFFLE_INIT_TIMEOUT"
+	// EnvStrictRounds 是否啟用嚴格輪次模式，格式同 strconv.ParseBool，如 true
+	EnvStrictRounds = "DRANDSHUFFLE_STRICT_ROUNDS"
+)
+
+// LoadConfig 按優先級由低到高依次應用默認配置、配置文件和環境變量，返回合併後的配置
+// path 為空時跳過配置文件；文件格式由擴展名決定，支持 .yaml、.yml 和 .toml，文件中未知的欄位視為錯誤
+// 命令行參數等更高優先級的設定應由調用者在返回的配置上修改，返回的配置尚未經過 Validate
+func LoadConfig(path string) (Config, error) {
+	cfg := DefaultConfig()
+
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return Config{}, err
+		}
+	}
+
+	if err := cfg.ApplyEnv(); err != nil {
+		return Config{}, err
+	}
+
+	return cfg, nil
+}
+
+// loadFile 以配置文件的內容覆蓋配置，文件中未出現的欄位保持不變
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return NewInputError(fmt.Errorf("%w: 無法讀取配置文件: %w", ErrInvalidConfig, err))
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(c); err != nil && err != io.EOF {
+			return NewInputError(fmt.Errorf("%w: 無法解析配置文件 %s: %w", ErrInvalidConfig, path, err))
+		}
+	case ".toml":
+		meta, err := toml.Decode(string(data), c)
+		if err != nil {
+			return NewInputError(fmt.Errorf("%w: 無法解析配置文件 %s: %w", ErrInvalidConfig, path, err))
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			return NewInputError(fmt.Errorf("%w: 配置文件 %s 含有未知的欄位 %v", ErrInvalidConfig, path, undecoded))
+		}
+	default:
+		return NewInputError(fmt.Errorf("%w: 不支持的配置文件格式 %q", ErrInvalidConfig, ext))
+	}
+
+	return nil
+}
+
+// ApplyEnv 以已設定的環境變量覆蓋配置，未設定或為空的環境變量不會修改配置
+func (c *Config) ApplyEnv() error {
+	if v := os.Getenv(EnvURLs); v != "" {
+		c.URLs = nil
+		for _, u := range strings.Split(v, ",") {
+			if u = strings.TrimSpace(u); u != "" {
+				c.URLs = append(c.URLs, u)
+			}
+		}
+	}
+	if v := os.Getenv(EnvChainHash); v != "" {
+		c.ChainHash = v
+	}
+	if v := os.Getenv(EnvChain); v != "" {
+		c.Chain = v
+	}
+	if v := os.Getenv(EnvChainInfoFile); v != "" {
+		c.ChainInfoFile = v
+	}
+	if v := os.Getenv(EnvCacheSize); v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil {
+			return NewInputError(fmt.Errorf("%w: 無法解析 %s: %w", ErrInvalidConfig, EnvCacheSize, err))
+		}
+		c.CacheSize = size
+	}
+	if v := os.Getenv(EnvStrictRounds); v != "" {
+		strict, err := strconv.ParseBool(v)
+		if err != nil {
+			return NewInputError(fmt.Errorf("%w: 無法解析 %s: %w", ErrInvalidConfig, EnvStrictRounds, err))
+		}
+		c.StrictRounds = strict
+	}
+	if v := os.Getenv(EnvLocale); v != "" {
+		locale, err := ParseLocale(v)
+		if err != nil {
+			return fmt.Errorf("無法解析 %s: %w", EnvLocale, err)
+		}
+		c.Locale = locale
+	}
+	for _, d := range []struct {
+		name string
+		dst  *time.Duration
+	}{
+		{EnvConnectTimeout, &c.ConnectTimeout},
+		{EnvFetchTimeout, &c.FetchTimeout},
+		{EnvFetchLatestTimeout, &c.FetchLatestTimeout},
+		{EnvFetchByRoundTimeout, &c.FetchByRoundTimeout},
+		{EnvInitTimeout, &c.InitTimeout},
+	} {
+		if err := durationFromEnv(d.name, d.dst); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// durationFromEnv 在環境變量已設定時解析時長並寫入 dst
+func durationFromEnv(name string, dst *time.Duration) error {
+	v := os.Getenv(name)
+	if v == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return NewInputError(fmt.Errorf("%w: 無法解析 %s: %w", ErrInvalidConfig, name, err))
+	}
+	*dst = d
+	return nil
+}
+
+// PrintEffectiveConfig 以 YAML 格式輸出配置，用於排查部署時實際生效的設定
+// 輸出可以直接保存為配置文件；Logger 不會輸出
+func PrintEffectiveConfig(w io.Writer, cfg Config) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(cfg); err != nil {
+		return fmt.Errorf("無法輸出配置: %w", err)
+	}
+	return enc.Close()
+}
@@ -0,0 +1,143 @@
+package drandshuffle
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// maxPlanCards 是發牌計劃最多的總張數，遠大於任何實際的牌組，防止無效的計劃分配大量記憶體
+const maxPlanCards = 1 << 16
+
+// slotNamePattern 是位置名稱的格式：以點分隔的若干段，每段以字母開頭，由字母、數字、下劃線和連字符組成
+var slotNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*(\.[A-Za-z][A-Za-z0-9_-]*)*$`)
+
+// DealStep 是發牌計劃中的一步：輪流為 Slots 中的每個位置各發一張牌，重複 Count 次
+// 只有一個位置時就是連續發出 Count 張，例如 {Slots: ["board.flop"], Count: 3}；
+// 多個位置時是逐張輪流發牌，例如 {Slots: ["player1.hole", "player2.hole"], Count: 2} 依次發出
+// player1.hole[0]、player2.hole[0]、player1.hole[1]、player2.hole[1]
+type DealStep struct {
+	Slots []string `json:"slots"`
+	Count int      `json:"count"`
+}
+
+// DealPlan 以聲明的方式描述洗好的牌組從頂部起的每個位置發到哪裡，新的遊戲不需要編寫發牌代碼，
+// 驗證頁面也可以用 Labels 為每個位置標上名稱。同一位置名稱可以出現在多步中，編號接著前一步繼續
+// 位置名稱以點分隔，如 "player1.hole"、"board.turn"、"burn"；每張牌的標籤為名稱加從 0 開始的編號，如 "player1.hole[0]"。
+// DealPlan 可以序列化為 JSON 保存或傳給前端，使用前應調用 Validate
+type DealPlan struct {
+	Name  string     `json:"name"` // 計劃的名稱，如 "holdem-6"，只用於顯示
+	Steps []DealStep `json:"steps"`
+}
+
+// PlannedCard 是按計劃發出的一張牌
+type PlannedCard struct {
+	Position int    // 在洗好的牌組中的位置，從 0 開始
+	Slot     string // 位置名稱，如 "player1.hole"
+	Index    int    // 在該位置中的編號，從 0 開始
+	Label    string // 如 "player1.hole[0]"
+	Card     Card
+}
+
+// PlannedDeal 是按計劃發牌的結果
+type PlannedDeal struct {
+	Cards []PlannedCard     // 按發出的順序排列，與牌組的順序相同
+	Slots map[string][]Card // 每個位置名稱發到的牌，按編號排列
+}
+
+// NewDealPlan 創建並檢查發牌計劃，無效時返回 Validate 的錯誤
+func NewDealPlan(name string, steps ...DealStep) (*DealPlan, error) {
+	plan := &DealPlan{Name: name, Steps: steps}
+	if err := plan.Validate(); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// Validate 檢查計劃至少有一步，每步至少有一個位置、Count 大於 0、同一步中沒有重複的位置，
+// 位置名稱符合格式，總張數不超過 65536；無效時返回包裝 ErrInvalidConfig 的輸入錯誤
+func (p *DealPlan) Validate() error {
+	if len(p.Steps) == 0 {
+		return NewInputError(fmt.Errorf("%w: 發牌計劃至少需要一步", ErrInvalidConfig))
+	}
+	total := 0
+	for i, step := range p.Steps {
+		if len(step.Slots) == 0 {
+			return NewInputError(fmt.Errorf("%w: 第 %d 步沒有位置", ErrInvalidConfig, i))
+		}
+		if step.Count < 1 {
+			return NewInputError(fmt.Errorf("%w: 第 %d 步的張數 %d 必須大於 0", ErrInvalidConfig, i, step.Count))
+		}
+		seen := make(map[string]bool, len(step.Slots))
+		for _, slot := range step.Slots {
+			if !slotNamePattern.MatchString(slot) {
+				return NewInputError(fmt.Errorf("%w: 第 %d 步的位置名稱 %q 無效", ErrInvalidConfig, i, slot))
+			}
+			if seen[slot] {
+				return NewInputError(fmt.Errorf("%w: 第 %d 步的位置 %s 重複", ErrInvalidConfig, i, slot))
+			}
+			seen[slot] = true
+		}
+		if step.Count > maxPlanCards || len(step.Slots) > maxPlanCards-total || step.Count > (maxPlanCards-total)/len(step.Slots) {
+			return NewInputError(fmt.Errorf("%w: 發牌計劃的總張數超過 %d", ErrInvalidConfig, maxPlanCards))
+		}
+		total += len(step.Slots) * step.Count
+	}
+	return nil
+}
+
+// Size 返回計劃需要的總張數
+func (p *DealPlan) Size() int {
+	n := 0
+	for _, step := range p.Steps {
+		n += len(step.Slots) * step.Count
+	}
+	return n
+}
+
+// Labels 返回牌組從頂部起每個位置的標籤，長度為 Size，不需要牌組
+func (p *DealPlan) Labels() []string {
+	labels := make([]string, 0, p.Size())
+	p.walk(func(slot string, index int) {
+		labels = append(labels, slot+"["+strconv.Itoa(index)+"]")
+	})
+	return labels
+}
+
+// Apply 按計劃從 deck 頂部發牌，計劃無效時返回 Validate 的錯誤
+// 牌不足時不發出任何牌，返回包裝 *NotEnoughCardsError 的輸入錯誤；多出的牌不發出
+func (p *DealPlan) Apply(deck []Card) (*PlannedDeal, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	if err := RequireCards(deck, p.Size()); err != nil {
+		return nil, err
+	}
+
+	deal := &PlannedDeal{Cards: make([]PlannedCard, 0, p.Size()), Slots: make(map[string][]Card)}
+	p.walk(func(slot string, index int) {
+		position := len(deal.Cards)
+		deal.Cards = append(deal.Cards, PlannedCard{
+			Position: position,
+			Slot:     slot,
+			Index:    index,
+			Label:    slot + "[" + strconv.Itoa(index) + "]",
+			Card:     deck[position],
+		})
+		deal.Slots[slot] = append(deal.Slots[slot], deck[position])
+	})
+	return deal, nil
+}
+
+// walk 按發牌順序對每張牌調用 fn，index 為該位置已發出的張數
+func (p *DealPlan) walk(fn func(slot string, index int)) {
+	next := make(map[string]int)
+	for _, step := range p.Steps {
+		for range step.Count {
+			for _, slot := range step.Slots {
+				fn(slot, next[slot])
+				next[slot]++
+			}
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package drandshuffle
+
+import (
+	"fmt"
+	"math"
+)
+
+// NotEnoughCardsError 表示牌組剩餘的牌不足以完成發牌，記錄需要和剩餘的張數
+// 可以用 errors.Is(err, ErrInsufficientCards) 判斷，用 errors.As 取得張數
+type NotEnoughCardsError struct {
+	Required  int // 需要的張數
+	Available int // 剩餘的張數
+}
+
+// Error 返回錯誤說明
+func (e *NotEnoughCardsError) Error() string {
+	return fmt.Sprintf("%v: 牌組長度不足，需要 %d 張牌，但只有 %d 張", ErrInsufficientCards, e.Required, e.Available)
+}
+
+// Is 使 errors.Is(err, ErrInsufficientCards) 成立
+func (e *NotEnoughCardsError) Is(target error) bool {
+	return target == ErrInsufficientCards
+}
+
+// RequireCards 檢查牌組是否至少有 n 張牌，不足時返回包裝 *NotEnoughCardsError 的輸入錯誤
+func RequireCards(deck []Card, n int) error {
+	if len(deck) < n {
+		return NewInputError(&NotEnoughCardsError{Required: n, Available: len(deck)})
+	}
+	return nil
+}
+
+// Dealer 從洗好的牌組頂部依次發牌，發出的牌不會再次發出
+// 牌組的順序由洗牌結果決定，相同的牌組和發牌順序總是得到相同的手牌
+// Dealer 不是並發安全的，每局遊戲應使用各自的 Dealer
+type Dealer struct {
+	deck []Card
+	next int
+}
+
+// NewDealer 創建從 deck 頂部發牌的 Dealer，Dealer 不會修改 deck
+func NewDealer(deck []Card) *Dealer {
+	return &Dealer{deck: deck}
+}
+
+// Remaining 返回尚未發出的牌數
+func (d *Dealer) Remaining() int {
+	return len(d.deck) - d.next
+}
+
+// Require 檢查剩餘的牌是否至少有 n 張，適合在發牌前一次檢查整局需要的張數
+func (d *Dealer) Require(n int) error {
+	return RequireCards(d.deck[d.next:], n)
+}
+
+// Deal 發出接下來的 n 張牌
+// 剩餘的牌不足時不發出任何牌，返回包裝 *NotEnoughCardsError 的輸入錯誤
+// 返回的切片與 deck 共享記憶體，但容量受限，append 不會覆蓋之後的牌
+func (d *Dealer) Deal(n int) ([]Card, error) {
+	if n < 0 {
+		return nil, NewInputError(fmt.Errorf("無效的發牌張數 %d", n))
+	}
+	if err := d.Require(n); err != nil {
+		return nil, err
+	}
+	cards := d.deck[d.next : d.next+n : d.next+n]
+	d.next += n
+	return cards, nil
+}
+
+// DealHands 依次為每位玩家發出 perPlayer 張牌，返回按玩家順序排列的手牌
+// 先檢查整輪需要的張數，不足時不發出任何牌
+func (d *Dealer) DealHands(players, perPlayer int) ([][]Card, error) {
+	if players < 0 || perPlayer < 0 {
+		return nil, NewInputError(fmt.Errorf("無效的玩家數量 %d 或每人張數 %d", players, perPlayer))
+	}
+	required := players * perPlayer
+	if perPlayer != 0 && required/perPlayer != players {
+		required = math.MaxInt
+	}
+	if err := d.Require(required); err != nil {
+		return nil, err
+	}
+	hands := make([][]Card, players)
+	for i := range hands {
+		hands[i], _ = d.Deal(perPlayer)
+	}
+	return hands, nil
+}
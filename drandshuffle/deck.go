@@ -0,0 +1,63 @@
+package drandshuffle
+
+// DeckSpec 描述一副牌由哪些花色和點數組成
+type DeckSpec struct {
+	Suits  []string // 花色
+	Values []string // 點數
+	Extras []Card   // 附加在所有花色和點數組合之後的牌，例如鬥地主的大小王
+}
+
+// StandardDeckSpec 標準52張撲克牌的組成
+var StandardDeckSpec = DeckSpec{
+	Suits:  []string{"黑桃", "紅心", "方塊", "梅花"},
+	Values: []string{"A", "2", "3", "4", "5", "6", "7", "8", "9", "10", "J", "Q", "K"},
+}
+
+// StandardDeckTemplate 標準52張撲克牌的預構建模板
+var StandardDeckTemplate = NewDeckTemplate(StandardDeckSpec)
+
+// DeckTemplate 是預先構建好的按順序排列的牌組
+// 模板創建後不可修改，可以被多個 goroutine 並發使用
+type DeckTemplate struct {
+	cards []Card
+}
+
+// NewDeckTemplate 根據牌組組成構建模板
+// 需要重複創建自定義牌組時，應只構建一次模板並重複使用
+func NewDeckTemplate(spec DeckSpec) *DeckTemplate {
+	cards := make([]Card, 0, len(spec.Suits)*len(spec.Values)+len(spec.Extras))
+
+	for _, suit := range spec.Suits {
+		for _, value := range spec.Values {
+			cards = append(cards, Card{Suit: suit, Value: value})
+		}
+	}
+	cards = append(cards, spec.Extras...)
+
+	return &DeckTemplate{cards: cards}
+}
+
+// Len 返回模板中牌的數量，nil 模板的長度為 0
+func (t *DeckTemplate) Len() int {
+	if t == nil {
+		return 0
+	}
+	return len(t.cards)
+}
+
+// NewDeck 從模板複製一副按順序排列的新牌組
+func (t *DeckTemplate) NewDeck() []Card {
+	if t == nil {
+		return []Card{}
+	}
+	deck := make([]Card, len(t.cards))
+	copy(deck, t.cards)
+	return deck
+}
+
+// Shuffled 從模板複製一副新牌組並使用Fisher-Yates算法洗牌
+func (t *DeckTemplate) Shuffled(randomness []byte) []Card {
+	deck := t.NewDeck()
+	shuffleInPlace(deck, randomness)
+	return deck
+}
@@ -0,0 +1,68 @@
+package drandshuffle
+
+import (
+	"fmt"
+	"sync"
+)
+
+// deckPool 緩存可重複使用的牌組，減少高頻率洗牌時的記憶體分配
+var deckPool = sync.Pool{
+	New: func() any {
+		return &ReusableDeck{Cards: make([]Card, StandardDeckTemplate.Len())}
+	},
+}
+
+// ReusableDeck 是可重複使用的牌組，適用於每秒大量洗牌的服務
+// 通過 AcquireDeck 或 AcquireShuffledDeck 取得，使用完畢後調用 Release 歸還
+// 歸還後不可再使用 Cards，也不可保留其引用
+type ReusableDeck struct {
+	Cards []Card
+}
+
+// AcquireDeck 從池中取得一副按標準順序排列的牌組
+func AcquireDeck() *ReusableDeck {
+	d := deckPool.Get().(*ReusableDeck)
+	// 調用者可能在歸還前縮減了 Cards 的容量，容量不足時重新分配
+	if cap(d.Cards) < StandardDeckTemplate.Len() {
+		d.Cards = make([]Card, StandardDeckTemplate.Len())
+	}
+	d.Cards = d.Cards[:StandardDeckTemplate.Len()]
+	copy(d.Cards, StandardDeckTemplate.cards)
+	return d
+}
+
+// AcquireShuffledDeck 從池中取得使用最新drand隨機信標洗牌後的牌組
+// 洗牌結果與 GetShuffledDeck 相同，返回牌組和使用的輪次號碼
+func AcquireShuffledDeck(gameSessionID string) (*ReusableDeck, uint64, error) {
+	// 獲取 DrandManager 實例
+	drandManager, err := GetDrandManager()
+	if err != nil {
+		return nil, 0, fmt.Errorf("無法初始化 DrandManager: %w", err)
+	}
+	if err := drandManager.checkLatestAllowed(); err != nil {
+		return nil, 0, err
+	}
+
+	// 獲取最新的隨機性和輪次號碼
+	randomness, round, err := drandManager.GetLatestRandomness()
+	if err != nil {
+		return nil, 0, fmt.Errorf("無法獲取最新隨機性: %w", err)
+	}
+
+	d := deckPool.Get().(*ReusableDeck)
+	d.Cards = defaultShuffler.ShuffleInto(d.Cards, randomness, gameSessionID)
+	Zeroize(randomness)
+	drandManager.observeDealLatency(round)
+
+	return d, round, nil
+}
+
+// Shuffle 使用Fisher-Yates算法原地洗牌
+func (d *ReusableDeck) Shuffle(randomness []byte) {
+	shuffleInPlace(d.Cards, randomness)
+}
+
+// Release 將牌組歸還到池中
+func (d *ReusableDeck) Release() {
+	deckPool.Put(d)
+}
@@ -0,0 +1,43 @@
+// Package drandshuffle 使用 drand 公開隨機信標進行可驗證的公平洗牌
+//
+// 每次洗牌由 drand 某一輪的隨機性和調用者提供的遊戲局號共同決定：
+// 相同的輪次和遊戲局號總是得到相同的牌組，任何人都可以用公開的輪次號碼和遊戲局號重現並驗證發牌結果，
+// 而在該輪次發布之前沒有人能預測結果。
+//
+// # 使用方式
+//
+// Client 是主要入口，持有一個 DrandManager，負責連接中繼、緩存隨機信標和後台輪詢：
+//
+//	client, err := drandshuffle.NewClient()
+//	if err != nil {
+//		return err
+//	}
+//	defer client.Close()
+//
+//	deck, round, err := client.ShuffleLatest(ctx, sessionID)
+//
+// 記錄 round 和 sessionID 之後，可以用 Client.Verify 或 Shuffler 重現同一副牌。
+// 依賴單例的 GetShuffledDeck 和 GetShuffledDeckByRound 仍然可用，但已棄用。
+//
+// # 配置
+//
+// 中繼地址、鏈哈希、超時和緩存容量等設定由 Config 描述，可以通過 With 選項修改，
+// 或用 LoadConfig 從配置文件和環境變量載入。
+//
+// # 錯誤
+//
+// 返回的錯誤可以用 errors.Is 匹配 ErrBeaconUnavailable、ErrRoundNotFound 等哨兵錯誤，
+// 並按網絡、驗證和輸入分類；IsRetryable 報告重試是否可能成功。
+//
+// # 測試
+//
+// 子包 drandshuffletest 提供不依賴網絡的假隨機信標源和檢查牌組的輔助函數。
+// 輪次的發布時間、信標年齡和輪詢調度都通過 Clock 取得當前時間，
+// 注入 drandshuffletest.Clock 後可以不等待真實時間地測試 WaitForRound 和後台輪詢。
+//
+// # 兼容性
+//
+// 本包和 drandshuffletest 遵循語義化版本。v1 的導出 API 記錄在倉庫的 api/v1.txt 中，
+// 其中的項目在 v1 期間不會被移除或修改簽名；標記為 Deprecated 的項目同樣保留到 v2。
+// 洗牌算法的輸出屬於兼容性保證的一部分：相同的隨機性和遊戲局號在所有 v1 版本中得到相同的牌組。
+package drandshuffle
@@ -2,230 +2,869 @@ package drandshuffle
 
 import (
 	"context"
-	"encoding/hex"
+	"errors"
 	"fmt"
-	"log"
-	"sort"
+	"log/slog"
+	nethttp "net/http"
+	"runtime/trace"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/drand/drand/v2/common"
+	"github.com/drand/drand/v2/common/chain"
 	"github.com/drand/go-clients/client"
 	"github.com/drand/go-clients/client/http"
 	"github.com/drand/go-clients/drand"
+	oteltrace "go.opentelemetry.io/otel/trace"
 )
 
 // DrandManager 管理 drand 隨機信標的獲取和緩存
+//
+// DrandManager 的所有方法都可以被多個 goroutine 並發調用：
+//   - StartBackgroundFetching 和 StopBackgroundFetching 可以重複或交替調用，
+//     StopBackgroundFetching 返回時後台獲取已經停止，進行中的請求會被取消
+//   - Close 可以重複調用，之後的調用等待第一次關閉完成後返回；
+//     關閉後 StartBackgroundFetching 不再生效，需要網絡請求的方法返回包裝 ErrClosed 的錯誤，
+//     已緩存的隨機信標仍可讀取
 type DrandManager struct {
-	client       drand.Client
-	latestBeacon drand.Result
-	beaconCache  map[uint64]drand.Result
-	mutex        sync.RWMutex
-	stopChan     chan struct{}
-	isRunning    bool
+	config Config
+	client drand.Client
+	// 最新的隨機信標，讀取不需要加鎖；寫入仍在 mutex 保護下進行以保證輪次單調遞增
+	latestBeacon atomic.Pointer[latestEntry]
+	// mutex 只用於保護緩存維護和運行狀態
+	beaconCache *beaconCache
+	mutex       sync.RWMutex
+	isRunning   bool
+	// 取消後台獲取和後台獲取退出時關閉的通道，只在運行時有效
+	stopPolling context.CancelFunc
+	pollDone    chan struct{}
+
+	// 鏈信息，用於計算下一輪隨機信標的發佈時間；無法獲取時為 nil
+	// 熱啟動時在後台連接成功後才寫入，因此使用原子指針
+	chainInfo atomic.Pointer[chain.Info]
+	// 通過 WithChainInfo 或鏈信息文件固定的鏈信息，為 nil 時從中繼獲取
+	pinnedInfo *chain.Info
+
+	// drand 之外的隨機性來源的標識，為空時表示 drand，見 NewDrandManagerWithProvider
+	provider string
+
+	// 連接中繼使用的 HTTP Transport，為 nil 時使用默認值
+	transport nethttp.RoundTripper
+
+	// 注入中繼請求的故障，只在以 drandshufflefaults 構建標籤編譯時可以設定
+	faults faultInjector
+
+	// 推算輪次時間和調度輪詢使用的時鐘
+	clock Clock
+
+	// 洗牌結果緩存，為 nil 時不緩存
+	shuffleCache *ShuffleCache
+
+	// 對沖請求使用的各中繼客戶端
+	relays []drand.Client
+
+	// 進行中的輪次獲取請求，用於合併同一輪次的並發請求
+	inflight      map[uint64]*roundCall
+	inflightMutex sync.Mutex
+
+	// 從輪次發佈到交付洗牌結果的延遲
+	dealLatency *LatencyHistogram
+
+	// 獲取最新隨機信標的成功和失敗統計
+	fetchStats fetchStats
+
+	// 持久化存儲，為 nil 時不保存
+	store BeaconStore
+	// 存儲的保留策略和壓縮間隔，未設定時不壓縮；stopCompaction 停止後台壓縮，未啟動時為 nil
+	retention         *RetentionPolicy
+	retentionInterval time.Duration
+	stopCompaction    func()
+
+	// 日誌記錄器，默認不輸出
+	logger *slog.Logger
+
+	// 後台獲取發現輪次缺口時調用的回調，為 nil 時不調用
+	gapHandler func(BeaconGap)
+
+	// 按租戶統計派生次數，為 nil 時不統計
+	usage *UsageMeter
+
+	// 優先於內置消息目錄的翻譯，為 nil 時只使用內置消息目錄
+	translator Translator
+
+	// 記錄 OpenTelemetry span 的 Tracer，為 nil 時不記錄
+	tracer oteltrace.Tracer
+
+	// 新信標的訂閱者
+	subscribers     map[chan Beacon]struct{}
+	subscriberMutex sync.Mutex
+
+	// 獲取到即時信標和關閉時分別關閉的通道
+	ready     chan struct{}
+	readyOnce sync.Once
+	closed    chan struct{}
+	closeOnce sync.Once
 }
 
+// latestEntry 保存最新的隨機信標及其首次獲取的時間
+type latestEntry struct {
+	result    drand.Result
+	fetchedAt time.Time
+}
+
+// roundCall 表示一個進行中的輪次獲取請求，完成後關閉 done
+type roundCall struct {
+	done   chan struct{}
+	result drand.Result
+	err    error
+}
+
+const (
+	// defaultPollInterval 無法獲取鏈信息時使用的固定輪詢間隔
+	defaultPollInterval = 3 * time.Second
+	// pollMargin 預期發佈時間之後再等待的時間，給中繼留出傳播新信標的餘裕
+	pollMargin = 200 * time.Millisecond
+	// minPollDelay 最短輪詢間隔，避免在中繼落後時頻繁重試
+	minPollDelay = 500 * time.Millisecond
+	// maxWarmUpDelay 熱啟動時重試連接的最長間隔
+	maxWarmUpDelay = 30 * time.Second
+)
+
 var (
-	// 單例實例
-	instance *DrandManager
-	once     sync.Once
+	// 單例實例及其創建時的錯誤
+	instance    *DrandManager
+	instanceErr error
+	once        sync.Once
 )
 
 // GetDrandManager 返回 DrandManager 的單例實例
-func GetDrandManager() (*DrandManager, error) {
-	var initErr error
+// 選項只在首次調用、創建單例時生效，之後的調用會忽略傳入的選項；首次創建失敗時之後的調用返回同一個錯誤
+func GetDrandManager(opts ...Option) (*DrandManager, error) {
 	once.Do(func() {
-		instance = &DrandManager{
-			beaconCache: make(map[uint64]drand.Result),
-			stopChan:    make(chan struct{}),
-		}
-		initErr = instance.initialize()
+		instance, instanceErr = NewDrandManager(opts...)
 	})
-	return instance, initErr
+	return instance, instanceErr
+}
+
+// NewDrandManager 使用指定選項創建新的 DrandManager
+// 不經過單例，適用於需要自定義配置的場景
+func NewDrandManager(opts ...Option) (*DrandManager, error) {
+	dm, err := newDrandManager(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := dm.initialize(); err != nil {
+		return nil, err
+	}
+
+	dm.startCompaction()
+	return dm, nil
+}
+
+// NewDrandManagerWithClient 使用已創建的 drand 客戶端創建 DrandManager
+// 不經過單例，適用於自定義客戶端或測試；配置中的鏈哈希和中繼 URL 不會被使用
+func NewDrandManagerWithClient(c drand.Client, opts ...Option) (*DrandManager, error) {
+	dm, err := newDrandManager(opts)
+	if err != nil {
+		return nil, err
+	}
+	dm.client = c
+
+	if dm.config.WarmStart {
+		dm.loadStore()
+		go dm.warmUp()
+		dm.startCompaction()
+		return dm, nil
+	}
+
+	ctx, cancel := dm.initContext()
+	defer cancel()
+
+	dm.loadChainInfo(ctx)
+
+	// 獲取初始隨機信標
+	if err := dm.fetchLatestBeacon(ctx); err != nil {
+		return nil, fmt.Errorf("無法獲取初始隨機信標: %w", err)
+	}
+
+	dm.startCompaction()
+	return dm, nil
+}
+
+// newDrandManager 應用選項並檢查配置，創建未初始化客戶端的 DrandManager
+func newDrandManager(opts []Option) (*DrandManager, error) {
+	dm := &DrandManager{
+		config:   DefaultConfig(),
+		clock:    systemClock{},
+		inflight: make(map[uint64]*roundCall),
+		ready:    make(chan struct{}),
+		closed:   make(chan struct{}),
+
+		subscribers: make(map[chan Beacon]struct{}),
+	}
+	for _, opt := range opts {
+		opt(dm)
+	}
+	if dm.faults != nil {
+		dm.transport = faultTransport{base: dm.transport, faults: dm.faults}
+	}
+
+	info, err := dm.config.resolveChain(dm.pinnedInfo)
+	if err != nil {
+		return nil, err
+	}
+	if err := dm.config.Validate(); err != nil {
+		return nil, err
+	}
+	if dm.pinnedInfo = info; info != nil {
+		dm.chainInfo.Store(info)
+	}
+
+	dm.beaconCache = newBeaconCache(dm.config.CacheSize)
+	dm.dealLatency = NewLatencyHistogram(dm.config.DealLatencyBuckets...)
+	dm.logger = dm.config.Logger
+	if dm.logger == nil {
+		dm.logger = discardLogger
+	}
+	if wb, ok := dm.store.(*WriteBehindStore); ok {
+		wb.useLogger(dm.logger)
+	}
+	return dm, nil
 }
 
 // initialize 初始化 drand 客戶端
 func (dm *DrandManager) initialize() error {
-	// 設定 drand 客戶端
-	urls := []string{"https://api.drand.sh", "https://drand.cloudflare.com"}
+	urls := dm.config.URLs
+	chainHash := dm.config.chainHashBytes()
 
-	// 使用 quicknet 鏈的哈希值
-	chainHash, err := hex.DecodeString("52db9ba70e0cc0f6eaf7803dd07447a1f5477735fd3f661792ba94600c84e971")
-	if err != nil {
-		return fmt.Errorf("無法解碼鏈哈希: %v", err)
+	connect := func(ctx context.Context) (drand.Client, error) {
+		return dm.connect(ctx, urls, chainHash, dm.pinnedInfo)
+	}
+
+	// 熱啟動時在首次請求時才連接中繼，不阻塞創建
+	if dm.config.WarmStart {
+		dm.client = newLazyClient(connect)
+		dm.loadStore()
+		go dm.warmUp()
+		return nil
 	}
 
-	// 創建上下文
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := dm.initContext()
 	defer cancel()
 
+	connectCtx, connectCancel := context.WithTimeout(ctx, dm.config.ConnectTimeout)
+	var err error
+	dm.client, err = connect(connectCtx)
+	connectCancel()
+	if err != nil {
+		return err
+	}
+
+	dm.loadChainInfo(ctx)
+
+	// 對沖請求需要每個中繼各自的驗證客戶端
+	if dm.config.Hedged && dm.relays == nil {
+		info := dm.chainInfo.Load()
+		if info == nil {
+			return networkError(fmt.Errorf("無法獲取鏈信息"))
+		}
+		dm.relays, err = newHedgedRelays(urls, info, dm.transport)
+		if err != nil {
+			return err
+		}
+	}
+
+	// 獲取初始隨機信標
+	err = dm.fetchLatestBeacon(ctx)
+	if err != nil {
+		return fmt.Errorf("無法獲取初始隨機信標: %w", err)
+	}
+
+	return nil
+}
+
+// initContext 返回創建時初始化使用的上下文，設定了 InitTimeout 時限制總時間
+func (dm *DrandManager) initContext() (context.Context, context.CancelFunc) {
+	if dm.config.InitTimeout > 0 {
+		return context.WithTimeout(context.Background(), dm.config.InitTimeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// connect 連接各中繼並創建聚合客戶端，info 不為 nil 時以其驗證隨機信標，不從中繼獲取鏈信息
+func (dm *DrandManager) connect(ctx context.Context, urls []string, chainHash []byte, info *chain.Info) (drand.Client, error) {
 	// 創建 drand 客戶端
-	clients := http.ForURLs(ctx, nil, urls, chainHash)
+	clients := newRelayClients(ctx, urls, chainHash, info, dm.transport)
 	if len(clients) == 0 {
-		return fmt.Errorf("無法創建 drand 客戶端")
+		return nil, networkError(fmt.Errorf("無法創建 drand 客戶端"))
+	}
+
+	trust := client.WithChainHash(chainHash)
+	if info != nil {
+		trust = client.WithChainInfo(info)
 	}
 
 	// 使用 client.New 創建聚合客戶端
-	dm.client, err = client.New(
+	c, err := client.New(
 		client.From(clients...),
-		client.WithChainHash(chainHash),
+		trust,
 	)
+	if err != nil {
+		return nil, networkError(fmt.Errorf("無法創建 drand 客戶端: %w", err))
+	}
+	return c, nil
+}
 
+// loadStore 從持久化存儲載入隨機信標到緩存，並以其中輪次最大的信標作為最新信標
+// 載入的信標沒有獲取時間，在 GetLatestRandomnessWithin 中總是被視為過期
+func (dm *DrandManager) loadStore() {
+	if dm.store == nil {
+		return
+	}
+
+	beacons, err := dm.store.Load()
 	if err != nil {
-		return fmt.Errorf("無法創建 drand 客戶端: %v", err)
+		dm.logger.Warn("無法載入保存的隨機信標", slog.Any("error", err))
+		return
 	}
 
-	// 獲取初始隨機信標
-	err = dm.fetchLatestBeacon()
+	dm.mutex.Lock()
+	defer dm.mutex.Unlock()
+
+	var latest *Beacon
+	for i := range beacons {
+		dm.beaconCache.put(beacons[i])
+		if latest == nil || beacons[i].Round > latest.Round {
+			latest = &beacons[i]
+		}
+	}
+	if latest != nil {
+		dm.latestBeacon.Store(&latestEntry{result: *latest})
+	}
+}
+
+// saveBeacon 將新獲取的隨機信標寫入持久化存儲，失敗時只記錄警告
+func (dm *DrandManager) saveBeacon(result drand.Result) {
+	if dm.store == nil {
+		return
+	}
+	var err error
+	if p := dm.catchPanic("beacon_store", func() { err = dm.store.Save(newBeacon(result)) }); p != nil {
+		err = p
+	}
 	if err != nil {
-		return fmt.Errorf("無法獲取初始隨機信標: %v", err)
+		dm.logger.Warn("無法保存隨機信標", slog.Uint64("round", result.GetRound()), slog.Any("error", err))
 	}
+}
 
-	return nil
+// warmUp 在後台重試獲取最新隨機信標直到成功或 DrandManager 關閉，重試間隔逐次加倍
+func (dm *DrandManager) warmUp() {
+	delay := minPollDelay
+	for {
+		err := dm.fetchLatestBeacon(context.Background())
+		if err == nil {
+			if dm.chainInfo.Load() == nil {
+				dm.loadChainInfo(context.Background())
+			}
+			return
+		}
+		dm.logger.Warn("熱啟動時無法獲取隨機信標，稍後重試", slog.Duration("retry_in", delay), slog.Any("error", err))
+
+		select {
+		case <-dm.clock.After(delay):
+		case <-dm.closed:
+			return
+		}
+		if delay *= 2; delay > maxWarmUpDelay {
+			delay = maxWarmUpDelay
+		}
+	}
 }
 
-// StartBackgroundFetching 開始後台獲取隨機信標
+// Ready 返回在獲取到第一個即時隨機信標後關閉的通道
+// 非熱啟動模式下創建成功時已經就緒；熱啟動時從存儲載入的信標不算即時信標
+func (dm *DrandManager) Ready() <-chan struct{} {
+	return dm.ready
+}
+
+// loadChainInfo 獲取並保存鏈信息，失敗時保持為 nil，輪詢退回固定間隔；已固定鏈信息時不做任何事
+// ctx 沒有截止時間時最多等待 FetchTimeout
+func (dm *DrandManager) loadChainInfo(ctx context.Context) {
+	if dm.pinnedInfo != nil {
+		return
+	}
+	ctx, cancel := withDefaultTimeout(ctx, dm.config.FetchTimeout)
+	defer cancel()
+
+	info, err := dm.client.Info(ctx)
+	if err != nil || info == nil || info.Period <= 0 {
+		return
+	}
+	dm.chainInfo.Store(info)
+}
+
+// Config 返回創建時生效的配置
+func (dm *DrandManager) Config() Config {
+	cfg := dm.config
+	cfg.URLs = append([]string(nil), cfg.URLs...)
+	cfg.DealLatencyBuckets = append([]time.Duration(nil), cfg.DealLatencyBuckets...)
+	return cfg
+}
+
+// ChainInfo 返回鏈信息，尚未獲取時返回 nil
+// 可用於創建 Verifier 驗證從其他來源取得的隨機信標
+func (dm *DrandManager) ChainInfo() *chain.Info {
+	return dm.chainInfo.Load()
+}
+
+// newRelayClients 為每個中繼 URL 創建 HTTP 客戶端，跳過無法連接的中繼
+// info 為 nil 時鏈信息只從第一個可用的中繼獲取一次，其餘中繼直接使用
+func newRelayClients(ctx context.Context, urls []string, chainHash []byte, info *chain.Info, transport nethttp.RoundTripper) []drand.Client {
+	clients := make([]drand.Client, 0, len(urls))
+	var skipped []string
+
+	for _, url := range urls {
+		if info != nil {
+			if c, err := http.NewWithInfo(nil, url, info, transport); err == nil {
+				clients = append(clients, c)
+			}
+			continue
+		}
+
+		c, err := http.New(ctx, nil, url, chainHash, transport)
+		if err != nil {
+			skipped = append(skipped, url)
+			continue
+		}
+		info, _ = c.Info(ctx)
+		clients = append(clients, c)
+	}
+
+	// 獲取鏈信息之前失敗的中繼，使用已知的鏈信息重試
+	if info != nil {
+		for _, url := range skipped {
+			if c, err := http.NewWithInfo(nil, url, info, transport); err == nil {
+				clients = append(clients, c)
+			}
+		}
+	}
+
+	return clients
+}
+
+// newHedgedRelays 為每個中繼 URL 創建獨立的驗證客戶端
+// 不與聚合客戶端共享底層 HTTP 客戶端，避免關閉時重複釋放
+func newHedgedRelays(urls []string, info *chain.Info, transport nethttp.RoundTripper) ([]drand.Client, error) {
+	relays := make([]drand.Client, 0, len(urls))
+	for _, url := range urls {
+		hc, err := http.NewWithInfo(nil, url, info, transport)
+		if err == nil {
+			var relay drand.Client
+			relay, err = client.New(
+				client.From(hc),
+				client.WithChainInfo(info),
+				client.WithCacheSize(0),
+			)
+			if err == nil {
+				relays = append(relays, relay)
+				continue
+			}
+		}
+
+		for _, relay := range relays {
+			relay.Close()
+		}
+		return nil, fmt.Errorf("無法創建中繼 %s 的客戶端: %w", url, err)
+	}
+	return relays, nil
+}
+
+// StartBackgroundFetching 開始後台獲取隨機信標，已經在運行或 DrandManager 已關閉時不做任何事
 func (dm *DrandManager) StartBackgroundFetching() {
 	dm.mutex.Lock()
-	if dm.isRunning {
+	if dm.isRunning || dm.isClosed() {
 		dm.mutex.Unlock()
 		return
 	}
+	ctx, cancel := context.WithCancel(context.Background())
 	dm.isRunning = true
+	dm.stopPolling = cancel
+	dm.pollDone = make(chan struct{})
+	go dm.pollLoop(ctx, dm.pollDone)
 	dm.mutex.Unlock()
 
-	go func() {
-		ticker := time.NewTicker(3 * time.Second)
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-ticker.C:
-				err := dm.fetchLatestBeacon()
-				if err != nil {
-					log.Printf("警告: 無法獲取最新隨機信標: %v", err)
-				} else {
-					log.Printf("成功獲取輪次 %d 的隨機信標", dm.latestBeacon.GetRound())
-				}
-			case <-dm.stopChan:
+	dm.logger.Info("已啟動後台 drand 隨機信標獲取服務", dm.chainAttr())
+}
+
+// pollLoop 在每一輪隨機信標預期發佈後獲取最新信標，直到 ctx 被取消，退出時關閉 done
+func (dm *DrandManager) pollLoop(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	// 這次運行中上一次成功獲取後的最新輪次，用於發現缺口；停止期間錯過的輪次不算缺口
+	var prev uint64
+	wait := dm.clock.After(dm.nextPollDelay())
+	for {
+		select {
+		case <-wait:
+			err := dm.fetchLatestBeacon(ctx)
+			if ctx.Err() != nil {
+				// 停止時取消了進行中的請求，不算獲取失敗
 				return
 			}
+			if err != nil {
+				dm.logger.Warn("無法獲取最新隨機信標", dm.chainAttr(), slog.Any("error", err))
+			} else if latest := dm.latestBeacon.Load(); latest != nil {
+				// 成功獲取每輪都會發生，只在 Debug 級別記錄，監控應使用 Health
+				round := latest.result.GetRound()
+				dm.logger.Debug("成功獲取隨機信標", dm.chainAttr(), slog.Uint64("round", round))
+				dm.detectGaps(prev, round)
+				prev = round
+			}
+			wait = dm.clock.After(dm.nextPollDelay())
+		case <-ctx.Done():
+			return
 		}
-	}()
+	}
+}
+
+// nextPollDelay 計算距離下一次輪詢的時間
+// 根據創世時間和週期推算下一輪的發佈時間，在其之後稍作等待再獲取，
+// 避免在兩輪之間發出無效的請求；無法獲取鏈信息時使用固定間隔
+func (dm *DrandManager) nextPollDelay() time.Duration {
+	latest := dm.latestBeacon.Load()
+	info := dm.chainInfo.Load()
+	if info == nil || latest == nil {
+		return defaultPollInterval
+	}
 
-	log.Println("已啟動後台 drand 隨機信標獲取服務")
+	next := common.TimeOfRound(info.Period, info.GenesisTime, latest.result.GetRound()+1)
+	if next == common.TimeOfRoundErrorValue {
+		return defaultPollInterval
+	}
+
+	delay := time.Unix(next, 0).Sub(dm.clock.Now()) + pollMargin
+	if delay < minPollDelay {
+		// 已經過了預期發佈時間但仍未獲取到新信標，稍後重試
+		return minPollDelay
+	}
+	return delay
 }
 
-// StopBackgroundFetching 停止後台獲取隨機信標
+// StopBackgroundFetching 停止後台獲取隨機信標，返回時後台獲取已經退出
 func (dm *DrandManager) StopBackgroundFetching() {
 	dm.mutex.Lock()
-	defer dm.mutex.Unlock()
-
 	if !dm.isRunning {
+		dm.mutex.Unlock()
 		return
 	}
-
-	dm.stopChan <- struct{}{}
+	dm.stopPolling()
+	done := dm.pollDone
 	dm.isRunning = false
-	log.Println("已停止後台 drand 隨機信標獲取服務")
+	dm.stopPolling, dm.pollDone = nil, nil
+	dm.mutex.Unlock()
+
+	// 後台獲取更新緩存時需要 mutex，必須在釋放後才等待其退出
+	<-done
+	dm.logger.Info("已停止後台 drand 隨機信標獲取服務", dm.chainAttr())
 }
 
 // fetchLatestBeacon 獲取最新的隨機信標
-func (dm *DrandManager) fetchLatestBeacon() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+// ctx 沒有截止時間時請求最多等待 FetchLatestTimeout，否則以 ctx 的截止時間為準
+func (dm *DrandManager) fetchLatestBeacon(ctx context.Context) error {
+	if dm.isClosed() {
+		return fmt.Errorf("%w: 無法獲取最新隨機信標", ErrClosed)
+	}
+
+	ctx, cancel := withDefaultTimeout(ctx, dm.config.fetchLatestTimeout())
 	defer cancel()
 
-	result, err := dm.client.Get(ctx, 0)
+	hedged := dm.config.Hedged && len(dm.relays) > 0
+	ctx, span := dm.startSpan(ctx, spanFetchLatest, attrHedged.Bool(hedged))
+	region := trace.StartRegion(ctx, "drandshuffle.fetchLatestBeacon")
+	var result drand.Result
+	var err error
+	if hedged {
+		result, err = dm.getLatestHedged(ctx)
+	} else {
+		result, err = dm.client.Get(ctx, 0)
+	}
+	region.End()
+	if err == nil {
+		span.SetAttributes(attrRound.Int64(int64(result.GetRound())))
+	}
 	if err != nil {
-		return fmt.Errorf("無法獲取最新隨機信標: %v", err)
+		err = networkError(fmt.Errorf("%w: 無法獲取最新隨機信標: %w", ErrBeaconUnavailable, err))
+		endSpan(span, err)
+		// 調用者主動取消的請求不算獲取失敗
+		if !errors.Is(err, context.Canceled) {
+			dm.fetchStats.recordFailure(dm.clock.Now(), err)
+		}
+		return err
 	}
+	endSpan(span, nil)
+	dm.fetchStats.recordSuccess(dm.clock.Now())
+	dm.readyOnce.Do(func() { close(dm.ready) })
 
 	dm.mutex.Lock()
-	defer dm.mutex.Unlock()
-
 	// 檢查是否已經有這個輪次的信標
-	if dm.latestBeacon != nil && dm.latestBeacon.GetRound() >= result.GetRound() {
+	if latest := dm.latestBeacon.Load(); latest != nil && latest.result.GetRound() >= result.GetRound() {
+		dm.mutex.Unlock()
 		return nil // 已經有更新或相同的信標，不需要更新
 	}
 
-	dm.latestBeacon = result
-	dm.beaconCache[result.GetRound()] = result
+	dm.latestBeacon.Store(&latestEntry{result: result, fetchedAt: dm.clock.Now()})
+	// 緩存已滿時自動淘汰最舊的項目
+	dm.beaconCache.put(result)
+	dm.mutex.Unlock()
 
-	// 清理舊的緩存，只保留最近 100 個
-	const maxCacheSize = 100
-	if len(dm.beaconCache) > maxCacheSize {
-		// 收集所有輪次號碼
-		rounds := make([]uint64, 0, len(dm.beaconCache))
-		for round := range dm.beaconCache {
-			rounds = append(rounds, round)
-		}
+	dm.saveBeacon(result)
+	dm.publish(result)
+	return nil
+}
 
-		// 按輪次號碼排序
-		sort.Slice(rounds, func(i, j int) bool {
-			return rounds[i] < rounds[j]
-		})
+// getLatestHedged 同時向所有中繼請求最新隨機信標，返回最先到達的有效結果
+// 返回後會取消其餘仍在進行中的請求
+func (dm *DrandManager) getLatestHedged(ctx context.Context) (drand.Result, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-		// 計算需要刪除的數量
-		deleteCount := len(dm.beaconCache) - maxCacheSize
+	type reply struct {
+		result drand.Result
+		err    error
+	}
 
-		// 刪除最舊的 deleteCount 個項目
-		for i := 0; i < deleteCount; i++ {
-			delete(dm.beaconCache, rounds[i])
-		}
+	// 使用緩衝通道，確保較慢的請求在返回後不會阻塞
+	replies := make(chan reply, len(dm.relays))
+	for _, relay := range dm.relays {
+		go func(relay drand.Client) {
+			result, err := relay.Get(ctx, 0)
+			replies <- reply{result: result, err: err}
+		}(relay)
+	}
 
-		log.Printf("已清理 %d 個舊的隨機信標緩存", deleteCount)
+	var lastErr error
+	for range dm.relays {
+		r := <-replies
+		if r.err != nil {
+			lastErr = r.err
+			continue
+		}
+		if r.result == nil || len(r.result.GetRandomness()) == 0 {
+			lastErr = fmt.Errorf("中繼返回了無效的隨機信標")
+			continue
+		}
+		return r.result, nil
 	}
 
-	return nil
+	return nil, fmt.Errorf("所有中繼請求均失敗: %w", lastErr)
 }
 
 // GetLatestRandomness 獲取最新的隨機性和輪次號碼
+// 此方法不加鎖，適合高頻率調用
+// 返回的切片是緩存內容的副本，歸調用者所有，可以自由修改或使用 Zeroize 清除
 func (dm *DrandManager) GetLatestRandomness() ([]byte, uint64, error) {
-	dm.mutex.RLock()
-	defer dm.mutex.RUnlock()
-
 	// 檢查是否已獲取隨機信標
-	if dm.latestBeacon == nil {
-		return nil, 0, fmt.Errorf("尚未獲取任何隨機信標")
+	latest := dm.latestBeacon.Load()
+	if latest == nil {
+		return nil, 0, networkError(fmt.Errorf("%w: 尚未獲取任何隨機信標", ErrBeaconUnavailable))
 	}
 
-	return dm.latestBeacon.GetRandomness(), dm.latestBeacon.GetRound(), nil
+	return copyBytes(latest.result.GetRandomness()), latest.result.GetRound(), nil
+}
+
+// GetLatestRandomnessWithin 在限定時間內獲取最新的隨機性和輪次號碼，使發牌延遲有確定的上限
+// 緩存的信標不超過 maxAge 時立即返回；否則在 budget 內嘗試獲取新信標，
+// 獲取失敗或超時則退回使用緩存的信標；ctx 的截止時間早於 budget 時以 ctx 為準
+// 只有在從未獲取過任何信標時才返回錯誤
+func (dm *DrandManager) GetLatestRandomnessWithin(ctx context.Context, maxAge, budget time.Duration) ([]byte, uint64, error) {
+	if latest := dm.latestBeacon.Load(); latest != nil && dm.beaconAge(latest) <= maxAge {
+		return copyBytes(latest.result.GetRandomness()), latest.result.GetRound(), nil
+	}
+
+	fetchCtx, cancel := context.WithTimeout(ctx, budget)
+	err := dm.fetchLatestBeacon(fetchCtx)
+	cancel()
+	if err != nil {
+		dm.logger.Warn("無法在限定時間內獲取最新隨機信標，使用緩存的信標", slog.Duration("budget", budget), slog.Any("error", err))
+	}
+
+	return dm.GetLatestRandomness()
+}
+
+// beaconAge 返回隨機信標的年齡
+// 已知鏈信息時以該輪的發佈時間計算，否則以首次獲取的時間計算
+func (dm *DrandManager) beaconAge(entry *latestEntry) time.Duration {
+	if published, ok := dm.RoundTime(entry.result.GetRound()); ok {
+		return dm.since(published)
+	}
+	return dm.since(entry.fetchedAt)
 }
 
 // GetRandomnessByRound 獲取指定輪次的隨機性
+// 返回的切片是緩存內容的副本，歸調用者所有，可以自由修改或使用 Zeroize 清除
 func (dm *DrandManager) GetRandomnessByRound(round uint64) ([]byte, error) {
 	dm.mutex.RLock()
 
 	// 檢查緩存
-	if beacon, ok := dm.beaconCache[round]; ok {
-		randomness := beacon.GetRandomness()
+	if beacon, ok := dm.beaconCache.get(round); ok {
+		randomness := copyBytes(beacon.GetRandomness())
 		dm.mutex.RUnlock()
 		return randomness, nil
 	}
 	dm.mutex.RUnlock()
 
 	// 緩存中沒有，從網絡獲取
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	result, err := dm.client.Get(ctx, round)
+	result, err := dm.fetchRound(context.Background(), round)
 	if err != nil {
-		return nil, fmt.Errorf("無法獲取輪次 %d 的隨機信標: %v", round, err)
+		return nil, err
 	}
 
-	// 更新緩存
-	dm.mutex.Lock()
-	dm.beaconCache[round] = result
-	dm.mutex.Unlock()
+	return copyBytes(result.GetRandomness()), nil
+}
 
-	return result.GetRandomness(), nil
+// beaconByRound 從緩存或網絡獲取指定輪次的隨機信標，ctx 取消時不再等待
+func (dm *DrandManager) beaconByRound(ctx context.Context, round uint64) (drand.Result, error) {
+	_, span := dm.startSpan(ctx, spanCacheLookup, attrCache.String("beacon"), attrRound.Int64(int64(round)))
+	dm.mutex.RLock()
+	result, ok := dm.beaconCache.get(round)
+	dm.mutex.RUnlock()
+	span.SetAttributes(attrCacheHit.Bool(ok))
+	span.End()
+	if ok {
+		return result, nil
+	}
+
+	return dm.fetchRound(ctx, round)
 }
 
-// Close 關閉 DrandManager
+// GetRandomnessRange 獲取 [from, to] 範圍內所有輪次的隨機性，按輪次順序返回
+// 返回的每個切片都是副本，歸調用者所有
+// 未緩存的輪次由固定數量的工作協程獲取，數量可通過 WithFetchConcurrency 設定，適用於回填歷史或批量驗證
+// 任一輪次獲取失敗時停止分派新的輪次並返回錯誤
+func (dm *DrandManager) GetRandomnessRange(from, to uint64) ([][]byte, error) {
+	if from == 0 || to < from {
+		return nil, NewInputError(fmt.Errorf("無效的輪次範圍: %d-%d", from, to))
+	}
+
+	defer trace.StartRegion(context.Background(), "drandshuffle.fetchRange").End()
+
+	results := make([][]byte, to-from+1)
+	var errOnce sync.Once
+	var firstErr error
+
+	dm.fetchRounds(context.Background(), from, to, func(round uint64, result drand.Result, err error) bool {
+		if err != nil {
+			errOnce.Do(func() { firstErr = err })
+			return false
+		}
+		results[round-from] = copyBytes(result.GetRandomness())
+		return true
+	})
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return results, nil
+}
+
+// fetchRound 從網絡獲取指定輪次的隨機信標並更新緩存
+// 輪次 0 和尚未發布的輪次不會發出請求，分別返回 ErrRoundBeforeGenesis 和 ErrFutureRound
+// 同一輪次的並發請求會被合併，只發出一次網絡請求並共享結果
+// 網絡請求不受 ctx 取消的影響，ctx 取消時只是不再等待，其他等待者仍能得到結果；
+// 請求的超時為發起請求的調用者 ctx 的截止時間，沒有截止時間時為 FetchByRoundTimeout
+func (dm *DrandManager) fetchRound(ctx context.Context, round uint64) (result drand.Result, err error) {
+	ctx, span := dm.startSpan(ctx, spanFetchRound, attrRound.Int64(int64(round)))
+	defer func() { endSpan(span, err) }()
+
+	if err := ctx.Err(); err != nil {
+		return nil, networkError(fmt.Errorf("無法獲取輪次 %d 的隨機信標: %w", round, err))
+	}
+	if dm.isClosed() {
+		return nil, fmt.Errorf("%w: 無法獲取輪次 %d 的隨機信標", ErrClosed, round)
+	}
+	if err := dm.checkRound(round); err != nil {
+		return nil, err
+	}
+
+	dm.inflightMutex.Lock()
+	call, ok := dm.inflight[round]
+	if !ok {
+		call = &roundCall{done: make(chan struct{})}
+		dm.inflight[round] = call
+		timeout := dm.config.fetchByRoundTimeout()
+		if deadline, ok := ctx.Deadline(); ok {
+			timeout = time.Until(deadline)
+		}
+		go dm.doFetchRound(round, timeout, call)
+	}
+	dm.inflightMutex.Unlock()
+	span.SetAttributes(attrCoalesced.Bool(ok))
+
+	select {
+	case <-call.done:
+		// 請求的計時器與 ctx 的計時器同時到期時可能先於 ctx 觸發，此時等待 ctx 結束，
+		// 使調用者得到 ctx 的錯誤，且返回後 ctx.Err() 一定不為 nil
+		if deadline, ok := ctx.Deadline(); ok && call.err != nil && !time.Now().Before(deadline) {
+			<-ctx.Done()
+			return nil, networkError(fmt.Errorf("無法獲取輪次 %d 的隨機信標: %w", round, ctx.Err()))
+		}
+		return call.result, call.err
+	case <-ctx.Done():
+		return nil, networkError(fmt.Errorf("無法獲取輪次 %d 的隨機信標: %w", round, ctx.Err()))
+	}
+}
+
+// doFetchRound 發出輪次獲取請求，完成後喚醒所有等待者
+func (dm *DrandManager) doFetchRound(round uint64, timeout time.Duration, call *roundCall) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	region := trace.StartRegion(ctx, "drandshuffle.fetchRound")
+	call.result, call.err = dm.client.Get(ctx, round)
+	region.End()
+	if call.err != nil {
+		call.err = networkError(fmt.Errorf("%w: 無法獲取輪次 %d 的隨機信標: %w", ErrRoundNotFound, round, call.err))
+	} else {
+		// 更新緩存
+		dm.mutex.Lock()
+		dm.beaconCache.put(call.result)
+		dm.mutex.Unlock()
+		dm.saveBeacon(call.result)
+	}
+
+	// 先移除進行中的記錄再喚醒等待者，之後的請求會直接命中緩存或重新獲取
+	dm.inflightMutex.Lock()
+	delete(dm.inflight, round)
+	dm.inflightMutex.Unlock()
+	close(call.done)
+}
+
+// Close 關閉 DrandManager，停止後台獲取和壓縮並關閉中繼客戶端和所有訂閱通道，可以重複調用
 func (dm *DrandManager) Close() {
-	if dm.isRunning {
+	dm.closeOnce.Do(func() {
+		close(dm.closed)
 		dm.StopBackgroundFetching()
-	}
-	if dm.client != nil {
-		dm.client.Close()
+		if dm.stopCompaction != nil {
+			dm.stopCompaction()
+		}
+		if dm.client != nil {
+			dm.client.Close()
+		}
+		for _, relay := range dm.relays {
+			relay.Close()
+		}
+		dm.closeSubscribers()
+	})
+}
+
+// isClosed 報告 DrandManager 是否已經關閉
+func (dm *DrandManager) isClosed() bool {
+	select {
+	case <-dm.closed:
+		return true
+	default:
+		return false
 	}
 }
@@ -0,0 +1,130 @@
+package drandshufflepb
+
+import (
+	"fmt"
+	"math"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+)
+
+// FromCard 將牌轉換為 Protobuf 消息
+func FromCard(card drandshuffle.Card) *Card {
+	return &Card{Suit: card.Suit, Value: card.Value}
+}
+
+// ToCard 將消息轉換為牌，nil 消息轉換為零值
+func (c *Card) ToCard() drandshuffle.Card {
+	return drandshuffle.Card{Suit: c.GetSuit(), Value: c.GetValue()}
+}
+
+// FromDeck 將牌組轉換為 Protobuf 消息，保留牌的順序
+func FromDeck(deck []drandshuffle.Card) *Deck {
+	msg := &Deck{Cards: make([]*Card, len(deck))}
+	for i, card := range deck {
+		msg.Cards[i] = FromCard(card)
+	}
+	return msg
+}
+
+// ToCards 將消息轉換為牌組，nil 消息轉換為空牌組
+func (d *Deck) ToCards() []drandshuffle.Card {
+	cards := make([]drandshuffle.Card, len(d.GetCards()))
+	for i, card := range d.GetCards() {
+		cards[i] = card.ToCard()
+	}
+	return cards
+}
+
+// FromBeacon 將隨機信標轉換為 Protobuf 消息，位元組切片會被複製
+func FromBeacon(beacon drandshuffle.Beacon) *Beacon {
+	return &Beacon{
+		Round:             beacon.Round,
+		Randomness:        clone(beacon.Randomness),
+		Signature:         clone(beacon.Signature),
+		PreviousSignature: clone(beacon.PreviousSignature),
+	}
+}
+
+// ToBeacon 將消息轉換為隨機信標，位元組切片會被複製
+func (b *Beacon) ToBeacon() drandshuffle.Beacon {
+	return drandshuffle.Beacon{
+		Round:             b.GetRound(),
+		Randomness:        clone(b.GetRandomness()),
+		Signature:         clone(b.GetSignature()),
+		PreviousSignature: clone(b.GetPreviousSignature()),
+	}
+}
+
+// FromProof 將洗牌證明轉換為 Protobuf 消息，proof 為 nil 時返回 nil
+// RoundTime 為零值時不設定 round_time
+func FromProof(proof *drandshuffle.ShuffleProof) *ShuffleProof {
+	if proof == nil {
+		return nil
+	}
+	msg := &ShuffleProof{
+		Algorithm:         proof.Algorithm,
+		ChainHash:         proof.ChainHash,
+		Round:             proof.Round,
+		Randomness:        clone(proof.Randomness),
+		Signature:         clone(proof.Signature),
+		PreviousSignature: clone(proof.PreviousSignature),
+		SessionId:         proof.SessionID,
+		Contributions:     make([][]byte, len(proof.Contributions)),
+		DeckSize:          uint32(proof.DeckSize),
+		PreviousDigest:    proof.PreviousDigest,
+		Provider:          proof.Provider,
+	}
+	if !proof.RoundTime.IsZero() {
+		msg.RoundTime = timestamppb.New(proof.RoundTime)
+	}
+	for i, c := range proof.Contributions {
+		msg.Contributions[i] = clone(c)
+	}
+	return msg
+}
+
+// ToProof 將消息轉換為洗牌證明，消息為 nil 或時間戳無效時返回錯誤
+func (p *ShuffleProof) ToProof() (*drandshuffle.ShuffleProof, error) {
+	if p == nil {
+		return nil, fmt.Errorf("缺少洗牌證明")
+	}
+	if uint64(p.DeckSize) > math.MaxInt32 {
+		return nil, fmt.Errorf("無效的牌組張數 %d", p.DeckSize)
+	}
+
+	proof := &drandshuffle.ShuffleProof{
+		Algorithm:         p.Algorithm,
+		ChainHash:         p.ChainHash,
+		Round:             p.Round,
+		Randomness:        clone(p.Randomness),
+		Signature:         clone(p.Signature),
+		PreviousSignature: clone(p.PreviousSignature),
+		SessionID:         p.SessionId,
+		DeckSize:          int(p.DeckSize),
+		PreviousDigest:    p.PreviousDigest,
+		Provider:          p.Provider,
+	}
+	if p.RoundTime != nil {
+		if err := p.RoundTime.CheckValid(); err != nil {
+			return nil, fmt.Errorf("無效的輪次時間: %w", err)
+		}
+		proof.RoundTime = p.RoundTime.AsTime()
+	}
+	if len(p.Contributions) > 0 {
+		proof.Contributions = make([][]byte, len(p.Contributions))
+		for i, c := range p.Contributions {
+			proof.Contributions[i] = clone(c)
+		}
+	}
+	return proof, nil
+}
+
+// clone 複製位元組切片，nil 和空切片都返回 nil
+func clone(b []byte) []byte {
+	if len(b) == 0 {
+		return nil
+	}
+	return append([]byte(nil), b...)
+}
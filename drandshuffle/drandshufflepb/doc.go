@@ -0,0 +1,12 @@
+// Package drandshufflepb 提供牌、牌組、隨機信標和洗牌證明的 Protobuf 消息及其與 drandshuffle 類型的轉換
+//
+// 消息定義在 drandshuffle.proto（包 drandshuffle.v1）中，其他語言的服務可以用它生成各自的類型，
+// 以二進制格式交換洗牌產物，不必依賴手寫的 JSON 轉換。修改 .proto 後運行 go generate 重新生成 Go 代碼。
+//
+//	msg := drandshufflepb.FromProof(result.Proof)
+//	data, err := proto.Marshal(msg)
+//	// ...
+//	proof, err := msg.ToProof()
+package drandshufflepb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative drandshuffle.proto
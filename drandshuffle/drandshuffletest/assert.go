@@ -0,0 +1,79 @@
+package drandshuffletest
+
+import (
+	"testing"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+)
+
+// NewManager 創建使用 src 的 DrandManager，創建失敗時終止測試，測試結束時自動關閉
+// src 跟隨時鐘時，DrandManager 也使用同一個時鐘，opts 中的 WithClock 優先
+func NewManager(t testing.TB, src *FakeBeaconSource, opts ...drandshuffle.Option) *drandshuffle.DrandManager {
+	t.Helper()
+
+	src.mu.Lock()
+	clock := src.clock
+	src.mu.Unlock()
+	if clock != nil {
+		opts = append([]drandshuffle.Option{drandshuffle.WithClock(clock)}, opts...)
+	}
+
+	dm, err := drandshuffle.NewDrandManagerWithClient(src, opts...)
+	if err != nil {
+		t.Fatalf("無法創建 DrandManager: %v", err)
+	}
+	t.Cleanup(dm.Close)
+	return dm
+}
+
+// ExpectedDeck 返回使用 src 中指定輪次的隨機性和遊戲局號洗出的標準牌組
+func ExpectedDeck(src *FakeBeaconSource, round uint64, gameSessionID string) []drandshuffle.Card {
+	return drandshuffle.NewShuffler(drandshuffle.StandardDeckTemplate).Shuffle(src.Randomness(round), gameSessionID)
+}
+
+// AssertDeck 檢查 deck 是否與使用 src 中指定輪次洗出的標準牌組一致，不一致時報告第一個不同的位置
+func AssertDeck(t testing.TB, src *FakeBeaconSource, round uint64, gameSessionID string, deck []drandshuffle.Card) bool {
+	t.Helper()
+
+	want := ExpectedDeck(src, round, gameSessionID)
+	if len(deck) != len(want) {
+		t.Errorf("牌組長度為 %d，預期為 %d", len(deck), len(want))
+		return false
+	}
+	for i := range want {
+		if deck[i] != want[i] {
+			t.Errorf("輪次 %d、遊戲局號 %q 的牌組在位置 %d 為 %s%s，預期為 %s%s",
+				round, gameSessionID, i, deck[i].Suit, deck[i].Value, want[i].Suit, want[i].Value)
+			return false
+		}
+	}
+	return true
+}
+
+// AssertPermutation 檢查 deck 是否恰好包含模板中的每張牌各一次
+func AssertPermutation(t testing.TB, template *drandshuffle.DeckTemplate, deck []drandshuffle.Card) bool {
+	t.Helper()
+
+	remaining := make(map[drandshuffle.Card]int, template.Len())
+	for _, card := range template.NewDeck() {
+		remaining[card]++
+	}
+	if len(deck) != template.Len() {
+		t.Errorf("牌組長度為 %d，預期為 %d", len(deck), template.Len())
+		return false
+	}
+	for i, card := range deck {
+		if remaining[card] == 0 {
+			t.Errorf("位置 %d 的 %s%s 不在模板中或重複出現", i, card.Suit, card.Value)
+			return false
+		}
+		remaining[card]--
+	}
+	return true
+}
+
+// AssertStandardDeck 檢查 deck 是否是標準52張撲克牌的一個排列
+func AssertStandardDeck(t testing.TB, deck []drandshuffle.Card) bool {
+	t.Helper()
+	return AssertPermutation(t, drandshuffle.StandardDeckTemplate, deck)
+}
@@ -0,0 +1,87 @@
+package drandshuffletest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+)
+
+// Clock 是只在測試代碼調用 Advance 或 Set 時才前進的時鐘，可以被多個 goroutine 並發使用
+// 實現 drandshuffle.Clock，可以通過 drandshuffle.WithClock 注入 DrandManager
+type Clock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []clockWaiter
+}
+
+// clockWaiter 是一個 After 調用，時鐘到達 at 時向 ch 發送當時的時間
+type clockWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+var _ drandshuffle.Clock = (*Clock)(nil)
+
+// NewClock 創建停在 start 的時鐘
+func NewClock(start time.Time) *Clock {
+	return &Clock{now: start}
+}
+
+// Now 返回時鐘的當前時間
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After 返回在時鐘前進 d 之後接收到當時時間的通道，d 不大於 0 時立即可以接收
+func (c *Clock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, clockWaiter{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// Waiters 返回尚未觸發的 After 調用數量，測試可以等待其大於 0 後再推進時鐘
+func (c *Clock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+// Advance 將時鐘向前推進 d，並觸發所有已經到期的 After
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	c.fireLocked()
+}
+
+// Set 將時鐘設為 t，並觸發所有已經到期的 After
+func (c *Clock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+	c.fireLocked()
+}
+
+// fireLocked 觸發已經到期的 After，調用者需持有鎖
+func (c *Clock) fireLocked() {
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			pending = append(pending, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	clear(c.waiters[len(pending):])
+	c.waiters = pending
+}
@@ -0,0 +1,148 @@
+package drandshuffletest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+)
+
+// CorpusCard 是語料中附加的一張牌
+type CorpusCard struct {
+	Suit  string `json:"suit"`
+	Value string `json:"value"`
+}
+
+// CorpusDeck 是語料中的牌組組成，對應 drandshuffle.DeckSpec
+type CorpusDeck struct {
+	Suits  []string     `json:"suits"`
+	Values []string     `json:"values"`
+	Extras []CorpusCard `json:"extras,omitempty"`
+}
+
+// CorpusCase 是洗牌語料中的一個用例：以 Randomness 和 SessionID 洗 Deck 組成的牌組，結果應為 Expected
+// 語料以 JSON Lines 保存，每行一個用例，見 ReadCorpus
+type CorpusCase struct {
+	Name       string      `json:"name"`
+	Randomness string      `json:"randomness"`     // 十六進制，長度不限，可以是模糊測試找到的任意字節
+	SessionID  string      `json:"session_id"`     // 不檢查 drandshuffle.ValidateSessionID，原樣參與洗牌
+	Deck       *CorpusDeck `json:"deck,omitempty"` // 為空時使用 drandshuffle.Poker52
+	Expected   []string    `json:"expected"`       // 按順序排列的 drandshuffle.CardToString
+}
+
+// Shuffle 以當前的洗牌算法重新計算用例，返回按順序排列的 drandshuffle.CardToString
+func (c CorpusCase) Shuffle() ([]string, error) {
+	randomness, err := hex.DecodeString(c.Randomness)
+	if err != nil {
+		return nil, fmt.Errorf("用例 %s 的隨機性不是有效的十六進制: %w", c.Name, err)
+	}
+	template := drandshuffle.Poker52
+	if c.Deck != nil {
+		spec := drandshuffle.DeckSpec{Suits: c.Deck.Suits, Values: c.Deck.Values}
+		for _, card := range c.Deck.Extras {
+			spec.Extras = append(spec.Extras, drandshuffle.Card{Suit: card.Suit, Value: card.Value})
+		}
+		template = drandshuffle.NewDeckTemplate(spec)
+	}
+	deck := drandshuffle.NewShuffler(template).Shuffle(randomness, c.SessionID)
+	out := make([]string, len(deck))
+	for i, card := range deck {
+		out[i] = drandshuffle.CardToString(card)
+	}
+	return out, nil
+}
+
+// CorpusMismatch 是重放時與記錄不一致的用例
+type CorpusMismatch struct {
+	Name     string // 用例名稱
+	Position int    // 第一個不一致的位置；長度不同時為較短一方的長度
+	Want     string // 記錄的牌，記錄較短時為空
+	Got      string // 重新計算的牌，結果較短時為空
+}
+
+// String 返回不一致的說明
+func (m CorpusMismatch) String() string {
+	return fmt.Sprintf("%s: 位置 %d 記錄為 %q，重新計算為 %q", m.Name, m.Position, m.Want, m.Got)
+}
+
+// ReplayCorpus 以當前的洗牌算法重放所有用例，返回與記錄不一致的用例
+// 記錄的結果為空的用例也視為不一致；用例本身無效（如隨機性不是十六進制）時返回錯誤
+func ReplayCorpus(cases []CorpusCase) ([]CorpusMismatch, error) {
+	var mismatches []CorpusMismatch
+	for _, c := range cases {
+		got, err := c.Shuffle()
+		if err != nil {
+			return nil, err
+		}
+		if m, ok := diffCorpus(c.Name, c.Expected, got); !ok {
+			mismatches = append(mismatches, m)
+		}
+	}
+	return mismatches, nil
+}
+
+// diffCorpus 比較記錄和重新計算的結果，一致時第二個返回值為 true
+func diffCorpus(name string, want, got []string) (CorpusMismatch, bool) {
+	n := min(len(want), len(got))
+	for i := 0; i < n; i++ {
+		if want[i] != got[i] {
+			return CorpusMismatch{Name: name, Position: i, Want: want[i], Got: got[i]}, false
+		}
+	}
+	if len(want) == len(got) && len(want) > 0 {
+		return CorpusMismatch{}, true
+	}
+	m := CorpusMismatch{Name: name, Position: n}
+	if n < len(want) {
+		m.Want = want[n]
+	}
+	if n < len(got) {
+		m.Got = got[n]
+	}
+	return m, false
+}
+
+// ReadCorpus 讀取 JSON Lines 格式的語料，忽略空行
+func ReadCorpus(r io.Reader) ([]CorpusCase, error) {
+	var cases []CorpusCase
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	names := make(map[string]bool)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		var c CorpusCase
+		dec := json.NewDecoder(bytes.NewReader(text))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&c); err != nil {
+			return nil, fmt.Errorf("無法解析語料第 %d 行: %w", line, err)
+		}
+		if c.Name == "" || names[c.Name] {
+			return nil, fmt.Errorf("語料第 %d 行的用例名稱為空或重複", line)
+		}
+		names[c.Name] = true
+		cases = append(cases, c)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("無法讀取語料: %w", err)
+	}
+	return cases, nil
+}
+
+// WriteCorpus 以 JSON Lines 格式寫出語料，每行一個用例
+func WriteCorpus(w io.Writer, cases []CorpusCase) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for _, c := range cases {
+		if err := enc.Encode(c); err != nil {
+			return fmt.Errorf("無法寫入語料: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+// Package drandshuffletest 提供不依賴網絡的測試工具
+//
+// FakeBeaconSource 實現 drand.Client，可以腳本化地控制最新輪次、每個輪次的隨機性和錯誤，
+// 配合 Clock 可以讓最新輪次隨測試時間推進；AssertDeck 等輔助函數用於檢查洗牌結果。
+// 需要經過真實 HTTP 客戶端和簽名驗證的集成測試可以使用 FakeRelay，它以 drand 中繼 API 提供本地簽名的信標，
+// 可以腳本化地設定延遲、失敗和停止更新。CheckDeterministic、CheckPermutation 和 CheckSessionIndependence
+// 讓建立在庫之上的遊戲模塊以同樣的不變量檢查自己的發牌邏輯。
+//
+//	src := drandshuffletest.NewFakeBeaconSource(1000)
+//	dm := drandshuffletest.NewManager(t, src)
+//	deck, round, err := dm.ShuffledDeck("game_1")
+//	drandshuffletest.AssertDeck(t, src, round, "game_1", deck)
+package drandshuffletest
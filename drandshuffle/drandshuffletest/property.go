@@ -0,0 +1,184 @@
+package drandshuffletest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+// DefaultTrials 是性質檢查默認生成的輸入數量
+const DefaultTrials = 200
+
+// propertyDomain 是性質檢查生成隨機性時使用的域分隔前綴
+const propertyDomain = "drandshuffletest/property/v1"
+
+// Deal 是性質檢查的對象：以隨機性和遊戲局號產生按順序排列的結果，例如洗好的牌組、發出的手牌或抽出的號碼
+// 結果由多個部分組成時（如多名玩家的手牌）應按固定順序展開為一個切片
+type Deal[E comparable] func(randomness []byte, sessionID string) ([]E, error)
+
+// propertyOptions 是性質檢查的參數
+type propertyOptions struct {
+	trials int
+}
+
+// PropertyOption 設定性質檢查的參數
+type PropertyOption func(*propertyOptions)
+
+// WithTrials 設定生成的輸入數量，默認為 DefaultTrials
+func WithTrials(n int) PropertyOption {
+	return func(o *propertyOptions) {
+		o.trials = n
+	}
+}
+
+// newPropertyOptions 應用選項
+func newPropertyOptions(opts []PropertyOption) propertyOptions {
+	o := propertyOptions{trials: DefaultTrials}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// PropertyInput 返回性質檢查生成的第 i 個輸入，用於重現檢查報告的失敗
+// 隨機性為 SHA256("drandshuffletest/property/v1" || 八字節大端序的 i)，遊戲局號為 "prop_<i>"
+func PropertyInput(i int) (randomness []byte, sessionID string) {
+	h := sha256.New()
+	h.Write([]byte(propertyDomain))
+	h.Write(binary.BigEndian.AppendUint64(nil, uint64(i)))
+	return h.Sum(nil), "prop_" + strconv.Itoa(i)
+}
+
+// CheckDeterministic 檢查相同的隨機性和遊戲局號總是產生相同的結果，且 deal 不修改傳入的隨機性
+// 每個輸入調用 deal 兩次並逐位比較，發現第一個失敗的輸入即停止並報告；返回是否全部通過
+func CheckDeterministic[E comparable](t testing.TB, deal Deal[E], opts ...PropertyOption) bool {
+	t.Helper()
+	o := newPropertyOptions(opts)
+	for i := 0; i < o.trials; i++ {
+		randomness, sessionID := PropertyInput(i)
+		input := slices.Clone(randomness)
+		first, err := deal(input, sessionID)
+		if err != nil {
+			t.Errorf("輸入 %d 發牌失敗: %v", i, err)
+			return false
+		}
+		if !bytes.Equal(input, randomness) {
+			t.Errorf("輸入 %d 的隨機性在發牌後被修改", i)
+			return false
+		}
+		second, err := deal(slices.Clone(randomness), sessionID)
+		if err != nil {
+			t.Errorf("輸入 %d 第二次發牌失敗: %v", i, err)
+			return false
+		}
+		if pos, ok := firstDifference(first, second); !ok {
+			t.Errorf("輸入 %d 兩次發牌的結果不同，第一個不同的位置為 %d", i, pos)
+			return false
+		}
+	}
+	return true
+}
+
+// CheckPermutation 檢查每個結果都恰好包含 universe 中的每個元素各一次，即 universe 的一個排列
+// universe 可以有重複的元素（如多副牌），此時每個元素出現的次數必須相同；發現第一個失敗的輸入即停止並報告
+func CheckPermutation[E comparable](t testing.TB, universe []E, deal Deal[E], opts ...PropertyOption) bool {
+	t.Helper()
+	o := newPropertyOptions(opts)
+	want := make(map[E]int, len(universe))
+	for _, e := range universe {
+		want[e]++
+	}
+	for i := 0; i < o.trials; i++ {
+		randomness, sessionID := PropertyInput(i)
+		result, err := deal(randomness, sessionID)
+		if err != nil {
+			t.Errorf("輸入 %d 發牌失敗: %v", i, err)
+			return false
+		}
+		if len(result) != len(universe) {
+			t.Errorf("輸入 %d 的結果有 %d 個元素，預期為 %d 個", i, len(result), len(universe))
+			return false
+		}
+		remaining := make(map[E]int, len(want))
+		for e, n := range want {
+			remaining[e] = n
+		}
+		for pos, e := range result {
+			if remaining[e] == 0 {
+				t.Errorf("輸入 %d 的結果在位置 %d 的 %v 不在 universe 中或重複出現", i, pos, e)
+				return false
+			}
+			remaining[e]--
+		}
+	}
+	return true
+}
+
+// CheckSessionIndependence 檢查同一隨機性下不同遊戲局的結果彼此獨立，即同一輪次的多局不會互相洩露
+// 對每個輸入，以相同的隨機性和兩個遊戲局號各發牌一次，統計兩個結果在相同位置相同的次數；
+// 再以另一個獨立的隨機性作對照。兩者的差超過四個標準差時報告失敗，因此適用於任何長度和元素類型的結果，
+// 結果本身可能相同的短結果（如擲硬幣）也不會誤報。
+// 注意 drandshuffle.Shuffler 的洗牌（ProofAlgorithm drandshuffle/v1）在 Fisher-Yates 的前段只讀取隨機性本身，
+// 不滿足這一性質；需要同一輪次多局獨立的遊戲應以 RandProof 的種子（含遊戲局號的哈希）派生結果
+func CheckSessionIndependence[E comparable](t testing.TB, deal Deal[E], opts ...PropertyOption) bool {
+	t.Helper()
+	o := newPropertyOptions(opts)
+	var same, baseline, positions int
+	for i := 0; i < o.trials; i++ {
+		randomness, sessionID := PropertyInput(i)
+		other, _ := PropertyInput(o.trials + i)
+		peer := sessionID + "_peer"
+
+		a, err := deal(randomness, sessionID)
+		if err != nil {
+			t.Errorf("輸入 %d 發牌失敗: %v", i, err)
+			return false
+		}
+		b, err := deal(randomness, peer)
+		if err != nil {
+			t.Errorf("輸入 %d 以遊戲局號 %s 發牌失敗: %v", i, peer, err)
+			return false
+		}
+		c, err := deal(other, peer)
+		if err != nil {
+			t.Errorf("輸入 %d 的對照發牌失敗: %v", i, err)
+			return false
+		}
+		same += countMatches(a, b)
+		baseline += countMatches(a, c)
+		positions += min(len(a), len(b))
+	}
+
+	if float64(same-baseline) > 4*math.Sqrt(float64(same+baseline+1)) {
+		t.Errorf("同一隨機性下不同遊戲局的結果在 %d 個位置中有 %d 個相同，獨立隨機性的對照只有 %d 個，結果受遊戲局號的影響不足",
+			positions, same, baseline)
+		return false
+	}
+	return true
+}
+
+// countMatches 返回 a 和 b 在相同位置元素相同的次數
+func countMatches[E comparable](a, b []E) int {
+	n := 0
+	for i := range min(len(a), len(b)) {
+		if a[i] == b[i] {
+			n++
+		}
+	}
+	return n
+}
+
+// firstDifference 返回 a 和 b 第一個不同的位置，長度不同時為較短一方的長度；相同時第二個返回值為 true
+func firstDifference[E comparable](a, b []E) (int, bool) {
+	n := min(len(a), len(b))
+	for i := 0; i < n; i++ {
+		if a[i] != b[i] {
+			return i, false
+		}
+	}
+	return n, len(a) == len(b)
+}
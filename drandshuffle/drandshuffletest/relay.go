@@ -0,0 +1,262 @@
+package drandshuffletest
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/drand/drand/v2/common"
+	"github.com/drand/drand/v2/common/chain"
+	"github.com/drand/drand/v2/crypto"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+)
+
+// FakeRelayPeriod 是 FakeRelay 的鏈的輪次週期
+const FakeRelayPeriod = 3 * time.Second
+
+// FakeRelay 是以 drand 中繼 HTTP API 提供隨機信標的本地測試服務器，用於不連接公網地測試 DrandManager 的重試、故障轉移和過期處理
+// 信標以固定的測試私鑰簽名（與 quicknet 相同的 G1 簽名方案），DrandManager 以 Info 返回的鏈信息或 ChainHash 連接即可正常驗證。
+// 鏈的創世時間為創建時的一小時前，默認的最新輪次隨真實時間推進；同一進程內創建的 FakeRelay 屬於同一條鏈，
+// 可以作為同一組中繼測試故障轉移。提供 /chains、/info、/public/latest 和 /public/<輪次>，以及帶鏈哈希前綴的形式。
+// 可以被多個 goroutine 並發使用
+type FakeRelay struct {
+	// URL 是服務器的地址，傳給 drandshuffle.WithRelayURLs
+	URL string
+
+	server *httptest.Server
+	info   *chain.Info
+	scheme *crypto.Scheme
+	sign   func(msg []byte) ([]byte, error)
+
+	mu       sync.Mutex
+	latest   uint64 // 為 0 時隨真實時間推進
+	latency  time.Duration
+	failNext []int
+	status   int
+	corrupt  map[uint64]bool
+	calls    map[uint64]int
+	requests int
+}
+
+// fakeRelayChain 返回同一進程內所有 FakeRelay 共用的鏈信息和簽名函數，私鑰由固定的字符串導出
+var fakeRelayChain = sync.OnceValues(func() (*chain.Info, func(msg []byte) ([]byte, error)) {
+	scheme := crypto.NewPedersenBLSUnchainedG1()
+	seed := sha256.Sum256([]byte("drandshuffletest fake relay key"))
+	priv := scheme.KeyGroup.Scalar().SetBytes(seed[:])
+	info := &chain.Info{
+		PublicKey:   scheme.KeyGroup.Point().Mul(priv, nil),
+		Period:      FakeRelayPeriod,
+		Scheme:      scheme.Name,
+		GenesisTime: time.Now().Add(-time.Hour).Unix(),
+	}
+	return info, func(msg []byte) ([]byte, error) {
+		return scheme.AuthScheme.Sign(priv, msg)
+	}
+})
+
+// NewFakeRelay 啟動 FakeRelay，測試結束時自動關閉
+func NewFakeRelay(t testing.TB) *FakeRelay {
+	t.Helper()
+	info, sign := fakeRelayChain()
+	r := &FakeRelay{
+		info:    info,
+		scheme:  crypto.NewPedersenBLSUnchainedG1(),
+		sign:    sign,
+		corrupt: make(map[uint64]bool),
+		calls:   make(map[uint64]int),
+	}
+	r.server = httptest.NewServer(http.HandlerFunc(r.serveHTTP))
+	r.URL = r.server.URL
+	t.Cleanup(r.server.Close)
+	return r
+}
+
+// Info 返回中繼的鏈信息，傳給 drandshuffle.WithChainInfo
+func (r *FakeRelay) Info() *chain.Info {
+	return r.info
+}
+
+// ChainHash 返回鏈哈希的十六進制字符串，傳給 drandshuffle.WithChainHash 時 DrandManager 會從 /info 取得鏈信息
+func (r *FakeRelay) ChainHash() string {
+	return r.info.HashString()
+}
+
+// Beacon 返回指定輪次的有效隨機信標，不受 CorruptRound 影響，用於核對 DrandManager 取得的結果
+func (r *FakeRelay) Beacon(round uint64) drandshuffle.Beacon {
+	sig, err := r.sign(r.scheme.DigestBeacon(&common.Beacon{Round: round}))
+	if err != nil {
+		panic("drandshuffletest: 無法簽名隨機信標: " + err.Error())
+	}
+	return drandshuffle.Beacon{
+		Round:      round,
+		Randomness: crypto.RandomnessFromSignature(sig),
+		Signature:  sig,
+	}
+}
+
+// CurrentRound 返回 /public/latest 當前返回的輪次
+func (r *FakeRelay) CurrentRound() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.currentRoundLocked()
+}
+
+// currentRoundLocked 返回最新輪次，調用者必須持有 mu
+func (r *FakeRelay) currentRoundLocked() uint64 {
+	if r.latest != 0 {
+		return r.latest
+	}
+	return uint64(time.Since(time.Unix(r.info.GenesisTime, 0))/r.info.Period) + 1
+}
+
+// SetLatest 將最新輪次固定為 round，大於該輪次的請求返回 404，用於模擬落後或停止更新的中繼
+// round 不應超過按真實時間計算的當前輪次，否則 DrandManager 會把它當作未來輪次拒絕；傳入 0 時恢復隨真實時間推進
+func (r *FakeRelay) SetLatest(round uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.latest = round
+}
+
+// SetLatency 設定每個請求在響應前的延遲，請求在延遲期間被取消時不再響應
+func (r *FakeRelay) SetLatency(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.latency = d
+}
+
+// FailNext 讓接下來的 n 個請求（包括 /info）以 HTTP 狀態碼 status 失敗，多次調用時依次排隊
+func (r *FakeRelay) FailNext(n int, status int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := 0; i < n; i++ {
+		r.failNext = append(r.failNext, status)
+	}
+}
+
+// SetStatus 讓之後的所有請求以 HTTP 狀態碼 status 失敗，用於模擬中繼停機；傳入 0 時恢復正常
+func (r *FakeRelay) SetStatus(status int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status = status
+}
+
+// CorruptRound 讓指定輪次返回簽名無效的隨機信標（簽名和隨機性屬於下一輪），用於測試驗證失敗時的處理
+func (r *FakeRelay) CorruptRound(round uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.corrupt[round] = true
+}
+
+// Calls 返回指定輪次被請求的次數，round 為 0 時返回請求 /public/latest 的次數；失敗的請求也計算在內
+func (r *FakeRelay) Calls(round uint64) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.calls[round]
+}
+
+// Requests 返回收到的請求總數，包括 /info 和失敗的請求
+func (r *FakeRelay) Requests() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.requests
+}
+
+// Close 立即關閉服務器，之後的請求會連接失敗，用於模擬中繼下線
+func (r *FakeRelay) Close() {
+	r.server.CloseClientConnections()
+	r.server.Close()
+}
+
+// serveHTTP 按路徑分派請求，先應用腳本化的延遲和失敗
+func (r *FakeRelay) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	path := strings.Trim(req.URL.Path, "/")
+	if hash, rest, ok := strings.Cut(path, "/"); ok && hash != "public" {
+		if hash != r.ChainHash() {
+			http.NotFound(w, req)
+			return
+		}
+		path = rest
+	}
+
+	r.mu.Lock()
+	r.requests++
+	round, isRound, err := parseRelayRound(path)
+	if isRound && err == nil {
+		r.calls[round]++
+	}
+	latency := r.latency
+	status := r.status
+	if status == 0 && len(r.failNext) > 0 {
+		status = r.failNext[0]
+		r.failNext = r.failNext[1:]
+	}
+	current := r.currentRoundLocked()
+	if round == drandshuffle.Latest {
+		round = current
+	}
+	corrupt := r.corrupt[round]
+	r.mu.Unlock()
+
+	if latency > 0 {
+		timer := time.NewTimer(latency)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			return
+		}
+	}
+	if status != 0 {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	switch {
+	case path == "chains":
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]string{r.ChainHash()})
+	case path == "info":
+		w.Header().Set("Content-Type", "application/json")
+		r.info.ToJSON(w, nil)
+	case !isRound:
+		http.NotFound(w, req)
+	case err != nil:
+		http.Error(w, "無效的輪次號碼", http.StatusBadRequest)
+	case round > current:
+		http.Error(w, "輪次尚未發布", http.StatusNotFound)
+	default:
+		beacon := r.Beacon(round)
+		if corrupt {
+			next := r.Beacon(round + 1)
+			beacon.Randomness, beacon.Signature = next.Randomness, next.Signature
+		}
+		data, err := drandshuffle.EncodeBeaconJSON(beacon)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}
+}
+
+// parseRelayRound 解析 public/latest 和 public/<輪次> 路徑，latest 返回 drandshuffle.Latest
+// 第二個返回值表示路徑是否是隨機信標的請求
+func parseRelayRound(path string) (uint64, bool, error) {
+	roundPath, ok := strings.CutPrefix(path, "public/")
+	if !ok {
+		return 0, false, nil
+	}
+	if roundPath == "latest" {
+		return drandshuffle.Latest, true, nil
+	}
+	round, err := strconv.ParseUint(roundPath, 10, 64)
+	return round, true, err
+}
@@ -0,0 +1,250 @@
+package drandshuffletest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/drand/drand/v2/common/chain"
+	"github.com/drand/go-clients/drand"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+)
+
+// ErrNoChainInfo 表示 FakeBeaconSource 沒有跟隨時鐘，不提供鏈信息
+// DrandManager 在沒有鏈信息時使用固定的輪詢間隔，且不會等待輪次的發布時間
+var ErrNoChainInfo = errors.New("drandshuffletest: no chain info")
+
+// FakeBeaconSource 是可腳本化的 drand 客戶端，用於不依賴網絡的測試
+// 未通過 SetRandomness 設定的輪次使用由輪次號碼確定性生成的隨機性，同一輪次每次返回相同的結果
+// 可以被多個 goroutine 並發使用
+type FakeBeaconSource struct {
+	mu         sync.Mutex
+	latest     uint64
+	randomness map[uint64][]byte
+	err        error
+	calls      map[uint64]int
+
+	// 跟隨時鐘時，最新輪次由時鐘的當前時間決定
+	clock   *Clock
+	genesis time.Time
+	period  time.Duration
+
+	watchMu  sync.Mutex
+	watchers map[*watcher]struct{}
+	closed   bool
+	stopped  chan struct{}
+}
+
+// watcher 是 Watch 的一個訂閱
+type watcher struct {
+	ch   chan drand.Result
+	done <-chan struct{}
+}
+
+// NewFakeBeaconSource 創建最新輪次為 latest 的假隨機信標源
+func NewFakeBeaconSource(latest uint64) *FakeBeaconSource {
+	return &FakeBeaconSource{
+		latest:     latest,
+		randomness: make(map[uint64][]byte),
+		calls:      make(map[uint64]int),
+		watchers:   make(map[*watcher]struct{}),
+		stopped:    make(chan struct{}),
+	}
+}
+
+// FollowClock 讓最新輪次跟隨時鐘推進
+// 輪次 1 在 genesis 發布，之後每隔 period 發布一輪，與 drand 的輪次計算方式相同；genesis 應為整秒
+// 跟隨時鐘時 Info 返回對應的創世時間和週期（不含公鑰），應在創建 DrandManager 之前調用
+func (s *FakeBeaconSource) FollowClock(clock *Clock, genesis time.Time, period time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clock = clock
+	s.genesis = genesis
+	s.period = period
+}
+
+// SetLatest 設定最新輪次，並停止跟隨時鐘
+func (s *FakeBeaconSource) SetLatest(round uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clock = nil
+	s.latest = round
+}
+
+// SetRandomness 設定指定輪次返回的隨機性
+func (s *FakeBeaconSource) SetRandomness(round uint64, randomness []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.randomness[round] = append([]byte(nil), randomness...)
+}
+
+// SetError 設定 Get 返回的錯誤，傳入 nil 恢復正常
+func (s *FakeBeaconSource) SetError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+// Publish 將最新輪次設為 round，並把該輪次的隨機信標發送給所有 Watch 訂閱者
+// 會等待每個訂閱者接收或取消訂閱後才返回
+func (s *FakeBeaconSource) Publish(round uint64) {
+	s.SetLatest(round)
+	beacon := s.Beacon(round)
+
+	s.watchMu.Lock()
+	defer s.watchMu.Unlock()
+	for w := range s.watchers {
+		select {
+		case w.ch <- beacon:
+		case <-w.done:
+		}
+	}
+}
+
+// Randomness 返回指定輪次的隨機性副本
+func (s *FakeBeaconSource) Randomness(round uint64) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.randomnessLocked(round)
+}
+
+// randomnessLocked 返回指定輪次的隨機性副本，調用者需持有鎖
+func (s *FakeBeaconSource) randomnessLocked(round uint64) []byte {
+	if r, ok := s.randomness[round]; ok {
+		return append([]byte(nil), r...)
+	}
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], round)
+	sum := sha256.Sum256(buf[:])
+	return sum[:]
+}
+
+// Beacon 返回指定輪次的隨機信標，不計入請求次數
+func (s *FakeBeaconSource) Beacon(round uint64) drandshuffle.Beacon {
+	return drandshuffle.Beacon{Round: round, Randomness: s.Randomness(round)}
+}
+
+// Latest 返回當前的最新輪次
+func (s *FakeBeaconSource) Latest() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.latestLocked()
+}
+
+// latestLocked 返回當前的最新輪次，調用者需持有鎖
+func (s *FakeBeaconSource) latestLocked() uint64 {
+	if s.clock == nil {
+		return s.latest
+	}
+	return s.roundAtLocked(s.clock.Now())
+}
+
+// roundAtLocked 返回時間 t 時最新的輪次，調用者需持有鎖
+func (s *FakeBeaconSource) roundAtLocked(t time.Time) uint64 {
+	if t.Before(s.genesis) {
+		return 0
+	}
+	return uint64(t.Sub(s.genesis)/s.period) + 1
+}
+
+// Calls 返回指定輪次被請求的次數，round 為 0 時返回請求最新輪次的次數
+func (s *FakeBeaconSource) Calls(round uint64) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[round]
+}
+
+// Get 返回指定輪次的隨機信標，round 為 0 時返回最新輪次
+// 請求尚未發布的輪次會返回錯誤
+func (s *FakeBeaconSource) Get(ctx context.Context, round uint64) (drand.Result, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls[round]++
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	latest := s.latestLocked()
+	if round == 0 {
+		round = latest
+	}
+	if round == 0 || round > latest {
+		return nil, fmt.Errorf("drandshuffletest: round %d not published, latest is %d", round, latest)
+	}
+
+	return drandshuffle.Beacon{Round: round, Randomness: s.randomnessLocked(round)}, nil
+}
+
+// Watch 返回接收 Publish 發布的隨機信標的通道，ctx 取消或源關閉時通道關閉
+func (s *FakeBeaconSource) Watch(ctx context.Context) <-chan drand.Result {
+	w := &watcher{ch: make(chan drand.Result), done: ctx.Done()}
+
+	s.watchMu.Lock()
+	defer s.watchMu.Unlock()
+	if s.closed {
+		close(w.ch)
+		return w.ch
+	}
+	s.watchers[w] = struct{}{}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-s.stopped:
+		}
+		s.watchMu.Lock()
+		defer s.watchMu.Unlock()
+		if _, ok := s.watchers[w]; ok {
+			delete(s.watchers, w)
+			close(w.ch)
+		}
+	}()
+
+	return w.ch
+}
+
+// Info 跟隨時鐘時返回只含創世時間和週期的鏈信息，否則返回 ErrNoChainInfo
+func (s *FakeBeaconSource) Info(ctx context.Context) (*chain.Info, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.clock == nil {
+		return nil, ErrNoChainInfo
+	}
+	return &chain.Info{GenesisTime: s.genesis.Unix(), Period: s.period}, nil
+}
+
+// RoundAt 返回時間 t 時最新的輪次；未跟隨時鐘時返回當前的最新輪次
+func (s *FakeBeaconSource) RoundAt(t time.Time) uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.clock == nil {
+		return s.latest
+	}
+	return s.roundAtLocked(t)
+}
+
+// Close 關閉所有 Watch 訂閱的通道，可以重複調用
+func (s *FakeBeaconSource) Close() error {
+	s.watchMu.Lock()
+	defer s.watchMu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	close(s.stopped)
+	for w := range s.watchers {
+		delete(s.watchers, w)
+		close(w.ch)
+	}
+	return nil
+}
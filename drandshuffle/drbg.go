@@ -0,0 +1,89 @@
+package drandshuffle
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"hash"
+	"math/bits"
+)
+
+// DRBG 是以 SHA-256 計數器模式構建的確定性隨機位元生成器
+// 第 i 個 32 字節輸出區塊為 SHA256(seed || uint64_be(i))，i 從 0 開始，
+// 任何語言都可以用相同的方式重現輸出序列
+// DRBG 不是並發安全的，每個 goroutine 應使用各自的實例
+type DRBG struct {
+	hasher  hash.Hash
+	seed    []byte
+	counter uint64
+	block   [sha256.Size]byte
+	offset  int
+	input   []byte
+}
+
+// NewDRBG 使用種子創建 DRBG，種子會被複製，之後修改原切片不影響輸出
+func NewDRBG(seed []byte) *DRBG {
+	d := &DRBG{
+		hasher: sha256.New(),
+		seed:   copyBytes(seed),
+		offset: sha256.Size,
+	}
+	d.input = make([]byte, 0, len(d.seed)+8)
+	return d
+}
+
+// refill 生成下一個輸出區塊
+func (d *DRBG) refill() {
+	d.input = append(d.input[:0], d.seed...)
+	d.input = binary.BigEndian.AppendUint64(d.input, d.counter)
+	d.counter++
+
+	d.hasher.Reset()
+	d.hasher.Write(d.input)
+	d.hasher.Sum(d.block[:0])
+	d.offset = 0
+}
+
+// Read 以確定性的隨機字節填滿 p，永遠不會返回錯誤
+func (d *DRBG) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if d.offset == len(d.block) {
+			d.refill()
+		}
+		copied := copy(p[n:], d.block[d.offset:])
+		d.offset += copied
+		n += copied
+	}
+	return n, nil
+}
+
+// Uint64 返回下一個 64 位元隨機數
+func (d *DRBG) Uint64() uint64 {
+	var buf [8]byte
+	d.Read(buf[:])
+	return binary.BigEndian.Uint64(buf[:])
+}
+
+// Uint64n 返回 [0, n) 範圍內均勻分佈的隨機數，n 為 0 時返回 0
+// 使用拒絕採樣消除取模偏差
+func (d *DRBG) Uint64n(n uint64) uint64 {
+	if n == 0 {
+		return 0
+	}
+	// 拒絕落在最後一個不完整區間內的值
+	limit := -n % n // 等於 2^64 mod n
+	for {
+		hi, lo := bits.Mul64(d.Uint64(), n)
+		if lo >= limit {
+			return hi
+		}
+	}
+}
+
+// Intn 返回 [0, n) 範圍內均勻分佈的隨機數，n 小於等於 0 時返回 0
+func (d *DRBG) Intn(n int) int {
+	if n <= 0 {
+		return 0
+	}
+	return int(d.Uint64n(uint64(n)))
+}
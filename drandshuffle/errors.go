@@ -0,0 +1,139 @@
+package drandshuffle
+
+import (
+	"context"
+	"errors"
+)
+
+// 哨兵錯誤，可以用 errors.Is 判斷錯誤類別
+// 錯誤文字固定為英文，便於日誌搜索和錯誤追蹤工具匹配；包裝後的詳細說明仍為中文
+var (
+	// ErrBeaconUnavailable 表示無法取得最新的隨機信標，例如尚未獲取過或所有中繼都無法連接
+	ErrBeaconUnavailable = errors.New("drandshuffle: beacon unavailable")
+	// ErrRoundNotFound 表示無法取得指定輪次的隨機信標
+	ErrRoundNotFound = errors.New("drandshuffle: round not found")
+	// ErrFutureRound 表示請求的輪次尚未發布，具體的可用時間見 FutureRoundError
+	ErrFutureRound = errors.New("drandshuffle: future round")
+	// ErrRoundBeforeGenesis 表示請求的輪次早於鏈的第一輪
+	ErrRoundBeforeGenesis = errors.New("drandshuffle: round before genesis")
+	// ErrInvalidCard 表示無法解析的牌字符串
+	ErrInvalidCard = errors.New("drandshuffle: invalid card")
+	// ErrInsufficientCards 表示牌組剩餘的牌不足以完成發牌
+	ErrInsufficientCards = errors.New("drandshuffle: insufficient cards")
+	// ErrDeckMismatch 表示牌組與指定輪次和遊戲局號洗出的結果不一致
+	ErrDeckMismatch = errors.New("drandshuffle: deck mismatch")
+	// ErrSeedMismatch 表示隨機數證明記錄的種子與其輪次和遊戲局號派生的種子不一致
+	ErrSeedMismatch = errors.New("drandshuffle: seed mismatch")
+	// ErrInvalidSessionID 表示遊戲局號為空、過長或含有不允許的字符
+	ErrInvalidSessionID = errors.New("drandshuffle: invalid session id")
+	// ErrInvalidConfig 表示配置無效，詳細原因見包裝的錯誤
+	ErrInvalidConfig = errors.New("drandshuffle: invalid config")
+	// ErrExplicitRoundRequired 表示嚴格輪次模式下請求了最新輪次，必須指定預先承諾的輪次
+	ErrExplicitRoundRequired = errors.New("drandshuffle: explicit round required")
+	// ErrInvalidBeacon 表示無法解析的隨機信標數據，例如 ParseBeaconJSON 的輸入格式錯誤
+	ErrInvalidBeacon = errors.New("drandshuffle: invalid beacon")
+	// ErrInvalidCBOR 表示無法解析的 CBOR 編碼，例如數據不完整、類型不符或不是規範編碼
+	ErrInvalidCBOR = errors.New("drandshuffle: invalid cbor")
+	// ErrChainBroken 表示證明鏈中某份證明記錄的上一份證明摘要與實際的上一份證明不一致
+	ErrChainBroken = errors.New("drandshuffle: proof chain broken")
+	// ErrQuotaExceeded 表示租戶在該輪次的派生次數已達 UsageMeter 的配額，具體的租戶和輪次見 QuotaExceededError
+	ErrQuotaExceeded = errors.New("drandshuffle: quota exceeded")
+	// ErrInvalidLink 表示驗證鏈接缺少參數、簽名無效或已經過期
+	ErrInvalidLink = errors.New("drandshuffle: invalid verification link")
+	// ErrClosed 表示 DrandManager 已經關閉，不再發出網絡請求
+	ErrClosed = errors.New("drandshuffle: manager closed")
+)
+
+// ErrorCategory 是錯誤的類別，用於決定是否重試
+type ErrorCategory int
+
+const (
+	// CategoryUnknown 表示未分類的錯誤
+	CategoryUnknown ErrorCategory = iota
+	// CategoryNetwork 表示連接中繼或獲取隨機信標失敗，通常是暫時性的
+	CategoryNetwork
+	// CategoryVerification 表示簽名、隨機性或牌組驗證失敗，重試不會改變結果
+	CategoryVerification
+	// CategoryInput 表示調用者提供的參數或配置無效，重試不會改變結果
+	CategoryInput
+)
+
+// String 返回類別名稱
+func (c ErrorCategory) String() string {
+	switch c {
+	case CategoryNetwork:
+		return "network"
+	case CategoryVerification:
+		return "verification"
+	case CategoryInput:
+		return "input"
+	default:
+		return "unknown"
+	}
+}
+
+// Error 是帶有類別的錯誤，錯誤文字與包裝的錯誤相同
+// 庫返回的網絡、驗證和輸入錯誤都包裝為 Error，可以用 errors.As 取得，或使用 IsTemporary 和 IsRetryable 判斷
+type Error struct {
+	Category ErrorCategory
+	Err      error
+}
+
+// Error 返回包裝的錯誤的文字
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap 返回包裝的錯誤，使 errors.Is 可以匹配哨兵錯誤
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Temporary 報告錯誤是否可能自行消失，只有網絡錯誤是暫時性的
+func (e *Error) Temporary() bool {
+	return e.Category == CategoryNetwork
+}
+
+// Retryable 報告以相同參數重試是否可能成功
+// 網絡錯誤可以重試，但調用者主動取消的請求除外
+func (e *Error) Retryable() bool {
+	return e.Temporary() && !errors.Is(e.Err, context.Canceled)
+}
+
+// Category 返回錯誤鏈中第一個 Error 的類別，沒有時返回 CategoryUnknown
+func Category(err error) ErrorCategory {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Category
+	}
+	return CategoryUnknown
+}
+
+// IsTemporary 報告錯誤鏈中是否有實現 Temporary() bool 且返回 true 的錯誤
+func IsTemporary(err error) bool {
+	var t interface{ Temporary() bool }
+	return errors.As(err, &t) && t.Temporary()
+}
+
+// IsRetryable 報告錯誤鏈中是否有實現 Retryable() bool 且返回 true 的錯誤
+func IsRetryable(err error) bool {
+	var r interface{ Retryable() bool }
+	return errors.As(err, &r) && r.Retryable()
+}
+
+// networkError 將錯誤標記為網絡錯誤
+func networkError(err error) error {
+	return &Error{Category: CategoryNetwork, Err: err}
+}
+
+// NewVerificationError 將錯誤標記為驗證錯誤（CategoryVerification），錯誤文字和 errors.Is 的匹配保持不變
+// 供子包和基於本庫的擴展返回與本包相同類別的錯誤
+func NewVerificationError(err error) error {
+	return &Error{Category: CategoryVerification, Err: err}
+}
+
+// NewInputError 將錯誤標記為輸入錯誤（CategoryInput），錯誤文字和 errors.Is 的匹配保持不變
+// 供子包和基於本庫的擴展返回與本包相同類別的錯誤
+func NewInputError(err error) error {
+	return &Error{Category: CategoryInput, Err: err}
+}
@@ -0,0 +1,283 @@
+// Package events 定義洗牌和隨機信標事件的 JSON 格式，供跨團隊的下游消費者驗證
+//
+// 事件採用 CloudEvents 1.0 的結構化 JSON 格式：信封中的 type 和 dataschema 帶有版本號，
+// data 中的 schema_version 與之一致。同一版本內只會新增可選欄位，消費者應忽略未知欄位；
+// 欄位的移除或含義改變會使用新的事件類型。各版本 data 的 JSON Schema 見 Schema。
+// 信封可以帶有 CloudEvents 分佈式追蹤擴展的 traceparent，與 OpenTelemetry 的 W3C 追蹤上下文相同。
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+)
+
+// SpecVersion 是事件使用的 CloudEvents 規範版本
+const SpecVersion = "1.0"
+
+// SchemaVersion 是本包產生的事件 data 的結構版本
+const SchemaVersion = 1
+
+// 事件類型
+const (
+	// TypeShuffleCreated 表示一局洗牌完成，data 為 ShuffleCreated
+	TypeShuffleCreated = "io.github.coseto6125.drandshuffle.shuffle.created.v1"
+	// TypeBeaconReceived 表示取得一輪新的隨機信標，data 為 BeaconReceived
+	TypeBeaconReceived = "io.github.coseto6125.drandshuffle.beacon.received.v1"
+)
+
+// schemaBase 是 JSON Schema 文檔的發布位置，文檔也隨本包嵌入，見 Schema
+const schemaBase = "https://github.com/coseto6125/DrandShuffle/blob/main/drandshuffle/events/schemas/"
+
+// schemaFiles 是各事件類型的 JSON Schema 文件名
+var schemaFiles = map[string]string{
+	TypeShuffleCreated: "shuffle.created.v1.json",
+	TypeBeaconReceived: "beacon.received.v1.json",
+}
+
+//go:embed schemas/*.json
+var schemas embed.FS
+
+// ErrInvalidEvent 表示事件不符合 CloudEvents 信封或其類型的結構
+var ErrInvalidEvent = errors.New("events: invalid event")
+
+// Event 是 CloudEvents 1.0 結構化 JSON 格式的事件
+type Event struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`     // 同一來源內唯一，重複投遞的事件 ID 相同，可以用於去重
+	Source          string          `json:"source"` // 產生事件的服務，如 "/casino/table-service"
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"` // 洗牌事件為遊戲局號，信標事件為輪次號碼
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	DataSchema      string          `json:"dataschema"`
+	TraceParent     string          `json:"traceparent,omitempty"` // W3C 追蹤上下文，見 WithTraceContext
+	Data            json.RawMessage `json:"data"`
+}
+
+// ShuffleCreated 是 TypeShuffleCreated 事件的 data
+// 事件不包含牌組本身，只包含其摘要；需要核對時以輪次和遊戲局號重新洗牌，再比對 drandshuffle.DeckDigest
+type ShuffleCreated struct {
+	SchemaVersion int        `json:"schema_version"`
+	SessionID     string     `json:"session_id"`
+	Round         uint64     `json:"round"`
+	RoundTime     *time.Time `json:"round_time,omitempty"` // 該輪隨機信標的發布時間，未知時省略
+	ChainHash     string     `json:"chain_hash,omitempty"` // 只在有洗牌證明時提供
+	DeckSize      int        `json:"deck_size"`
+	DeckDigest    string     `json:"deck_digest"`            // 見 drandshuffle.DeckDigest
+	ProofDigest   string     `json:"proof_digest,omitempty"` // 見 ShuffleProof.Digest，沒有證明時省略
+}
+
+// BeaconReceived 是 TypeBeaconReceived 事件的 data，字節欄位以十六進制編碼，與 drand 中繼的響應相同
+type BeaconReceived struct {
+	SchemaVersion     int    `json:"schema_version"`
+	ChainHash         string `json:"chain_hash,omitempty"`
+	Round             uint64 `json:"round"`
+	Randomness        string `json:"randomness"`
+	Signature         string `json:"signature"`
+	PreviousSignature string `json:"previous_signature,omitempty"`
+}
+
+// NewShuffleCreated 從 ShuffleBuilder.Do 的結果創建洗牌完成事件，sessionID 應與洗牌時使用的遊戲局號相同
+func NewShuffleCreated(source string, result *drandshuffle.ShuffleResult, sessionID string, at time.Time) (*Event, error) {
+	data := ShuffleCreated{
+		SchemaVersion: SchemaVersion,
+		SessionID:     sessionID,
+		Round:         result.Round,
+		DeckSize:      len(result.Deck),
+		DeckDigest:    drandshuffle.DeckDigest(result.Deck),
+	}
+	if !result.RoundTime.IsZero() {
+		t := result.RoundTime.UTC()
+		data.RoundTime = &t
+	}
+	if result.Proof != nil {
+		data.ChainHash = result.Proof.ChainHash
+		data.ProofDigest = result.Proof.Digest()
+	}
+	if err := data.validate(); err != nil {
+		return nil, err
+	}
+	return newEvent(source, TypeShuffleCreated, sessionID, at, data)
+}
+
+// NewBeaconReceived 創建取得隨機信標的事件，chainHash 為十六進制的鏈哈希，未知時可以為空
+func NewBeaconReceived(source, chainHash string, beacon drandshuffle.Beacon, at time.Time) (*Event, error) {
+	data := BeaconReceived{
+		SchemaVersion:     SchemaVersion,
+		ChainHash:         chainHash,
+		Round:             beacon.Round,
+		Randomness:        hex.EncodeToString(beacon.Randomness),
+		Signature:         hex.EncodeToString(beacon.Signature),
+		PreviousSignature: hex.EncodeToString(beacon.PreviousSignature),
+	}
+	if err := data.validate(); err != nil {
+		return nil, err
+	}
+	return newEvent(source, TypeBeaconReceived, strconv.FormatUint(beacon.Round, 10), at, data)
+}
+
+// newEvent 創建事件信封，ID 由類型、來源和主題決定，同一事件重複創建時 ID 相同
+func newEvent(source, eventType, subject string, at time.Time, data any) (*Event, error) {
+	if source == "" {
+		return nil, drandshuffle.NewInputError(fmt.Errorf("%w: 缺少事件來源", ErrInvalidEvent))
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("無法編碼事件數據: %w", err)
+	}
+	id := sha256.Sum256([]byte(eventType + "\x00" + source + "\x00" + subject))
+	return &Event{
+		SpecVersion:     SpecVersion,
+		ID:              hex.EncodeToString(id[:16]),
+		Source:          source,
+		Type:            eventType,
+		Subject:         subject,
+		Time:            at.UTC(),
+		DataContentType: "application/json",
+		DataSchema:      schemaBase + schemaFiles[eventType],
+		Data:            raw,
+	}, nil
+}
+
+// WithTraceContext 將 ctx 中的 OpenTelemetry span 以 W3C traceparent 記錄在事件中並返回事件
+// ctx 沒有有效的 span 時不做任何事
+func (e *Event) WithTraceContext(ctx context.Context) *Event {
+	sc := trace.SpanContextFromContext(ctx)
+	if sc.IsValid() {
+		e.TraceParent = fmt.Sprintf("00-%s-%s-%s", sc.TraceID(), sc.SpanID(), sc.TraceFlags())
+	}
+	return e
+}
+
+// Parse 解析並驗證結構化 JSON 格式的事件：信封須符合 CloudEvents 1.0，已知類型的 data 須符合其結構
+// 未知類型的事件只檢查信封；不符合時返回包裝 ErrInvalidEvent 的輸入錯誤
+func Parse(data []byte) (*Event, error) {
+	var e Event
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, drandshuffle.NewInputError(fmt.Errorf("%w: 無法解析 JSON: %w", ErrInvalidEvent, err))
+	}
+	if err := e.Validate(); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// Validate 檢查事件的信封和已知類型的 data
+func (e *Event) Validate() error {
+	if e.SpecVersion != SpecVersion {
+		return drandshuffle.NewInputError(fmt.Errorf("%w: 不支持的 CloudEvents 版本 %q", ErrInvalidEvent, e.SpecVersion))
+	}
+	if e.ID == "" || e.Source == "" || e.Type == "" {
+		return drandshuffle.NewInputError(fmt.Errorf("%w: 缺少 id、source 或 type", ErrInvalidEvent))
+	}
+
+	var data interface{ validate() error }
+	switch e.Type {
+	case TypeShuffleCreated:
+		data = &ShuffleCreated{}
+	case TypeBeaconReceived:
+		data = &BeaconReceived{}
+	default:
+		return nil
+	}
+	if err := e.DecodeData(data); err != nil {
+		return err
+	}
+	return data.validate()
+}
+
+// DecodeData 將事件的 data 解碼到 v，如 *ShuffleCreated
+func (e *Event) DecodeData(v any) error {
+	if len(bytes.TrimSpace(e.Data)) == 0 {
+		return drandshuffle.NewInputError(fmt.Errorf("%w: 缺少 data", ErrInvalidEvent))
+	}
+	if err := json.Unmarshal(e.Data, v); err != nil {
+		return drandshuffle.NewInputError(fmt.Errorf("%w: 無法解析 %s 的 data: %w", ErrInvalidEvent, e.Type, err))
+	}
+	return nil
+}
+
+// validate 檢查洗牌完成事件的必填欄位
+func (d *ShuffleCreated) validate() error {
+	if d.SchemaVersion != SchemaVersion {
+		return drandshuffle.NewInputError(fmt.Errorf("%w: 不支持的結構版本 %d", ErrInvalidEvent, d.SchemaVersion))
+	}
+	if err := drandshuffle.ValidateSessionID(d.SessionID); err != nil {
+		return drandshuffle.NewInputError(fmt.Errorf("%w: %w", ErrInvalidEvent, err))
+	}
+	if d.Round == 0 || d.DeckSize <= 0 || !isDigest(d.DeckDigest) {
+		return drandshuffle.NewInputError(fmt.Errorf("%w: 缺少輪次、牌數或牌組摘要", ErrInvalidEvent))
+	}
+	if (d.ChainHash != "" && !isDigest(d.ChainHash)) || (d.ProofDigest != "" && !isDigest(d.ProofDigest)) {
+		return drandshuffle.NewInputError(fmt.Errorf("%w: 無效的鏈哈希或證明摘要", ErrInvalidEvent))
+	}
+	return nil
+}
+
+// validate 檢查取得隨機信標事件的必填欄位
+func (d *BeaconReceived) validate() error {
+	if d.SchemaVersion != SchemaVersion {
+		return drandshuffle.NewInputError(fmt.Errorf("%w: 不支持的結構版本 %d", ErrInvalidEvent, d.SchemaVersion))
+	}
+	if d.Round == 0 || !isHex(d.Randomness) || !isHex(d.Signature) {
+		return drandshuffle.NewInputError(fmt.Errorf("%w: 缺少輪次、隨機性或簽名", ErrInvalidEvent))
+	}
+	if (d.ChainHash != "" && !isDigest(d.ChainHash)) || (d.PreviousSignature != "" && !isHex(d.PreviousSignature)) {
+		return drandshuffle.NewInputError(fmt.Errorf("%w: 無效的鏈哈希或上一輪簽名", ErrInvalidEvent))
+	}
+	return nil
+}
+
+// Beacon 將事件數據轉換為隨機信標，可以交給 drandshuffle.Verifier 驗證
+func (d *BeaconReceived) Beacon() (drandshuffle.Beacon, error) {
+	if err := d.validate(); err != nil {
+		return drandshuffle.Beacon{}, err
+	}
+	beacon := drandshuffle.Beacon{Round: d.Round}
+	beacon.Randomness, _ = hex.DecodeString(d.Randomness)
+	beacon.Signature, _ = hex.DecodeString(d.Signature)
+	if d.PreviousSignature != "" {
+		beacon.PreviousSignature, _ = hex.DecodeString(d.PreviousSignature)
+	}
+	return beacon, nil
+}
+
+// Schema 返回事件類型的 data 的 JSON Schema（draft 2020-12）文檔，未知類型返回 false
+func Schema(eventType string) ([]byte, bool) {
+	name, ok := schemaFiles[eventType]
+	if !ok {
+		return nil, false
+	}
+	doc, err := schemas.ReadFile("schemas/" + name)
+	return doc, err == nil
+}
+
+// isHex 報告 s 是否為非空的小寫十六進制字節串
+func isHex(s string) bool {
+	if s == "" || len(s)%2 != 0 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !('0' <= s[i] && s[i] <= '9' || 'a' <= s[i] && s[i] <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// isDigest 報告 s 是否為 SHA-256 摘要的十六進制字符串
+func isDigest(s string) bool {
+	return len(s) == 64 && isHex(s)
+}
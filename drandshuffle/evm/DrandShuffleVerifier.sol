@@ -0,0 +1,45 @@
+// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.20;
+
+/// @title DrandShuffleVerifier
+/// @notice 在鏈上重現 drandshuffle/v1 對標準 52 張撲克牌的洗牌，與 Go 庫的結果逐張一致。
+/// @dev 牌以一個字節表示：花色序號 * 13 + 點數序號，花色依次為黑桃、紅心、方塊、梅花，
+///      點數依次為 A、2 到 10、J、Q、K。本合約只檢查牌組是否由 randomness 和 sessionId 洗出，
+///      randomness 本身是否為該輪 drand 的隨機性（BLS 簽名）須由調用方的預言機或驗證合約另外確認。
+contract DrandShuffleVerifier {
+    uint256 internal constant DECK_SIZE = 52;
+
+    /// @notice 返回 randomness 和 sessionId 洗出的牌組
+    function shuffle(bytes32 randomness, string calldata sessionId) public pure returns (bytes memory deck) {
+        // 種子為 randomness || sha256(randomness || sessionId)，共 64 字節
+        bytes memory seed = abi.encodePacked(randomness, sha256(abi.encodePacked(randomness, sessionId)));
+        uint256 span = seed.length - 8;
+
+        deck = new bytes(DECK_SIZE);
+        for (uint256 k = 0; k < DECK_SIZE; k++) {
+            deck[k] = bytes1(uint8(k));
+        }
+
+        // Fisher-Yates：第 i 步讀取種子第 i % span 字節起的 8 字節（大端序）作為隨機數
+        for (uint256 i = DECK_SIZE - 1; i > 0; i--) {
+            uint256 pos = i % span;
+            uint64 v;
+            for (uint256 b = 0; b < 8; b++) {
+                v = (v << 8) | uint64(uint8(seed[pos + b]));
+            }
+            uint256 j = uint256(v) % (i + 1);
+            (deck[i], deck[j]) = (deck[j], deck[i]);
+        }
+    }
+
+    /// @notice 檢查 deck 是否為 round 輪的 randomness 和 sessionId 洗出的牌組
+    /// @dev round 不參與洗牌，僅供調用方核對 randomness 對應的輪次
+    function verifyShuffle(uint64 round, bytes32 randomness, string calldata sessionId, bytes calldata deck)
+        external
+        pure
+        returns (bool)
+    {
+        round;
+        return keccak256(shuffle(randomness, sessionId)) == keccak256(deck);
+    }
+}
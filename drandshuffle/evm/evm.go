@@ -0,0 +1,176 @@
+// Package evm 產生在以太坊等 EVM 鏈上驗證洗牌所需的輸入，讓鏈上遊戲可以按同一次洗牌結算
+//
+// 牌組在鏈上以每張牌一個字節表示（見 CardIndex），牌組摘要為這些字節的 keccak256，
+// 與 Solidity 的 keccak256(deck) 相同。VerifierSource 是參考的驗證合約，
+// 它在鏈上重現 drandshuffle/v1 的洗牌；Calldata 產生調用該合約 verifyShuffle 的交易數據。
+package evm
+
+import (
+	_ "embed"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+
+	"golang.org/x/crypto/sha3"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+)
+
+// VerifierSource 是參考驗證合約 DrandShuffleVerifier 的 Solidity 源碼
+// 合約只支持標準52張撲克牌，且不驗證 drand 的 BLS 簽名，隨機性須由調用方另外確認
+//
+//go:embed DrandShuffleVerifier.sol
+var VerifierSource string
+
+// VerifySignature 是驗證合約 verifyShuffle 函數的 ABI 簽名
+const VerifySignature = "verifyShuffle(uint64,bytes32,string,bytes)"
+
+// VerifySelector 返回 verifyShuffle 的函數選擇器，即 VerifySignature 的 keccak256 的前 4 字節
+func VerifySelector() [4]byte {
+	var selector [4]byte
+	digest := Keccak256([]byte(VerifySignature))
+	copy(selector[:], digest[:4])
+	return selector
+}
+
+// Keccak256 返回數據的 keccak256 摘要，與 Solidity 的 keccak256 相同（不是 SHA3-256）
+func Keccak256(data []byte) [32]byte {
+	var sum [32]byte
+	h := sha3.NewLegacyKeccak256()
+	h.Write(data)
+	h.Sum(sum[:0])
+	return sum
+}
+
+// cardIndexes 是標準牌組中每張牌的序號
+var cardIndexes = buildCardIndexes()
+
+// buildCardIndexes 按 StandardDeckTemplate 的順序為每張牌編號
+func buildCardIndexes() map[drandshuffle.Card]uint8 {
+	deck := drandshuffle.StandardDeckTemplate.NewDeck()
+	indexes := make(map[drandshuffle.Card]uint8, len(deck))
+	for i, card := range deck {
+		indexes[card] = uint8(i)
+	}
+	return indexes
+}
+
+// CardIndex 返回牌在鏈上的編碼：花色序號 * 13 + 點數序號，即牌在 StandardDeckTemplate 中的位置
+// 花色依次為黑桃、紅心、方塊、梅花，點數依次為 A、2 到 10、J、Q、K；不在標準牌組中的牌返回包裝 ErrInvalidCard 的輸入錯誤
+func CardIndex(card drandshuffle.Card) (uint8, error) {
+	if index, ok := cardIndexes[card]; ok {
+		return index, nil
+	}
+	return 0, drandshuffle.NewInputError(fmt.Errorf("%w: %s 不在標準牌組中", drandshuffle.ErrInvalidCard, drandshuffle.CardToString(card)))
+}
+
+// EncodeDeck 將牌組編碼為 Solidity 的 bytes，每張牌一個字節，見 CardIndex
+func EncodeDeck(deck []drandshuffle.Card) ([]byte, error) {
+	encoded := make([]byte, len(deck))
+	for i, card := range deck {
+		index, err := CardIndex(card)
+		if err != nil {
+			return nil, err
+		}
+		encoded[i] = index
+	}
+	return encoded, nil
+}
+
+// DecodeDeck 解析 EncodeDeck 產生的字節，遇到超出標準牌組的字節時返回包裝 ErrInvalidCard 的輸入錯誤
+func DecodeDeck(encoded []byte) ([]drandshuffle.Card, error) {
+	standard := drandshuffle.StandardDeckTemplate.NewDeck()
+	deck := make([]drandshuffle.Card, len(encoded))
+	for i, b := range encoded {
+		if int(b) >= len(standard) {
+			return nil, drandshuffle.NewInputError(fmt.Errorf("%w: 第 %d 張牌的編碼 %d 超出標準牌組", drandshuffle.ErrInvalidCard, i+1, b))
+		}
+		deck[i] = standard[b]
+	}
+	return deck, nil
+}
+
+// DeckDigest 返回牌組在鏈上的摘要，即 EncodeDeck 結果的 keccak256
+func DeckDigest(deck []drandshuffle.Card) ([32]byte, error) {
+	encoded, err := EncodeDeck(deck)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return Keccak256(encoded), nil
+}
+
+// Inputs 是鏈上驗證一次洗牌所需的輸入
+type Inputs struct {
+	Round      uint64   // 使用的輪次號碼
+	Randomness [32]byte // 該輪 drand 的隨機性，對應 Solidity 的 bytes32
+	SessionID  string   // 遊戲局號
+	Deck       []byte   // EncodeDeck 編碼的牌組
+	DeckDigest [32]byte // Deck 的 keccak256
+}
+
+// NewInputs 從洗牌證明和牌組產生鏈上驗證的輸入，會先用 drandshuffle.VerifyProof 確認牌組與證明一致
+// 參考合約只實現標準52張撲克牌和沒有參與方貢獻的洗牌，其他證明返回輸入錯誤
+func NewInputs(proof *drandshuffle.ShuffleProof, deck []drandshuffle.Card) (*Inputs, error) {
+	if proof == nil {
+		return nil, drandshuffle.NewInputError(fmt.Errorf("缺少洗牌證明"))
+	}
+	if len(proof.Contributions) > 0 {
+		return nil, drandshuffle.NewInputError(fmt.Errorf("驗證合約不支持參與方貢獻"))
+	}
+	if len(proof.Randomness) != 32 {
+		return nil, drandshuffle.NewInputError(fmt.Errorf("隨機性須為 32 字節，實際為 %d 字節", len(proof.Randomness)))
+	}
+	if err := drandshuffle.VerifyProof(proof, drandshuffle.Poker52, deck); err != nil {
+		return nil, err
+	}
+	encoded, err := EncodeDeck(deck)
+	if err != nil {
+		return nil, err
+	}
+
+	inputs := &Inputs{
+		Round:      proof.Round,
+		SessionID:  proof.SessionID,
+		Deck:       encoded,
+		DeckDigest: Keccak256(encoded),
+	}
+	copy(inputs.Randomness[:], proof.Randomness)
+	return inputs, nil
+}
+
+// Calldata 返回調用驗證合約 verifyShuffle(round, randomness, sessionId, deck) 的 ABI 編碼交易數據
+func (in *Inputs) Calldata() []byte {
+	selector := VerifySelector()
+	data := append([]byte(nil), selector[:]...)
+
+	// 靜態參數和動態參數的偏移量，偏移量從參數區開頭（選擇器之後）計算
+	const headSize = 4 * 32
+	session := abiBytes([]byte(in.SessionID))
+	data = append(data, abiUint(in.Round)...)
+	data = append(data, in.Randomness[:]...)
+	data = append(data, abiUint(headSize)...)
+	data = append(data, abiUint(uint64(headSize+len(session)))...)
+	data = append(data, session...)
+	data = append(data, abiBytes(in.Deck)...)
+	return data
+}
+
+// Hex 返回帶 0x 前綴的十六進制字符串，錢包和 JSON-RPC 以此格式傳遞 bytes32 和交易數據
+func Hex(data []byte) string {
+	return "0x" + hex.EncodeToString(data)
+}
+
+// abiUint 將整數編碼為 32 字節的 ABI 字
+func abiUint(v uint64) []byte {
+	word := make([]byte, 32)
+	binary.BigEndian.PutUint64(word[24:], v)
+	return word
+}
+
+// abiBytes 將動態的 bytes 或 string 編碼為長度字加上補零到 32 字節倍數的內容
+func abiBytes(b []byte) []byte {
+	padded := (len(b) + 31) / 32 * 32
+	out := abiUint(uint64(len(b)))
+	out = append(out, b...)
+	return append(out, make([]byte, padded-len(b))...)
+}
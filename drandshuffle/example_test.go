@@ -0,0 +1,156 @@
+package drandshuffle_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/drandshuffletest"
+)
+
+// newExampleClient 創建使用假隨機信標源的 Client，使示例不依賴網絡
+func newExampleClient(latest uint64) (*drandshuffle.Client, *drandshuffletest.FakeBeaconSource) {
+	src := drandshuffletest.NewFakeBeaconSource(latest)
+	dm, err := drandshuffle.NewDrandManagerWithClient(src)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return drandshuffle.NewClientWithManager(dm), src
+}
+
+func Example() {
+	// 實際使用時通過 drandshuffle.NewClient() 連接 drand 公共中繼
+	client, _ := newExampleClient(1000)
+	defer client.Close()
+
+	deck, round, err := client.ShuffleLatest(context.Background(), "game_42")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println("輪次:", round)
+	fmt.Println("前五張:", deck[:5])
+	// Output:
+	// 輪次: 1000
+	// 前五張: [紅心J 方塊9 方塊2 方塊4 梅花4]
+}
+
+// 依賴單例的舊接口，需要連接 drand 網絡，因此示例只編譯不運行
+func ExampleGetShuffledDeck() {
+	deck, round, err := drandshuffle.GetShuffledDeck("game_42")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("輪次 %d 的第一張牌: %s\n", round, deck[0])
+}
+
+func ExampleClient_ShuffleAtRound() {
+	client, _ := newExampleClient(1000)
+	defer client.Close()
+
+	// 同一輪次和遊戲局號總是洗出同一副牌
+	first, _ := client.ShuffleAtRound(context.Background(), 990, "game_42")
+	again, _ := client.ShuffleAtRound(context.Background(), 990, "game_42")
+	other, _ := client.ShuffleAtRound(context.Background(), 990, "game_43")
+
+	fmt.Println(first[:3])
+	fmt.Println(slices.Equal(first, again), slices.Equal(first, other))
+
+	// 尚未發布的輪次不會洗牌
+	_, err := client.ShuffleAtRound(context.Background(), 1001, "game_42")
+	fmt.Println(errors.Is(err, drandshuffle.ErrRoundNotFound))
+	// Output:
+	// [紅心2 紅心6 梅花4]
+	// true false
+	// true
+}
+
+func ExampleClient_Verify() {
+	client, _ := newExampleClient(1000)
+	defer client.Close()
+	ctx := context.Background()
+
+	deck, round, _ := client.ShuffleLatest(ctx, "game_42")
+
+	// 公開輪次號碼和遊戲局號後，任何人都可以驗證發牌結果
+	fmt.Println(client.Verify(ctx, round, "game_42", deck))
+
+	// 被調換過的牌組無法通過驗證
+	deck[0], deck[1] = deck[1], deck[0]
+	err := client.Verify(ctx, round, "game_42", deck)
+	fmt.Println(errors.Is(err, drandshuffle.ErrDeckMismatch))
+	// Output:
+	// <nil>
+	// true
+}
+
+func ExampleShuffleBuilder() {
+	client, _ := newExampleClient(1000)
+	defer client.Close()
+
+	// 玩家在輪次發布前提交的種子與隨機信標一起決定牌組
+	result, err := client.NewShuffle().
+		Deck(drandshuffle.Poker52).
+		Session("game_42").
+		Round(990).
+		WithContributions([]byte("alice"), []byte("bob")).
+		WithProof().
+		Do(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println("輪次:", result.Round)
+	fmt.Println(drandshuffle.VerifyProof(result.Proof, drandshuffle.Poker52, result.Deck))
+	// Output:
+	// 輪次: 990
+	// <nil>
+}
+
+func ExampleClient_Subscribe() {
+	client, src := newExampleClient(1000)
+	defer client.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	beacons := client.Subscribe(ctx)
+
+	// 新一輪發布後，後台獲取會把它發送給訂閱者；這裡主動刷新以免等待輪詢間隔
+	src.SetLatest(1001)
+	if _, _, err := client.Manager().GetLatestRandomnessWithin(ctx, 0, time.Second); err != nil {
+		log.Fatal(err)
+	}
+
+	beacon := <-beacons
+	deck := drandshuffle.NewShuffler(drandshuffle.StandardDeckTemplate).Shuffle(beacon.Randomness, "game_42")
+	fmt.Println("新輪次:", beacon.Round)
+	fmt.Println("第一張:", deck[0])
+	// Output:
+	// 新輪次: 1001
+	// 第一張: 方塊2
+}
+
+func ExampleShuffler_Shuffle() {
+	shuffler := drandshuffle.NewShuffler(drandshuffle.StandardDeckTemplate)
+
+	// 不連接網絡，直接使用已知的隨機性重現洗牌結果
+	randomness := make([]byte, 32)
+	deck := shuffler.Shuffle(randomness, "game_42")
+	fmt.Println(len(deck), deck[:3])
+	// Output:
+	// 52 [方塊4 方塊10 黑桃4]
+}
+
+func ExampleNewSessionID() {
+	id, err := drandshuffle.NewSessionID()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(len(id), drandshuffle.ValidateSessionID(id))
+	// Output:
+	// 37 <nil>
+}
@@ -0,0 +1,163 @@
+package drandshuffle
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"strconv"
+)
+
+// DefaultSnapshotEvery 是 ShuffleTrace 默認每隔多少步記錄一次牌組狀態
+const DefaultSnapshotEvery = 10
+
+// ShuffleTrace 是一次洗牌的逐步說明，可以序列化為 JSON 供逐步演示的頁面使用
+// 字節欄位以十六進制字符串表示，牌以 CardToString 的寫法表示；64 位元的整數以十進制字符串表示，
+// 避免 JavaScript 的數字損失精度
+type ShuffleTrace struct {
+	Algorithm   string         `json:"algorithm"` // ProofAlgorithm
+	Round       uint64         `json:"round"`
+	SessionID   string         `json:"session_id"`
+	Randomness  string         `json:"randomness"`   // 該輪隨機信標的隨機性
+	SessionHash string         `json:"session_hash"` // SHA256(隨機性 || 遊戲局號)
+	Seed        string         `json:"seed"`         // 洗牌使用的字節：隨機性 || SessionHash
+	Window      int            `json:"window"`       // 每一步讀取的位置在 Seed 中循環的範圍，即 len(Seed)-8
+	Initial     []string       `json:"initial"`      // 洗牌前的牌組
+	Steps       []ShuffleStep  `json:"steps"`        // Fisher-Yates 的每一步，從最後一張牌開始
+	Snapshots   []DeckSnapshot `json:"snapshots"`    // 每隔若干步的牌組狀態，最後一項總是最終結果
+	Final       []string       `json:"final"`        // 洗牌後的牌組，與 Shuffler.Shuffle 的結果相同
+}
+
+// ShuffleStep 是 Fisher-Yates 洗牌的一步：以 Seed[Offset:Offset+8] 的大端序整數除以 I+1 的餘數 J，交換位置 I 和 J 的牌
+type ShuffleStep struct {
+	Step   int    `json:"step"`   // 從 0 開始的步驟編號
+	I      int    `json:"i"`      // 這一步確定的位置，從牌數減 1 遞減到 1
+	Offset int    `json:"offset"` // 讀取的 8 個字節在 Seed 中的起點，即 I mod Window
+	Bytes  string `json:"bytes"`  // 讀取的 8 個字節
+	Value  string `json:"value"`  // 8 個字節的大端序無符號整數，十進制
+	J      int    `json:"j"`      // Value mod (I+1)，與 I 交換的位置
+	CardI  string `json:"card_i"` // 交換前位置 I 的牌
+	CardJ  string `json:"card_j"` // 交換前位置 J 的牌
+}
+
+// DeckSnapshot 是完成若干步之後的牌組狀態
+type DeckSnapshot struct {
+	AfterStep int      `json:"after_step"` // 完成的最後一步的編號
+	Deck      []string `json:"deck"`
+}
+
+// explainOptions 是 ExplainShuffle 的參數
+type explainOptions struct {
+	template *DeckTemplate
+	every    int
+}
+
+// ExplainOption 設定 ExplainShuffle 和 TraceShuffle 的參數
+type ExplainOption func(*explainOptions)
+
+// WithExplainDeck 設定洗牌使用的牌組模板，默認為 Poker52
+func WithExplainDeck(template *DeckTemplate) ExplainOption {
+	return func(o *explainOptions) {
+		o.template = template
+	}
+}
+
+// WithSnapshotEvery 設定每隔多少步記錄一次牌組狀態，默認為 DefaultSnapshotEvery；小於 1 時只記錄最終結果
+func WithSnapshotEvery(k int) ExplainOption {
+	return func(o *explainOptions) {
+		o.every = k
+	}
+}
+
+// ExplainShuffle 使用默認 Client 說明一次洗牌，見 Client.ExplainShuffle
+func ExplainShuffle(ctx context.Context, round uint64, sessionID string, opts ...ExplainOption) (*ShuffleTrace, error) {
+	c, err := DefaultClient()
+	if err != nil {
+		return nil, err
+	}
+	return c.ExplainShuffle(ctx, round, sessionID, opts...)
+}
+
+// ExplainShuffle 取得指定輪次的隨機信標，逐步記錄以遊戲局號洗牌的過程，用於向玩家說明洗牌如何由公開的隨機性決定
+// 結果與 NewShuffle().Session(sessionID).Round(round) 沒有貢獻時洗出的牌組相同；
+// round 為 Latest 時使用最新的隨機信標（嚴格輪次模式下不允許）
+func (c *Client) ExplainShuffle(ctx context.Context, round uint64, sessionID string, opts ...ExplainOption) (*ShuffleTrace, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := ValidateSessionID(sessionID); err != nil {
+		return nil, err
+	}
+
+	b := &ShuffleBuilder{client: c, round: round, sessionID: sessionID}
+	beacon, err := b.beacon(ctx, c.manager)
+	if err != nil {
+		return nil, err
+	}
+	trace := TraceShuffle(beacon.Randomness, sessionID, opts...)
+	trace.Round = beacon.Round
+	return trace, nil
+}
+
+// TraceShuffle 以給定的隨機性和遊戲局號逐步記錄洗牌的過程，不連接網絡；返回的 Round 為 0
+// 遊戲局號不經過 ValidateSessionID 的檢查，原樣參與洗牌
+func TraceShuffle(randomness []byte, sessionID string, opts ...ExplainOption) *ShuffleTrace {
+	o := explainOptions{template: Poker52, every: DefaultSnapshotEvery}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.template == nil {
+		o.template = Poker52
+	}
+
+	state := &shuffleState{hasher: sha256.New()}
+	seed := state.extend(randomness, sessionID)
+	deck := o.template.NewDeck()
+	trace := &ShuffleTrace{
+		Algorithm:   ProofAlgorithm,
+		SessionID:   sessionID,
+		Randomness:  hex.EncodeToString(randomness),
+		SessionHash: hex.EncodeToString(seed[len(randomness):]),
+		Seed:        hex.EncodeToString(seed),
+		Initial:     deckStrings(deck),
+		Steps:       []ShuffleStep{},
+		Snapshots:   []DeckSnapshot{},
+	}
+
+	// 與 shuffleInPlace 相同的步驟；派生的種子至少有 32 字節，不會進入其擴展分支
+	trace.Window = max(1, len(seed)-8)
+	for i := len(deck) - 1; i > 0; i-- {
+		pos := i % trace.Window
+		value := binary.BigEndian.Uint64(seed[pos : pos+8])
+		j := int(value % uint64(i+1))
+		step := ShuffleStep{
+			Step:   len(trace.Steps),
+			I:      i,
+			Offset: pos,
+			Bytes:  hex.EncodeToString(seed[pos : pos+8]),
+			Value:  strconv.FormatUint(value, 10),
+			J:      j,
+			CardI:  CardToString(deck[i]),
+			CardJ:  CardToString(deck[j]),
+		}
+		deck[i], deck[j] = deck[j], deck[i]
+		trace.Steps = append(trace.Steps, step)
+		if o.every > 0 && (step.Step+1)%o.every == 0 && i > 1 {
+			trace.Snapshots = append(trace.Snapshots, DeckSnapshot{AfterStep: step.Step, Deck: deckStrings(deck)})
+		}
+	}
+	state.wipe()
+
+	trace.Final = deckStrings(deck)
+	trace.Snapshots = append(trace.Snapshots, DeckSnapshot{AfterStep: len(trace.Steps) - 1, Deck: trace.Final})
+	return trace
+}
+
+// deckStrings 將牌組轉為 CardToString 的寫法
+func deckStrings(deck []Card) []string {
+	out := make([]string, len(deck))
+	for i, card := range deck {
+		out[i] = CardToString(card)
+	}
+	return out
+}
@@ -0,0 +1,73 @@
+package drandshuffle
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	nethttp "net/http"
+	"strings"
+)
+
+// faultInjector 在連接中繼的 HTTP 請求中注入故障，用於系統地測試故障轉移、過期和驗證失敗的處理
+// 只有以 drandshufflefaults 構建標籤編譯時才能通過 WithFaults 設定，正常構建中 DrandManager 的 faults 總是 nil
+type faultInjector interface {
+	// inject 在隨機信標的請求發出前調用，可以在返回前延遲；
+	// 返回錯誤時丟棄請求，不發往中繼，corrupt 為 true 時破壞響應中的簽名
+	inject(req *nethttp.Request) (corrupt bool, err error)
+}
+
+// faultTransport 在隨機信標的請求上應用 faultInjector，獲取鏈信息等其他請求不受影響
+// 故障發生在 drand 客戶端驗證簽名之前，破壞的簽名會經過與真實中繼相同的驗證路徑
+type faultTransport struct {
+	base   nethttp.RoundTripper
+	faults faultInjector
+}
+
+// RoundTrip 注入故障後通過 base 發出請求
+func (t faultTransport) RoundTrip(req *nethttp.Request) (*nethttp.Response, error) {
+	base := t.base
+	if base == nil {
+		base = nethttp.DefaultTransport
+	}
+	if !strings.Contains(req.URL.Path, "/public/") {
+		return base.RoundTrip(req)
+	}
+
+	corrupt, err := t.faults.inject(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil || !corrupt || resp.StatusCode != nethttp.StatusOK {
+		return resp, err
+	}
+	return corruptSignature(resp)
+}
+
+// corruptSignature 翻轉響應中簽名的最後一個十六進制字符，隨機性保持不變
+func corruptSignature(resp *nethttp.Response) (*nethttp.Response, error) {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("無法讀取響應: %w", err)
+	}
+	var record map[string]any
+	if err := json.Unmarshal(body, &record); err != nil {
+		return nil, fmt.Errorf("無法解析響應: %w", err)
+	}
+	if sig, ok := record["signature"].(string); ok && sig != "" {
+		last := "0"
+		if sig[len(sig)-1] == '0' {
+			last = "1"
+		}
+		record["signature"] = sig[:len(sig)-1] + last
+	}
+	if body, err = json.Marshal(record); err != nil {
+		return nil, fmt.Errorf("無法編碼響應: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.Header.Del("Content-Length")
+	return resp, nil
+}
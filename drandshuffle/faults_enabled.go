@@ -0,0 +1,80 @@
+//go:build drandshufflefaults
+
+package drandshuffle
+
+import (
+	"errors"
+	nethttp "net/http"
+	"sync"
+	"time"
+)
+
+// errFaultDropped 是被 Faults.DropNext 丟棄的請求返回的錯誤
+var errFaultDropped = errors.New("drandshuffle: request dropped by injected fault")
+
+// Faults 是注入 DrandManager 連接中繼的 HTTP 請求的故障，零值不注入任何故障，可以被多個 goroutine 並發設定
+// 只在以 drandshufflefaults 構建標籤編譯時存在，例如 go test -tags drandshufflefaults ./tests；
+// 只影響隨機信標的請求（/public/...），不影響獲取鏈信息，也不影響 NewDrandManagerWithClient 等不經過中繼的 DrandManager
+type Faults struct {
+	mu      sync.Mutex
+	drop    int
+	corrupt int
+	delay   time.Duration
+}
+
+// DropNext 讓接下來的 n 個請求不發往中繼，直接返回錯誤，與 CorruptNext 同時設定時先計算丟棄
+func (f *Faults) DropNext(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.drop += n
+}
+
+// CorruptNext 破壞接下來 n 個成功響應中的簽名，使 drand 客戶端的驗證失敗
+func (f *Faults) CorruptNext(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.corrupt += n
+}
+
+// SetDelay 讓之後的每個請求延遲 d 再發出，請求在延遲期間被取消時返回 ctx 的錯誤；傳入 0 時取消延遲
+func (f *Faults) SetDelay(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.delay = d
+}
+
+// inject 實現 faultInjector
+func (f *Faults) inject(req *nethttp.Request) (bool, error) {
+	f.mu.Lock()
+	delay := f.delay
+	drop := f.drop > 0
+	corrupt := !drop && f.corrupt > 0
+	if drop {
+		f.drop--
+	}
+	if corrupt {
+		f.corrupt--
+	}
+	f.mu.Unlock()
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			return false, req.Context().Err()
+		}
+	}
+	if drop {
+		return false, errFaultDropped
+	}
+	return corrupt, nil
+}
+
+// WithFaults 讓 DrandManager 連接中繼的請求經過 f 注入故障，只在以 drandshufflefaults 構建標籤編譯時存在
+func WithFaults(f *Faults) Option {
+	return func(dm *DrandManager) {
+		dm.faults = f
+	}
+}
@@ -0,0 +1,126 @@
+// Package bigtwo 從洗好的牌組為四位玩家各發十三張牌，適用於鋤大D（大老二）和十三張
+//
+// 發牌順序與 drandshuffle.Dealer.DealHands 相同：座位 0 取牌組頂部的十三張，座位 1 取接下來的十三張，依此類推，
+// 即座位 i 的手牌是牌組的第 13i 到 13i+12 張。相同的輪次和遊戲局號總是得到相同的手牌，玩家可以用洗牌證明獨立重現。
+// 花色大小和先出牌的規則各地不同，由 SuitOrder 選擇；發牌本身與規則無關。
+package bigtwo
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+)
+
+const (
+	// Players 是一局的玩家數量
+	Players = 4
+	// HandSize 是每位玩家的手牌張數
+	HandSize = 13
+	// DeckSize 是一局使用的牌數，即一副不含鬼牌的標準撲克牌
+	DeckSize = Players * HandSize
+)
+
+// values 是點數由小到大的順序：3 最小，2 最大
+var values = []string{"3", "4", "5", "6", "7", "8", "9", "10", "J", "Q", "K", "A", "2"}
+
+// SuitOrder 是花色由小到大的順序
+type SuitOrder [4]string
+
+var (
+	// SuitsHongKong 是香港和大部分地區的規則：方塊 < 梅花 < 紅心 < 黑桃，持有方塊3的玩家先出牌
+	SuitsHongKong = SuitOrder{"方塊", "梅花", "紅心", "黑桃"}
+	// SuitsTaiwan 是台灣的規則：梅花 < 方塊 < 紅心 < 黑桃，持有梅花3的玩家先出牌
+	SuitsTaiwan = SuitOrder{"梅花", "方塊", "紅心", "黑桃"}
+)
+
+// Rank 返回牌在此規則下的大小，由 0（最小的 3）到 51（最大的 2），先比點數再比花色
+// 不是標準撲克牌時返回 -1
+func (o SuitOrder) Rank(card drandshuffle.Card) int {
+	v := slices.Index(values, card.Value)
+	s := slices.Index(o[:], card.Suit)
+	if v < 0 || s < 0 {
+		return -1
+	}
+	return v*len(o) + s
+}
+
+// Lowest 返回此規則下最小的牌，持有它的玩家先出牌
+func (o SuitOrder) Lowest() drandshuffle.Card {
+	return drandshuffle.Card{Suit: o[0], Value: values[0]}
+}
+
+// Game 是一局已發完牌的鋤大D或十三張
+type Game struct {
+	Round     uint64                     // 使用的輪次號碼
+	RoundTime time.Time                  // 該輪隨機信標的發布時間，未知時為零值
+	SessionID string                     // 遊戲局號
+	Hands     [][]drandshuffle.Card      // 按座位順序排列的手牌，每位玩家十三張，保持發牌的順序
+	Proof     *drandshuffle.ShuffleProof // 洗牌證明，沒有時為 nil
+}
+
+// Deal 將 deck 按座位順序分成四手，每手十三張
+// deck 必須是一副 52 張的標準撲克牌，例如以 drandshuffle.Poker52 洗出的牌組；張數不符、含有其他牌或有重複的牌時返回輸入錯誤
+func Deal(deck []drandshuffle.Card) ([][]drandshuffle.Card, error) {
+	if len(deck) != DeckSize {
+		return nil, drandshuffle.NewInputError(fmt.Errorf("%w: 牌組有 %d 張牌，需要 %d 張", drandshuffle.ErrInvalidConfig, len(deck), DeckSize))
+	}
+	seen := make(map[drandshuffle.Card]bool, len(deck))
+	for _, card := range deck {
+		if SuitsHongKong.Rank(card) < 0 {
+			return nil, drandshuffle.NewInputError(fmt.Errorf("%w: %s 不是標準撲克牌", drandshuffle.ErrInvalidCard, drandshuffle.CardToString(card)))
+		}
+		if seen[card] {
+			return nil, drandshuffle.NewInputError(fmt.Errorf("%w: %s 重複", drandshuffle.ErrInvalidCard, drandshuffle.CardToString(card)))
+		}
+		seen[card] = true
+	}
+	return drandshuffle.NewDealer(deck).DealHands(Players, HandSize)
+}
+
+// NewGame 從 ShuffleBuilder.Do 的結果發出一局牌
+// 結果附帶證明時同時保存證明；sessionID 應與洗牌時使用的遊戲局號相同，與證明中的遊戲局號不一致時返回輸入錯誤
+func NewGame(result *drandshuffle.ShuffleResult, sessionID string) (*Game, error) {
+	if result == nil {
+		return nil, drandshuffle.NewInputError(fmt.Errorf("%w: 缺少洗牌結果", drandshuffle.ErrInvalidConfig))
+	}
+	if result.Proof != nil && result.Proof.SessionID != sessionID {
+		return nil, drandshuffle.NewInputError(fmt.Errorf("%w: 遊戲局號 %s 與洗牌證明的遊戲局號 %s 不一致", drandshuffle.ErrInvalidConfig, sessionID, result.Proof.SessionID))
+	}
+	hands, err := Deal(result.Deck)
+	if err != nil {
+		return nil, fmt.Errorf("無法發牌: %w", err)
+	}
+	return &Game{
+		Round:     result.Round,
+		RoundTime: result.RoundTime,
+		SessionID: sessionID,
+		Hands:     hands,
+		Proof:     result.Proof,
+	}, nil
+}
+
+// Leader 返回持有 order.Lowest() 的座位，即鋤大D中先出牌的玩家
+func (g *Game) Leader(order SuitOrder) int {
+	lowest := order.Lowest()
+	for seat, hand := range g.Hands {
+		if slices.Contains(hand, lowest) {
+			return seat
+		}
+	}
+	return -1
+}
+
+// SortedHand 返回座位 seat 按 order 由小到大排列的手牌副本，用於顯示；Hands 中的發牌順序不變
+// seat 不是有效的座位時返回 nil
+func (g *Game) SortedHand(seat int, order SuitOrder) []drandshuffle.Card {
+	if seat < 0 || seat >= len(g.Hands) {
+		return nil
+	}
+	hand := slices.Clone(g.Hands[seat])
+	slices.SortFunc(hand, func(a, b drandshuffle.Card) int {
+		return order.Rank(a) - order.Rank(b)
+	})
+	return hand
+}
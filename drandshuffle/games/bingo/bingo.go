@@ -0,0 +1,148 @@
+// Package bingo 從隨機信標和卡號生成 75 球賓果卡，每一列的號碼限定在該列的範圍內
+//
+// 卡片為 5×5：B 列取 1–15、I 列取 16–30、N 列取 31–45、G 列取 46–60、O 列取 61–75，中間格為免費格。
+// 種子是 drandshuffle.Client.NewRandForSession 以卡號作為遊戲局號派生的 RandProof.Seed；
+// 以 drandshuffle.NewDRBG(種子) 依次為 B 到 O 列做部分 Fisher-Yates 洗牌：該列的 15 個號碼由小到大排列，
+// 第 i 次（i 從 0 開始）以 i + Intn(15-i) 選出位置與 i 交換，前 5 個號碼（N 列為 4 個）由上到下填入該列。
+// 相同的輪次和卡號總是得到相同的卡片，監管方取得證明即可用 Verify 獨立重現；叫號順序可以用 keno.NewDraw(…, Balls, Balls) 抽出。
+package bingo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+)
+
+const (
+	// Size 是卡片的行數和列數
+	Size = 5
+	// ColumnRange 是每一列可用的號碼數量
+	ColumnRange = 15
+	// Balls 是號碼的總數，號碼為 1 到 75
+	Balls = Size * ColumnRange
+	// Free 是中間免費格在 Grid 中的值
+	Free = 0
+)
+
+// Letters 是各列的字母，Letter 按號碼返回對應的字母
+var Letters = [Size]string{"B", "I", "N", "G", "O"}
+
+// ErrCardMismatch 表示卡片記錄的號碼與證明重新生成的號碼不一致
+var ErrCardMismatch = errors.New("bingo: card mismatch")
+
+// Grid 是卡片的號碼，Grid[行][列]，中間格為 Free
+type Grid [Size][Size]int
+
+// Card 是一張已生成的賓果卡
+type Card struct {
+	Round     uint64                  // 使用的輪次號碼
+	RoundTime time.Time               // 該輪隨機信標的發布時間，未知時為零值
+	CardID    string                  // 卡號，即派生種子時使用的遊戲局號
+	Grid      Grid                    // 卡片的號碼
+	Proof     *drandshuffle.RandProof // 派生種子的證明
+}
+
+// NewCard 以指定輪次的隨機信標和卡號生成賓果卡
+// round 為 drandshuffle.Latest 時使用最新的隨機信標；卡號須通過 drandshuffle.ValidateSessionID 的檢查，
+// 同一局的每張卡應使用不同的卡號
+func NewCard(ctx context.Context, c *drandshuffle.Client, round uint64, cardID string) (*Card, error) {
+	_, proof, err := c.NewRandForSession(ctx, round, cardID)
+	if err != nil {
+		return nil, err
+	}
+	return &Card{
+		Round:     proof.Round,
+		RoundTime: proof.RoundTime,
+		CardID:    cardID,
+		Grid:      NewGrid(proof.Seed),
+		Proof:     proof,
+	}, nil
+}
+
+// NewGrid 以種子生成卡片的號碼，算法見包的說明
+func NewGrid(seed []byte) Grid {
+	drbg := drandshuffle.NewDRBG(seed)
+	var grid Grid
+	for col := 0; col < Size; col++ {
+		var numbers [ColumnRange]int
+		for i := range numbers {
+			numbers[i] = col*ColumnRange + i + 1
+		}
+		rows := Size
+		if col == Size/2 {
+			rows = Size - 1
+		}
+		for i := 0; i < rows; i++ {
+			j := i + drbg.Intn(ColumnRange-i)
+			numbers[i], numbers[j] = numbers[j], numbers[i]
+		}
+		next := 0
+		for row := 0; row < Size; row++ {
+			if row == Size/2 && col == Size/2 {
+				grid[row][col] = Free
+				continue
+			}
+			grid[row][col] = numbers[next]
+			next++
+		}
+	}
+	return grid
+}
+
+// Verify 以證明重新派生種子並重新生成卡片，檢查卡片記錄的號碼、輪次和卡號是否與證明一致
+// 不一致時返回包裝 ErrCardMismatch 的驗證錯誤；隨機信標本身的簽名應另外用 Verifier 驗證 Proof.Beacon()
+func Verify(card *Card) error {
+	if card == nil || card.Proof == nil {
+		return drandshuffle.NewInputError(fmt.Errorf("%w: 缺少卡片或證明", drandshuffle.ErrInvalidConfig))
+	}
+	if err := drandshuffle.VerifyRandProof(card.Proof); err != nil {
+		return err
+	}
+	if card.Round != card.Proof.Round || card.CardID != card.Proof.SessionID {
+		return drandshuffle.NewVerificationError(fmt.Errorf("%w: 卡片的輪次 %d 和卡號 %s 與證明不符", ErrCardMismatch, card.Round, card.CardID))
+	}
+	if want := NewGrid(card.Proof.Seed); card.Grid != want {
+		return drandshuffle.NewVerificationError(fmt.Errorf("%w: 卡號 %s 的號碼與證明重新生成的不一致", ErrCardMismatch, card.CardID))
+	}
+	return nil
+}
+
+// Letter 返回號碼所在列的字母，號碼不在 1 到 Balls 之間時返回空字符串
+func Letter(ball int) string {
+	if ball < 1 || ball > Balls {
+		return ""
+	}
+	return Letters[(ball-1)/ColumnRange]
+}
+
+// Wins 報告叫出 called 中的號碼後，卡片是否有完整的一行、一列或一條對角線，中間的免費格總是視為已叫出
+func (c *Card) Wins(called []int) bool {
+	var marked [Balls + 1]bool
+	for _, n := range called {
+		if n >= 1 && n <= Balls {
+			marked[n] = true
+		}
+	}
+	at := func(row, col int) bool {
+		n := c.Grid[row][col]
+		return n == Free || (n >= 1 && n <= Balls && marked[n])
+	}
+
+	diag, anti := true, true
+	for i := 0; i < Size; i++ {
+		row, col := true, true
+		for j := 0; j < Size; j++ {
+			row = row && at(i, j)
+			col = col && at(j, i)
+		}
+		if row || col {
+			return true
+		}
+		diag = diag && at(i, i)
+		anti = anti && at(i, Size-1-i)
+	}
+	return diag || anti
+}
@@ -0,0 +1,154 @@
+// Package doudizhu 從洗好的 54 張牌組發出鬥地主的三手牌和三張底牌，並決定先叫地主的玩家
+//
+// 發牌順序與 drandshuffle.Dealer.DealHands 相同：座位 0 取牌組頂部的十七張，座位 1、2 依次取接下來的十七張，
+// 最後三張是底牌。先叫地主的座位由洗牌證明中的隨機性和遊戲局號派生，見 FirstBidder；
+// 手牌、底牌和叫地主的順序都只由同一個輪次和遊戲局號決定，玩家可以用洗牌證明獨立重現。
+package doudizhu
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+)
+
+const (
+	// Players 是一局的玩家數量
+	Players = 3
+	// HandSize 是每位玩家的手牌張數
+	HandSize = 17
+	// BottomSize 是底牌的張數，叫到地主的玩家取走底牌
+	BottomSize = 3
+	// DeckSize 是一局使用的牌數，即一副含大小王的撲克牌
+	DeckSize = Players*HandSize + BottomSize
+)
+
+// FirstBidderAlgorithm 是 FirstBidder 派生先叫地主座位的算法標識，也是派生種子時的域分隔前綴
+const FirstBidderAlgorithm = "drandshuffle/doudizhu/first-bidder/v1"
+
+var (
+	// SmallJoker 是小王，沒有花色，CardToString 寫作 "小王"
+	SmallJoker = drandshuffle.Card{Suit: "小", Value: "王"}
+	// BigJoker 是大王，沒有花色，CardToString 寫作 "大王"
+	BigJoker = drandshuffle.Card{Suit: "大", Value: "王"}
+)
+
+// Deck54 是鬥地主使用的牌組模板：標準52張撲克牌之後接小王和大王
+// 洗牌時以 ShuffleBuilder.Deck(Deck54) 指定，驗證時以 drandshuffle.VerifyProof(proof, Deck54, deck) 重新洗牌
+var Deck54 = drandshuffle.NewDeckTemplate(drandshuffle.DeckSpec{
+	Suits:  drandshuffle.StandardDeckSpec.Suits,
+	Values: drandshuffle.StandardDeckSpec.Values,
+	Extras: []drandshuffle.Card{SmallJoker, BigJoker},
+})
+
+// values 是點數由小到大的順序：3 最小，2 之後是小王和大王
+var values = []string{"3", "4", "5", "6", "7", "8", "9", "10", "J", "Q", "K", "A", "2"}
+
+// Rank 返回牌的大小，由 0（3）到 12（2），小王為 13，大王為 14；鬥地主不比花色，同點數的牌大小相同
+// 不是 Deck54 中的牌時返回 -1
+func Rank(card drandshuffle.Card) int {
+	switch card {
+	case SmallJoker:
+		return len(values)
+	case BigJoker:
+		return len(values) + 1
+	}
+	if !slices.Contains(drandshuffle.StandardDeckSpec.Suits, card.Suit) {
+		return -1
+	}
+	return slices.Index(values, card.Value)
+}
+
+// Game 是一局已發完牌的鬥地主
+type Game struct {
+	Round       uint64                     // 使用的輪次號碼
+	RoundTime   time.Time                  // 該輪隨機信標的發布時間，未知時為零值
+	SessionID   string                     // 遊戲局號
+	Hands       [][]drandshuffle.Card      // 按座位順序排列的手牌，每位玩家十七張，保持發牌的順序
+	Bottom      []drandshuffle.Card        // 三張底牌
+	FirstBidder int                        // 先叫地主的座位，見 FirstBidder
+	Proof       *drandshuffle.ShuffleProof // 洗牌證明
+}
+
+// Deal 將 deck 按座位順序分成三手，每手十七張，最後三張為底牌
+// deck 必須是以 Deck54 洗出的 54 張牌；張數不符、含有其他牌或有重複的牌（包括重複的大小王）時返回輸入錯誤
+func Deal(deck []drandshuffle.Card) (hands [][]drandshuffle.Card, bottom []drandshuffle.Card, err error) {
+	if len(deck) != DeckSize {
+		return nil, nil, drandshuffle.NewInputError(fmt.Errorf("%w: 牌組有 %d 張牌，需要 %d 張", drandshuffle.ErrInvalidConfig, len(deck), DeckSize))
+	}
+	seen := make(map[drandshuffle.Card]bool, len(deck))
+	for _, card := range deck {
+		if Rank(card) < 0 {
+			return nil, nil, drandshuffle.NewInputError(fmt.Errorf("%w: %s 不是鬥地主使用的牌", drandshuffle.ErrInvalidCard, drandshuffle.CardToString(card)))
+		}
+		if seen[card] {
+			return nil, nil, drandshuffle.NewInputError(fmt.Errorf("%w: %s 重複", drandshuffle.ErrInvalidCard, drandshuffle.CardToString(card)))
+		}
+		seen[card] = true
+	}
+	dealer := drandshuffle.NewDealer(deck)
+	hands, _ = dealer.DealHands(Players, HandSize)
+	bottom, _ = dealer.Deal(BottomSize)
+	return hands, bottom, nil
+}
+
+// FirstBidder 從洗牌證明派生先叫地主的座位（0 到 2）
+// 種子為 SHA256(FirstBidderAlgorithm || Randomness || SessionID)，以 drandshuffle.NewDRBG(種子).Intn(3) 取得座位；
+// 只使用隨機信標和遊戲局號，與參與方的貢獻無關，審計方取得證明即可重新計算
+func FirstBidder(proof *drandshuffle.ShuffleProof) (int, error) {
+	if proof == nil {
+		return 0, drandshuffle.NewInputError(fmt.Errorf("%w: 缺少洗牌證明", drandshuffle.ErrInvalidConfig))
+	}
+	h := sha256.New()
+	h.Write([]byte(FirstBidderAlgorithm))
+	h.Write(proof.Randomness)
+	h.Write([]byte(proof.SessionID))
+	return drandshuffle.NewDRBG(h.Sum(nil)).Intn(Players), nil
+}
+
+// NewGame 從 ShuffleBuilder.Do 的結果發出一局牌
+// 洗牌必須使用 Deck54 並調用 WithProof，先叫地主的座位由證明派生；sessionID 必須與證明中的遊戲局號相同，否則返回輸入錯誤
+func NewGame(result *drandshuffle.ShuffleResult, sessionID string) (*Game, error) {
+	if result == nil {
+		return nil, drandshuffle.NewInputError(fmt.Errorf("%w: 缺少洗牌結果", drandshuffle.ErrInvalidConfig))
+	}
+	if result.Proof == nil {
+		return nil, drandshuffle.NewInputError(fmt.Errorf("%w: 缺少洗牌證明，洗牌時應調用 WithProof", drandshuffle.ErrInvalidConfig))
+	}
+	if result.Proof.SessionID != sessionID {
+		return nil, drandshuffle.NewInputError(fmt.Errorf("%w: 遊戲局號 %s 與洗牌證明的遊戲局號 %s 不一致", drandshuffle.ErrInvalidConfig, sessionID, result.Proof.SessionID))
+	}
+	hands, bottom, err := Deal(result.Deck)
+	if err != nil {
+		return nil, fmt.Errorf("無法發牌: %w", err)
+	}
+	bidder, _ := FirstBidder(result.Proof)
+	return &Game{
+		Round:       result.Round,
+		RoundTime:   result.RoundTime,
+		SessionID:   sessionID,
+		Hands:       hands,
+		Bottom:      bottom,
+		FirstBidder: bidder,
+		Proof:       result.Proof,
+	}, nil
+}
+
+// LandlordHand 返回座位 seat 成為地主後的二十張牌：手牌之後接底牌；seat 不是有效的座位時返回 nil
+func (g *Game) LandlordHand(seat int) []drandshuffle.Card {
+	if seat < 0 || seat >= len(g.Hands) {
+		return nil
+	}
+	return append(slices.Clone(g.Hands[seat]), g.Bottom...)
+}
+
+// SortedHand 返回按 Rank 由小到大排列的牌的副本，同點數的牌保持原有的順序，用於顯示
+func SortedHand(cards []drandshuffle.Card) []drandshuffle.Card {
+	sorted := slices.Clone(cards)
+	slices.SortStableFunc(sorted, func(a, b drandshuffle.Card) int {
+		return Rank(a) - Rank(b)
+	})
+	return sorted
+}
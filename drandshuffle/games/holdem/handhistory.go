@@ -0,0 +1,285 @@
+package holdem
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+)
+
+// Street 是一局中的下注輪
+type Street int
+
+const (
+	// Preflop 翻牌前
+	Preflop Street = iota
+	// FlopStreet 翻牌圈
+	FlopStreet
+	// TurnStreet 轉牌圈
+	TurnStreet
+	// RiverStreet 河牌圈
+	RiverStreet
+)
+
+// Player 是手牌歷史中一個座位上的玩家
+type Player struct {
+	Name  string // 玩家名稱，不可為空，不可含有冒號、方括號或換行
+	Stack int64  // 開局時的籌碼，以分為單位；0 時使用一百個大盲注
+}
+
+// Action 是玩家在某個下注輪的一個動作，Text 按手牌歷史的寫法原樣輸出
+// 例如 Action{Street: Preflop, Player: "alice", Text: "raises $0.04 to $0.06"}
+type Action struct {
+	Street Street
+	Player string
+	Text   string
+}
+
+// HandHistory 是導出手牌歷史時牌局本身沒有記錄的資料，零值的欄位使用默認值
+type HandHistory struct {
+	HandID     uint64    // 手牌編號；0 時由輪次和遊戲局號派生，同一局總是得到相同的編號
+	Table      string    // 牌桌名稱；空時為 "drand <輪次>"
+	Time       time.Time // 牌局時間；零值時使用 Game.RoundTime
+	SmallBlind int64     // 小盲注，以分為單位；0 時為 1
+	BigBlind   int64     // 大盲注，以分為單位；0 時為小盲注的兩倍
+	Players    []Player  // 按座位順序排列的玩家，數量須與手牌相同；空時命名為 Player1、Player2 等
+	Button     int       // 按鈕位的座位號，從 1 開始；0 時為 1
+	Hero       string    // 以 "Dealt to" 顯示手牌的玩家；空時為第一位玩家
+	Actions    []Action  // 盲注之後的下注動作，按發生順序排列；沒有時所有玩家直接攤牌
+}
+
+// WriteHandHistory 以 PokerStars 的手牌歷史格式將牌局寫入 w，可以導入 PokerTracker、Holdem Manager 等工具
+// 所有玩家的手牌都在攤牌段落中公開；輪次、遊戲局號和證明摘要寫在 SUMMARY 段落末尾，
+// 玩家可以用它們找到對應的洗牌證明並重現這一局。多局寫入同一文件時，各局之間應以空行分隔
+func (g *Game) WriteHandHistory(w io.Writer, h HandHistory) error {
+	if len(g.Hands) < MinPlayers || len(g.Board) != BoardSize {
+		return drandshuffle.NewInputError(fmt.Errorf("%w: 牌局不完整", drandshuffle.ErrInvalidConfig))
+	}
+	players, err := h.players(len(g.Hands))
+	if err != nil {
+		return err
+	}
+	h = h.withDefaults(g)
+	if h.Time.IsZero() {
+		return drandshuffle.NewInputError(fmt.Errorf("%w: 缺少牌局時間", drandshuffle.ErrInvalidConfig))
+	}
+	if h.Button < 1 || h.Button > len(players) {
+		return drandshuffle.NewInputError(fmt.Errorf("%w: 按鈕位 %d 不在 1 到 %d 之間", drandshuffle.ErrInvalidConfig, h.Button, len(players)))
+	}
+	hero := seatOf(players, h.Hero)
+	if hero < 0 {
+		return drandshuffle.NewInputError(fmt.Errorf("%w: 玩家 %q 不在牌桌上", drandshuffle.ErrInvalidConfig, h.Hero))
+	}
+
+	hands := make([]string, len(g.Hands))
+	for i, hand := range g.Hands {
+		if hands[i], err = cardCodes(hand); err != nil {
+			return err
+		}
+	}
+	board, err := cardCodes(g.Board)
+	if err != nil {
+		return err
+	}
+	flop, turn, river := board[:8], board[9:11], board[12:]
+
+	// 單挑時按鈕位是小盲注，否則按鈕位之後的兩個座位依次是小盲注和大盲注
+	button := h.Button - 1
+	sb, bb := (button+1)%len(players), (button+2)%len(players)
+	if len(players) == 2 {
+		sb, bb = button, (button+1)%len(players)
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "PokerStars Hand #%d:  Hold'em No Limit (%s/%s USD) - %s\n",
+		h.HandID, formatAmount(h.SmallBlind), formatAmount(h.BigBlind), formatTime(h.Time))
+	fmt.Fprintf(&b, "Table '%s' %d-max Seat #%d is the button\n", h.Table, tableSize(len(players)), h.Button)
+	for i, p := range players {
+		fmt.Fprintf(&b, "Seat %d: %s (%s in chips)\n", i+1, p.Name, formatAmount(p.Stack))
+	}
+	fmt.Fprintf(&b, "%s: posts small blind %s\n", players[sb].Name, formatAmount(h.SmallBlind))
+	fmt.Fprintf(&b, "%s: posts big blind %s\n", players[bb].Name, formatAmount(h.BigBlind))
+
+	b.WriteString("*** HOLE CARDS ***\n")
+	fmt.Fprintf(&b, "Dealt to %s [%s]\n", players[hero].Name, hands[hero])
+	writeActions(&b, h.Actions, Preflop)
+	fmt.Fprintf(&b, "*** FLOP *** [%s]\n", flop)
+	writeActions(&b, h.Actions, FlopStreet)
+	fmt.Fprintf(&b, "*** TURN *** [%s] [%s]\n", flop, turn)
+	writeActions(&b, h.Actions, TurnStreet)
+	fmt.Fprintf(&b, "*** RIVER *** [%s %s] [%s]\n", flop, turn, river)
+	writeActions(&b, h.Actions, RiverStreet)
+
+	b.WriteString("*** SHOW DOWN ***\n")
+	for i, p := range players {
+		fmt.Fprintf(&b, "%s: shows [%s]\n", p.Name, hands[i])
+	}
+
+	b.WriteString("*** SUMMARY ***\n")
+	fmt.Fprintf(&b, "Board [%s]\n", board)
+	for i, p := range players {
+		position := ""
+		switch i {
+		case button:
+			position = " (button)"
+			if i == sb {
+				position = " (button) (small blind)"
+			}
+		case sb:
+			position = " (small blind)"
+		case bb:
+			position = " (big blind)"
+		}
+		fmt.Fprintf(&b, "Seat %d: %s%s showed [%s]\n", i+1, p.Name, position, hands[i])
+	}
+	fmt.Fprintf(&b, "drand round %d, session %s\n", g.Round, g.SessionID)
+	if g.Proof != nil {
+		fmt.Fprintf(&b, "drand proof sha256:%s\n", g.Proof.Digest())
+	}
+
+	if _, err := w.Write(b.Bytes()); err != nil {
+		return fmt.Errorf("無法寫入手牌歷史: %w", err)
+	}
+	return nil
+}
+
+// players 返回檢查過的玩家列表，未提供時按座位生成
+func (h HandHistory) players(n int) ([]Player, error) {
+	if len(h.Players) == 0 {
+		players := make([]Player, n)
+		for i := range players {
+			players[i].Name = fmt.Sprintf("Player%d", i+1)
+		}
+		return h.withStacks(players), nil
+	}
+	if len(h.Players) != n {
+		return nil, drandshuffle.NewInputError(fmt.Errorf("%w: 有 %d 位玩家，但牌局有 %d 手牌", drandshuffle.ErrInvalidConfig, len(h.Players), n))
+	}
+	seen := make(map[string]bool, n)
+	for _, p := range h.Players {
+		if p.Name == "" || strings.ContainsAny(p.Name, ":[]\r\n") || seen[p.Name] {
+			return nil, drandshuffle.NewInputError(fmt.Errorf("%w: 無效或重複的玩家名稱 %q", drandshuffle.ErrInvalidConfig, p.Name))
+		}
+		if p.Stack < 0 {
+			return nil, drandshuffle.NewInputError(fmt.Errorf("%w: 玩家 %q 的籌碼不能為負數", drandshuffle.ErrInvalidConfig, p.Name))
+		}
+		seen[p.Name] = true
+	}
+	return h.withStacks(append([]Player(nil), h.Players...)), nil
+}
+
+// withStacks 為沒有設定籌碼的玩家填入一百個大盲注
+func (h HandHistory) withStacks(players []Player) []Player {
+	for i := range players {
+		if players[i].Stack == 0 {
+			players[i].Stack = 100 * h.bigBlind()
+		}
+	}
+	return players
+}
+
+// withDefaults 返回填入默認值的副本
+func (h HandHistory) withDefaults(g *Game) HandHistory {
+	if h.HandID == 0 {
+		sum := sha256.Sum256([]byte(fmt.Sprintf("%d/%s", g.Round, g.SessionID)))
+		// PokerStars 的手牌編號不超過 13 位數字
+		h.HandID = binary.BigEndian.Uint64(sum[:8])%1_000_000_000_000 + 1
+	}
+	if h.Table == "" {
+		h.Table = fmt.Sprintf("drand %d", g.Round)
+	}
+	if h.Time.IsZero() {
+		h.Time = g.RoundTime
+	}
+	h.BigBlind = h.bigBlind()
+	if h.SmallBlind == 0 {
+		h.SmallBlind = 1
+	}
+	if h.Button == 0 {
+		h.Button = 1
+	}
+	if h.Hero == "" {
+		h.Hero = "Player1"
+		if len(h.Players) > 0 {
+			h.Hero = h.Players[0].Name
+		}
+	}
+	return h
+}
+
+// bigBlind 返回大盲注，未設定時為小盲注的兩倍
+func (h HandHistory) bigBlind() int64 {
+	switch {
+	case h.BigBlind != 0:
+		return h.BigBlind
+	case h.SmallBlind != 0:
+		return 2 * h.SmallBlind
+	default:
+		return 2
+	}
+}
+
+// writeActions 寫出指定下注輪的動作
+func writeActions(b *bytes.Buffer, actions []Action, street Street) {
+	for _, a := range actions {
+		if a.Street == street {
+			fmt.Fprintf(b, "%s: %s\n", a.Player, a.Text)
+		}
+	}
+}
+
+// seatOf 返回玩家的座位索引，不在牌桌上時返回 -1
+func seatOf(players []Player, name string) int {
+	for i, p := range players {
+		if p.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// cardCodes 將牌轉換為以空格分隔的兩字符代碼，如 "As Th"
+func cardCodes(cards []drandshuffle.Card) (string, error) {
+	codes := make([]string, len(cards))
+	for i, card := range cards {
+		code, err := drandshuffle.CardCode(card)
+		if err != nil {
+			return "", err
+		}
+		codes[i] = code
+	}
+	return strings.Join(codes, " "), nil
+}
+
+// formatAmount 將以分為單位的金額格式化為 "$0.02" 或 "$4" 的形式
+func formatAmount(cents int64) string {
+	if cents%100 == 0 {
+		return fmt.Sprintf("$%d", cents/100)
+	}
+	return fmt.Sprintf("$%d.%02d", cents/100, cents%100)
+}
+
+// formatTime 以 UTC 格式化牌局時間，能載入美東時區時附上 PokerStars 慣用的美東時間
+func formatTime(t time.Time) string {
+	const layout = "2006/01/02 15:04:05"
+	s := t.UTC().Format(layout) + " UTC"
+	if et, err := time.LoadLocation("America/New_York"); err == nil {
+		s += " [" + t.In(et).Format(layout) + " ET]"
+	}
+	return s
+}
+
+// tableSize 返回能容納 n 位玩家的最小常見牌桌人數
+func tableSize(n int) int {
+	for _, size := range []int{2, 6, 9} {
+		if n <= size {
+			return size
+		}
+	}
+	return MaxPlayers
+}
@@ -0,0 +1,102 @@
+// Package holdem 從洗好的牌組發出德州撲克的手牌和公共牌，並將完成的牌局導出為手牌歷史
+//
+// 發牌順序與示例程序相同：從牌組頂部依次為每位玩家發兩張手牌，之後發五張公共牌，不燒牌。
+// 相同的輪次、遊戲局號和玩家數量總是得到相同的牌局，玩家可以用洗牌證明獨立重現。
+package holdem
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+)
+
+const (
+	// MinPlayers 是一局的最少玩家數量
+	MinPlayers = 2
+	// MaxPlayers 是一局的最多玩家數量
+	MaxPlayers = 10
+	// HoleCards 是每位玩家的手牌張數
+	HoleCards = 2
+	// BoardSize 是公共牌的張數
+	BoardSize = 5
+)
+
+// Game 是一局已發完牌的德州撲克
+type Game struct {
+	Round     uint64                     // 使用的輪次號碼
+	RoundTime time.Time                  // 該輪隨機信標的發布時間，未知時為零值
+	SessionID string                     // 遊戲局號
+	Hands     [][]drandshuffle.Card      // 按座位順序排列的手牌，每位玩家兩張
+	Board     []drandshuffle.Card        // 公共牌：三張翻牌、一張轉牌、一張河牌
+	Proof     *drandshuffle.ShuffleProof // 洗牌證明，沒有時為 nil
+}
+
+// Deal 從 deck 頂部為 players 位玩家發手牌，之後發五張公共牌
+// 玩家數量不在 MinPlayers 到 MaxPlayers 之間時返回包裝 ErrInvalidConfig 的錯誤；牌不足時不發出任何牌
+func Deal(deck []drandshuffle.Card, players int) (hands [][]drandshuffle.Card, board []drandshuffle.Card, err error) {
+	if players < MinPlayers || players > MaxPlayers {
+		return nil, nil, drandshuffle.NewInputError(fmt.Errorf("%w: 玩家數量 %d 不在 %d 到 %d 之間", drandshuffle.ErrInvalidConfig, players, MinPlayers, MaxPlayers))
+	}
+
+	dealer := drandshuffle.NewDealer(deck)
+	if err := dealer.Require(players*HoleCards + BoardSize); err != nil {
+		return nil, nil, err
+	}
+	hands, _ = dealer.DealHands(players, HoleCards)
+	board, _ = dealer.Deal(BoardSize)
+	return hands, board, nil
+}
+
+// Plan 返回與 Deal 相同發牌順序的 drandshuffle.DealPlan，位置名稱為 "player<座位>.hole"（座位從 1 開始）、
+// "board.flop"、"board.turn" 和 "board.river"，驗證頁面可以用它標註牌組中每張牌的去向
+func Plan(players int) (*drandshuffle.DealPlan, error) {
+	if players < MinPlayers || players > MaxPlayers {
+		return nil, drandshuffle.NewInputError(fmt.Errorf("%w: 玩家數量 %d 不在 %d 到 %d 之間", drandshuffle.ErrInvalidConfig, players, MinPlayers, MaxPlayers))
+	}
+	steps := make([]drandshuffle.DealStep, 0, players+3)
+	for seat := 1; seat <= players; seat++ {
+		steps = append(steps, drandshuffle.DealStep{Slots: []string{fmt.Sprintf("player%d.hole", seat)}, Count: HoleCards})
+	}
+	steps = append(steps,
+		drandshuffle.DealStep{Slots: []string{"board.flop"}, Count: 3},
+		drandshuffle.DealStep{Slots: []string{"board.turn"}, Count: 1},
+		drandshuffle.DealStep{Slots: []string{"board.river"}, Count: 1},
+	)
+	return drandshuffle.NewDealPlan(fmt.Sprintf("holdem-%d", players), steps...)
+}
+
+// NewGame 從 ShuffleBuilder.Do 的結果發出一局牌
+// 結果附帶證明時同時保存證明；sessionID 應與洗牌時使用的遊戲局號相同
+func NewGame(result *drandshuffle.ShuffleResult, sessionID string, players int) (*Game, error) {
+	if result == nil {
+		return nil, drandshuffle.NewInputError(fmt.Errorf("%w: 缺少洗牌結果", drandshuffle.ErrInvalidConfig))
+	}
+	hands, board, err := Deal(result.Deck, players)
+	if err != nil {
+		return nil, fmt.Errorf("無法發牌: %w", err)
+	}
+	return &Game{
+		Round:     result.Round,
+		RoundTime: result.RoundTime,
+		SessionID: sessionID,
+		Hands:     hands,
+		Board:     board,
+		Proof:     result.Proof,
+	}, nil
+}
+
+// Flop 返回三張翻牌
+func (g *Game) Flop() []drandshuffle.Card {
+	return g.Board[:3]
+}
+
+// Turn 返回轉牌
+func (g *Game) Turn() drandshuffle.Card {
+	return g.Board[3]
+}
+
+// River 返回河牌
+func (g *Game) River() drandshuffle.Card {
+	return g.Board[4]
+}
@@ -0,0 +1,106 @@
+// Package keno 從隨機信標和開獎編號抽出基諾的號碼，例如從 1 到 80 中依次抽出 20 個
+//
+// 種子是 drandshuffle.Client.NewRandForSession 以開獎編號作為遊戲局號派生的 RandProof.Seed，
+// 號碼按 drandshuffle.NewPermutation(號碼池大小, 種子) 依次取得的索引加 1，保持抽出的順序。
+// 相同的輪次和開獎編號總是抽出相同的號碼，監管方取得證明即可用 Verify 獨立重現。
+package keno
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+)
+
+const (
+	// DefaultPool 是常見基諾的號碼池大小，號碼為 1 到 80
+	DefaultPool = 80
+	// DefaultCount 是常見基諾每期抽出的號碼數量
+	DefaultCount = 20
+)
+
+// ErrDrawMismatch 表示開獎記錄的號碼與證明重新抽出的號碼不一致
+var ErrDrawMismatch = errors.New("keno: draw mismatch")
+
+// Draw 是一期已開出的基諾
+type Draw struct {
+	Round     uint64                  // 使用的輪次號碼
+	RoundTime time.Time               // 該輪隨機信標的發布時間，未知時為零值
+	DrawID    string                  // 開獎編號，即派生種子時使用的遊戲局號
+	Pool      int                     // 號碼池大小，號碼為 1 到 Pool
+	Numbers   []int                   // 按抽出順序排列的號碼
+	Proof     *drandshuffle.RandProof // 派生種子的證明
+}
+
+// NewDraw 以指定輪次的隨機信標和開獎編號從 1 到 pool 中抽出 count 個不重複的號碼
+// round 為 drandshuffle.Latest 時使用最新的隨機信標；開獎編號須通過 drandshuffle.ValidateSessionID 的檢查，
+// 每一期應使用不同的開獎編號；count 不在 1 到 pool 之間時返回包裝 ErrInvalidConfig 的輸入錯誤
+func NewDraw(ctx context.Context, c *drandshuffle.Client, round uint64, drawID string, pool, count int) (*Draw, error) {
+	if err := checkSize(pool, count); err != nil {
+		return nil, err
+	}
+	_, proof, err := c.NewRandForSession(ctx, round, drawID)
+	if err != nil {
+		return nil, err
+	}
+	return &Draw{
+		Round:     proof.Round,
+		RoundTime: proof.RoundTime,
+		DrawID:    drawID,
+		Pool:      pool,
+		Numbers:   Numbers(proof.Seed, pool, count),
+		Proof:     proof,
+	}, nil
+}
+
+// Numbers 以種子從 1 到 pool 中依次抽出 count 個不重複的號碼，即 NewPermutation(pool, seed) 的前 count 個索引加 1
+func Numbers(seed []byte, pool, count int) []int {
+	numbers := drandshuffle.NewPermutation(pool, seed).Take(count)
+	for i := range numbers {
+		numbers[i]++
+	}
+	return numbers
+}
+
+// Verify 以證明重新派生種子並重新抽號，檢查開獎記錄的號碼、輪次和開獎編號是否與證明一致
+// 不一致時返回包裝 ErrDrawMismatch 的驗證錯誤；隨機信標本身的簽名應另外用 Verifier 驗證 Proof.Beacon()
+func Verify(d *Draw) error {
+	if d == nil || d.Proof == nil {
+		return drandshuffle.NewInputError(fmt.Errorf("%w: 缺少開獎記錄或證明", drandshuffle.ErrInvalidConfig))
+	}
+	if err := checkSize(d.Pool, len(d.Numbers)); err != nil {
+		return err
+	}
+	if err := drandshuffle.VerifyRandProof(d.Proof); err != nil {
+		return err
+	}
+	if d.Round != d.Proof.Round || d.DrawID != d.Proof.SessionID {
+		return drandshuffle.NewVerificationError(fmt.Errorf("%w: 開獎記錄的輪次 %d 和開獎編號 %s 與證明不符", ErrDrawMismatch, d.Round, d.DrawID))
+	}
+	if want := Numbers(d.Proof.Seed, d.Pool, len(d.Numbers)); !slices.Equal(d.Numbers, want) {
+		return drandshuffle.NewVerificationError(fmt.Errorf("%w: 開獎編號 %s 的號碼應為 %v", ErrDrawMismatch, d.DrawID, want))
+	}
+	return nil
+}
+
+// Hits 返回 picks 中被抽中的號碼數量，重複的選號只計算一次
+func (d *Draw) Hits(picks []int) int {
+	hits := 0
+	for i, n := range picks {
+		if !slices.Contains(picks[:i], n) && slices.Contains(d.Numbers, n) {
+			hits++
+		}
+	}
+	return hits
+}
+
+// checkSize 檢查號碼池大小和抽出的號碼數量
+func checkSize(pool, count int) error {
+	if pool < 1 || count < 1 || count > pool {
+		return drandshuffle.NewInputError(fmt.Errorf("%w: 無法從 %d 個號碼中抽出 %d 個", drandshuffle.ErrInvalidConfig, pool, count))
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+// Package scratch 從隨機信標、公開的獎項表和票號決定刮刮樂等即開型彩票的結果
+//
+// 支持兩種獎池：
+//   - 按概率抽獎（NewTicket）：每張票獨立抽獎，種子是 drandshuffle.Client.NewRandForSession 以票號作為遊戲局號派生的
+//     RandProof.Seed，以 drandshuffle.NewDRBG(種子).Intn(Total) 取得位置 r；
+//   - 有限獎池（NewPool）：整批 Total 張票不放回地分配獎項，種子以獎池編號派生，
+//     序號 k（從 0 開始）的票取 drandshuffle.NewPermutation(Total, 種子) 的第 k 個索引作為位置 r。
+//
+// 位置 r 按 Table.Prizes 的順序對應獎項：前 Count[0] 個位置是第一個獎項，接下來 Count[1] 個位置是第二個獎項，依此類推，
+// 剩下的位置不中獎。獎項表應在所用輪次的隨機信標發布前公開，記錄中的 Table.Digest 讓監管方確認結果所用的正是公開的獎項表。
+package scratch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+)
+
+// NoPrize 是不中獎時的獎項名稱
+const NoPrize = ""
+
+// TableAlgorithm 是 Table.Digest 的算法標識，也是計算摘要時的域分隔前綴
+const TableAlgorithm = "drandshuffle/scratch/table/v1"
+
+// ErrOutcomeMismatch 表示記錄的結果、輪次、票號或獎項表與證明重新計算的不一致
+var ErrOutcomeMismatch = errors.New("scratch: outcome mismatch")
+
+// Prize 是獎項表中的一個獎項
+type Prize struct {
+	Name  string `json:"name"`  // 獎項名稱，不能為空且在表中唯一
+	Count int    `json:"count"` // 獎項佔用的位置數量：有限獎池中為獎品數量，按概率抽獎時為權重
+}
+
+// Table 是公開的獎項表
+// 有限獎池中 Total 是整批的票數；按概率抽獎時 Total 是權重的分母，獎項的中獎概率為 Count / Total。
+// 所有 Count 的和不能超過 Total，剩餘的位置不中獎
+type Table struct {
+	Prizes []Prize `json:"prizes"`
+	Total  int     `json:"total"`
+}
+
+// Validate 檢查獎項表，不合法時返回包裝 ErrInvalidConfig 的輸入錯誤
+func (t Table) Validate() error {
+	if t.Total < 1 {
+		return drandshuffle.NewInputError(fmt.Errorf("%w: 獎項表的總數 %d 必須大於 0", drandshuffle.ErrInvalidConfig, t.Total))
+	}
+	names := make(map[string]bool, len(t.Prizes))
+	sum := 0
+	for _, p := range t.Prizes {
+		if p.Name == NoPrize {
+			return drandshuffle.NewInputError(fmt.Errorf("%w: 獎項名稱不能為空", drandshuffle.ErrInvalidConfig))
+		}
+		if names[p.Name] {
+			return drandshuffle.NewInputError(fmt.Errorf("%w: 獎項 %s 重複", drandshuffle.ErrInvalidConfig, p.Name))
+		}
+		names[p.Name] = true
+		if p.Count < 0 {
+			return drandshuffle.NewInputError(fmt.Errorf("%w: 獎項 %s 的數量 %d 不能為負數", drandshuffle.ErrInvalidConfig, p.Name, p.Count))
+		}
+		sum += p.Count
+		if sum > t.Total {
+			return drandshuffle.NewInputError(fmt.Errorf("%w: 獎項的數量之和超過總數 %d", drandshuffle.ErrInvalidConfig, t.Total))
+		}
+	}
+	return nil
+}
+
+// Digest 返回獎項表的 SHA-256 摘要（十六進制）
+// 輸入依次為 TableAlgorithm、Total（8 字節大端序）、獎項數量（8 字節大端序），
+// 以及每個獎項的名稱長度（8 字節大端序）、名稱的 UTF-8 字節和 Count（8 字節大端序）
+func (t Table) Digest() string {
+	h := sha256.New()
+	h.Write([]byte(TableAlgorithm))
+	var n [8]byte
+	put := func(v uint64) {
+		binary.BigEndian.PutUint64(n[:], v)
+		h.Write(n[:])
+	}
+	put(uint64(t.Total))
+	put(uint64(len(t.Prizes)))
+	for _, p := range t.Prizes {
+		put(uint64(len(p.Name)))
+		h.Write([]byte(p.Name))
+		put(uint64(p.Count))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// prizeAt 返回位置 r 對應的獎項名稱
+func (t Table) prizeAt(r int) string {
+	for _, p := range t.Prizes {
+		if r < p.Count {
+			return p.Name
+		}
+		r -= p.Count
+	}
+	return NoPrize
+}
+
+// Ticket 是一張按概率抽獎的彩票結果
+type Ticket struct {
+	Round       uint64                  // 使用的輪次號碼
+	RoundTime   time.Time               // 該輪隨機信標的發布時間，未知時為零值
+	TicketID    string                  // 票號，即派生種子時使用的遊戲局號
+	TableDigest string                  // 所用獎項表的 Table.Digest
+	Prize       string                  // 中得的獎項名稱，不中獎時為 NoPrize
+	Proof       *drandshuffle.RandProof // 派生種子的證明
+}
+
+// NewTicket 以指定輪次的隨機信標和票號按概率抽出一張彩票的結果
+// round 為 drandshuffle.Latest 時使用最新的隨機信標；票號須通過 drandshuffle.ValidateSessionID 的檢查，每張票應使用不同的票號
+func NewTicket(ctx context.Context, c *drandshuffle.Client, round uint64, ticketID string, table Table) (*Ticket, error) {
+	if err := table.Validate(); err != nil {
+		return nil, err
+	}
+	_, proof, err := c.NewRandForSession(ctx, round, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	return &Ticket{
+		Round:       proof.Round,
+		RoundTime:   proof.RoundTime,
+		TicketID:    ticketID,
+		TableDigest: table.Digest(),
+		Prize:       Outcome(proof.Seed, table),
+		Proof:       proof,
+	}, nil
+}
+
+// Outcome 以種子按概率抽出獎項，即 NewDRBG(seed).Intn(table.Total) 對應的獎項；table 應已通過 Validate
+func Outcome(seed []byte, table Table) string {
+	return table.prizeAt(drandshuffle.NewDRBG(seed).Intn(table.Total))
+}
+
+// Verify 以證明和公開的獎項表重新抽獎，檢查彩票記錄的獎項、輪次、票號和獎項表摘要是否與證明一致
+// 不一致時返回包裝 ErrOutcomeMismatch 的驗證錯誤；隨機信標本身的簽名應另外用 Verifier 驗證 Proof.Beacon()
+func Verify(ticket *Ticket, table Table) error {
+	if ticket == nil || ticket.Proof == nil {
+		return drandshuffle.NewInputError(fmt.Errorf("%w: 缺少彩票記錄或證明", drandshuffle.ErrInvalidConfig))
+	}
+	if err := table.Validate(); err != nil {
+		return err
+	}
+	if err := drandshuffle.VerifyRandProof(ticket.Proof); err != nil {
+		return err
+	}
+	if ticket.Round != ticket.Proof.Round || ticket.TicketID != ticket.Proof.SessionID {
+		return drandshuffle.NewVerificationError(fmt.Errorf("%w: 彩票的輪次 %d 和票號 %s 與證明不符", ErrOutcomeMismatch, ticket.Round, ticket.TicketID))
+	}
+	if digest := table.Digest(); ticket.TableDigest != digest {
+		return drandshuffle.NewVerificationError(fmt.Errorf("%w: 彩票使用的獎項表摘要 %s 與公開的獎項表 %s 不符", ErrOutcomeMismatch, ticket.TableDigest, digest))
+	}
+	if want := Outcome(ticket.Proof.Seed, table); ticket.Prize != want {
+		return drandshuffle.NewVerificationError(fmt.Errorf("%w: 票號 %s 的結果應為 %q", ErrOutcomeMismatch, ticket.TicketID, want))
+	}
+	return nil
+}
+
+// Pool 是一批不放回分配獎項的彩票，序號為 0 到 Table.Total-1
+type Pool struct {
+	Round       uint64                  // 使用的輪次號碼
+	RoundTime   time.Time               // 該輪隨機信標的發布時間，未知時為零值
+	PoolID      string                  // 獎池編號，即派生種子時使用的遊戲局號
+	Table       Table                   // 獎項表
+	TableDigest string                  // Table.Digest()
+	Proof       *drandshuffle.RandProof // 派生種子的證明
+}
+
+// NewPool 以指定輪次的隨機信標和獎池編號為整批彩票分配獎項
+// 每個獎項恰好分配 Count 次，整批售完時中獎的總數與獎項表一致；獎池編號須通過 drandshuffle.ValidateSessionID 的檢查
+func NewPool(ctx context.Context, c *drandshuffle.Client, round uint64, poolID string, table Table) (*Pool, error) {
+	if err := table.Validate(); err != nil {
+		return nil, err
+	}
+	_, proof, err := c.NewRandForSession(ctx, round, poolID)
+	if err != nil {
+		return nil, err
+	}
+	return &Pool{
+		Round:       proof.Round,
+		RoundTime:   proof.RoundTime,
+		PoolID:      poolID,
+		Table:       table,
+		TableDigest: table.Digest(),
+		Proof:       proof,
+	}, nil
+}
+
+// Prize 返回序號 serial 的彩票中得的獎項名稱，不中獎時為 NoPrize
+// 需要從頭生成排列，耗時與 serial 成正比；需要整批結果時應使用 Prizes
+func (p *Pool) Prize(serial int) (string, error) {
+	if serial < 0 || serial >= p.Table.Total {
+		return NoPrize, drandshuffle.NewInputError(fmt.Errorf("%w: 序號 %d 不在 0 到 %d 之間", drandshuffle.ErrInvalidConfig, serial, p.Table.Total-1))
+	}
+	perm := drandshuffle.NewPermutation(p.Table.Total, p.Proof.Seed)
+	var r int
+	for i := 0; i <= serial; i++ {
+		r, _ = perm.Next()
+	}
+	return p.Table.prizeAt(r), nil
+}
+
+// Prizes 返回按序號排列的整批彩票的獎項名稱
+func (p *Pool) Prizes() []string {
+	perm := drandshuffle.NewPermutation(p.Table.Total, p.Proof.Seed)
+	prizes := make([]string, 0, p.Table.Total)
+	for r, ok := perm.Next(); ok; r, ok = perm.Next() {
+		prizes = append(prizes, p.Table.prizeAt(r))
+	}
+	return prizes
+}
+
+// VerifyPool 檢查獎池記錄的輪次、獎池編號和獎項表是否與證明及公開的獎項表一致
+// 通過後即可用 Prize 或 Prizes 重新計算任一張票的結果；隨機信標本身的簽名應另外用 Verifier 驗證 Proof.Beacon()
+func VerifyPool(p *Pool, table Table) error {
+	if p == nil || p.Proof == nil {
+		return drandshuffle.NewInputError(fmt.Errorf("%w: 缺少獎池記錄或證明", drandshuffle.ErrInvalidConfig))
+	}
+	if err := table.Validate(); err != nil {
+		return err
+	}
+	if err := drandshuffle.VerifyRandProof(p.Proof); err != nil {
+		return err
+	}
+	if p.Round != p.Proof.Round || p.PoolID != p.Proof.SessionID {
+		return drandshuffle.NewVerificationError(fmt.Errorf("%w: 獎池的輪次 %d 和獎池編號 %s 與證明不符", ErrOutcomeMismatch, p.Round, p.PoolID))
+	}
+	digest := table.Digest()
+	if p.TableDigest != digest || p.Table.Digest() != digest {
+		return drandshuffle.NewVerificationError(fmt.Errorf("%w: 獎池使用的獎項表與公開的獎項表 %s 不符", ErrOutcomeMismatch, digest))
+	}
+	return nil
+}
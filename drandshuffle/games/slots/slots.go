@@ -0,0 +1,189 @@
+// Package slots 從隨機信標和旋轉編號決定老虎機每條捲軸的停止位置
+//
+// 種子是 drandshuffle.Client.NewRandForSession 以旋轉編號作為遊戲局號派生的 RandProof.Seed。
+// 以同一個 drandshuffle.NewDRBG(種子) 依次為每條捲軸抽出停止位置：沒有權重的捲軸取 Intn(符號數)；
+// 有權重的捲軸取 r = Intn(權重之和)，停在累計權重第一次超過 r 的位置。
+// 捲軸條和權重應在所用輪次的隨機信標發布前公開，記錄中的 Machine.Digest 讓監管方確認結果所用的正是公開的捲軸。
+// 賠付表與可驗證性無關，不在此包的範圍內；Window 返回停止後可見的符號供遊戲引擎計算賠付。
+package slots
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+)
+
+// MachineAlgorithm 是 Machine.Digest 的算法標識，也是計算摘要時的域分隔前綴
+const MachineAlgorithm = "drandshuffle/slots/machine/v1"
+
+// ErrSpinMismatch 表示記錄的停止位置、輪次、旋轉編號或捲軸與證明重新計算的不一致
+var ErrSpinMismatch = errors.New("slots: spin mismatch")
+
+// Reel 是一條捲軸
+type Reel struct {
+	Symbols []string `json:"symbols"`           // 捲軸條上的符號，按位置排列，首尾相接
+	Weights []int    `json:"weights,omitempty"` // 每個位置的停止權重，為空時每個位置的機率相同
+}
+
+// Machine 是公開的捲軸配置
+type Machine struct {
+	Reels []Reel `json:"reels"`
+}
+
+// Validate 檢查捲軸配置，不合法時返回包裝 ErrInvalidConfig 的輸入錯誤
+func (m Machine) Validate() error {
+	if len(m.Reels) == 0 {
+		return drandshuffle.NewInputError(fmt.Errorf("%w: 至少需要一條捲軸", drandshuffle.ErrInvalidConfig))
+	}
+	for i, reel := range m.Reels {
+		if len(reel.Symbols) == 0 {
+			return drandshuffle.NewInputError(fmt.Errorf("%w: 第 %d 條捲軸沒有符號", drandshuffle.ErrInvalidConfig, i))
+		}
+		if len(reel.Weights) == 0 {
+			continue
+		}
+		if len(reel.Weights) != len(reel.Symbols) {
+			return drandshuffle.NewInputError(fmt.Errorf("%w: 第 %d 條捲軸有 %d 個符號和 %d 個權重", drandshuffle.ErrInvalidConfig, i, len(reel.Symbols), len(reel.Weights)))
+		}
+		total := 0
+		for _, w := range reel.Weights {
+			if w < 0 {
+				return drandshuffle.NewInputError(fmt.Errorf("%w: 第 %d 條捲軸的權重 %d 不能為負數", drandshuffle.ErrInvalidConfig, i, w))
+			}
+			total += w
+		}
+		if total == 0 {
+			return drandshuffle.NewInputError(fmt.Errorf("%w: 第 %d 條捲軸的權重之和為 0", drandshuffle.ErrInvalidConfig, i))
+		}
+	}
+	return nil
+}
+
+// Digest 返回捲軸配置的 SHA-256 摘要（十六進制）
+// 輸入依次為 MachineAlgorithm 和捲軸數量，以及每條捲軸的符號數量、每個符號的長度和 UTF-8 字節、
+// 權重數量和每個權重；所有整數都是 8 字節大端序
+func (m Machine) Digest() string {
+	h := sha256.New()
+	h.Write([]byte(MachineAlgorithm))
+	var n [8]byte
+	put := func(v uint64) {
+		binary.BigEndian.PutUint64(n[:], v)
+		h.Write(n[:])
+	}
+	put(uint64(len(m.Reels)))
+	for _, reel := range m.Reels {
+		put(uint64(len(reel.Symbols)))
+		for _, s := range reel.Symbols {
+			put(uint64(len(s)))
+			h.Write([]byte(s))
+		}
+		put(uint64(len(reel.Weights)))
+		for _, w := range reel.Weights {
+			put(uint64(w))
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Window 返回每條捲軸從停止位置起連續 rows 個可見的符號，Window(stops, rows)[捲軸][行]，超出捲軸條末尾時從頭接續
+// stops 的長度應與捲軸數量相同。缺少停止位置、停止位置不在 [0, 符號數) 內或沒有符號的捲軸返回 nil，rows 為負數時按 0 處理，不會 panic
+func (m Machine) Window(stops []int, rows int) [][]string {
+	rows = max(rows, 0)
+	window := make([][]string, len(m.Reels))
+	for i, reel := range m.Reels {
+		if i >= len(stops) || stops[i] < 0 || stops[i] >= len(reel.Symbols) {
+			continue
+		}
+		window[i] = make([]string, rows)
+		for row := range window[i] {
+			window[i][row] = reel.Symbols[(stops[i]+row)%len(reel.Symbols)]
+		}
+	}
+	return window
+}
+
+// Spin 是一次已完成的旋轉
+type Spin struct {
+	Round         uint64                  // 使用的輪次號碼
+	RoundTime     time.Time               // 該輪隨機信標的發布時間，未知時為零值
+	SpinID        string                  // 旋轉編號，即派生種子時使用的遊戲局號
+	MachineDigest string                  // 所用捲軸配置的 Machine.Digest
+	Stops         []int                   // 每條捲軸的停止位置，即 Reel.Symbols 的索引
+	Proof         *drandshuffle.RandProof // 派生種子的證明
+}
+
+// NewSpin 以指定輪次的隨機信標和旋轉編號決定每條捲軸的停止位置
+// round 為 drandshuffle.Latest 時使用最新的隨機信標；旋轉編號須通過 drandshuffle.ValidateSessionID 的檢查，每次旋轉應使用不同的編號
+func NewSpin(ctx context.Context, c *drandshuffle.Client, round uint64, spinID string, m Machine) (*Spin, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	_, proof, err := c.NewRandForSession(ctx, round, spinID)
+	if err != nil {
+		return nil, err
+	}
+	return &Spin{
+		Round:         proof.Round,
+		RoundTime:     proof.RoundTime,
+		SpinID:        spinID,
+		MachineDigest: m.Digest(),
+		Stops:         Stops(proof.Seed, m),
+		Proof:         proof,
+	}, nil
+}
+
+// Stops 以種子依次為每條捲軸抽出停止位置，算法見包的說明；m 應已通過 Validate
+func Stops(seed []byte, m Machine) []int {
+	drbg := drandshuffle.NewDRBG(seed)
+	stops := make([]int, len(m.Reels))
+	for i, reel := range m.Reels {
+		if len(reel.Weights) == 0 {
+			stops[i] = drbg.Intn(len(reel.Symbols))
+			continue
+		}
+		total := 0
+		for _, w := range reel.Weights {
+			total += w
+		}
+		r := drbg.Intn(total)
+		for pos, w := range reel.Weights {
+			if r < w {
+				stops[i] = pos
+				break
+			}
+			r -= w
+		}
+	}
+	return stops
+}
+
+// Verify 以證明和公開的捲軸配置重新抽出停止位置，檢查旋轉記錄的停止位置、輪次、旋轉編號和捲軸摘要是否與證明一致
+// 不一致時返回包裝 ErrSpinMismatch 的驗證錯誤；隨機信標本身的簽名應另外用 Verifier 驗證 Proof.Beacon()
+func Verify(spin *Spin, m Machine) error {
+	if spin == nil || spin.Proof == nil {
+		return drandshuffle.NewInputError(fmt.Errorf("%w: 缺少旋轉記錄或證明", drandshuffle.ErrInvalidConfig))
+	}
+	if err := m.Validate(); err != nil {
+		return err
+	}
+	if err := drandshuffle.VerifyRandProof(spin.Proof); err != nil {
+		return err
+	}
+	if spin.Round != spin.Proof.Round || spin.SpinID != spin.Proof.SessionID {
+		return drandshuffle.NewVerificationError(fmt.Errorf("%w: 旋轉的輪次 %d 和旋轉編號 %s 與證明不符", ErrSpinMismatch, spin.Round, spin.SpinID))
+	}
+	if digest := m.Digest(); spin.MachineDigest != digest {
+		return drandshuffle.NewVerificationError(fmt.Errorf("%w: 旋轉使用的捲軸摘要 %s 與公開的捲軸 %s 不符", ErrSpinMismatch, spin.MachineDigest, digest))
+	}
+	if want := Stops(spin.Proof.Seed, m); !slices.Equal(spin.Stops, want) {
+		return drandshuffle.NewVerificationError(fmt.Errorf("%w: 旋轉編號 %s 的停止位置應為 %v", ErrSpinMismatch, spin.SpinID, want))
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package drandshuffle
+
+import (
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// BeaconGap 是後台獲取中缺失的一段連續輪次，From 和 To 都包括在內
+// 缺口表示中繼或網絡在這段時間內無法及時提供新信標，預定在這些輪次進行的發牌可能受到影響；
+// 缺失的輪次仍可以按輪次號碼重新獲取
+type BeaconGap struct {
+	From       uint64    // 第一個缺失的輪次
+	To         uint64    // 最後一個缺失的輪次
+	DetectedAt time.Time // 發現缺口的時間
+}
+
+// Missed 返回缺口缺失的輪次數量
+func (g BeaconGap) Missed() uint64 {
+	if g.To < g.From {
+		return 0
+	}
+	return g.To - g.From + 1
+}
+
+// detectGaps 在後台獲取的最新輪次從 prev 前進到 latest 時找出兩者之間沒有緩存的連續輪次
+// 期間由其他請求取得並緩存的輪次不算缺失；每個缺口都記入 Health、記錄日誌並交給 WithGapHandler 設定的回調
+func (dm *DrandManager) detectGaps(prev, latest uint64) {
+	if prev == 0 || latest <= prev+1 {
+		return
+	}
+
+	// 按緩存內容而不是逐個輪次查找，長時間中斷後缺口很大時也不需要遍歷每個輪次
+	dm.mutex.RLock()
+	var cached []uint64
+	for _, result := range dm.beaconCache.all() {
+		if round := result.GetRound(); round > prev && round < latest {
+			cached = append(cached, round)
+		}
+	}
+	dm.mutex.RUnlock()
+	slices.Sort(cached)
+
+	now := dm.clock.Now()
+	from := prev + 1
+	for _, round := range append(cached, latest) {
+		if round > from {
+			dm.reportGap(BeaconGap{From: from, To: round - 1, DetectedAt: now})
+		}
+		from = round + 1
+	}
+}
+
+// reportGap 記錄一個缺口並通知回調
+func (dm *DrandManager) reportGap(gap BeaconGap) {
+	dm.fetchStats.recordGap(gap)
+	dm.logger.Warn("後台獲取缺失隨機信標輪次", dm.chainAttr(),
+		slog.Uint64("from", gap.From), slog.Uint64("to", gap.To), slog.Uint64("missed", gap.Missed()))
+	if dm.gapHandler != nil {
+		// 回調的 panic 不應使後台獲取退出，也不影響之後的缺口通知
+		_ = dm.catchPanic("gap_handler", func() { dm.gapHandler(gap) })
+	}
+}
@@ -0,0 +1,257 @@
+// Package giveaway 以預先公開的參與名單承諾從隨機信標抽出抽獎活動的得獎者，並為每位得獎者附上名單的包含證明
+//
+// 流程分三步：
+//  1. 報名截止後，主辦方以 NewCommitment 計算名單的 Merkle 根，連同活動編號和目標輪次一起公開，必須在目標輪次發布前完成；
+//  2. 目標輪次發布後，PickWinners 以 drandshuffle.Client.NewRandForSession(目標輪次, 活動編號) 的種子和 Merkle 根
+//     派生 SHA256(Algorithm || 種子 || 根)，取 drandshuffle.NewPermutation(名單長度, 派生種子) 的前 n 個索引作為得獎者；
+//  3. 任何人以公開的承諾調用 Verify，即可確認得獎者由隨機信標決定，且每位得獎者都在公開承諾的名單中，不需要取得完整名單。
+//
+// Merkle 樹與 RFC 6962（證書透明度）相同：葉子為 SHA256(0x00 || 參與項)，內部節點為 SHA256(0x01 || 左 || 右)，
+// 左子樹包含不超過總數的最大 2 的冪個葉子。名單公開後再增刪或調換參與項都會改變根，使抽獎無法通過驗證。
+package giveaway
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+)
+
+// Algorithm 是派生得獎者種子的算法標識，也是派生時的域分隔前綴
+const Algorithm = "drandshuffle/giveaway/v1"
+
+var (
+	// ErrRootMismatch 表示參與名單的 Merkle 根或長度與公開的承諾不一致
+	ErrRootMismatch = errors.New("giveaway: entry list does not match commitment")
+	// ErrInvalidInclusion 表示包含證明不能從參與項推出承諾的 Merkle 根
+	ErrInvalidInclusion = errors.New("giveaway: invalid inclusion proof")
+	// ErrWinnersMismatch 表示抽獎結果的得獎者、輪次或活動編號與證明重新計算的不一致
+	ErrWinnersMismatch = errors.New("giveaway: winners mismatch")
+)
+
+// Commitment 是主辦方在目標輪次發布前公開的參與名單承諾
+type Commitment struct {
+	GiveawayID string `json:"giveaway_id"` // 活動編號，即派生種子時使用的遊戲局號
+	Round      uint64 `json:"round"`       // 目標輪次，必須在承諾公開之後發布
+	Entries    int    `json:"entries"`     // 參與名單的長度
+	Root       []byte `json:"root"`        // 參與名單的 Merkle 根
+}
+
+// NewCommitment 計算參與名單的承諾
+// 名單中的同一參與者可以出現多次（例如按參與次數增加中獎機會），得獎的索引不會重複；
+// 活動編號須通過 drandshuffle.ValidateSessionID 的檢查，round 不能是 drandshuffle.Latest
+func NewCommitment(giveawayID string, round uint64, entries []string) (*Commitment, error) {
+	if err := drandshuffle.ValidateSessionID(giveawayID); err != nil {
+		return nil, err
+	}
+	if round == drandshuffle.Latest {
+		return nil, drandshuffle.NewInputError(fmt.Errorf("%w: 承諾必須指定目標輪次", drandshuffle.ErrExplicitRoundRequired))
+	}
+	if len(entries) == 0 {
+		return nil, drandshuffle.NewInputError(fmt.Errorf("%w: 參與名單為空", drandshuffle.ErrInvalidConfig))
+	}
+	return &Commitment{GiveawayID: giveawayID, Round: round, Entries: len(entries), Root: MerkleRoot(entries)}, nil
+}
+
+// MerkleRoot 返回參與名單的 Merkle 根，名單為空時返回 SHA256("")
+func MerkleRoot(entries []string) []byte {
+	levels := merkleLevels(entries)
+	return levels[len(levels)-1][0]
+}
+
+// InclusionProof 證明某個參與項位於名單的 Index 位置
+type InclusionProof struct {
+	Index int      `json:"index"` // 參與項在名單中的索引，從 0 開始
+	Path  [][]byte `json:"path"`  // 由葉子向上的兄弟節點哈希，即 RFC 6962 的審計路徑
+}
+
+// Prove 返回名單中第 index 個參與項的包含證明
+func Prove(entries []string, index int) (*InclusionProof, error) {
+	if index < 0 || index >= len(entries) {
+		return nil, drandshuffle.NewInputError(fmt.Errorf("%w: 索引 %d 不在 0 到 %d 之間", drandshuffle.ErrInvalidConfig, index, len(entries)-1))
+	}
+	return prove(merkleLevels(entries), index), nil
+}
+
+// VerifyInclusion 檢查包含證明能否從參與項推出長度為 size 的名單的 Merkle 根
+// 按 RFC 9162 第 2.1.3.2 節的算法驗證；不能推出時返回包裝 ErrInvalidInclusion 的驗證錯誤
+func VerifyInclusion(root []byte, size int, entry string, proof *InclusionProof) error {
+	if proof == nil || proof.Index < 0 || proof.Index >= size {
+		return drandshuffle.NewVerificationError(fmt.Errorf("%w: 索引不在名單範圍內", ErrInvalidInclusion))
+	}
+	fn, sn := proof.Index, size-1
+	r := leafHash(entry)
+	for _, p := range proof.Path {
+		if sn == 0 {
+			return drandshuffle.NewVerificationError(fmt.Errorf("%w: 審計路徑過長", ErrInvalidInclusion))
+		}
+		if fn&1 == 1 || fn == sn {
+			r = nodeHash(p, r)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			r = nodeHash(r, p)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	if sn != 0 || !bytes.Equal(r, root) {
+		return drandshuffle.NewVerificationError(fmt.Errorf("%w: 索引 %d 的參與項 %q 不在承諾的名單中", ErrInvalidInclusion, proof.Index, entry))
+	}
+	return nil
+}
+
+// Winner 是一位得獎者
+type Winner struct {
+	Entry     string         `json:"entry"`     // 參與項
+	Inclusion InclusionProof `json:"inclusion"` // 參與項在名單中的包含證明
+}
+
+// Result 是一次抽獎的結果，連同公開的承諾即可獨立驗證
+type Result struct {
+	GiveawayID string                  `json:"giveaway_id"`
+	Round      uint64                  `json:"round"`
+	RoundTime  time.Time               `json:"round_time"` // 目標輪次的發布時間，未知時為零值
+	Root       []byte                  `json:"root"`
+	Winners    []Winner                `json:"winners"` // 按抽出順序排列的得獎者
+	Proof      *drandshuffle.RandProof `json:"proof"`   // 派生種子的證明
+}
+
+// PickWinners 以承諾的目標輪次抽出 count 位得獎者
+// entries 必須與承諾時的名單完全相同，否則返回包裝 ErrRootMismatch 的錯誤；
+// 目標輪次尚未發布時返回 NewRandForSession 的錯誤，已知鏈信息時為 drandshuffle.ErrFutureRound；
+// count 不在 1 到名單長度之間時返回包裝 ErrInvalidConfig 的輸入錯誤
+func PickWinners(ctx context.Context, c *drandshuffle.Client, commitment *Commitment, entries []string, count int) (*Result, error) {
+	if commitment == nil {
+		return nil, drandshuffle.NewInputError(fmt.Errorf("%w: 缺少承諾", drandshuffle.ErrInvalidConfig))
+	}
+	if count < 1 || count > len(entries) {
+		return nil, drandshuffle.NewInputError(fmt.Errorf("%w: 無法從 %d 個參與項中抽出 %d 位得獎者", drandshuffle.ErrInvalidConfig, len(entries), count))
+	}
+	levels := merkleLevels(entries)
+	if root := levels[len(levels)-1][0]; len(entries) != commitment.Entries || !bytes.Equal(root, commitment.Root) {
+		return nil, drandshuffle.NewInputError(fmt.Errorf("%w: 名單有 %d 個參與項，承諾的是 %d 個", ErrRootMismatch, len(entries), commitment.Entries))
+	}
+	if commitment.Round == drandshuffle.Latest {
+		return nil, drandshuffle.NewInputError(fmt.Errorf("%w: 承諾必須指定目標輪次", drandshuffle.ErrExplicitRoundRequired))
+	}
+	_, proof, err := c.NewRandForSession(ctx, commitment.Round, commitment.GiveawayID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{
+		GiveawayID: commitment.GiveawayID,
+		Round:      proof.Round,
+		RoundTime:  proof.RoundTime,
+		Root:       slices.Clone(commitment.Root),
+		Proof:      proof,
+	}
+	for _, i := range WinnerIndices(proof.Seed, commitment.Root, len(entries), count) {
+		result.Winners = append(result.Winners, Winner{Entry: entries[i], Inclusion: *prove(levels, i)})
+	}
+	return result, nil
+}
+
+// WinnerIndices 返回得獎者在名單中的索引，算法見包的說明
+func WinnerIndices(seed, root []byte, entries, count int) []int {
+	h := sha256.New()
+	h.Write([]byte(Algorithm))
+	h.Write(seed)
+	h.Write(root)
+	return drandshuffle.NewPermutation(entries, h.Sum(nil)).Take(count)
+}
+
+// Verify 以公開的承諾驗證抽獎結果：證明的種子、輪次和活動編號與承諾一致，得獎者的索引由種子和 Merkle 根決定，
+// 且每位得獎者都能以包含證明推出承諾的 Merkle 根；隨機信標本身的簽名應另外用 Verifier 驗證 Proof.Beacon()
+func Verify(commitment *Commitment, result *Result) error {
+	if commitment == nil || result == nil || result.Proof == nil {
+		return drandshuffle.NewInputError(fmt.Errorf("%w: 缺少承諾、抽獎結果或證明", drandshuffle.ErrInvalidConfig))
+	}
+	if err := drandshuffle.VerifyRandProof(result.Proof); err != nil {
+		return err
+	}
+	if result.Proof.Round != commitment.Round || result.Round != commitment.Round ||
+		result.Proof.SessionID != commitment.GiveawayID || result.GiveawayID != commitment.GiveawayID {
+		return drandshuffle.NewVerificationError(fmt.Errorf("%w: 抽獎使用的輪次 %d 和活動編號 %s 與承諾不符", ErrWinnersMismatch, result.Proof.Round, result.Proof.SessionID))
+	}
+	if !bytes.Equal(result.Root, commitment.Root) {
+		return drandshuffle.NewVerificationError(fmt.Errorf("%w: 抽獎使用的 Merkle 根與承諾不符", ErrRootMismatch))
+	}
+	if len(result.Winners) < 1 || len(result.Winners) > commitment.Entries {
+		return drandshuffle.NewVerificationError(fmt.Errorf("%w: 得獎者數量 %d 不在 1 到 %d 之間", ErrWinnersMismatch, len(result.Winners), commitment.Entries))
+	}
+	want := WinnerIndices(result.Proof.Seed, commitment.Root, commitment.Entries, len(result.Winners))
+	for i, w := range result.Winners {
+		if w.Inclusion.Index != want[i] {
+			return drandshuffle.NewVerificationError(fmt.Errorf("%w: 第 %d 位得獎者應為索引 %d，結果記錄的是 %d", ErrWinnersMismatch, i+1, want[i], w.Inclusion.Index))
+		}
+		if err := VerifyInclusion(commitment.Root, commitment.Entries, w.Entry, &w.Inclusion); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// merkleLevels 返回由葉子到根的每一層節點哈希
+// 每層由左到右兩兩合併，落單的最後一個節點原樣升到上一層，得到的樹與 RFC 6962 的定義相同
+func merkleLevels(entries []string) [][][]byte {
+	if len(entries) == 0 {
+		empty := sha256.Sum256(nil)
+		return [][][]byte{{empty[:]}}
+	}
+	level := make([][]byte, len(entries))
+	for i, e := range entries {
+		level[i] = leafHash(e)
+	}
+	levels := [][][]byte{level}
+	for len(level) > 1 {
+		next := make([][]byte, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			next = append(next, nodeHash(level[i], level[i+1]))
+		}
+		levels = append(levels, next)
+		level = next
+	}
+	return levels
+}
+
+// prove 從已計算的各層節點取出第 index 個葉子的審計路徑
+func prove(levels [][][]byte, index int) *InclusionProof {
+	proof := &InclusionProof{Index: index, Path: [][]byte{}}
+	for _, level := range levels[:len(levels)-1] {
+		if sibling := index ^ 1; sibling < len(level) {
+			proof.Path = append(proof.Path, level[sibling])
+		}
+		index >>= 1
+	}
+	return proof
+}
+
+// leafHash 返回參與項的葉子哈希 SHA256(0x00 || entry)
+func leafHash(entry string) []byte {
+	h := sha256.New()
+	h.Write([]byte{0x00})
+	h.Write([]byte(entry))
+	return h.Sum(nil)
+}
+
+// nodeHash 返回內部節點的哈希 SHA256(0x01 || left || right)
+func nodeHash(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{0x01})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
@@ -0,0 +1,229 @@
+package drandshuffle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// 以下 http.Handler 供嵌入調用者自己的 HTTP 服務，例如掛在 chi 或 echo 的路由下並套上自己的認證、限流和日誌中間件。
+// 它們不檢查請求路徑，可以掛在任何路徑下；參數只取自查詢字符串和請求體。
+// 錯誤以純文字返回：輸入錯誤為 400，指定輪次不存在為 404，輪次尚未發布為 425 並帶 Retry-After，超出租戶配額為 429，
+// 無法取得隨機信標為 503，其他錯誤為 500
+
+// maxHandlerBody 是 ShuffleHandler 和 VerifyHandler 接受的請求體上限
+const maxHandlerBody = 1 << 20
+
+// ShuffleRequest 是 NewShuffleHandler 的請求體
+type ShuffleRequest struct {
+	SessionID     string   `json:"session_id"`
+	Round         uint64   `json:"round,omitempty"`         // 為 0（Latest）時使用最新的隨機信標
+	Contributions [][]byte `json:"contributions,omitempty"` // 見 ShuffleBuilder.WithContributions，JSON 中以 base64 編碼
+}
+
+// ShuffleResponse 是 NewShuffleHandler 的響應
+type ShuffleResponse struct {
+	Round     uint64        `json:"round"`
+	RoundTime time.Time     `json:"round_time"`
+	Deck      string        `json:"deck"` // EncodeDeck 的編碼
+	Proof     *ShuffleProof `json:"proof"`
+}
+
+// VerifyRequest 是 NewVerifyHandler 的請求體
+// 帶有 Proof 時按證明重新洗牌，否則以 Round 和 SessionID 重新獲取隨機信標，見 Client.Verify
+type VerifyRequest struct {
+	Deck      string        `json:"deck"` // EncodeDeck 的編碼
+	Proof     *ShuffleProof `json:"proof,omitempty"`
+	Round     uint64        `json:"round,omitempty"`
+	SessionID string        `json:"session_id,omitempty"`
+}
+
+// VerifyResponse 是 NewVerifyHandler 的響應，牌組或簽名不一致時 Valid 為 false，Reason 說明原因
+type VerifyResponse struct {
+	Valid   bool   `json:"valid"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message"` // 按請求的 Accept-Language 本地化、適合展示給玩家的說明，見 VerifyMessage
+}
+
+// NewShuffleHandler 返回以 POST 接受 ShuffleRequest 並返回 ShuffleResponse 的 http.Handler
+// 洗牌使用標準52張撲克牌並總是附帶證明；調用者應在中間件中確認請求者有權為該遊戲局號洗牌
+func NewShuffleHandler(c *Client) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ShuffleRequest
+		if !decodeRequest(w, r, &req) {
+			return
+		}
+		result, err := c.NewShuffle().
+			Session(req.SessionID).
+			Round(req.Round).
+			WithContributions(req.Contributions...).
+			WithProof().
+			Do(r.Context())
+		if err != nil {
+			writeHandlerError(w, c.manager, err)
+			return
+		}
+		writeJSON(w, ShuffleResponse{
+			Round:     result.Round,
+			RoundTime: result.RoundTime,
+			Deck:      EncodeDeck(result.Deck),
+			Proof:     result.Proof,
+		})
+	})
+}
+
+// NewVerifyHandler 返回以 POST 接受 VerifyRequest 並返回 VerifyResponse 的 http.Handler
+// 牌組或隨機信標簽名不一致時仍返回 200，以 Valid 報告結果；帶有證明時以鏈的公鑰驗證其中隨機信標的簽名，
+// 證明來自其他鏈或其他隨機性來源、或沒有鏈信息而無法驗證簽名時同樣報告為無效。
+// Message 使用 Accept-Language 中第一個支持的語言，沒有時使用配置的語言
+func NewVerifyHandler(c *Client) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req VerifyRequest
+		if !decodeRequest(w, r, &req) {
+			return
+		}
+		deck, err := DecodeDeck(req.Deck)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if req.Proof != nil {
+			err = verifyProofBeacon(r.Context(), c.manager, req.Proof)
+			if err == nil {
+				err = VerifyProof(req.Proof, nil, deck)
+			}
+		} else {
+			err = c.Verify(r.Context(), req.Round, req.SessionID, deck)
+		}
+		if err != nil && Category(err) != CategoryVerification {
+			writeHandlerError(w, c.manager, err)
+			return
+		}
+
+		round, sessionID := req.Round, req.SessionID
+		if req.Proof != nil {
+			round, sessionID = req.Proof.Round, req.Proof.SessionID
+		}
+		locale := LocaleFromAcceptLanguage(r.Header.Get("Accept-Language"), c.manager.Locale())
+		resp := VerifyResponse{Valid: err == nil, Message: verifyMessage(c.manager.translator, err, round, sessionID, locale)}
+		if err != nil {
+			resp.Reason = err.Error()
+		}
+		writeJSON(w, resp)
+	})
+}
+
+// verifyProofBeacon 以鏈的公鑰驗證證明中的隨機信標簽名
+// 證明來自其他鏈或其他隨機性來源時無法驗證其隨機性，返回驗證錯誤；尚未取得含公鑰的鏈信息時先嘗試獲取一次，仍然沒有時同樣返回驗證錯誤
+func verifyProofBeacon(ctx context.Context, dm *DrandManager, proof *ShuffleProof) error {
+	if proof.Provider != "" && proof.Provider != ProviderDrand {
+		return NewVerificationError(fmt.Errorf("證明的隨機性來源 %s 不是 drand，無法驗證其隨機信標", proof.Provider))
+	}
+	if proof.ChainHash != dm.config.ChainHash {
+		return NewVerificationError(fmt.Errorf("證明的鏈哈希 %s 與服務的鏈 %s 不一致，無法驗證其隨機信標", proof.ChainHash, dm.config.ChainHash))
+	}
+	info := dm.ChainInfo()
+	if info == nil {
+		dm.loadChainInfo(ctx)
+		info = dm.ChainInfo()
+	}
+	if info == nil || info.PublicKey == nil {
+		return NewVerificationError(errors.New("尚未取得含公鑰的鏈信息，無法驗證證明的隨機信標"))
+	}
+	verifier, err := NewVerifier(info)
+	if err != nil {
+		return fmt.Errorf("無法創建驗證器: %w", err)
+	}
+	if err := verifier.Verify(proof.Beacon()); err != nil {
+		return fmt.Errorf("輪次 %d 的隨機信標驗證失敗: %w", proof.Round, err)
+	}
+	return nil
+}
+
+// NewBeaconHandler 返回以 GET 提供隨機信標的 http.Handler，格式與 drand 中繼的 /public/<輪次> 相同
+// 查詢參數 round 指定輪次，省略時返回緩存的最新信標（應先調用 StartBackgroundFetching）；
+// 需要完整的中繼 API 時使用 NewMirrorHandler
+func NewBeaconHandler(dm *DrandManager) http.Handler {
+	m := &mirror{dm: dm}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "只接受 GET 和 HEAD 請求", http.StatusMethodNotAllowed)
+			return
+		}
+		if round := r.URL.Query().Get("round"); round != "" {
+			m.serveRound(w, r, round)
+			return
+		}
+		m.serveLatest(w)
+	})
+}
+
+// NewUsageHandler 返回以 GET 提供 UsageMeter 用量的 http.Handler，供計費系統拉取或監控告警
+// 返回所有租戶的 []TenantUsage；查詢參數 tenant 指定租戶時只返回該租戶的 TenantUsage。
+// 用量會暴露所有租戶的名稱，應只掛在管理接口上
+func NewUsageHandler(m *UsageMeter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "只接受 GET 和 HEAD 請求", http.StatusMethodNotAllowed)
+			return
+		}
+		if r.URL.Query().Has("tenant") {
+			writeJSON(w, m.Usage(r.URL.Query().Get("tenant")))
+			return
+		}
+		writeJSON(w, m.Snapshot())
+	})
+}
+
+// decodeRequest 檢查請求方法並解析 JSON 請求體，失敗時寫出錯誤並返回 false
+func decodeRequest(w http.ResponseWriter, r *http.Request, v any) bool {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "只接受 POST 請求", http.StatusMethodNotAllowed)
+		return false
+	}
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxHandlerBody))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		http.Error(w, "無效的請求: "+err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// writeJSON 以 JSON 寫出響應，結果依賴請求內容，不應被共享緩存
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeHandlerError 按錯誤類別寫出 HTTP 狀態碼和錯誤說明
+func writeHandlerError(w http.ResponseWriter, dm *DrandManager, err error) {
+	var future *FutureRoundError
+	switch {
+	case errors.As(err, &future):
+		if !future.AvailableAt.IsZero() {
+			wait := max(1, int(future.AvailableAt.Sub(dm.clock.Now())/time.Second))
+			w.Header().Set("Retry-After", strconv.Itoa(wait))
+		}
+		http.Error(w, err.Error(), http.StatusTooEarly)
+	case errors.Is(err, ErrQuotaExceeded):
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+	case errors.Is(err, ErrRoundBeforeGenesis), errors.Is(err, ErrRoundNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case Category(err) == CategoryInput:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, ErrBeaconUnavailable), Category(err) == CategoryNetwork:
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,114 @@
+package drandshuffle
+
+import (
+	"sync"
+	"time"
+)
+
+// unhealthyAfterFailures 連續獲取失敗達到此次數時視為不健康
+const unhealthyAfterFailures = 3
+
+// Health 是獲取最新隨機信標的狀態快照，用於監控和健康檢查
+// 成功獲取只在 Debug 級別記錄日誌，生產環境應通過 Health 觀察獲取情況
+type Health struct {
+	Running             bool          // 後台獲取服務是否在運行
+	LatestRound         uint64        // 最新隨機信標的輪次，尚未獲取時為 0
+	LatestAge           time.Duration // 最新隨機信標的年齡，尚未獲取時為 0
+	Successes           uint64        // 成功獲取最新信標的次數
+	Failures            uint64        // 獲取最新信標失敗的次數
+	ConsecutiveFailures uint64        // 最近一次成功之後連續失敗的次數
+	LastSuccess         time.Time     // 最近一次成功的時間
+	LastFailure         time.Time     // 最近一次失敗的時間
+	LastError           error         // 最近一次失敗的錯誤，之後成功時清除
+	Gaps                uint64        // 後台獲取發現的輪次缺口數量
+	MissedRounds        uint64        // 各缺口缺失的輪次總數
+	LastGap             BeaconGap     // 最近一次發現的缺口，沒有時為零值
+	Panics              uint64        // 後台調用回調和存儲時從 panic 恢復的次數
+	LastPanic           *PanicError   // 最近一次恢復的 panic，沒有時為 nil
+}
+
+// Healthy 報告是否已獲取過隨機信標且沒有持續失敗
+func (h Health) Healthy() bool {
+	return h.LatestRound != 0 && h.ConsecutiveFailures < unhealthyAfterFailures
+}
+
+// fetchStats 記錄獲取最新隨機信標的結果，零值可以直接使用
+type fetchStats struct {
+	mu                  sync.Mutex
+	successes           uint64
+	failures            uint64
+	consecutiveFailures uint64
+	lastSuccess         time.Time
+	lastFailure         time.Time
+	lastErr             error
+	gaps                uint64
+	missedRounds        uint64
+	lastGap             BeaconGap
+	panics              uint64
+	lastPanic           *PanicError
+}
+
+// recordSuccess 記錄一次成功的獲取
+func (s *fetchStats) recordSuccess(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.successes++
+	s.consecutiveFailures = 0
+	s.lastSuccess = now
+	s.lastErr = nil
+}
+
+// recordFailure 記錄一次失敗的獲取
+func (s *fetchStats) recordFailure(now time.Time, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures++
+	s.consecutiveFailures++
+	s.lastFailure = now
+	s.lastErr = err
+}
+
+// recordGap 記錄一個輪次缺口
+func (s *fetchStats) recordGap(gap BeaconGap) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gaps++
+	s.missedRounds += gap.Missed()
+	s.lastGap = gap
+}
+
+// recordPanic 記錄一次從 panic 恢復
+func (s *fetchStats) recordPanic(p *PanicError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.panics++
+	s.lastPanic = p
+}
+
+// Health 返回獲取最新隨機信標的狀態快照
+func (dm *DrandManager) Health() Health {
+	dm.mutex.RLock()
+	h := Health{Running: dm.isRunning}
+	dm.mutex.RUnlock()
+
+	if latest := dm.latestBeacon.Load(); latest != nil {
+		h.LatestRound = latest.result.GetRound()
+		h.LatestAge = dm.beaconAge(latest)
+	}
+
+	dm.fetchStats.mu.Lock()
+	h.Successes = dm.fetchStats.successes
+	h.Failures = dm.fetchStats.failures
+	h.ConsecutiveFailures = dm.fetchStats.consecutiveFailures
+	h.LastSuccess = dm.fetchStats.lastSuccess
+	h.LastFailure = dm.fetchStats.lastFailure
+	h.LastError = dm.fetchStats.lastErr
+	h.Gaps = dm.fetchStats.gaps
+	h.MissedRounds = dm.fetchStats.missedRounds
+	h.LastGap = dm.fetchStats.lastGap
+	h.Panics = dm.fetchStats.panics
+	h.LastPanic = dm.fetchStats.lastPanic
+	dm.fetchStats.mu.Unlock()
+
+	return h
+}
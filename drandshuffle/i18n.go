@@ -0,0 +1,350 @@
+package drandshuffle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+	"time"
+)
+
+// Locale 是顯示文字使用的語言
+type Locale string
+
+const (
+	// LocaleZhTW 繁體中文，默認語言
+	LocaleZhTW Locale = "zh-TW"
+	// LocaleZhCN 簡體中文
+	LocaleZhCN Locale = "zh-CN"
+	// LocaleEn 英文
+	LocaleEn Locale = "en"
+	// LocaleJa 日文
+	LocaleJa Locale = "ja"
+
+	// DefaultLocale 未指定語言時使用的語言
+	DefaultLocale = LocaleZhTW
+)
+
+// Locales 返回內置消息目錄支持的語言
+func Locales() []Locale {
+	return []Locale{LocaleZhTW, LocaleZhCN, LocaleEn, LocaleJa}
+}
+
+// EnvLocale 選擇顯示語言的環境變量，如 en 或 zh-TW
+const EnvLocale = "DRANDSHUFFLE_LANG"
+
+// ParseLocale 解析語言標籤，接受 en、en-US、en_US.UTF-8、zh-TW、zh_CN.UTF-8、ja_JP 等形式
+// 英文和日文的各地區變體分別解析為 LocaleEn 和 LocaleJa；中文的 zh-CN、zh-SG、zh-Hans 等簡體變體解析為 LocaleZhCN，
+// 其他中文變體解析為 LocaleZhTW
+func ParseLocale(s string) (Locale, error) {
+	tag := strings.ToLower(strings.ReplaceAll(s, "_", "-"))
+	// 去掉編碼和修飾部分，如 .UTF-8 和 @euro
+	if i := strings.IndexAny(tag, ".@"); i >= 0 {
+		tag = tag[:i]
+	}
+
+	switch {
+	case tag == "en" || strings.HasPrefix(tag, "en-"):
+		return LocaleEn, nil
+	case tag == "ja" || strings.HasPrefix(tag, "ja-"):
+		return LocaleJa, nil
+	case tag == "zh-cn" || tag == "zh-sg" || tag == "zh-my" || tag == "zh-hans" || strings.HasPrefix(tag, "zh-hans-"):
+		return LocaleZhCN, nil
+	case tag == "zh" || strings.HasPrefix(tag, "zh-"):
+		return LocaleZhTW, nil
+	}
+	return "", NewInputError(fmt.Errorf("%w: 不支持的語言 %q", ErrInvalidConfig, s))
+}
+
+// LocaleFromEnv 從環境變量選擇語言
+// 依次參考 DRANDSHUFFLE_LANG、LC_ALL、LC_MESSAGES 和 LANG，第一個可以解析的生效，都沒有時返回 DefaultLocale
+func LocaleFromEnv() Locale {
+	for _, name := range []string{EnvLocale, "LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := os.Getenv(name); v != "" {
+			if locale, err := ParseLocale(v); err == nil {
+				return locale
+			}
+		}
+	}
+	return DefaultLocale
+}
+
+// LocaleFromAcceptLanguage 從 HTTP Accept-Language 請求頭選擇語言
+// 按列出的順序取第一個可以解析的語言標籤，忽略 q 權重和 "*"，都不能解析時返回 fallback
+func LocaleFromAcceptLanguage(header string, fallback Locale) Locale {
+	for _, part := range strings.Split(header, ",") {
+		tag, _, _ := strings.Cut(part, ";")
+		if locale, err := ParseLocale(strings.TrimSpace(tag)); err == nil {
+			return locale
+		}
+	}
+	return fallback
+}
+
+// Translator 將消息鍵翻譯為指定語言的文字，沒有這條消息時返回 false
+// 以 WithTranslator 設定後優先於內置的消息目錄，可以用來改寫內置的文字，或接入應用程序已有的翻譯系統。
+// 內置的消息鍵和參數見 BuiltinMessages；實現必須可以被多個 goroutine 並發使用
+type Translator interface {
+	Translate(locale Locale, key string, args ...any) (string, bool)
+}
+
+// Catalog 是按語言分組的消息目錄，鍵為消息標識，值為 fmt 格式字符串
+// 應用程序可以用它管理自己的界面文字，本包的牌名和錯誤消息也使用同樣的結構
+type Catalog map[Locale]map[string]string
+
+// Sprintf 使用指定語言的格式字符串格式化消息
+// 該語言沒有這條消息時使用 DefaultLocale，都沒有時以鍵本身作為格式字符串
+func (c Catalog) Sprintf(locale Locale, key string, args ...any) string {
+	format, ok := c[locale][key]
+	if !ok {
+		format, ok = c[DefaultLocale][key]
+	}
+	if !ok {
+		format = key
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// Translate 實現 Translator，只查找指定的語言，沒有這條消息時返回 false 而不回退到 DefaultLocale
+func (c Catalog) Translate(locale Locale, key string, args ...any) (string, bool) {
+	format, ok := c[locale][key]
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprintf(format, args...), true
+}
+
+// BuiltinMessages 返回本包內置消息目錄的副本，可以作為編寫翻譯的起點
+// 鍵包括牌名的 "card"（參數為花色和點數）、各花色的 "suit.<花色>"、錯誤消息的 "error.*" 和驗證結果的 "verify.*"
+func BuiltinMessages() Catalog {
+	c := make(Catalog, len(messages))
+	for locale, m := range messages {
+		c[locale] = maps.Clone(m)
+	}
+	return c
+}
+
+// messages 是本包的消息目錄
+// 繁體中文的錯誤消息使用 err.Error() 的完整說明，因此只有牌名和驗證結果
+var messages = Catalog{
+	LocaleZhTW: {
+		"card":          "%[1]s%[2]s",
+		"suit.黑桃":       "黑桃",
+		"suit.紅心":       "紅心",
+		"suit.方塊":       "方塊",
+		"suit.梅花":       "梅花",
+		"verify.passed": "驗證通過：牌組與輪次 %[1]d、遊戲局號 %[2]s 的洗牌結果一致",
+		"verify.failed": "驗證失敗：%[3]s",
+	},
+	LocaleZhCN: {
+		"card":                     "%[1]s%[2]s",
+		"suit.黑桃":                  "黑桃",
+		"suit.紅心":                  "红心",
+		"suit.方塊":                  "方块",
+		"suit.梅花":                  "梅花",
+		"verify.passed":            "验证通过：牌组与轮次 %[1]d、游戏局号 %[2]s 的洗牌结果一致",
+		"verify.failed":            "验证失败：%[3]s",
+		"error.beacon_unavailable": "无法取得 drand 随机信标，请稍后再试",
+		"error.round_not_found":    "无法取得指定轮次的随机信标",
+		"error.round_before_gen":   "轮次从 1 开始",
+		"error.invalid_card":       "无效的牌",
+		"error.insufficient_cards": "牌组剩余的牌不足",
+		"error.not_enough_cards":   "牌不足：需要 %d 张，只剩 %d 张",
+		"error.deck_mismatch":      "牌组与该轮次和游戏局号的洗牌结果不一致",
+		"error.quota_exceeded":     "本轮次的用量已达配额，请使用之后的轮次",
+		"error.chain_broken":       "证明链已断开：有牌局缺失或被改动",
+		"error.invalid_link":       "验证链接无效或已过期",
+		"error.invalid_session_id": "无效的游戏局号",
+		"error.invalid_config":     "无效的配置",
+		"error.closed":             "DrandManager 已关闭",
+		"error.round_required":     "必须指定预先承诺的轮次",
+		"error.canceled":           "请求已取消",
+		"error.timeout":            "请求超时",
+		"error.future_round":       "轮次 %d 尚未发布，将于 %s 发布",
+		"error.future_round_any":   "轮次 %d 尚未发布",
+	},
+	LocaleEn: {
+		"card":                     "%[2]s of %[1]s",
+		"suit.黑桃":                  "Spades",
+		"suit.紅心":                  "Hearts",
+		"suit.方塊":                  "Diamonds",
+		"suit.梅花":                  "Clubs",
+		"verify.passed":            "Verified: the deck matches the shuffle for round %[1]d and session ID %[2]s",
+		"verify.failed":            "Verification failed: %[3]s",
+		"error.beacon_unavailable": "the drand beacon is unavailable, please try again later",
+		"error.round_not_found":    "the requested round could not be fetched",
+		"error.round_before_gen":   "rounds start at 1",
+		"error.invalid_card":       "invalid card",
+		"error.insufficient_cards": "not enough cards left in the deck",
+		"error.not_enough_cards":   "not enough cards: %d required, only %d left",
+		"error.deck_mismatch":      "the deck does not match the round and session ID",
+		"error.quota_exceeded":     "the usage quota for this round has been reached, please use a later round",
+		"error.chain_broken":       "the proof chain is broken: a deal is missing or was altered",
+		"error.invalid_link":       "this verification link is invalid or has expired",
+		"error.invalid_session_id": "invalid session ID",
+		"error.invalid_config":     "invalid configuration",
+		"error.closed":             "the DrandManager is closed",
+		"error.round_required":     "an explicit, pre-committed round is required",
+		"error.canceled":           "the request was cancelled",
+		"error.timeout":            "the request timed out",
+		"error.future_round":       "round %d has not been published yet, available at %s",
+		"error.future_round_any":   "round %d has not been published yet",
+	},
+	LocaleJa: {
+		"card":                     "%[1]sの%[2]s",
+		"suit.黑桃":                  "スペード",
+		"suit.紅心":                  "ハート",
+		"suit.方塊":                  "ダイヤ",
+		"suit.梅花":                  "クラブ",
+		"verify.passed":            "検証成功：デッキはラウンド %[1]d、セッション ID %[2]s のシャッフル結果と一致しています",
+		"verify.failed":            "検証失敗：%[3]s",
+		"error.beacon_unavailable": "drand のビーコンを取得できません。しばらくしてから再試行してください",
+		"error.round_not_found":    "指定されたラウンドのビーコンを取得できません",
+		"error.round_before_gen":   "ラウンドは 1 から始まります",
+		"error.invalid_card":       "無効なカードです",
+		"error.insufficient_cards": "デッキの残りカードが足りません",
+		"error.not_enough_cards":   "カードが足りません：%d 枚必要ですが、残りは %d 枚です",
+		"error.deck_mismatch":      "デッキがラウンドとセッション ID のシャッフル結果と一致しません",
+		"error.quota_exceeded":     "このラウンドの利用上限に達しました。後のラウンドを使用してください",
+		"error.chain_broken":       "証明チェーンが途切れています：欠落または改ざんされたゲームがあります",
+		"error.invalid_link":       "この検証リンクは無効か、有効期限が切れています",
+		"error.invalid_session_id": "無効なセッション ID です",
+		"error.invalid_config":     "無効な設定です",
+		"error.closed":             "DrandManager は終了しています",
+		"error.round_required":     "事前に確定したラウンドを指定する必要があります",
+		"error.canceled":           "リクエストはキャンセルされました",
+		"error.timeout":            "リクエストがタイムアウトしました",
+		"error.future_round":       "ラウンド %d はまだ公開されていません（公開予定 %s）",
+		"error.future_round_any":   "ラウンド %d はまだ公開されていません",
+	},
+}
+
+// errorMessageKeys 將哨兵錯誤對應到消息目錄中的鍵，按順序匹配第一個
+var errorMessageKeys = []struct {
+	err error
+	key string
+}{
+	{ErrClosed, "error.closed"},
+	{ErrInvalidLink, "error.invalid_link"},
+	{ErrInvalidConfig, "error.invalid_config"},
+	{ErrExplicitRoundRequired, "error.round_required"},
+	{ErrInvalidSessionID, "error.invalid_session_id"},
+	{ErrInvalidCard, "error.invalid_card"},
+	{ErrInsufficientCards, "error.insufficient_cards"},
+	{ErrDeckMismatch, "error.deck_mismatch"},
+	{ErrChainBroken, "error.chain_broken"},
+	{ErrQuotaExceeded, "error.quota_exceeded"},
+	{ErrRoundBeforeGenesis, "error.round_before_gen"},
+	{context.Canceled, "error.canceled"},
+	{context.DeadlineExceeded, "error.timeout"},
+	{ErrRoundNotFound, "error.round_not_found"},
+	{ErrBeaconUnavailable, "error.beacon_unavailable"},
+}
+
+// CardName 返回牌在指定語言中的顯示名稱，如 "黑桃A"、"A of Spades" 或 "スペードのA"
+// 牌本身（Card 的欄位、CardToString 和洗牌結果）不受語言影響；非標準花色按原樣顯示
+func CardName(card Card, locale Locale) string {
+	return cardName(nil, card, locale)
+}
+
+// ErrorMessage 返回適合展示給最終用戶的錯誤消息
+// DefaultLocale 下返回 err.Error() 的完整中文說明；其他語言按包裝的哨兵錯誤返回翻譯後的摘要，
+// 無法識別的錯誤返回 err.Error()
+func ErrorMessage(err error, locale Locale) string {
+	return errorMessage(nil, err, locale)
+}
+
+// VerifyMessage 返回驗證結果適合展示給玩家的說明，err 為 Client.Verify、VerifyProof 等驗證的結果
+// err 為 nil 時說明牌組與輪次和遊戲局號的洗牌結果一致，否則以 ErrorMessage 說明失敗的原因
+func VerifyMessage(err error, round uint64, sessionID string, locale Locale) string {
+	return verifyMessage(nil, err, round, sessionID, locale)
+}
+
+// cardName 返回牌的顯示名稱，tr 不為 nil 時優先使用
+func cardName(tr Translator, card Card, locale Locale) string {
+	suit, ok := lookup(tr, locale, "suit."+card.Suit)
+	if !ok {
+		suit = card.Suit
+	}
+	return translate(tr, locale, "card", suit, card.Value)
+}
+
+// errorMessage 返回錯誤的顯示消息，tr 不為 nil 時優先使用，包括 DefaultLocale
+func errorMessage(tr Translator, err error, locale Locale) string {
+	if err == nil {
+		return ""
+	}
+	key, args, ok := errorMessageKey(err)
+	if ok && tr != nil {
+		if msg, ok := tr.Translate(locale, key, args...); ok {
+			return msg
+		}
+	}
+	if !ok || locale == DefaultLocale {
+		return err.Error()
+	}
+	if _, found := messages[locale][key]; !found {
+		return err.Error()
+	}
+	return messages.Sprintf(locale, key, args...)
+}
+
+// errorMessageKey 返回錯誤對應的消息鍵和參數，無法識別時返回 false
+func errorMessageKey(err error) (string, []any, bool) {
+	var future *FutureRoundError
+	if errors.As(err, &future) {
+		if future.AvailableAt.IsZero() {
+			return "error.future_round_any", []any{future.Round}, true
+		}
+		return "error.future_round", []any{future.Round, future.AvailableAt.Format(time.RFC3339)}, true
+	}
+	var short *NotEnoughCardsError
+	if errors.As(err, &short) {
+		return "error.not_enough_cards", []any{short.Required, short.Available}, true
+	}
+	for _, m := range errorMessageKeys {
+		if errors.Is(err, m.err) {
+			return m.key, nil, true
+		}
+	}
+	return "", nil, false
+}
+
+// verifyMessage 返回驗證結果的說明，tr 不為 nil 時優先使用
+// "verify.passed" 和 "verify.failed" 的參數都是輪次、遊戲局號和失敗原因，通過時失敗原因為空
+func verifyMessage(tr Translator, err error, round uint64, sessionID string, locale Locale) string {
+	if err == nil {
+		return translate(tr, locale, "verify.passed", round, sessionID, "")
+	}
+	return translate(tr, locale, "verify.failed", round, sessionID, errorMessage(tr, err, locale))
+}
+
+// translate 格式化消息，tr 沒有這條消息時使用內置的消息目錄
+func translate(tr Translator, locale Locale, key string, args ...any) string {
+	if tr != nil {
+		if msg, ok := tr.Translate(locale, key, args...); ok {
+			return msg
+		}
+	}
+	return messages.Sprintf(locale, key, args...)
+}
+
+// lookup 返回不帶參數的消息，tr 和內置的消息目錄在該語言都沒有時返回 false
+func lookup(tr Translator, locale Locale, key string) (string, bool) {
+	if tr != nil {
+		if msg, ok := tr.Translate(locale, key); ok {
+			return msg, true
+		}
+	}
+	return messages.Translate(locale, key)
+}
+
+// Locale 返回配置的顯示語言
+func (dm *DrandManager) Locale() Locale {
+	if dm.config.Locale == "" {
+		return DefaultLocale
+	}
+	return dm.config.Locale
+}
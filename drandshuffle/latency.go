@@ -0,0 +1,127 @@
+package drandshuffle
+
+import (
+	"math"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// defaultDealLatencyBuckets 發牌延遲直方圖默認的桶上限
+var defaultDealLatencyBuckets = []time.Duration{
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2 * time.Second,
+	3 * time.Second,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+}
+
+// LatencyHistogram 是桶邊界固定的延遲直方圖，可以被多個 goroutine 並發記錄
+type LatencyHistogram struct {
+	bounds []time.Duration
+	counts []atomic.Uint64 // 最後一個桶記錄超過所有上限的樣本
+	count  atomic.Uint64
+	sum    atomic.Int64 // 納秒
+}
+
+// HistogramSnapshot 是直方圖某一時刻的副本
+type HistogramSnapshot struct {
+	Bounds []time.Duration // 每個桶的上限（包含）
+	Counts []uint64        // 每個桶的樣本數，比 Bounds 多一個，最後一個為超過所有上限的樣本
+	Count  uint64          // 樣本總數
+	Sum    time.Duration   // 所有樣本的總和
+}
+
+// NewLatencyHistogram 創建使用指定桶上限的直方圖，未指定時使用默認的發牌延遲桶
+func NewLatencyHistogram(bounds ...time.Duration) *LatencyHistogram {
+	if len(bounds) == 0 {
+		bounds = defaultDealLatencyBuckets
+	}
+	sorted := append([]time.Duration(nil), bounds...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	return &LatencyHistogram{
+		bounds: sorted,
+		counts: make([]atomic.Uint64, len(sorted)+1),
+	}
+}
+
+// Observe 記錄一個樣本，負值視為 0
+func (h *LatencyHistogram) Observe(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	i := sort.Search(len(h.bounds), func(i int) bool { return d <= h.bounds[i] })
+	h.counts[i].Add(1)
+	h.count.Add(1)
+	h.sum.Add(int64(d))
+}
+
+// Snapshot 返回直方圖當前的副本
+// 記錄與讀取並發進行時，各計數之間可能相差正在記錄的少數樣本
+func (h *LatencyHistogram) Snapshot() HistogramSnapshot {
+	s := HistogramSnapshot{
+		Bounds: append([]time.Duration(nil), h.bounds...),
+		Counts: make([]uint64, len(h.counts)),
+		Count:  h.count.Load(),
+		Sum:    time.Duration(h.sum.Load()),
+	}
+	for i := range h.counts {
+		s.Counts[i] = h.counts[i].Load()
+	}
+	return s
+}
+
+// Quantile 返回包含第 q 分位樣本的桶上限，例如 Quantile(0.99) 表示 99% 的樣本不超過該值
+// 沒有樣本時返回 0；分位落在最後一個桶時返回 -1，表示超過所有上限
+func (s HistogramSnapshot) Quantile(q float64) time.Duration {
+	var total uint64
+	for _, c := range s.Counts {
+		total += c
+	}
+	if total == 0 {
+		return 0
+	}
+
+	// 超出 [0, 1] 範圍或 NaN 的分位數按邊界處理
+	if !(q >= 0) {
+		q = 0
+	}
+	if q > 1 {
+		q = 1
+	}
+	// 向上取整：第 q 分位是至少覆蓋 q*total 個樣本的最小排名，向下取整會使高分位落到較低的桶
+	rank := uint64(math.Ceil(q * float64(total)))
+	if rank < 1 {
+		rank = 1
+	}
+	var seen uint64
+	for i, c := range s.Counts {
+		seen += c
+		if seen >= rank {
+			// 計數比邊界多出的桶（包括溢出桶）都沒有上限
+			if i >= len(s.Bounds) {
+				return -1
+			}
+			return s.Bounds[i]
+		}
+	}
+	return -1
+}
+
+// observeDealLatency 記錄從輪次發佈到交付洗牌結果的時間，沒有鏈信息時無法得知發佈時間，不記錄
+func (dm *DrandManager) observeDealLatency(round uint64) {
+	if published, ok := dm.RoundTime(round); ok {
+		dm.dealLatency.Observe(dm.since(published))
+	}
+}
+
+// DealLatency 返回使用最新隨機信標發牌時，從輪次發佈到交付牌組的延遲分佈
+// 輪次發佈時間按鏈的創世時間和週期計算，精度為秒；按指定輪次的洗牌不計入
+func (dm *DrandManager) DealLatency() HistogramSnapshot {
+	return dm.dealLatency.Snapshot()
+}
@@ -0,0 +1,96 @@
+package drandshuffle
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/drand/drand/v2/common/chain"
+	"github.com/drand/go-clients/drand"
+)
+
+// lazyClient 是在首次請求時才連接中繼的 drand 客戶端
+// 連接失敗時不保存結果，下一次請求會重新嘗試，使熱啟動不必在啟動時等待網絡
+type lazyClient struct {
+	connect func(ctx context.Context) (drand.Client, error)
+
+	mu     sync.Mutex
+	client drand.Client
+	closed bool
+}
+
+// newLazyClient 創建使用 connect 建立連接的 lazyClient
+func newLazyClient(connect func(ctx context.Context) (drand.Client, error)) *lazyClient {
+	return &lazyClient{connect: connect}
+}
+
+// get 返回已連接的客戶端，尚未連接時嘗試連接
+func (c *lazyClient) get(ctx context.Context) (drand.Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil, fmt.Errorf("drand 客戶端已關閉")
+	}
+	if c.client == nil {
+		client, err := c.connect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		c.client = client
+	}
+	return c.client, nil
+}
+
+// Get 獲取指定輪次的隨機信標
+func (c *lazyClient) Get(ctx context.Context, round uint64) (drand.Result, error) {
+	client, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.Get(ctx, round)
+}
+
+// Watch 訂閱新的隨機信標，無法連接時返回已關閉的通道
+func (c *lazyClient) Watch(ctx context.Context) <-chan drand.Result {
+	client, err := c.get(ctx)
+	if err != nil {
+		ch := make(chan drand.Result)
+		close(ch)
+		return ch
+	}
+	return client.Watch(ctx)
+}
+
+// Info 獲取鏈信息
+func (c *lazyClient) Info(ctx context.Context) (*chain.Info, error) {
+	client, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.Info(ctx)
+}
+
+// RoundAt 返回指定時間對應的輪次，尚未連接時返回 0，不會觸發連接
+func (c *lazyClient) RoundAt(t time.Time) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.client == nil {
+		return 0
+	}
+	return c.client.RoundAt(t)
+}
+
+// Close 關閉底層客戶端，之後的請求都會失敗
+func (c *lazyClient) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed = true
+	if c.client == nil {
+		return nil
+	}
+	return c.client.Close()
+}
@@ -0,0 +1,27 @@
+package drandshuffle
+
+import (
+	"context"
+	"encoding/hex"
+	"log/slog"
+)
+
+// discardHandler 丟棄所有日誌記錄，使庫在未設定日誌記錄器時保持安靜
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
+
+// discardLogger 是默認的日誌記錄器，不輸出任何內容
+var discardLogger = slog.New(discardHandler{})
+
+// chainAttr 返回鏈哈希的日誌屬性，尚未取得鏈信息或鏈信息不含公鑰時為空
+func (dm *DrandManager) chainAttr() slog.Attr {
+	info := dm.chainInfo.Load()
+	if info == nil || info.PublicKey == nil {
+		return slog.String("chain", "")
+	}
+	return slog.String("chain", hex.EncodeToString(info.Hash()))
+}
@@ -0,0 +1,129 @@
+package drandshuffle
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NewMirrorHandler 返回以 drand 中繼 HTTP API 格式重新提供隨機信標的只讀 http.Handler
+// 隔離網段內的服務可以把它當作中繼使用，例如 drand 客戶端或 WithRelayURLs 指向此地址，無需連接公網。
+// 提供 /chains、/info、/public/latest 和 /public/<輪次>，以及帶鏈哈希前綴的 /<鏈哈希>/... 形式；
+// 最新信標取自 dm 緩存的最新輪次（應先調用 StartBackgroundFetching），指定輪次不在緩存中時由 dm 向上游獲取。
+// 信標在上游獲取時已經驗證，下游仍可以用鏈信息中的公鑰重新驗證；只接受 GET 和 HEAD 請求
+func NewMirrorHandler(dm *DrandManager) http.Handler {
+	return &mirror{dm: dm}
+}
+
+// mirror 實現 NewMirrorHandler 返回的 http.Handler
+type mirror struct {
+	dm *DrandManager
+}
+
+// ServeHTTP 按路徑分派請求，帶鏈哈希前綴時前綴必須是 dm 的鏈哈希
+func (m *mirror) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "只接受 GET 和 HEAD 請求", http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := strings.Trim(r.URL.Path, "/")
+	if path == "chains" {
+		m.serveChains(w)
+		return
+	}
+	if hash, rest, ok := strings.Cut(path, "/"); ok && hash != "public" {
+		if hash != m.dm.config.ChainHash {
+			http.NotFound(w, r)
+			return
+		}
+		path = rest
+	}
+
+	switch {
+	case path == "info":
+		m.serveInfo(w)
+	case path == "public/latest":
+		m.serveLatest(w)
+	case strings.HasPrefix(path, "public/"):
+		m.serveRound(w, r, strings.TrimPrefix(path, "public/"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveChains 返回鏡像提供的鏈哈希列表
+func (m *mirror) serveChains(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode([]string{m.dm.config.ChainHash})
+}
+
+// serveInfo 返回鏈信息，尚未取得時返回 503
+func (m *mirror) serveInfo(w http.ResponseWriter) {
+	info := m.dm.ChainInfo()
+	if info == nil {
+		http.Error(w, "尚未取得鏈信息", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	info.ToJSON(w, nil)
+}
+
+// serveLatest 返回緩存的最新隨機信標，在下一輪發布前可以被緩存
+func (m *mirror) serveLatest(w http.ResponseWriter) {
+	latest := m.dm.latestBeacon.Load()
+	if latest == nil {
+		http.Error(w, ErrBeaconUnavailable.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	maxAge := 0
+	if next, ok := m.dm.RoundTime(latest.result.GetRound() + 1); ok {
+		maxAge = max(0, int(next.Sub(m.dm.clock.Now())/time.Second))
+	}
+	m.writeBeacon(w, newBeacon(latest.result), "public, max-age="+strconv.Itoa(maxAge))
+}
+
+// serveRound 返回指定輪次的隨機信標，已發布的輪次內容不會改變，可以長期緩存
+func (m *mirror) serveRound(w http.ResponseWriter, r *http.Request, roundPath string) {
+	round, err := strconv.ParseUint(roundPath, 10, 64)
+	if err != nil {
+		http.Error(w, "無效的輪次號碼", http.StatusBadRequest)
+		return
+	}
+
+	result, err := m.dm.beaconByRound(r.Context(), round)
+	if err != nil {
+		var future *FutureRoundError
+		switch {
+		case errors.As(err, &future):
+			if !future.AvailableAt.IsZero() {
+				wait := max(1, int(future.AvailableAt.Sub(m.dm.clock.Now())/time.Second))
+				w.Header().Set("Retry-After", strconv.Itoa(wait))
+			}
+			http.Error(w, err.Error(), http.StatusTooEarly)
+		case errors.Is(err, ErrRoundBeforeGenesis), errors.Is(err, ErrRoundNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		}
+		return
+	}
+	m.writeBeacon(w, newBeacon(result), "public, max-age=31536000, immutable")
+}
+
+// writeBeacon 以中繼的 JSON 格式寫出隨機信標
+func (m *mirror) writeBeacon(w http.ResponseWriter, beacon Beacon, cacheControl string) {
+	data, err := EncodeBeaconJSON(beacon)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Write(data)
+}
@@ -0,0 +1,153 @@
+// Package mobile 是可以用 gomobile 導出到 iOS 和 Android 的驗證核心，客戶端無需服務器即可在本地驗證發牌
+//
+// 導出的函數和類型只使用 gomobile 支持的類型（string、[]byte、int64、error 和本包的結構），
+// 牌組以 CardCode 的兩字符代碼表示，代碼之間以空格分隔，例如 "As Kh Td"。
+// 證明包是服務器以 ShuffleResult.MarshalCBOR 編碼的洗牌結果（必須使用 WithProof），只支持標準52張撲克牌。
+//
+//	gomobile bind -target=ios -o DrandShuffle.xcframework github.com/coseto6125/DrandShuffle/drandshuffle/mobile
+//	gomobile bind -target=android -o drandshuffle.aar github.com/coseto6125/DrandShuffle/drandshuffle/mobile
+package mobile
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+)
+
+// DeckSeparator 是牌組字符串中分隔各張牌代碼的字符
+const DeckSeparator = " "
+
+// Bundle 是證明包中可以展示給玩家的內容
+type Bundle struct {
+	Round     int64  // 使用的輪次號碼
+	SessionID string // 遊戲局號
+	ChainHash string // drand 鏈哈希（十六進制）
+	Deck      string // 洗好的牌組，以空格分隔的牌代碼
+}
+
+// ParseBundle 解析證明包，不做任何驗證；展示前應先用 VerifyProofBundle 或 Verifier.VerifyProofBundle 驗證
+func ParseBundle(bundle []byte) (*Bundle, error) {
+	result, err := decodeBundle(bundle)
+	if err != nil {
+		return nil, err
+	}
+	deck, err := encodeCodes(result.Deck)
+	if err != nil {
+		return nil, err
+	}
+	return &Bundle{
+		Round:     int64(result.Round),
+		SessionID: result.Proof.SessionID,
+		ChainHash: result.Proof.ChainHash,
+		Deck:      deck,
+	}, nil
+}
+
+// VerifyProofBundle 根據證明包中的隨機性和遊戲局號重新洗牌，檢查牌組是否一致
+// 只檢查牌組，不驗證隨機信標的簽名；需要確認隨機性確實來自 drand 時使用 Verifier.VerifyProofBundle
+func VerifyProofBundle(bundle []byte) error {
+	result, err := decodeBundle(bundle)
+	if err != nil {
+		return err
+	}
+	return drandshuffle.VerifyProof(result.Proof, drandshuffle.Poker52, result.Deck)
+}
+
+// RecomputeDeck 以隨機性和遊戲局號重新計算標準52張撲克牌的洗牌結果，返回以空格分隔的牌代碼
+// 不包括參與方貢獻；結果與服務器用相同輪次和遊戲局號洗出的牌組相同
+func RecomputeDeck(randomness []byte, sessionID string) (string, error) {
+	if len(randomness) == 0 {
+		return "", drandshuffle.NewInputError(fmt.Errorf("缺少隨機性"))
+	}
+	if err := drandshuffle.ValidateSessionID(sessionID); err != nil {
+		return "", err
+	}
+	return encodeCodes(drandshuffle.NewShuffler(drandshuffle.Poker52).Shuffle(randomness, sessionID))
+}
+
+// CardCode 返回標準牌的兩字符代碼，如黑桃A為 "As"，見 drandshuffle.CardCode
+func CardCode(suit, value string) (string, error) {
+	return drandshuffle.CardCode(drandshuffle.Card{Suit: suit, Value: value})
+}
+
+// CardName 返回牌代碼在指定語言（"zh-TW"、"zh-CN"、"en" 或 "ja"）中的顯示名稱，如 "黑桃A" 或 "A of Spades"
+func CardName(code, locale string) (string, error) {
+	card, err := drandshuffle.ParseCardCode(code)
+	if err != nil {
+		return "", err
+	}
+	l, err := drandshuffle.ParseLocale(locale)
+	if err != nil {
+		return "", err
+	}
+	return drandshuffle.CardName(card, l), nil
+}
+
+// Verifier 以鏈的公鑰驗證證明包，包括隨機信標的簽名和牌組
+type Verifier struct {
+	verifier  *drandshuffle.Verifier
+	chainHash string
+}
+
+// NewVerifier 從 drand 中繼 /info 返回的鏈信息 JSON 創建 Verifier
+// 應用應內置鏈信息，而不是在運行時從服務器取得，否則無法發現服務器偽造的隨機性
+func NewVerifier(chainInfoJSON []byte) (*Verifier, error) {
+	info, err := drandshuffle.ReadChainInfo(bytes.NewReader(chainInfoJSON))
+	if err != nil {
+		return nil, err
+	}
+	v, err := drandshuffle.NewVerifier(info)
+	if err != nil {
+		return nil, err
+	}
+	return &Verifier{verifier: v, chainHash: info.HashString()}, nil
+}
+
+// VerifyProofBundle 檢查證明包屬於此鏈、隨機信標的簽名有效，且牌組與重新洗牌的結果一致
+func (v *Verifier) VerifyProofBundle(bundle []byte) error {
+	result, err := decodeBundle(bundle)
+	if err != nil {
+		return err
+	}
+	if result.Proof.Provider != "" {
+		return drandshuffle.NewVerificationError(fmt.Errorf("證明包的隨機性來源 %s 不是 drand", result.Proof.Provider))
+	}
+	if result.Proof.ChainHash != v.chainHash {
+		return drandshuffle.NewVerificationError(fmt.Errorf("證明包的鏈哈希 %s 與驗證器的鏈 %s 不一致", result.Proof.ChainHash, v.chainHash))
+	}
+	if err := v.verifier.Verify(result.Proof.Beacon()); err != nil {
+		return err
+	}
+	return drandshuffle.VerifyProof(result.Proof, drandshuffle.Poker52, result.Deck)
+}
+
+// decodeBundle 解析證明包，檢查其中有洗牌證明且輪次與證明一致
+func decodeBundle(bundle []byte) (*drandshuffle.ShuffleResult, error) {
+	var result drandshuffle.ShuffleResult
+	if err := result.UnmarshalCBOR(bundle); err != nil {
+		return nil, err
+	}
+	if result.Proof == nil {
+		return nil, drandshuffle.NewInputError(fmt.Errorf("證明包中沒有洗牌證明"))
+	}
+	if result.Round != result.Proof.Round || result.Round > math.MaxInt64 {
+		return nil, drandshuffle.NewInputError(fmt.Errorf("證明包的輪次 %d 無效", result.Round))
+	}
+	return &result, nil
+}
+
+// encodeCodes 將牌組轉換為以空格分隔的牌代碼
+func encodeCodes(deck []drandshuffle.Card) (string, error) {
+	codes := make([]string, len(deck))
+	for i, card := range deck {
+		code, err := drandshuffle.CardCode(card)
+		if err != nil {
+			return "", err
+		}
+		codes[i] = code
+	}
+	return strings.Join(codes, DeckSeparator), nil
+}
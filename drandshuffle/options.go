@@ -0,0 +1,220 @@
+package drandshuffle
+
+import (
+	"log/slog"
+	nethttp "net/http"
+	"time"
+
+	"github.com/drand/drand/v2/common/chain"
+	"github.com/drand/go-clients/drand"
+)
+
+// Option 是 DrandManager 的配置選項
+// 選項按傳入順序修改配置，全部應用後才檢查配置是否有效
+type Option func(*DrandManager)
+
+// WithConfig 以完整的配置取代當前配置，之後傳入的選項仍會在其基礎上修改
+// cfg 應以 DefaultConfig 的返回值為基礎修改
+func WithConfig(cfg Config) Option {
+	return func(dm *DrandManager) {
+		dm.config = cfg
+	}
+}
+
+// WithChainHash 設定要連接的鏈，hash 為十六進制字符串，默認為 quicknet
+func WithChainHash(hash string) Option {
+	return func(dm *DrandManager) {
+		dm.config.ChainHash = hash
+	}
+}
+
+// WithChain 使用內置的網絡預設，如 "testnet"，見 Chains
+// 鏈哈希和中繼地址取自預設；之後或之前用 WithRelayURLs 設定的地址會保留，以便連接自建的中繼
+func WithChain(name string) Option {
+	return func(dm *DrandManager) {
+		dm.config.Chain = name
+	}
+}
+
+// WithChainInfo 使用自定義的鏈信息，適用於自建或測試用的 drand 網絡
+// 鏈哈希由鏈信息計算，不再從中繼獲取鏈信息，而是以此驗證中繼返回的隨機信標；
+// 鏈信息文檔可以用 ReadChainInfo 解析，配置文件中則以 chain_info_file 指定
+func WithChainInfo(info *chain.Info) Option {
+	return func(dm *DrandManager) {
+		dm.pinnedInfo = info
+	}
+}
+
+// WithRelayURLs 設定 drand 中繼的地址，默認為 api.drand.sh 和 drand.cloudflare.com
+func WithRelayURLs(urls ...string) Option {
+	return func(dm *DrandManager) {
+		dm.config.URLs = urls
+	}
+}
+
+// WithHedgedRequests 啟用對沖請求
+// 獲取最新隨機信標時同時向所有中繼發出請求，使用最先返回的有效結果並取消其他請求，
+// 以少量額外請求換取更穩定的延遲
+func WithHedgedRequests() Option {
+	return func(dm *DrandManager) {
+		dm.config.Hedged = true
+	}
+}
+
+// WithRelayClients 設定對沖請求使用的各中繼客戶端
+// NewDrandManager 會根據中繼 URL 自動創建，通常只在使用自定義客戶端時需要設定
+// DrandManager 關閉時會一併關閉這些客戶端
+func WithRelayClients(relays ...drand.Client) Option {
+	return func(dm *DrandManager) {
+		dm.relays = relays
+	}
+}
+
+// WithCacheSize 設定緩存保留的隨機信標數量上限，默認為 100
+// 超過上限時淘汰最早寫入的隨機信標，記憶體用量與上限成正比
+func WithCacheSize(size int) Option {
+	return func(dm *DrandManager) {
+		dm.config.CacheSize = size
+	}
+}
+
+// WithTransport 設定連接 drand 中繼使用的 HTTP Transport
+// 可用於啟用 HTTP/2、調整 MaxIdleConnsPerHost、設定代理或與服務的其他部分共享連接池
+func WithTransport(transport nethttp.RoundTripper) Option {
+	return func(dm *DrandManager) {
+		dm.transport = transport
+	}
+}
+
+// WithLocale 設定 CardName、ErrorMessage 等顯示文字使用的語言
+func WithLocale(locale Locale) Option {
+	return func(dm *DrandManager) {
+		dm.config.Locale = locale
+	}
+}
+
+// WithTranslator 設定 Client 的 CardName、ErrorMessage 和 VerifyMessage 優先使用的翻譯
+// 翻譯沒有某條消息時使用內置的消息目錄，因此只需提供要改寫的部分
+func WithTranslator(t Translator) Option {
+	return func(dm *DrandManager) {
+		dm.translator = t
+	}
+}
+
+// WithClock 設定推算輪次時間和調度輪詢使用的時鐘，傳入 nil 時使用系統時鐘
+// 主要用於測試，配合 drandshuffletest.Clock 可以不等待真實時間地測試輪詢和 WaitForRound
+func WithClock(clock Clock) Option {
+	return func(dm *DrandManager) {
+		if clock == nil {
+			clock = systemClock{}
+		}
+		dm.clock = clock
+	}
+}
+
+// WithHTTPClient 設定連接 drand 中繼使用的 HTTP 客戶端
+// 請求會經由該客戶端發出，因此其 Transport、Timeout 和重定向策略都會生效
+func WithHTTPClient(c *nethttp.Client) Option {
+	return func(dm *DrandManager) {
+		dm.transport = clientTransport{client: c}
+	}
+}
+
+// clientTransport 將 *http.Client 適配為 RoundTripper
+type clientTransport struct {
+	client *nethttp.Client
+}
+
+// RoundTrip 通過包裝的客戶端發出請求
+func (t clientTransport) RoundTrip(req *nethttp.Request) (*nethttp.Response, error) {
+	return t.client.Do(req)
+}
+
+// WithShuffleCache 設定洗牌結果緩存
+// 啟用後，相同輪次和遊戲局號的洗牌請求直接返回緩存的結果，不重新計算
+func WithShuffleCache(cache *ShuffleCache) Option {
+	return func(dm *DrandManager) {
+		dm.shuffleCache = cache
+	}
+}
+
+// WithBeaconStore 設定持久化保存隨機信標的存儲
+// 啟用後新獲取的隨機信標會寫入存儲，熱啟動時從存儲載入緩存
+func WithBeaconStore(store BeaconStore) Option {
+	return func(dm *DrandManager) {
+		dm.store = store
+	}
+}
+
+// WithRetention 按保留策略定期壓縮 WithBeaconStore 設定的存儲，刪除不再需要的舊隨機信標
+// 創建後立即壓縮一次，之後每隔 interval（不大於 0 時為一小時）壓縮一次，Close 時停止；失敗時以 Warn 級別記錄日誌。
+// 存儲須實現 Compactor，例如 FileBeaconStore、sqlstore.Store 或包裝它們的 WriteBehindStore，否則只記錄警告；
+// policy.RoundTime 為 nil 時使用 DrandManager.RoundTime 按 MaxAge 推算信標的年齡
+func WithRetention(policy RetentionPolicy, interval time.Duration) Option {
+	return func(dm *DrandManager) {
+		dm.retention = &policy
+		dm.retentionInterval = interval
+	}
+}
+
+// WithWarmStart 啟用熱啟動模式
+// 創建時不等待網絡：先從 BeaconStore（如已設定）載入保存的信標開始服務，
+// 並在後台重試連接中繼，獲取到即時信標後關閉 Ready 返回的通道
+// 熱啟動時不會創建對沖請求使用的中繼客戶端，除非已通過 WithRelayClients 設定
+func WithWarmStart() Option {
+	return func(dm *DrandManager) {
+		dm.config.WarmStart = true
+	}
+}
+
+// WithStrictRounds 啟用嚴格輪次模式，適用於受監管的部署
+// 使用「當前最新輪次」洗牌時，營運方可以反覆重試直到出現有利的牌組；嚴格模式下
+// ShuffleLatest、GetShuffledDeck、AcquireShuffledDeck 和以 Latest 為輪次的 ShuffleBuilder 都返回 ErrExplicitRoundRequired，
+// 調用者必須在輪次發布前承諾輪次，再用 ShuffleAtRound 等指定輪次的方法洗牌
+func WithStrictRounds() Option {
+	return func(dm *DrandManager) {
+		dm.config.StrictRounds = true
+	}
+}
+
+// WithFetchConcurrency 設定 GetRandomnessRange 和 Prefetch 批量獲取輪次時的工作協程數量，默認為 8
+// 數量越大回填越快，但也會對公共中繼造成更大的壓力
+func WithFetchConcurrency(n int) Option {
+	return func(dm *DrandManager) {
+		dm.config.FetchConcurrency = n
+	}
+}
+
+// WithDealLatencyBuckets 設定 DealLatency 直方圖的桶上限
+// 默認從 100ms 到 30s，需要證明更嚴格的延遲目標時可以加入更細的桶
+func WithDealLatencyBuckets(bounds ...time.Duration) Option {
+	return func(dm *DrandManager) {
+		dm.config.DealLatencyBuckets = bounds
+	}
+}
+
+// WithGapHandler 設定後台獲取發現輪次缺口時調用的回調，見 BeaconGap
+// 回調在後台獲取的 goroutine 中同步調用，應盡快返回，需要耗時的處理（如發送告警）應另開 goroutine；
+// 回調發生 panic 時恢復並以 Error 級別記錄崩潰報告，計入 Health.Panics，後台獲取繼續運行。
+// 不設定時缺口仍會記入 Health 並以 Warn 級別記錄日誌
+func WithGapHandler(handler func(BeaconGap)) Option {
+	return func(dm *DrandManager) {
+		dm.gapHandler = handler
+	}
+}
+
+// WithUsageMeter 設定按租戶統計和限制派生次數的 UsageMeter，租戶取自 TenantFromContext
+// 多個 DrandManager 可以共用同一個 UsageMeter
+func WithUsageMeter(m *UsageMeter) Option {
+	return func(dm *DrandManager) {
+		dm.usage = m
+	}
+}
+
+// WithLogger 設定日誌記錄器，默認不輸出任何日誌
+// 日誌帶有 round、chain 等結構化欄位；每輪成功獲取信標的記錄為 Debug 級別
+func WithLogger(logger *slog.Logger) Option {
+	return func(dm *DrandManager) {
+		dm.config.Logger = logger
+	}
+}
@@ -0,0 +1,64 @@
+package drandshuffle
+
+import (
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"time"
+)
+
+// PanicError 是從 panic 恢復得到的錯誤，記錄 panic 的值和發生時的堆棧
+// 後台 goroutine 調用調用者提供的回調和存儲時，panic 轉換為 PanicError 記錄下來，而不是讓整個進程退出
+type PanicError struct {
+	Value any       // 傳給 panic 的值
+	Stack []byte    // 發生 panic 的 goroutine 的堆棧
+	Where string    // 發生 panic 的位置，例如 "gap_handler"
+	At    time.Time // 恢復的時間
+}
+
+// Error 返回錯誤說明，不包括堆棧
+func (e *PanicError) Error() string {
+	if e.Where == "" {
+		return fmt.Sprintf("panic: %v", e.Value)
+	}
+	return fmt.Sprintf("%s 發生 panic: %v", e.Where, e.Value)
+}
+
+// Unwrap 在 panic 的值是錯誤時返回它，使 errors.Is 可以匹配
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// LogValue 實現 slog.LogValuer，以結構化的欄位記錄崩潰報告
+func (e *PanicError) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("where", e.Where),
+		slog.String("panic", fmt.Sprint(e.Value)),
+		slog.Time("at", e.At),
+		slog.String("stack", string(e.Stack)),
+	)
+}
+
+// CatchPanic 調用 fn，fn 發生 panic 時恢復並返回 *PanicError，否則返回 nil
+// where 說明發生的位置，記入 PanicError.Where；panic(nil) 在 Go 1.21 之後也會恢復為 *runtime.PanicNilError
+func CatchPanic(where string, fn func()) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = &PanicError{Value: v, Stack: debug.Stack(), Where: where, At: time.Now()}
+		}
+	}()
+	fn()
+	return nil
+}
+
+// catchPanic 調用後台 goroutine 中調用者提供的回調或存儲，發生 panic 時記錄崩潰報告並計入 Health，返回 *PanicError
+func (dm *DrandManager) catchPanic(where string, fn func()) error {
+	err := CatchPanic(where, fn)
+	if p, ok := err.(*PanicError); ok {
+		p.At = dm.clock.Now()
+		dm.fetchStats.recordPanic(p)
+		dm.logger.Error("從 panic 恢復", dm.chainAttr(), slog.Any("crash", p))
+	}
+	return err
+}
@@ -0,0 +1,97 @@
+package drandshuffle
+
+import "crypto/sha256"
+
+// Permutation 是確定性的流式排列生成器，適用於百萬級的抽獎名單等大型集合
+// 使用稀疏的 Fisher-Yates 算法逐個產生 [0, n) 的索引，只記錄被交換過的位置，
+// 只需抽取前 k 個結果時，記憶體和計算量都只與 k 成正比，也不需要複製原始集合
+// Permutation 不是並發安全的
+type Permutation struct {
+	n       int
+	pos     int
+	swapped map[int]int
+	drbg    *DRBG
+}
+
+// NewPermutation 創建 [0, n) 的排列生成器，相同的 n 和種子總是產生相同的排列
+func NewPermutation(n int, seed []byte) *Permutation {
+	if n < 0 {
+		n = 0
+	}
+	return &Permutation{
+		n:       n,
+		swapped: make(map[int]int),
+		drbg:    NewDRBG(seed),
+	}
+}
+
+// at 返回位置 i 當前的值
+func (p *Permutation) at(i int) int {
+	if v, ok := p.swapped[i]; ok {
+		return v
+	}
+	return i
+}
+
+// Next 返回排列中的下一個索引，排列結束時第二個返回值為 false
+func (p *Permutation) Next() (int, bool) {
+	if p.pos >= p.n {
+		return 0, false
+	}
+
+	i := p.pos
+	j := i + p.drbg.Intn(p.n-i)
+	vi, vj := p.at(i), p.at(j)
+
+	// 位置 i 之後不會再被讀取，只需保存被換到 j 的值
+	delete(p.swapped, i)
+	if j != i {
+		p.swapped[j] = vi
+	}
+	p.pos++
+
+	return vj, true
+}
+
+// Take 返回排列中接下來的至多 k 個索引
+func (p *Permutation) Take(k int) []int {
+	if k > p.Remaining() {
+		k = p.Remaining()
+	}
+	if k < 0 {
+		k = 0
+	}
+	out := make([]int, 0, k)
+	for len(out) < k {
+		v, _ := p.Next()
+		out = append(out, v)
+	}
+	return out
+}
+
+// Remaining 返回排列中尚未產生的索引數量
+func (p *Permutation) Remaining() int {
+	return p.n - p.pos
+}
+
+// ShuffleSlice 使用種子原地打亂任意切片，不分配新的切片
+// 使用 DRBG 和無偏的 Fisher-Yates 算法，結果與從 NewPermutation 依次取得的索引順序一致
+func ShuffleSlice[T any](items []T, seed []byte) {
+	shuffleWith(NewDRBG(seed), items)
+}
+
+// domainSeed 以域分隔前綴從種子派生 DRBG 的種子，即 SHA256(domain || seed)，使同一種子用於不同用途時結果互不相關
+func domainSeed(domain string, seed []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte(domain))
+	h.Write(seed)
+	return h.Sum(nil)
+}
+
+// shuffleWith 以 drbg 原地打亂切片，算法與 ShuffleSlice 相同
+func shuffleWith[T any](drbg *DRBG, items []T) {
+	for i := 0; i < len(items)-1; i++ {
+		j := i + drbg.Intn(len(items)-i)
+		items[i], items[j] = items[j], items[i]
+	}
+}
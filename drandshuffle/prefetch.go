@@ -0,0 +1,122 @@
+package drandshuffle
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/drand/go-clients/drand"
+)
+
+// FetchProgress 報告批量獲取中一個輪次的結果
+type FetchProgress struct {
+	Round uint64 // 完成的輪次
+	Done  int    // 已完成（包括失敗）的輪次數量
+	Total int    // 需要獲取的輪次總數
+	Err   error  // 獲取失敗時的錯誤
+}
+
+// Prefetch 在後台獲取 [from, to] 範圍內的所有輪次並寫入緩存，適用於回填歷史或預取即將發佈的輪次
+// 尚未發佈的輪次會等到預期發佈時間後再獲取（需要鏈信息），已緩存的輪次不會重新請求
+// 獲取由固定數量的工作協程進行，數量可通過 WithFetchConcurrency 設定
+// 每個輪次完成後向返回的通道發送一次進度，全部完成或 ctx 取消後關閉通道；
+// 通道沒有緩衝，調用者讀取得慢時工作協程會等待，因此必須讀取到通道關閉或取消 ctx
+// 緩存容量小於範圍時，較早的輪次可能在完成前已被淘汰
+func (dm *DrandManager) Prefetch(ctx context.Context, from, to uint64) <-chan FetchProgress {
+	progress := make(chan FetchProgress)
+
+	if from == 0 || to < from {
+		go func() {
+			defer close(progress)
+			select {
+			case progress <- FetchProgress{Err: NewInputError(fmt.Errorf("無效的輪次範圍: %d-%d", from, to))}:
+			case <-ctx.Done():
+			}
+		}()
+		return progress
+	}
+
+	total := int(to - from + 1)
+	go func() {
+		defer close(progress)
+
+		var done atomic.Int64
+		dm.fetchRounds(ctx, from, to, func(round uint64, _ drand.Result, err error) bool {
+			p := FetchProgress{Round: round, Done: int(done.Add(1)), Total: total, Err: err}
+			select {
+			case progress <- p:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+
+	return progress
+}
+
+// fetchRounds 使用固定數量的工作協程獲取 [from, to] 範圍內的輪次，直到全部完成後返回
+// 每個輪次完成後調用 handle，handle 會在多個工作協程中並發調用；
+// handle 返回 false 或 ctx 取消時停止分派新的輪次，已在進行中的輪次仍會完成
+func (dm *DrandManager) fetchRounds(ctx context.Context, from, to uint64, handle func(round uint64, result drand.Result, err error) bool) {
+	workers := dm.config.FetchConcurrency
+	if span := to - from; span < uint64(workers) {
+		workers = int(span) + 1
+	}
+
+	rounds := make(chan uint64)
+	var stopped atomic.Bool
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for round := range rounds {
+				result, err := dm.waitAndFetchRound(ctx, round)
+				if !handle(round, result, err) {
+					stopped.Store(true)
+				}
+			}
+		}()
+	}
+
+dispatch:
+	for round := from; !stopped.Load(); round++ {
+		select {
+		case rounds <- round:
+		case <-ctx.Done():
+			break dispatch
+		}
+		if round == to {
+			break
+		}
+	}
+	close(rounds)
+	wg.Wait()
+}
+
+// waitAndFetchRound 從緩存或網絡獲取指定輪次，尚未發佈的輪次先等待到預期發佈時間
+func (dm *DrandManager) waitAndFetchRound(ctx context.Context, round uint64) (drand.Result, error) {
+	dm.mutex.RLock()
+	result, ok := dm.beaconCache.get(round)
+	dm.mutex.RUnlock()
+	if ok {
+		return result, nil
+	}
+
+	if published, ok := dm.RoundTime(round); ok {
+		if delay := published.Sub(dm.clock.Now()); delay > 0 {
+			select {
+			case <-dm.clock.After(delay + pollMargin):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return dm.fetchRound(ctx, round)
+}
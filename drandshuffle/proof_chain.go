@@ -0,0 +1,34 @@
+package drandshuffle
+
+import (
+	"encoding/hex"
+	"fmt"
+)
+
+// VerifyProofChain 檢查同一牌桌按發牌順序排列的證明是否首尾相接：每份證明的 PreviousDigest 必須等於前一份證明的 Digest
+// 第一份證明的 PreviousDigest 不檢查，因此可以只驗證鏈的一段；牌桌的完整鏈應從 PreviousDigest 為空的第一局開始。
+// 鏈接不一致時返回包裝 ErrChainBroken 的驗證錯誤，說明斷開的位置；只檢查鏈接，每局的牌組仍應以 VerifyProof 驗證
+func VerifyProofChain(proofs []*ShuffleProof) error {
+	for i, proof := range proofs {
+		if proof == nil {
+			return NewInputError(fmt.Errorf("第 %d 份洗牌證明為空", i+1))
+		}
+		if i == 0 {
+			continue
+		}
+		if want := proofs[i-1].Digest(); proof.PreviousDigest != want {
+			return NewVerificationError(fmt.Errorf("%w: 第 %d 份證明（遊戲局號 %s）記錄的上一份摘要為 %q，第 %d 份證明（遊戲局號 %s）的摘要為 %s",
+				ErrChainBroken, i+1, proof.SessionID, proof.PreviousDigest, i, proofs[i-1].SessionID, want))
+		}
+	}
+	return nil
+}
+
+// isDigest 報告 s 是否是 ShuffleProof.Digest 的格式，即 64 個小寫十六進制字符
+func isDigest(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	sum, err := hex.DecodeString(s)
+	return err == nil && hex.EncodeToString(sum) == s
+}
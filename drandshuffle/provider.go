@@ -0,0 +1,122 @@
+package drandshuffle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/drand/drand/v2/common/chain"
+	"github.com/drand/go-clients/drand"
+)
+
+// ProviderDrand 是 drand 網絡的來源標識；證明的 Provider 為空時即表示 drand
+const ProviderDrand = "drand"
+
+// BeaconProvider 是 drand 之外的可驗證隨機性來源，例如 Chainlink VRF 或 HSM 簽名的隨機數服務
+// 適配器實現它後即可通過 NewDrandManagerWithProvider 接入相同的緩存、洗牌和證明流程。
+// Beacon 中的 Signature 等欄位由來源自行定義，只需要對應的 BeaconVerifier 能夠驗證
+type BeaconProvider interface {
+	// Name 返回記錄在證明中的來源標識，例如 "chainlink-vrf/polygon"，不能為空或 ProviderDrand
+	Name() string
+	// Beacon 返回指定輪次的隨機信標，round 為 0 時返回最新的一輪；
+	// 輪次不存在時應返回包裝 ErrRoundNotFound 的錯誤
+	Beacon(ctx context.Context, round uint64) (Beacon, error)
+}
+
+// BeaconVerifier 驗證隨機信標確實由其來源產生，*Verifier 是 drand 的實現
+type BeaconVerifier interface {
+	Verify(beacon Beacon) error
+}
+
+// NewDrandManagerWithProvider 使用 drand 之外的隨機性來源創建 DrandManager
+// 證明的 Provider 記錄 p.Name()，ChainHash 為空；來源沒有鏈信息，RoundTime 不可用，後台獲取使用固定的間隔。
+// p 同時實現 io.Closer 時，Close 會一併關閉它
+func NewDrandManagerWithProvider(p BeaconProvider, opts ...Option) (*DrandManager, error) {
+	if p == nil {
+		return nil, NewInputError(fmt.Errorf("%w: 缺少隨機性來源", ErrInvalidConfig))
+	}
+	name := p.Name()
+	if name == "" || name == ProviderDrand {
+		return nil, NewInputError(fmt.Errorf("%w: 無效的來源標識 %q", ErrInvalidConfig, name))
+	}
+
+	dm, err := NewDrandManagerWithClient(&providerClient{provider: p}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	dm.provider = name
+	dm.config.ChainHash = ""
+	return dm, nil
+}
+
+// Provider 返回隨機性來源的標識，drand 網絡返回 ProviderDrand
+func (dm *DrandManager) Provider() string {
+	if dm.provider == "" {
+		return ProviderDrand
+	}
+	return dm.provider
+}
+
+// VerifyProofWith 按證明記錄的來源選擇驗證器驗證隨機信標，再用 VerifyProof 重新洗牌比對牌組
+// verifiers 以來源標識為鍵，drand 的證明使用 ProviderDrand；沒有對應的驗證器時返回輸入錯誤
+func VerifyProofWith(proof *ShuffleProof, verifiers map[string]BeaconVerifier, template *DeckTemplate, deck []Card) error {
+	if proof == nil {
+		return NewInputError(fmt.Errorf("缺少洗牌證明"))
+	}
+	provider := proof.Provider
+	if provider == "" {
+		provider = ProviderDrand
+	}
+	verifier, ok := verifiers[provider]
+	if !ok || verifier == nil {
+		return NewInputError(fmt.Errorf("沒有隨機性來源 %q 的驗證器", provider))
+	}
+	if err := verifier.Verify(proof.Beacon()); err != nil {
+		return fmt.Errorf("來源 %s 的隨機信標驗證失敗: %w", provider, err)
+	}
+	return VerifyProof(proof, template, deck)
+}
+
+// errNoProviderInfo 表示隨機性來源沒有 drand 的鏈信息
+var errNoProviderInfo = errors.New("隨機性來源沒有鏈信息")
+
+// providerClient 將 BeaconProvider 適配為 DrandManager 使用的 drand.Client
+type providerClient struct {
+	provider BeaconProvider
+}
+
+// Get 返回指定輪次的隨機信標
+func (c *providerClient) Get(ctx context.Context, round uint64) (drand.Result, error) {
+	beacon, err := c.provider.Beacon(ctx, round)
+	if err != nil {
+		return nil, err
+	}
+	return beacon, nil
+}
+
+// Watch 返回已關閉的通道，DrandManager 以輪詢獲取新的隨機信標
+func (c *providerClient) Watch(ctx context.Context) <-chan drand.Result {
+	ch := make(chan drand.Result)
+	close(ch)
+	return ch
+}
+
+// Info 返回錯誤，隨機性來源沒有鏈信息
+func (c *providerClient) Info(ctx context.Context) (*chain.Info, error) {
+	return nil, errNoProviderInfo
+}
+
+// RoundAt 返回 0，沒有鏈信息時無法從時間推算輪次
+func (c *providerClient) RoundAt(time.Time) uint64 {
+	return 0
+}
+
+// Close 在來源實現 io.Closer 時關閉它
+func (c *providerClient) Close() error {
+	if closer, ok := c.provider.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
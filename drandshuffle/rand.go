@@ -0,0 +1,136 @@
+package drandshuffle
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// RandAlgorithm 是 RandProof 記錄的種子派生算法標識
+const RandAlgorithm = "drandshuffle/rand/v1"
+
+// randDomain 是派生 *rand.Rand 種子時使用的域分隔前綴，使其與洗牌的種子互不相同
+const randDomain = "drandshuffle/rand/v1"
+
+// RandProof 記錄 NewRandForSession 派生種子所需的全部輸入
+// 審計方可以用 VerifyRandProof 重新計算種子，再用 NewRand 重放遊戲引擎的全部隨機數。
+// Seed 也可以直接傳給 TieBreak、SplitTeams 和 VetoDraft，它們各自以不同的域分隔前綴派生種子，同一個 Seed 的各種結果互不相關
+type RandProof struct {
+	Algorithm         string    `json:"algorithm"`
+	ChainHash         string    `json:"chain_hash"`
+	Round             uint64    `json:"round"`
+	RoundTime         time.Time `json:"round_time"`
+	Randomness        []byte    `json:"randomness"`
+	Signature         []byte    `json:"signature,omitempty"`
+	PreviousSignature []byte    `json:"previous_signature,omitempty"`
+	SessionID         string    `json:"session_id"`
+	Seed              []byte    `json:"seed"`               // SHA256("drandshuffle/rand/v1" || Randomness || SessionID)
+	Provider          string    `json:"provider,omitempty"` // 隨機性來源的標識，為空時表示 drand，見 BeaconProvider
+}
+
+// NewRandForSession 使用默認 Client 派生遊戲局的 *rand.Rand，見 Client.NewRandForSession
+func NewRandForSession(ctx context.Context, round uint64, sessionID string) (*rand.Rand, *RandProof, error) {
+	c, err := DefaultClient()
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.NewRandForSession(ctx, round, sessionID)
+}
+
+// NewRandForSession 返回由指定輪次的隨機信標和遊戲局號決定的 *rand.Rand，以及記錄種子派生過程的證明
+// 接受 *rand.Rand 的舊遊戲引擎只需替換隨機數來源即可變得可驗證：相同的輪次和遊戲局號總是產生相同的隨機數序列。
+// 底層是以 RandProof.Seed 為種子的 DRBG，Seed 方法不會改變輸出，以免引擎以時間等重新播種破壞可驗證性；
+// Intn 等方法的結果由 math/rand 的算法決定，其他語言重現時應直接使用 DRBG 的字節流。
+// round 為 Latest 時使用最新的隨機信標（嚴格輪次模式下不允許）；返回的 *rand.Rand 不是並發安全的
+func (c *Client) NewRandForSession(ctx context.Context, round uint64, sessionID string) (*rand.Rand, *RandProof, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	if err := ValidateSessionID(sessionID); err != nil {
+		return nil, nil, err
+	}
+
+	b := &ShuffleBuilder{client: c, round: round, sessionID: sessionID}
+	beacon, err := b.beacon(ctx, c.manager)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := c.manager.chargeUsage(ctx, beacon.Round); err != nil {
+		return nil, nil, err
+	}
+
+	proof := &RandProof{
+		Algorithm:         RandAlgorithm,
+		ChainHash:         c.manager.config.ChainHash,
+		Round:             beacon.Round,
+		Randomness:        beacon.Randomness,
+		Signature:         beacon.Signature,
+		PreviousSignature: beacon.PreviousSignature,
+		SessionID:         sessionID,
+		Seed:              randSeed(beacon.Randomness, sessionID),
+		Provider:          c.manager.provider,
+	}
+	proof.RoundTime, _ = c.manager.RoundTime(beacon.Round)
+	return proof.NewRand(), proof, nil
+}
+
+// randSeed 從隨機性和遊戲局號派生 *rand.Rand 的種子
+func randSeed(randomness []byte, sessionID string) []byte {
+	h := sha256.New()
+	h.Write([]byte(randDomain))
+	h.Write(randomness)
+	h.Write([]byte(sessionID))
+	return h.Sum(nil)
+}
+
+// VerifyRandProof 根據證明中的隨機性和遊戲局號重新派生種子，檢查是否與證明記錄的種子一致
+// 只檢查種子；隨機信標本身的簽名應另外用 Verifier 驗證，見 RandProof.Beacon
+func VerifyRandProof(proof *RandProof) error {
+	if proof == nil {
+		return NewInputError(fmt.Errorf("缺少隨機數證明"))
+	}
+	if proof.Algorithm != RandAlgorithm {
+		return NewInputError(fmt.Errorf("不支持的種子派生算法 %q", proof.Algorithm))
+	}
+	want := randSeed(proof.Randomness, proof.SessionID)
+	if subtle.ConstantTimeCompare(want, proof.Seed) != 1 {
+		return NewVerificationError(fmt.Errorf("%w: 輪次 %d 和遊戲局號 %s 派生的種子與證明不一致", ErrSeedMismatch, proof.Round, proof.SessionID))
+	}
+	return nil
+}
+
+// NewRand 以證明記錄的種子創建新的 *rand.Rand，輸出與 NewRandForSession 返回的 *rand.Rand 從頭開始的序列相同
+func (p *RandProof) NewRand() *rand.Rand {
+	return rand.New(&drbgSource{drbg: NewDRBG(p.Seed)})
+}
+
+// Beacon 返回證明中記錄的隨機信標，可以交給 Verifier 驗證簽名
+func (p *RandProof) Beacon() Beacon {
+	return Beacon{
+		Round:             p.Round,
+		Randomness:        copyBytes(p.Randomness),
+		Signature:         copyBytes(p.Signature),
+		PreviousSignature: copyBytes(p.PreviousSignature),
+	}
+}
+
+// drbgSource 將 DRBG 包裝為 math/rand 的 Source64
+type drbgSource struct {
+	drbg *DRBG
+}
+
+// Int63 返回非負的 63 位元隨機數
+func (s *drbgSource) Int63() int64 {
+	return int64(s.drbg.Uint64() >> 1)
+}
+
+// Uint64 返回 64 位元隨機數
+func (s *drbgSource) Uint64() uint64 {
+	return s.drbg.Uint64()
+}
+
+// Seed 不做任何事，種子由隨機信標和遊戲局號決定，不能被重新設定
+func (s *drbgSource) Seed(int64) {}
@@ -0,0 +1,68 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/sqlstore"
+)
+
+// ParseTime 解析報告時間範圍的邊界，接受 RFC 3339 時間或 2006-01-02 格式的 UTC 日期
+func ParseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return time.Time{}, drandshuffle.NewInputError(fmt.Errorf("%w: 無效的時間 %q，應為 RFC 3339 或 2006-01-02 格式", drandshuffle.ErrInvalidConfig, s))
+	}
+	return t, nil
+}
+
+// NewHandler 返回以 GET 生成報告的 http.Handler，查詢參數為 from、to（格式見 ParseTime）和可選的 tenant
+// 響應是未簽名的 Report JSON：監管方以自己的實例對複製過來的牌局重新統計，不需要運營方的簽名。
+// 與 drandshuffle 的其他 Handler 一樣不檢查請求路徑，也不做身份驗證；opts 適用於每次請求，請求的 tenant 優先於 WithTenant
+func NewHandler(store *sqlstore.Store, opts ...Option) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "只接受 GET 和 HEAD 請求", http.StatusMethodNotAllowed)
+			return
+		}
+		params := r.URL.Query()
+		if params.Get("from") == "" || params.Get("to") == "" {
+			http.Error(w, "必須指定 from 和 to", http.StatusBadRequest)
+			return
+		}
+		from, err := ParseTime(params.Get("from"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		to, err := ParseTime(params.Get("to"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		reqOpts := opts
+		if tenant := params.Get("tenant"); tenant != "" {
+			reqOpts = append(opts[:len(opts):len(opts)], WithTenant(tenant))
+		}
+
+		rep, err := Generate(r.Context(), store, from, to, reqOpts...)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if drandshuffle.Category(err) == drandshuffle.CategoryInput {
+				status = http.StatusBadRequest
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		json.NewEncoder(w).Encode(rep)
+	})
+}
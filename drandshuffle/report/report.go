@@ -0,0 +1,183 @@
+// Package report 為監管機構生成一段時間內隨機性使用情況的報告，並以 Ed25519 簽名
+//
+// Generate 從 sqlstore 讀取時間範圍內保存的牌局，統計使用的輪次和發出的局數，以洗牌證明重新核對每局的牌組得出驗證通過率，
+// 並以 simulate.Tally 統計通過驗證的牌組的位置頻率和卡方檢驗。Sign 將報告和簽名封裝為一個 JSON 文件，
+// 監管方以運營方公布的公鑰調用 Verify 檢查文件未被修改。
+//
+// 報告只核對牌組與證明一致，不驗證隨機信標的簽名；同一輪次發出多局時各局並不獨立（見 simulate 包的說明），
+// 這時 Rounds.MaxSessionsPerRound 大於 1，均勻性統計只能作為參考。
+package report
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/simulate"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/sqlstore"
+)
+
+// Format 是報告的格式標識，格式不兼容地改變時提升版本
+const Format = "drandshuffle-report/v1"
+
+// MaxFailures 是報告中列出的驗證失敗牌局的數量上限，更多的失敗只計入 Verification.Failed
+const MaxFailures = 100
+
+// Report 是一段時間內的隨機性使用報告，可以直接序列化為 JSON
+type Report struct {
+	Format       string               `json:"format"`
+	From         time.Time            `json:"from"`             // 時間範圍的開始（含），UTC
+	To           time.Time            `json:"to"`               // 時間範圍的結束（不含），UTC
+	Tenant       string               `json:"tenant,omitempty"` // 只統計此租戶的牌局，為空時統計全部
+	GeneratedAt  time.Time            `json:"generated_at"`
+	Algorithm    string               `json:"algorithm"` // drandshuffle.ProofAlgorithm
+	Sessions     int                  `json:"sessions"`  // 時間範圍內保存的牌局數
+	Rounds       RoundStats           `json:"rounds"`
+	Verification VerificationStats    `json:"verification"`
+	Uniformity   *simulate.Uniformity `json:"uniformity"` // 通過驗證的牌組的位置頻率，沒有時為 nil
+}
+
+// RoundStats 是報告期間使用的隨機信標輪次
+type RoundStats struct {
+	Used                int    `json:"used"`  // 使用的不同輪次數量
+	First               uint64 `json:"first"` // 最小的輪次，沒有牌局時為 0
+	Last                uint64 `json:"last"`  // 最大的輪次，沒有牌局時為 0
+	MaxSessionsPerRound int    `json:"max_sessions_per_round"`
+}
+
+// VerificationStats 是以洗牌證明重新核對牌組的結果
+type VerificationStats struct {
+	Verified int       `json:"verified"`           // 牌組與證明一致
+	Failed   int       `json:"failed"`             // 牌組與證明不符或沒有證明
+	Skipped  int       `json:"skipped"`            // 證明的牌組張數與報告的牌組模板不同，屬於其他遊戲，不核對
+	PassRate float64   `json:"pass_rate"`          // Verified / (Verified + Failed)，兩者都為 0 時為 0
+	Failures []Failure `json:"failures,omitempty"` // 最多 MaxFailures 局失敗的牌局
+}
+
+// Failure 是一局驗證失敗的牌局
+type Failure struct {
+	SessionID string `json:"session_id"`
+	Round     uint64 `json:"round"`
+	Reason    string `json:"reason"`
+}
+
+// options 是 Generate 的參數
+type options struct {
+	tenant string
+	deck   *drandshuffle.DeckTemplate
+	now    func() time.Time
+}
+
+// Option 設定 Generate 的參數
+type Option func(*options)
+
+// WithTenant 只統計指定租戶的牌局
+func WithTenant(tenant string) Option {
+	return func(o *options) {
+		o.tenant = tenant
+	}
+}
+
+// WithDeck 設定核對和統計使用的牌組模板，默認為 drandshuffle.Poker52；證明的牌組張數不同的牌局計入 Skipped
+func WithDeck(template *drandshuffle.DeckTemplate) Option {
+	return func(o *options) {
+		o.deck = template
+	}
+}
+
+// WithNow 設定取得 GeneratedAt 的時間來源，默認為 time.Now，用於生成可重現的報告
+func WithNow(now func() time.Time) Option {
+	return func(o *options) {
+		o.now = now
+	}
+}
+
+// Generate 統計 [from, to) 期間保存到 store 的牌局，按 sqlstore.Session.CreatedAt 篩選
+// 時間範圍為空或顛倒時返回包裝 ErrInvalidConfig 的錯誤；ctx 取消時返回 ctx 的錯誤
+func Generate(ctx context.Context, store *sqlstore.Store, from, to time.Time, opts ...Option) (*Report, error) {
+	o := options{deck: drandshuffle.Poker52, now: time.Now}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if from.IsZero() || !to.After(from) {
+		return nil, drandshuffle.NewInputError(fmt.Errorf("%w: 無效的時間範圍 %s 至 %s", drandshuffle.ErrInvalidConfig, from.Format(time.RFC3339), to.Format(time.RFC3339)))
+	}
+	if o.deck == nil {
+		o.deck = drandshuffle.Poker52
+	}
+	tally, err := simulate.NewTally(o.deck)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Report{
+		Format:      Format,
+		From:        from.UTC(),
+		To:          to.UTC(),
+		Tenant:      o.tenant,
+		GeneratedAt: o.now().UTC(),
+		Algorithm:   drandshuffle.ProofAlgorithm,
+	}
+	perRound := make(map[uint64]int)
+	q := sqlstore.SessionQuery{Tenant: o.tenant, CreatedFrom: from, CreatedTo: to, Limit: sqlstore.MaxPageSize}
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		page, err := store.ListSessions(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		for i := range page.Sessions {
+			r.add(&page.Sessions[i], o.deck, tally, perRound)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		q.Cursor = page.NextCursor
+	}
+
+	r.Rounds.Used = len(perRound)
+	for _, n := range perRound {
+		r.Rounds.MaxSessionsPerRound = max(r.Rounds.MaxSessionsPerRound, n)
+	}
+	if checked := r.Verification.Verified + r.Verification.Failed; checked > 0 {
+		r.Verification.PassRate = float64(r.Verification.Verified) / float64(checked)
+	}
+	if r.Verification.Verified > 0 {
+		r.Uniformity = tally.Uniformity()
+	}
+	return r, nil
+}
+
+// add 將一局牌局計入報告
+func (r *Report) add(s *sqlstore.Session, template *drandshuffle.DeckTemplate, tally *simulate.Tally, perRound map[uint64]int) {
+	r.Sessions++
+	if r.Rounds.First == 0 || s.Round < r.Rounds.First {
+		r.Rounds.First = s.Round
+	}
+	r.Rounds.Last = max(r.Rounds.Last, s.Round)
+	perRound[s.Round]++
+
+	v := &r.Verification
+	if s.Proof != nil && s.Proof.DeckSize != template.Len() {
+		v.Skipped++
+		return
+	}
+	err := fmt.Errorf("沒有洗牌證明")
+	if s.Proof != nil {
+		err = drandshuffle.VerifyProof(s.Proof, template, s.Deck)
+	}
+	if err == nil {
+		err = tally.Add(s.Deck)
+	}
+	if err != nil {
+		v.Failed++
+		if len(v.Failures) < MaxFailures {
+			v.Failures = append(v.Failures, Failure{SessionID: s.SessionID, Round: s.Round, Reason: err.Error()})
+		}
+		return
+	}
+	v.Verified++
+}
@@ -0,0 +1,74 @@
+package report
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+)
+
+// signatureDomain 是簽名內容的域分隔前綴，防止簽名被挪用到其他格式的數據上
+const signatureDomain = "drandshuffle/report/v1\n"
+
+// ErrBadSignature 表示簽名的報告無法以指定的公鑰驗證，報告可能被修改或不是由該公鑰的持有者簽名
+var ErrBadSignature = errors.New("report: bad signature")
+
+// signedReport 是 Sign 輸出的文件格式
+// signature 是 Ed25519 對簽名域前綴加 report 的緊湊 JSON 編碼的簽名；public_key 只供識別，驗證時使用調用者提供的公鑰
+type signedReport struct {
+	Report    json.RawMessage `json:"report"`
+	PublicKey []byte          `json:"public_key"`
+	Signature []byte          `json:"signature"`
+}
+
+// Sign 以 Ed25519 私鑰簽名報告，返回包含報告、公鑰和簽名的 JSON 文件
+// 只改變文件中的空白（例如重新縮進）不影響驗證，報告的任何內容改變都會使驗證失敗
+func Sign(r *Report, key ed25519.PrivateKey) ([]byte, error) {
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, drandshuffle.NewInputError(fmt.Errorf("無效的 Ed25519 私鑰長度 %d", len(key)))
+	}
+	body, err := json.Marshal(r)
+	if err != nil {
+		return nil, fmt.Errorf("無法編碼報告: %w", err)
+	}
+	signed := signedReport{
+		Report:    body,
+		PublicKey: key.Public().(ed25519.PublicKey),
+		Signature: ed25519.Sign(key, append([]byte(signatureDomain), body...)),
+	}
+	data, err := json.MarshalIndent(signed, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("無法編碼簽名的報告: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// Verify 以公鑰驗證 Sign 輸出的文件並返回其中的報告
+// 簽名不符時返回 ErrBadSignature；文件格式錯誤或報告格式不是 Format 時返回輸入錯誤
+func Verify(data []byte, key ed25519.PublicKey) (*Report, error) {
+	if len(key) != ed25519.PublicKeySize {
+		return nil, drandshuffle.NewInputError(fmt.Errorf("無效的 Ed25519 公鑰長度 %d", len(key)))
+	}
+	var signed signedReport
+	if err := json.Unmarshal(data, &signed); err != nil {
+		return nil, drandshuffle.NewInputError(fmt.Errorf("無法解析簽名的報告: %w", err))
+	}
+	var body bytes.Buffer
+	if err := json.Compact(&body, signed.Report); err != nil {
+		return nil, drandshuffle.NewInputError(fmt.Errorf("無法解析報告: %w", err))
+	}
+	if !ed25519.Verify(key, append([]byte(signatureDomain), body.Bytes()...), signed.Signature) {
+		return nil, drandshuffle.NewVerificationError(ErrBadSignature)
+	}
+	var r Report
+	if err := json.Unmarshal(body.Bytes(), &r); err != nil {
+		return nil, drandshuffle.NewInputError(fmt.Errorf("無法解析報告: %w", err))
+	}
+	if r.Format != Format {
+		return nil, drandshuffle.NewInputError(fmt.Errorf("不支持的報告格式 %q，預期為 %q", r.Format, Format))
+	}
+	return &r, nil
+}
@@ -0,0 +1,290 @@
+package drandshuffle
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// RetentionPolicy 決定壓縮存儲時保留哪些記錄
+// 各條件同時生效，任一條件要求刪除的記錄都會被刪除；不大於 0 的欄位表示不按該條件刪除，零值的策略不刪除任何記錄
+type RetentionPolicy struct {
+	// MaxAge 刪除早於 now-MaxAge 的記錄
+	// 隨機信標按 RoundTime 推算的發布時間判斷；牌局和審計記錄同時按保存或發牌的時間判斷
+	MaxAge time.Duration
+	// MinRound 刪除輪次小於 MinRound 的記錄
+	MinRound uint64
+	// MaxRounds 只保留最新輪次及其之前共 MaxRounds 個輪次的記錄
+	MaxRounds uint64
+	// MaxEntries 只保留最新的 MaxEntries 條記錄
+	MaxEntries int
+	// MaxBytes 只保留最新的、合計不超過 MaxBytes 字節的記錄，只適用於文件存儲
+	MaxBytes int64
+	// RoundTime 返回輪次的發布時間，通常是 DrandManager.RoundTime；為 nil 時隨機信標不按 MaxAge 刪除
+	RoundTime func(round uint64) (time.Time, bool)
+}
+
+// KeepFrom 按 MinRound、MaxRounds 和 MaxAge 返回應保留的最小輪次，latest 是存儲中最大的輪次
+// MaxAge 只在設定了 RoundTime 時生效；RoundTime 無法推算的輪次視為未過期
+func (p RetentionPolicy) KeepFrom(latest uint64, now time.Time) uint64 {
+	from := p.MinRound
+	if p.MaxRounds > 0 && latest >= p.MaxRounds && latest-p.MaxRounds+1 > from {
+		from = latest - p.MaxRounds + 1
+	}
+	if p.MaxAge > 0 && p.RoundTime != nil && latest > 0 {
+		deadline := now.Add(-p.MaxAge)
+		// 輪次的發布時間隨輪次遞增，二分查找第一個不早於 deadline 的輪次
+		lo, hi := uint64(1), latest+1
+		for lo < hi {
+			mid := lo + (hi-lo)/2
+			if t, ok := p.RoundTime(mid); ok && t.Before(deadline) {
+				lo = mid + 1
+			} else {
+				hi = mid
+			}
+		}
+		if lo > from {
+			from = lo
+		}
+	}
+	return from
+}
+
+// Expired 報告時間為 at 的記錄是否超過 MaxAge
+func (p RetentionPolicy) Expired(at, now time.Time) bool {
+	return p.MaxAge > 0 && at.Before(now.Add(-p.MaxAge))
+}
+
+// KeepLast 按 MaxEntries 和 MaxBytes 返回應保留的最新記錄數量，sizes 是由舊到新排列的各條記錄的字節數
+func (p RetentionPolicy) KeepLast(sizes []int64) int {
+	n := len(sizes)
+	if p.MaxEntries > 0 {
+		n = min(n, p.MaxEntries)
+	}
+	if p.MaxBytes > 0 {
+		var total int64
+		for i := 0; i < n; i++ {
+			if total += sizes[len(sizes)-1-i]; total > p.MaxBytes {
+				return i
+			}
+		}
+	}
+	return n
+}
+
+// Compactor 是可以按保留策略刪除舊記錄的存儲
+// FileBeaconStore、WriteBehindStore、sqlstore.Store 和 audit.FileStore 都實現了 Compactor
+type Compactor interface {
+	// Compact 刪除策略不再保留的記錄，返回刪除的記錄數；now 是判斷記錄年齡的當前時間
+	Compact(ctx context.Context, policy RetentionPolicy, now time.Time) (int, error)
+}
+
+// 確保文件存儲可以壓縮
+var (
+	_ Compactor = (*FileBeaconStore)(nil)
+	_ Compactor = (*WriteBehindStore)(nil)
+)
+
+// defaultCompactionInterval 未指定間隔時後台壓縮的間隔
+const defaultCompactionInterval = time.Hour
+
+// compaction 是後台定期壓縮一個存儲的任務
+type compaction struct {
+	store    Compactor
+	policy   RetentionPolicy
+	interval time.Duration
+	clock    Clock
+	logger   *slog.Logger
+	// catch 調用一次壓縮，發生 panic 時恢復並返回 *PanicError
+	catch func(where string, fn func()) error
+}
+
+// start 在後台立即壓縮一次，之後每隔 interval 壓縮一次，返回的函數停止後台壓縮並等待進行中的壓縮完成
+func (c compaction) start() (stop func()) {
+	if c.interval <= 0 {
+		c.interval = defaultCompactionInterval
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			c.run(ctx)
+			select {
+			case <-c.clock.After(c.interval):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			cancel()
+			wg.Wait()
+		})
+	}
+}
+
+// run 壓縮一次並記錄結果
+func (c compaction) run(ctx context.Context) {
+	var removed int
+	var err error
+	if p := c.catch("compaction", func() { removed, err = c.store.Compact(ctx, c.policy, c.clock.Now()) }); p != nil {
+		err = p
+	}
+	switch {
+	case ctx.Err() != nil:
+		// 停止時取消了進行中的壓縮，不算失敗
+	case err != nil:
+		c.logger.Warn("無法壓縮存儲", slog.Any("error", err))
+	case removed > 0:
+		c.logger.Info("已壓縮存儲", slog.Int("removed", removed))
+	}
+}
+
+// StartCompaction 在後台按保留策略定期壓縮存儲，適用於不經過 DrandManager 的存儲，例如 audit.FileStore 或保存牌局的 sqlstore.Store
+// 立即壓縮一次，之後每隔 interval（不大於 0 時為一小時）壓縮一次；失敗時以 Warn 級別記錄到 logger，logger 為 nil 時不記錄。
+// 返回的 stop 停止後台壓縮並等待進行中的壓縮完成，可以重複調用。隨機信標存儲交給 DrandManager 時改用 WithRetention
+func StartCompaction(store Compactor, policy RetentionPolicy, interval time.Duration, logger *slog.Logger) (stop func()) {
+	if logger == nil {
+		logger = discardLogger
+	}
+	return compaction{
+		store:    store,
+		policy:   policy,
+		interval: interval,
+		clock:    systemClock{},
+		logger:   logger,
+		catch:    CatchPanic,
+	}.start()
+}
+
+// startCompaction 按 WithRetention 設定的策略開始後台壓縮隨機信標存儲，未設定策略時不做任何事
+func (dm *DrandManager) startCompaction() {
+	if dm.retention == nil {
+		return
+	}
+	if !canCompact(dm.store) {
+		dm.logger.Warn("隨機信標存儲不支持壓縮，保留策略不會生效")
+		return
+	}
+	policy := *dm.retention
+	if policy.RoundTime == nil {
+		policy.RoundTime = dm.RoundTime
+	}
+	dm.stopCompaction = compaction{
+		store:    dm.store.(Compactor),
+		policy:   policy,
+		interval: dm.retentionInterval,
+		clock:    dm.clock,
+		logger:   dm.logger,
+		catch:    dm.catchPanic,
+	}.start()
+}
+
+// canCompact 報告存儲是否可以壓縮，WriteBehindStore 取決於底層存儲
+func canCompact(store BeaconStore) bool {
+	if wb, ok := store.(*WriteBehindStore); ok {
+		store = wb.store
+	}
+	_, ok := store.(Compactor)
+	return ok
+}
+
+// Compact 按保留策略重寫信標文件，同一輪次的重複記錄只保留一條
+// 先寫入同一目錄下的臨時文件並 fsync，再以重命名替換原文件，崩潰時原文件保持不變
+func (s *FileBeaconStore) Compact(ctx context.Context, policy RetentionPolicy, now time.Time) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	beacons, err := s.load()
+	if err != nil || len(beacons) == 0 {
+		return 0, err
+	}
+
+	kept := slices.Clone(beacons)
+	slices.SortStableFunc(kept, func(a, b Beacon) int { return cmp.Compare(a.Round, b.Round) })
+	kept = slices.CompactFunc(kept, func(a, b Beacon) bool { return a.Round == b.Round })
+	from := policy.KeepFrom(kept[len(kept)-1].Round, now)
+	for len(kept) > 0 && kept[0].Round < from {
+		kept = kept[1:]
+	}
+
+	lines := make([][]byte, len(kept))
+	sizes := make([]int64, len(kept))
+	for i, beacon := range kept {
+		if lines[i], err = encodeBeaconRecord(beacon); err != nil {
+			return 0, err
+		}
+		sizes[i] = int64(len(lines[i]))
+	}
+	lines = lines[len(lines)-policy.KeepLast(sizes):]
+
+	removed := len(beacons) - len(lines)
+	if removed == 0 {
+		return 0, nil
+	}
+	var buf []byte
+	for _, line := range lines {
+		buf = append(buf, line...)
+	}
+	if err := ReplaceFile(s.path, buf); err != nil {
+		return 0, fmt.Errorf("無法重寫信標文件: %w", err)
+	}
+	return removed, nil
+}
+
+// Compact 先寫入暫存的信標，再壓縮底層存儲；底層存儲不支持壓縮時返回錯誤
+func (s *WriteBehindStore) Compact(ctx context.Context, policy RetentionPolicy, now time.Time) (int, error) {
+	c, ok := s.store.(Compactor)
+	if !ok {
+		return 0, fmt.Errorf("底層存儲 %T 不支持壓縮", s.store)
+	}
+	if err := s.Flush(); err != nil {
+		return 0, err
+	}
+	return c.Compact(ctx, policy, now)
+}
+
+// ReplaceFile 以臨時文件和重命名原子地替換 path 的內容
+// 先寫入同一目錄下的臨時文件並 fsync，再重命名覆蓋 path 並 fsync 目錄，崩潰時 path 保持原來的內容或完整的新內容。
+// 持有 path 打開的文件句柄的調用者在替換後應重新打開文件，舊句柄仍指向被替換掉的文件
+func ReplaceFile(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	// 目錄的 fsync 使重命名持久化，部分平台不支持打開目錄，此時忽略
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package drandshuffle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/drand/drand/v2/common"
+)
+
+// FutureRoundError 表示請求的輪次尚未發布，AvailableAt 是該輪次最早可以獲取的時間
+// 可以用 errors.Is(err, ErrFutureRound) 判斷，用 errors.As 取得 AvailableAt
+type FutureRoundError struct {
+	Round       uint64
+	AvailableAt time.Time
+}
+
+// Error 返回錯誤說明
+func (e *FutureRoundError) Error() string {
+	if e.AvailableAt.IsZero() {
+		return fmt.Sprintf("%v: 輪次 %d 尚未發布", ErrFutureRound, e.Round)
+	}
+	return fmt.Sprintf("%v: 輪次 %d 尚未發布，最早可在 %s 獲取", ErrFutureRound, e.Round, e.AvailableAt.Format(time.RFC3339))
+}
+
+// Is 使 errors.Is(err, ErrFutureRound) 成立
+func (e *FutureRoundError) Is(target error) bool {
+	return target == ErrFutureRound
+}
+
+// checkRound 檢查輪次是否已經發布
+// 輪次 1 在創世時間發布，輪次 0 不存在；尚未取得鏈信息時只檢查輪次 0
+func (dm *DrandManager) checkRound(round uint64) error {
+	if round == 0 {
+		return NewInputError(fmt.Errorf("%w: 輪次從 1 開始", ErrRoundBeforeGenesis))
+	}
+
+	info := dm.chainInfo.Load()
+	if info == nil {
+		return nil
+	}
+
+	published := common.TimeOfRound(info.Period, info.GenesisTime, round)
+	if published == common.TimeOfRoundErrorValue {
+		return NewInputError(&FutureRoundError{Round: round})
+	}
+	if availableAt := time.Unix(published, 0); availableAt.After(dm.clock.Now()) {
+		return NewInputError(&FutureRoundError{Round: round, AvailableAt: availableAt})
+	}
+	return nil
+}
+
+// RoundTime 返回輪次的發布時間，按鏈的創世時間和週期計算，精度為秒
+// 尚未取得鏈信息、輪次為 0 或超出鏈的時間範圍時返回 false；不需要網絡請求，也不檢查輪次是否已經發布
+func (dm *DrandManager) RoundTime(round uint64) (time.Time, bool) {
+	info := dm.chainInfo.Load()
+	if info == nil || round == 0 {
+		return time.Time{}, false
+	}
+	published := common.TimeOfRound(info.Period, info.GenesisTime, round)
+	if published == common.TimeOfRoundErrorValue {
+		return time.Time{}, false
+	}
+	return time.Unix(published, 0).UTC(), true
+}
+
+// checkLatestAllowed 在嚴格輪次模式下拒絕使用最新隨機信標的請求
+func (dm *DrandManager) checkLatestAllowed() error {
+	if dm.config.StrictRounds {
+		return NewInputError(fmt.Errorf("%w: 嚴格輪次模式下不能使用最新輪次洗牌，請指定預先承諾的輪次", ErrExplicitRoundRequired))
+	}
+	return nil
+}
+
+// WaitForRound 等待指定輪次發布並返回其隨機性，適用於預先約定未來輪次的開獎和發牌
+// 已知鏈信息時按時鐘等待到發布時間後才請求；中繼尚未傳播新信標時每隔一段時間重試，
+// 直到 ctx 取消或 DrandManager 關閉；返回的切片歸調用者所有
+func (dm *DrandManager) WaitForRound(ctx context.Context, round uint64) ([]byte, error) {
+	for {
+		delay := minPollDelay
+		err := dm.checkRound(round)
+		var future *FutureRoundError
+		switch {
+		case errors.As(err, &future) && !future.AvailableAt.IsZero():
+			delay = future.AvailableAt.Sub(dm.clock.Now()) + pollMargin
+		case err != nil:
+			return nil, err
+		default:
+			beacon, err := dm.beaconByRound(ctx, round)
+			if err == nil {
+				return copyBytes(beacon.GetRandomness()), nil
+			}
+			if !IsRetryable(err) || ctx.Err() != nil {
+				return nil, err
+			}
+		}
+
+		select {
+		case <-dm.clock.After(delay):
+		case <-ctx.Done():
+			return nil, networkError(fmt.Errorf("無法等待輪次 %d 發布: %w", round, ctx.Err()))
+		case <-dm.closed:
+			return nil, fmt.Errorf("%w: 無法等待輪次 %d 發布", ErrClosed, round)
+		}
+	}
+}
@@ -0,0 +1,445 @@
+// Package schedule 管理預先約定輪次的牌局，並統一處理輪次在開局前已經發布的情況
+//
+// 牌局在創建時綁定一個尚未發布的輪次，玩家入座後以 Start 開局，再以 Wait 等待該輪次發布並用它洗牌。
+// 輪次的隨機性一經發布就是公開的，開局前已經發布的輪次不能再用來洗牌，否則知道隨機性的人可以提前算出牌組。
+// 這時按 Policy 處理：作廢牌局、改綁到下一個尚未發布的輪次並在審計記錄中留下說明，或暫停等待人工處理，
+// 而不是由各個集成方各自決定。
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/audit"
+)
+
+// 哨兵錯誤，可以用 errors.Is 判斷
+var (
+	// ErrSessionNotFound 表示沒有該遊戲局號的預定牌局
+	ErrSessionNotFound = errors.New("schedule: session not found")
+	// ErrSessionExists 表示該遊戲局號已經預定過
+	ErrSessionExists = errors.New("schedule: session exists")
+	// ErrSessionExpired 表示牌局的輪次在開局前已經發布，牌局已作廢
+	ErrSessionExpired = errors.New("schedule: session expired")
+	// ErrSessionHeld 表示牌局的輪次在開局前已經發布，牌局暫停等待人工處理
+	ErrSessionHeld = errors.New("schedule: session held")
+	// ErrInvalidState 表示牌局當前的狀態不允許該操作，例如未開局就等待發牌
+	ErrInvalidState = errors.New("schedule: invalid session state")
+)
+
+// Action 是綁定的輪次在開局前發布時的處理方式
+type Action int
+
+const (
+	// Expire 作廢牌局，之後的 Start 和 Wait 返回 ErrSessionExpired
+	Expire Action = iota
+	// Rebind 把牌局改綁到下一個尚未發布的輪次，改綁經過記入 Session.Rebinds 和審計記錄的 Note
+	Rebind
+	// Hold 暫停牌局，等待人工以 Release 改綁或以 Cancel 作廢，期間 Start 返回 ErrSessionHeld
+	Hold
+)
+
+// String 返回處理方式的名稱
+func (a Action) String() string {
+	switch a {
+	case Expire:
+		return "expire"
+	case Rebind:
+		return "rebind"
+	case Hold:
+		return "hold"
+	default:
+		return fmt.Sprintf("Action(%d)", int(a))
+	}
+}
+
+// Policy 決定綁定的輪次在開局前發布時如何處理
+type Policy struct {
+	// Action 是處理方式，零值為 Expire
+	Action Action
+	// Lead 是改綁時新輪次距離當前時間的最短間隔，給玩家留出重新確認的時間；為 0 時改綁到下一個發布的輪次
+	Lead time.Duration
+	// MaxRebinds 是一個牌局最多自動改綁的次數，超過後按 Expire 處理；為 0 時不限。人工的 Release 不計入
+	MaxRebinds int
+}
+
+// State 是預定牌局的狀態
+type State int
+
+const (
+	// Scheduled 表示牌局已預定，等待開局
+	Scheduled State = iota
+	// Started 表示牌局在輪次發布前已經開局，可以等待輪次發布後發牌
+	Started
+	// Dealt 表示輪次已經發布，可以用它洗牌
+	Dealt
+	// Held 表示輪次在開局前已經發布，牌局暫停等待人工處理
+	Held
+	// Expired 表示輪次在開局前已經發布或牌局被取消，牌局已作廢
+	Expired
+)
+
+// String 返回狀態的名稱
+func (s State) String() string {
+	switch s {
+	case Scheduled:
+		return "scheduled"
+	case Started:
+		return "started"
+	case Dealt:
+		return "dealt"
+	case Held:
+		return "held"
+	case Expired:
+		return "expired"
+	default:
+		return fmt.Sprintf("State(%d)", int(s))
+	}
+}
+
+// Rebinding 是牌局一次改綁輪次的經過
+type Rebinding struct {
+	From   uint64    // 原來綁定、在開局前已經發布的輪次
+	To     uint64    // 新綁定的輪次
+	At     time.Time // 改綁的時間
+	Manual bool      // 是否由人工以 Release 改綁
+}
+
+// Session 是預定牌局的快照
+type Session struct {
+	ID          string      // 遊戲局號
+	Round       uint64      // 當前綁定的輪次
+	State       State       // 牌局的狀態
+	Rebinds     []Rebinding // 改綁的經過，按時間排列
+	Reason      string      // 牌局暫停或作廢的原因，其他狀態為空
+	ScheduledAt time.Time   // 預定的時間
+}
+
+// Note 返回改綁經過的審計說明，沒有改綁時返回空字符串
+func (s Session) Note() string {
+	notes := make([]string, len(s.Rebinds))
+	for i, r := range s.Rebinds {
+		how := "自動"
+		if r.Manual {
+			how = "人工"
+		}
+		notes[i] = fmt.Sprintf("輪次 %d 在開局前已發布，於 %s %s改綁到輪次 %d", r.From, r.At.UTC().Format(time.RFC3339), how, r.To)
+	}
+	return strings.Join(notes, "；")
+}
+
+// Record 從發牌結果創建審計記錄，並以 Note 記下改綁的經過
+func (s Session) Record(result *drandshuffle.ShuffleResult, dealtAt time.Time) audit.Record {
+	record := audit.NewRecord(result, s.ID, dealtAt)
+	record.Note = s.Note()
+	return record
+}
+
+// Option 是 Scheduler 的選項
+type Option func(*Scheduler)
+
+// WithClock 設定判斷輪次是否已經發布所用的時鐘，應與 DrandManager 的 WithClock 相同；默認使用系統時鐘
+func WithClock(clock drandshuffle.Clock) Option {
+	return func(s *Scheduler) {
+		s.clock = clock
+	}
+}
+
+// Scheduler 保存預定的牌局並按 Policy 處理錯過的輪次，可以並發使用
+// 輪次的發布時間由 DrandManager.RoundTime 推算，需要 DrandManager 已經取得鏈信息
+type Scheduler struct {
+	manager *drandshuffle.DrandManager
+	policy  Policy
+	clock   drandshuffle.Clock
+
+	mu       sync.Mutex
+	sessions map[string]*Session
+}
+
+// New 創建使用 manager 推算和等待輪次的 Scheduler
+func New(manager *drandshuffle.DrandManager, policy Policy, opts ...Option) *Scheduler {
+	s := &Scheduler{
+		manager:  manager,
+		policy:   policy,
+		clock:    systemClock{},
+		sessions: make(map[string]*Session),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// systemClock 是使用 time 包的系統時鐘
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// Schedule 預定一局綁定 round 的牌局
+// round 必須尚未發布，已發布時返回包裝 drandshuffle.ErrInvalidConfig 的錯誤；同一遊戲局號只能預定一次
+func (s *Scheduler) Schedule(sessionID string, round uint64) (Session, error) {
+	if err := drandshuffle.ValidateSessionID(sessionID); err != nil {
+		return Session{}, err
+	}
+	now := s.clock.Now()
+	published, err := s.published(round, now)
+	if err != nil {
+		return Session{}, err
+	}
+	if published {
+		return Session{}, fmt.Errorf("%w: 輪次 %d 已經發布，不能用於預定牌局", drandshuffle.ErrInvalidConfig, round)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.sessions[sessionID]; ok {
+		return Session{}, fmt.Errorf("%w: %s", ErrSessionExists, sessionID)
+	}
+	session := &Session{ID: sessionID, Round: round, State: Scheduled, ScheduledAt: now}
+	s.sessions[sessionID] = session
+	return session.snapshot(), nil
+}
+
+// Start 在玩家入座後開局
+// 綁定的輪次尚未發布時牌局進入 Started；已經發布時按 Policy 處理：Expire 返回 ErrSessionExpired，
+// Hold 返回 ErrSessionHeld，Rebind 改綁到下一個尚未發布的輪次後開局，返回的快照帶有新的輪次
+func (s *Scheduler) Start(sessionID string) (Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, err := s.lookup(sessionID)
+	if err != nil {
+		return Session{}, err
+	}
+	switch session.State {
+	case Started, Dealt:
+		return session.snapshot(), nil
+	case Held:
+		return session.snapshot(), fmt.Errorf("%w: %s", ErrSessionHeld, session.Reason)
+	case Expired:
+		return session.snapshot(), fmt.Errorf("%w: %s", ErrSessionExpired, session.Reason)
+	}
+
+	if err := s.applyPolicy(session, s.clock.Now()); err != nil {
+		return session.snapshot(), err
+	}
+	switch session.State {
+	case Held:
+		return session.snapshot(), fmt.Errorf("%w: %s", ErrSessionHeld, session.Reason)
+	case Expired:
+		return session.snapshot(), fmt.Errorf("%w: %s", ErrSessionExpired, session.Reason)
+	}
+	session.State = Started
+	return session.snapshot(), nil
+}
+
+// Sweep 對尚未開局、綁定的輪次已經發布的牌局執行 Policy，按遊戲局號排序返回狀態或輪次改變的牌局
+// 應定期調用，例如在 DrandManager.Subscribe 收到每個新信標時，使錯過的牌局及時改綁或作廢
+func (s *Scheduler) Sweep() ([]Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock.Now()
+	var changed []Session
+	for _, session := range s.sessions {
+		if session.State != Scheduled {
+			continue
+		}
+		round, rebinds := session.Round, len(session.Rebinds)
+		if err := s.applyPolicy(session, now); err != nil {
+			return changed, err
+		}
+		if session.State != Scheduled || session.Round != round || len(session.Rebinds) != rebinds {
+			changed = append(changed, session.snapshot())
+		}
+	}
+	slices.SortFunc(changed, func(a, b Session) int { return strings.Compare(a.ID, b.ID) })
+	return changed, nil
+}
+
+// Wait 以 DrandManager.WaitForRound 等待已開局的牌局綁定的輪次發布並返回其隨機性，之後牌局進入 Dealt
+// 調用者應以返回的 Session.Round 洗牌，並以 Session.Record 創建審計記錄。未開局時返回 ErrInvalidState，等待期間被作廢時返回 ErrSessionExpired
+func (s *Scheduler) Wait(ctx context.Context, sessionID string) (Session, []byte, error) {
+	s.mu.Lock()
+	session, err := s.lookup(sessionID)
+	if err == nil && session.State != Started && session.State != Dealt {
+		err = fmt.Errorf("%w: 牌局 %s 的狀態為 %s，尚未開局", ErrInvalidState, sessionID, session.State)
+	}
+	var round uint64
+	if err == nil {
+		round = session.Round
+	}
+	s.mu.Unlock()
+	if err != nil {
+		return Session{}, nil, err
+	}
+
+	randomness, err := s.manager.WaitForRound(ctx, round)
+	if err != nil {
+		return Session{}, nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if session.State == Expired {
+		// 等待期間被 Cancel 作廢
+		return session.snapshot(), nil, fmt.Errorf("%w: %s", ErrSessionExpired, session.Reason)
+	}
+	session.State = Dealt
+	return session.snapshot(), randomness, nil
+}
+
+// Release 人工改綁暫停的牌局到下一個尚未發布的輪次，牌局回到 Scheduled，改綁經過記入 Rebinds
+func (s *Scheduler) Release(sessionID string) (Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, err := s.lookup(sessionID)
+	if err != nil {
+		return Session{}, err
+	}
+	if session.State != Held {
+		return session.snapshot(), fmt.Errorf("%w: 牌局 %s 的狀態為 %s，沒有暫停", ErrInvalidState, sessionID, session.State)
+	}
+	if err := s.rebind(session, s.clock.Now(), true); err != nil {
+		return session.snapshot(), err
+	}
+	session.State, session.Reason = Scheduled, ""
+	return session.snapshot(), nil
+}
+
+// Cancel 作廢尚未發牌的牌局，reason 記入 Session.Reason
+func (s *Scheduler) Cancel(sessionID, reason string) (Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, err := s.lookup(sessionID)
+	if err != nil {
+		return Session{}, err
+	}
+	if session.State == Dealt {
+		return session.snapshot(), fmt.Errorf("%w: 牌局 %s 已經發牌", ErrInvalidState, sessionID)
+	}
+	session.State, session.Reason = Expired, reason
+	return session.snapshot(), nil
+}
+
+// Get 返回牌局的快照，沒有時返回 ErrSessionNotFound
+func (s *Scheduler) Get(sessionID string) (Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, err := s.lookup(sessionID)
+	if err != nil {
+		return Session{}, err
+	}
+	return session.snapshot(), nil
+}
+
+// Remove 刪除牌局，通常在發牌並保存審計記錄之後，或作廢的牌局處理完畢之後調用
+func (s *Scheduler) Remove(sessionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, sessionID)
+}
+
+// lookup 返回遊戲局號的牌局，調用者持有 s.mu
+func (s *Scheduler) lookup(sessionID string) (*Session, error) {
+	session, ok := s.sessions[sessionID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, sessionID)
+	}
+	return session, nil
+}
+
+// applyPolicy 在綁定的輪次已經發布時按 Policy 處理尚未開局的牌局，調用者持有 s.mu
+func (s *Scheduler) applyPolicy(session *Session, now time.Time) error {
+	published, err := s.published(session.Round, now)
+	if err != nil || !published {
+		return err
+	}
+	reason := fmt.Sprintf("輪次 %d 在開局前已發布", session.Round)
+	switch s.policy.Action {
+	case Hold:
+		session.State, session.Reason = Held, reason
+	case Rebind:
+		if s.policy.MaxRebinds > 0 && automaticRebinds(session) >= s.policy.MaxRebinds {
+			session.State, session.Reason = Expired, fmt.Sprintf("%s，已自動改綁 %d 次", reason, s.policy.MaxRebinds)
+			return nil
+		}
+		return s.rebind(session, now, false)
+	default:
+		session.State, session.Reason = Expired, reason
+	}
+	return nil
+}
+
+// rebind 把牌局改綁到 now+Lead 之後發布的第一個輪次並記錄經過，調用者持有 s.mu
+func (s *Scheduler) rebind(session *Session, now time.Time, manual bool) error {
+	next, err := s.nextRound(session.Round, now.Add(s.policy.Lead))
+	if err != nil {
+		return err
+	}
+	session.Rebinds = append(session.Rebinds, Rebinding{From: session.Round, To: next, At: now, Manual: manual})
+	session.Round = next
+	return nil
+}
+
+// nextRound 返回大於 after、在 deadline 之後發布的第一個輪次
+// 輪次的發布時間隨輪次遞增，先倍增找到上界再二分查找
+func (s *Scheduler) nextRound(after uint64, deadline time.Time) (uint64, error) {
+	lo, hi, step := after+1, after+1, uint64(1)
+	for {
+		published, err := s.published(hi, deadline)
+		if err != nil {
+			return 0, err
+		}
+		if !published {
+			break
+		}
+		lo, hi, step = hi+1, hi+step, step*2
+	}
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		published, err := s.published(mid, deadline)
+		if err != nil {
+			return 0, err
+		}
+		if published {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return hi, nil
+}
+
+// published 報告輪次在 now 時是否已經發布，無法推算發布時間時返回錯誤
+// 與 DrandManager 的判斷一致：發布時間不晚於 now 的輪次已經發布，更晚的輪次會返回 FutureRoundError
+func (s *Scheduler) published(round uint64, now time.Time) (bool, error) {
+	at, ok := s.manager.RoundTime(round)
+	if !ok {
+		return false, fmt.Errorf("%w: 無法推算輪次 %d 的發布時間，DrandManager 尚未取得鏈信息", drandshuffle.ErrInvalidConfig, round)
+	}
+	return !at.After(now), nil
+}
+
+// automaticRebinds 返回牌局自動改綁的次數
+func automaticRebinds(session *Session) int {
+	n := 0
+	for _, r := range session.Rebinds {
+		if !r.Manual {
+			n++
+		}
+	}
+	return n
+}
+
+// snapshot 返回牌局的副本
+func (session *Session) snapshot() Session {
+	out := *session
+	out.Rebinds = append([]Rebinding(nil), session.Rebinds...)
+	return out
+}
@@ -0,0 +1,69 @@
+package drandshuffle
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+)
+
+const (
+	// DefaultSessionIDPrefix 是 NewSessionID 使用的前綴
+	DefaultSessionIDPrefix = "game_"
+	// MaxSessionIDLength 是遊戲局號的最大長度（字節）
+	MaxSessionIDLength = 128
+
+	// sessionIDRandomBytes 是遊戲局號中隨機部分的字節數，128 位足以避免碰撞
+	sessionIDRandomBytes = 16
+)
+
+// NewSessionID 生成以 "game_" 為前綴的遊戲局號，隨機部分為 128 位的加密安全隨機數
+func NewSessionID() (string, error) {
+	return NewSessionIDWithPrefix(DefaultSessionIDPrefix)
+}
+
+// NewSessionIDWithPrefix 生成以 prefix 為前綴的遊戲局號，前綴可以為空
+// 前綴只能包含 ValidateSessionID 允許的字符，且加上隨機部分後不能超過 MaxSessionIDLength
+func NewSessionIDWithPrefix(prefix string) (string, error) {
+	if len(prefix)+2*sessionIDRandomBytes > MaxSessionIDLength {
+		return "", NewInputError(fmt.Errorf("%w: 前綴過長", ErrInvalidSessionID))
+	}
+	if i := invalidSessionIDChar(prefix); i >= 0 {
+		return "", NewInputError(fmt.Errorf("%w: 前綴第 %d 個字節 %q 不是允許的字符", ErrInvalidSessionID, i+1, prefix[i]))
+	}
+
+	b := make([]byte, sessionIDRandomBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("無法生成隨機數: %w", err)
+	}
+	return prefix + hex.EncodeToString(b), nil
+}
+
+// ValidateSessionID 檢查調用者提供的遊戲局號
+// 遊戲局號不能為空、不能超過 MaxSessionIDLength 字節，且只能包含 ASCII 字母、數字和 "_-.:"，
+// 避免不可見字符或不同編碼的相似字符讓看起來相同的局號洗出不同的牌
+func ValidateSessionID(id string) error {
+	if id == "" {
+		return NewInputError(fmt.Errorf("%w: 遊戲局號為空", ErrInvalidSessionID))
+	}
+	if len(id) > MaxSessionIDLength {
+		return NewInputError(fmt.Errorf("%w: 長度 %d 超過上限 %d", ErrInvalidSessionID, len(id), MaxSessionIDLength))
+	}
+	if i := invalidSessionIDChar(id); i >= 0 {
+		return NewInputError(fmt.Errorf("%w: 第 %d 個字節 %q 不是允許的字符", ErrInvalidSessionID, i+1, id[i]))
+	}
+	return nil
+}
+
+// invalidSessionIDChar 返回第一個不允許的字節的位置，全部允許時返回 -1
+func invalidSessionIDChar(s string) int {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case c == '_', c == '-', c == '.', c == ':':
+		default:
+			return i
+		}
+	}
+	return -1
+}
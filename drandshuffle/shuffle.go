@@ -1,10 +1,12 @@
 package drandshuffle
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/binary"
+	"encoding/hex"
 	"fmt"
-	"log"
+	"log/slog"
 	"strings"
 )
 
@@ -16,18 +18,7 @@ type Card struct {
 
 // InitializeDeck 初始化標準52張撲克牌
 func InitializeDeck() []Card {
-	suits := []string{"黑桃", "紅心", "方塊", "梅花"}
-	values := []string{"A", "2", "3", "4", "5", "6", "7", "8", "9", "10", "J", "Q", "K"}
-
-	deck := make([]Card, 0, len(suits)*len(values))
-
-	for _, suit := range suits {
-		for _, value := range values {
-			deck = append(deck, Card{Suit: suit, Value: value})
-		}
-	}
-
-	return deck
+	return StandardDeckTemplate.NewDeck()
 }
 
 // ShuffleDeck 使用Fisher-Yates算法洗牌
@@ -35,6 +26,13 @@ func ShuffleDeck(deck []Card, randomness []byte) []Card {
 	shuffled := make([]Card, len(deck))
 	copy(shuffled, deck)
 
+	shuffleInPlace(shuffled, randomness)
+
+	return shuffled
+}
+
+// shuffleInPlace 使用Fisher-Yates算法原地洗牌
+func shuffleInPlace(shuffled []Card, randomness []byte) {
 	// 確保有足夠的隨機字節
 	if len(randomness) < 8 {
 		// 擴展隨機性
@@ -50,8 +48,6 @@ func ShuffleDeck(deck []Card, randomness []byte) []Card {
 		j := int(binary.BigEndian.Uint64(randomness[pos:pos+8]) % uint64(i+1))
 		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
 	}
-
-	return shuffled
 }
 
 // 輔助函數
@@ -63,113 +59,209 @@ func max(a, b int) int {
 }
 
 // GetShuffledDeck 返回使用最新drand隨機信標洗牌後的牌組
-// gameSessionID 參數用於確保不同遊戲局次有不同的洗牌結果
+// gameSessionID 參數用於確保不同遊戲局次有不同的洗牌結果，須通過 ValidateSessionID 的檢查
 // 返回洗好的牌組和使用的輪次號碼
+//
+// Deprecated: 使用 NewClient 創建 Client 並調用 ShuffleLatest，依賴關係更明確且便於測試
 func GetShuffledDeck(gameSessionID string) ([]Card, uint64, error) {
-	// 獲取 DrandManager 實例
-	drandManager, err := GetDrandManager()
+	c, err := DefaultClient()
 	if err != nil {
-		return nil, 0, fmt.Errorf("無法初始化 DrandManager: %v", err)
+		return nil, 0, err
 	}
 
-	// 獲取最新的隨機性和輪次號碼
-	randomness, round, err := drandManager.GetLatestRandomness()
+	return c.ShuffleLatest(context.Background(), gameSessionID)
+}
+
+// GetShuffledDeckByRound 返回使用指定輪次drand隨機信標洗牌後的牌組
+// gameSessionID 參數用於確保不同遊戲局次有不同的洗牌結果，須通過 ValidateSessionID 的檢查
+//
+// Deprecated: 使用 NewClient 創建 Client 並調用 ShuffleAtRound，依賴關係更明確且便於測試
+func GetShuffledDeckByRound(round uint64, gameSessionID string) ([]Card, error) {
+	c, err := DefaultClient()
 	if err != nil {
-		return nil, 0, fmt.Errorf("無法獲取最新隨機性: %v", err)
+		return nil, err
 	}
 
-	// 創建足夠的隨機性
-	hasher := sha256.New()
-	hasher.Write(randomness)
-	// 加入遊戲局號以確保不同局次有不同的洗牌結果
-	hasher.Write([]byte(gameSessionID))
-	extendedRandomness := hasher.Sum(randomness)
-
-	// 初始化並洗牌
-	deck := InitializeDeck()
-	shuffledDeck := ShuffleDeck(deck, extendedRandomness)
+	return c.ShuffleAtRound(context.Background(), round, gameSessionID)
+}
 
-	return shuffledDeck, round, nil
+// ShuffledDeck 返回使用最新drand隨機信標洗牌後的牌組和使用的輪次號碼
+// 嚴格輪次模式下返回 ErrExplicitRoundRequired，見 WithStrictRounds
+func (dm *DrandManager) ShuffledDeck(gameSessionID string) ([]Card, uint64, error) {
+	return dm.shuffledDeck(context.Background(), gameSessionID)
 }
 
-// GetShuffledDeckByRound 返回使用指定輪次drand隨機信標洗牌後的牌組
-// gameSessionID 參數用於確保不同遊戲局次有不同的洗牌結果
-func GetShuffledDeckByRound(round uint64, gameSessionID string) ([]Card, error) {
-	// 獲取 DrandManager 實例
-	drandManager, err := GetDrandManager()
+// shuffledDeck 返回使用最新drand隨機信標洗牌後的牌組和使用的輪次號碼，ctx 只用於追蹤
+func (dm *DrandManager) shuffledDeck(ctx context.Context, gameSessionID string) ([]Card, uint64, error) {
+	if err := dm.checkLatestAllowed(); err != nil {
+		return nil, 0, err
+	}
+
+	// 獲取最新的隨機性和輪次號碼
+	randomness, round, err := dm.GetLatestRandomness()
 	if err != nil {
-		return nil, fmt.Errorf("無法初始化 DrandManager: %v", err)
+		return nil, 0, fmt.Errorf("無法獲取最新隨機性: %w", err)
+	}
+	if err := dm.chargeUsage(ctx, round); err != nil {
+		Zeroize(randomness)
+		return nil, 0, err
+	}
+
+	deck := dm.shuffleWithCache(ctx, round, randomness, gameSessionID)
+	dm.observeDealLatency(round)
+	return deck, round, nil
+}
+
+// ShuffledDeckByRound 返回使用指定輪次drand隨機信標洗牌後的牌組
+func (dm *DrandManager) ShuffledDeckByRound(round uint64, gameSessionID string) ([]Card, error) {
+	return dm.shuffledDeckByRound(context.Background(), round, gameSessionID)
+}
+
+// shuffledDeckByRound 返回使用指定輪次drand隨機信標洗牌後的牌組，ctx 取消時不再等待網絡請求
+func (dm *DrandManager) shuffledDeckByRound(ctx context.Context, round uint64, gameSessionID string) ([]Card, error) {
+	key := standardShuffleKey(round, gameSessionID)
+	if dm.shuffleCache != nil {
+		if deck, ok := dm.lookupShuffleCache(ctx, key); ok {
+			if err := dm.chargeUsage(ctx, round); err != nil {
+				return nil, err
+			}
+			return deck, nil
+		}
 	}
 
 	// 獲取指定輪次的隨機性
-	randomness, err := drandManager.GetRandomnessByRound(round)
+	beacon, err := dm.beaconByRound(ctx, round)
 	if err != nil {
-		return nil, fmt.Errorf("無法獲取輪次 %d 的隨機性: %v", round, err)
+		return nil, fmt.Errorf("無法獲取輪次 %d 的隨機性: %w", round, err)
+	}
+	if err := dm.chargeUsage(ctx, round); err != nil {
+		return nil, err
+	}
+	randomness := copyBytes(beacon.GetRandomness())
+
+	return dm.shuffleWithCache(ctx, round, randomness, gameSessionID), nil
+}
+
+// shuffleWithCache 結合遊戲局號洗牌並更新洗牌結果緩存，使用後清除隨機性副本
+func (dm *DrandManager) shuffleWithCache(ctx context.Context, round uint64, randomness []byte, gameSessionID string) []Card {
+	defer Zeroize(randomness)
+
+	if dm.shuffleCache == nil {
+		return dm.shuffle(ctx, defaultShuffler, round, randomness, gameSessionID)
 	}
 
-	// 創建足夠的隨機性
-	hasher := sha256.New()
-	hasher.Write(randomness)
-	// 加入遊戲局號以確保不同局次有不同的洗牌結果
-	hasher.Write([]byte(gameSessionID))
-	extendedRandomness := hasher.Sum(randomness)
+	key := standardShuffleKey(round, gameSessionID)
+	if deck, ok := dm.lookupShuffleCache(ctx, key); ok {
+		return deck
+	}
 
-	// 初始化並洗牌
-	deck := InitializeDeck()
-	shuffledDeck := ShuffleDeck(deck, extendedRandomness)
+	deck := dm.shuffle(ctx, defaultShuffler, round, randomness, gameSessionID)
+	dm.shuffleCache.Put(key, deck)
+	return deck
+}
 
-	return shuffledDeck, nil
+// String 返回牌的字符串表示，實現 fmt.Stringer
+func (c Card) String() string {
+	return CardToString(c)
 }
 
 // CardToString 將牌轉換為字符串表示
+// 標準牌組中的牌使用預先構建的字符串，不分配記憶體
 func CardToString(card Card) string {
+	if str, ok := cardStrings[card]; ok {
+		return str
+	}
 	return card.Suit + card.Value
 }
 
+// AppendString 將牌的字符串表示追加到 dst 並返回，適用於高頻率的日誌輸出
+func AppendString(dst []byte, card Card) []byte {
+	dst = append(dst, card.Suit...)
+	return append(dst, card.Value...)
+}
+
 // StringToCard 將字符串表示轉換為牌
+// 標準牌組中的牌通過預先構建的查找表解析，不分配記憶體
 func StringToCard(s string) (Card, error) {
+	if card, ok := stringCards[s]; ok {
+		return card, nil
+	}
+
 	// 檢查空字符串或太短的字符串
 	if len(s) < 3 {
-		return Card{}, fmt.Errorf("無效的牌字符串")
+		return Card{}, NewInputError(fmt.Errorf("%w: 無效的牌字符串", ErrInvalidCard))
 	}
 
-	// 驗證花色
-	validSuits := []string{"黑桃", "紅心", "方塊", "梅花"}
-	var suit string
-	var value string
-	var found bool
-
 	// 嘗試匹配花色
-	for _, validSuit := range validSuits {
+	for _, validSuit := range StandardDeckSpec.Suits {
 		if strings.HasPrefix(s, validSuit) {
-			suit = validSuit
-			value = s[len(validSuit):]
-			found = true
-			break
+			// 花色有效但查找表中沒有該牌，說明點數無效
+			return Card{}, NewInputError(fmt.Errorf("%w: 無效的點數", ErrInvalidCard))
 		}
 	}
 
 	// 如果沒有找到有效的花色
-	if !found {
-		return Card{}, fmt.Errorf("無效的花色")
+	return Card{}, NewInputError(fmt.Errorf("%w: 無效的花色", ErrInvalidCard))
+}
+
+// deckSeparator 是牌組編碼中分隔各張牌的字符
+const deckSeparator = ","
+
+// EncodeDeck 將牌組編碼為以逗號分隔的字符串，例如 "黑桃A,紅心10"，可用 DecodeDeck 還原
+func EncodeDeck(deck []Card) string {
+	var b strings.Builder
+	for i, card := range deck {
+		if i > 0 {
+			b.WriteString(deckSeparator)
+		}
+		b.WriteString(CardToString(card))
 	}
+	return b.String()
+}
 
-	// 驗證點數
-	validValues := map[string]bool{
-		"A": true, "2": true, "3": true, "4": true, "5": true,
-		"6": true, "7": true, "8": true, "9": true, "10": true,
-		"J": true, "Q": true, "K": true,
+// DecodeDeck 解析 EncodeDeck 產生的字符串，各張牌前後的空白會被忽略
+// 空字符串解析為空牌組；任何一張牌無效時返回包裝 ErrInvalidCard 的輸入錯誤
+func DecodeDeck(s string) ([]Card, error) {
+	if strings.TrimSpace(s) == "" {
+		return []Card{}, nil
 	}
-	if !validValues[value] {
-		return Card{}, fmt.Errorf("無效的點數")
+
+	parts := strings.Split(s, deckSeparator)
+	deck := make([]Card, 0, len(parts))
+	for i, part := range parts {
+		card, err := StringToCard(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("無法解析第 %d 張牌: %w", i+1, err)
+		}
+		deck = append(deck, card)
 	}
+	return deck, nil
+}
 
-	return Card{Suit: suit, Value: value}, nil
+// DeckDigest 返回牌組 EncodeDeck 編碼的 SHA-256 摘要（十六進制），審計記錄中可以用它代表整副牌組
+func DeckDigest(deck []Card) string {
+	sum := sha256.Sum256([]byte(EncodeDeck(deck)))
+	return hex.EncodeToString(sum[:])
 }
 
-// LogDeck 打印牌組（用於調試）
-func LogDeck(deck []Card) {
-	for i, card := range deck {
-		log.Printf("%d: %s%s\n", i+1, card.Suit, card.Value)
+// cardStrings 和 stringCards 是標準牌組的字符串查找表
+var cardStrings, stringCards = buildCardTables(StandardDeckTemplate)
+
+// buildCardTables 構建牌與字符串之間的雙向查找表
+func buildCardTables(template *DeckTemplate) (map[Card]string, map[string]Card) {
+	toString := make(map[Card]string, template.Len())
+	toCard := make(map[string]Card, template.Len())
+	for _, card := range template.cards {
+		str := card.Suit + card.Value
+		toString[card] = str
+		toCard[str] = card
 	}
+	return toString, toCard
+}
+
+// LogDeck 以 Info 級別向 slog 的默認記錄器記錄牌組（用於調試）
+//
+// Deprecated: 使用 EncodeDeck 取得牌組的文本，再以自己的記錄器記錄
+func LogDeck(deck []Card) {
+	slog.Info("牌組", slog.Int("cards", len(deck)), slog.String("deck", EncodeDeck(deck)))
 }
@@ -0,0 +1,94 @@
+package drandshuffle
+
+import (
+	"container/list"
+	"sync"
+)
+
+// shuffleAlgorithm 是目前洗牌算法的標識，作為緩存鍵的一部分，
+// 以免算法變更後返回舊算法的結果
+const shuffleAlgorithm = "fisher-yates-sha256-v1"
+
+// ShuffleKey 唯一標識一次洗牌的結果
+type ShuffleKey struct {
+	Round     uint64        // 使用的輪次號碼
+	SessionID string        // 遊戲局號
+	Deck      *DeckTemplate // 使用的牌組模板
+	Algorithm string        // 洗牌算法標識
+}
+
+// standardShuffleKey 返回標準牌組洗牌結果的緩存鍵
+func standardShuffleKey(round uint64, sessionID string) ShuffleKey {
+	return ShuffleKey{Round: round, SessionID: sessionID, Deck: StandardDeckTemplate, Algorithm: shuffleAlgorithm}
+}
+
+// ShuffleCache 是有容量上限的洗牌結果緩存，按最近使用順序淘汰
+// 適用於同一局遊戲反覆請求牌組的場景（如斷線重連、驗證頁面），可以被多個 goroutine 並發使用
+type ShuffleCache struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[ShuffleKey]*list.Element
+	order    *list.List // 最近使用的在前
+}
+
+// shuffleCacheEntry 是緩存中的一個項目
+type shuffleCacheEntry struct {
+	key  ShuffleKey
+	deck []Card
+}
+
+// NewShuffleCache 創建最多保存 size 個洗牌結果的緩存
+func NewShuffleCache(size int) *ShuffleCache {
+	if size < 1 {
+		size = 1
+	}
+	return &ShuffleCache{
+		capacity: size,
+		items:    make(map[ShuffleKey]*list.Element, size),
+		order:    list.New(),
+	}
+}
+
+// Get 獲取緩存的洗牌結果，返回的牌組是副本，歸調用者所有
+func (c *ShuffleCache) Get(key ShuffleKey) ([]Card, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+
+	deck := elem.Value.(*shuffleCacheEntry).deck
+	return append([]Card(nil), deck...), true
+}
+
+// Put 保存洗牌結果，緩存會保存牌組的副本
+func (c *ShuffleCache) Put(key ShuffleKey, deck []Card) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	deck = append([]Card(nil), deck...)
+	if elem, ok := c.items[key]; ok {
+		elem.Value.(*shuffleCacheEntry).deck = deck
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&shuffleCacheEntry{key: key, deck: deck})
+
+	// 超過容量時淘汰最久未使用的項目
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*shuffleCacheEntry).key)
+	}
+}
+
+// Len 返回緩存中的項目數量
+func (c *ShuffleCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
@@ -0,0 +1,114 @@
+package drandshuffle
+
+import (
+	"context"
+	"crypto/sha256"
+	"hash"
+	"runtime/trace"
+	"sync"
+)
+
+// Shuffler 是可重複使用的洗牌器，適用於高吞吐量的服務
+// Shuffler 可以被多個 goroutine 並發使用：每次洗牌從內部池中取得獨立的雜湊狀態和緩衝區，
+// 不同 goroutine 之間不共享狀態，也不會在每次調用時重新分配
+type Shuffler struct {
+	template *DeckTemplate
+	states   sync.Pool
+}
+
+// shuffleState 保存單次洗牌所需的雜湊狀態和擴展隨機性緩衝區
+type shuffleState struct {
+	hasher hash.Hash
+	seed   []byte
+}
+
+// defaultShuffler 使用標準52張撲克牌的洗牌器
+var defaultShuffler = NewShuffler(StandardDeckTemplate)
+
+// NewShuffler 創建使用指定牌組模板的洗牌器，template 為 nil 時使用標準52張撲克牌
+func NewShuffler(template *DeckTemplate) *Shuffler {
+	if template == nil {
+		template = StandardDeckTemplate
+	}
+	s := &Shuffler{template: template}
+	s.states.New = func() any {
+		return &shuffleState{hasher: sha256.New()}
+	}
+	return s
+}
+
+// Shuffle 結合 drand 隨機性和遊戲局號洗牌，返回新分配的牌組
+// 使用標準牌組模板時，結果與 GetShuffledDeck 和 GetShuffledDeckByRound 相同
+func (s *Shuffler) Shuffle(randomness []byte, gameSessionID string) []Card {
+	return s.ShuffleInto(nil, randomness, gameSessionID)
+}
+
+// ShuffleInto 結合 drand 隨機性和遊戲局號洗牌，將結果寫入 dst 並返回
+// dst 容量足夠時不會分配記憶體
+func (s *Shuffler) ShuffleInto(dst []Card, randomness []byte, gameSessionID string) []Card {
+	return s.shuffleInto(dst, func(state *shuffleState) []byte {
+		return state.extend(randomness, gameSessionID)
+	}, nil)
+}
+
+// shuffleInto 使用 derive 從池中的狀態派生洗牌用的隨機性，並將洗牌結果寫入 dst
+// permute 為 nil 時直接使用 shuffleInPlace，否則由 permute 完成置換，用於記錄追蹤 span
+func (s *Shuffler) shuffleInto(dst []Card, derive func(state *shuffleState) []byte, permute func(deck []Card, seed []byte)) []Card {
+	if trace.IsEnabled() {
+		defer trace.StartRegion(context.Background(), "drandshuffle.shuffle").End()
+	}
+
+	n := s.template.Len()
+	if cap(dst) < n {
+		dst = make([]Card, n)
+	}
+	dst = dst[:n]
+	copy(dst, s.template.cards)
+
+	state := s.states.Get().(*shuffleState)
+	if permute == nil {
+		shuffleInPlace(dst, derive(state))
+	} else {
+		permute(dst, derive(state))
+	}
+	// 洗牌完成後立即清除派生的種子，避免其殘留在池中的緩衝區
+	state.wipe()
+	s.states.Put(state)
+
+	return dst
+}
+
+// extend 結合 drand 隨機性和遊戲局號創建洗牌用的隨機性
+// 結果為 randomness || SHA256(randomness || gameSessionID)，寫入狀態自身的緩衝區，不修改 randomness
+func (st *shuffleState) extend(randomness []byte, gameSessionID string) []byte {
+	st.hasher.Reset()
+	st.hasher.Write(randomness)
+	// 加入遊戲局號以確保不同局次有不同的洗牌結果
+	st.hasher.Write([]byte(gameSessionID))
+
+	st.seed = append(st.seed[:0], randomness...)
+	st.seed = st.hasher.Sum(st.seed)
+	return st.seed
+}
+
+// wipe 清除狀態中殘留的種子材料
+func (st *shuffleState) wipe() {
+	Zeroize(st.seed)
+	st.hasher.Reset()
+}
+
+// Zeroize 將切片內容全部覆寫為零
+// 可用於在使用完畢後清除從 DrandManager 取得的隨機性或其他種子材料
+func Zeroize(b []byte) {
+	clear(b)
+}
+
+// copyBytes 返回切片的獨立副本
+func copyBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	c := make([]byte, len(b))
+	copy(c, b)
+	return c
+}
@@ -0,0 +1,334 @@
+// Package simulate 以歷史輪次重放洗牌，統計每張牌出現在每個位置的頻率，作為洗牌均勻性的證據交給認證實驗室
+//
+// Run 對 [from, to] 範圍內的每個輪次，以合成的遊戲局號 "<前綴>_0"、"<前綴>_1"… 各洗牌一次，
+// 洗牌與生產環境相同，經過 drandshuffle.Client.NewShuffle。統計結果以卡方檢驗衡量：
+// 每張牌在各位置的分佈（自由度 n-1）和整個牌×位置頻率表（自由度 (n-1)²），p 值過小表示分佈偏離均勻。
+// 相同的輪次範圍和參數總是得到相同的報告。
+//
+// 作為均勻性證據時應保持默認的每輪一局，以增加輪次數量擴大樣本：同一輪的各局共用隨機信標，
+// 而洗牌的 Fisher-Yates 在較小的索引（標準牌組為 1 到 24）只讀取隨機信標本身的字節，不受遊戲局號影響，
+// 同一輪的多局因此並不獨立，卡方統計量會明顯偏大。每輪多局的報告適合用來觀察同一輪各局之間的相關性。
+package simulate
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+)
+
+// DefaultSessions 是每個輪次默認的合成遊戲局數量，原因見包的說明
+const DefaultSessions = 1
+
+// DefaultSessionPrefix 是合成遊戲局號的默認前綴
+const DefaultSessionPrefix = "sim"
+
+// options 是 Run 的參數
+type options struct {
+	deck     *drandshuffle.DeckTemplate
+	sessions int
+	prefix   string
+}
+
+// Option 設定 Run 的參數
+type Option func(*options)
+
+// WithDeck 設定洗牌使用的牌組模板，默認為 drandshuffle.Poker52；模板中不能有重複的牌
+func WithDeck(template *drandshuffle.DeckTemplate) Option {
+	return func(o *options) {
+		o.deck = template
+	}
+}
+
+// WithSessions 設定每個輪次的合成遊戲局數量，默認為 DefaultSessions；大於 1 時各局並不獨立，見包的說明
+func WithSessions(n int) Option {
+	return func(o *options) {
+		o.sessions = n
+	}
+}
+
+// WithSessionPrefix 設定合成遊戲局號的前綴，默認為 DefaultSessionPrefix
+func WithSessionPrefix(prefix string) Option {
+	return func(o *options) {
+		o.prefix = prefix
+	}
+}
+
+// CardStat 是一張牌在各位置分佈的卡方檢驗結果
+type CardStat struct {
+	Card      string  `json:"card"`       // drandshuffle.CardToString 的寫法
+	ChiSquare float64 `json:"chi_square"` // 卡方統計量，自由度為牌數減 1
+	PValue    float64 `json:"p_value"`
+	MinCount  int     `json:"min_count"` // 出現次數最少的位置的次數
+	MaxCount  int     `json:"max_count"` // 出現次數最多的位置的次數
+}
+
+// Report 是模擬的統計報告
+type Report struct {
+	Algorithm        string     `json:"algorithm"`  // drandshuffle.ProofAlgorithm
+	ChainHash        string     `json:"chain_hash"` // 隨機信標所屬的鏈
+	From             uint64     `json:"from"`
+	To               uint64     `json:"to"`
+	Rounds           int        `json:"rounds"`
+	SessionsPerRound int        `json:"sessions_per_round"`
+	SessionPrefix    string     `json:"session_prefix"`
+	Shuffles         int        `json:"shuffles"` // 洗牌的總次數
+	Cards            []string   `json:"cards"`    // 按模板順序排列的牌
+	Counts           [][]int    `json:"counts"`   // Counts[牌][位置] 是該牌出現在該位置的次數
+	Expected         float64    `json:"expected"` // 均勻分佈時每個格子的期望次數
+	ChiSquare        float64    `json:"chi_square"`
+	DegreesOfFreedom int        `json:"degrees_of_freedom"`
+	PValue           float64    `json:"p_value"`
+	CardStats        []CardStat `json:"card_stats"`
+}
+
+// Run 重放 [from, to] 範圍內的歷史輪次並統計每張牌的位置頻率
+// 開始前以 DrandManager.Prefetch 並發獲取整個範圍，任一輪次獲取失敗時返回錯誤；ctx 取消時停止並返回 ctx 的錯誤
+func Run(ctx context.Context, c *drandshuffle.Client, from, to uint64, opts ...Option) (*Report, error) {
+	o := options{deck: drandshuffle.Poker52, sessions: DefaultSessions, prefix: DefaultSessionPrefix}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if from == 0 || to < from {
+		return nil, drandshuffle.NewInputError(fmt.Errorf("%w: 無效的輪次範圍 %d-%d", drandshuffle.ErrInvalidConfig, from, to))
+	}
+	if o.sessions < 1 {
+		return nil, drandshuffle.NewInputError(fmt.Errorf("%w: 每個輪次至少需要一局", drandshuffle.ErrInvalidConfig))
+	}
+	tally, err := NewTally(o.deck)
+	if err != nil {
+		return nil, err
+	}
+	sessionIDs := make([]string, o.sessions)
+	for i := range sessionIDs {
+		sessionIDs[i] = fmt.Sprintf("%s_%d", o.prefix, i)
+		if err := drandshuffle.ValidateSessionID(sessionIDs[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	for p := range c.Manager().Prefetch(ctx, from, to) {
+		if p.Err != nil {
+			return nil, fmt.Errorf("無法獲取輪次 %d: %w", p.Round, p.Err)
+		}
+	}
+
+	for round := from; round <= to; round++ {
+		for _, id := range sessionIDs {
+			result, err := c.NewShuffle().Deck(o.deck).Session(id).Round(round).Do(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("無法以輪次 %d 和遊戲局號 %s 洗牌: %w", round, id, err)
+			}
+			if err := tally.Add(result.Deck); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	u := tally.Uniformity()
+	return &Report{
+		Algorithm:        drandshuffle.ProofAlgorithm,
+		ChainHash:        c.Manager().Config().ChainHash,
+		From:             from,
+		To:               to,
+		Rounds:           int(to - from + 1),
+		SessionsPerRound: o.sessions,
+		SessionPrefix:    o.prefix,
+		Shuffles:         u.Shuffles,
+		Cards:            u.Cards,
+		Counts:           u.Counts,
+		Expected:         u.Expected,
+		ChiSquare:        u.ChiSquare,
+		DegreesOfFreedom: u.DegreesOfFreedom,
+		PValue:           u.PValue,
+		CardStats:        u.CardStats,
+	}, nil
+}
+
+// WriteCSV 將頻率表寫為帶標題行的 CSV：每張牌一行，依次為牌、各位置的次數、卡方統計量和 p 值
+func (r *Report) WriteCSV(w io.Writer) error {
+	u := Uniformity{Cards: r.Cards, Counts: r.Counts, CardStats: r.CardStats}
+	return u.WriteCSV(w)
+}
+
+// Uniformity 是牌×位置頻率表及其卡方檢驗，由 Tally 產生
+type Uniformity struct {
+	Shuffles         int        `json:"shuffles"` // 統計的牌組數量
+	Cards            []string   `json:"cards"`    // 按模板順序排列的牌，drandshuffle.CardToString 的寫法
+	Counts           [][]int    `json:"counts"`   // Counts[牌][位置] 是該牌出現在該位置的次數
+	Expected         float64    `json:"expected"` // 均勻分佈時每個格子的期望次數
+	ChiSquare        float64    `json:"chi_square"`
+	DegreesOfFreedom int        `json:"degrees_of_freedom"`
+	PValue           float64    `json:"p_value"`
+	CardStats        []CardStat `json:"card_stats"`
+}
+
+// Tally 累計牌組中每張牌出現在每個位置的次數，可以統計生產環境實際發出的牌組，不必重放輪次
+// Tally 不是並發安全的
+type Tally struct {
+	cards  []string
+	index  map[drandshuffle.Card]int
+	counts [][]int
+	total  int
+}
+
+// NewTally 創建統計 template 的牌組的 Tally，template 為 nil 時使用 drandshuffle.Poker52；
+// 模板至少需要兩張牌且不能有重複的牌，否則返回包裝 ErrInvalidConfig 的錯誤
+func NewTally(template *drandshuffle.DeckTemplate) (*Tally, error) {
+	if template == nil {
+		template = drandshuffle.Poker52
+	}
+	cards := template.NewDeck()
+	if len(cards) < 2 {
+		return nil, drandshuffle.NewInputError(fmt.Errorf("%w: 牌組至少需要兩張牌", drandshuffle.ErrInvalidConfig))
+	}
+	t := &Tally{index: make(map[drandshuffle.Card]int, len(cards)), counts: make([][]int, len(cards))}
+	for i, card := range cards {
+		if _, ok := t.index[card]; ok {
+			return nil, drandshuffle.NewInputError(fmt.Errorf("%w: 牌組中 %s 重複，無法區分位置", drandshuffle.ErrInvalidConfig, drandshuffle.CardToString(card)))
+		}
+		t.index[card] = i
+		t.cards = append(t.cards, drandshuffle.CardToString(card))
+		t.counts[i] = make([]int, len(cards))
+	}
+	return t, nil
+}
+
+// Add 統計一副牌組，牌組不是模板的排列時返回包裝 ErrDeckMismatch 的錯誤，不計入統計
+func (t *Tally) Add(deck []drandshuffle.Card) error {
+	if len(deck) != len(t.cards) {
+		return drandshuffle.NewInputError(fmt.Errorf("%w: 牌組有 %d 張牌，模板為 %d 張", drandshuffle.ErrDeckMismatch, len(deck), len(t.cards)))
+	}
+	seen := make([]bool, len(t.cards))
+	for _, card := range deck {
+		i, ok := t.index[card]
+		if !ok || seen[i] {
+			return drandshuffle.NewInputError(fmt.Errorf("%w: 牌組不是模板的排列，%s 不在模板中或重複", drandshuffle.ErrDeckMismatch, drandshuffle.CardToString(card)))
+		}
+		seen[i] = true
+	}
+	for pos, card := range deck {
+		t.counts[t.index[card]][pos]++
+	}
+	t.total++
+	return nil
+}
+
+// Uniformity 返回目前的頻率表和卡方檢驗，沒有統計任何牌組時統計量為 0、p 值為 1
+func (t *Tally) Uniformity() *Uniformity {
+	n := len(t.cards)
+	u := &Uniformity{
+		Shuffles:         t.total,
+		Cards:            append([]string(nil), t.cards...),
+		Counts:           make([][]int, n),
+		Expected:         float64(t.total) / float64(n),
+		DegreesOfFreedom: (n - 1) * (n - 1),
+	}
+	for i, row := range t.counts {
+		u.Counts[i] = append([]int(nil), row...)
+		stat := CardStat{Card: t.cards[i], MinCount: row[0], MaxCount: row[0]}
+		for _, count := range row {
+			if u.Expected > 0 {
+				d := float64(count) - u.Expected
+				stat.ChiSquare += d * d / u.Expected
+			}
+			stat.MinCount = min(stat.MinCount, count)
+			stat.MaxCount = max(stat.MaxCount, count)
+		}
+		stat.PValue = chiSquarePValue(stat.ChiSquare, n-1)
+		u.ChiSquare += stat.ChiSquare
+		u.CardStats = append(u.CardStats, stat)
+	}
+	u.PValue = chiSquarePValue(u.ChiSquare, u.DegreesOfFreedom)
+	return u
+}
+
+// WriteCSV 將頻率表寫為帶標題行的 CSV：每張牌一行，依次為牌、各位置的次數、卡方統計量和 p 值
+func (u *Uniformity) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	header := []string{"card"}
+	for pos := range u.Cards {
+		header = append(header, "pos_"+strconv.Itoa(pos))
+	}
+	header = append(header, "chi_square", "p_value")
+	if err := cw.Write(header); err != nil {
+		return fmt.Errorf("無法寫入 CSV: %w", err)
+	}
+	for i, row := range u.Counts {
+		record := []string{u.Cards[i]}
+		for _, count := range row {
+			record = append(record, strconv.Itoa(count))
+		}
+		record = append(record,
+			strconv.FormatFloat(u.CardStats[i].ChiSquare, 'f', 4, 64),
+			strconv.FormatFloat(u.CardStats[i].PValue, 'f', 6, 64))
+		if err := cw.Write(record); err != nil {
+			return fmt.Errorf("無法寫入 CSV: %w", err)
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("無法寫入 CSV: %w", err)
+	}
+	return nil
+}
+
+// chiSquarePValue 返回自由度為 df 的卡方分佈大於 x 的機率，即正則化上不完全伽瑪函數 Q(df/2, x/2)
+func chiSquarePValue(x float64, df int) float64 {
+	if x <= 0 {
+		return 1
+	}
+	return gammaQ(float64(df)/2, x/2)
+}
+
+// gammaQ 計算正則化上不完全伽瑪函數 Q(a, x)
+// x < a+1 時用級數求 P(a, x) 再取 1-P，否則用連分數（Lentz 算法），兩者在各自的範圍內收斂較快
+func gammaQ(a, x float64) float64 {
+	const (
+		maxIter = 1000
+		eps     = 1e-14
+		tiny    = 1e-300
+	)
+	lg, _ := math.Lgamma(a)
+	prefix := math.Exp(-x + a*math.Log(x) - lg)
+
+	if x < a+1 {
+		sum, term := 1/a, 1/a
+		for n := 1; n < maxIter; n++ {
+			term *= x / (a + float64(n))
+			sum += term
+			if math.Abs(term) < math.Abs(sum)*eps {
+				break
+			}
+		}
+		return max(0, 1-sum*prefix)
+	}
+
+	b := x + 1 - a
+	c := 1 / tiny
+	d := 1 / b
+	h := d
+	for n := 1; n < maxIter; n++ {
+		an := -float64(n) * (float64(n) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < eps {
+			break
+		}
+	}
+	return prefix * h
+}
@@ -0,0 +1,641 @@
+package sqlstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+)
+
+// GraphQLSchema 是 NewGraphQLHandler 提供的 GraphQL 模式
+// 接口不支持內省查詢，需要模式的工具（如代碼生成或編輯器的自動完成）應直接載入此定義
+const GraphQLSchema = `enum Verification {
+  UNVERIFIED
+  VERIFIED
+  FAILED
+}
+
+type Query {
+  "按輪次和遊戲局號排序的牌局，after 為上一頁的 nextCursor"
+  sessions(minRound: Int, maxRound: Int, tenant: String, verification: Verification, first: Int, after: String): SessionConnection!
+  session(id: String!): Session
+  "按輪次排序的隨機信標，first 默認為 100，最多 1000"
+  beacons(minRound: Int, maxRound: Int, first: Int): [Beacon!]!
+  beacon(round: Int!): Beacon
+}
+
+type SessionConnection {
+  nodes: [Session!]!
+  "下一頁的游標，沒有更多牌局時為 null"
+  nextCursor: String
+}
+
+type Session {
+  sessionId: String!
+  tenant: String!
+  round: Int!
+  "drandshuffle.EncodeDeck 的編碼"
+  deck: String!
+  verification: Verification!
+  "RFC 3339 格式的 UTC 時間"
+  createdAt: String!
+  proof: Proof
+  "牌局使用的輪次的隨機信標，數據庫中沒有時為 null"
+  beacon: Beacon
+}
+
+type Proof {
+  algorithm: String!
+  provider: String!
+  chainHash: String!
+  round: Int!
+  roundTime: String
+  randomness: String!
+  signature: String!
+  previousSignature: String!
+  sessionId: String!
+  deckSize: Int!
+  digest: String!
+}
+
+type Beacon {
+  round: Int!
+  randomness: String!
+  signature: String!
+  previousSignature: String!
+  "使用此輪次的牌局"
+  sessions(tenant: String, verification: Verification, first: Int, after: String): SessionConnection!
+}
+`
+
+// maxGraphQLBody 是查詢請求體的大小上限
+const maxGraphQLBody = 1 << 20
+
+// maxGraphQLDepth 是選擇集的最大嵌套深度，防止過深的查詢放大數據庫負載
+const maxGraphQLDepth = 8
+
+// 深度限制不能限制扇出：每層 sessions(first: 1000) 都使下一層的查詢次數乘以一千，
+// 因此每個請求另有數據庫查詢次數和解析對象數量的預算，超出時中止並返回錯誤
+const (
+	// maxGraphQLQueries 是一個請求最多執行的數據庫查詢次數，足夠一整頁牌局各自查詢隨機信標
+	maxGraphQLQueries = 2 * MaxPageSize
+	// maxGraphQLObjects 是一個請求最多解析的對象數量
+	maxGraphQLObjects = 20 * MaxPageSize
+)
+
+// graphQLRequest 是 GraphQL over HTTP 的請求
+type graphQLRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// graphQLError 是響應中的一個錯誤
+type graphQLError struct {
+	Message string `json:"message"`
+}
+
+// graphQLResponse 是 GraphQL 的響應
+type graphQLResponse struct {
+	Data   *gqlObject     `json:"data,omitempty"`
+	Errors []graphQLError `json:"errors,omitempty"`
+}
+
+// NewGraphQLHandler 返回只讀的 GraphQL 接口，供後台工具以任意組合查詢牌局、隨機信標、洗牌證明和驗證狀態
+//
+// 模式見 GraphQLSchema。接受 POST 的 JSON 請求（query、variables、operationName）和 GET 的同名查詢參數。
+// 只實現查詢所需的子集：支持變量、別名、嵌套字段和 __typename，不支持片段、指令、內省和變更。
+// 接口不做身份驗證，應掛在內部的管理地址或加上調用者自己的認證中間件
+func NewGraphQLHandler(store *Store) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req graphQLRequest
+		switch r.Method {
+		case http.MethodGet:
+			params := r.URL.Query()
+			req.Query = params.Get("query")
+			req.OperationName = params.Get("operationName")
+			if v := params.Get("variables"); v != "" {
+				if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+					writeGraphQL(w, http.StatusBadRequest, graphQLResponse{Errors: []graphQLError{{"無效的 variables: " + err.Error()}}})
+					return
+				}
+			}
+		case http.MethodPost:
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxGraphQLBody+1))
+			if err != nil || len(body) > maxGraphQLBody {
+				writeGraphQL(w, http.StatusRequestEntityTooLarge, graphQLResponse{Errors: []graphQLError{{"請求過大"}}})
+				return
+			}
+			if err := json.Unmarshal(body, &req); err != nil {
+				writeGraphQL(w, http.StatusBadRequest, graphQLResponse{Errors: []graphQLError{{"無效的請求: " + err.Error()}}})
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, "只接受 GET 和 POST 請求", http.StatusMethodNotAllowed)
+			return
+		}
+
+		op, err := selectOperation(req.Query, req.OperationName)
+		if err != nil {
+			writeGraphQL(w, http.StatusBadRequest, graphQLResponse{Errors: []graphQLError{{err.Error()}}})
+			return
+		}
+		e := &gqlExecutor{ctx: r.Context(), store: store, op: op, vars: req.Variables}
+		data, err := e.object(&gqlField{name: "query", selections: op.selections}, "Query", 0, e.resolveQuery)
+		if err != nil {
+			writeGraphQL(w, http.StatusOK, graphQLResponse{Errors: []graphQLError{{err.Error()}}})
+			return
+		}
+		writeGraphQL(w, http.StatusOK, graphQLResponse{Data: &data})
+	})
+}
+
+// selectOperation 解析查詢並按名稱選擇要執行的操作，只有一個操作時可以不指定名稱
+func selectOperation(query, name string) (*gqlOperation, error) {
+	ops, err := parseGraphQL(query)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		if len(ops) > 1 {
+			return nil, fmt.Errorf("查詢包含多個操作，需要指定 operationName")
+		}
+		return ops[0], nil
+	}
+	for _, op := range ops {
+		if op.name == name {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("沒有名為 %s 的操作", name)
+}
+
+// writeGraphQL 以 JSON 寫出響應
+func writeGraphQL(w http.ResponseWriter, status int, resp graphQLResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// gqlObject 是按選擇集順序排列字段的響應對象
+type gqlObject []gqlEntry
+
+// gqlEntry 是響應對象的一個字段
+type gqlEntry struct {
+	key   string
+	value any
+}
+
+// MarshalJSON 按字段順序輸出 JSON 對象
+func (o gqlObject) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, entry := range o {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(entry.key)
+		b.Write(key)
+		b.WriteByte(':')
+		value, err := json.Marshal(entry.value)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// gqlExecutor 執行一個查詢操作
+type gqlExecutor struct {
+	ctx   context.Context
+	store *Store
+	op    *gqlOperation
+	vars  map[string]any
+
+	queries int // 已執行的數據庫查詢次數
+	objects int // 已解析的對象數量
+}
+
+// query 在執行數據庫查詢前扣除預算，超出 maxGraphQLQueries 時返回錯誤
+func (e *gqlExecutor) query() error {
+	if e.queries++; e.queries > maxGraphQLQueries {
+		return fmt.Errorf("查詢需要超過 %d 次數據庫查詢，請減小 first 或減少嵌套的列表", maxGraphQLQueries)
+	}
+	return nil
+}
+
+// gqlResolver 解析類型的一個字段，depth 是字段所在對象的嵌套深度
+type gqlResolver func(f *gqlField, depth int) (any, error)
+
+// object 以 resolve 解析 f 的選擇集中的每個字段
+func (e *gqlExecutor) object(f *gqlField, typename string, depth int, resolve gqlResolver) (gqlObject, error) {
+	if len(f.selections) == 0 {
+		return nil, fmt.Errorf("字段 %s 的類型 %s 需要選擇集", f.name, typename)
+	}
+	if depth >= maxGraphQLDepth {
+		return nil, fmt.Errorf("查詢的嵌套深度超過 %d", maxGraphQLDepth)
+	}
+	if e.objects++; e.objects > maxGraphQLObjects {
+		return nil, fmt.Errorf("查詢的結果超過 %d 個對象，請減小 first 或減少嵌套的列表", maxGraphQLObjects)
+	}
+	obj := make(gqlObject, 0, len(f.selections))
+	for _, sel := range f.selections {
+		var value any
+		var err error
+		if sel.name == "__typename" {
+			value, err = scalar(sel, typename)
+		} else {
+			value, err = resolve(sel, depth+1)
+		}
+		if err != nil {
+			return nil, err
+		}
+		obj = append(obj, gqlEntry{key: sel.key(), value: value})
+	}
+	return obj, nil
+}
+
+// scalar 檢查標量字段沒有參數和選擇集後返回其值
+func scalar(f *gqlField, value any) (any, error) {
+	if len(f.args) > 0 {
+		return nil, fmt.Errorf("字段 %s 不接受參數", f.name)
+	}
+	if len(f.selections) > 0 {
+		return nil, fmt.Errorf("標量字段 %s 不能有選擇集", f.name)
+	}
+	return value, nil
+}
+
+// unknownField 返回類型沒有該字段的錯誤
+func unknownField(typename string, f *gqlField) error {
+	return fmt.Errorf("類型 %s 沒有字段 %s", typename, f.name)
+}
+
+// args 返回字段的參數值，變量已替換為請求中的值或默認值；出現 allowed 之外的參數時返回錯誤
+func (e *gqlExecutor) args(f *gqlField, allowed ...string) (map[string]any, error) {
+	values := make(map[string]any, len(f.args))
+	for name, v := range f.args {
+		if !slices.Contains(allowed, name) {
+			return nil, fmt.Errorf("字段 %s 沒有參數 %s", f.name, name)
+		}
+		value, err := e.value(v)
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			values[name] = value
+		}
+	}
+	return values, nil
+}
+
+// value 解析參數值中的變量
+func (e *gqlExecutor) value(v gqlValue) (any, error) {
+	if v.variable == "" {
+		return v.value, nil
+	}
+	def, declared := e.op.variables[v.variable]
+	if !declared {
+		return nil, fmt.Errorf("未定義的變量 $%s", v.variable)
+	}
+	if value, ok := e.vars[v.variable]; ok {
+		return value, nil
+	}
+	if def != nil {
+		return def.value, nil
+	}
+	return nil, nil
+}
+
+// intArg 讀取整數參數，變量的 JSON 數字須為整數
+func intArg(args map[string]any, name string) (int64, bool, error) {
+	switch v := args[name].(type) {
+	case nil:
+		return 0, false, nil
+	case int64:
+		return v, true, nil
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return int64(v), true, nil
+		}
+	}
+	return 0, false, fmt.Errorf("參數 %s 必須是整數", name)
+}
+
+// roundArg 讀取非負的輪次參數
+func roundArg(args map[string]any, name string) (uint64, bool, error) {
+	n, ok, err := intArg(args, name)
+	if err == nil && n < 0 {
+		err = fmt.Errorf("參數 %s 不能為負數", name)
+	}
+	return uint64(n), ok, err
+}
+
+// stringArg 讀取字符串參數
+func stringArg(args map[string]any, name string) (string, bool, error) {
+	switch v := args[name].(type) {
+	case nil:
+		return "", false, nil
+	case string:
+		return v, true, nil
+	}
+	return "", false, fmt.Errorf("參數 %s 必須是字符串", name)
+}
+
+// verificationValues 是 Verification 枚舉的 GraphQL 值
+var verificationValues = map[string]Verification{
+	"UNVERIFIED": Unverified,
+	"VERIFIED":   Verified,
+	"FAILED":     VerificationFailed,
+}
+
+// verificationArg 讀取 Verification 枚舉參數，變量中的枚舉以字符串傳入
+func verificationArg(args map[string]any, name string) (Verification, error) {
+	var value string
+	switch v := args[name].(type) {
+	case nil:
+		return "", nil
+	case gqlEnum:
+		value = string(v)
+	case string:
+		value = v
+	}
+	status, ok := verificationValues[value]
+	if !ok {
+		return "", fmt.Errorf("參數 %s 必須是 UNVERIFIED、VERIFIED 或 FAILED", name)
+	}
+	return status, nil
+}
+
+// resolveQuery 解析 Query 類型的字段
+func (e *gqlExecutor) resolveQuery(f *gqlField, depth int) (any, error) {
+	switch f.name {
+	case "sessions":
+		args, err := e.args(f, "minRound", "maxRound", "tenant", "verification", "first", "after")
+		if err != nil {
+			return nil, err
+		}
+		var q SessionQuery
+		if q.MinRound, _, err = roundArg(args, "minRound"); err != nil {
+			return nil, err
+		}
+		if q.MaxRound, _, err = roundArg(args, "maxRound"); err != nil {
+			return nil, err
+		}
+		return e.sessions(f, depth, args, q)
+	case "session":
+		args, err := e.args(f, "id")
+		if err != nil {
+			return nil, err
+		}
+		id, ok, err := stringArg(args, "id")
+		if err != nil || !ok {
+			return nil, fmt.Errorf("session 需要字符串參數 id")
+		}
+		if err := e.query(); err != nil {
+			return nil, err
+		}
+		session, err := e.store.LoadSession(e.ctx, id)
+		if err != nil {
+			if errors.Is(err, ErrSessionNotFound) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		return e.object(f, "Session", depth, e.sessionResolver(session))
+	case "beacons":
+		args, err := e.args(f, "minRound", "maxRound", "first")
+		if err != nil {
+			return nil, err
+		}
+		minRound, _, err := roundArg(args, "minRound")
+		if err != nil {
+			return nil, err
+		}
+		maxRound, hasMax, err := roundArg(args, "maxRound")
+		if err != nil {
+			return nil, err
+		}
+		if !hasMax {
+			maxRound = math.MaxInt64
+		}
+		first, _, err := intArg(args, "first")
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case first < 0:
+			return nil, fmt.Errorf("參數 first 不能為負數")
+		case first == 0:
+			first = DefaultPageSize
+		case first > MaxPageSize:
+			first = MaxPageSize
+		}
+		if err := e.query(); err != nil {
+			return nil, err
+		}
+		beacons, err := e.store.queryBeacons(e.ctx, "SELECT round, randomness, signature, previous_signature FROM drandshuffle_beacons WHERE round >= ? AND round <= ? ORDER BY round LIMIT ?",
+			int64(minRound), int64(maxRound), first)
+		if err != nil {
+			return nil, err
+		}
+		list := make([]gqlObject, len(beacons))
+		for i := range beacons {
+			if list[i], err = e.object(f, "Beacon", depth, e.beaconResolver(beacons[i])); err != nil {
+				return nil, err
+			}
+		}
+		return list, nil
+	case "beacon":
+		args, err := e.args(f, "round")
+		if err != nil {
+			return nil, err
+		}
+		round, ok, err := roundArg(args, "round")
+		if err != nil || !ok {
+			return nil, fmt.Errorf("beacon 需要整數參數 round")
+		}
+		return e.beacon(f, depth, round)
+	default:
+		return nil, unknownField("Query", f)
+	}
+}
+
+// sessions 按參數查詢一頁牌局並解析為 SessionConnection，q 中已填入輪次範圍
+func (e *gqlExecutor) sessions(f *gqlField, depth int, args map[string]any, q SessionQuery) (any, error) {
+	var err error
+	if q.Tenant, _, err = stringArg(args, "tenant"); err != nil {
+		return nil, err
+	}
+	if q.Verification, err = verificationArg(args, "verification"); err != nil {
+		return nil, err
+	}
+	if q.Cursor, _, err = stringArg(args, "after"); err != nil {
+		return nil, err
+	}
+	first, _, err := intArg(args, "first")
+	if err != nil {
+		return nil, err
+	}
+	if first < 0 {
+		return nil, fmt.Errorf("參數 first 不能為負數")
+	}
+	q.Limit = int(min(first, MaxPageSize))
+
+	if err := e.query(); err != nil {
+		return nil, err
+	}
+	page, err := e.store.ListSessions(e.ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	return e.object(f, "SessionConnection", depth, func(f *gqlField, depth int) (any, error) {
+		switch f.name {
+		case "nodes":
+			nodes := make([]gqlObject, len(page.Sessions))
+			for i := range page.Sessions {
+				if nodes[i], err = e.object(f, "Session", depth, e.sessionResolver(&page.Sessions[i])); err != nil {
+					return nil, err
+				}
+			}
+			return nodes, nil
+		case "nextCursor":
+			if page.NextCursor == "" {
+				return scalar(f, nil)
+			}
+			return scalar(f, page.NextCursor)
+		default:
+			return nil, unknownField("SessionConnection", f)
+		}
+	})
+}
+
+// beacon 讀取輪次的隨機信標並解析為 Beacon，數據庫中沒有時返回 null
+func (e *gqlExecutor) beacon(f *gqlField, depth int, round uint64) (any, error) {
+	if err := e.query(); err != nil {
+		return nil, err
+	}
+	beacons, err := e.store.queryBeacons(e.ctx, "SELECT round, randomness, signature, previous_signature FROM drandshuffle_beacons WHERE round = ?", int64(round))
+	if err != nil {
+		return nil, err
+	}
+	if len(beacons) == 0 {
+		if len(f.selections) == 0 {
+			return nil, fmt.Errorf("字段 %s 的類型 Beacon 需要選擇集", f.name)
+		}
+		return nil, nil
+	}
+	return e.object(f, "Beacon", depth, e.beaconResolver(beacons[0]))
+}
+
+// sessionResolver 返回 Session 類型的字段解析函數
+func (e *gqlExecutor) sessionResolver(s *Session) gqlResolver {
+	return func(f *gqlField, depth int) (any, error) {
+		switch f.name {
+		case "sessionId":
+			return scalar(f, s.SessionID)
+		case "tenant":
+			return scalar(f, s.Tenant)
+		case "round":
+			return scalar(f, s.Round)
+		case "deck":
+			return scalar(f, drandshuffle.EncodeDeck(s.Deck))
+		case "verification":
+			for value, status := range verificationValues {
+				if status == s.Verification {
+					return scalar(f, value)
+				}
+			}
+			return nil, fmt.Errorf("遊戲局號 %s 的驗證狀態 %q 無效", s.SessionID, s.Verification)
+		case "createdAt":
+			return scalar(f, s.CreatedAt.UTC().Format(time.RFC3339Nano))
+		case "proof":
+			if s.Proof == nil {
+				return nil, nil
+			}
+			return e.object(f, "Proof", depth, proofResolver(s.Proof))
+		case "beacon":
+			if len(f.args) > 0 {
+				return nil, fmt.Errorf("字段 %s 不接受參數", f.name)
+			}
+			return e.beacon(f, depth, s.Round)
+		default:
+			return nil, unknownField("Session", f)
+		}
+	}
+}
+
+// proofResolver 返回 Proof 類型的字段解析函數，字節以十六進制輸出
+func proofResolver(p *drandshuffle.ShuffleProof) gqlResolver {
+	return func(f *gqlField, depth int) (any, error) {
+		switch f.name {
+		case "algorithm":
+			return scalar(f, p.Algorithm)
+		case "provider":
+			if p.Provider == "" {
+				return scalar(f, drandshuffle.ProviderDrand)
+			}
+			return scalar(f, p.Provider)
+		case "chainHash":
+			return scalar(f, p.ChainHash)
+		case "round":
+			return scalar(f, p.Round)
+		case "roundTime":
+			if p.RoundTime.IsZero() {
+				return scalar(f, nil)
+			}
+			return scalar(f, p.RoundTime.UTC().Format(time.RFC3339Nano))
+		case "randomness":
+			return scalar(f, hex.EncodeToString(p.Randomness))
+		case "signature":
+			return scalar(f, hex.EncodeToString(p.Signature))
+		case "previousSignature":
+			return scalar(f, hex.EncodeToString(p.PreviousSignature))
+		case "sessionId":
+			return scalar(f, p.SessionID)
+		case "deckSize":
+			return scalar(f, p.DeckSize)
+		case "digest":
+			return scalar(f, p.Digest())
+		default:
+			return nil, unknownField("Proof", f)
+		}
+	}
+}
+
+// beaconResolver 返回 Beacon 類型的字段解析函數
+func (e *gqlExecutor) beaconResolver(b drandshuffle.Beacon) gqlResolver {
+	return func(f *gqlField, depth int) (any, error) {
+		switch f.name {
+		case "round":
+			return scalar(f, b.Round)
+		case "randomness":
+			return scalar(f, hex.EncodeToString(b.Randomness))
+		case "signature":
+			return scalar(f, hex.EncodeToString(b.Signature))
+		case "previousSignature":
+			return scalar(f, hex.EncodeToString(b.PreviousSignature))
+		case "sessions":
+			args, err := e.args(f, "tenant", "verification", "first", "after")
+			if err != nil {
+				return nil, err
+			}
+			return e.sessions(f, depth, args, SessionQuery{MinRound: b.Round, MaxRound: b.Round})
+		default:
+			return nil, unknownField("Beacon", f)
+		}
+	}
+}
@@ -0,0 +1,407 @@
+package sqlstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 本文件實現 NewGraphQLHandler 所需的 GraphQL 查詢子集的解析：
+// 查詢操作（含簡寫形式）、變量定義和默認值、別名、參數和嵌套的選擇集。
+// 片段、指令、訂閱和變更不受支持，解析時返回錯誤。
+
+// gqlField 是選擇集中的一個字段
+type gqlField struct {
+	alias      string
+	name       string
+	args       map[string]gqlValue
+	selections []*gqlField
+}
+
+// key 返回字段在響應中的名稱，有別名時使用別名
+func (f *gqlField) key() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+// gqlValue 是參數的值，變量在執行時以 resolve 替換
+type gqlValue struct {
+	variable string // 變量名，不為空時其餘欄位無效
+	value    any    // 常量值：int64、float64、string、bool、nil、gqlEnum、[]gqlValue 或 map[string]gqlValue
+}
+
+// gqlEnum 是枚舉值，與字符串區分
+type gqlEnum string
+
+// gqlOperation 是文檔中的一個查詢操作
+type gqlOperation struct {
+	name       string
+	variables  map[string]*gqlValue // 變量的默認值，沒有默認值時為 nil
+	selections []*gqlField
+}
+
+// gqlParser 是逐個讀取記號的遞歸下降解析器
+type gqlParser struct {
+	src string
+	pos int
+	tok string // 當前記號，字符串記號保留引號
+}
+
+// parseGraphQL 解析查詢文檔，返回其中的所有操作
+func parseGraphQL(src string) ([]*gqlOperation, error) {
+	p := &gqlParser{src: strings.TrimPrefix(src, "\ufeff")}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	var ops []*gqlOperation
+	for p.tok != "" {
+		op, err := p.parseOperation()
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, op)
+	}
+	if len(ops) == 0 {
+		return nil, fmt.Errorf("查詢中沒有操作")
+	}
+	return ops, nil
+}
+
+// next 讀取下一個記號，忽略空白、逗號和註釋；到達末尾時 tok 為空
+func (p *gqlParser) next() error {
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			p.pos++
+		case c == '#':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		default:
+			return p.scan()
+		}
+	}
+	p.tok = ""
+	return nil
+}
+
+// scan 讀取從 pos 開始的記號
+func (p *gqlParser) scan() error {
+	start := p.pos
+	c := p.src[p.pos]
+	switch {
+	case strings.IndexByte("{}()[]:$!=@", c) >= 0:
+		p.pos++
+	case c == '.':
+		if !strings.HasPrefix(p.src[p.pos:], "...") {
+			return fmt.Errorf("位置 %d: 無效的字符 %q", p.pos, c)
+		}
+		p.pos += 3
+	case c == '"':
+		if strings.HasPrefix(p.src[p.pos:], `"""`) {
+			return fmt.Errorf("位置 %d: 不支持塊字符串", p.pos)
+		}
+		p.pos++
+		for {
+			if p.pos >= len(p.src) || p.src[p.pos] == '\n' {
+				return fmt.Errorf("位置 %d: 字符串未結束", start)
+			}
+			if p.src[p.pos] == '\\' {
+				p.pos += 2
+				continue
+			}
+			p.pos++
+			if p.src[p.pos-1] == '"' {
+				break
+			}
+		}
+	case c == '-' || c >= '0' && c <= '9':
+		p.pos++
+		for p.pos < len(p.src) && strings.IndexByte("0123456789.eE+-", p.src[p.pos]) >= 0 {
+			p.pos++
+		}
+	case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+		for p.pos < len(p.src) && isNameByte(p.src[p.pos]) {
+			p.pos++
+		}
+	default:
+		return fmt.Errorf("位置 %d: 無效的字符 %q", p.pos, c)
+	}
+	p.tok = p.src[start:p.pos]
+	return nil
+}
+
+// isNameByte 報告字節是否可以出現在名稱中
+func isNameByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// isName 報告當前記號是否為名稱
+func (p *gqlParser) isName() bool {
+	return p.tok != "" && (p.tok[0] == '_' || p.tok[0] >= 'a' && p.tok[0] <= 'z' || p.tok[0] >= 'A' && p.tok[0] <= 'Z')
+}
+
+// expect 檢查當前記號為 tok 並讀取下一個記號
+func (p *gqlParser) expect(tok string) error {
+	if p.tok != tok {
+		return p.unexpected("需要 " + tok)
+	}
+	return p.next()
+}
+
+// name 讀取一個名稱
+func (p *gqlParser) name() (string, error) {
+	if !p.isName() {
+		return "", p.unexpected("需要名稱")
+	}
+	name := p.tok
+	return name, p.next()
+}
+
+// unexpected 返回遇到意外記號的錯誤
+func (p *gqlParser) unexpected(want string) error {
+	if p.tok == "" {
+		return fmt.Errorf("查詢意外結束，%s", want)
+	}
+	return fmt.Errorf("位置 %d: 意外的 %q，%s", p.pos-len(p.tok), p.tok, want)
+}
+
+// parseOperation 解析一個操作，可以是簡寫的 { ... } 或 query Name($v: T) { ... }
+func (p *gqlParser) parseOperation() (*gqlOperation, error) {
+	op := &gqlOperation{variables: map[string]*gqlValue{}}
+	if p.tok != "{" {
+		switch p.tok {
+		case "query":
+		case "mutation", "subscription", "fragment":
+			return nil, fmt.Errorf("不支持 %s，只能查詢", p.tok)
+		default:
+			return nil, p.unexpected("需要 query 或 {")
+		}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if p.isName() {
+			var err error
+			if op.name, err = p.name(); err != nil {
+				return nil, err
+			}
+		}
+		if p.tok == "(" {
+			if err := p.parseVariableDefinitions(op); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if p.tok == "@" {
+		return nil, fmt.Errorf("不支持指令")
+	}
+	var err error
+	op.selections, err = p.parseSelectionSet()
+	return op, err
+}
+
+// parseVariableDefinitions 解析 ($name: Type = default, ...)，類型只用於語法檢查
+func (p *gqlParser) parseVariableDefinitions(op *gqlOperation) error {
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	for p.tok != ")" {
+		if err := p.expect("$"); err != nil {
+			return err
+		}
+		name, err := p.name()
+		if err != nil {
+			return err
+		}
+		if err := p.expect(":"); err != nil {
+			return err
+		}
+		if err := p.parseType(); err != nil {
+			return err
+		}
+		op.variables[name] = nil
+		if p.tok == "=" {
+			if err := p.next(); err != nil {
+				return err
+			}
+			def, err := p.parseValue(true)
+			if err != nil {
+				return err
+			}
+			op.variables[name] = &def
+		}
+	}
+	return p.next()
+}
+
+// parseType 解析 Name、[Type] 和非空的 Type!
+func (p *gqlParser) parseType() error {
+	if p.tok == "[" {
+		if err := p.next(); err != nil {
+			return err
+		}
+		if err := p.parseType(); err != nil {
+			return err
+		}
+		if err := p.expect("]"); err != nil {
+			return err
+		}
+	} else if _, err := p.name(); err != nil {
+		return err
+	}
+	if p.tok == "!" {
+		return p.next()
+	}
+	return nil
+}
+
+// parseSelectionSet 解析 { field ... }
+func (p *gqlParser) parseSelectionSet() ([]*gqlField, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var fields []*gqlField
+	for p.tok != "}" {
+		if p.tok == "..." {
+			return nil, fmt.Errorf("不支持片段")
+		}
+		f, err := p.parseField()
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, f)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("選擇集不能為空")
+	}
+	return fields, p.next()
+}
+
+// parseField 解析 alias: name(args) { ... }
+func (p *gqlParser) parseField() (*gqlField, error) {
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	f := &gqlField{name: name}
+	if p.tok == ":" {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		f.alias = name
+		if f.name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	if p.tok == "(" {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		f.args = map[string]gqlValue{}
+		for p.tok != ")" {
+			arg, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			if f.args[arg], err = p.parseValue(false); err != nil {
+				return nil, err
+			}
+		}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+	}
+	if p.tok == "@" {
+		return nil, fmt.Errorf("不支持指令")
+	}
+	if p.tok == "{" {
+		if f.selections, err = p.parseSelectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// parseValue 解析參數值，constant 為 true 時不允許變量（用於默認值）
+func (p *gqlParser) parseValue(constant bool) (gqlValue, error) {
+	tok := p.tok
+	switch {
+	case tok == "$":
+		if constant {
+			return gqlValue{}, fmt.Errorf("默認值不能使用變量")
+		}
+		if err := p.next(); err != nil {
+			return gqlValue{}, err
+		}
+		name, err := p.name()
+		return gqlValue{variable: name}, err
+	case tok == "[":
+		if err := p.next(); err != nil {
+			return gqlValue{}, err
+		}
+		var list []gqlValue
+		for p.tok != "]" {
+			v, err := p.parseValue(constant)
+			if err != nil {
+				return gqlValue{}, err
+			}
+			list = append(list, v)
+		}
+		return gqlValue{value: list}, p.next()
+	case tok == "{":
+		if err := p.next(); err != nil {
+			return gqlValue{}, err
+		}
+		obj := map[string]gqlValue{}
+		for p.tok != "}" {
+			name, err := p.name()
+			if err != nil {
+				return gqlValue{}, err
+			}
+			if err := p.expect(":"); err != nil {
+				return gqlValue{}, err
+			}
+			if obj[name], err = p.parseValue(constant); err != nil {
+				return gqlValue{}, err
+			}
+		}
+		return gqlValue{value: obj}, p.next()
+	case tok != "" && tok[0] == '"':
+		var s string
+		if err := json.Unmarshal([]byte(tok), &s); err != nil {
+			return gqlValue{}, fmt.Errorf("無效的字符串 %s", tok)
+		}
+		return gqlValue{value: s}, p.next()
+	case tok != "" && (tok[0] == '-' || tok[0] >= '0' && tok[0] <= '9'):
+		if n, err := strconv.ParseInt(tok, 10, 64); err == nil {
+			return gqlValue{value: n}, p.next()
+		}
+		f, err := strconv.ParseFloat(tok, 64)
+		if err != nil {
+			return gqlValue{}, fmt.Errorf("無效的數字 %s", tok)
+		}
+		return gqlValue{value: f}, p.next()
+	case p.isName():
+		var v any
+		switch tok {
+		case "true":
+			v = true
+		case "false":
+			v = false
+		case "null":
+			v = nil
+		default:
+			v = gqlEnum(tok)
+		}
+		return gqlValue{value: v}, p.next()
+	default:
+		return gqlValue{}, p.unexpected("需要值")
+	}
+}
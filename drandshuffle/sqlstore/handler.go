@@ -0,0 +1,105 @@
+package sqlstore
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+)
+
+// sessionJSON 是 /sessions 響應中一局牌的 JSON 格式
+type sessionJSON struct {
+	SessionID    string                     `json:"session_id"`
+	Tenant       string                     `json:"tenant,omitempty"`
+	Round        uint64                     `json:"round"`
+	Deck         string                     `json:"deck"` // drandshuffle.EncodeDeck 的編碼
+	Proof        *drandshuffle.ShuffleProof `json:"proof,omitempty"`
+	Verification Verification               `json:"verification"`
+	CreatedAt    time.Time                  `json:"created_at"`
+}
+
+// sessionPageJSON 是 /sessions 的響應
+type sessionPageJSON struct {
+	Sessions   []sessionJSON `json:"sessions"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+// NewSessionHandler 返回以 JSON 提供 GET /sessions 的只讀接口，供後台工具瀏覽和核對歷史牌局
+//
+// 查詢參數對應 SessionQuery：min_round、max_round、tenant、verification（unverified、verified 或 failed）、
+// limit 和 cursor。響應的 next_cursor 不為空時，以它作為 cursor 取得下一頁。
+// 接口不做身份驗證，應掛在內部的管理地址或加上調用者自己的認證中間件
+func NewSessionHandler(store *Store) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "只接受 GET 和 HEAD 請求", http.StatusMethodNotAllowed)
+			return
+		}
+		if r.URL.Path != "/sessions" {
+			http.NotFound(w, r)
+			return
+		}
+
+		params := r.URL.Query()
+		q := SessionQuery{
+			Tenant:       params.Get("tenant"),
+			Verification: Verification(params.Get("verification")),
+			Cursor:       params.Get("cursor"),
+		}
+		if q.Verification != "" && !q.Verification.valid() {
+			http.Error(w, "無效的驗證狀態", http.StatusBadRequest)
+			return
+		}
+		for _, p := range []struct {
+			name string
+			dst  *uint64
+		}{{"min_round", &q.MinRound}, {"max_round", &q.MaxRound}} {
+			if v := params.Get(p.name); v != "" {
+				n, err := strconv.ParseUint(v, 10, 63)
+				if err != nil {
+					http.Error(w, "無效的 "+p.name, http.StatusBadRequest)
+					return
+				}
+				*p.dst = n
+			}
+		}
+		if v := params.Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				http.Error(w, "無效的 limit", http.StatusBadRequest)
+				return
+			}
+			q.Limit = n
+		}
+
+		page, err := store.ListSessions(r.Context(), q)
+		if errors.Is(err, ErrInvalidCursor) {
+			http.Error(w, "無效的 cursor", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		resp := sessionPageJSON{Sessions: make([]sessionJSON, len(page.Sessions)), NextCursor: page.NextCursor}
+		for i, s := range page.Sessions {
+			resp.Sessions[i] = sessionJSON{
+				SessionID:    s.SessionID,
+				Tenant:       s.Tenant,
+				Round:        s.Round,
+				Deck:         drandshuffle.EncodeDeck(s.Deck),
+				Proof:        s.Proof,
+				Verification: s.Verification,
+				CreatedAt:    s.CreatedAt,
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		json.NewEncoder(w).Encode(resp)
+	})
+}
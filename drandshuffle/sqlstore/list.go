@@ -0,0 +1,140 @@
+package sqlstore
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultPageSize 是 SessionQuery.Limit 為 0 時每頁返回的牌局數
+const DefaultPageSize = 100
+
+// MaxPageSize 是每頁最多返回的牌局數，更大的 Limit 會被截斷
+const MaxPageSize = 1000
+
+// SessionQuery 是 ListSessions 的篩選和分頁條件，零值的條件不篩選
+type SessionQuery struct {
+	MinRound     uint64       // 最小輪次（含）
+	MaxRound     uint64       // 最大輪次（含）
+	Tenant       string       // 只返回此租戶的牌局
+	Verification Verification // 只返回此驗證狀態的牌局
+	CreatedFrom  time.Time    // 只返回此時間（含）之後保存的牌局
+	CreatedTo    time.Time    // 只返回此時間（不含）之前保存的牌局
+	Limit        int          // 每頁的牌局數，0 時使用 DefaultPageSize
+	Cursor       string       // 上一頁的 SessionPage.NextCursor，為空時從第一頁開始
+}
+
+// SessionPage 是 ListSessions 返回的一頁牌局
+type SessionPage struct {
+	Sessions   []Session // 按輪次和遊戲局號排序的牌局
+	NextCursor string    // 下一頁的游標，沒有更多牌局時為空
+}
+
+// ListSessions 按輪次和遊戲局號的順序返回符合條件的一頁牌局
+// 分頁以游標定位而不是偏移量，翻頁期間新保存的牌局不會導致重複或遺漏；游標無效時返回 ErrInvalidCursor
+func (s *Store) ListSessions(ctx context.Context, q SessionQuery) (*SessionPage, error) {
+	if q.Verification != "" && !q.Verification.valid() {
+		return nil, fmt.Errorf("無效的驗證狀態 %q", q.Verification)
+	}
+	limit := q.Limit
+	switch {
+	case limit < 0:
+		return nil, fmt.Errorf("無效的每頁數量 %d", limit)
+	case limit == 0:
+		limit = DefaultPageSize
+	case limit > MaxPageSize:
+		limit = MaxPageSize
+	}
+
+	var conds []string
+	var args []any
+	if q.MinRound > 0 {
+		conds = append(conds, "round >= ?")
+		args = append(args, int64(q.MinRound))
+	}
+	if q.MaxRound > 0 {
+		conds = append(conds, "round <= ?")
+		args = append(args, int64(q.MaxRound))
+	}
+	if q.Tenant != "" {
+		conds = append(conds, "tenant = ?")
+		args = append(args, q.Tenant)
+	}
+	if q.Verification != "" {
+		conds = append(conds, "verification = ?")
+		args = append(args, string(q.Verification))
+	}
+	if !q.CreatedFrom.IsZero() {
+		conds = append(conds, "created_at >= ?")
+		args = append(args, q.CreatedFrom.UTC())
+	}
+	if !q.CreatedTo.IsZero() {
+		conds = append(conds, "created_at < ?")
+		args = append(args, q.CreatedTo.UTC())
+	}
+	if q.Cursor != "" {
+		round, sessionID, err := decodeCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		conds = append(conds, "(round > ? OR (round = ? AND session_id > ?))")
+		args = append(args, round, round, sessionID)
+	}
+
+	query := "SELECT " + sessionColumns + " FROM drandshuffle_sessions"
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	// 多取一行以判斷是否還有下一頁
+	query += " ORDER BY round, session_id LIMIT ?"
+	args = append(args, limit+1)
+
+	rows, err := s.db.QueryContext(ctx, s.dialect.bind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("無法查詢牌局: %w", err)
+	}
+	defer rows.Close()
+
+	page := &SessionPage{}
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("無法讀取牌局: %w", err)
+		}
+		page.Sessions = append(page.Sessions, *session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("無法讀取牌局: %w", err)
+	}
+	if len(page.Sessions) > limit {
+		page.Sessions = page.Sessions[:limit]
+		last := page.Sessions[limit-1]
+		page.NextCursor = encodeCursor(last.Round, last.SessionID)
+	}
+	return page, nil
+}
+
+// encodeCursor 將最後一局的輪次和遊戲局號編碼為不透明的游標
+func encodeCursor(round uint64, sessionID string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(round, 10) + ":" + sessionID))
+}
+
+// decodeCursor 解析 encodeCursor 產生的游標
+func decodeCursor(cursor string) (int64, string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, "", fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+	roundText, sessionID, ok := strings.Cut(string(data), ":")
+	if !ok || sessionID == "" {
+		return 0, "", ErrInvalidCursor
+	}
+	round, err := strconv.ParseInt(roundText, 10, 64)
+	if err != nil || round < 0 {
+		return 0, "", ErrInvalidCursor
+	}
+	return round, sessionID, nil
+}
@@ -0,0 +1,110 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// migrations 是按順序執行的數據庫遷移，第 i 個遷移的版本號為 i+1
+// 已發布的遷移不能修改，表結構的改變應追加新的遷移並增加 SchemaVersion
+var migrations = []func(d Dialect) []string{
+	// 1: 隨機信標和牌局
+	func(d Dialect) []string {
+		return []string{
+			`CREATE TABLE drandshuffle_beacons (
+				round BIGINT NOT NULL PRIMARY KEY,
+				randomness TEXT NOT NULL,
+				signature TEXT NOT NULL,
+				previous_signature TEXT NOT NULL
+			)` + d.tableOptions(),
+			`CREATE TABLE drandshuffle_sessions (
+				session_id VARCHAR(128) NOT NULL PRIMARY KEY,
+				round BIGINT NOT NULL,
+				deck TEXT NOT NULL,
+				proof TEXT,
+				created_at ` + d.timestampType() + ` NOT NULL
+			)` + d.tableOptions(),
+			`CREATE INDEX drandshuffle_sessions_round ON drandshuffle_sessions (round)`,
+		}
+	},
+	// 2: 牌局的租戶和驗證狀態，供 ListSessions 篩選
+	func(d Dialect) []string {
+		return []string{
+			`ALTER TABLE drandshuffle_sessions ADD COLUMN tenant VARCHAR(128) NOT NULL DEFAULT ''`,
+			`ALTER TABLE drandshuffle_sessions ADD COLUMN verification VARCHAR(16) NOT NULL DEFAULT 'unverified'`,
+			`CREATE INDEX drandshuffle_sessions_tenant ON drandshuffle_sessions (tenant, round)`,
+		}
+	},
+}
+
+// SchemaVersion 是本版本的遷移完成後的結構版本，等於 migrations 的數量
+const SchemaVersion = 2
+
+// timestampType 返回保存帶時區時間的列類型
+func (d Dialect) timestampType() string {
+	switch d {
+	case Postgres:
+		return "TIMESTAMPTZ"
+	case MySQL:
+		return "DATETIME(6)"
+	default:
+		return "TIMESTAMP"
+	}
+}
+
+// tableOptions 返回建表語句的附加選項，MySQL 需要 utf8mb4 才能保存中文的牌名
+func (d Dialect) tableOptions() string {
+	if d == MySQL {
+		return " DEFAULT CHARSET=utf8mb4"
+	}
+	return ""
+}
+
+// Migrate 創建或升級本包使用的表，已執行的遷移不會重複執行，可以在每次啟動時調用
+// 每個遷移在一個事務中執行並記錄在 drandshuffle_schema_migrations 表中；
+// MySQL 的 DDL 會隱式提交，遷移中途失敗時可能需要手動清理
+func (s *Store) Migrate(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS drandshuffle_schema_migrations (
+		version INTEGER NOT NULL PRIMARY KEY,
+		applied_at `+s.dialect.timestampType()+` NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("無法創建遷移記錄表: %w", err)
+	}
+
+	version, err := s.Version(ctx)
+	if err != nil {
+		return err
+	}
+	if version > SchemaVersion {
+		return fmt.Errorf("數據庫的結構版本 %d 比本版本支持的 %d 新，請升級 drandshuffle", version, SchemaVersion)
+	}
+
+	for v := version + 1; v <= SchemaVersion; v++ {
+		err := s.inTx(ctx, func(tx *sql.Tx) error {
+			for _, stmt := range migrations[v-1](s.dialect) {
+				if _, err := tx.ExecContext(ctx, stmt); err != nil {
+					return err
+				}
+			}
+			_, err := tx.ExecContext(ctx, s.dialect.bind("INSERT INTO drandshuffle_schema_migrations (version, applied_at) VALUES (?, ?)"), v, time.Now().UTC())
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("無法執行遷移 %d: %w", v, err)
+		}
+	}
+	return nil
+}
+
+// Version 返回數據庫當前的結構版本，尚未執行任何遷移時返回 0
+func (s *Store) Version(ctx context.Context) (int, error) {
+	var version sql.NullInt64
+	err := s.db.QueryRowContext(ctx, "SELECT MAX(version) FROM drandshuffle_schema_migrations").Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("無法讀取結構版本: %w", err)
+	}
+	return int(version.Int64), nil
+}
@@ -0,0 +1,110 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+)
+
+// 確保 Store 可以作為 WithRetention 和 StartCompaction 的存儲
+var _ drandshuffle.Compactor = (*Store)(nil)
+
+// Compact 在一個事務中按保留策略以 DELETE 刪除舊的隨機信標和牌局，返回刪除的行數
+// 隨機信標按輪次刪除，MaxEntries 保留輪次最大的若干個；牌局按輪次和 CreatedAt 刪除，MaxEntries 保留最近保存的若干局，
+// 保存時間相同的牌局一併保留。MaxBytes 不適用於數據庫，會被忽略
+func (s *Store) Compact(ctx context.Context, policy drandshuffle.RetentionPolicy, now time.Time) (int, error) {
+	var removed int64
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		n, err := s.compactBeacons(ctx, tx, policy, now)
+		if err != nil {
+			return err
+		}
+		removed += n
+		n, err = s.compactSessions(ctx, tx, policy, now)
+		removed += n
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(removed), nil
+}
+
+// compactBeacons 刪除策略不再保留的隨機信標
+func (s *Store) compactBeacons(ctx context.Context, tx *sql.Tx, policy drandshuffle.RetentionPolicy, now time.Time) (int64, error) {
+	var latest sql.NullInt64
+	if err := tx.QueryRowContext(ctx, "SELECT MAX(round) FROM drandshuffle_beacons").Scan(&latest); err != nil {
+		return 0, fmt.Errorf("無法查詢最新輪次: %w", err)
+	}
+	if !latest.Valid {
+		return 0, nil
+	}
+	from := policy.KeepFrom(uint64(latest.Int64), now)
+	if policy.MaxEntries > 0 {
+		var nth int64
+		err := tx.QueryRowContext(ctx, s.dialect.bind("SELECT round FROM drandshuffle_beacons ORDER BY round DESC LIMIT 1 OFFSET ?"), policy.MaxEntries-1).Scan(&nth)
+		switch {
+		case err == nil:
+			if uint64(nth) > from {
+				from = uint64(nth)
+			}
+		case !errors.Is(err, sql.ErrNoRows):
+			return 0, fmt.Errorf("無法查詢保留的隨機信標: %w", err)
+		}
+	}
+	if from == 0 {
+		return 0, nil
+	}
+	res, err := tx.ExecContext(ctx, s.dialect.bind("DELETE FROM drandshuffle_beacons WHERE round < ?"), int64(from))
+	if err != nil {
+		return 0, fmt.Errorf("無法刪除舊的隨機信標: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// compactSessions 刪除策略不再保留的牌局
+func (s *Store) compactSessions(ctx context.Context, tx *sql.Tx, policy drandshuffle.RetentionPolicy, now time.Time) (int64, error) {
+	var latest sql.NullInt64
+	if err := tx.QueryRowContext(ctx, "SELECT MAX(round) FROM drandshuffle_sessions").Scan(&latest); err != nil {
+		return 0, fmt.Errorf("無法查詢最新牌局: %w", err)
+	}
+	if !latest.Valid {
+		return 0, nil
+	}
+
+	var conds []string
+	var args []any
+	if from := policy.KeepFrom(uint64(latest.Int64), now); from > 0 {
+		conds = append(conds, "round < ?")
+		args = append(args, int64(from))
+	}
+	if policy.MaxAge > 0 {
+		conds = append(conds, "created_at < ?")
+		args = append(args, now.Add(-policy.MaxAge).UTC())
+	}
+	if policy.MaxEntries > 0 {
+		var nth time.Time
+		err := tx.QueryRowContext(ctx, s.dialect.bind("SELECT created_at FROM drandshuffle_sessions ORDER BY created_at DESC LIMIT 1 OFFSET ?"), policy.MaxEntries-1).Scan(&nth)
+		switch {
+		case err == nil:
+			conds = append(conds, "created_at < ?")
+			args = append(args, nth.UTC())
+		case !errors.Is(err, sql.ErrNoRows):
+			return 0, fmt.Errorf("無法查詢保留的牌局: %w", err)
+		}
+	}
+	if len(conds) == 0 {
+		return 0, nil
+	}
+
+	res, err := tx.ExecContext(ctx, s.dialect.bind("DELETE FROM drandshuffle_sessions WHERE "+strings.Join(conds, " OR ")), args...)
+	if err != nil {
+		return 0, fmt.Errorf("無法刪除舊的牌局: %w", err)
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,124 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+)
+
+// Snapshot 是數據庫中所有隨機信標和牌局在同一時刻的快照，用於在服務之間遷移狀態
+type Snapshot struct {
+	Beacons  []drandshuffle.Beacon // 按輪次排序
+	Sessions []Session             // 按保存時間和遊戲局號排序
+}
+
+// RestoreResult 是 Restore 實際寫入的數量，已存在的輪次和遊戲局號不計入
+type RestoreResult struct {
+	Beacons  int
+	Sessions int
+}
+
+// Snapshot 在一個只讀事務中讀取所有隨機信標和牌局，服務運行中調用也能得到一致的快照
+// PostgreSQL 和 MySQL 使用 REPEATABLE READ 隔離級別，SQLite 的事務本身即為一致的讀取
+func (s *Store) Snapshot(ctx context.Context) (*Snapshot, error) {
+	var opts *sql.TxOptions
+	if s.dialect != SQLite {
+		opts = &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+	}
+
+	snapshot := &Snapshot{}
+	err := s.inTxWith(ctx, opts, func(tx *sql.Tx) error {
+		var err error
+		snapshot.Beacons, err = s.queryBeaconsWith(ctx, tx, "SELECT round, randomness, signature, previous_signature FROM drandshuffle_beacons ORDER BY round")
+		if err != nil {
+			return err
+		}
+
+		rows, err := tx.QueryContext(ctx, "SELECT "+sessionColumns+" FROM drandshuffle_sessions ORDER BY created_at, session_id")
+		if err != nil {
+			return fmt.Errorf("無法讀取牌局: %w", err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			session, err := scanSession(rows)
+			if err != nil {
+				return fmt.Errorf("無法讀取牌局: %w", err)
+			}
+			snapshot.Sessions = append(snapshot.Sessions, *session)
+		}
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("無法讀取牌局: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// Restore 在一個事務中寫入快照中的隨機信標和牌局，任何一項失敗時全部回滾
+// 已存在的輪次和遊戲局號保持原樣，不會被快照覆蓋，因此可以重複導入同一個快照；
+// 牌局的驗證狀態和保存時間按快照寫入
+func (s *Store) Restore(ctx context.Context, snapshot *Snapshot) (RestoreResult, error) {
+	var result RestoreResult
+	beaconQuery := s.dialect.bind(s.dialect.insertIgnore("drandshuffle_beacons",
+		"round, randomness, signature, previous_signature", "?, ?, ?, ?"))
+	sessionQuery := s.dialect.bind(s.dialect.insertIgnore("drandshuffle_sessions",
+		sessionColumns, "?, ?, ?, ?, ?, ?, ?"))
+
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		for _, b := range snapshot.Beacons {
+			res, err := tx.ExecContext(ctx, beaconQuery, int64(b.Round), hex.EncodeToString(b.Randomness),
+				hex.EncodeToString(b.Signature), hex.EncodeToString(b.PreviousSignature))
+			if err != nil {
+				return fmt.Errorf("無法保存輪次 %d 的隨機信標: %w", b.Round, err)
+			}
+			result.Beacons += rowsAffected(res)
+		}
+
+		for _, session := range snapshot.Sessions {
+			if err := drandshuffle.ValidateSessionID(session.SessionID); err != nil {
+				return err
+			}
+			if session.Verification == "" {
+				session.Verification = Unverified
+			}
+			if !session.Verification.valid() {
+				return fmt.Errorf("遊戲局號 %s 的驗證狀態 %q 無效", session.SessionID, session.Verification)
+			}
+			var proof sql.NullString
+			if session.Proof != nil {
+				data, err := json.Marshal(session.Proof)
+				if err != nil {
+					return fmt.Errorf("無法編碼遊戲局號 %s 的洗牌證明: %w", session.SessionID, err)
+				}
+				proof = sql.NullString{String: string(data), Valid: true}
+			}
+			res, err := tx.ExecContext(ctx, sessionQuery, session.SessionID, int64(session.Round), drandshuffle.EncodeDeck(session.Deck),
+				proof, session.CreatedAt.UTC(), session.Tenant, string(session.Verification))
+			if err != nil {
+				return fmt.Errorf("無法保存遊戲局號 %s 的牌局: %w", session.SessionID, err)
+			}
+			result.Sessions += rowsAffected(res)
+		}
+		return nil
+	})
+	if err != nil {
+		return RestoreResult{}, err
+	}
+	return result, nil
+}
+
+// rowsAffected 返回語句影響的行數，驅動不支持時視為 1
+func rowsAffected(res sql.Result) int {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 1
+	}
+	return int(n)
+}
@@ -0,0 +1,354 @@
+// Package sqlstore 以 database/sql 在 PostgreSQL、MySQL 或 SQLite 中保存隨機信標和已發出的牌局
+//
+// Store 實現 drandshuffle.BatchBeaconStore，可以傳給 WithBeaconStore，服務重啟時從數據庫恢復信標；
+// 同時保存每個遊戲局號發出的牌組和洗牌證明，供事後查詢和審計。
+// 本包不導入任何驅動，調用者自行導入驅動並打開 *sql.DB，使用前先調用 Migrate 創建表。
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+)
+
+// 哨兵錯誤，可以用 errors.Is 判斷
+var (
+	// ErrSessionNotFound 表示數據庫中沒有該遊戲局號的牌局
+	ErrSessionNotFound = errors.New("sqlstore: session not found")
+	// ErrSessionExists 表示該遊戲局號已經發過牌，同一局號不能保存兩次
+	ErrSessionExists = errors.New("sqlstore: session exists")
+	// ErrInvalidCursor 表示 ListSessions 的分頁游標無法解析
+	ErrInvalidCursor = errors.New("sqlstore: invalid cursor")
+)
+
+// Dialect 是數據庫的 SQL 方言，決定佔位符、類型和忽略重複插入的寫法
+type Dialect int
+
+const (
+	// Postgres 是 PostgreSQL，例如 github.com/jackc/pgx/v5/stdlib 驅動
+	Postgres Dialect = iota
+	// MySQL 是 MySQL 或 MariaDB，例如 github.com/go-sql-driver/mysql 驅動，連接參數須包含 parseTime=true
+	MySQL
+	// SQLite 是 SQLite，適合單機部署和測試
+	SQLite
+)
+
+// String 返回方言名稱
+func (d Dialect) String() string {
+	switch d {
+	case Postgres:
+		return "postgres"
+	case MySQL:
+		return "mysql"
+	case SQLite:
+		return "sqlite"
+	default:
+		return fmt.Sprintf("Dialect(%d)", int(d))
+	}
+}
+
+// bind 將查詢中的 ? 佔位符替換為方言的寫法，PostgreSQL 使用 $1、$2 等
+func (d Dialect) bind(query string) string {
+	if d != Postgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// insertIgnore 返回插入時忽略主鍵衝突的語句
+func (d Dialect) insertIgnore(table, columns, values string) string {
+	switch d {
+	case MySQL:
+		return fmt.Sprintf("INSERT IGNORE INTO %s (%s) VALUES (%s)", table, columns, values)
+	case SQLite:
+		return fmt.Sprintf("INSERT OR IGNORE INTO %s (%s) VALUES (%s)", table, columns, values)
+	default:
+		return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT DO NOTHING", table, columns, values)
+	}
+}
+
+// Store 在 SQL 數據庫中保存隨機信標和已發出的牌局，可以並發使用
+type Store struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+// 確保 Store 可以作為 BeaconStore 使用
+var _ drandshuffle.BatchBeaconStore = (*Store)(nil)
+
+// New 創建使用 db 的 Store，Store 不負責關閉 db
+func New(db *sql.DB, dialect Dialect) *Store {
+	return &Store{db: db, dialect: dialect}
+}
+
+// Load 讀取所有已保存的隨機信標，按輪次排序
+func (s *Store) Load() ([]drandshuffle.Beacon, error) {
+	return s.queryBeacons(context.Background(), "SELECT round, randomness, signature, previous_signature FROM drandshuffle_beacons ORDER BY round")
+}
+
+// queryBeacons 執行返回 round, randomness, signature, previous_signature 列的查詢並解析隨機信標
+func (s *Store) queryBeacons(ctx context.Context, query string, args ...any) ([]drandshuffle.Beacon, error) {
+	return s.queryBeaconsWith(ctx, s.db, query, args...)
+}
+
+// querier 是 *sql.DB 和 *sql.Tx 共有的查詢方法
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// queryBeaconsWith 與 queryBeacons 相同，但使用 q 執行查詢，例如在事務中讀取
+func (s *Store) queryBeaconsWith(ctx context.Context, q querier, query string, args ...any) ([]drandshuffle.Beacon, error) {
+	rows, err := q.QueryContext(ctx, s.dialect.bind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("無法讀取隨機信標: %w", err)
+	}
+	defer rows.Close()
+
+	var beacons []drandshuffle.Beacon
+	for rows.Next() {
+		var round int64
+		var randomness, signature, previous string
+		if err := rows.Scan(&round, &randomness, &signature, &previous); err != nil {
+			return nil, fmt.Errorf("無法讀取隨機信標: %w", err)
+		}
+		beacon := drandshuffle.Beacon{Round: uint64(round)}
+		for _, f := range []struct {
+			dst *[]byte
+			src string
+		}{{&beacon.Randomness, randomness}, {&beacon.Signature, signature}, {&beacon.PreviousSignature, previous}} {
+			if *f.dst, err = hex.DecodeString(f.src); err != nil {
+				return nil, fmt.Errorf("無法解析輪次 %d 的隨機信標: %w", round, err)
+			}
+		}
+		beacons = append(beacons, beacon)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("無法讀取隨機信標: %w", err)
+	}
+	return beacons, nil
+}
+
+// Save 保存一個隨機信標，已保存的輪次會被忽略
+func (s *Store) Save(beacon drandshuffle.Beacon) error {
+	return s.SaveBatch([]drandshuffle.Beacon{beacon})
+}
+
+// SaveBatch 在一個事務中保存多個隨機信標，已保存的輪次會被忽略
+func (s *Store) SaveBatch(beacons []drandshuffle.Beacon) error {
+	if len(beacons) == 0 {
+		return nil
+	}
+	query := s.dialect.bind(s.dialect.insertIgnore("drandshuffle_beacons",
+		"round, randomness, signature, previous_signature", "?, ?, ?, ?"))
+
+	return s.inTx(context.Background(), func(tx *sql.Tx) error {
+		stmt, err := tx.Prepare(query)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		for _, b := range beacons {
+			if _, err := stmt.Exec(int64(b.Round), hex.EncodeToString(b.Randomness),
+				hex.EncodeToString(b.Signature), hex.EncodeToString(b.PreviousSignature)); err != nil {
+				return fmt.Errorf("無法保存輪次 %d 的隨機信標: %w", b.Round, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Session 是一個遊戲局號發出的牌局
+type Session struct {
+	SessionID string                     // 遊戲局號
+	Round     uint64                     // 使用的輪次號碼
+	Deck      []drandshuffle.Card        // 洗好的牌組
+	Proof     *drandshuffle.ShuffleProof // 洗牌證明，沒有時為 nil
+	CreatedAt time.Time                  // 保存的時間
+	Tenant    string                     // 牌局所屬的租戶，單租戶部署時為空
+	// Verification 是牌局的驗證狀態，保存時為空表示 Unverified
+	Verification Verification
+}
+
+// Verification 是牌局的驗證狀態
+type Verification string
+
+const (
+	// Unverified 表示牌局尚未驗證
+	Unverified Verification = "unverified"
+	// Verified 表示牌組已通過洗牌證明的驗證
+	Verified Verification = "verified"
+	// VerificationFailed 表示牌組與洗牌證明不符，或牌局沒有證明
+	VerificationFailed Verification = "failed"
+)
+
+// valid 報告驗證狀態是否為已定義的值
+func (v Verification) valid() bool {
+	return v == Unverified || v == Verified || v == VerificationFailed
+}
+
+// SaveSession 保存一局已發出的牌，CreatedAt 為零值時使用當前時間
+// 同一遊戲局號只能保存一次，重複保存時返回 ErrSessionExists，防止同一局號被重新發牌
+func (s *Store) SaveSession(ctx context.Context, session Session) error {
+	if err := drandshuffle.ValidateSessionID(session.SessionID); err != nil {
+		return err
+	}
+	if session.Verification == "" {
+		session.Verification = Unverified
+	}
+	if !session.Verification.valid() {
+		return fmt.Errorf("無效的驗證狀態 %q", session.Verification)
+	}
+	if len(session.Tenant) > 128 {
+		return fmt.Errorf("租戶名稱超過 128 字節")
+	}
+	var proof sql.NullString
+	if session.Proof != nil {
+		data, err := json.Marshal(session.Proof)
+		if err != nil {
+			return fmt.Errorf("無法編碼洗牌證明: %w", err)
+		}
+		proof = sql.NullString{String: string(data), Valid: true}
+	}
+	if session.CreatedAt.IsZero() {
+		session.CreatedAt = time.Now()
+	}
+
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		var exists int
+		err := tx.QueryRowContext(ctx, s.dialect.bind("SELECT 1 FROM drandshuffle_sessions WHERE session_id = ?"), session.SessionID).Scan(&exists)
+		switch {
+		case err == nil:
+			return fmt.Errorf("%w: %s", ErrSessionExists, session.SessionID)
+		case !errors.Is(err, sql.ErrNoRows):
+			return fmt.Errorf("無法查詢遊戲局號 %s: %w", session.SessionID, err)
+		}
+
+		_, err = tx.ExecContext(ctx, s.dialect.bind("INSERT INTO drandshuffle_sessions (session_id, round, deck, proof, created_at, tenant, verification) VALUES (?, ?, ?, ?, ?, ?, ?)"),
+			session.SessionID, int64(session.Round), drandshuffle.EncodeDeck(session.Deck), proof, session.CreatedAt.UTC(), session.Tenant, string(session.Verification))
+		if err != nil {
+			return fmt.Errorf("無法保存遊戲局號 %s 的牌局: %w", session.SessionID, err)
+		}
+		return nil
+	})
+}
+
+// LoadSession 讀取遊戲局號的牌局，沒有時返回 ErrSessionNotFound
+func (s *Store) LoadSession(ctx context.Context, sessionID string) (*Session, error) {
+	row := s.db.QueryRowContext(ctx, s.dialect.bind("SELECT "+sessionColumns+" FROM drandshuffle_sessions WHERE session_id = ?"), sessionID)
+	session, err := scanSession(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, sessionID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("無法讀取遊戲局號 %s 的牌局: %w", sessionID, err)
+	}
+	return session, nil
+}
+
+// SetVerification 更新牌局的驗證狀態，沒有該遊戲局號時返回 ErrSessionNotFound
+func (s *Store) SetVerification(ctx context.Context, sessionID string, status Verification) error {
+	if !status.valid() {
+		return fmt.Errorf("無效的驗證狀態 %q", status)
+	}
+	res, err := s.db.ExecContext(ctx, s.dialect.bind("UPDATE drandshuffle_sessions SET verification = ? WHERE session_id = ?"), string(status), sessionID)
+	if err != nil {
+		return fmt.Errorf("無法更新遊戲局號 %s 的驗證狀態: %w", sessionID, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("%w: %s", ErrSessionNotFound, sessionID)
+	}
+	return nil
+}
+
+// VerifySession 以洗牌證明重新洗牌核對牌局的牌組，並記錄驗證狀態
+// 只核對牌組，不驗證隨機信標的簽名；牌局沒有證明或核對失敗時記錄 VerificationFailed 並返回錯誤
+func (s *Store) VerifySession(ctx context.Context, sessionID string, template *drandshuffle.DeckTemplate) error {
+	session, err := s.LoadSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	verifyErr := fmt.Errorf("遊戲局號 %s 沒有洗牌證明", sessionID)
+	if session.Proof != nil {
+		verifyErr = drandshuffle.VerifyProof(session.Proof, template, session.Deck)
+	}
+	status := Verified
+	if verifyErr != nil {
+		status = VerificationFailed
+	}
+	if err := s.SetVerification(ctx, sessionID, status); err != nil {
+		return err
+	}
+	return verifyErr
+}
+
+// sessionColumns 是 scanSession 按順序讀取的列
+const sessionColumns = "session_id, round, deck, proof, created_at, tenant, verification"
+
+// rowScanner 是 *sql.Row 和 *sql.Rows 共有的 Scan 方法
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanSession 讀取一行 sessionColumns 並解析牌組和證明
+func scanSession(row rowScanner) (*Session, error) {
+	var round int64
+	var deck, verification string
+	var proof sql.NullString
+	session := &Session{}
+	if err := row.Scan(&session.SessionID, &round, &deck, &proof, &session.CreatedAt, &session.Tenant, &verification); err != nil {
+		return nil, err
+	}
+
+	session.Round = uint64(round)
+	session.CreatedAt = session.CreatedAt.UTC()
+	session.Verification = Verification(verification)
+	var err error
+	if session.Deck, err = drandshuffle.DecodeDeck(deck); err != nil {
+		return nil, fmt.Errorf("無法解析遊戲局號 %s 的牌組: %w", session.SessionID, err)
+	}
+	if proof.Valid {
+		session.Proof = new(drandshuffle.ShuffleProof)
+		if err := json.Unmarshal([]byte(proof.String), session.Proof); err != nil {
+			return nil, fmt.Errorf("無法解析遊戲局號 %s 的洗牌證明: %w", session.SessionID, err)
+		}
+	}
+	return session, nil
+}
+
+// inTx 在事務中執行 fn，fn 返回錯誤時回滾
+func (s *Store) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	return s.inTxWith(ctx, nil, fn)
+}
+
+// inTxWith 以指定的事務選項執行 fn，fn 返回錯誤時回滾
+func (s *Store) inTxWith(ctx context.Context, opts *sql.TxOptions, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("無法開始事務: %w", err)
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("無法提交事務: %w", err)
+	}
+	return nil
+}
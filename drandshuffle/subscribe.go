@@ -0,0 +1,64 @@
+package drandshuffle
+
+import (
+	"context"
+
+	"github.com/drand/go-clients/drand"
+)
+
+// Subscribe 返回接收新隨機信標的通道，ctx 取消或 DrandManager 關閉時通道關閉
+// 信標由後台獲取或 GetLatestRandomnessWithin 等刷新最新信標的操作發布，需要持續接收時應先調用 StartBackgroundFetching
+// 通道只緩衝一個信標，接收方跟不上時會跳過輪次，可以用 GetRandomnessRange 補齊
+func (dm *DrandManager) Subscribe(ctx context.Context) <-chan Beacon {
+	ch := make(chan Beacon, 1)
+
+	dm.subscriberMutex.Lock()
+	select {
+	case <-dm.closed:
+		dm.subscriberMutex.Unlock()
+		close(ch)
+		return ch
+	default:
+	}
+	dm.subscribers[ch] = struct{}{}
+	dm.subscriberMutex.Unlock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-dm.closed:
+		}
+		dm.subscriberMutex.Lock()
+		defer dm.subscriberMutex.Unlock()
+		if _, ok := dm.subscribers[ch]; ok {
+			delete(dm.subscribers, ch)
+			close(ch)
+		}
+	}()
+
+	return ch
+}
+
+// publish 將新的最新信標發送給所有訂閱者，不等待接收方
+func (dm *DrandManager) publish(result drand.Result) {
+	dm.subscriberMutex.Lock()
+	defer dm.subscriberMutex.Unlock()
+
+	// 每個訂閱者得到獨立的副本
+	for ch := range dm.subscribers {
+		select {
+		case ch <- newBeacon(result):
+		default:
+		}
+	}
+}
+
+// closeSubscribers 關閉所有訂閱者的通道
+func (dm *DrandManager) closeSubscribers() {
+	dm.subscriberMutex.Lock()
+	defer dm.subscriberMutex.Unlock()
+	for ch := range dm.subscribers {
+		delete(dm.subscribers, ch)
+		close(ch)
+	}
+}
@@ -0,0 +1,54 @@
+package drandshuffle
+
+import "fmt"
+
+// teamsDomain 是 SplitTeams 派生種子時使用的域分隔前綴，使同一種子的分隊與選圖、平局排序等結果互不相關
+const teamsDomain = "drandshuffle/teams/v1"
+
+// TeamSplit 是 SplitTeams 的結果
+type TeamSplit struct {
+	Teams     [][]string `json:"teams"`      // 按 teamSizes 的順序排列的各隊玩家
+	PickOrder []int      `json:"pick_order"` // 隊伍的選邊或選圖順序，元素為 Teams 的索引
+}
+
+// SplitTeams 以種子將玩家隨機分成 len(teamSizes) 隊，並隨機排列各隊的選邊順序
+// 以 NewDRBG(SHA256("drandshuffle/teams/v1" || seed)) 先對 playerIDs 的副本做與 ShuffleSlice 相同的 Fisher-Yates 洗牌，按 teamSizes 依次切成各隊；
+// 再以同一個 DRBG 用相同的算法打亂 [0, len(teamSizes)) 作為 PickOrder，參賽者取得種子即可重新計算分隊結果。
+// teamSizes 的每一項必須大於 0 且總和等於玩家數量，玩家 ID 不能重複，否則返回包裝 ErrInvalidConfig 的輸入錯誤
+func SplitTeams(playerIDs []string, teamSizes []int, seed []byte) (*TeamSplit, error) {
+	if len(teamSizes) == 0 {
+		return nil, NewInputError(fmt.Errorf("%w: 至少需要一隊", ErrInvalidConfig))
+	}
+	total := 0
+	for i, size := range teamSizes {
+		if size < 1 {
+			return nil, NewInputError(fmt.Errorf("%w: 第 %d 隊的人數 %d 必須大於 0", ErrInvalidConfig, i, size))
+		}
+		total += size
+	}
+	if total != len(playerIDs) {
+		return nil, NewInputError(fmt.Errorf("%w: 各隊人數之和 %d 與玩家數量 %d 不符", ErrInvalidConfig, total, len(playerIDs)))
+	}
+	seen := make(map[string]bool, len(playerIDs))
+	for _, id := range playerIDs {
+		if seen[id] {
+			return nil, NewInputError(fmt.Errorf("%w: 玩家 %s 重複", ErrInvalidConfig, id))
+		}
+		seen[id] = true
+	}
+
+	drbg := NewDRBG(domainSeed(teamsDomain, seed))
+	players := append([]string(nil), playerIDs...)
+	shuffleWith(drbg, players)
+	order := make([]int, len(teamSizes))
+	for i := range order {
+		order[i] = i
+	}
+	shuffleWith(drbg, order)
+
+	split := &TeamSplit{Teams: make([][]string, len(teamSizes)), PickOrder: order}
+	for i, size := range teamSizes {
+		split.Teams[i], players = players[:size:size], players[size:]
+	}
+	return split, nil
+}
@@ -0,0 +1,16 @@
+package drandshuffle
+
+import "slices"
+
+// tieBreakDomain 是 TieBreak 派生種子時使用的域分隔前綴，使同一種子的平局排序與洗牌、分隊等結果互不相關
+const tieBreakDomain = "drandshuffle/tiebreak/v1"
+
+// TieBreak 以種子為平局的候選者決定一個可驗證的全序，例如平分底池時零頭的歸屬、先行動的玩家或同分得獎者的名次
+// 先把候選者按字節序排序，再以 NewDRBG(SHA256("drandshuffle/tiebreak/v1" || seed)) 做與 ShuffleSlice 相同的 Fisher-Yates 洗牌，
+// 因此結果只取決於候選者的集合和種子，與調用者列出候選者的順序無關，任何人取得種子和候選者即可重新計算。返回新的切片，不修改 candidates；重複的候選者各自佔一個位置
+func TieBreak(candidates []string, seed []byte) []string {
+	order := slices.Clone(candidates)
+	slices.Sort(order)
+	shuffleWith(NewDRBG(domainSeed(tieBreakDomain, seed)), order)
+	return order
+}
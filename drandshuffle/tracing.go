@@ -0,0 +1,102 @@
+package drandshuffle
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName 是本包創建 Tracer 時使用的儀表庫名稱
+const tracerName = "github.com/coseto6125/DrandShuffle/drandshuffle"
+
+// span 名稱，與 runtime/trace 的區域名稱分開，便於在分佈式追蹤中按操作篩選
+const (
+	spanFetchLatest      = "drandshuffle.fetch_latest"
+	spanFetchRound       = "drandshuffle.fetch_round"
+	spanCacheLookup      = "drandshuffle.cache_lookup"
+	spanShuffle          = "drandshuffle.shuffle"
+	spanDeriveSeed       = "drandshuffle.derive_seed"
+	spanPermute          = "drandshuffle.permute"
+	spanMixContributions = "drandshuffle.mix_contributions"
+)
+
+// span 屬性的鍵
+var (
+	attrRound     = attribute.Key("drandshuffle.round")
+	attrSessionID = attribute.Key("drandshuffle.session_id")
+	attrDeckSize  = attribute.Key("drandshuffle.deck_size")
+	attrCache     = attribute.Key("drandshuffle.cache")
+	attrCacheHit  = attribute.Key("drandshuffle.cache_hit")
+	attrHedged    = attribute.Key("drandshuffle.hedged")
+	attrCoalesced = attribute.Key("drandshuffle.coalesced")
+	attrCategory  = attribute.Key("drandshuffle.error_category")
+)
+
+// nonRecordingSpan 是未設定 TracerProvider 時返回的 span，所有方法都不做任何事
+var nonRecordingSpan = trace.SpanFromContext(context.Background())
+
+// WithTracerProvider 設定記錄 OpenTelemetry span 的 TracerProvider，默認不記錄
+// 獲取最新信標、獲取指定輪次、緩存查找、種子派生和洗牌都會記錄為 span，
+// 可以在分佈式追蹤中看到發牌延遲花在哪一步；span 以調用者 ctx 中的 span 為父節點
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(dm *DrandManager) {
+		if tp == nil {
+			dm.tracer = nil
+			return
+		}
+		dm.tracer = tp.Tracer(tracerName)
+	}
+}
+
+// startSpan 開始一個 span，未設定 TracerProvider 時不修改 ctx，也不分配記憶體
+func (dm *DrandManager) startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if dm.tracer == nil {
+		return ctx, nonRecordingSpan
+	}
+	return dm.tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endSpan 結束 span，err 不為 nil 時記錄錯誤和錯誤類別
+func endSpan(span trace.Span, err error) {
+	if err != nil && span.IsRecording() {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		span.SetAttributes(attrCategory.String(Category(err).String()))
+	}
+	span.End()
+}
+
+// lookupShuffleCache 在洗牌結果緩存中查找，並記錄緩存查找的 span
+func (dm *DrandManager) lookupShuffleCache(ctx context.Context, key ShuffleKey) ([]Card, bool) {
+	_, span := dm.startSpan(ctx, spanCacheLookup, attrCache.String("shuffle"), attrRound.Int64(int64(key.Round)))
+	deck, ok := dm.shuffleCache.Get(key)
+	span.SetAttributes(attrCacheHit.Bool(ok))
+	span.End()
+	return deck, ok
+}
+
+// shuffle 使用洗牌器結合隨機性和遊戲局號洗牌，並分別記錄種子派生和置換的 span
+func (dm *DrandManager) shuffle(ctx context.Context, s *Shuffler, round uint64, randomness []byte, gameSessionID string) []Card {
+	if dm.tracer == nil {
+		return s.Shuffle(randomness, gameSessionID)
+	}
+
+	ctx, span := dm.startSpan(ctx, spanShuffle,
+		attrRound.Int64(int64(round)),
+		attrSessionID.String(gameSessionID),
+		attrDeckSize.Int(s.template.Len()))
+	defer span.End()
+
+	return s.shuffleInto(nil, func(state *shuffleState) []byte {
+		_, derive := dm.startSpan(ctx, spanDeriveSeed)
+		seed := state.extend(randomness, gameSessionID)
+		derive.End()
+		return seed
+	}, func(deck []Card, seed []byte) {
+		_, permute := dm.startSpan(ctx, spanPermute)
+		shuffleInPlace(deck, seed)
+		permute.End()
+	})
+}
@@ -0,0 +1,221 @@
+package drandshuffle
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// DefaultUsageHistory 是 UsageMeter 默認為每個租戶保留逐輪用量的輪次數量，quicknet 上約為 50 分鐘
+const DefaultUsageHistory = 1000
+
+// tenantKey 是 context 中保存租戶的鍵
+type tenantKey struct{}
+
+// ContextWithTenant 返回帶有租戶標識的 context
+// 多租戶服務應在完成認證的中間件中調用，之後以該 context 洗牌或派生隨機數時，用量計入此租戶
+func ContextWithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext 返回 ContextWithTenant 設定的租戶，沒有設定時返回空字符串
+func TenantFromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+// QuotaExceededError 表示租戶在該輪次的派生次數已達配額，可以用 errors.Is(err, ErrQuotaExceeded) 判斷
+// 配額按輪次計算，換用之後的輪次即可繼續
+type QuotaExceededError struct {
+	Tenant string
+	Round  uint64
+	Limit  int
+}
+
+// Error 返回錯誤說明
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("%v: 租戶 %q 在輪次 %d 已派生 %d 次，達到配額", ErrQuotaExceeded, e.Tenant, e.Round, e.Limit)
+}
+
+// Is 使 errors.Is(err, ErrQuotaExceeded) 成立
+func (e *QuotaExceededError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+// RoundUsage 是租戶在一個輪次的派生次數
+type RoundUsage struct {
+	Round uint64 `json:"round"`
+	Count int    `json:"count"`
+}
+
+// TenantUsage 是一個租戶的用量快照
+type TenantUsage struct {
+	Tenant      string       `json:"tenant"`        // 租戶標識，沒有設定租戶的調用為空字符串
+	Total       uint64       `json:"total"`         // 累計的派生次數，ResetTotals 時歸零
+	Rejected    uint64       `json:"rejected"`      // 因超出配額被拒絕的次數，ResetTotals 時歸零
+	MaxPerRound int          `json:"max_per_round"` // 保留的輪次中單輪的最多派生次數，明顯偏高可能是在反覆嘗試同一輪次
+	LastRound   uint64       `json:"last_round"`    // 派生過的最大輪次
+	Quota       int          `json:"quota"`         // 每輪的配額，0 表示不限
+	Rounds      []RoundUsage `json:"rounds"`        // 最近保留的各輪次用量，按輪次排序
+}
+
+// UsageOption 設定 UsageMeter 的參數
+type UsageOption func(*UsageMeter)
+
+// WithRoundQuota 設定每個租戶在每個輪次最多的派生次數，默認為 0 即不限
+func WithRoundQuota(n int) UsageOption {
+	return func(m *UsageMeter) {
+		m.quota = max(0, n)
+	}
+}
+
+// WithTenantQuota 為指定租戶設定每輪的配額，優先於 WithRoundQuota；n 為 0 時該租戶不限
+func WithTenantQuota(tenant string, n int) UsageOption {
+	return func(m *UsageMeter) {
+		m.overrides[tenant] = max(0, n)
+	}
+}
+
+// WithUsageHistory 設定每個租戶保留逐輪用量的輪次數量，默認為 DefaultUsageHistory
+func WithUsageHistory(rounds int) UsageOption {
+	return func(m *UsageMeter) {
+		m.history = rounds
+	}
+}
+
+// UsageMeter 按租戶和輪次統計洗牌和隨機數的派生次數，並可以限制每輪的派生次數，用於按用量計費和發現濫用
+// 以 WithUsageMeter 設定後，ShuffleBuilder.Do、NewRandForSession、ShuffleLatest、ShuffleAtRound 等派生隨機性的調用
+// 都按 TenantFromContext 的租戶計數，驗證類的調用不計數；其他派生方式可以直接調用 Charge。
+// 每個租戶只保留最近若干輪次的逐輪用量，設定了配額時早於保留範圍的輪次一律拒絕，以免繞過配額。可以並發使用
+type UsageMeter struct {
+	quota     int
+	overrides map[string]int
+	history   int
+
+	mu      sync.Mutex
+	tenants map[string]*tenantUsage
+}
+
+// tenantUsage 是一個租戶的累計用量
+type tenantUsage struct {
+	total    uint64
+	rejected uint64
+	last     uint64
+	rounds   map[uint64]int
+}
+
+// NewUsageMeter 創建 UsageMeter
+func NewUsageMeter(opts ...UsageOption) *UsageMeter {
+	m := &UsageMeter{overrides: make(map[string]int), history: DefaultUsageHistory, tenants: make(map[string]*tenantUsage)}
+	for _, opt := range opts {
+		opt(m)
+	}
+	m.history = max(1, m.history)
+	return m
+}
+
+// Charge 為租戶在輪次記錄一次派生，已達配額時不記錄，返回包裝 *QuotaExceededError 的輸入錯誤
+func (m *UsageMeter) Charge(tenant string, round uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	u := m.tenants[tenant]
+	if u == nil {
+		u = &tenantUsage{rounds: make(map[uint64]int)}
+		m.tenants[tenant] = u
+	}
+
+	limit := m.limit(tenant)
+	expired := u.last >= uint64(m.history) && round <= u.last-uint64(m.history)
+	if limit > 0 && (expired || u.rounds[round] >= limit) {
+		u.rejected++
+		return NewInputError(&QuotaExceededError{Tenant: tenant, Round: round, Limit: limit})
+	}
+
+	u.total++
+	if expired {
+		return nil
+	}
+	u.rounds[round]++
+	if round > u.last {
+		u.last = round
+		if u.last >= uint64(m.history) {
+			for r := range u.rounds {
+				if r <= u.last-uint64(m.history) {
+					delete(u.rounds, r)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Usage 返回租戶的用量快照，沒有用量時 Total 為 0
+func (m *UsageMeter) Usage(tenant string) TenantUsage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.snapshot(tenant)
+}
+
+// Snapshot 返回所有租戶的用量快照，按租戶排序
+func (m *UsageMeter) Snapshot() []TenantUsage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.snapshotAll()
+}
+
+// ResetTotals 返回所有租戶的用量快照，並將累計的 Total 和 Rejected 歸零，用於按計費週期結算
+// 逐輪的用量保留，配額不受影響
+func (m *UsageMeter) ResetTotals() []TenantUsage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	usage := m.snapshotAll()
+	for _, u := range m.tenants {
+		u.total, u.rejected = 0, 0
+	}
+	return usage
+}
+
+// limit 返回租戶每輪的配額，調用時須持有 mu
+func (m *UsageMeter) limit(tenant string) int {
+	if n, ok := m.overrides[tenant]; ok {
+		return n
+	}
+	return m.quota
+}
+
+// snapshotAll 返回所有租戶的用量快照，調用時須持有 mu
+func (m *UsageMeter) snapshotAll() []TenantUsage {
+	usage := make([]TenantUsage, 0, len(m.tenants))
+	for tenant := range m.tenants {
+		usage = append(usage, m.snapshot(tenant))
+	}
+	slices.SortFunc(usage, func(a, b TenantUsage) int { return strings.Compare(a.Tenant, b.Tenant) })
+	return usage
+}
+
+// snapshot 返回租戶的用量快照，調用時須持有 mu
+func (m *UsageMeter) snapshot(tenant string) TenantUsage {
+	usage := TenantUsage{Tenant: tenant, Quota: m.limit(tenant), Rounds: []RoundUsage{}}
+	u := m.tenants[tenant]
+	if u == nil {
+		return usage
+	}
+	usage.Total, usage.Rejected, usage.LastRound = u.total, u.rejected, u.last
+	for round, count := range u.rounds {
+		usage.Rounds = append(usage.Rounds, RoundUsage{Round: round, Count: count})
+		usage.MaxPerRound = max(usage.MaxPerRound, count)
+	}
+	slices.SortFunc(usage.Rounds, func(a, b RoundUsage) int { return cmp.Compare(a.Round, b.Round) })
+	return usage
+}
+
+// chargeUsage 為 ctx 的租戶在輪次記錄一次派生，沒有設定 UsageMeter 時不做任何事
+func (dm *DrandManager) chargeUsage(ctx context.Context, round uint64) error {
+	if dm.usage == nil {
+		return nil
+	}
+	return dm.usage.Charge(TenantFromContext(ctx), round)
+}
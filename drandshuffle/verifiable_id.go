@@ -0,0 +1,149 @@
+package drandshuffle
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// VerifiableIDAlgorithm 是 VerifiableID 記錄的派生算法標識
+const VerifiableIDAlgorithm = "drandshuffle/id/v1"
+
+// verifiableIDDomain 是派生 ID 時使用的域分隔前綴，使 ID 與同一隨機性派生的種子互不相同
+const verifiableIDDomain = "drandshuffle/id/v1"
+
+// VerifiableID 是由隨機信標派生的 ID 及其派生所需的全部輸入
+// 輪次的隨機性在輪次發布前無法預知，因此 ID 證明自己不早於該輪次生成；審計方用 VerifyVerifiableID 重新計算
+type VerifiableID struct {
+	ID                string    `json:"id"` // RFC 9562 版本 8 的 UUID 文本形式
+	Algorithm         string    `json:"algorithm"`
+	ChainHash         string    `json:"chain_hash"`
+	Round             uint64    `json:"round"`
+	RoundTime         time.Time `json:"round_time"`
+	Randomness        []byte    `json:"randomness"`
+	Signature         []byte    `json:"signature,omitempty"`
+	PreviousSignature []byte    `json:"previous_signature,omitempty"`
+	Namespace         string    `json:"namespace"`
+	Counter           uint64    `json:"counter"`
+	Provider          string    `json:"provider,omitempty"` // 隨機性來源的標識，為空時表示 drand，見 BeaconProvider
+}
+
+// NewVerifiableID 使用默認 Client 派生 ID，見 Client.NewVerifiableID
+func NewVerifiableID(ctx context.Context, round uint64, namespace string, counter uint64) (*VerifiableID, error) {
+	c, err := DefaultClient()
+	if err != nil {
+		return nil, err
+	}
+	return c.NewVerifiableID(ctx, round, namespace, counter)
+}
+
+// NewVerifiableID 從指定輪次的隨機信標、命名空間和計數器派生 UUID 格式的 ID，可以用作訂單號、會話令牌或遊戲局號
+// ID 取 SHA256("drandshuffle/id/v1" || uint64_be(len(namespace)) || namespace || uint64_be(counter) || 隨機性) 的前 16 字節，
+// 再按 RFC 9562 設定版本 8 和變體位元，保留 122 位元的哈希，同一輪次內命名空間或計數器不同的 ID 不會碰撞。
+// ID 由公開的隨機性決定，任何知道輪次、命名空間和計數器的人都能算出，不能單獨作為保密的令牌使用。
+// 命名空間的規則與遊戲局號相同，無效時返回包裝 ErrInvalidConfig 的輸入錯誤；round 為 Latest 時使用最新的隨機信標（嚴格輪次模式下不允許）
+func (c *Client) NewVerifiableID(ctx context.Context, round uint64, namespace string, counter uint64) (*VerifiableID, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := validateIDNamespace(namespace); err != nil {
+		return nil, err
+	}
+
+	b := &ShuffleBuilder{client: c, round: round}
+	beacon, err := b.beacon(ctx, c.manager)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.manager.chargeUsage(ctx, beacon.Round); err != nil {
+		return nil, err
+	}
+
+	id := &VerifiableID{
+		ID:                verifiableID(beacon.Randomness, namespace, counter),
+		Algorithm:         VerifiableIDAlgorithm,
+		ChainHash:         c.manager.config.ChainHash,
+		Round:             beacon.Round,
+		Randomness:        beacon.Randomness,
+		Signature:         beacon.Signature,
+		PreviousSignature: beacon.PreviousSignature,
+		Namespace:         namespace,
+		Counter:           counter,
+		Provider:          c.manager.provider,
+	}
+	id.RoundTime, _ = c.manager.RoundTime(beacon.Round)
+	return id, nil
+}
+
+// validateIDNamespace 檢查 ID 的命名空間，規則與 ValidateSessionID 相同
+func validateIDNamespace(namespace string) error {
+	if namespace == "" {
+		return NewInputError(fmt.Errorf("%w: ID 的命名空間為空", ErrInvalidConfig))
+	}
+	if len(namespace) > MaxSessionIDLength {
+		return NewInputError(fmt.Errorf("%w: ID 的命名空間長度 %d 超過上限 %d", ErrInvalidConfig, len(namespace), MaxSessionIDLength))
+	}
+	if i := invalidSessionIDChar(namespace); i >= 0 {
+		return NewInputError(fmt.Errorf("%w: ID 的命名空間第 %d 個字節 %q 不是允許的字符", ErrInvalidConfig, i+1, namespace[i]))
+	}
+	return nil
+}
+
+// verifiableID 從隨機性、命名空間和計數器派生版本 8 的 UUID
+func verifiableID(randomness []byte, namespace string, counter uint64) string {
+	h := sha256.New()
+	h.Write([]byte(verifiableIDDomain))
+	h.Write(binary.BigEndian.AppendUint64(nil, uint64(len(namespace))))
+	h.Write([]byte(namespace))
+	h.Write(binary.BigEndian.AppendUint64(nil, counter))
+	h.Write(randomness)
+	sum := h.Sum(nil)
+
+	var u [16]byte
+	copy(u[:], sum)
+	u[6] = u[6]&0x0f | 0x80 // 版本 8
+	u[8] = u[8]&0x3f | 0x80 // RFC 9562 變體
+	var out [36]byte
+	hex.Encode(out[0:8], u[0:4])
+	out[8] = '-'
+	hex.Encode(out[9:13], u[4:6])
+	out[13] = '-'
+	hex.Encode(out[14:18], u[6:8])
+	out[18] = '-'
+	hex.Encode(out[19:23], u[8:10])
+	out[23] = '-'
+	hex.Encode(out[24:], u[10:])
+	return string(out[:])
+}
+
+// VerifyVerifiableID 根據記錄的隨機性、命名空間和計數器重新派生 ID，檢查是否與記錄的 ID 一致
+// 不一致時返回包裝 ErrSeedMismatch 的驗證錯誤。只檢查派生；隨機信標本身的簽名應另外用 Verifier 驗證，見 VerifiableID.Beacon
+func VerifyVerifiableID(id *VerifiableID) error {
+	if id == nil {
+		return NewInputError(fmt.Errorf("缺少 ID 記錄"))
+	}
+	if id.Algorithm != VerifiableIDAlgorithm {
+		return NewInputError(fmt.Errorf("不支持的 ID 派生算法 %q", id.Algorithm))
+	}
+	if err := validateIDNamespace(id.Namespace); err != nil {
+		return err
+	}
+	if want := verifiableID(id.Randomness, id.Namespace, id.Counter); want != id.ID {
+		return NewVerificationError(fmt.Errorf("%w: 輪次 %d、命名空間 %s 和計數器 %d 派生的 ID 為 %s，記錄中為 %s",
+			ErrSeedMismatch, id.Round, id.Namespace, id.Counter, want, id.ID))
+	}
+	return nil
+}
+
+// Beacon 返回記錄中的隨機信標，可以交給 Verifier 驗證簽名
+func (id *VerifiableID) Beacon() Beacon {
+	return Beacon{
+		Round:             id.Round,
+		Randomness:        copyBytes(id.Randomness),
+		Signature:         copyBytes(id.Signature),
+		PreviousSignature: copyBytes(id.PreviousSignature),
+	}
+}
@@ -0,0 +1,111 @@
+package drandshuffle
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/drand/drand/v2/common/chain"
+	"github.com/drand/drand/v2/crypto"
+)
+
+// Verifier 使用鏈的公鑰驗證隨機信標
+// BLS 配對運算是審計時的主要開銷，VerifyBatch 會把信標分配給多個工作協程並行驗證
+type Verifier struct {
+	info    *chain.Info
+	scheme  *crypto.Scheme
+	workers int
+}
+
+// VerifierOption 是 Verifier 的配置選項
+type VerifierOption func(*Verifier)
+
+// WithVerifyWorkers 設定 VerifyBatch 的工作協程數量
+// 默認為 runtime.GOMAXPROCS(0)，即未限制時等於 CPU 核心數
+func WithVerifyWorkers(n int) VerifierOption {
+	return func(v *Verifier) {
+		v.workers = n
+	}
+}
+
+// NewVerifier 創建使用指定鏈信息驗證隨機信標的 Verifier
+func NewVerifier(info *chain.Info, opts ...VerifierOption) (*Verifier, error) {
+	if info == nil || info.PublicKey == nil {
+		return nil, NewInputError(fmt.Errorf("缺少鏈信息或公鑰"))
+	}
+	scheme, err := crypto.SchemeFromName(info.Scheme)
+	if err != nil {
+		return nil, NewInputError(fmt.Errorf("不支持的簽名方案 %q: %w", info.Scheme, err))
+	}
+
+	v := &Verifier{info: info, scheme: scheme, workers: runtime.GOMAXPROCS(0)}
+	for _, opt := range opts {
+		opt(v)
+	}
+	if v.workers < 1 {
+		v.workers = 1
+	}
+	return v, nil
+}
+
+// Verify 驗證單個隨機信標：簽名必須能以鏈的公鑰驗證，且隨機性必須等於簽名的 SHA-256
+func (v *Verifier) Verify(beacon Beacon) error {
+	return v.verifyWith(v.scheme, beacon)
+}
+
+// VerifyBatch 並行驗證多個隨機信標，返回與 beacons 一一對應的錯誤，有效的信標對應 nil
+// 每個工作協程創建一次自己的簽名方案實例並在其負責的所有信標間重複使用，不與其他協程共享
+// ctx 取消後尚未驗證的信標對應 ctx 的錯誤
+func (v *Verifier) VerifyBatch(ctx context.Context, beacons []Beacon) []error {
+	errs := make([]error, len(beacons))
+
+	workers := v.workers
+	if workers > len(beacons) {
+		workers = len(beacons)
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			scheme, err := crypto.SchemeFromName(v.info.Scheme)
+			if err != nil {
+				scheme = v.scheme
+			}
+			for i := range indexes {
+				errs[i] = v.verifyWith(scheme, beacons[i])
+			}
+		}()
+	}
+
+dispatch:
+	for i := range beacons {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			for j := i; j < len(beacons); j++ {
+				errs[j] = ctx.Err()
+			}
+			break dispatch
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	return errs
+}
+
+// verifyWith 使用指定的簽名方案實例驗證隨機信標
+func (v *Verifier) verifyWith(scheme *crypto.Scheme, beacon Beacon) error {
+	if err := scheme.VerifyBeacon(beacon, v.info.PublicKey); err != nil {
+		return NewVerificationError(fmt.Errorf("輪次 %d 的簽名無效: %w", beacon.Round, err))
+	}
+	if !bytes.Equal(crypto.RandomnessFromSignature(beacon.Signature), beacon.Randomness) {
+		return NewVerificationError(fmt.Errorf("輪次 %d 的隨機性與簽名不符", beacon.Round))
+	}
+	return nil
+}
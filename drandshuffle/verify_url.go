@@ -0,0 +1,203 @@
+package drandshuffle
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// MinVerifyURLKeyLength 是驗證鏈接簽名密鑰的最小長度（字節）
+const MinVerifyURLKeyLength = 32
+
+// verifyURLDomain 是驗證鏈接簽名的域分隔前綴，避免同一密鑰的其他 HMAC 被當作鏈接簽名
+const verifyURLDomain = "drandshuffle/verify-url/v1\n"
+
+// 驗證鏈接的查詢參數
+const (
+	verifyURLRound   = "round"
+	verifyURLSession = "session"
+	verifyURLDeck    = "deck"
+	verifyURLExpires = "exp"
+	verifyURLSig     = "sig"
+)
+
+// VerifyLink 是驗證鏈接攜帶的牌局信息
+type VerifyLink struct {
+	Round      uint64
+	SessionID  string
+	DeckDigest string    // 牌組的 DeckDigest
+	Expires    time.Time // 鏈接的過期時間，零值表示不過期
+	Signed     bool      // 鏈接是否帶有簽名
+}
+
+// verifyURLOptions 是 BuildVerifyURL 和 ParseVerifyURL 的參數
+type verifyURLOptions struct {
+	key     []byte
+	expires time.Time
+	now     func() time.Time
+}
+
+// VerifyURLOption 設定 BuildVerifyURL 和 ParseVerifyURL 的參數
+type VerifyURLOption func(*verifyURLOptions)
+
+// WithVerifyURLKey 設定簽名密鑰，至少 MinVerifyURLKeyLength 字節
+// BuildVerifyURL 以 HMAC-SHA256 簽名鏈接；ParseVerifyURL 要求鏈接帶有以此密鑰生成的簽名
+func WithVerifyURLKey(key []byte) VerifyURLOption {
+	return func(o *verifyURLOptions) {
+		o.key = key
+	}
+}
+
+// WithVerifyURLExpiry 設定鏈接的過期時間，只用於 BuildVerifyURL，須同時設定簽名密鑰，否則過期時間可以被隨意刪除
+func WithVerifyURLExpiry(expires time.Time) VerifyURLOption {
+	return func(o *verifyURLOptions) {
+		o.expires = expires
+	}
+}
+
+// WithVerifyURLNow 設定 ParseVerifyURL 判斷過期使用的時間來源，默認為 time.Now
+func WithVerifyURLNow(now func() time.Time) VerifyURLOption {
+	return func(o *verifyURLOptions) {
+		o.now = now
+	}
+}
+
+// newVerifyURLOptions 應用參數並檢查密鑰長度
+func newVerifyURLOptions(opts []VerifyURLOption) (verifyURLOptions, error) {
+	o := verifyURLOptions{now: time.Now}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.key != nil && len(o.key) < MinVerifyURLKeyLength {
+		return o, NewInputError(fmt.Errorf("%w: 驗證鏈接的簽名密鑰只有 %d 字節，至少需要 %d 字節", ErrInvalidConfig, len(o.key), MinVerifyURLKeyLength))
+	}
+	return o, nil
+}
+
+// BuildVerifyURL 生成指向驗證頁面的深度鏈接，遊戲客戶端可以把它嵌入牌局結果，玩家打開後核對發到的牌組
+// 鏈接在 baseURL 上附加輪次、遊戲局號和牌組摘要（DeckDigest），baseURL 原有的查詢參數保留。
+// 設定 WithVerifyURLKey 時以 HMAC-SHA256 簽名，驗證頁面以 ParseVerifyURL 確認鏈接確實由運營方發出、未被修改，
+// 還可以用 WithVerifyURLExpiry 設定過期時間。參數無效時返回輸入錯誤
+func BuildVerifyURL(baseURL string, round uint64, sessionID, deckDigest string, opts ...VerifyURLOption) (string, error) {
+	o, err := newVerifyURLOptions(opts)
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", NewInputError(fmt.Errorf("%w: 驗證頁面地址 %q 不是 http 或 https 的絕對地址", ErrInvalidConfig, baseURL))
+	}
+	link := VerifyLink{Round: round, SessionID: sessionID, DeckDigest: deckDigest, Expires: o.expires}
+	if err := link.validate(); err != nil {
+		return "", err
+	}
+	if !o.expires.IsZero() && o.key == nil {
+		return "", NewInputError(fmt.Errorf("%w: 設定過期時間的驗證鏈接須同時設定簽名密鑰", ErrInvalidConfig))
+	}
+
+	q := u.Query()
+	for _, name := range []string{verifyURLRound, verifyURLSession, verifyURLDeck, verifyURLExpires, verifyURLSig} {
+		q.Del(name)
+	}
+	q.Set(verifyURLRound, strconv.FormatUint(round, 10))
+	q.Set(verifyURLSession, sessionID)
+	q.Set(verifyURLDeck, deckDigest)
+	if !o.expires.IsZero() {
+		q.Set(verifyURLExpires, strconv.FormatInt(o.expires.Unix(), 10))
+	}
+	if o.key != nil {
+		q.Set(verifyURLSig, link.sign(o.key))
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// ParseVerifyURL 解析 BuildVerifyURL 生成的鏈接，驗證頁面以此取得要核對的牌局
+// 設定 WithVerifyURLKey 時要求鏈接帶有有效的簽名且尚未過期，否則返回包裝 ErrInvalidLink 的驗證錯誤；
+// 沒有設定密鑰時不檢查簽名和過期時間，Signed 報告鏈接是否帶有簽名。缺少或格式錯誤的參數返回包裝 ErrInvalidLink 的輸入錯誤
+func ParseVerifyURL(rawURL string, opts ...VerifyURLOption) (*VerifyLink, error) {
+	o, err := newVerifyURLOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, NewInputError(fmt.Errorf("%w: %v", ErrInvalidLink, err))
+	}
+	q := u.Query()
+	round, err := strconv.ParseUint(q.Get(verifyURLRound), 10, 64)
+	if err != nil {
+		return nil, NewInputError(fmt.Errorf("%w: 無效的輪次 %q", ErrInvalidLink, q.Get(verifyURLRound)))
+	}
+	link := &VerifyLink{Round: round, SessionID: q.Get(verifyURLSession), DeckDigest: q.Get(verifyURLDeck), Signed: q.Has(verifyURLSig)}
+	if exp := q.Get(verifyURLExpires); exp != "" {
+		unix, err := strconv.ParseInt(exp, 10, 64)
+		if err != nil {
+			return nil, NewInputError(fmt.Errorf("%w: 無效的過期時間 %q", ErrInvalidLink, exp))
+		}
+		link.Expires = time.Unix(unix, 0).UTC()
+	}
+	if err := link.validate(); err != nil {
+		return nil, NewInputError(fmt.Errorf("%w: %w", ErrInvalidLink, err))
+	}
+
+	if o.key == nil {
+		return link, nil
+	}
+	if !hmac.Equal([]byte(q.Get(verifyURLSig)), []byte(link.sign(o.key))) {
+		return nil, NewVerificationError(fmt.Errorf("%w: 簽名無效", ErrInvalidLink))
+	}
+	if !link.Expires.IsZero() && !o.now().Before(link.Expires) {
+		return nil, NewVerificationError(fmt.Errorf("%w: 鏈接已於 %s 過期", ErrInvalidLink, link.Expires.Format(time.RFC3339)))
+	}
+	return link, nil
+}
+
+// validate 檢查輪次、遊戲局號和牌組摘要
+func (l *VerifyLink) validate() error {
+	if l.Round == 0 {
+		return NewInputError(fmt.Errorf("%w: 輪次從 1 開始", ErrRoundBeforeGenesis))
+	}
+	if err := ValidateSessionID(l.SessionID); err != nil {
+		return err
+	}
+	if !isDigest(l.DeckDigest) {
+		return NewInputError(fmt.Errorf("%w: 牌組摘要 %q 不是 64 個小寫十六進制字符", ErrInvalidConfig, l.DeckDigest))
+	}
+	return nil
+}
+
+// sign 返回鏈接的 HMAC-SHA256 簽名（無填充的 base64url）
+// 簽名覆蓋輪次、遊戲局號、牌組摘要和過期時間，各欄位以換行分隔；遊戲局號和摘要不含換行，因此拼接沒有歧義
+func (l *VerifyLink) sign(key []byte) string {
+	var expires int64
+	if !l.Expires.IsZero() {
+		expires = l.Expires.Unix()
+	}
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "%s%d\n%s\n%s\n%d", verifyURLDomain, l.Round, l.SessionID, l.DeckDigest, expires)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyLink 以鏈接的輪次和遊戲局號重新洗牌，檢查結果的 DeckDigest 與鏈接一致，並返回洗出的牌組供驗證頁面展示
+// 已取得鏈信息時同時驗證該輪信標的簽名；不一致時返回包裝 ErrDeckMismatch 的錯誤。重新洗牌屬於驗證，不計入 UsageMeter 的用量
+func (c *Client) VerifyLink(ctx context.Context, link *VerifyLink) ([]Card, error) {
+	if err := link.validate(); err != nil {
+		return nil, err
+	}
+	randomness, err := c.verifiedRandomness(ctx, link.Round)
+	if err != nil {
+		return nil, err
+	}
+	deck := defaultShuffler.Shuffle(randomness, link.SessionID)
+	if digest := DeckDigest(deck); digest != link.DeckDigest {
+		return nil, NewVerificationError(fmt.Errorf("%w: 輪次 %d、遊戲局號 %s 洗出的牌組摘要為 %s，鏈接中為 %s",
+			ErrDeckMismatch, link.Round, link.SessionID, digest, link.DeckDigest))
+	}
+	return deck, nil
+}
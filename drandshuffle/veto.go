@@ -0,0 +1,77 @@
+package drandshuffle
+
+import (
+	"fmt"
+	"slices"
+)
+
+// vetoDomain 是 VetoDraft 派生種子時使用的域分隔前綴，使同一種子的選圖與分隊、平局排序等結果互不相關
+const vetoDomain = "drandshuffle/veto/v1"
+
+// VetoAction 是選圖流程中一步的動作
+type VetoAction string
+
+const (
+	// VetoBan 表示禁用一張地圖
+	VetoBan VetoAction = "ban"
+	// VetoPick 表示選用一張地圖
+	VetoPick VetoAction = "pick"
+	// VetoDecider 表示流程結束後剩下的最後一張地圖，作為決勝圖
+	VetoDecider VetoAction = "decider"
+)
+
+// VetoStep 是選圖流程中的一步
+type VetoStep struct {
+	Team   int        `json:"team"`   // 執行這一步的隊伍，0 或 1；決勝圖為 -1
+	Action VetoAction `json:"action"` // 禁用、選用或決勝圖
+	Choice string     `json:"choice"` // 被禁用或選用的地圖
+}
+
+// VetoResult 是 VetoDraft 的結果
+type VetoResult struct {
+	FirstTeam int        `json:"first_team"` // 先行動的隊伍
+	Steps     []VetoStep `json:"steps"`      // 按順序排列的每一步，最後一步是決勝圖
+	Maps      []string   `json:"maps"`       // 比賽使用的地圖：按選用順序排列的地圖，最後是決勝圖
+}
+
+// VetoDraft 以種子模擬兩隊輪流禁用和選用地圖的流程，適用於需要公開抽籤的比賽
+// format 是每一步的動作（VetoBan 或 VetoPick），長度必須是候選地圖數量減 1，剩下的一張為決勝圖；
+// 例如 7 張地圖的 BO3 常用 ban, ban, pick, pick, ban, ban。
+// 以 NewDRBG(SHA256("drandshuffle/veto/v1" || seed)) 先用 Intn(2) 決定先行動的隊伍，之後兩隊輪流行動，每一步以 Intn(剩餘數量) 從剩餘的地圖中選出一張，
+// 剩餘的地圖保持 candidates 中的順序，轉播方和參賽者取得種子即可逐步重現。候選地圖重複、為空或 format 不合法時返回包裝 ErrInvalidConfig 的輸入錯誤
+func VetoDraft(candidates []string, format []VetoAction, seed []byte) (*VetoResult, error) {
+	if len(candidates) == 0 {
+		return nil, NewInputError(fmt.Errorf("%w: 沒有候選地圖", ErrInvalidConfig))
+	}
+	seen := make(map[string]bool, len(candidates))
+	for _, c := range candidates {
+		if seen[c] {
+			return nil, NewInputError(fmt.Errorf("%w: 候選地圖 %s 重複", ErrInvalidConfig, c))
+		}
+		seen[c] = true
+	}
+	if len(format) != len(candidates)-1 {
+		return nil, NewInputError(fmt.Errorf("%w: %d 張候選地圖需要 %d 步禁用或選用，format 有 %d 步", ErrInvalidConfig, len(candidates), len(candidates)-1, len(format)))
+	}
+	for i, action := range format {
+		if action != VetoBan && action != VetoPick {
+			return nil, NewInputError(fmt.Errorf("%w: 第 %d 步的動作 %q 不是 ban 或 pick", ErrInvalidConfig, i, action))
+		}
+	}
+
+	drbg := NewDRBG(domainSeed(vetoDomain, seed))
+	result := &VetoResult{FirstTeam: drbg.Intn(2), Maps: []string{}}
+	remaining := slices.Clone(candidates)
+	for i, action := range format {
+		j := drbg.Intn(len(remaining))
+		choice := remaining[j]
+		remaining = slices.Delete(remaining, j, j+1)
+		result.Steps = append(result.Steps, VetoStep{Team: (result.FirstTeam + i) % 2, Action: action, Choice: choice})
+		if action == VetoPick {
+			result.Maps = append(result.Maps, choice)
+		}
+	}
+	result.Steps = append(result.Steps, VetoStep{Team: -1, Action: VetoDecider, Choice: remaining[0]})
+	result.Maps = append(result.Maps, remaining[0])
+	return result, nil
+}
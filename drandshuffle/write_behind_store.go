@@ -0,0 +1,289 @@
+package drandshuffle
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WriteBehindStore 在內存中暫存待保存的隨機信標，定期或累積到一定數量時批量寫入底層存儲
+// 高輪次速率下可以避免每個信標各自觸發一次磁盤寫入和 fsync
+// NewWriteBehindStore 創建的存儲只在內存中暫存，進程崩潰時最多丟失尚未寫入的一批信標，這些信標之後可以重新從網絡獲取；
+// 需要崩潰後不丟失時用 NewJournaledWriteBehindStore，每個信標先追加到日誌文件，重新打開時重放尚未寫入底層存儲的信標。
+// 後台寫入失敗時以 Warn 級別記錄日誌：以 WithBeaconStore 交給 DrandManager 時使用其 WithLogger 設定的記錄器，否則不記錄。
+// 使用完畢後必須調用 Close，確保暫存的信標全部寫入
+type WriteBehindStore struct {
+	store    BeaconStore
+	maxBatch int
+	logger   atomic.Pointer[slog.Logger]
+
+	mu      sync.Mutex
+	pending []Beacon
+	closed  bool
+	journal *os.File // 崩潰恢復日誌，未啟用時為 nil
+	syncs   atomic.Uint64
+
+	flushMu   sync.Mutex // 保證各批次按順序寫入
+	kick      chan struct{}
+	done      chan struct{}
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// maxPendingBatches 寫入持續失敗時最多保留的批次數量，超過後丟棄最舊的信標
+const maxPendingBatches = 16
+
+// NewWriteBehindStore 創建包裝 store 的 WriteBehindStore
+// 每隔 interval 或暫存的信標達到 maxBatch 個時寫入一次；底層存儲實現 BatchBeaconStore 時一批只寫入一次
+func NewWriteBehindStore(store BeaconStore, interval time.Duration, maxBatch int) *WriteBehindStore {
+	s := newWriteBehindStore(store, maxBatch)
+	s.start(interval)
+	return s
+}
+
+// NewJournaledWriteBehindStore 創建帶崩潰恢復日誌的 WriteBehindStore，其他行為與 NewWriteBehindStore 相同
+// Save 先把信標追加到 journalPath 再放入暫存，不等待 fsync；每次寫入底層存儲前對日誌 fsync 一次，一批信標共用一次 fsync。
+// 進程崩潰時已追加的信標仍在操作系統的緩存中，不會丟失；操作系統崩潰或斷電時最多丟失上一次寫入之後保存的信標，
+// 即至多 maxBatch 個或 interval 內保存的信標。暫存全部寫入底層存儲後清空日誌。打開時若日誌中有信標（上次崩潰或 Close 時寫入失敗留下的），
+// 先放回暫存並立即開始寫入；其中可能有已經寫入過的信標，底層存儲應能容忍重複保存同一輪次。
+// 日誌格式與 FileBeaconStore 相同，寫入中斷留下的不完整的最後一行會被忽略
+func NewJournaledWriteBehindStore(store BeaconStore, journalPath string, interval time.Duration, maxBatch int) (*WriteBehindStore, error) {
+	replay, err := NewFileBeaconStore(journalPath).Load()
+	if err != nil {
+		return nil, fmt.Errorf("無法重放信標日誌: %w", err)
+	}
+	journal, err := os.OpenFile(journalPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("無法打開信標日誌: %w", err)
+	}
+	s := newWriteBehindStore(store, maxBatch)
+	s.journal = journal
+	s.pending = replay
+	s.start(interval)
+	if len(replay) > 0 {
+		s.kick <- struct{}{}
+	}
+	return s, nil
+}
+
+// newWriteBehindStore 創建尚未啟動後台寫入的 WriteBehindStore
+func newWriteBehindStore(store BeaconStore, maxBatch int) *WriteBehindStore {
+	s := &WriteBehindStore{
+		store:    store,
+		maxBatch: max(maxBatch, 1),
+		kick:     make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+	s.logger.Store(discardLogger)
+	return s
+}
+
+// start 啟動後台寫入
+func (s *WriteBehindStore) start(interval time.Duration) {
+	s.wg.Add(1)
+	go s.run(interval)
+}
+
+// run 在後台定期寫入暫存的信標，直到 Close
+func (s *WriteBehindStore) run(interval time.Duration) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.kick:
+		case <-s.done:
+			return
+		}
+		// 失敗的信標已放回暫存，下一次寫入時重試；Close 會返回最後的錯誤
+		if err := s.Flush(); err != nil {
+			s.logger.Load().Warn("無法寫入暫存的隨機信標", slog.Int("pending", s.pendingLen()), slog.Any("error", err))
+		}
+	}
+}
+
+// useLogger 設定後台寫入失敗時使用的日誌記錄器
+func (s *WriteBehindStore) useLogger(logger *slog.Logger) {
+	s.logger.Store(logger)
+}
+
+// pendingLen 返回暫存中尚未寫入的信標數量
+func (s *WriteBehindStore) pendingLen() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.pending)
+}
+
+// Save 將隨機信標加入暫存，不等待寫入底層存儲；啟用日誌時先追加到日誌，追加失敗時返回錯誤且不加入暫存
+func (s *WriteBehindStore) Save(beacon Beacon) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return fmt.Errorf("信標存儲已關閉")
+	}
+	if err := s.appendJournal(beacon); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	s.pending = append(s.pending, beacon)
+	full := len(s.pending) == s.maxBatch
+	s.mu.Unlock()
+
+	if full {
+		select {
+		case s.kick <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Load 先寫入暫存的信標，再從底層存儲讀取所有信標
+func (s *WriteBehindStore) Load() ([]Beacon, error) {
+	if err := s.Flush(); err != nil {
+		return nil, err
+	}
+	return s.store.Load()
+}
+
+// Flush 立即將暫存的信標寫入底層存儲
+// 寫入失敗時信標會放回暫存等待下次重試，暫存過多時丟棄最舊的信標
+func (s *WriteBehindStore) Flush() error {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	s.mu.Lock()
+	batch := s.pending
+	s.pending = nil
+	s.mu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	// 同步失敗時仍嘗試寫入底層存儲，寫入成功後日誌會被清空，不再需要這些記錄
+	syncErr := s.syncJournal()
+	unsaved, err := s.saveBatch(batch)
+	if err == nil {
+		return s.truncateJournal()
+	}
+
+	s.mu.Lock()
+	s.pending = append(unsaved, s.pending...)
+	if limit := maxPendingBatches * s.maxBatch; len(s.pending) > limit {
+		s.pending = s.pending[len(s.pending)-limit:]
+	}
+	s.mu.Unlock()
+	return errors.Join(err, syncErr)
+}
+
+// saveBatch 將一批信標寫入底層存儲，失敗時返回尚未寫入的信標
+// 底層存儲發生 panic 時視為寫入失敗，返回 *PanicError，整批信標放回暫存等待重試
+func (s *WriteBehindStore) saveBatch(batch []Beacon) (unsaved []Beacon, err error) {
+	if p := CatchPanic("beacon_store", func() { unsaved, err = s.writeBatch(batch) }); p != nil {
+		return batch, p
+	}
+	return unsaved, err
+}
+
+// writeBatch 將一批信標寫入底層存儲，失敗時返回尚未寫入的信標
+func (s *WriteBehindStore) writeBatch(batch []Beacon) ([]Beacon, error) {
+	if bs, ok := s.store.(BatchBeaconStore); ok {
+		if err := bs.SaveBatch(batch); err != nil {
+			return batch, err
+		}
+		return nil, nil
+	}
+	for i, beacon := range batch {
+		if err := s.store.Save(beacon); err != nil {
+			// 已寫入的信標不再重試
+			return batch[i:], err
+		}
+	}
+	return nil, nil
+}
+
+// appendJournal 將信標追加到日誌，不等待 fsync，未啟用日誌時不做任何事；調用者持有 s.mu
+func (s *WriteBehindStore) appendJournal(beacon Beacon) error {
+	if s.journal == nil {
+		return nil
+	}
+	line, err := encodeBeaconRecord(beacon)
+	if err != nil {
+		return err
+	}
+	if _, err := s.journal.Write(line); err != nil {
+		return fmt.Errorf("無法寫入信標日誌: %w", err)
+	}
+	return nil
+}
+
+// syncJournal 對日誌 fsync 一次，使已追加的信標在斷電後仍然保留，未啟用日誌時不做任何事
+// 調用者持有 s.flushMu，fsync 期間不持有 s.mu，不阻塞並發的 Save
+func (s *WriteBehindStore) syncJournal() error {
+	s.mu.Lock()
+	journal := s.journal
+	s.mu.Unlock()
+	if journal == nil {
+		return nil
+	}
+	s.syncs.Add(1)
+	if err := journal.Sync(); err != nil {
+		return fmt.Errorf("無法同步信標日誌: %w", err)
+	}
+	return nil
+}
+
+// JournalSyncs 返回對崩潰恢復日誌 fsync 的次數，未啟用日誌時為 0
+// 每次寫入底層存儲最多 fsync 一次，可用於監控日誌的磁盤開銷
+func (s *WriteBehindStore) JournalSyncs() uint64 {
+	return s.syncs.Load()
+}
+
+// truncateJournal 在暫存已全部寫入底層存儲時清空日誌
+// 寫入期間新加入暫存的信標仍在日誌中，此時保留日誌，待下一次寫入後再清空
+func (s *WriteBehindStore) truncateJournal() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.journal == nil || len(s.pending) > 0 {
+		return nil
+	}
+	if err := s.journal.Truncate(0); err != nil {
+		return fmt.Errorf("無法清空信標日誌: %w", err)
+	}
+	return nil
+}
+
+// Close 停止後台寫入並寫入所有暫存的信標，之後的 Save 會返回錯誤
+// 啟用日誌時關閉日誌文件；最後的寫入失敗時信標留在日誌中，下次打開時重放
+func (s *WriteBehindStore) Close() error {
+	s.closeOnce.Do(func() {
+		s.mu.Lock()
+		s.closed = true
+		s.mu.Unlock()
+
+		close(s.done)
+		s.wg.Wait()
+	})
+	err := s.Flush()
+
+	// 等待並發的 Flush 完成同步後再關閉日誌
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.journal != nil {
+		if cerr := s.journal.Close(); err == nil {
+			err = cerr
+		}
+		s.journal = nil
+	}
+	return err
+}
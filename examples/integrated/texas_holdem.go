@@ -1,17 +1,66 @@
 package main
 
 import (
-	"crypto/rand"
-	"encoding/hex"
+	"context"
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
-	"go_drand/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
 )
 
+// ui 是命令行輸出的消息目錄
+var ui = drandshuffle.Catalog{
+	drandshuffle.LocaleZhTW: {
+		"title":           "德州撲克遊戲 (輪次: %d, 遊戲局號: %s)",
+		"hand":            "玩家 %d 的手牌: %s, %s",
+		"community":       "公共牌:",
+		"flop":            "翻牌: %s",
+		"turn":            "轉牌: %s",
+		"river":           "河牌: %s",
+		"round_used":      "遊戲使用的輪次號碼: %d",
+		"round_time":      "該輪隨機性的產生時間: %s",
+		"session_used":    "遊戲使用的遊戲局號: %s",
+		"reproducible":    "任何人都可以使用相同的輪次號碼和遊戲局號重現完全相同的發牌結果。",
+		"verify_cmd":      "驗證命令: go run texas_holdem.go %d %s",
+		"network_warning": "警告: 無法連接到 drand 網絡，請檢查您的網絡連接。",
+		"error_detail":    "錯誤詳情: %s",
+		"retry_hint":      "您可以稍後再試，或使用本地隨機源作為備用。",
+		"invalid_round":   "無效的輪次號碼: %v",
+		"invalid_session": "無效的遊戲局號: %s",
+		"session_failed":  "無法生成遊戲局號: %v",
+		"config_failed":   "無法載入配置: %v",
+		"init_failed":     "無法初始化 DrandManager: %s",
+		"create_failed":   "無法創建遊戲: %s",
+	},
+	drandshuffle.LocaleEn: {
+		"title":           "Texas Hold'em (round: %d, session ID: %s)",
+		"hand":            "Player %d hand: %s, %s",
+		"community":       "Community cards:",
+		"flop":            "Flop: %s",
+		"turn":            "Turn: %s",
+		"river":           "River: %s",
+		"round_used":      "Round used by this game: %d",
+		"round_time":      "Randomness generated at: %s",
+		"session_used":    "Session ID used by this game: %s",
+		"reproducible":    "Anyone can reproduce exactly the same deal from the same round and session ID.",
+		"verify_cmd":      "Verify with: go run texas_holdem.go %d %s",
+		"network_warning": "Warning: could not reach the drand network, please check your connection.",
+		"error_detail":    "Details: %s",
+		"retry_hint":      "Try again later, or fall back to a local randomness source.",
+		"invalid_round":   "Invalid round number: %v",
+		"invalid_session": "Invalid session ID: %s",
+		"session_failed":  "Could not generate a session ID: %v",
+		"config_failed":   "Could not load configuration: %v",
+		"init_failed":     "Could not initialise the DrandManager: %s",
+		"create_failed":   "Could not create the game: %s",
+	},
+}
+
 // 德州撲克遊戲狀態
 type TexasHoldemGame struct {
 	// 玩家手牌，每個玩家2張牌
@@ -28,7 +77,7 @@ type TexasHoldemGame struct {
 }
 
 // 初始化新的德州撲克遊戲
-func NewTexasHoldemGame(numPlayers int, round uint64, gameSessionID string) (*TexasHoldemGame, error) {
+func NewTexasHoldemGame(ctx context.Context, client *drandshuffle.Client, numPlayers int, round uint64, gameSessionID string) (*TexasHoldemGame, error) {
 	if numPlayers < 2 || numPlayers > 10 {
 		return nil, fmt.Errorf("玩家數量必須在2到10之間")
 	}
@@ -39,14 +88,14 @@ func NewTexasHoldemGame(numPlayers int, round uint64, gameSessionID string) (*Te
 
 	// 如果指定了輪次號碼，使用該輪次的隨機信標
 	if round > 0 {
-		shuffledDeck, err = drandshuffle.GetShuffledDeckByRound(round, gameSessionID)
+		shuffledDeck, err = client.ShuffleAtRound(ctx, round, gameSessionID)
 		if err != nil {
 			return nil, fmt.Errorf("無法獲取洗牌後的牌組: %v", err)
 		}
 		newRound = round
 	} else {
 		// 否則使用最新的隨機信標
-		shuffledDeck, newRound, err = drandshuffle.GetShuffledDeck(gameSessionID)
+		shuffledDeck, newRound, err = client.ShuffleLatest(ctx, gameSessionID)
 		if err != nil {
 			return nil, fmt.Errorf("無法獲取洗牌後的牌組: %v", err)
 		}
@@ -60,52 +109,52 @@ func NewTexasHoldemGame(numPlayers int, round uint64, gameSessionID string) (*Te
 		GameSessionID:  gameSessionID,
 	}
 
-	// 確保有足夠的牌
-	requiredCards := numPlayers*2 + 5 // 每個玩家2張牌 + 5張公共牌
-	if len(shuffledDeck) < requiredCards {
-		return nil, fmt.Errorf("牌組長度不足，需要 %d 張牌，但只有 %d 張", requiredCards, len(shuffledDeck))
+	// 確保有足夠的牌：每個玩家2張牌 + 5張公共牌
+	dealer := drandshuffle.NewDealer(shuffledDeck)
+	if err := dealer.Require(numPlayers*2 + 5); err != nil {
+		return nil, err
 	}
 
 	// 發牌：每個玩家2張牌
-	cardIndex := 0
-	for player := 0; player < numPlayers; player++ {
-		game.PlayerHands[player] = shuffledDeck[cardIndex : cardIndex+2]
-		cardIndex += 2
+	hands, _ := dealer.DealHands(numPlayers, 2)
+	for player, hand := range hands {
+		game.PlayerHands[player] = hand
 	}
 
 	// 發公共牌：5張
-	game.CommunityCards = shuffledDeck[cardIndex : cardIndex+5]
+	game.CommunityCards, _ = dealer.Deal(5)
 
 	return game, nil
 }
 
-// 顯示遊戲狀態
-func (g *TexasHoldemGame) DisplayGame() {
-	fmt.Printf("德州撲克遊戲 (輪次: %d, 遊戲局號: %s)\n\n", g.Round, g.GameSessionID)
+// 顯示遊戲狀態，牌名和提示文字使用指定的語言
+func (g *TexasHoldemGame) DisplayGame(locale drandshuffle.Locale) {
+	fmt.Println(ui.Sprintf(locale, "title", g.Round, g.GameSessionID))
+	fmt.Println()
 
 	// 顯示玩家手牌
 	for player, cards := range g.PlayerHands {
-		fmt.Printf("玩家 %d 的手牌: %s%s, %s%s\n",
-			player+1,
-			cards[0].Suit, cards[0].Value,
-			cards[1].Suit, cards[1].Value)
+		fmt.Println(ui.Sprintf(locale, "hand", player+1,
+			drandshuffle.CardName(cards[0], locale),
+			drandshuffle.CardName(cards[1], locale)))
 	}
 
 	// 顯示公共牌
-	fmt.Println("\n公共牌:")
+	fmt.Println()
+	fmt.Println(ui.Sprintf(locale, "community"))
 
 	// 翻牌 (前3張)
-	fmt.Print("翻牌: ")
-	for i := 0; i < 3; i++ {
-		fmt.Printf("%s%s ", g.CommunityCards[i].Suit, g.CommunityCards[i].Value)
+	flop := make([]string, 3)
+	for i := range flop {
+		flop[i] = drandshuffle.CardName(g.CommunityCards[i], locale)
 	}
-	fmt.Println()
+	fmt.Println(ui.Sprintf(locale, "flop", strings.Join(flop, ", ")))
 
 	// 轉牌 (第4張)
-	fmt.Printf("轉牌: %s%s\n", g.CommunityCards[3].Suit, g.CommunityCards[3].Value)
+	fmt.Println(ui.Sprintf(locale, "turn", drandshuffle.CardName(g.CommunityCards[3], locale)))
 
 	// 河牌 (第5張)
-	fmt.Printf("河牌: %s%s\n", g.CommunityCards[4].Suit, g.CommunityCards[4].Value)
+	fmt.Println(ui.Sprintf(locale, "river", drandshuffle.CardName(g.CommunityCards[4], locale)))
 }
 
 // 獲取遊戲使用的輪次號碼
@@ -119,55 +168,66 @@ func (g *TexasHoldemGame) GetGameSessionID() string {
 }
 
 func main() {
-	// 初始化 DrandManager
-	drandManager, err := drandshuffle.GetDrandManager()
+	// 載入配置，可通過配置文件（DRANDSHUFFLE_CONFIG）和環境變量覆蓋默認值
+	cfg, err := drandshuffle.LoadConfig(os.Getenv("DRANDSHUFFLE_CONFIG"))
+	if err != nil {
+		log.Fatal(ui.Sprintf(drandshuffle.LocaleFromEnv(), "config_failed", err))
+	}
+
+	// 創建 Client，將庫的日誌輸出到標準錯誤
+	client, err := drandshuffle.NewClient(
+		drandshuffle.WithConfig(cfg),
+		drandshuffle.WithLogger(slog.Default()),
+	)
 	if err != nil {
-		log.Fatalf("無法初始化 DrandManager: %v", err)
+		log.Fatal(ui.Sprintf(cfg.Locale, "init_failed", drandshuffle.ErrorMessage(err, cfg.Locale)))
 	}
+	defer client.Close()
 
-	// 啟動後台獲取（如果尚未啟動）
-	drandManager.StartBackgroundFetching()
-	defer drandManager.Close()
+	// 顯示語言由配置文件的 locale 或環境變量 DRANDSHUFFLE_LANG 決定
+	locale := client.Manager().Locale()
+
+	// 啟動後台獲取
+	client.Manager().StartBackgroundFetching()
 
 	// 檢查命令行參數
 	var round uint64 = 0
-	var gameSessionID string = generateSecureGameSessionID()
+	var gameSessionID string
 
 	// 處理命令行參數
 	if len(os.Args) > 1 {
 		round, err = strconv.ParseUint(os.Args[1], 10, 64)
 		if err != nil {
-			log.Fatalf("無效的輪次號碼: %v", err)
+			log.Fatal(ui.Sprintf(locale, "invalid_round", err))
 		}
 	}
 
 	if len(os.Args) > 2 {
 		gameSessionID = os.Args[2]
+	} else {
+		// 使用加密安全的隨機遊戲局號
+		gameSessionID, err = drandshuffle.NewSessionID()
+		if err != nil {
+			log.Fatal(ui.Sprintf(locale, "session_failed", err))
+		}
 	}
 
 	// 創建一個4人的德州撲克遊戲
-	game, err := NewTexasHoldemGame(4, round, gameSessionID)
+	game, err := NewTexasHoldemGame(context.Background(), client, 4, round, gameSessionID)
 	if err != nil {
-		log.Fatalf("無法創建遊戲: %v", err)
+		log.Fatal(ui.Sprintf(locale, "create_failed", drandshuffle.ErrorMessage(err, locale)))
 	}
 
 	// 顯示遊戲狀態
-	game.DisplayGame()
+	game.DisplayGame(locale)
 
 	// 輸出驗證信息
-	fmt.Printf("\n遊戲使用的輪次號碼: %d\n", game.GetRound())
-	fmt.Printf("遊戲使用的遊戲局號: %s\n", game.GetGameSessionID())
-	fmt.Println("任何人都可以使用相同的輪次號碼和遊戲局號重現完全相同的發牌結果。")
-	fmt.Printf("驗證命令: go run texas_holdem.go %d %s\n", game.GetRound(), game.GetGameSessionID())
-}
-
-// 生成加密安全的遊戲局號
-func generateSecureGameSessionID() string {
-	bytes := make([]byte, 16) // 128位隨機數
-	_, err := rand.Read(bytes)
-	if err != nil {
-		// 如果無法生成隨機數，退回到時間戳（不理想但總比沒有好）
-		return "game_" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	fmt.Println()
+	fmt.Println(ui.Sprintf(locale, "round_used", game.GetRound()))
+	if roundTime, ok := client.RoundTime(game.GetRound()); ok {
+		fmt.Println(ui.Sprintf(locale, "round_time", roundTime.Format(time.RFC3339)))
 	}
-	return "game_" + hex.EncodeToString(bytes)
+	fmt.Println(ui.Sprintf(locale, "session_used", game.GetGameSessionID()))
+	fmt.Println(ui.Sprintf(locale, "reproducible"))
+	fmt.Println(ui.Sprintf(locale, "verify_cmd", game.GetRound(), game.GetGameSessionID()))
 }
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"log/slog"
+	nethttp "net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/drand/drand/v2/common/chain"
+	"github.com/drand/go-clients/client"
+	"github.com/drand/go-clients/client/http"
+	"github.com/drand/go-clients/drand"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+)
+
+// settings 保存可以在運行時重新載入的設定，由獲取循環和管理接口並發讀取
+type settings struct {
+	// load 按啟動時的優先級重新載入配置：默認配置、配置文件、環境變量、命令行參數
+	load   func() (drandshuffle.Config, error)
+	client *swappableClient
+	// certs 在使用 -tls-cert 時不為 nil，重新載入時重新讀取證書
+	certs *certReloader
+
+	fetchTimeout atomic.Int64 // time.Duration
+	interval     atomic.Int64 // time.Duration
+	verbose      atomic.Bool
+	// intervalChanged 在獲取間隔改變時收到新的間隔，供獲取循環重設計時器
+	intervalChanged chan time.Duration
+
+	// mu 串行化重新載入，cfg 是當前生效的配置
+	mu  sync.Mutex
+	cfg drandshuffle.Config
+}
+
+// newSettings 以啟動時的配置創建 settings，並連接配置中的中繼
+func newSettings(cfg drandshuffle.Config, load func() (drandshuffle.Config, error), interval time.Duration, verbose bool) (*settings, error) {
+	c, err := connect(cfg)
+	if err != nil {
+		return nil, err
+	}
+	s := &settings{
+		load:            load,
+		client:          newSwappableClient(c),
+		intervalChanged: make(chan time.Duration, 1),
+		cfg:             cfg,
+	}
+	s.fetchTimeout.Store(int64(cfg.FetchTimeout))
+	s.interval.Store(int64(interval))
+	s.verbose.Store(verbose)
+	return s, nil
+}
+
+// connect 以配置中的中繼和鏈哈希創建 drand 客戶端，鏈哈希已通過 Validate 檢查
+func connect(cfg drandshuffle.Config) (drand.Client, error) {
+	chainHashBytes, _ := hex.DecodeString(cfg.ChainHash)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnectTimeout)
+	defer cancel()
+
+	c, err := client.New(
+		client.From(http.ForURLs(ctx, nil, cfg.URLs, chainHashBytes)...),
+		client.WithChainHash(chainHashBytes),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("無法創建 drand 客戶端: %w", err)
+	}
+	return c, nil
+}
+
+// reloadRequest 是 POST /admin/reload 的可選請求體，調整不在配置文件中的運行設定
+type reloadRequest struct {
+	Interval string `json:"interval,omitempty"`  // 獲取間隔，格式同 time.ParseDuration
+	LogLevel string `json:"log_level,omitempty"` // DEBUG 時記錄每次獲取的信標，INFO 或更高時只記錄失敗
+}
+
+// reload 重新載入配置並套用可以在運行時改變的部分：中繼地址、超時、獲取間隔、日誌級別和 TLS 證書
+// 信標鏡像的緩存和進行中的獲取不受影響；鏈哈希改變時需要重新啟動，此時返回錯誤且不做任何修改
+func (s *settings) reload(req reloadRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 通知 systemd 正在重新載入，無論成功與否完成後都回報就緒
+	notify("RELOADING=1")
+	defer notify("READY=1")
+
+	cfg, err := s.load()
+	if err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	if cfg.ChainHash != s.cfg.ChainHash {
+		return fmt.Errorf("鏈哈希由 %s 改為 %s，需要重新啟動服務", s.cfg.ChainHash, cfg.ChainHash)
+	}
+
+	var interval time.Duration
+	if req.Interval != "" {
+		if interval, err = time.ParseDuration(req.Interval); err != nil || interval <= 0 {
+			return fmt.Errorf("無效的獲取間隔 %q", req.Interval)
+		}
+	}
+	var level slog.Level
+	if req.LogLevel != "" {
+		if err := level.UnmarshalText([]byte(req.LogLevel)); err != nil {
+			return fmt.Errorf("無效的日誌級別 %q", req.LogLevel)
+		}
+	}
+
+	// 重新讀取續期的證書，讀取失敗時保留原有的證書
+	if s.certs != nil {
+		if err := s.certs.reload(); err != nil {
+			return err
+		}
+	}
+	// 先連接新的中繼，連接失敗時保留原有的客戶端
+	if !slices.Equal(cfg.URLs, s.cfg.URLs) {
+		c, err := connect(cfg)
+		if err != nil {
+			return err
+		}
+		s.client.swap(c)
+	}
+	s.fetchTimeout.Store(int64(cfg.FetchTimeout))
+	if interval > 0 && interval != time.Duration(s.interval.Load()) {
+		s.interval.Store(int64(interval))
+		select {
+		case <-s.intervalChanged:
+		default:
+		}
+		s.intervalChanged <- interval
+	}
+	if req.LogLevel != "" {
+		s.verbose.Store(level <= slog.LevelDebug)
+	}
+	s.cfg = cfg
+
+	log.Printf("配置已重新載入: 中繼 %v, 獲取間隔 %v, 詳細日誌 %v",
+		cfg.URLs, time.Duration(s.interval.Load()), s.verbose.Load())
+	return nil
+}
+
+// serveReload 處理 POST /admin/reload，請求體可以為空
+func (s *settings) serveReload(w nethttp.ResponseWriter, r *nethttp.Request) {
+	if r.Method != nethttp.MethodPost {
+		w.Header().Set("Allow", "POST")
+		nethttp.Error(w, "只接受 POST 請求", nethttp.StatusMethodNotAllowed)
+		return
+	}
+	var req reloadRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(nethttp.MaxBytesReader(w, r.Body, 1<<10)).Decode(&req); err != nil {
+			nethttp.Error(w, "無效的請求: "+err.Error(), nethttp.StatusBadRequest)
+			return
+		}
+	}
+	if err := s.reload(req); err != nil {
+		log.Printf("警告: 無法重新載入配置: %v", err)
+		nethttp.Error(w, err.Error(), nethttp.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(nethttp.StatusNoContent)
+}
+
+// swappableClient 是可以在運行時替換底層客戶端的 drand.Client
+// 信標鏡像的 DrandManager 持有它，重新載入中繼地址時不必重建 DrandManager，緩存得以保留
+type swappableClient struct {
+	current atomic.Pointer[clientBox]
+	closed  atomic.Bool
+}
+
+// clientBox 包裝介面值以便存入 atomic.Pointer
+type clientBox struct {
+	drand.Client
+}
+
+// newSwappableClient 創建以 c 為初始客戶端的 swappableClient
+func newSwappableClient(c drand.Client) *swappableClient {
+	s := &swappableClient{}
+	s.current.Store(&clientBox{c})
+	return s
+}
+
+// swap 替換底層客戶端並關閉原有的客戶端，原有客戶端上進行中的請求可能因此失敗，下一次獲取使用新的客戶端
+func (s *swappableClient) swap(c drand.Client) {
+	old := s.current.Swap(&clientBox{c})
+	old.Close()
+}
+
+// Get 返回指定輪次的隨機信標
+func (s *swappableClient) Get(ctx context.Context, round uint64) (drand.Result, error) {
+	return s.current.Load().Get(ctx, round)
+}
+
+// Watch 訂閱新的隨機信標，替換客戶端後已有的訂閱不會轉移
+func (s *swappableClient) Watch(ctx context.Context) <-chan drand.Result {
+	return s.current.Load().Watch(ctx)
+}
+
+// Info 返回鏈信息
+func (s *swappableClient) Info(ctx context.Context) (*chain.Info, error) {
+	return s.current.Load().Info(ctx)
+}
+
+// RoundAt 返回指定時間的輪次
+func (s *swappableClient) RoundAt(t time.Time) uint64 {
+	return s.current.Load().RoundAt(t)
+}
+
+// Close 關閉當前的底層客戶端，可以重複調用：信標鏡像的 DrandManager 關閉時也會調用它
+func (s *swappableClient) Close() error {
+	if s.closed.Swap(true) {
+		return nil
+	}
+	return s.current.Load().Close()
+}
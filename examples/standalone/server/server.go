@@ -0,0 +1,394 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	nethttp "net/http"
+	"net/http/pprof"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime/trace"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/report"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/sqlstore"
+)
+
+func main() {
+	configPath := flag.String("config", os.Getenv("DRANDSHUFFLE_CONFIG"), "YAML 或 TOML 配置文件路徑，默認取自環境變量 DRANDSHUFFLE_CONFIG")
+	printConfig := flag.Bool("print-config", false, "輸出實際生效的配置後退出")
+	pprofAddr := flag.String("pprof", "", "pprof 和 /healthz 管理接口的監聽地址（如 127.0.0.1:6060），為空時不啟用")
+	urlList := flag.String("urls", "", "drand 中繼地址，以逗號分隔")
+	chainHash := flag.String("chain-hash", "", "鏈哈希的十六進制字符串")
+	connectTimeout := flag.Duration("connect-timeout", 0, "連接中繼的超時")
+	fetchTimeout := flag.Duration("fetch-timeout", 0, "獲取隨機信標的超時")
+	interval := flag.Duration("interval", 10*time.Second, "獲取最新隨機信標的間隔")
+	verbose := flag.Bool("verbose", false, "記錄每次成功獲取的隨機信標和模擬發牌結果")
+	sessionsDB := flag.String("sessions-db", "", "遊戲服務保存牌局的 SQLite 數據庫路徑，設定後在管理接口提供 /sessions 和 /graphql 查詢")
+	sessionsRetention := flag.Duration("sessions-retention", 0, "刪除 -sessions-db 中保存超過此時間的牌局，每小時檢查一次；為 0 時不刪除，auditor 角色不能設定")
+	mirrorAddr := flag.String("mirror", "", "以 drand 中繼 API 格式提供只讀信標鏡像的監聽地址（如 0.0.0.0:8080），為空時不啟用")
+	apiAddr := flag.String("api", "", "提供 /v1/shuffle、/v1/verify 和 /v1/beacon 接口的監聽地址（如 127.0.0.1:8081），為空時不啟用")
+	role := flag.String("role", roleDealer, "服務角色：dealer 提供洗牌接口；auditor 不能洗牌，只提供驗證、信標和報告接口，並以只讀方式打開 -sessions-db")
+	var tlsOpts tlsOptions
+	flag.StringVar(&tlsOpts.certFile, "tls-cert", "", "-api 和 -mirror 使用的 TLS 證書文件（PEM），重新載入配置時重新讀取")
+	flag.StringVar(&tlsOpts.keyFile, "tls-key", "", "-tls-cert 對應的私鑰文件（PEM）")
+	flag.StringVar(&tlsOpts.acmeDomains, "acme-domains", "", "以 ACME（如 Let's Encrypt）自動申請證書的域名，以逗號分隔；不能與 -tls-cert 同時使用")
+	flag.StringVar(&tlsOpts.acmeCache, "acme-cache", "acme-cache", "保存 ACME 賬戶和證書的目錄")
+	flag.StringVar(&tlsOpts.acmeEmail, "acme-email", "", "ACME 賬戶的聯絡郵箱，證書即將過期或被吊銷時接收通知")
+	flag.StringVar(&tlsOpts.acmeHTTP, "acme-http", "", "ACME HTTP-01 驗證的監聽地址（通常為 :80），同時將 HTTP 請求跳轉到 HTTPS；為空時使用 TLS-ALPN-01，公開接口須監聽 443 端口")
+	logFile := flag.String("log-file", "", "追加寫入日誌的文件路徑，為空時輸出到標準錯誤；作為 Windows 服務運行時應設定")
+	flag.Parse()
+
+	if *logFile != "" {
+		f, err := os.OpenFile(*logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			log.Fatalf("無法打開日誌文件: %v", err)
+		}
+		defer f.Close()
+		log.SetOutput(f)
+	}
+
+	// 由 Windows 服務控制管理器啟動時接入服務控制，serviceDone 最後執行，在清理完成後回報已停止
+	serviceStop, serviceDone, err := startService()
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	defer serviceDone()
+
+	// 優先級由低到高：默認配置、配置文件、環境變量、命令行參數；重新載入時按相同的順序
+	loadConfig := func() (drandshuffle.Config, error) {
+		cfg, err := drandshuffle.LoadConfig(*configPath)
+		if err != nil {
+			return cfg, err
+		}
+		flag.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "urls":
+				cfg.URLs = strings.Split(*urlList, ",")
+			case "chain-hash":
+				cfg.ChainHash = *chainHash
+			case "connect-timeout":
+				cfg.ConnectTimeout = *connectTimeout
+			case "fetch-timeout":
+				cfg.FetchTimeout = *fetchTimeout
+			}
+		})
+		return cfg, nil
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		log.Fatalf("無法載入配置: %v", err)
+	}
+
+	if *printConfig {
+		if err := drandshuffle.PrintEffectiveConfig(os.Stdout, cfg); err != nil {
+			log.Fatalf("%v", err)
+		}
+		return
+	}
+
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("配置無效: %v", err)
+	}
+	if *interval <= 0 {
+		log.Fatalf("配置無效: 獲取間隔必須大於 0")
+	}
+	if *role != roleDealer && *role != roleAuditor {
+		log.Fatalf("配置無效: 未知的服務角色 %q，應為 %s 或 %s", *role, roleDealer, roleAuditor)
+	}
+	if *role == roleAuditor && *sessionsDB == "" {
+		log.Fatalf("配置無效: auditor 角色必須以 -sessions-db 指定從運營方複製的牌局數據庫")
+	}
+	if *sessionsRetention < 0 || (*sessionsRetention > 0 && (*sessionsDB == "" || *role == roleAuditor)) {
+		log.Fatalf("配置無效: -sessions-retention 必須不小於 0，且只能與 dealer 角色的 -sessions-db 一起使用")
+	}
+
+	// 在網絡邊緣單獨部署時由服務自己終止 TLS；在反向代理之後不需要設定
+	tlsConfig, certs, err := newTLSConfig(tlsOpts)
+	if err != nil {
+		log.Fatalf("配置無效: %v", err)
+	}
+
+	log.Println("啟動 drand 隨機信標服務...")
+
+	// 初始化 drand 客戶端，中繼地址等設定可以在運行時重新載入
+	s, err := newSettings(cfg, loadConfig, *interval, *verbose)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	defer s.client.Close()
+	s.certs = certs
+
+	// 成功獲取不再逐次記錄日誌，獲取狀態通過管理接口的 /healthz 提供
+	var stats fetchStats
+
+	var store *sqlstore.Store
+	if *sessionsDB != "" {
+		db, err := openSessionsDB(*sessionsDB, *role)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		defer db.Close()
+		store = sqlstore.New(db, sqlstore.SQLite)
+		if *sessionsRetention > 0 {
+			// 牌局按保存時間刪除；沒有設定 RoundTime，數據庫中的隨機信標不按年齡刪除
+			policy := drandshuffle.RetentionPolicy{MaxAge: *sessionsRetention}
+			stop := drandshuffle.StartCompaction(store, policy, time.Hour, slog.Default())
+			defer stop()
+		}
+	}
+
+	// 啟用管理接口，用於健康檢查和診斷生產環境的延遲問題
+	if *pprofAddr != "" {
+		go serveAdmin(*pprofAddr, &stats, s, store)
+	}
+
+	// 信標鏡像和洗牌接口共用同一個 DrandManager，重新載入中繼地址時緩存保持不變
+	if *mirrorAddr != "" || *apiAddr != "" {
+		dm, err := drandshuffle.NewDrandManagerWithClient(s.client, drandshuffle.WithConfig(cfg))
+		if err != nil {
+			log.Fatalf("無法創建 DrandManager: %v", err)
+		}
+		defer dm.Close()
+		dm.StartBackgroundFetching()
+		if *mirrorAddr != "" {
+			go serveMirror(*mirrorAddr, dm, tlsConfig)
+		}
+		if *apiAddr != "" {
+			go serveAPI(*apiAddr, dm, tlsConfig, *role, store)
+		}
+	}
+
+	// 洗牌器可以在多個 goroutine 間重複使用
+	shuffler := drandshuffle.NewShuffler(drandshuffle.StandardDeckTemplate)
+
+	// 設置信號處理，優雅地關閉服務；SIGHUP 重新載入配置
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
+	go supervise("reload", &stats, nil, func() {
+		for range hupChan {
+			if err := s.reload(reloadRequest{}); err != nil {
+				log.Printf("警告: 無法重新載入配置: %v", err)
+			}
+		}
+	})
+
+	// stop 在收到終止信號或服務停止請求時關閉
+	stop := make(chan struct{})
+
+	// 按間隔獲取最新的隨機信標
+	go supervise("fetch", &stats, stop, func() {
+		ticker := time.NewTicker(*interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case d := <-s.intervalChanged:
+				ticker.Reset(d)
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), time.Duration(s.fetchTimeout.Load()))
+				region := trace.StartRegion(ctx, "drand.fetchLatest")
+				result, err := s.client.Get(ctx, 0)
+				region.End()
+				cancel()
+
+				if err != nil {
+					stats.consecutiveFailures.Add(1)
+					log.Printf("警告: 無法獲取最新隨機信標: %v", err)
+					continue
+				}
+				stats.consecutiveFailures.Store(0)
+				stats.latestRound.Store(result.GetRound())
+
+				if !s.verbose.Load() {
+					continue
+				}
+
+				randomness := result.GetRandomness()
+				round := result.GetRound()
+				log.Printf("當前最新輪次: %d, 隨機性前 8 字節: %x", round, randomness[:8])
+
+				// 模擬遊戲發牌，加入遊戲局號以確保不同局次有不同的洗牌結果
+				gameSessionID := "demo_game_" + time.Now().Format("150405")
+				shuffledDeck := shuffler.Shuffle(randomness, gameSessionID)
+
+				log.Printf("模擬發牌: 第一張牌 %s%s, 最後一張牌 %s%s",
+					shuffledDeck[0].Suit, shuffledDeck[0].Value,
+					shuffledDeck[len(shuffledDeck)-1].Suit, shuffledDeck[len(shuffledDeck)-1].Value)
+			case <-stop:
+				return
+			}
+		}
+	})
+
+	// 初始化完成，通知 systemd 並開始發送看門狗心跳
+	notify("READY=1\nSTATUS=正在獲取 drand 隨機信標")
+	go runWatchdog(stop)
+
+	// 等待終止信號或服務停止請求
+	select {
+	case <-sigChan:
+	case <-serviceStop:
+	}
+	close(stop)
+	notify("STOPPING=1")
+	log.Println("收到終止信號，正在關閉服務...")
+	log.Println("服務已關閉")
+}
+
+// fetchStats 記錄後台獲取的結果，供 /healthz 讀取
+type fetchStats struct {
+	latestRound         atomic.Uint64
+	consecutiveFailures atomic.Uint64
+	panics              atomic.Uint64
+}
+
+// restartDelay 是後台 goroutine 從 panic 恢復後重新啟動前的等待時間，避免持續 panic 時空轉
+const restartDelay = time.Second
+
+// supervise 運行後台 goroutine 的 fn，fn 發生 panic 時記錄崩潰報告並在 restartDelay 後重新運行，
+// 使一個 goroutine 的錯誤不會讓整個服務退出；fn 正常返回或 stop 關閉後不再重新運行
+func supervise(name string, stats *fetchStats, stop <-chan struct{}, fn func()) {
+	for {
+		err := drandshuffle.CatchPanic(name, fn)
+		if err == nil {
+			return
+		}
+		stats.panics.Add(1)
+		var p *drandshuffle.PanicError
+		if errors.As(err, &p) {
+			log.Printf("錯誤: 後台任務 %s 發生 panic，%s 後重新啟動: %v\n%s", name, restartDelay, p.Value, p.Stack)
+		}
+		select {
+		case <-time.After(restartDelay):
+		case <-stop:
+			return
+		}
+	}
+}
+
+// unhealthyAfterFailures 連續獲取失敗達到此次數時 /healthz 返回 503
+const unhealthyAfterFailures = 3
+
+// serveHealth 以 JSON 返回獲取狀態，尚未獲取到信標或持續失敗時返回 503
+func (s *fetchStats) serveHealth(w nethttp.ResponseWriter, _ *nethttp.Request) {
+	round := s.latestRound.Load()
+	failures := s.consecutiveFailures.Load()
+
+	w.Header().Set("Content-Type", "application/json")
+	if round == 0 || failures >= unhealthyAfterFailures {
+		w.WriteHeader(nethttp.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(map[string]uint64{
+		"latest_round":         round,
+		"consecutive_failures": failures,
+		"panics":               s.panics.Load(),
+	})
+}
+
+// serveAdmin 在獨立的管理地址上提供 pprof、健康檢查和重新載入配置的接口，store 不為 nil 時同時提供牌局查詢
+// 只應監聽內部地址，不要暴露到公網
+func serveAdmin(addr string, stats *fetchStats, s *settings, store *sqlstore.Store) {
+	mux := nethttp.NewServeMux()
+	mux.HandleFunc("/healthz", stats.serveHealth)
+	mux.HandleFunc("/admin/reload", s.serveReload)
+	if store != nil {
+		mux.Handle("/sessions", sqlstore.NewSessionHandler(store))
+		mux.Handle("/graphql", sqlstore.NewGraphQLHandler(store))
+	}
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	log.Printf("管理接口已啟動: http://%s/debug/pprof/ 和 http://%s/healthz", addr, addr)
+	if err := nethttp.ListenAndServe(addr, mux); err != nil {
+		log.Printf("警告: 管理接口已停止: %v", err)
+	}
+}
+
+// serveMirror 以 drand 中繼 API 格式提供只讀的信標鏡像
+// 隔離網段內的服務可以把此地址當作中繼，例如設定 DRANDSHUFFLE_URLS=http://<地址>
+func serveMirror(addr string, dm *drandshuffle.DrandManager, tlsConfig *tls.Config) {
+	log.Printf("信標鏡像已啟動: %s://%s/public/latest", scheme(tlsConfig), addr)
+	if err := listenAndServe(addr, drandshuffle.NewMirrorHandler(dm), tlsConfig); err != nil {
+		log.Printf("警告: 信標鏡像已停止: %v", err)
+	}
+}
+
+// serveAPI 提供洗牌、驗證和信標接口，由庫提供的 http.Handler 組合而成
+// auditor 角色不註冊洗牌接口，改為在 store 上提供 /v1/report；接口不做身份驗證，公開部署時應放在反向代理或自己的認證中間件之後
+func serveAPI(addr string, dm *drandshuffle.DrandManager, tlsConfig *tls.Config, role string, store *sqlstore.Store) {
+	client := drandshuffle.NewClientWithManager(dm)
+	mux := nethttp.NewServeMux()
+	mux.Handle("/v1/verify", drandshuffle.NewVerifyHandler(client))
+	mux.Handle("/v1/beacon", drandshuffle.NewBeaconHandler(dm))
+	if role == roleAuditor {
+		mux.Handle("/v1/report", report.NewHandler(store))
+		log.Printf("審計接口已啟動（只讀，不能洗牌）: %s://%s/v1/verify 和 /v1/report", scheme(tlsConfig), addr)
+	} else {
+		mux.Handle("/v1/shuffle", drandshuffle.NewShuffleHandler(client))
+		log.Printf("洗牌接口已啟動: %s://%s/v1/shuffle", scheme(tlsConfig), addr)
+	}
+
+	if err := listenAndServe(addr, mux, tlsConfig); err != nil {
+		log.Printf("警告: 接口已停止: %v", err)
+	}
+}
+
+// 服務角色
+const (
+	// roleDealer 是運營方的發牌服務，提供洗牌接口並遷移牌局數據庫
+	roleDealer = "dealer"
+	// roleAuditor 是監管方以複製的數據運行的審計實例，不能洗牌，牌局數據庫只讀
+	roleAuditor = "auditor"
+)
+
+// openSessionsDB 打開牌局數據庫
+// dealer 角色創建或遷移表；auditor 角色以只讀方式打開從運營方複製的數據庫，不做任何寫入，表結構由運營方遷移
+func openSessionsDB(path, role string) (*sql.DB, error) {
+	dsn := path
+	if role == roleAuditor {
+		// 以 url.URL 轉義路徑，路徑中的 "?"、"#" 和 "%" 不會被當作 URI 的查詢參數而覆蓋 mode=ro；
+		// 相對路徑在 URI 中沒有明確的含義，先轉為絕對路徑
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("無法打開牌局數據庫: %w", err)
+		}
+		dsn = (&url.URL{Scheme: "file", Path: abs, RawQuery: "mode=ro"}).String()
+	}
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("無法打開牌局數據庫: %w", err)
+	}
+	if role == roleAuditor {
+		if err := db.PingContext(context.Background()); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("無法打開牌局數據庫: %w", err)
+		}
+		return db, nil
+	}
+	if err := sqlstore.New(db, sqlstore.SQLite).Migrate(context.Background()); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("無法遷移牌局數據庫: %w", err)
+	}
+	return db, nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// sdNotify 以 systemd 的 sd_notify 協議向服務管理器回報狀態，例如 "READY=1"
+// 沒有設定 NOTIFY_SOCKET（不是由 systemd 以 Type=notify 啟動）時不做任何事
+func sdNotify(state string) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+	// 以 @ 開頭的是 Linux 的抽象命名空間套接字
+	if socket[0] == '@' {
+		socket = "\x00" + socket[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return fmt.Errorf("無法連接 systemd 通知套接字: %w", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return fmt.Errorf("無法發送 systemd 通知: %w", err)
+	}
+	return nil
+}
+
+// notify 發送 systemd 通知，失敗時只記錄警告
+func notify(state string) {
+	if err := sdNotify(state); err != nil {
+		log.Printf("警告: %v", err)
+	}
+}
+
+// watchdogInterval 返回向 systemd 看門狗發送心跳的間隔，即 WatchdogSec 的一半
+// 單元沒有設定 WatchdogSec，或看門狗針對的是其他進程時返回 0
+func watchdogInterval() time.Duration {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond / 2
+}
+
+// runWatchdog 按 watchdogInterval 發送 WATCHDOG=1，直到 stop 關閉
+// 心跳只證明進程沒有卡死；中繼不可用時服務仍然存活，由 /healthz 反映，不會觸發 systemd 重啟
+func runWatchdog(stop <-chan struct{}) {
+	interval := watchdogInterval()
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			notify("WATCHDOG=1")
+		case <-stop:
+			return
+		}
+	}
+}
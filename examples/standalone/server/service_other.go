@@ -0,0 +1,9 @@
+//go:build !windows
+
+package main
+
+// startService 只在 Windows 上接入服務控制管理器，其他平台返回 nil 通道和空函數
+// systemd 的整合見 sdNotify，不需要單獨的運行模式
+func startService() (stop <-chan struct{}, done func(), err error) {
+	return nil, func() {}, nil
+}
@@ -0,0 +1,72 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/windows/svc"
+)
+
+// serviceName 是向 Windows 服務控制管理器註冊時使用的服務名稱
+const serviceName = "DrandShuffle"
+
+// startService 在進程由 Windows 服務控制管理器啟動時接入服務控制
+// 返回的 stop 在收到停止或關機請求時關閉；done 應在服務完成清理後調用，回報已停止並等待控制分派結束。
+// 從命令行運行時返回 nil 通道和空函數
+func startService() (stop <-chan struct{}, done func(), err error) {
+	isService, err := svc.IsWindowsService()
+	if err != nil {
+		return nil, nil, fmt.Errorf("無法判斷是否作為 Windows 服務運行: %w", err)
+	}
+	if !isService {
+		return nil, func() {}, nil
+	}
+
+	h := &windowsService{
+		stop:     make(chan struct{}),
+		finished: make(chan struct{}),
+		exited:   make(chan struct{}),
+	}
+	go func() {
+		defer close(h.exited)
+		svc.Run(serviceName, h)
+	}()
+	return h.stop, h.done, nil
+}
+
+// windowsService 實現 svc.Handler，將服務控制請求轉為關閉 stop
+type windowsService struct {
+	stop     chan struct{}
+	finished chan struct{}
+	exited   chan struct{}
+}
+
+// Execute 回報服務已啟動，收到停止或關機請求後等待主流程完成清理
+func (h *windowsService) Execute(_ []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	status <- svc.Status{State: svc.StartPending}
+	status <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+	for {
+		select {
+		case req := <-requests:
+			switch req.Cmd {
+			case svc.Interrogate:
+				status <- req.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				status <- svc.Status{State: svc.StopPending}
+				close(h.stop)
+				<-h.finished
+				return false, 0
+			}
+		case <-h.finished:
+			// 主流程因錯誤自行退出
+			return false, 0
+		}
+	}
+}
+
+// done 通知服務已完成清理，並等待服務控制管理器收到已停止的狀態
+func (h *windowsService) done() {
+	close(h.finished)
+	<-h.exited
+}
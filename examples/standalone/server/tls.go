@@ -0,0 +1,111 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	nethttp "net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// tlsOptions 是公開接口（-api 和 -mirror）終止 TLS 的設定，證明文件和 ACME 只能二選一
+// 部署在 Caddy、Traefik 等反向代理之後時都不設定，由代理終止 TLS
+type tlsOptions struct {
+	certFile, keyFile string
+	acmeDomains       string // 以逗號分隔的域名
+	acmeCache         string
+	acmeEmail         string
+	acmeHTTP          string // HTTP-01 驗證和跳轉到 HTTPS 的監聽地址，為空時只使用 TLS-ALPN-01
+}
+
+// newTLSConfig 按設定創建 tls.Config，都未設定時返回 nil，公開接口使用明文 HTTP
+// 使用證明文件時返回的 certReloader 可以在重新載入配置時重新讀取證書
+func newTLSConfig(o tlsOptions) (*tls.Config, *certReloader, error) {
+	switch {
+	case o.certFile != "" && o.acmeDomains != "":
+		return nil, nil, errors.New("-tls-cert 和 -acme-domains 不能同時設定")
+	case (o.certFile == "") != (o.keyFile == ""):
+		return nil, nil, errors.New("-tls-cert 和 -tls-key 必須同時設定")
+	case o.certFile != "":
+		certs := &certReloader{certFile: o.certFile, keyFile: o.keyFile}
+		if err := certs.reload(); err != nil {
+			return nil, nil, err
+		}
+		return &tls.Config{MinVersion: tls.VersionTLS12, GetCertificate: certs.getCertificate}, certs, nil
+	case o.acmeDomains != "":
+		m := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(strings.Split(o.acmeDomains, ",")...),
+			Cache:      autocert.DirCache(o.acmeCache),
+			Email:      o.acmeEmail,
+		}
+		if o.acmeHTTP != "" {
+			go serveACMEChallenge(o.acmeHTTP, m)
+		}
+		cfg := m.TLSConfig()
+		cfg.MinVersion = tls.VersionTLS12
+		return cfg, nil, nil
+	}
+	return nil, nil, nil
+}
+
+// serveACMEChallenge 響應 ACME 的 HTTP-01 驗證，其他請求跳轉到 HTTPS
+func serveACMEChallenge(addr string, m *autocert.Manager) {
+	log.Printf("ACME HTTP-01 驗證接口已啟動: http://%s", addr)
+	if err := newServer(addr, m.HTTPHandler(nil), nil).ListenAndServe(); err != nil {
+		log.Printf("警告: ACME HTTP-01 驗證接口已停止: %v", err)
+	}
+}
+
+// certReloader 保存從證書文件載入的證書，續期後重新載入配置即可生效，不必重新啟動
+type certReloader struct {
+	certFile, keyFile string
+	cert              atomic.Pointer[tls.Certificate]
+}
+
+// reload 重新讀取證書和私鑰，讀取失敗時保留原有的證書
+func (c *certReloader) reload() error {
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("無法載入 TLS 證書: %w", err)
+	}
+	c.cert.Store(&cert)
+	return nil
+}
+
+// getCertificate 實現 tls.Config.GetCertificate
+func (c *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return c.cert.Load(), nil
+}
+
+// newServer 創建公開接口使用的 http.Server，限制讀取請求頭的時間以免慢速連接佔用資源
+func newServer(addr string, handler nethttp.Handler, tlsConfig *tls.Config) *nethttp.Server {
+	return &nethttp.Server{
+		Addr:              addr,
+		Handler:           handler,
+		TLSConfig:         tlsConfig,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
+
+// listenAndServe 在 tlsConfig 不為 nil 時以 HTTPS 提供服務，否則使用明文 HTTP
+func listenAndServe(addr string, handler nethttp.Handler, tlsConfig *tls.Config) error {
+	srv := newServer(addr, handler, tlsConfig)
+	if tlsConfig != nil {
+		return srv.ListenAndServeTLS("", "")
+	}
+	return srv.ListenAndServe()
+}
+
+// scheme 返回公開接口的 URL 協議，用於日誌
+func scheme(tlsConfig *tls.Config) string {
+	if tlsConfig != nil {
+		return "https"
+	}
+	return "http"
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/drand/go-clients/client"
+	"github.com/drand/go-clients/client/http"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+)
+
+// ui 是命令行輸出的消息目錄
+var ui = drandshuffle.Catalog{
+	drandshuffle.LocaleZhTW: {
+		"title":           "德州撲克遊戲 (輪次: %d, 遊戲局號: %s)",
+		"hand":            "玩家 %d 的手牌: %s, %s",
+		"community":       "公共牌:",
+		"flop":            "翻牌: %s",
+		"turn":            "轉牌: %s",
+		"river":           "河牌: %s",
+		"round_used":      "遊戲使用的輪次號碼: %d",
+		"session_used":    "遊戲使用的遊戲局號: %s",
+		"reproducible":    "任何人都可以使用相同的輪次號碼和遊戲局號重現完全相同的發牌結果。",
+		"verify_cmd":      "驗證命令: go run texas_holdem.go %d %s",
+		"network_warning": "警告: 無法連接到 drand 網絡，請檢查您的網絡連接。",
+		"error_detail":    "錯誤詳情: %s",
+		"retry_hint":      "您可以稍後再試，或使用本地隨機源作為備用。",
+		"invalid_round":   "無效的輪次號碼: %v",
+		"invalid_session": "無效的遊戲局號: %s",
+		"session_failed":  "無法生成遊戲局號: %v",
+		"config_failed":   "無法載入配置: %v",
+		"init_failed":     "無法初始化 DrandManager: %s",
+		"create_failed":   "無法創建遊戲: %s",
+	},
+	drandshuffle.LocaleEn: {
+		"title":           "Texas Hold'em (round: %d, session ID: %s)",
+		"hand":            "Player %d hand: %s, %s",
+		"community":       "Community cards:",
+		"flop":            "Flop: %s",
+		"turn":            "Turn: %s",
+		"river":           "River: %s",
+		"round_used":      "Round used by this game: %d",
+		"session_used":    "Session ID used by this game: %s",
+		"reproducible":    "Anyone can reproduce exactly the same deal from the same round and session ID.",
+		"verify_cmd":      "Verify with: go run texas_holdem.go %d %s",
+		"network_warning": "Warning: could not reach the drand network, please check your connection.",
+		"error_detail":    "Details: %s",
+		"retry_hint":      "Try again later, or fall back to a local randomness source.",
+		"invalid_round":   "Invalid round number: %v",
+		"invalid_session": "Invalid session ID: %s",
+		"session_failed":  "Could not generate a session ID: %v",
+		"config_failed":   "Could not load configuration: %v",
+		"init_failed":     "Could not initialise the DrandManager: %s",
+		"create_failed":   "Could not create the game: %s",
+	},
+}
+
+// 德州撲克遊戲狀態
+type TexasHoldemGame struct {
+	// 玩家手牌，每個玩家2張牌
+	PlayerHands map[int][]drandshuffle.Card
+
+	// 公共牌（翻牌、轉牌、河牌）
+	CommunityCards []drandshuffle.Card
+
+	// 使用的輪次號碼，用於驗證
+	Round uint64
+
+	// 遊戲局號，用於確保不同局次有不同的洗牌結果
+	GameSessionID string
+}
+
+// 初始化新的德州撲克遊戲
+func NewTexasHoldemGame(numPlayers int, round uint64, gameSessionID string) (*TexasHoldemGame, error) {
+	if numPlayers < 2 || numPlayers > 10 {
+		return nil, fmt.Errorf("玩家數量必須在2到10之間")
+	}
+
+	var shuffledDeck []drandshuffle.Card
+	var err error
+	var newRound uint64
+
+	// 如果指定了輪次號碼，使用該輪次的隨機信標
+	if round > 0 {
+		shuffledDeck, err = GetShuffledDeckByRound(round, gameSessionID)
+		if err != nil {
+			return nil, fmt.Errorf("無法獲取洗牌後的牌組: %w", err)
+		}
+		newRound = round
+	} else {
+		// 否則使用最新的隨機信標
+		shuffledDeck, newRound, err = GetShuffledDeck(gameSessionID)
+		if err != nil {
+			return nil, fmt.Errorf("無法獲取洗牌後的牌組: %w", err)
+		}
+	}
+
+	// 初始化遊戲
+	game := &TexasHoldemGame{
+		PlayerHands:    make(map[int][]drandshuffle.Card),
+		CommunityCards: make([]drandshuffle.Card, 0, 5),
+		Round:          newRound,
+		GameSessionID:  gameSessionID,
+	}
+
+	// 確保有足夠的牌：每個玩家2張牌 + 5張公共牌
+	dealer := drandshuffle.NewDealer(shuffledDeck)
+	if err := dealer.Require(numPlayers*2 + 5); err != nil {
+		return nil, err
+	}
+
+	// 發牌：每個玩家2張牌
+	hands, _ := dealer.DealHands(numPlayers, 2)
+	for player, hand := range hands {
+		game.PlayerHands[player] = hand
+	}
+
+	// 發公共牌：5張
+	game.CommunityCards, _ = dealer.Deal(5)
+
+	return game, nil
+}
+
+// 顯示遊戲狀態，牌名和提示文字使用指定的語言
+func (g *TexasHoldemGame) DisplayGame(locale drandshuffle.Locale) {
+	fmt.Println(ui.Sprintf(locale, "title", g.Round, g.GameSessionID))
+	fmt.Println()
+
+	// 顯示玩家手牌
+	for player, cards := range g.PlayerHands {
+		fmt.Println(ui.Sprintf(locale, "hand", player+1,
+			drandshuffle.CardName(cards[0], locale),
+			drandshuffle.CardName(cards[1], locale)))
+	}
+
+	// 顯示公共牌
+	fmt.Println()
+	fmt.Println(ui.Sprintf(locale, "community"))
+
+	// 翻牌 (前3張)
+	flop := make([]string, 3)
+	for i := range flop {
+		flop[i] = drandshuffle.CardName(g.CommunityCards[i], locale)
+	}
+	fmt.Println(ui.Sprintf(locale, "flop", strings.Join(flop, ", ")))
+
+	// 轉牌 (第4張)
+	fmt.Println(ui.Sprintf(locale, "turn", drandshuffle.CardName(g.CommunityCards[3], locale)))
+
+	// 河牌 (第5張)
+	fmt.Println(ui.Sprintf(locale, "river", drandshuffle.CardName(g.CommunityCards[4], locale)))
+}
+
+// 獲取遊戲使用的輪次號碼
+func (g *TexasHoldemGame) GetRound() uint64 {
+	return g.Round
+}
+
+// 獲取遊戲使用的遊戲局號
+func (g *TexasHoldemGame) GetGameSessionID() string {
+	return g.GameSessionID
+}
+
+// shuffler 使用標準52張撲克牌的洗牌器，結果與 drandshuffle.GetShuffledDeck 相同
+var shuffler = drandshuffle.NewShuffler(drandshuffle.StandardDeckTemplate)
+
+// GetShuffledDeck 返回使用最新drand隨機信標洗牌後的牌組
+// gameSessionID 參數用於確保不同遊戲局次有不同的洗牌結果
+// 返回洗好的牌組和使用的輪次號碼
+func GetShuffledDeck(gameSessionID string) ([]drandshuffle.Card, uint64, error) {
+	// 獲取最新的隨機性和輪次號碼
+	randomness, round, err := getDrandRandomness(0)
+	if err != nil {
+		return nil, 0, fmt.Errorf("無法獲取最新隨機性: %w", err)
+	}
+
+	// 加入遊戲局號以確保不同局次有不同的洗牌結果
+	shuffledDeck := shuffler.Shuffle(randomness, gameSessionID)
+
+	return shuffledDeck, round, nil
+}
+
+// GetShuffledDeckByRound 返回使用指定輪次drand隨機信標洗牌後的牌組
+// gameSessionID 參數用於確保不同遊戲局次有不同的洗牌結果
+func GetShuffledDeckByRound(round uint64, gameSessionID string) ([]drandshuffle.Card, error) {
+	// 獲取指定輪次的隨機性
+	randomness, err := getDrandRandomnessByRound(round)
+	if err != nil {
+		return nil, fmt.Errorf("無法獲取輪次 %d 的隨機性: %w", round, err)
+	}
+
+	// 加入遊戲局號以確保不同局次有不同的洗牌結果
+	shuffledDeck := shuffler.Shuffle(randomness, gameSessionID)
+
+	return shuffledDeck, nil
+}
+
+// 獲取drand最新隨機信標
+func getDrandRandomness(round uint64) ([]byte, uint64, error) {
+	// 使用默認配置，可通過配置文件（DRANDSHUFFLE_CONFIG）和環境變量覆蓋
+	cfg, err := drandshuffle.LoadConfig(os.Getenv("DRANDSHUFFLE_CONFIG"))
+	if err != nil {
+		return nil, 0, fmt.Errorf("無法載入配置: %w", err)
+	}
+	chainHash, err := hex.DecodeString(cfg.ChainHash)
+	if err != nil {
+		return nil, 0, fmt.Errorf("無法解碼鏈哈希: %v", err)
+	}
+
+	// 創建上下文，連接超時取自配置
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnectTimeout)
+	defer cancel()
+
+	// 創建drand客戶端
+	drandClient, err := client.New(
+		client.From(http.ForURLs(ctx, nil, cfg.URLs, chainHash)...),
+		client.WithChainHash(chainHash),
+	)
+
+	if err != nil {
+		return nil, 0, networkError(fmt.Errorf("無法創建drand客戶端: %w", err))
+	}
+	defer drandClient.Close()
+
+	// 創建新的上下文用於獲取隨機信標，獲取超時取自配置
+	getCtx, getCancel := context.WithTimeout(context.Background(), cfg.FetchTimeout)
+	defer getCancel()
+
+	// 獲取隨機信標
+	result, err := drandClient.Get(getCtx, round)
+	if err != nil {
+		if round == 0 {
+			return nil, 0, networkError(fmt.Errorf("無法獲取最新隨機信標: %w", err))
+		}
+		return nil, 0, networkError(fmt.Errorf("無法獲取輪次 %d 的隨機信標: %w", round, err))
+	}
+
+	return result.GetRandomness(), result.GetRound(), nil
+}
+
+// networkError 將錯誤標記為網絡錯誤，調用者可以用 drandshuffle.IsRetryable 判斷是否重試
+func networkError(err error) error {
+	return &drandshuffle.Error{Category: drandshuffle.CategoryNetwork, Err: err}
+}
+
+// 獲取指定輪次的drand隨機信標
+func getDrandRandomnessByRound(round uint64) ([]byte, error) {
+	randomness, _, err := getDrandRandomness(round)
+	return randomness, err
+}
+
+func main() {
+	// 顯示語言由 DRANDSHUFFLE_LANG 或系統的 LANG 等環境變量決定
+	locale := drandshuffle.LocaleFromEnv()
+
+	// 檢查命令行參數
+	var round uint64 = 0
+	var err error
+	var gameSessionID string
+
+	// 處理命令行參數
+	if len(os.Args) > 1 {
+		round, err = strconv.ParseUint(os.Args[1], 10, 64)
+		if err != nil {
+			log.Fatal(ui.Sprintf(locale, "invalid_round", err))
+		}
+	}
+
+	if len(os.Args) > 2 {
+		gameSessionID = os.Args[2]
+		if err := drandshuffle.ValidateSessionID(gameSessionID); err != nil {
+			log.Fatal(ui.Sprintf(locale, "invalid_session", drandshuffle.ErrorMessage(err, locale)))
+		}
+	} else {
+		// 使用加密安全的隨機遊戲局號
+		gameSessionID, err = drandshuffle.NewSessionID()
+		if err != nil {
+			log.Fatal(ui.Sprintf(locale, "session_failed", err))
+		}
+	}
+
+	// 創建一個4人的德州撲克遊戲
+	game, err := NewTexasHoldemGame(4, round, gameSessionID)
+	if err != nil {
+		// 處理網絡錯誤
+		if drandshuffle.IsRetryable(err) {
+			fmt.Println(ui.Sprintf(locale, "network_warning"))
+			fmt.Println(ui.Sprintf(locale, "error_detail", drandshuffle.ErrorMessage(err, locale)))
+			fmt.Println(ui.Sprintf(locale, "retry_hint"))
+			os.Exit(1)
+		}
+		log.Fatal(ui.Sprintf(locale, "create_failed", drandshuffle.ErrorMessage(err, locale)))
+	}
+
+	// 顯示遊戲狀態
+	game.DisplayGame(locale)
+
+	// 輸出驗證信息
+	fmt.Println()
+	fmt.Println(ui.Sprintf(locale, "round_used", game.GetRound()))
+	fmt.Println(ui.Sprintf(locale, "session_used", game.GetGameSessionID()))
+	fmt.Println(ui.Sprintf(locale, "reproducible"))
+	fmt.Println(ui.Sprintf(locale, "verify_cmd", game.GetRound(), game.GetGameSessionID()))
+}
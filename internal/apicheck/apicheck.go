@@ -0,0 +1,234 @@
+// Package apicheck 列出 Go 包的導出 API，並與保存的 API 清單比較，用於發布前檢查兼容性
+//
+// 每個導出的函數、方法、類型、結構體欄位、接口方法、常量和變量各佔一行，參數名稱不計入。
+// 清單中已有的行在當前代碼中消失即為不兼容的修改；新增的行是兼容的。
+package apicheck
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Features 返回目錄 dir 中 Go 包（不含測試文件）的導出 API，每行一項，已排序去重
+func Features(dir string) ([]string, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi fs.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("無法解析 %s: %w", dir, err)
+	}
+
+	set := make(map[string]struct{})
+	for name, pkg := range pkgs {
+		w := &walker{fset: fset, prefix: "pkg " + name + ", ", features: set}
+		for _, file := range pkg.Files {
+			w.file(file)
+		}
+	}
+
+	features := make([]string, 0, len(set))
+	for f := range set {
+		features = append(features, f)
+	}
+	sort.Strings(features)
+	return features, nil
+}
+
+// Compare 比較保存的 API 清單和當前的 API，返回消失的項和新增的項
+func Compare(golden, current []string) (removed, added []string) {
+	cur := make(map[string]bool, len(current))
+	for _, f := range current {
+		cur[f] = true
+	}
+	old := make(map[string]bool, len(golden))
+	for _, f := range golden {
+		old[f] = true
+		if !cur[f] {
+			removed = append(removed, f)
+		}
+	}
+	for _, f := range current {
+		if !old[f] {
+			added = append(added, f)
+		}
+	}
+	return removed, added
+}
+
+// ReadFile 讀取 API 清單，忽略空行和以 # 開頭的註釋
+func ReadFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("無法讀取 API 清單: %w", err)
+	}
+	var features []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		features = append(features, line)
+	}
+	return features, nil
+}
+
+// walker 收集一個包的導出 API
+type walker struct {
+	fset     *token.FileSet
+	prefix   string
+	features map[string]struct{}
+}
+
+func (w *walker) emit(format string, args ...any) {
+	w.features[w.prefix+fmt.Sprintf(format, args...)] = struct{}{}
+}
+
+func (w *walker) file(file *ast.File) {
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			w.funcDecl(d)
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					w.typeSpec(s)
+				case *ast.ValueSpec:
+					w.valueSpec(d.Tok, s)
+				}
+			}
+		}
+	}
+}
+
+func (w *walker) funcDecl(d *ast.FuncDecl) {
+	if !d.Name.IsExported() {
+		return
+	}
+	if d.Recv == nil {
+		w.emit("func %s%s", d.Name.Name, w.signature(d.Type))
+		return
+	}
+
+	recv := d.Recv.List[0].Type
+	base := recv
+	if star, ok := base.(*ast.StarExpr); ok {
+		base = star.X
+	}
+	if index, ok := base.(*ast.IndexExpr); ok {
+		base = index.X
+	}
+	if ident, ok := base.(*ast.Ident); !ok || !ident.IsExported() {
+		return
+	}
+	w.emit("method (%s) %s%s", w.expr(recv), d.Name.Name, w.signature(d.Type))
+}
+
+func (w *walker) typeSpec(s *ast.TypeSpec) {
+	if !s.Name.IsExported() {
+		return
+	}
+	name := s.Name.Name
+	if s.Assign.IsValid() {
+		w.emit("type %s = %s", name, w.expr(s.Type))
+		return
+	}
+
+	switch t := s.Type.(type) {
+	case *ast.StructType:
+		w.emit("type %s struct", name)
+		for _, field := range t.Fields.List {
+			if len(field.Names) == 0 {
+				w.emit("type %s struct, embedded %s", name, w.expr(field.Type))
+				continue
+			}
+			for _, n := range field.Names {
+				if n.IsExported() {
+					w.emit("type %s struct, %s %s", name, n.Name, w.expr(field.Type))
+				}
+			}
+		}
+	case *ast.InterfaceType:
+		w.emit("type %s interface", name)
+		for _, m := range t.Methods.List {
+			if len(m.Names) == 0 {
+				w.emit("type %s interface, embedded %s", name, w.expr(m.Type))
+				continue
+			}
+			for _, n := range m.Names {
+				if ft, ok := m.Type.(*ast.FuncType); ok {
+					w.emit("type %s interface, %s%s", name, n.Name, w.signature(ft))
+				}
+			}
+		}
+	default:
+		w.emit("type %s %s", name, w.expr(s.Type))
+	}
+}
+
+func (w *walker) valueSpec(tok token.Token, s *ast.ValueSpec) {
+	for _, n := range s.Names {
+		if !n.IsExported() {
+			continue
+		}
+		if s.Type != nil {
+			w.emit("%s %s %s", tok, n.Name, w.expr(s.Type))
+		} else {
+			w.emit("%s %s", tok, n.Name)
+		}
+	}
+}
+
+// signature 返回不含參數名稱的函數簽名
+func (w *walker) signature(ft *ast.FuncType) string {
+	var b strings.Builder
+	b.WriteString("(")
+	b.WriteString(w.fieldTypes(ft.Params))
+	b.WriteString(")")
+	if ft.Results != nil && len(ft.Results.List) > 0 {
+		results := w.fieldTypes(ft.Results)
+		if len(ft.Results.List) == 1 && len(ft.Results.List[0].Names) <= 1 {
+			b.WriteString(" " + results)
+		} else {
+			b.WriteString(" (" + results + ")")
+		}
+	}
+	return b.String()
+}
+
+// fieldTypes 返回以逗號分隔的欄位類型，多個名稱共享一個類型時重複該類型
+func (w *walker) fieldTypes(list *ast.FieldList) string {
+	if list == nil {
+		return ""
+	}
+	var types []string
+	for _, field := range list.List {
+		t := w.expr(field.Type)
+		n := len(field.Names)
+		if n == 0 {
+			n = 1
+		}
+		for i := 0; i < n; i++ {
+			types = append(types, t)
+		}
+	}
+	return strings.Join(types, ", ")
+}
+
+// expr 將表達式格式化為單行源碼
+func (w *walker) expr(e ast.Expr) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, w.fset, e); err != nil {
+		return fmt.Sprintf("<%v>", err)
+	}
+	return strings.Join(strings.Fields(buf.String()), " ")
+}
@@ -2,11 +2,12 @@ package tests
 
 import (
 	"crypto/sha256"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
-	"go_drand/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
 )
 
 // TestStringToCardErrors 測試 StringToCard 函數的錯誤處理
@@ -51,7 +52,7 @@ func TestStringToCardErrors(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			_, err := drandshuffle.StringToCard(tc.cardString)
-			assert.Error(t, err)
+			assert.ErrorIs(t, err, drandshuffle.ErrInvalidCard)
 			assert.Contains(t, err.Error(), tc.expectedErr)
 		})
 	}
@@ -170,3 +171,51 @@ func TestShuffleReproducibility(t *testing.T) {
 		assert.True(t, different, "Shuffles with different game session IDs should produce different results")
 	})
 }
+
+// TestShuffler 測試洗牌器與原有的洗牌流程結果一致，並可被並發使用
+func TestShuffler(t *testing.T) {
+	randomness := []byte("test_randomness_for_shuffler_0123")
+	gameSessionID := "test_game_session_id"
+
+	// 按照 GetShuffledDeck 的方式創建擴展隨機性
+	hasher := sha256.New()
+	hasher.Write(randomness)
+	hasher.Write([]byte(gameSessionID))
+	extendedRandomness := hasher.Sum(append([]byte(nil), randomness...))
+	expected := drandshuffle.ShuffleDeck(drandshuffle.InitializeDeck(), extendedRandomness)
+
+	shuffler := drandshuffle.NewShuffler(drandshuffle.StandardDeckTemplate)
+
+	t.Run("Matches ShuffleDeck", func(t *testing.T) {
+		assert.Equal(t, expected, shuffler.Shuffle(randomness, gameSessionID))
+	})
+
+	t.Run("ShuffleInto reuses buffer", func(t *testing.T) {
+		buf := make([]drandshuffle.Card, 0, 52)
+		deck := shuffler.ShuffleInto(buf, randomness, gameSessionID)
+		assert.Equal(t, expected, deck)
+		assert.Equal(t, &buf[:1][0], &deck[0], "ShuffleInto should write into the provided buffer")
+	})
+
+	t.Run("Does not modify randomness", func(t *testing.T) {
+		input := make([]byte, len(randomness), len(randomness)+64)
+		copy(input, randomness)
+		shuffler.Shuffle(input, gameSessionID)
+		assert.Equal(t, randomness, input[:len(randomness)])
+		assert.Equal(t, make([]byte, 64), input[len(randomness):cap(input)], "Spare capacity should be untouched")
+	})
+
+	t.Run("Concurrent use", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 16; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					assert.Equal(t, expected, shuffler.Shuffle(randomness, gameSessionID))
+				}
+			}()
+		}
+		wg.Wait()
+	})
+}
@@ -0,0 +1,26 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/internal/apicheck"
+)
+
+// TestAPICompatibility 測試 api/v1.txt 中的每一項 API 仍然存在且簽名不變
+// 新增 API 後應運行 go run ./tools/apicheck -write 更新清單
+func TestAPICompatibility(t *testing.T) {
+	golden, err := apicheck.ReadFile("../api/v1.txt")
+	assert.NoError(t, err)
+
+	var current []string
+	for _, dir := range []string{"../drandshuffle", "../drandshuffle/drandshuffletest", "../drandshuffle/drandshufflepb", "../drandshuffle/games/holdem", "../drandshuffle/games/bigtwo", "../drandshuffle/games/doudizhu", "../drandshuffle/games/keno", "../drandshuffle/games/bingo", "../drandshuffle/games/scratch", "../drandshuffle/games/slots", "../drandshuffle/giveaway", "../drandshuffle/simulate", "../drandshuffle/report", "../drandshuffle/audit", "../drandshuffle/sqlstore", "../drandshuffle/archive", "../drandshuffle/evm", "../drandshuffle/events", "../drandshuffle/mobile", "../drandshuffle/schedule"} {
+		features, err := apicheck.Features(dir)
+		assert.NoError(t, err)
+		current = append(current, features...)
+	}
+
+	removed, _ := apicheck.Compare(golden, current)
+	assert.Empty(t, removed, "v1 API 出現不兼容的修改")
+}
@@ -0,0 +1,305 @@
+package tests
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/archive"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/drandshuffletest"
+)
+
+// archiveEntries 返回指定輪次的局，每局都附帶洗牌證明
+func archiveEntries(t *testing.T, rounds ...uint64) []archive.Entry {
+	t.Helper()
+	src := drandshuffletest.NewFakeBeaconSource(1000)
+	client := drandshuffle.NewClientWithManager(drandshuffletest.NewManager(t, src))
+	entries := make([]archive.Entry, len(rounds))
+	for i, round := range rounds {
+		id := "game_" + string(rune('a'+i))
+		result, err := client.NewShuffle().Session(id).Round(round).WithProof().Do(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries[i] = archive.NewEntry(result, id)
+	}
+	return entries
+}
+
+// TestBundle 測試證明包的封存和驗證
+func TestBundle(t *testing.T) {
+	entries := archiveEntries(t, 990, 991)
+	bundle, err := archive.Seal("batch", entries, time.Unix(1_700_000_000, 0))
+	assert.NoError(t, err)
+	assert.NoError(t, bundle.Verify(nil))
+
+	data, err := json.Marshal(bundle)
+	assert.NoError(t, err)
+	var decoded archive.Bundle
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.NoError(t, decoded.Verify(nil), "A bundle should verify after a JSON round trip")
+
+	decoded.Entries[1].Round = 992
+	assert.ErrorIs(t, decoded.Verify(nil), archive.ErrBundleTampered)
+
+	// 重新封存被修改的牌組時摘要一致，但證明無法重現牌組
+	forged := append([]archive.Entry(nil), entries...)
+	deck, _ := drandshuffle.DecodeDeck(forged[0].Deck)
+	deck[0], deck[1] = deck[1], deck[0]
+	forged[0].Deck = drandshuffle.EncodeDeck(deck)
+	resealed, err := archive.Seal("batch", forged, time.Now())
+	assert.NoError(t, err)
+	assert.ErrorIs(t, resealed.Verify(nil), drandshuffle.ErrDeckMismatch)
+}
+
+// recordingSink 記錄上傳的證明包
+type recordingSink struct {
+	mu      sync.Mutex
+	bundles []*archive.Bundle
+}
+
+func (s *recordingSink) Archive(ctx context.Context, bundle *archive.Bundle) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bundles = append(s.bundles, bundle)
+	return nil
+}
+
+func (s *recordingSink) names() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := make([]string, len(s.bundles))
+	for i, b := range s.bundles {
+		names[i] = b.Name
+	}
+	return names
+}
+
+// TestArchiver 測試按局和按輪次批次自動上傳證明包
+func TestArchiver(t *testing.T) {
+	ctx := context.Background()
+	entries := archiveEntries(t, 101, 150, 201, 120)
+
+	t.Run("Per session", func(t *testing.T) {
+		sink := &recordingSink{}
+		archiver := &archive.Archiver{Sink: sink}
+		for _, e := range entries[:2] {
+			assert.NoError(t, archiver.Add(ctx, e))
+		}
+		assert.Equal(t, []string{"game_a", "game_b"}, sink.names())
+	})
+
+	t.Run("Per round batch", func(t *testing.T) {
+		sink := &recordingSink{}
+		archiver := &archive.Archiver{Sink: sink, BatchRounds: 100}
+		assert.NoError(t, archiver.Add(ctx, entries[0]))
+		assert.NoError(t, archiver.Add(ctx, entries[1]))
+		assert.Empty(t, sink.names(), "A batch is uploaded once it is complete")
+
+		assert.NoError(t, archiver.Add(ctx, entries[2]))
+		assert.Equal(t, []string{"rounds-101-200"}, sink.names())
+		assert.Len(t, sink.bundles[0].Entries, 2)
+
+		assert.NoError(t, archiver.Add(ctx, entries[3]), "A late entry should not overwrite an uploaded batch")
+		assert.Equal(t, []string{"rounds-101-200", "game_d"}, sink.names())
+
+		assert.NoError(t, archiver.Flush(ctx))
+		assert.Equal(t, []string{"rounds-101-200", "game_d", "rounds-201-300"}, sink.names())
+		assert.NoError(t, archiver.Flush(ctx))
+		assert.Len(t, sink.names(), 3)
+		for _, b := range sink.bundles {
+			assert.NoError(t, b.Verify(nil))
+		}
+	})
+
+	t.Run("Directory sink", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "proofs")
+		archiver := &archive.Archiver{Sink: archive.DirSink(dir)}
+		assert.NoError(t, archiver.Add(ctx, entries[0]))
+		data, err := os.ReadFile(filepath.Join(dir, "game_a.json"))
+		assert.NoError(t, err)
+		var bundle archive.Bundle
+		assert.NoError(t, json.Unmarshal(data, &bundle))
+		assert.NoError(t, bundle.Verify(nil))
+	})
+
+	t.Run("Invalid entry", func(t *testing.T) {
+		archiver := &archive.Archiver{Sink: &recordingSink{}, BatchRounds: 100}
+		assert.ErrorIs(t, archiver.Add(ctx, archive.Entry{SessionID: "game_x"}), drandshuffle.ErrRoundBeforeGenesis)
+		assert.ErrorIs(t, archiver.Add(ctx, archive.Entry{Round: 1}), drandshuffle.ErrInvalidSessionID)
+	})
+}
+
+// TestObjectStoreSink 測試上傳到 S3 兼容存儲的請求
+func TestObjectStoreSink(t *testing.T) {
+	type upload struct {
+		path   string
+		header http.Header
+		body   []byte
+	}
+	uploads := make(chan upload, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != http.MethodPut {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if strings.Contains(r.URL.Path, "denied") {
+			http.Error(w, "AccessDenied", http.StatusForbidden)
+			return
+		}
+		uploads <- upload{path: r.URL.EscapedPath(), header: r.Header, body: body}
+	}))
+	defer server.Close()
+
+	bundle, err := archive.Seal("table:7", archiveEntries(t, 990), time.Now())
+	assert.NoError(t, err)
+
+	sink, err := archive.NewS3Sink(archive.ObjectStoreConfig{
+		Endpoint:        server.URL,
+		Region:          "us-east-1",
+		Bucket:          "records",
+		Prefix:          "proofs/",
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "secret",
+		RetainFor:       7 * 365 * 24 * time.Hour,
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, sink.Archive(context.Background(), bundle))
+
+	got := <-uploads
+	assert.Equal(t, "/records/proofs/table%3A7.json", got.path)
+	md5sum := md5.Sum(got.body)
+	assert.Equal(t, base64.StdEncoding.EncodeToString(md5sum[:]), got.header.Get("Content-MD5"))
+	sum := sha256.Sum256(got.body)
+	assert.Equal(t, hex.EncodeToString(sum[:]), got.header.Get("X-Amz-Content-Sha256"))
+	assert.Equal(t, "COMPLIANCE", got.header.Get("X-Amz-Object-Lock-Mode"))
+	retainUntil, err := time.Parse(time.RFC3339, got.header.Get("X-Amz-Object-Lock-Retain-Until-Date"))
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(7*365*24*time.Hour), retainUntil, time.Minute)
+	auth := got.header.Get("Authorization")
+	assert.True(t, strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/"), auth)
+	assert.Contains(t, auth, "/us-east-1/s3/aws4_request, SignedHeaders=content-md5;content-type;host;x-amz-content-sha256;x-amz-date;x-amz-object-lock-mode;x-amz-object-lock-retain-until-date, Signature=")
+
+	var uploaded archive.Bundle
+	assert.NoError(t, json.Unmarshal(got.body, &uploaded))
+	assert.NoError(t, uploaded.Verify(nil))
+
+	t.Run("GCS", func(t *testing.T) {
+		gcs, err := archive.NewGCSSink(archive.ObjectStoreConfig{
+			Endpoint:        server.URL,
+			Bucket:          "records",
+			AccessKeyID:     "GOOGEXAMPLE",
+			SecretAccessKey: "secret",
+			RetainFor:       time.Hour,
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, gcs.Archive(context.Background(), bundle))
+		got := <-uploads
+		assert.Equal(t, "/records/table%3A7.json", got.path)
+		assert.Contains(t, got.header.Get("Authorization"), "/auto/s3/aws4_request")
+		assert.Empty(t, got.header.Get("X-Amz-Object-Lock-Mode"), "GCS uses bucket retention policies")
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		denied, err := archive.NewS3Sink(archive.ObjectStoreConfig{
+			Endpoint: server.URL, Region: "us-east-1", Bucket: "denied", AccessKeyID: "id", SecretAccessKey: "secret",
+		})
+		assert.NoError(t, err)
+		err = denied.Archive(context.Background(), bundle)
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "403")
+			assert.Contains(t, err.Error(), "AccessDenied")
+		}
+
+		_, err = archive.NewS3Sink(archive.ObjectStoreConfig{Bucket: "records"})
+		assert.Error(t, err)
+	})
+}
+
+// TestFairnessFile 測試以公平性文件交換完整的牌局記錄
+func TestFairnessFile(t *testing.T) {
+	bundle, err := archive.Seal("game_a", archiveEntries(t, 990), time.Unix(1_700_000_000, 0))
+	if !assert.NoError(t, err) {
+		return
+	}
+	file := &archive.FairnessFile{
+		Bundle:    bundle,
+		Metadata:  map[string]string{"table": "7", "blinds": "1/2"},
+		CreatedAt: time.Unix(1_700_000_100, 0),
+	}
+	var buf bytes.Buffer
+	assert.NoError(t, archive.WriteFairnessFile(&buf, file))
+	var again bytes.Buffer
+	assert.NoError(t, archive.WriteFairnessFile(&again, file))
+	assert.Equal(t, buf.Bytes(), again.Bytes(), "The same record should produce the same file")
+
+	path := filepath.Join(t.TempDir(), "game_a"+archive.FairnessFileExt)
+	assert.NoError(t, os.WriteFile(path, buf.Bytes(), 0o600))
+	read, err := archive.OpenFairnessFile(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, drandshuffle.ProofAlgorithm, read.Verifier)
+	assert.Equal(t, file.Metadata, read.Metadata)
+	assert.True(t, file.CreatedAt.Equal(read.CreatedAt))
+	assert.Equal(t, bundle.Digest, read.Bundle.Digest)
+	assert.NoError(t, read.Verify(nil))
+
+	// rewrite 複製公平性文件，以 modify 修改指定文件的內容
+	rewrite := func(name string, modify func([]byte) []byte) []byte {
+		zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out bytes.Buffer
+		zw := zip.NewWriter(&out)
+		for _, f := range zr.File {
+			rc, _ := f.Open()
+			data, _ := io.ReadAll(rc)
+			rc.Close()
+			if f.Name == name {
+				data = modify(data)
+			}
+			w, _ := zw.Create(f.Name)
+			w.Write(data)
+		}
+		zw.Close()
+		return out.Bytes()
+	}
+	for name, data := range map[string][]byte{
+		"Not a zip": []byte("not a zip"),
+		"Edited metadata": rewrite("metadata.json", func(b []byte) []byte {
+			return bytes.Replace(b, []byte(`"7"`), []byte(`"8"`), 1)
+		}),
+		"Newer format": rewrite("manifest.json", func(b []byte) []byte {
+			return bytes.Replace(b, []byte(`"version": 1`), []byte(`"version": 2`), 1)
+		}),
+		"Missing bundle": rewrite("bundle.json", func([]byte) []byte { return nil }),
+	} {
+		_, err := archive.ReadFairnessFile(bytes.NewReader(data), int64(len(data)))
+		assert.ErrorIs(t, err, archive.ErrInvalidFairnessFile, name)
+	}
+
+	tampered := *read
+	tampered.Verifier = "drandshuffle/v2"
+	assert.ErrorIs(t, tampered.Verify(nil), archive.ErrInvalidFairnessFile)
+	assert.Error(t, archive.WriteFairnessFile(io.Discard, &archive.FairnessFile{}))
+}
@@ -0,0 +1,230 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/audit"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/drandshuffletest"
+)
+
+// TestAuditExport 測試審計記錄的 CSV 和 Parquet 導出
+func TestAuditExport(t *testing.T) {
+	src := drandshuffletest.NewFakeBeaconSource(1000)
+	client := drandshuffle.NewClientWithManager(drandshuffletest.NewManager(t, src))
+	ctx := context.Background()
+	dealtAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	var log audit.Log
+	withProof, err := client.NewShuffle().Session("game_1").Round(990).WithProof().Do(ctx)
+	assert.NoError(t, err)
+	log.Append(audit.NewRecord(withProof, "game_1", dealtAt))
+	plain, err := client.NewShuffle().Session("game_2").Round(991).Do(ctx)
+	assert.NoError(t, err)
+	log.Append(audit.NewRecord(plain, "game_2", dealtAt.Add(time.Second)))
+
+	records := log.Records()
+	if !assert.Len(t, records, 2) {
+		return
+	}
+	assert.Equal(t, drandshuffle.DeckDigest(drandshuffletest.ExpectedDeck(src, 990, "game_1")), records[0].DeckDigest)
+	assert.Equal(t, withProof.Proof.Digest(), records[0].ProofDigest)
+	assert.Empty(t, records[1].ProofDigest)
+	assert.NotEqual(t, records[0].DeckDigest, records[1].DeckDigest)
+
+	t.Run("CSV", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(t, log.WriteCSV(&buf))
+		rows, err := csv.NewReader(&buf).ReadAll()
+		assert.NoError(t, err)
+		assert.Equal(t, [][]string{
+			{"round", "session_id", "deck_digest", "proof_digest", "round_time", "dealt_at", "note"},
+			{"990", "game_1", records[0].DeckDigest, records[0].ProofDigest, "", "2024-05-01T12:00:00Z", ""},
+			{"991", "game_2", records[1].DeckDigest, "", "", "2024-05-01T12:00:01Z", ""},
+		}, rows)
+	})
+
+	t.Run("Parquet", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(t, log.WriteParquet(&buf))
+		rows, err := audit.ReadParquet(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		assert.NoError(t, err)
+		assert.Equal(t, records, rows)
+
+		// 以數據倉庫的角度讀取，確認缺少的值寫為 null
+		type row struct {
+			SessionID   string     `parquet:"session_id"`
+			ProofDigest *string    `parquet:"proof_digest,optional"`
+			RoundTime   *time.Time `parquet:"round_time,optional"`
+		}
+		raw, err := parquet.Read[row](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		assert.NoError(t, err)
+		if assert.Len(t, raw, 2) {
+			assert.Equal(t, "game_2", raw[1].SessionID)
+			assert.NotNil(t, raw[0].ProofDigest)
+			assert.Nil(t, raw[1].ProofDigest)
+			assert.Nil(t, raw[1].RoundTime)
+		}
+	})
+}
+
+// failingStore 是寫入總是失敗的本地存儲
+type failingStore struct{}
+
+func (failingStore) Append(audit.Record) error { return errors.New("disk full") }
+
+// TestAuditPipeline 測試審計記錄同時寫入本地文件和遠端收集器，收集器故障時重試
+func TestAuditPipeline(t *testing.T) {
+	var mu sync.Mutex
+	var received []audit.Record
+	failures := 2
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		if failures > 0 {
+			failures--
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		var batch []audit.Record
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&batch))
+		received = append(received, batch...)
+	}))
+	defer collector.Close()
+
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	store, err := audit.OpenFileStore(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer store.Close()
+	remote := &audit.HTTPCollector{URL: collector.URL, Header: http.Header{"Authorization": {"Bearer secret"}}}
+	pipeline := audit.NewPipeline(store, remote, audit.WithBatchSize(2), audit.WithRetryInterval(10*time.Millisecond))
+
+	dealtAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var want []audit.Record
+	for i := 0; i < 5; i++ {
+		record := audit.Record{Round: uint64(990 + i), SessionID: fmt.Sprintf("game_%d", i), DeckDigest: "digest", DealtAt: dealtAt}
+		if i == 0 {
+			record.ProofDigest = "proof"
+			record.RoundTime = dealtAt.Add(-time.Second)
+		}
+		assert.NoError(t, pipeline.Record(record))
+		want = append(want, record)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, pipeline.Close(ctx))
+	stats := pipeline.Stats()
+	assert.Equal(t, uint64(5), stats.Recorded)
+	assert.Equal(t, uint64(5), stats.Shipped)
+	assert.Equal(t, 0, stats.Pending)
+	assert.Equal(t, uint64(2), stats.Failures)
+	assert.NoError(t, stats.LastError)
+
+	mu.Lock()
+	assert.Equal(t, want, received)
+	mu.Unlock()
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	local, err := audit.ReadJSONLines(bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, want, local)
+
+	assert.ErrorIs(t, pipeline.Record(want[0]), drandshuffle.ErrClosed)
+}
+
+// TestAuditPipelineBacklog 測試收集器持續不可用時暫存有上限，本地寫入失敗時返回錯誤
+func TestAuditPipelineBacklog(t *testing.T) {
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer collector.Close()
+
+	local := &recordingStore{}
+	pipeline := audit.NewPipeline(local, &audit.HTTPCollector{URL: collector.URL},
+		audit.WithBatchSize(1), audit.WithBacklog(2), audit.WithRetryInterval(time.Hour))
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, pipeline.Record(audit.Record{Round: uint64(i + 1), SessionID: "game"}))
+	}
+	assert.Eventually(t, func() bool { return pipeline.Stats().Failures > 0 }, 5*time.Second, time.Millisecond)
+	stats := pipeline.Stats()
+	assert.Equal(t, uint64(5), stats.Recorded)
+	assert.Equal(t, uint64(3), stats.Dropped)
+	assert.Equal(t, 2, stats.Pending)
+	assert.ErrorContains(t, stats.LastError, "503")
+	assert.Len(t, local.records, 5, "local store should keep every record")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, pipeline.Close(ctx), context.DeadlineExceeded)
+
+	failing := audit.NewPipeline(failingStore{}, &audit.HTTPCollector{URL: collector.URL})
+	assert.ErrorContains(t, failing.Record(audit.Record{Round: 1}), "disk full")
+	assert.Equal(t, 0, failing.Stats().Pending)
+	assert.NoError(t, failing.Close(context.Background()))
+}
+
+// TestAuditPipelinePanic 測試收集器發生 panic 時視為發送失敗，記錄保留在暫存中重試
+func TestAuditPipelinePanic(t *testing.T) {
+	remote := &panickyCollector{panics: 1}
+	pipeline := audit.NewPipeline(&recordingStore{}, remote, audit.WithRetryInterval(10*time.Millisecond))
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, pipeline.Record(audit.Record{Round: uint64(i + 1), SessionID: "game"}))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, pipeline.Close(ctx))
+	stats := pipeline.Stats()
+	assert.Equal(t, uint64(3), stats.Shipped)
+	assert.Equal(t, uint64(1), stats.Failures)
+	remote.mu.Lock()
+	assert.Len(t, remote.received, 3)
+	remote.mu.Unlock()
+}
+
+// panickyCollector 是前 panics 次發送時 panic 的收集器
+type panickyCollector struct {
+	mu       sync.Mutex
+	panics   int
+	received []audit.Record
+}
+
+func (c *panickyCollector) Send(_ context.Context, records []audit.Record) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.panics > 0 {
+		c.panics--
+		panic("collector bug")
+	}
+	c.received = append(c.received, records...)
+	return nil
+}
+
+// recordingStore 是保存在記憶體中的本地存儲
+type recordingStore struct {
+	records []audit.Record
+}
+
+func (s *recordingStore) Append(record audit.Record) error {
+	s.records = append(s.records, record)
+	return nil
+}
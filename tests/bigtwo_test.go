@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/drandshuffletest"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/games/bigtwo"
+)
+
+// TestBigTwoDeal 測試鋤大D按座位順序發牌、先出牌的座位和手牌排序
+func TestBigTwoDeal(t *testing.T) {
+	src := drandshuffletest.NewFakeBeaconSource(1000)
+	client := drandshuffle.NewClientWithManager(drandshuffletest.NewManager(t, src))
+	result, err := client.NewShuffle().Session("table_3").Round(990).WithProof().Do(context.Background())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	game, err := bigtwo.NewGame(result, "table_3")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, game.Hands, bigtwo.Players)
+	for seat, hand := range game.Hands {
+		assert.Equal(t, result.Deck[seat*13:seat*13+13], hand)
+	}
+	assert.Equal(t, uint64(990), game.Round)
+	assert.NoError(t, drandshuffle.VerifyProof(game.Proof, nil, result.Deck))
+
+	// 先出牌的座位持有該規則下最小的牌
+	for _, order := range []bigtwo.SuitOrder{bigtwo.SuitsHongKong, bigtwo.SuitsTaiwan} {
+		leader := game.Leader(order)
+		if assert.GreaterOrEqual(t, leader, 0) {
+			assert.Contains(t, game.Hands[leader], order.Lowest())
+			sorted := game.SortedHand(leader, order)
+			assert.Equal(t, order.Lowest(), sorted[0])
+			assert.ElementsMatch(t, game.Hands[leader], sorted)
+		}
+	}
+	assert.Equal(t, drandshuffle.Card{Suit: "方塊", Value: "3"}, bigtwo.SuitsHongKong.Lowest())
+	assert.Equal(t, drandshuffle.Card{Suit: "梅花", Value: "3"}, bigtwo.SuitsTaiwan.Lowest())
+	assert.Equal(t, 51, bigtwo.SuitsHongKong.Rank(drandshuffle.Card{Suit: "黑桃", Value: "2"}))
+	assert.Less(t, bigtwo.SuitsTaiwan.Rank(drandshuffle.Card{Suit: "梅花", Value: "A"}), bigtwo.SuitsTaiwan.Rank(drandshuffle.Card{Suit: "方塊", Value: "A"}))
+	assert.Less(t, bigtwo.SuitsHongKong.Rank(drandshuffle.Card{Suit: "方塊", Value: "A"}), bigtwo.SuitsHongKong.Rank(drandshuffle.Card{Suit: "梅花", Value: "A"}))
+	assert.Equal(t, -1, bigtwo.SuitsHongKong.Rank(drandshuffle.Card{Suit: "Joker", Value: "1"}))
+
+	// 牌組必須是一副標準撲克牌
+	_, err = bigtwo.Deal(result.Deck[:51])
+	assert.ErrorIs(t, err, drandshuffle.ErrInvalidConfig)
+	joker := append([]drandshuffle.Card{{Suit: "Joker", Value: "1"}}, result.Deck[1:]...)
+	_, err = bigtwo.Deal(joker)
+	assert.ErrorIs(t, err, drandshuffle.ErrInvalidCard)
+	assert.Equal(t, drandshuffle.CategoryInput, drandshuffle.Category(err))
+	duplicate := append([]drandshuffle.Card{result.Deck[1]}, result.Deck[1:]...)
+	_, err = bigtwo.Deal(duplicate)
+	assert.ErrorIs(t, err, drandshuffle.ErrInvalidCard)
+	assert.Equal(t, drandshuffle.CategoryInput, drandshuffle.Category(err))
+	_, err = bigtwo.NewGame(nil, "table_3")
+	assert.ErrorIs(t, err, drandshuffle.ErrInvalidConfig)
+
+	// 遊戲局號必須與證明一致
+	_, err = bigtwo.NewGame(result, "table_4")
+	assert.ErrorIs(t, err, drandshuffle.ErrInvalidConfig)
+	assert.Equal(t, drandshuffle.CategoryInput, drandshuffle.Category(err))
+
+	// 無效的座位不會 panic
+	assert.Nil(t, game.SortedHand(-1, bigtwo.SuitsHongKong))
+	assert.Nil(t, game.SortedHand(bigtwo.Players, bigtwo.SuitsHongKong))
+}
@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/drandshuffletest"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/games/bingo"
+)
+
+// TestBingoCard 測試賓果卡每一列的號碼範圍、重現、驗證和連線判斷
+func TestBingoCard(t *testing.T) {
+	src := drandshuffletest.NewFakeBeaconSource(1000)
+	client := drandshuffle.NewClientWithManager(drandshuffletest.NewManager(t, src))
+	ctx := context.Background()
+
+	card, err := bingo.NewCard(ctx, client, 990, "hall_1:card_0001")
+	if !assert.NoError(t, err) {
+		return
+	}
+	seen := map[int]bool{}
+	for row := 0; row < bingo.Size; row++ {
+		for col := 0; col < bingo.Size; col++ {
+			n := card.Grid[row][col]
+			if row == 2 && col == 2 {
+				assert.Equal(t, bingo.Free, n)
+				continue
+			}
+			assert.True(t, n > col*15 && n <= col*15+15, "number %d out of column %d", n, col)
+			assert.Equal(t, bingo.Letters[col], bingo.Letter(n))
+			assert.False(t, seen[n], "duplicate number: %d", n)
+			seen[n] = true
+		}
+	}
+	assert.NoError(t, bingo.Verify(card))
+	assert.Equal(t, card.Grid, bingo.NewGrid(card.Proof.Seed))
+
+	other, err := bingo.NewCard(ctx, client, 990, "hall_1:card_0002")
+	if assert.NoError(t, err) {
+		assert.NotEqual(t, card.Grid, other.Grid)
+	}
+
+	tampered := *card
+	tampered.Grid[0][0], tampered.Grid[1][0] = tampered.Grid[1][0], tampered.Grid[0][0]
+	assert.ErrorIs(t, bingo.Verify(&tampered), bingo.ErrCardMismatch)
+
+	// N 列經過免費格，叫出其餘四個號碼即連成一列
+	var column []int
+	for row := 0; row < bingo.Size; row++ {
+		column = append(column, card.Grid[row][2])
+	}
+	assert.False(t, card.Wins(column[:3]))
+	assert.True(t, card.Wins(column))
+	diagonal := []int{card.Grid[0][0], card.Grid[1][1], card.Grid[3][3], card.Grid[4][4]}
+	assert.True(t, card.Wins(diagonal))
+	assert.False(t, card.Wins([]int{0, 76, -1}))
+	assert.Equal(t, "", bingo.Letter(76))
+}
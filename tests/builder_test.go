@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/drandshuffletest"
+)
+
+// TestShuffleBuilder 測試鏈式構建洗牌的結果和證明
+func TestShuffleBuilder(t *testing.T) {
+	src := drandshuffletest.NewFakeBeaconSource(1000)
+	client := drandshuffle.NewClientWithManager(drandshuffletest.NewManager(t, src))
+	ctx := context.Background()
+
+	t.Run("Matches the existing API without contributions", func(t *testing.T) {
+		result, err := client.NewShuffle().Deck(drandshuffle.Poker52).Session("game_1").Round(990).Do(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(990), result.Round)
+		assert.Nil(t, result.Proof)
+		drandshuffletest.AssertDeck(t, src, 990, "game_1", result.Deck)
+
+		latest, err := client.NewShuffle().Session("game_1").Round(drandshuffle.Latest).Do(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(1000), latest.Round)
+		drandshuffletest.AssertDeck(t, src, 1000, "game_1", latest.Deck)
+	})
+
+	t.Run("Contributions change the deck in order", func(t *testing.T) {
+		base, _ := client.NewShuffle().Session("game_1").Round(990).Do(ctx)
+		ab, err := client.NewShuffle().Session("game_1").Round(990).
+			WithContributions([]byte("alice"), []byte("bob")).Do(ctx)
+		assert.NoError(t, err)
+		ba, _ := client.NewShuffle().Session("game_1").Round(990).
+			WithContributions([]byte("bob"), []byte("alice")).Do(ctx)
+		split, _ := client.NewShuffle().Session("game_1").Round(990).
+			WithContributions([]byte("ali"), []byte("cebob")).Do(ctx)
+
+		drandshuffletest.AssertStandardDeck(t, ab.Deck)
+		assert.NotEqual(t, base.Deck, ab.Deck)
+		assert.NotEqual(t, ab.Deck, ba.Deck, "Order of contributions should matter")
+		assert.NotEqual(t, ab.Deck, split.Deck, "Contribution boundaries should matter")
+	})
+
+	t.Run("Proof verifies the deck", func(t *testing.T) {
+		result, err := client.NewShuffle().Session("game_2").Round(995).
+			WithContributions([]byte("seed")).WithProof().Do(ctx)
+		assert.NoError(t, err)
+		proof := result.Proof
+		if !assert.NotNil(t, proof) {
+			return
+		}
+		assert.Equal(t, drandshuffle.ProofAlgorithm, proof.Algorithm)
+		assert.Equal(t, src.Randomness(995), proof.Randomness)
+		assert.Equal(t, uint64(995), proof.Beacon().Round)
+		assert.NoError(t, drandshuffle.VerifyProof(proof, nil, result.Deck))
+
+		// 證明可以序列化後交給第三方驗證
+		data, err := json.Marshal(proof)
+		assert.NoError(t, err)
+		var decoded drandshuffle.ShuffleProof
+		assert.NoError(t, json.Unmarshal(data, &decoded))
+		assert.NoError(t, drandshuffle.VerifyProof(&decoded, drandshuffle.Poker52, result.Deck))
+
+		decoded.Contributions[0] = []byte("tampered")
+		assert.ErrorIs(t, drandshuffle.VerifyProof(&decoded, nil, result.Deck), drandshuffle.ErrDeckMismatch)
+
+		decoded.Algorithm = "other"
+		assert.Equal(t, drandshuffle.CategoryInput, drandshuffle.Category(drandshuffle.VerifyProof(&decoded, nil, result.Deck)))
+	})
+
+	t.Run("Custom deck", func(t *testing.T) {
+		template := drandshuffle.NewDeckTemplate(drandshuffle.DeckSpec{Suits: []string{"紅", "藍"}, Values: []string{"1", "2", "3"}})
+		result, err := client.NewShuffle().Deck(template).Session("game_3").Round(990).WithProof().Do(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, result.Deck, 6)
+		assert.Equal(t, drandshuffle.NewShuffler(template).Shuffle(src.Randomness(990), "game_3"), result.Deck)
+		assert.NoError(t, drandshuffle.VerifyProof(result.Proof, template, result.Deck))
+		assert.ErrorIs(t, drandshuffle.VerifyProof(result.Proof, nil, result.Deck), drandshuffle.ErrDeckMismatch)
+	})
+
+	t.Run("Proof chain", func(t *testing.T) {
+		var chain []*drandshuffle.ShuffleProof
+		previous := ""
+		for i, session := range []string{"table_1_hand_1", "table_1_hand_2", "table_1_hand_3"} {
+			result, err := client.NewShuffle().Session(session).Round(uint64(990 + i)).ChainAfter(previous).Do(ctx)
+			if !assert.NoError(t, err) || !assert.NotNil(t, result.Proof, "ChainAfter should produce a proof") {
+				return
+			}
+			assert.Equal(t, previous, result.Proof.PreviousDigest)
+			chain = append(chain, result.Proof)
+			previous = result.Proof.Digest()
+		}
+		assert.NoError(t, drandshuffle.VerifyProofChain(chain))
+		assert.NoError(t, drandshuffle.VerifyProofChain(chain[1:]), "A segment of the chain should verify")
+
+		// 刪除或修改其中一局都會使之後的鏈接不一致
+		err := drandshuffle.VerifyProofChain([]*drandshuffle.ShuffleProof{chain[0], chain[2]})
+		assert.ErrorIs(t, err, drandshuffle.ErrChainBroken)
+		assert.Equal(t, drandshuffle.CategoryVerification, drandshuffle.Category(err))
+		tampered := *chain[1]
+		tampered.SessionID = "table_1_hand_x"
+		assert.ErrorIs(t, drandshuffle.VerifyProofChain([]*drandshuffle.ShuffleProof{chain[0], &tampered, chain[2]}), drandshuffle.ErrChainBroken)
+
+		// 未串連的證明不含新欄位，摘要與以前相同
+		plain, _ := client.NewShuffle().Session("game_1").Round(990).WithProof().Do(ctx)
+		data, _ := json.Marshal(plain.Proof)
+		assert.NotContains(t, string(data), "previous_digest")
+
+		_, err = client.NewShuffle().Session("game_1").Round(990).ChainAfter("not-a-digest").Do(ctx)
+		assert.ErrorIs(t, err, drandshuffle.ErrInvalidConfig)
+	})
+
+	t.Run("Invalid input", func(t *testing.T) {
+		_, err := client.NewShuffle().Round(990).Do(ctx)
+		assert.ErrorIs(t, err, drandshuffle.ErrInvalidSessionID)
+
+		_, err = client.NewShuffle().Session("game_1").Round(2000).Do(ctx)
+		assert.ErrorIs(t, err, drandshuffle.ErrRoundNotFound)
+	})
+}
@@ -0,0 +1,124 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/drandshuffletest"
+)
+
+// TestDeckCBOR 測試牌組的緊湊 CBOR 編碼
+func TestDeckCBOR(t *testing.T) {
+	deck := drandshuffle.InitializeDeck()
+	encoded := drandshuffle.EncodeDeckCBOR(deck)
+	assert.Len(t, encoded, 54, "A standard deck should use one byte per card")
+	assert.Equal(t, []byte{0x58, 52, 0}, encoded[:3])
+	decoded, err := drandshuffle.DecodeDeckCBOR(encoded)
+	assert.NoError(t, err)
+	assert.Equal(t, deck, decoded)
+
+	assert.Equal(t, []byte{0x40}, drandshuffle.EncodeDeckCBOR(nil))
+	empty, err := drandshuffle.DecodeDeckCBOR([]byte{0x40})
+	assert.NoError(t, err)
+	assert.Empty(t, empty)
+
+	custom := []drandshuffle.Card{{Suit: "鬼牌", Value: "大"}, deck[0]}
+	encoded = drandshuffle.EncodeDeckCBOR(custom)
+	assert.Equal(t, byte(0x82), encoded[0])
+	decoded, err = drandshuffle.DecodeDeckCBOR(encoded)
+	assert.NoError(t, err)
+	assert.Equal(t, custom, decoded)
+
+	for name, input := range map[string][]byte{
+		"Empty input":       {},
+		"Out of range":      {0x41, 52},
+		"Truncated":         {0x58, 52, 0},
+		"Trailing bytes":    {0x41, 0, 0},
+		"Non-shortest":      {0x58, 1, 0},
+		"Indefinite length": {0x5f, 0x41, 0, 0xff},
+		"Standard as pairs": append([]byte{0x81, 0x82}, drandshuffle.EncodeDeckCBOR(custom)[3:]...),
+		"Wrong type":        {0x01},
+	} {
+		_, err := drandshuffle.DecodeDeckCBOR(input)
+		assert.ErrorIs(t, err, drandshuffle.ErrInvalidCBOR, name)
+		assert.Equal(t, drandshuffle.CategoryInput, drandshuffle.Category(err), name)
+	}
+}
+
+// TestProofCBOR 測試洗牌證明和洗牌結果的確定性 CBOR 編碼
+func TestProofCBOR(t *testing.T) {
+	src := drandshuffletest.NewFakeBeaconSource(1000)
+	client := drandshuffle.NewClientWithManager(drandshuffletest.NewManager(t, src))
+	result, err := client.NewShuffle().Session("game_1").Round(990).WithProof().Do(context.Background())
+	if !assert.NoError(t, err) {
+		return
+	}
+	result.RoundTime = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	result.Proof.RoundTime = result.RoundTime
+	result.Proof.ChainHash = "52db9ba70e0cc0f6eaf7803dd07447a1f5477735fd3f661792ba94600c84e971"
+
+	encoded, err := result.Proof.MarshalCBOR()
+	if !assert.NoError(t, err) {
+		return
+	}
+	again, _ := result.Proof.MarshalCBOR()
+	assert.Equal(t, encoded, again, "Encoding should be deterministic")
+	asJSON, _ := json.Marshal(result.Proof)
+	assert.Less(t, len(encoded)*2, len(asJSON), "CBOR should be much smaller than JSON")
+
+	var proof drandshuffle.ShuffleProof
+	assert.NoError(t, proof.UnmarshalCBOR(encoded))
+	assert.Equal(t, *result.Proof, proof)
+	assert.Equal(t, result.Proof.Digest(), proof.Digest())
+	assert.NoError(t, drandshuffle.VerifyProof(&proof, nil, result.Deck))
+
+	bundle, err := result.MarshalCBOR()
+	if !assert.NoError(t, err) {
+		return
+	}
+	var decoded drandshuffle.ShuffleResult
+	assert.NoError(t, decoded.UnmarshalCBOR(bundle))
+	assert.Equal(t, *result, decoded)
+	bundleJSON, _ := json.Marshal(result)
+	assert.Less(t, len(bundle)*5, len(bundleJSON), "A hand with its proof should be much smaller than JSON")
+
+	t.Run("Contributions, custom chain hash and previous digest", func(t *testing.T) {
+		custom := *result.Proof
+		custom.ChainHash = "staging"
+		custom.Contributions = [][]byte{[]byte("player-1"), []byte("player-2")}
+		custom.RoundTime = time.Time{}
+		custom.PreviousDigest = result.Proof.Digest()
+		encoded, err := custom.MarshalCBOR()
+		assert.NoError(t, err)
+		var decoded drandshuffle.ShuffleProof
+		assert.NoError(t, decoded.UnmarshalCBOR(encoded))
+		assert.Equal(t, custom, decoded)
+	})
+
+	t.Run("Invalid input", func(t *testing.T) {
+		var decoded drandshuffle.ShuffleProof
+		for name, input := range map[string][]byte{
+			"Truncated":      encoded[:len(encoded)-1],
+			"Trailing bytes": append(append([]byte(nil), encoded...), 0),
+			"Unknown key":    {0xa1, 0x18, 99, 0x00},
+			"Missing fields": {0xa1, 0x03, 0x01},
+			"Not a map":      {0x80},
+		} {
+			err := decoded.UnmarshalCBOR(input)
+			assert.ErrorIs(t, err, drandshuffle.ErrInvalidCBOR, name)
+			assert.Equal(t, drandshuffle.CategoryInput, drandshuffle.Category(err), name)
+		}
+		assert.Equal(t, drandshuffle.ShuffleProof{}, decoded, "Failed decoding should not modify the proof")
+
+		_, err := (&drandshuffle.ShuffleProof{DeckSize: -1}).MarshalCBOR()
+		assert.Error(t, err)
+		var nilProof *drandshuffle.ShuffleProof
+		_, err = nilProof.MarshalCBOR()
+		assert.Error(t, err)
+	})
+}
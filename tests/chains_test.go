@@ -0,0 +1,160 @@
+package tests
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/drandshuffletest"
+)
+
+// TestChainPresets 測試按名稱選擇內置的網絡預設
+func TestChainPresets(t *testing.T) {
+	var names []string
+	for _, c := range drandshuffle.Chains() {
+		names = append(names, c.Name)
+		assert.Len(t, c.ChainHash, 64, c.Name)
+		assert.NotEmpty(t, c.URLs, c.Name)
+	}
+	assert.Equal(t, []string{"quicknet", "mainnet-default", "testnet", "fastnet"}, names)
+
+	testnet, err := drandshuffle.LookupChain("testnet")
+	assert.NoError(t, err)
+	assert.Equal(t, 3*time.Second, testnet.Period)
+	testnet.URLs[0] = "https://modified.example"
+	again, _ := drandshuffle.LookupChain("testnet")
+	assert.NotEqual(t, "https://modified.example", again.URLs[0], "Presets must not be modified through the returned copy")
+
+	_, err = drandshuffle.LookupChain("devnet")
+	assert.ErrorIs(t, err, drandshuffle.ErrInvalidConfig)
+
+	src := drandshuffletest.NewFakeBeaconSource(1000)
+	newManager := func(opts ...drandshuffle.Option) (*drandshuffle.DrandManager, error) {
+		dm, err := drandshuffle.NewDrandManagerWithClient(src, opts...)
+		if err == nil {
+			t.Cleanup(func() { dm.Close() })
+		}
+		return dm, err
+	}
+
+	t.Run("Preset relays", func(t *testing.T) {
+		dm, err := newManager(drandshuffle.WithChain("testnet"))
+		if assert.NoError(t, err) {
+			cfg := dm.Config()
+			assert.Equal(t, testnet.ChainHash, cfg.ChainHash)
+			assert.Equal(t, again.URLs, cfg.URLs, "Staging should not use the production relays")
+		}
+	})
+
+	t.Run("Self-hosted relays", func(t *testing.T) {
+		dm, err := newManager(drandshuffle.WithRelayURLs("https://relay.internal"), drandshuffle.WithChain("mainnet-default"))
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{"https://relay.internal"}, dm.Config().URLs)
+		}
+	})
+
+	t.Run("Conflicting hash", func(t *testing.T) {
+		_, err := newManager(drandshuffle.WithChain("testnet"), drandshuffle.WithChainHash(strings.Repeat("ab", 32)))
+		assert.ErrorIs(t, err, drandshuffle.ErrInvalidConfig)
+		_, err = newManager(drandshuffle.WithChain("devnet"))
+		assert.ErrorIs(t, err, drandshuffle.ErrInvalidConfig)
+	})
+
+	t.Run("Config file and environment", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		assert.NoError(t, os.WriteFile(path, []byte("chain: fastnet\n"), 0o600))
+		cfg, err := drandshuffle.LoadConfig(path)
+		assert.NoError(t, err)
+		assert.Equal(t, "fastnet", cfg.Chain)
+
+		t.Setenv(drandshuffle.EnvChain, "testnet")
+		cfg, err = drandshuffle.LoadConfig(path)
+		assert.NoError(t, err)
+		dm, err := newManager(drandshuffle.WithConfig(cfg))
+		if assert.NoError(t, err) {
+			assert.Equal(t, testnet.ChainHash, dm.Config().ChainHash)
+		}
+	})
+}
+
+// TestCustomChainInfo 測試使用自定義鏈信息連接自建網絡的中繼
+func TestCustomChainInfo(t *testing.T) {
+	signer := newTestSigner(t)
+	signer.info.ID = "staging"
+	var doc bytes.Buffer
+	assert.NoError(t, signer.info.ToJSON(&doc, nil))
+	info, err := drandshuffle.ReadChainInfo(bytes.NewReader(doc.Bytes()))
+	if !assert.NoError(t, err) {
+		return
+	}
+	hash := info.HashString()
+	assert.Equal(t, signer.info.HashString(), hash)
+
+	_, err = drandshuffle.ReadChainInfo(strings.NewReader("{}"))
+	assert.ErrorIs(t, err, drandshuffle.ErrInvalidConfig)
+
+	// 自建網絡的中繼，請求鏈信息時返回錯誤，確認客戶端使用固定的鏈信息
+	relay, infoRequests := newTestRelay(t, signer)
+
+	path := filepath.Join(t.TempDir(), "chain.json")
+	assert.NoError(t, os.WriteFile(path, doc.Bytes(), 0o600))
+	cfg := drandshuffle.DefaultConfig()
+	cfg.ChainInfoFile = path
+	cfg.URLs = []string{relay.URL}
+
+	dm, err := drandshuffle.NewDrandManager(drandshuffle.WithConfig(cfg))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer dm.Close()
+	assert.Equal(t, hash, dm.Config().ChainHash)
+	assert.True(t, info.Equal(dm.ChainInfo()))
+
+	randomness, err := dm.GetRandomnessByRound(5)
+	assert.NoError(t, err)
+	assert.Equal(t, signer.beacon(5).Randomness, randomness)
+	assert.Zero(t, infoRequests.Load(), "The pinned chain info should be used instead of fetching it")
+
+	_, err = drandshuffle.NewDrandManagerWithClient(drandshuffletest.NewFakeBeaconSource(10),
+		drandshuffle.WithChainInfo(info), drandshuffle.WithChain("quicknet"))
+	assert.ErrorIs(t, err, drandshuffle.ErrInvalidConfig, "A preset and chain info for different chains conflict")
+}
+
+// newTestRelay 啟動以 drand 中繼 API 提供 signer 簽名信標的測試服務器
+// 請求鏈信息時返回 404，返回的計數器記錄鏈信息的請求次數
+func newTestRelay(t testing.TB, signer *testSigner) (*httptest.Server, *atomic.Int32) {
+	hash := signer.info.HashString()
+	var infoRequests atomic.Int32
+	relay := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/"+hash)
+		if path == "/info" {
+			infoRequests.Add(1)
+			http.NotFound(w, r)
+			return
+		}
+		round := uint64(time.Since(time.Unix(signer.info.GenesisTime, 0))/signer.info.Period) + 1
+		if path != "/public/latest" {
+			parsed, err := strconv.ParseUint(strings.TrimPrefix(path, "/public/"), 10, 64)
+			if err != nil {
+				http.NotFound(w, r)
+				return
+			}
+			round = parsed
+		}
+		beacon := signer.beacon(round)
+		fmt.Fprintf(w, `{"round":%d,"randomness":"%x","signature":"%x"}`, beacon.Round, beacon.Randomness, beacon.Signature)
+	}))
+	t.Cleanup(relay.Close)
+	return relay, &infoRequests
+}
@@ -0,0 +1,194 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/drandshuffletest"
+)
+
+// TestClient 測試 Client 的洗牌和驗證
+func TestClient(t *testing.T) {
+	src := drandshuffletest.NewFakeBeaconSource(1000)
+	client := drandshuffle.NewClientWithManager(drandshuffletest.NewManager(t, src))
+	ctx := context.Background()
+
+	deck, round, err := client.ShuffleLatest(ctx, "game_1")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1000), round)
+	drandshuffletest.AssertDeck(t, src, round, "game_1", deck)
+	assert.NoError(t, client.Verify(ctx, round, "game_1", deck))
+
+	deck, err = client.ShuffleAtRound(ctx, 990, "game_2")
+	assert.NoError(t, err)
+	drandshuffletest.AssertDeck(t, src, 990, "game_2", deck)
+	assert.NoError(t, client.Verify(ctx, 990, "game_2", deck))
+
+	// 換了遊戲局號或順序被改動的牌組都無法通過驗證
+	assert.ErrorIs(t, client.Verify(ctx, 990, "game_3", deck), drandshuffle.ErrDeckMismatch)
+	deck[0], deck[1] = deck[1], deck[0]
+	assert.ErrorIs(t, client.Verify(ctx, 990, "game_2", deck), drandshuffle.ErrDeckMismatch)
+	assert.ErrorIs(t, client.Verify(ctx, 990, "game_2", deck[:51]), drandshuffle.ErrDeckMismatch)
+}
+
+// TestClientContext 測試 ctx 取消時 ShuffleAtRound 不再等待網絡請求
+func TestClientContext(t *testing.T) {
+	slow := newFakeClient(1000)
+	slow.delay = time.Second
+	dm, err := drandshuffle.NewDrandManagerWithClient(slow, drandshuffle.WithWarmStart())
+	assert.NoError(t, err)
+	client := drandshuffle.NewClientWithManager(dm)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = client.ShuffleAtRound(ctx, 500, "game_1")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+
+	_, _, err = client.ShuffleLatest(ctx, "game_1")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+// TestSubscribe 測試新的最新信標會發布給訂閱者
+func TestSubscribe(t *testing.T) {
+	src := drandshuffletest.NewFakeBeaconSource(1000)
+	dm := drandshuffletest.NewManager(t, src)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := dm.Subscribe(ctx)
+
+	src.SetLatest(1001)
+	_, round, err := dm.GetLatestRandomnessWithin(context.Background(), 0, time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1001), round)
+
+	select {
+	case beacon := <-ch:
+		assert.Equal(t, uint64(1001), beacon.Round)
+		assert.Equal(t, src.Randomness(1001), beacon.Randomness)
+	case <-time.After(time.Second):
+		t.Fatal("沒有收到新的隨機信標")
+	}
+
+	cancel()
+	assert.Eventually(t, func() bool {
+		_, ok := <-ch
+		return !ok
+	}, time.Second, 10*time.Millisecond)
+
+	// 關閉後訂閱得到已關閉的通道
+	dm.Close()
+	_, ok := <-dm.Subscribe(context.Background())
+	assert.False(t, ok)
+}
+
+// TestSessionID 測試遊戲局號的生成和檢查
+func TestSessionID(t *testing.T) {
+	id, err := drandshuffle.NewSessionID()
+	assert.NoError(t, err)
+	assert.Regexp(t, `^game_[0-9a-f]{32}$`, id)
+	assert.NoError(t, drandshuffle.ValidateSessionID(id))
+
+	other, err := drandshuffle.NewSessionID()
+	assert.NoError(t, err)
+	assert.NotEqual(t, id, other)
+
+	id, err = drandshuffle.NewSessionIDWithPrefix("table-7:")
+	assert.NoError(t, err)
+	assert.Regexp(t, `^table-7:[0-9a-f]{32}$`, id)
+
+	_, err = drandshuffle.NewSessionIDWithPrefix("牌桌")
+	assert.ErrorIs(t, err, drandshuffle.ErrInvalidSessionID)
+
+	for _, bad := range []string{"", "game 1", "game\u200b1", "遊戲1", strings.Repeat("a", drandshuffle.MaxSessionIDLength+1)} {
+		err := drandshuffle.ValidateSessionID(bad)
+		assert.ErrorIs(t, err, drandshuffle.ErrInvalidSessionID, bad)
+		assert.Equal(t, drandshuffle.CategoryInput, drandshuffle.Category(err))
+	}
+
+	src := drandshuffletest.NewFakeBeaconSource(1000)
+	client := drandshuffle.NewClientWithManager(drandshuffletest.NewManager(t, src))
+	_, _, err = client.ShuffleLatest(context.Background(), "game 1")
+	assert.ErrorIs(t, err, drandshuffle.ErrInvalidSessionID)
+	_, err = client.ShuffleAtRound(context.Background(), 999, "")
+	assert.ErrorIs(t, err, drandshuffle.ErrInvalidSessionID)
+}
+
+// TestHealth 測試獲取狀態的統計
+func TestHealth(t *testing.T) {
+	src := drandshuffletest.NewFakeBeaconSource(1000)
+	dm := drandshuffletest.NewManager(t, src)
+
+	h := dm.Health()
+	assert.True(t, h.Healthy(), "Manager should be healthy after the initial fetch")
+	assert.Equal(t, uint64(1000), h.LatestRound)
+	assert.Equal(t, uint64(1), h.Successes)
+	assert.False(t, h.Running)
+
+	src.SetError(errors.New("relay down"))
+	for i := 0; i < 3; i++ {
+		_, _, err := dm.GetLatestRandomnessWithin(context.Background(), 0, time.Second)
+		assert.NoError(t, err, "Cached beacon should still be served")
+	}
+
+	h = dm.Health()
+	assert.False(t, h.Healthy(), "Manager should be unhealthy after repeated failures")
+	assert.Equal(t, uint64(3), h.Failures)
+	assert.Equal(t, uint64(3), h.ConsecutiveFailures)
+	assert.ErrorIs(t, h.LastError, drandshuffle.ErrBeaconUnavailable)
+
+	src.SetError(nil)
+	src.SetLatest(1001)
+	_, round, err := dm.GetLatestRandomnessWithin(context.Background(), 0, time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1001), round)
+
+	h = dm.Health()
+	assert.True(t, h.Healthy())
+	assert.Equal(t, uint64(0), h.ConsecutiveFailures)
+	assert.NoError(t, h.LastError)
+}
+
+// TestStrictRounds 測試嚴格輪次模式拒絕使用最新輪次的洗牌
+func TestStrictRounds(t *testing.T) {
+	src := drandshuffletest.NewFakeBeaconSource(1000)
+	client := drandshuffle.NewClientWithManager(drandshuffletest.NewManager(t, src, drandshuffle.WithStrictRounds()))
+	ctx := context.Background()
+
+	_, _, err := client.ShuffleLatest(ctx, "game_1")
+	assert.ErrorIs(t, err, drandshuffle.ErrExplicitRoundRequired)
+	assert.Equal(t, drandshuffle.CategoryInput, drandshuffle.Category(err))
+	assert.False(t, drandshuffle.IsRetryable(err), "Retrying must not produce another deck")
+
+	_, err = client.NewShuffle().Session("game_1").Round(drandshuffle.Latest).Do(ctx)
+	assert.ErrorIs(t, err, drandshuffle.ErrExplicitRoundRequired)
+
+	_, err = client.ShuffleAtRound(ctx, 0, "game_1")
+	assert.ErrorIs(t, err, drandshuffle.ErrRoundBeforeGenesis)
+
+	deck, err := client.ShuffleAtRound(ctx, 1000, "game_1")
+	assert.NoError(t, err)
+	assert.NoError(t, client.Verify(ctx, 1000, "game_1", deck))
+
+	result, err := client.NewShuffle().Session("game_1").Round(1000).Do(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, deck, result.Deck)
+
+	t.Setenv(drandshuffle.EnvStrictRounds, "true")
+	cfg, err := drandshuffle.LoadConfig("")
+	assert.NoError(t, err)
+	assert.True(t, cfg.StrictRounds)
+
+	t.Setenv(drandshuffle.EnvStrictRounds, "sometimes")
+	_, err = drandshuffle.LoadConfig("")
+	assert.ErrorIs(t, err, drandshuffle.ErrInvalidConfig)
+}
@@ -0,0 +1,267 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/drandshuffletest"
+)
+
+// newClockedManager 創建跟隨假時鐘的 DrandManager，時鐘停在第 11 輪剛發布的時間
+func newClockedManager(t *testing.T) (*drandshuffle.DrandManager, *drandshuffletest.FakeBeaconSource, *drandshuffletest.Clock, time.Time) {
+	genesis := time.Unix(1_700_000_000, 0)
+	clock := drandshuffletest.NewClock(genesis.Add(30 * time.Second))
+
+	src := drandshuffletest.NewFakeBeaconSource(0)
+	src.FollowClock(clock, genesis, 3*time.Second)
+	return drandshuffletest.NewManager(t, src), src, clock, genesis
+}
+
+// waitForWaiter 等待有 goroutine 在時鐘上等待，之後推進時鐘才能喚醒它
+func waitForWaiter(t *testing.T, clock *drandshuffletest.Clock) {
+	t.Helper()
+	assert.Eventually(t, func() bool { return clock.Waiters() > 0 }, 5*time.Second, time.Millisecond)
+}
+
+// TestClock 測試假時鐘的 After
+func TestClock(t *testing.T) {
+	start := time.Unix(1_700_000_000, 0)
+	clock := drandshuffletest.NewClock(start)
+
+	assert.Equal(t, start, <-clock.After(0), "Non-positive durations should fire immediately")
+
+	ch := clock.After(2 * time.Second)
+	assert.Equal(t, 1, clock.Waiters())
+	clock.Advance(time.Second)
+	select {
+	case <-ch:
+		t.Fatal("After should not fire before its deadline")
+	default:
+	}
+
+	clock.Advance(time.Second)
+	assert.Equal(t, start.Add(2*time.Second), <-ch)
+	assert.Equal(t, 0, clock.Waiters())
+}
+
+// TestWaitForRound 測試等待未來輪次發布
+func TestWaitForRound(t *testing.T) {
+	dm, src, clock, genesis := newClockedManager(t)
+	ctx := context.Background()
+
+	t.Run("Published round returns immediately", func(t *testing.T) {
+		randomness, err := dm.WaitForRound(ctx, 5)
+		assert.NoError(t, err)
+		assert.Equal(t, src.Randomness(5), randomness)
+	})
+
+	t.Run("Future round waits for the clock", func(t *testing.T) {
+		type result struct {
+			randomness []byte
+			err        error
+		}
+		done := make(chan result, 1)
+		go func() {
+			randomness, err := dm.WaitForRound(ctx, 13)
+			done <- result{randomness, err}
+		}()
+
+		waitForWaiter(t, clock)
+		select {
+		case <-done:
+			t.Fatal("WaitForRound should not return before the round is published")
+		default:
+		}
+
+		clock.Advance(7 * time.Second)
+		select {
+		case r := <-done:
+			assert.NoError(t, r.err)
+			assert.Equal(t, src.Randomness(13), r.randomness)
+		case <-time.After(5 * time.Second):
+			t.Fatal("WaitForRound did not return after the clock advanced")
+		}
+	})
+
+	t.Run("Cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		done := make(chan error, 1)
+		go func() {
+			_, err := dm.WaitForRound(ctx, 100)
+			done <- err
+		}()
+
+		waitForWaiter(t, clock)
+		cancel()
+		err := <-done
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.False(t, drandshuffle.IsRetryable(err))
+	})
+
+	t.Run("Round bounds follow the clock", func(t *testing.T) {
+		_, err := dm.GetRandomnessByRound(30)
+		var future *drandshuffle.FutureRoundError
+		if assert.True(t, errors.As(err, &future)) {
+			assert.Equal(t, genesis.Add(29*3*time.Second), future.AvailableAt)
+		}
+	})
+}
+
+// TestRoundTime 測試按創世時間和週期推算輪次的發布時間
+func TestRoundTime(t *testing.T) {
+	dm, _, _, genesis := newClockedManager(t)
+	client := drandshuffle.NewClientWithManager(dm)
+
+	roundTime, ok := client.RoundTime(11)
+	assert.True(t, ok)
+	assert.Equal(t, genesis.Add(30*time.Second).UTC(), roundTime)
+	assert.Equal(t, time.UTC, roundTime.Location())
+
+	_, ok = client.RoundTime(0)
+	assert.False(t, ok, "Round 0 does not exist")
+
+	result, err := client.NewShuffle().Session("game_1").Round(5).WithProof().Do(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, genesis.Add(12*time.Second).UTC(), result.RoundTime)
+	assert.Equal(t, result.RoundTime, result.Proof.RoundTime)
+
+	result, err = client.NewShuffle().Session("game_1").Do(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(11), result.Round)
+	assert.Equal(t, roundTime, result.RoundTime)
+
+	t.Run("Without chain info", func(t *testing.T) {
+		client := drandshuffle.NewClientWithManager(drandshuffletest.NewManager(t, drandshuffletest.NewFakeBeaconSource(1000)))
+		_, ok := client.RoundTime(1000)
+		assert.False(t, ok)
+
+		result, err := client.NewShuffle().Session("game_1").Do(context.Background())
+		assert.NoError(t, err)
+		assert.True(t, result.RoundTime.IsZero())
+	})
+}
+
+// TestPollingFollowsClock 測試後台輪詢按時鐘調度，不需要等待真實的輪次間隔
+func TestPollingFollowsClock(t *testing.T) {
+	dm, _, clock, _ := newClockedManager(t)
+	ch := dm.Subscribe(context.Background())
+
+	dm.StartBackgroundFetching()
+	defer dm.StopBackgroundFetching()
+
+	start := dm.Health().LatestRound
+	for i := uint64(1); i <= 3; i++ {
+		// 輪詢在每輪發布後稍等片刻才請求，推進的時間需要略多於一個週期
+		waitForWaiter(t, clock)
+		clock.Advance(3500 * time.Millisecond)
+
+		select {
+		case beacon := <-ch:
+			assert.Equal(t, start+i, beacon.Round)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Round %d was not fetched after the clock advanced", start+i)
+		}
+	}
+}
+
+// TestGapDetection 測試後台獲取跳過輪次時記錄缺口，期間已緩存的輪次不算缺失
+func TestGapDetection(t *testing.T) {
+	genesis := time.Unix(1_700_000_000, 0)
+	clock := drandshuffletest.NewClock(genesis.Add(30 * time.Second))
+	src := drandshuffletest.NewFakeBeaconSource(0)
+	src.FollowClock(clock, genesis, 3*time.Second)
+	gaps := make(chan drandshuffle.BeaconGap, 4)
+	dm := drandshuffletest.NewManager(t, src, drandshuffle.WithGapHandler(func(gap drandshuffle.BeaconGap) {
+		gaps <- gap
+	}))
+	ch := dm.Subscribe(context.Background())
+
+	dm.StartBackgroundFetching()
+	defer dm.StopBackgroundFetching()
+	start := dm.Health().LatestRound
+
+	// 第一次輪詢正常取得下一輪
+	waitForWaiter(t, clock)
+	clock.Advance(3500 * time.Millisecond)
+	assert.Equal(t, start+1, (<-ch).Round)
+
+	// 中繼故障期間錯過一輪，該輪之後按輪次號碼取得並緩存
+	src.SetError(errors.New("relay down"))
+	waitForWaiter(t, clock)
+	clock.Advance(3 * time.Second)
+	assert.Eventually(t, func() bool { return dm.Health().Failures == 1 }, 5*time.Second, time.Millisecond)
+	src.SetError(nil)
+	_, err := dm.GetRandomnessByRound(start + 2)
+	assert.NoError(t, err)
+
+	// 恢復後直接取得 start+4，只有 start+3 缺失
+	waitForWaiter(t, clock)
+	clock.Advance(6 * time.Second)
+	assert.Equal(t, start+4, (<-ch).Round)
+	select {
+	case gap := <-gaps:
+		assert.Equal(t, start+3, gap.From)
+		assert.Equal(t, start+3, gap.To)
+		assert.Equal(t, uint64(1), gap.Missed())
+		assert.Equal(t, clock.Now(), gap.DetectedAt)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Gap handler was not called")
+	}
+
+	h := dm.Health()
+	assert.Equal(t, uint64(1), h.Gaps)
+	assert.Equal(t, uint64(1), h.MissedRounds)
+	assert.Equal(t, start+3, h.LastGap.From)
+	assert.Empty(t, gaps, "only one gap should be reported")
+}
+
+// TestGapHandlerPanic 測試缺口回調發生 panic 時後台獲取繼續運行，崩潰報告計入 Health
+func TestGapHandlerPanic(t *testing.T) {
+	genesis := time.Unix(1_700_000_000, 0)
+	clock := drandshuffletest.NewClock(genesis.Add(30 * time.Second))
+	src := drandshuffletest.NewFakeBeaconSource(0)
+	src.FollowClock(clock, genesis, 3*time.Second)
+	dm := drandshuffletest.NewManager(t, src, drandshuffle.WithGapHandler(func(gap drandshuffle.BeaconGap) {
+		panic(fmt.Sprintf("alert service down for round %d", gap.From))
+	}))
+	ch := dm.Subscribe(context.Background())
+
+	dm.StartBackgroundFetching()
+	defer dm.StopBackgroundFetching()
+	start := dm.Health().LatestRound
+
+	waitForWaiter(t, clock)
+	clock.Advance(3500 * time.Millisecond)
+	assert.Equal(t, start+1, (<-ch).Round)
+
+	// 中繼故障期間錯過兩輪，恢復後觸發會 panic 的缺口回調
+	src.SetError(errors.New("relay down"))
+	waitForWaiter(t, clock)
+	clock.Advance(3 * time.Second)
+	assert.Eventually(t, func() bool { return dm.Health().Failures == 1 }, 5*time.Second, time.Millisecond)
+	src.SetError(nil)
+	waitForWaiter(t, clock)
+	clock.Advance(6 * time.Second)
+	assert.Equal(t, start+4, (<-ch).Round)
+
+	// 後台獲取沒有因 panic 退出
+	waitForWaiter(t, clock)
+	clock.Advance(3 * time.Second)
+	assert.Equal(t, start+5, (<-ch).Round)
+
+	h := dm.Health()
+	assert.True(t, h.Running)
+	assert.Equal(t, uint64(1), h.Gaps)
+	assert.Equal(t, uint64(1), h.Panics)
+	if assert.NotNil(t, h.LastPanic) {
+		assert.Equal(t, "gap_handler", h.LastPanic.Where)
+		assert.Equal(t, fmt.Sprintf("alert service down for round %d", start+2), h.LastPanic.Value)
+		assert.Contains(t, string(h.LastPanic.Stack), "TestGapHandlerPanic")
+	}
+}
@@ -0,0 +1,157 @@
+package tests
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/drand/go-clients/drand"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/drandshuffletest"
+)
+
+// 以下測試應使用 go test -race 運行，用於檢查 DrandManager 文檔中的並發約定
+
+// TestConcurrentLifecycle 測試並發地啟動、停止、關閉和讀取 DrandManager
+func TestConcurrentLifecycle(t *testing.T) {
+	src := drandshuffletest.NewFakeBeaconSource(1000)
+	dm := drandshuffletest.NewManager(t, src)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(4)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				dm.StartBackgroundFetching()
+				dm.StopBackgroundFetching()
+			}
+		}()
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				dm.GetRandomnessByRound(uint64(900 + (i*50+j)%100))
+				dm.GetLatestRandomness()
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				dm.Health()
+				dm.Snapshot(10)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			subCtx, cancel := context.WithCancel(ctx)
+			dm.Subscribe(subCtx)
+			dm.GetLatestRandomnessWithin(ctx, 0, time.Second)
+			cancel()
+		}()
+	}
+	wg.Wait()
+
+	// 與其他操作並發地重複關閉
+	var closers sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		closers.Add(2)
+		go func() {
+			defer closers.Done()
+			dm.Close()
+		}()
+		go func() {
+			defer closers.Done()
+			dm.StartBackgroundFetching()
+			dm.GetRandomnessByRound(950)
+		}()
+	}
+	closers.Wait()
+
+	t.Run("Closed manager stays stopped", func(t *testing.T) {
+		dm.StartBackgroundFetching()
+		assert.False(t, dm.Health().Running)
+	})
+
+	t.Run("Closed manager serves cache only", func(t *testing.T) {
+		_, err := dm.GetRandomnessByRound(950)
+		assert.NoError(t, err, "Cached round should still be readable")
+
+		_, err = dm.GetRandomnessByRound(10)
+		assert.ErrorIs(t, err, drandshuffle.ErrClosed)
+		assert.False(t, drandshuffle.IsRetryable(err))
+	})
+
+	t.Run("Subscribe after close", func(t *testing.T) {
+		_, ok := <-dm.Subscribe(ctx)
+		assert.False(t, ok, "Channel should be closed immediately")
+	})
+}
+
+// blockingSource 在啟用後讓最新輪次的請求一直阻塞到 ctx 取消
+type blockingSource struct {
+	*drandshuffletest.FakeBeaconSource
+	mu      sync.Mutex
+	started chan struct{}
+}
+
+// Get 在啟用阻塞時通知 started 並等待 ctx 取消
+func (s *blockingSource) Get(ctx context.Context, round uint64) (drand.Result, error) {
+	s.mu.Lock()
+	started := s.started
+	s.started = nil
+	s.mu.Unlock()
+
+	if round != 0 || started == nil {
+		return s.FakeBeaconSource.Get(ctx, round)
+	}
+	close(started)
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// TestStopDuringFetch 測試後台獲取正在等待中繼時停止不會死鎖
+func TestStopDuringFetch(t *testing.T) {
+	if testing.Short() {
+		t.Skip("需要等待一個輪詢間隔")
+	}
+
+	src := &blockingSource{FakeBeaconSource: drandshuffletest.NewFakeBeaconSource(1000)}
+	cfg := drandshuffle.DefaultConfig()
+	cfg.FetchTimeout = time.Minute
+	dm, err := drandshuffle.NewDrandManagerWithClient(src, drandshuffle.WithConfig(cfg))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer dm.Close()
+
+	started := make(chan struct{})
+	src.mu.Lock()
+	src.started = started
+	src.mu.Unlock()
+
+	dm.StartBackgroundFetching()
+	select {
+	case <-started:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Background fetch did not start")
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		dm.StopBackgroundFetching()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("StopBackgroundFetching should cancel the in-flight fetch instead of blocking")
+	}
+
+	h := dm.Health()
+	assert.False(t, h.Running)
+	assert.Equal(t, uint64(0), h.Failures, "Cancelled fetch should not count as a failure")
+}
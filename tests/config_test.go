@@ -0,0 +1,192 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/drand/go-clients/drand"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/drandshuffletest"
+)
+
+// TestLoadConfig 測試配置文件和環境變量的載入及其優先級
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	yamlPath := filepath.Join(dir, "config.yaml")
+	assert.NoError(t, os.WriteFile(yamlPath, []byte("urls:\n  - https://relay.example\ncache_size: 10\nfetch_timeout: 2s\n"), 0o600))
+
+	tomlPath := filepath.Join(dir, "config.toml")
+	assert.NoError(t, os.WriteFile(tomlPath, []byte("cache_size = 20\nconnect_timeout = \"3s\"\n"), 0o600))
+
+	t.Run("YAML", func(t *testing.T) {
+		cfg, err := drandshuffle.LoadConfig(yamlPath)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"https://relay.example"}, cfg.URLs)
+		assert.Equal(t, 10, cfg.CacheSize)
+		assert.Equal(t, 2*time.Second, cfg.FetchTimeout)
+		// 文件中未出現的欄位保持默認值
+		assert.Equal(t, drandshuffle.QuicknetChainHash, cfg.ChainHash)
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("TOML", func(t *testing.T) {
+		cfg, err := drandshuffle.LoadConfig(tomlPath)
+		assert.NoError(t, err)
+		assert.Equal(t, 20, cfg.CacheSize)
+		assert.Equal(t, 3*time.Second, cfg.ConnectTimeout)
+	})
+
+	t.Run("環境變量優先於文件", func(t *testing.T) {
+		t.Setenv(drandshuffle.EnvURLs, "https://a.example, https://b.example")
+		t.Setenv(drandshuffle.EnvCacheSize, "42")
+
+		cfg, err := drandshuffle.LoadConfig(yamlPath)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"https://a.example", "https://b.example"}, cfg.URLs)
+		assert.Equal(t, 42, cfg.CacheSize)
+		assert.Equal(t, 2*time.Second, cfg.FetchTimeout)
+	})
+
+	t.Run("無效輸入", func(t *testing.T) {
+		unknown := filepath.Join(dir, "unknown.yaml")
+		assert.NoError(t, os.WriteFile(unknown, []byte("cache_sise: 10\n"), 0o600))
+		_, err := drandshuffle.LoadConfig(unknown)
+		assert.ErrorIs(t, err, drandshuffle.ErrInvalidConfig)
+
+		_, err = drandshuffle.LoadConfig(filepath.Join(dir, "config.json"))
+		assert.ErrorIs(t, err, drandshuffle.ErrInvalidConfig)
+
+		t.Setenv(drandshuffle.EnvCacheSize, "many")
+		_, err = drandshuffle.LoadConfig("")
+		assert.ErrorIs(t, err, drandshuffle.ErrInvalidConfig)
+	})
+}
+
+// TestPrintEffectiveConfig 測試輸出的配置可以重新載入
+func TestPrintEffectiveConfig(t *testing.T) {
+	cfg := drandshuffle.DefaultConfig()
+	cfg.CacheSize = 7
+	cfg.DealLatencyBuckets = []time.Duration{time.Second, time.Minute}
+
+	var buf bytes.Buffer
+	assert.NoError(t, drandshuffle.PrintEffectiveConfig(&buf, cfg))
+	assert.Contains(t, buf.String(), "cache_size: 7")
+
+	path := filepath.Join(t.TempDir(), "effective.yml")
+	assert.NoError(t, os.WriteFile(path, buf.Bytes(), 0o600))
+
+	loaded, err := drandshuffle.LoadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, cfg, loaded)
+}
+
+// deadlineSource 記錄每次獲取時 ctx 剩餘的時間
+type deadlineSource struct {
+	*drandshuffletest.FakeBeaconSource
+	mu        sync.Mutex
+	remaining map[uint64]time.Duration
+}
+
+// Get 記錄 ctx 剩餘的時間後轉發請求
+func (s *deadlineSource) Get(ctx context.Context, round uint64) (drand.Result, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		s.mu.Lock()
+		s.remaining[round] = time.Until(deadline)
+		s.mu.Unlock()
+	}
+	return s.FakeBeaconSource.Get(ctx, round)
+}
+
+// timeout 返回最近一次獲取輪次時 ctx 剩餘的時間，latest 為 0
+func (s *deadlineSource) timeout(round uint64) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.remaining[round]
+}
+
+// TestOperationTimeouts 測試各操作的默認超時和 ctx 截止時間的覆蓋
+func TestOperationTimeouts(t *testing.T) {
+	newSource := func() *deadlineSource {
+		return &deadlineSource{
+			FakeBeaconSource: drandshuffletest.NewFakeBeaconSource(1000),
+			remaining:        make(map[uint64]time.Duration),
+		}
+	}
+	const slack = float64(500 * time.Millisecond)
+
+	cfg := drandshuffle.DefaultConfig()
+	cfg.FetchLatestTimeout = 2 * time.Second
+	cfg.FetchByRoundTimeout = 30 * time.Second
+	src := newSource()
+	dm, err := drandshuffle.NewDrandManagerWithClient(src, drandshuffle.WithConfig(cfg))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer dm.Close()
+	assert.InDelta(t, float64(2*time.Second), float64(src.timeout(0)), slack, "Latest fetches use FetchLatestTimeout")
+
+	_, err = dm.GetRandomnessByRound(999)
+	assert.NoError(t, err)
+	assert.InDelta(t, float64(30*time.Second), float64(src.timeout(999)), slack, "Round fetches use FetchByRoundTimeout")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 45*time.Second)
+	defer cancel()
+	_, err = drandshuffle.NewClientWithManager(dm).ShuffleAtRound(ctx, 998, "audit_1")
+	assert.NoError(t, err)
+	assert.InDelta(t, float64(45*time.Second), float64(src.timeout(998)), slack, "A ctx deadline may extend the default")
+
+	_, _, err = dm.GetLatestRandomnessWithin(context.Background(), 0, 500*time.Millisecond)
+	assert.NoError(t, err)
+	assert.InDelta(t, float64(500*time.Millisecond), float64(src.timeout(0)), slack, "A ctx deadline may shorten the default")
+
+	t.Run("Fallback and init", func(t *testing.T) {
+		cfg := drandshuffle.DefaultConfig()
+		cfg.FetchTimeout = 7 * time.Second
+		src := newSource()
+		dm, err := drandshuffle.NewDrandManagerWithClient(src, drandshuffle.WithConfig(cfg))
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer dm.Close()
+		assert.InDelta(t, float64(7*time.Second), float64(src.timeout(0)), slack, "Unset timeouts fall back to FetchTimeout")
+		_, err = dm.GetRandomnessByRound(999)
+		assert.NoError(t, err)
+		assert.InDelta(t, float64(7*time.Second), float64(src.timeout(999)), slack)
+
+		cfg.InitTimeout = time.Second
+		src = newSource()
+		dm, err = drandshuffle.NewDrandManagerWithClient(src, drandshuffle.WithConfig(cfg))
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer dm.Close()
+		assert.LessOrEqual(t, src.timeout(0), time.Second, "InitTimeout bounds the initial fetch")
+	})
+
+	t.Run("Config", func(t *testing.T) {
+		cfg := drandshuffle.DefaultConfig()
+		cfg.FetchByRoundTimeout = -time.Second
+		assert.ErrorIs(t, cfg.Validate(), drandshuffle.ErrInvalidConfig)
+
+		t.Setenv(drandshuffle.EnvFetchLatestTimeout, "1500ms")
+		t.Setenv(drandshuffle.EnvFetchByRoundTimeout, "30s")
+		t.Setenv(drandshuffle.EnvInitTimeout, "15s")
+		cfg, err := drandshuffle.LoadConfig("")
+		assert.NoError(t, err)
+		assert.Equal(t, 1500*time.Millisecond, cfg.FetchLatestTimeout)
+		assert.Equal(t, 30*time.Second, cfg.FetchByRoundTimeout)
+		assert.Equal(t, 15*time.Second, cfg.InitTimeout)
+
+		t.Setenv(drandshuffle.EnvInitTimeout, "soon")
+		_, err = drandshuffle.LoadConfig("")
+		assert.ErrorIs(t, err, drandshuffle.ErrInvalidConfig)
+	})
+}
@@ -2,11 +2,12 @@ package tests
 
 import (
 	"crypto/rand"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
-	"go_drand/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
 )
 
 // TestCardConversion 測試牌的字符串轉換功能
@@ -173,3 +174,86 @@ func TestShuffleDeck(t *testing.T) {
 		}
 	})
 }
+
+// TestReusableDeck 測試可重複使用的牌組與 ShuffleDeck 結果一致
+func TestReusableDeck(t *testing.T) {
+	randomness := make([]byte, 64)
+	_, err := rand.Read(randomness)
+	assert.NoError(t, err)
+
+	expected := drandshuffle.ShuffleDeck(drandshuffle.InitializeDeck(), randomness)
+
+	for i := 0; i < 3; i++ {
+		deck := drandshuffle.AcquireDeck()
+		assert.Equal(t, drandshuffle.InitializeDeck(), deck.Cards, "Acquired deck should be in standard order")
+
+		deck.Shuffle(randomness)
+		assert.Equal(t, expected, deck.Cards, "Reusable deck should shuffle the same as ShuffleDeck")
+		deck.Release()
+	}
+}
+
+// TestDeckTemplate 測試自定義牌組模板
+func TestDeckTemplate(t *testing.T) {
+	t.Run("Standard template matches InitializeDeck", func(t *testing.T) {
+		assert.Equal(t, 52, drandshuffle.StandardDeckTemplate.Len())
+		assert.Equal(t, drandshuffle.InitializeDeck(), drandshuffle.StandardDeckTemplate.NewDeck())
+	})
+
+	t.Run("Custom template", func(t *testing.T) {
+		template := drandshuffle.NewDeckTemplate(drandshuffle.DeckSpec{
+			Suits:  []string{"黑桃", "紅心"},
+			Values: []string{"A", "K", "Q"},
+		})
+		assert.Equal(t, 6, template.Len())
+
+		deck := template.NewDeck()
+		assert.Equal(t, drandshuffle.Card{Suit: "黑桃", Value: "A"}, deck[0])
+		assert.Equal(t, drandshuffle.Card{Suit: "紅心", Value: "Q"}, deck[5])
+
+		// 修改返回的牌組不應影響模板
+		deck[0] = drandshuffle.Card{Suit: "梅花", Value: "2"}
+		assert.Equal(t, drandshuffle.Card{Suit: "黑桃", Value: "A"}, template.NewDeck()[0])
+
+		randomness := []byte("test_randomness_for_custom_template")
+		assert.Equal(t,
+			drandshuffle.ShuffleDeck(template.NewDeck(), randomness),
+			template.Shuffled(randomness),
+			"Shuffled should match ShuffleDeck on a fresh copy")
+	})
+}
+
+// TestCardStringAllocations 測試牌的字符串轉換
+func TestCardStringAllocations(t *testing.T) {
+	card := drandshuffle.Card{Suit: "紅心", Value: "10"}
+
+	t.Run("Stringer and AppendString", func(t *testing.T) {
+		assert.Equal(t, "紅心10", card.String())
+		assert.Equal(t, "紅心10", fmt.Sprint(card))
+		assert.Equal(t, "牌: 紅心10", string(drandshuffle.AppendString([]byte("牌: "), card)))
+
+		// 非標準牌組的牌同樣可以轉換
+		custom := drandshuffle.Card{Suit: "星星", Value: "X"}
+		assert.Equal(t, "星星X", drandshuffle.CardToString(custom))
+	})
+
+	t.Run("Standard cards convert without allocation", func(t *testing.T) {
+		buf := make([]byte, 0, 16)
+		allocs := testing.AllocsPerRun(100, func() {
+			str := drandshuffle.CardToString(card)
+			if _, err := drandshuffle.StringToCard(str); err != nil {
+				t.Fatal(err)
+			}
+			buf = drandshuffle.AppendString(buf[:0], card)
+		})
+		assert.Equal(t, float64(0), allocs)
+	})
+}
+
+// BenchmarkStringToCard 測試字符串轉換為牌的性能
+func BenchmarkStringToCard(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		drandshuffle.StringToCard("方塊Q")
+	}
+}
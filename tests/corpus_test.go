@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/drandshuffletest"
+)
+
+// TestShuffleCorpus 以當前的洗牌算法重放 testdata 中的語料，任何派生方式的改變都會使此測試失敗
+// 有意修改算法時應以 drandshuffle replay-corpus --update 更新語料，並同時提升 ProofAlgorithm
+func TestShuffleCorpus(t *testing.T) {
+	f, err := os.Open("testdata/shuffle_corpus.jsonl")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer f.Close()
+	cases, err := drandshuffletest.ReadCorpus(f)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NotEmpty(t, cases)
+
+	mismatches, err := drandshuffletest.ReplayCorpus(cases)
+	assert.NoError(t, err)
+	for _, m := range mismatches {
+		t.Error(m)
+	}
+}
+
+// TestCorpusFormat 測試語料的讀寫和不一致的報告
+func TestCorpusFormat(t *testing.T) {
+	c := drandshuffletest.CorpusCase{Name: "case_1", Randomness: "0102030405060708090a", SessionID: "game_1"}
+	got, err := c.Shuffle()
+	if !assert.NoError(t, err) {
+		return
+	}
+	deck := drandshuffle.NewShuffler(drandshuffle.Poker52).Shuffle([]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, "game_1")
+	assert.Equal(t, drandshuffle.CardToString(deck[0]), got[0])
+	assert.Len(t, got, 52)
+
+	c.Expected = got
+	var buf bytes.Buffer
+	assert.NoError(t, drandshuffletest.WriteCorpus(&buf, []drandshuffletest.CorpusCase{c}))
+	cases, err := drandshuffletest.ReadCorpus(strings.NewReader("\n" + buf.String() + "\n"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, []drandshuffletest.CorpusCase{c}, cases)
+	}
+
+	swapped := c
+	swapped.Name = "case_2"
+	swapped.Expected = append([]string(nil), got...)
+	swapped.Expected[3], swapped.Expected[4] = swapped.Expected[4], swapped.Expected[3]
+	short := c
+	short.Name = "case_3"
+	short.Expected = got[:51]
+	missing := c
+	missing.Name = "case_4"
+	missing.Expected = nil
+	mismatches, err := drandshuffletest.ReplayCorpus([]drandshuffletest.CorpusCase{c, swapped, short, missing})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []drandshuffletest.CorpusMismatch{
+			{Name: "case_2", Position: 3, Want: got[4], Got: got[3]},
+			{Name: "case_3", Position: 51, Got: got[51]},
+			{Name: "case_4", Position: 0, Got: got[0]},
+		}, mismatches)
+	}
+
+	_, err = drandshuffletest.ReplayCorpus([]drandshuffletest.CorpusCase{{Name: "bad", Randomness: "zz"}})
+	assert.Error(t, err)
+	_, err = drandshuffletest.ReadCorpus(strings.NewReader(buf.String() + buf.String()))
+	assert.Error(t, err, "duplicate names should be rejected")
+	_, err = drandshuffletest.ReadCorpus(strings.NewReader(`{"name":"x","seed":"00"}`))
+	assert.Error(t, err, "unknown fields should be rejected")
+}
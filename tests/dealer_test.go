@@ -0,0 +1,140 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/games/holdem"
+)
+
+// TestDealer 測試從牌組頂部依次發牌和牌數不足的錯誤
+func TestDealer(t *testing.T) {
+	deck := drandshuffle.InitializeDeck()
+	dealer := drandshuffle.NewDealer(deck)
+	assert.Equal(t, 52, dealer.Remaining())
+
+	hands, err := dealer.DealHands(4, 2)
+	assert.NoError(t, err)
+	if assert.Len(t, hands, 4) {
+		assert.Equal(t, deck[0:2], hands[0])
+		assert.Equal(t, deck[6:8], hands[3])
+	}
+
+	flop, err := dealer.Deal(3)
+	assert.NoError(t, err)
+	assert.Equal(t, deck[8:11], flop)
+	assert.Equal(t, 41, dealer.Remaining())
+
+	// append 不應覆蓋之後要發的牌
+	_ = append(flop, drandshuffle.Card{Suit: "Joker", Value: "1"})
+	next, err := dealer.Deal(1)
+	assert.NoError(t, err)
+	assert.Equal(t, deck[11], next[0])
+
+	_, err = dealer.Deal(41)
+	assert.ErrorIs(t, err, drandshuffle.ErrInsufficientCards)
+	var short *drandshuffle.NotEnoughCardsError
+	if assert.True(t, errors.As(err, &short)) {
+		assert.Equal(t, 41, short.Required)
+		assert.Equal(t, 40, short.Available)
+	}
+	assert.Equal(t, drandshuffle.CategoryInput, drandshuffle.Category(err))
+	assert.Equal(t, 40, dealer.Remaining(), "A failed deal should not consume cards")
+
+	_, err = dealer.DealHands(10, 5)
+	assert.ErrorIs(t, err, drandshuffle.ErrInsufficientCards)
+	assert.Equal(t, 40, dealer.Remaining(), "A failed round should not deal partial hands")
+
+	_, err = dealer.Deal(-1)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, drandshuffle.ErrInsufficientCards)
+
+	err = drandshuffle.RequireCards(deck[:10], 13)
+	assert.Equal(t, "not enough cards: 13 required, only 10 left", drandshuffle.ErrorMessage(err, drandshuffle.LocaleEn))
+	assert.NoError(t, drandshuffle.RequireCards(deck, 52))
+}
+
+// TestDealPlan 測試發牌計劃的檢查、輪流發牌的標籤、跨步驟的編號和牌數不足
+func TestDealPlan(t *testing.T) {
+	invalid := [][]drandshuffle.DealStep{
+		nil,
+		{{Slots: nil, Count: 1}},
+		{{Slots: []string{"burn"}, Count: 0}},
+		{{Slots: []string{"player1.hole", "player1.hole"}, Count: 2}},
+		{{Slots: []string{"board..flop"}, Count: 1}},
+		{{Slots: []string{"1st"}, Count: 1}},
+		{{Slots: []string{"burn"}, Count: 1 << 62}, {Slots: []string{"burn"}, Count: 1 << 62}},
+	}
+	for _, steps := range invalid {
+		_, err := drandshuffle.NewDealPlan("bad", steps...)
+		assert.ErrorIs(t, err, drandshuffle.ErrInvalidConfig, "steps %v should be rejected", steps)
+		assert.Equal(t, drandshuffle.CategoryInput, drandshuffle.Category(err))
+	}
+
+	plan, err := drandshuffle.NewDealPlan("mini",
+		drandshuffle.DealStep{Slots: []string{"player1.hole", "player2.hole"}, Count: 2},
+		drandshuffle.DealStep{Slots: []string{"burn"}, Count: 1},
+		drandshuffle.DealStep{Slots: []string{"board.flop"}, Count: 3},
+		drandshuffle.DealStep{Slots: []string{"burn"}, Count: 1},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, 9, plan.Size())
+	assert.Equal(t, []string{
+		"player1.hole[0]", "player2.hole[0]", "player1.hole[1]", "player2.hole[1]",
+		"burn[0]", "board.flop[0]", "board.flop[1]", "board.flop[2]", "burn[1]",
+	}, plan.Labels())
+
+	deck := drandshuffle.InitializeDeck()
+	deal, err := plan.Apply(deck)
+	assert.NoError(t, err)
+	if assert.Len(t, deal.Cards, 9) {
+		for i, card := range deal.Cards {
+			assert.Equal(t, i, card.Position)
+			assert.Equal(t, deck[i], card.Card)
+			assert.Equal(t, plan.Labels()[i], card.Label)
+		}
+	}
+	assert.Equal(t, []drandshuffle.Card{deck[0], deck[2]}, deal.Slots["player1.hole"])
+	assert.Equal(t, []drandshuffle.Card{deck[4], deck[8]}, deal.Slots["burn"])
+	assert.Equal(t, deck[5:8], deal.Slots["board.flop"])
+
+	_, err = plan.Apply(deck[:8])
+	assert.ErrorIs(t, err, drandshuffle.ErrInsufficientCards)
+
+	// JSON 往返後應得到相同的計劃
+	data, err := json.Marshal(plan)
+	assert.NoError(t, err)
+	var decoded drandshuffle.DealPlan
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, *plan, decoded)
+	assert.NoError(t, decoded.Validate())
+}
+
+// TestHoldemPlan 測試德州撲克的發牌計劃與 holdem.Deal 的發牌順序一致
+func TestHoldemPlan(t *testing.T) {
+	deck := drandshuffle.InitializeDeck()
+	for players := holdem.MinPlayers; players <= holdem.MaxPlayers; players++ {
+		plan, err := holdem.Plan(players)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		hands, board, err := holdem.Deal(deck, players)
+		assert.NoError(t, err)
+		deal, err := plan.Apply(deck)
+		assert.NoError(t, err)
+		for seat, hand := range hands {
+			assert.Equal(t, hand, deal.Slots[fmt.Sprintf("player%d.hole", seat+1)])
+		}
+		assert.Equal(t, board[:3], deal.Slots["board.flop"])
+		assert.Equal(t, board[3:4], deal.Slots["board.turn"])
+		assert.Equal(t, board[4:5], deal.Slots["board.river"])
+	}
+
+	_, err := holdem.Plan(1)
+	assert.ErrorIs(t, err, drandshuffle.ErrInvalidConfig)
+}
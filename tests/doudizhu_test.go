@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/drandshuffletest"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/games/doudizhu"
+)
+
+// TestDoudizhuDeal 測試鬥地主的 54 張牌組、發牌、底牌和先叫地主的座位
+func TestDoudizhuDeal(t *testing.T) {
+	src := drandshuffletest.NewFakeBeaconSource(1000)
+	client := drandshuffle.NewClientWithManager(drandshuffletest.NewManager(t, src))
+
+	assert.Equal(t, doudizhu.DeckSize, doudizhu.Deck54.Len())
+	deck := doudizhu.Deck54.NewDeck()
+	assert.Equal(t, []drandshuffle.Card{doudizhu.SmallJoker, doudizhu.BigJoker}, deck[52:])
+	assert.Equal(t, "小王", drandshuffle.CardToString(doudizhu.SmallJoker))
+
+	result, err := client.NewShuffle().Deck(doudizhu.Deck54).Session("room_9").Round(990).WithProof().Do(context.Background())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, doudizhu.DeckSize, result.Proof.DeckSize)
+	assert.NoError(t, drandshuffle.VerifyProof(result.Proof, doudizhu.Deck54, result.Deck))
+	drandshuffletest.AssertPermutation(t, doudizhu.Deck54, result.Deck)
+
+	game, err := doudizhu.NewGame(result, "room_9")
+	if !assert.NoError(t, err) {
+		return
+	}
+	for seat, hand := range game.Hands {
+		assert.Equal(t, result.Deck[seat*17:seat*17+17], hand)
+	}
+	assert.Equal(t, result.Deck[51:], game.Bottom)
+	landlord := game.LandlordHand(1)
+	assert.Len(t, landlord, 20)
+	assert.Equal(t, game.Bottom, landlord[17:])
+	assert.Len(t, game.Hands[1], 17, "LandlordHand must not modify the hand")
+	assert.Nil(t, game.LandlordHand(-1))
+	assert.Nil(t, game.LandlordHand(doudizhu.Players))
+
+	sorted := doudizhu.SortedHand(result.Deck)
+	assert.Equal(t, "3", sorted[0].Value)
+	assert.Equal(t, []drandshuffle.Card{doudizhu.SmallJoker, doudizhu.BigJoker}, sorted[52:])
+
+	// 先叫地主的座位只由證明決定，不同局號分佈在所有座位上
+	bidder, err := doudizhu.FirstBidder(game.Proof)
+	assert.NoError(t, err)
+	assert.Equal(t, game.FirstBidder, bidder)
+	seats := map[int]int{}
+	for i := 0; i < 60; i++ {
+		id := fmt.Sprintf("room_%d", i)
+		r, err := client.NewShuffle().Deck(doudizhu.Deck54).Session(id).Round(990).WithProof().Do(context.Background())
+		if !assert.NoError(t, err) {
+			return
+		}
+		g, err := doudizhu.NewGame(r, id)
+		if assert.NoError(t, err) {
+			assert.GreaterOrEqual(t, g.FirstBidder, 0)
+			assert.Less(t, g.FirstBidder, doudizhu.Players)
+			seats[g.FirstBidder]++
+		}
+	}
+	assert.Len(t, seats, doudizhu.Players)
+
+	// 以標準牌組洗牌或沒有證明時無法發牌
+	standard, err := client.NewShuffle().Session("room_9").Round(990).WithProof().Do(context.Background())
+	if assert.NoError(t, err) {
+		_, err = doudizhu.NewGame(standard, "room_9")
+		assert.ErrorIs(t, err, drandshuffle.ErrInvalidConfig)
+	}
+	_, err = doudizhu.NewGame(result, "room_10")
+	assert.ErrorIs(t, err, drandshuffle.ErrInvalidConfig, "sessionID must match the proof")
+	result.Proof = nil
+	_, err = doudizhu.NewGame(result, "room_9")
+	assert.ErrorIs(t, err, drandshuffle.ErrInvalidConfig)
+	invalid := append([]drandshuffle.Card{{Suit: "Joker", Value: "1"}}, deck[1:]...)
+	_, _, err = doudizhu.Deal(invalid)
+	assert.ErrorIs(t, err, drandshuffle.ErrInvalidCard)
+
+	// 重複的牌和重複的大小王
+	for _, card := range []drandshuffle.Card{deck[1], doudizhu.BigJoker} {
+		duplicate := append(slices.DeleteFunc(slices.Clone(deck), func(c drandshuffle.Card) bool { return c == doudizhu.SmallJoker }), card)
+		_, _, err = doudizhu.Deal(duplicate)
+		assert.ErrorIs(t, err, drandshuffle.ErrInvalidCard, drandshuffle.CardToString(card))
+		assert.Equal(t, drandshuffle.CategoryInput, drandshuffle.Category(err))
+	}
+	_, err = doudizhu.FirstBidder(nil)
+	assert.ErrorIs(t, err, drandshuffle.ErrInvalidConfig)
+}
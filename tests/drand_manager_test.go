@@ -0,0 +1,1116 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/drand/drand/v2/common/chain"
+	"github.com/drand/go-clients/drand"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+)
+
+// fakeResult 是測試用的隨機信標
+type fakeResult struct {
+	round      uint64
+	randomness []byte
+}
+
+func (r *fakeResult) GetRound() uint64             { return r.round }
+func (r *fakeResult) GetRandomness() []byte        { return r.randomness }
+func (r *fakeResult) GetPreviousSignature() []byte { return nil }
+func (r *fakeResult) GetSignature() []byte         { return nil }
+
+// newFakeResult 根據輪次號碼生成確定性的隨機信標
+func newFakeResult(round uint64) *fakeResult {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, round)
+	sum := sha256.Sum256(buf)
+	return &fakeResult{round: round, randomness: sum[:]}
+}
+
+// fakeClient 是測試用的 drand 客戶端，記錄每個輪次的請求次數
+type fakeClient struct {
+	latest uint64
+	info   *chain.Info
+	delay  time.Duration
+	fail   atomic.Bool
+
+	mu        sync.Mutex
+	calls     map[uint64]int
+	cancelled int32
+
+	// 同時進行中的請求數量及其峰值
+	active int32
+	peak   int32
+}
+
+func newFakeClient(latest uint64) *fakeClient {
+	return &fakeClient{latest: latest, calls: make(map[uint64]int)}
+}
+
+func (c *fakeClient) Get(ctx context.Context, round uint64) (drand.Result, error) {
+	c.mu.Lock()
+	c.calls[round]++
+	c.mu.Unlock()
+
+	active := atomic.AddInt32(&c.active, 1)
+	defer atomic.AddInt32(&c.active, -1)
+	for {
+		peak := atomic.LoadInt32(&c.peak)
+		if active <= peak || atomic.CompareAndSwapInt32(&c.peak, peak, active) {
+			break
+		}
+	}
+
+	if c.delay > 0 {
+		select {
+		case <-time.After(c.delay):
+		case <-ctx.Done():
+			atomic.AddInt32(&c.cancelled, 1)
+			return nil, ctx.Err()
+		}
+	}
+	if c.fail.Load() {
+		return nil, errors.New("fake network error")
+	}
+	if round == 0 {
+		round = atomic.LoadUint64(&c.latest)
+	}
+	return newFakeResult(round), nil
+}
+
+func (c *fakeClient) Watch(ctx context.Context) <-chan drand.Result {
+	ch := make(chan drand.Result)
+	close(ch)
+	return ch
+}
+
+func (c *fakeClient) Info(ctx context.Context) (*chain.Info, error) {
+	if c.info != nil {
+		return c.info, nil
+	}
+	return nil, errors.New("not supported")
+}
+
+func (c *fakeClient) RoundAt(t time.Time) uint64 {
+	return atomic.LoadUint64(&c.latest)
+}
+
+func (c *fakeClient) Close() error {
+	return nil
+}
+
+func (c *fakeClient) callCount(round uint64) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls[round]
+}
+
+// TestGetRandomnessByRoundCoalescing 測試同一輪次的並發請求只會發出一次網絡請求
+func TestGetRandomnessByRoundCoalescing(t *testing.T) {
+	client := newFakeClient(1000)
+	client.delay = 50 * time.Millisecond
+
+	dm, err := drandshuffle.NewDrandManagerWithClient(client)
+	assert.NoError(t, err)
+
+	const workers = 200
+	results := make([][]byte, workers)
+	errs := make([]error, workers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = dm.GetRandomnessByRound(42)
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, client.callCount(42), "Concurrent requests for the same round should be coalesced")
+	for i := 0; i < workers; i++ {
+		assert.NoError(t, errs[i])
+		assert.Equal(t, newFakeResult(42).randomness, results[i])
+	}
+
+	// 之後的請求應直接命中緩存
+	_, err = dm.GetRandomnessByRound(42)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, client.callCount(42), "Cached round should not be fetched again")
+}
+
+// TestGetRandomnessByRoundCoalescingError 測試合併的請求共享錯誤結果，且錯誤不會被緩存
+func TestGetRandomnessByRoundCoalescingError(t *testing.T) {
+	client := newFakeClient(1000)
+
+	dm, err := drandshuffle.NewDrandManagerWithClient(client)
+	assert.NoError(t, err)
+
+	client.fail.Store(true)
+	client.delay = 20 * time.Millisecond
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := dm.GetRandomnessByRound(7)
+			assert.Error(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, client.callCount(7))
+
+	// 失敗的結果不應被緩存，下一次請求應重新獲取
+	_, err = dm.GetRandomnessByRound(7)
+	assert.ErrorIs(t, err, drandshuffle.ErrRoundNotFound)
+	assert.Equal(t, 2, client.callCount(7))
+}
+
+// TestBeaconCacheEviction 測試緩存超過容量時淘汰最早的項目
+func TestBeaconCacheEviction(t *testing.T) {
+	client := newFakeClient(1000)
+
+	dm, err := drandshuffle.NewDrandManagerWithClient(client)
+	assert.NoError(t, err)
+
+	// 寫入超過緩存容量的輪次
+	for round := uint64(1); round <= 150; round++ {
+		_, err := dm.GetRandomnessByRound(round)
+		assert.NoError(t, err)
+	}
+
+	// 最近的輪次仍在緩存中
+	_, err = dm.GetRandomnessByRound(150)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, client.callCount(150), "Recent round should still be cached")
+
+	// 最早的輪次已被淘汰，需要重新獲取
+	_, err = dm.GetRandomnessByRound(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, client.callCount(1), "Oldest round should have been evicted")
+}
+
+// TestCacheSizeOption 測試自定義緩存容量
+func TestCacheSizeOption(t *testing.T) {
+	client := newFakeClient(1000)
+
+	dm, err := drandshuffle.NewDrandManagerWithClient(client, drandshuffle.WithCacheSize(5))
+	assert.NoError(t, err)
+
+	for round := uint64(1); round <= 6; round++ {
+		_, err := dm.GetRandomnessByRound(round)
+		assert.NoError(t, err)
+	}
+
+	// 容量為 5 時，寫入第 6 個輪次後第 1 個輪次已被淘汰
+	_, err = dm.GetRandomnessByRound(2)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, client.callCount(2))
+
+	_, err = dm.GetRandomnessByRound(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, client.callCount(1))
+}
+
+// TestHedgedRequests 測試對沖請求使用最先返回的中繼結果並取消其餘請求
+func TestHedgedRequests(t *testing.T) {
+	t.Run("Fastest relay wins", func(t *testing.T) {
+		slow := newFakeClient(2000)
+		slow.delay = 2 * time.Second
+		fast := newFakeClient(2001)
+
+		start := time.Now()
+		dm, err := drandshuffle.NewDrandManagerWithClient(newFakeClient(1000),
+			drandshuffle.WithHedgedRequests(),
+			drandshuffle.WithRelayClients(slow, fast),
+		)
+		assert.NoError(t, err)
+		assert.Less(t, time.Since(start), time.Second, "Hedged fetch should not wait for the slow relay")
+
+		_, round, err := dm.GetLatestRandomness()
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(2001), round)
+
+		// 較慢的請求應被取消
+		assert.Eventually(t, func() bool {
+			return atomic.LoadInt32(&slow.cancelled) == 1
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("Failed relay falls through", func(t *testing.T) {
+		broken := newFakeClient(3000)
+		broken.fail.Store(true)
+		healthy := newFakeClient(3001)
+		healthy.delay = 20 * time.Millisecond
+
+		dm, err := drandshuffle.NewDrandManagerWithClient(newFakeClient(1000),
+			drandshuffle.WithHedgedRequests(),
+			drandshuffle.WithRelayClients(broken, healthy),
+		)
+		assert.NoError(t, err)
+
+		_, round, err := dm.GetLatestRandomness()
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(3001), round)
+	})
+
+	t.Run("All relays failing", func(t *testing.T) {
+		broken1 := newFakeClient(4000)
+		broken1.fail.Store(true)
+		broken2 := newFakeClient(4001)
+		broken2.fail.Store(true)
+
+		_, err := drandshuffle.NewDrandManagerWithClient(newFakeClient(1000),
+			drandshuffle.WithHedgedRequests(),
+			drandshuffle.WithRelayClients(broken1, broken2),
+		)
+		assert.Error(t, err)
+	})
+}
+
+// recordingTransport 記錄所有請求的主機並返回錯誤，不訪問網絡
+type recordingTransport struct {
+	mu    sync.Mutex
+	hosts []string
+}
+
+func (rt *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.mu.Lock()
+	rt.hosts = append(rt.hosts, req.URL.Host)
+	rt.mu.Unlock()
+	return nil, errors.New("offline transport")
+}
+
+// TestCustomTransport 測試自定義 Transport 和 HTTP 客戶端被用於連接中繼
+func TestCustomTransport(t *testing.T) {
+	t.Run("WithTransport", func(t *testing.T) {
+		rt := &recordingTransport{}
+		_, err := drandshuffle.NewDrandManager(drandshuffle.WithTransport(rt))
+		assert.Error(t, err)
+		assert.Contains(t, rt.hosts, "api.drand.sh")
+	})
+
+	t.Run("WithHTTPClient", func(t *testing.T) {
+		rt := &recordingTransport{}
+		_, err := drandshuffle.NewDrandManager(drandshuffle.WithHTTPClient(&http.Client{Transport: rt}))
+		assert.Error(t, err)
+		assert.Contains(t, rt.hosts, "api.drand.sh")
+	})
+}
+
+// TestGetRandomnessRange 測試批量獲取輪次範圍
+func TestGetRandomnessRange(t *testing.T) {
+	t.Run("Fetches rounds concurrently in order", func(t *testing.T) {
+		client := newFakeClient(1000)
+		dm, err := drandshuffle.NewDrandManagerWithClient(client)
+		assert.NoError(t, err)
+		client.delay = 20 * time.Millisecond
+
+		start := time.Now()
+		results, err := dm.GetRandomnessRange(101, 150)
+		assert.NoError(t, err)
+		assert.Less(t, time.Since(start), 500*time.Millisecond, "Range fetch should not be serial")
+
+		assert.Len(t, results, 50)
+		for i, randomness := range results {
+			assert.Equal(t, newFakeResult(uint64(101+i)).randomness, randomness)
+		}
+	})
+
+	t.Run("Invalid range", func(t *testing.T) {
+		dm, err := drandshuffle.NewDrandManagerWithClient(newFakeClient(1000))
+		assert.NoError(t, err)
+
+		_, err = dm.GetRandomnessRange(10, 5)
+		assert.Error(t, err)
+		_, err = dm.GetRandomnessRange(0, 5)
+		assert.Error(t, err)
+	})
+
+	t.Run("Error stops the range", func(t *testing.T) {
+		client := newFakeClient(1000)
+		dm, err := drandshuffle.NewDrandManagerWithClient(client)
+		assert.NoError(t, err)
+		client.fail.Store(true)
+
+		_, err = dm.GetRandomnessRange(1, 100)
+		assert.Error(t, err)
+	})
+}
+
+// TestRandomnessOwnership 測試返回的隨機性是副本，修改後不影響緩存
+func TestRandomnessOwnership(t *testing.T) {
+	dm, err := drandshuffle.NewDrandManagerWithClient(newFakeClient(1000))
+	assert.NoError(t, err)
+
+	latest, _, err := dm.GetLatestRandomness()
+	assert.NoError(t, err)
+	drandshuffle.Zeroize(latest)
+	assert.Equal(t, make([]byte, len(latest)), latest)
+
+	latest, _, err = dm.GetLatestRandomness()
+	assert.NoError(t, err)
+	assert.Equal(t, newFakeResult(1000).randomness, latest, "Cached latest randomness should be unaffected")
+
+	byRound, err := dm.GetRandomnessByRound(1000)
+	assert.NoError(t, err)
+	drandshuffle.Zeroize(byRound)
+
+	byRound, err = dm.GetRandomnessByRound(1000)
+	assert.NoError(t, err)
+	assert.Equal(t, newFakeResult(1000).randomness, byRound, "Cached round randomness should be unaffected")
+}
+
+// TestShuffleCache 測試洗牌結果緩存
+func TestShuffleCache(t *testing.T) {
+	t.Run("LRU eviction and copies", func(t *testing.T) {
+		cache := drandshuffle.NewShuffleCache(2)
+		deck := drandshuffle.InitializeDeck()
+		key := func(round uint64) drandshuffle.ShuffleKey {
+			return drandshuffle.ShuffleKey{Round: round, SessionID: "session", Deck: drandshuffle.StandardDeckTemplate}
+		}
+
+		cache.Put(key(1), deck)
+		cache.Put(key(2), deck)
+		_, ok := cache.Get(key(1)) // 使輪次 1 成為最近使用
+		assert.True(t, ok)
+		cache.Put(key(3), deck)
+
+		_, ok = cache.Get(key(2))
+		assert.False(t, ok, "Least recently used entry should be evicted")
+		assert.Equal(t, 2, cache.Len())
+
+		cached, ok := cache.Get(key(1))
+		assert.True(t, ok)
+		cached[0] = drandshuffle.Card{Suit: "梅花", Value: "2"}
+		cached, _ = cache.Get(key(1))
+		assert.Equal(t, deck[0], cached[0], "Cached deck should not be affected by caller mutation")
+	})
+
+	t.Run("Manager serves repeated sessions from cache", func(t *testing.T) {
+		client := newFakeClient(1000)
+		dm, err := drandshuffle.NewDrandManagerWithClient(client,
+			drandshuffle.WithCacheSize(1),
+			drandshuffle.WithShuffleCache(drandshuffle.NewShuffleCache(10)),
+		)
+		assert.NoError(t, err)
+
+		first, err := dm.ShuffledDeckByRound(42, "session_a")
+		assert.NoError(t, err)
+
+		// 擠出信標緩存，再次請求應直接由洗牌結果緩存返回，不訪問網絡
+		_, err = dm.GetRandomnessByRound(43)
+		assert.NoError(t, err)
+		second, err := dm.ShuffledDeckByRound(42, "session_a")
+		assert.NoError(t, err)
+
+		assert.Equal(t, first, second)
+		assert.Equal(t, 1, client.callCount(42))
+
+		// 不同的遊戲局號產生不同的結果
+		other, err := dm.ShuffledDeckByRound(42, "session_b")
+		assert.NoError(t, err)
+		assert.NotEqual(t, first, other)
+	})
+}
+
+// TestGetLatestRandomnessWithin 測試限時獲取最新隨機性
+func TestGetLatestRandomnessWithin(t *testing.T) {
+	t.Run("Fresh cache is served without fetching", func(t *testing.T) {
+		client := newFakeClient(1000)
+		dm, err := drandshuffle.NewDrandManagerWithClient(client)
+		assert.NoError(t, err)
+
+		_, round, err := dm.GetLatestRandomnessWithin(context.Background(), time.Minute, time.Second)
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(1000), round)
+		assert.Equal(t, 1, client.callCount(0), "Fresh beacon should not trigger a fetch")
+	})
+
+	t.Run("Stale cache triggers a fetch", func(t *testing.T) {
+		client := newFakeClient(1000)
+		dm, err := drandshuffle.NewDrandManagerWithClient(client)
+		assert.NoError(t, err)
+		atomic.StoreUint64(&client.latest, 1001)
+
+		_, round, err := dm.GetLatestRandomnessWithin(context.Background(), 0, time.Second)
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(1001), round)
+	})
+
+	t.Run("Slow fetch falls back to cache within budget", func(t *testing.T) {
+		client := newFakeClient(1000)
+		dm, err := drandshuffle.NewDrandManagerWithClient(client)
+		assert.NoError(t, err)
+		client.delay = 2 * time.Second
+		atomic.StoreUint64(&client.latest, 1001)
+
+		start := time.Now()
+		randomness, round, err := dm.GetLatestRandomnessWithin(context.Background(), 0, 50*time.Millisecond)
+		assert.NoError(t, err)
+		assert.Less(t, time.Since(start), time.Second, "Call should return within the budget")
+		assert.Equal(t, uint64(1000), round)
+		assert.Equal(t, newFakeResult(1000).randomness, randomness)
+	})
+}
+
+// TestSnapshot 測試一次取得最新信標和近期緩存的快照
+func TestSnapshot(t *testing.T) {
+	client := newFakeClient(1000)
+	dm, err := drandshuffle.NewDrandManagerWithClient(client)
+	assert.NoError(t, err)
+
+	for round := uint64(990); round < 1000; round++ {
+		_, err := dm.GetRandomnessByRound(round)
+		assert.NoError(t, err)
+	}
+
+	snap := dm.Snapshot(5)
+	assert.Equal(t, uint64(1000), snap.Latest.Round)
+	assert.Equal(t, newFakeResult(1000).randomness, snap.Latest.Randomness)
+
+	assert.Len(t, snap.Recent, 5)
+	for i, beacon := range snap.Recent {
+		assert.Equal(t, uint64(1000-i), beacon.Round, "Recent beacons should be ordered newest first")
+	}
+
+	beacon, ok := snap.Round(997)
+	assert.True(t, ok)
+	assert.Equal(t, newFakeResult(997).randomness, beacon.Randomness)
+	_, ok = snap.Round(990)
+	assert.False(t, ok, "Rounds outside the snapshot should not be found")
+
+	// 修改快照不影響緩存
+	drandshuffle.Zeroize(beacon.Randomness)
+	randomness, err := dm.GetRandomnessByRound(997)
+	assert.NoError(t, err)
+	assert.Equal(t, newFakeResult(997).randomness, randomness)
+
+	assert.Len(t, dm.Snapshot(0).Recent, 11)
+}
+
+// TestWarmStart 測試熱啟動時先使用保存的信標服務，連接恢復後才標記為就緒
+func TestWarmStart(t *testing.T) {
+	store := drandshuffle.NewFileBeaconStore(filepath.Join(t.TempDir(), "beacons.jsonl"))
+
+	// 第一次運行時獲取的信標寫入存儲
+	online := newFakeClient(1000)
+	dm, err := drandshuffle.NewDrandManagerWithClient(online, drandshuffle.WithBeaconStore(store))
+	assert.NoError(t, err)
+	_, err = dm.GetRandomnessByRound(995)
+	assert.NoError(t, err)
+	dm.Close()
+
+	// 網絡中斷時熱啟動不返回錯誤，從存儲中的信標開始服務
+	offline := newFakeClient(1001)
+	offline.fail.Store(true)
+	dm, err = drandshuffle.NewDrandManagerWithClient(offline,
+		drandshuffle.WithBeaconStore(store),
+		drandshuffle.WithWarmStart(),
+	)
+	assert.NoError(t, err)
+	defer dm.Close()
+
+	randomness, round, err := dm.GetLatestRandomness()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1000), round)
+	assert.Equal(t, newFakeResult(1000).randomness, randomness)
+
+	randomness, err = dm.GetRandomnessByRound(995)
+	assert.NoError(t, err)
+	assert.Equal(t, newFakeResult(995).randomness, randomness)
+
+	select {
+	case <-dm.Ready():
+		t.Fatal("Stored beacons should not mark the manager as ready")
+	default:
+	}
+
+	// 網絡恢復後獲取到即時信標
+	offline.fail.Store(false)
+	select {
+	case <-dm.Ready():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Manager should become ready once the network recovers")
+	}
+
+	_, round, err = dm.GetLatestRandomness()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1001), round)
+}
+
+// TestFileBeaconStoreTornWrite 測試最後一行不完整時忽略該行
+func TestFileBeaconStoreTornWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "beacons.jsonl")
+	store := drandshuffle.NewFileBeaconStore(path)
+
+	beacons, err := store.Load()
+	assert.NoError(t, err)
+	assert.Empty(t, beacons)
+
+	assert.NoError(t, store.Save(drandshuffle.Beacon{Round: 7, Randomness: newFakeResult(7).randomness}))
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	assert.NoError(t, err)
+	_, err = f.WriteString(`{"round":8,"rando`)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	beacons, err = store.Load()
+	assert.NoError(t, err)
+	assert.Len(t, beacons, 1)
+	assert.Equal(t, uint64(7), beacons[0].Round)
+	assert.Equal(t, newFakeResult(7).randomness, beacons[0].Randomness)
+}
+
+// TestPrefetch 測試批量預取使用有限的工作協程並報告每個輪次的進度
+func TestPrefetch(t *testing.T) {
+	t.Run("Bounded workers", func(t *testing.T) {
+		client := newFakeClient(1000)
+		client.delay = 10 * time.Millisecond
+
+		dm, err := drandshuffle.NewDrandManagerWithClient(client, drandshuffle.WithFetchConcurrency(3))
+		assert.NoError(t, err)
+
+		seen := make(map[uint64]bool)
+		last := 0
+		for p := range dm.Prefetch(context.Background(), 901, 950) {
+			assert.NoError(t, p.Err)
+			assert.Equal(t, 50, p.Total)
+			assert.Equal(t, last+1, p.Done, "Progress should count up by one")
+			last = p.Done
+			seen[p.Round] = true
+		}
+		assert.Len(t, seen, 50)
+		assert.LessOrEqual(t, atomic.LoadInt32(&client.peak), int32(3), "Concurrent requests should not exceed the worker count")
+
+		// 預取的輪次已寫入緩存
+		randomness, err := dm.GetRandomnessByRound(925)
+		assert.NoError(t, err)
+		assert.Equal(t, newFakeResult(925).randomness, randomness)
+		assert.Equal(t, 1, client.callCount(925))
+	})
+
+	t.Run("Cancellation", func(t *testing.T) {
+		client := newFakeClient(1000)
+		client.delay = 10 * time.Millisecond
+
+		dm, err := drandshuffle.NewDrandManagerWithClient(client, drandshuffle.WithFetchConcurrency(2))
+		assert.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		progress := dm.Prefetch(ctx, 1, 500)
+		<-progress
+		cancel()
+
+		count := 1
+		for range progress {
+			count++
+		}
+		assert.Less(t, count, 500, "Cancelling should stop dispatching new rounds")
+	})
+
+	t.Run("Invalid range", func(t *testing.T) {
+		dm, err := drandshuffle.NewDrandManagerWithClient(newFakeClient(1000))
+		assert.NoError(t, err)
+
+		progress := dm.Prefetch(context.Background(), 10, 5)
+		p, ok := <-progress
+		assert.True(t, ok)
+		assert.Error(t, p.Err)
+		_, ok = <-progress
+		assert.False(t, ok, "Channel should be closed after reporting the error")
+	})
+}
+
+// TestDealLatency 測試記錄從輪次發佈到交付牌組的延遲
+func TestDealLatency(t *testing.T) {
+	client := newFakeClient(1000)
+	// 使第 1000 輪剛好在一秒前發佈
+	client.info = &chain.Info{
+		Period:      3 * time.Second,
+		GenesisTime: time.Now().Add(-time.Second).Unix() - 999*3,
+	}
+
+	dm, err := drandshuffle.NewDrandManagerWithClient(client,
+		drandshuffle.WithDealLatencyBuckets(500*time.Millisecond, 5*time.Second, time.Minute),
+	)
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, round, err := dm.ShuffledDeck("latency-session")
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(1000), round)
+	}
+	// 按指定輪次的洗牌不計入
+	_, err = dm.ShuffledDeckByRound(10, "latency-session")
+	assert.NoError(t, err)
+
+	snap := dm.DealLatency()
+	assert.Equal(t, uint64(3), snap.Count)
+	assert.Equal(t, []uint64{0, 3, 0, 0}, snap.Counts)
+	assert.Equal(t, 5*time.Second, snap.Quantile(0.99))
+	assert.GreaterOrEqual(t, snap.Sum, 3*500*time.Millisecond)
+}
+
+// TestLatencyHistogramQuantile 測試樣本分佈在多個桶時的分位數
+func TestLatencyHistogramQuantile(t *testing.T) {
+	h := drandshuffle.NewLatencyHistogram(10*time.Millisecond, 100*time.Millisecond, time.Second)
+	h.Observe(5 * time.Millisecond)
+	h.Observe(50 * time.Millisecond)
+	h.Observe(500 * time.Millisecond)
+	snap := h.Snapshot()
+	assert.Equal(t, 10*time.Millisecond, snap.Quantile(0.1))
+	assert.Equal(t, 10*time.Millisecond, snap.Quantile(1.0/3))
+	assert.Equal(t, 100*time.Millisecond, snap.Quantile(0.5))
+	assert.Equal(t, time.Second, snap.Quantile(0.9))
+	assert.Equal(t, time.Second, snap.Quantile(0.99), "High quantiles should cover the slowest sample")
+	assert.Equal(t, time.Second, snap.Quantile(1))
+
+	h.Observe(2 * time.Second)
+	assert.Equal(t, time.Duration(-1), h.Snapshot().Quantile(0.99))
+	assert.Equal(t, 100*time.Millisecond, h.Snapshot().Quantile(0.5))
+}
+
+// countingStore 記錄寫入次數的內存信標存儲
+type countingStore struct {
+	mu      sync.Mutex
+	beacons []drandshuffle.Beacon
+	batches int
+	fail    bool
+	panics  bool
+}
+
+func (s *countingStore) Load() ([]drandshuffle.Beacon, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]drandshuffle.Beacon(nil), s.beacons...), nil
+}
+
+func (s *countingStore) Save(beacon drandshuffle.Beacon) error {
+	return s.SaveBatch([]drandshuffle.Beacon{beacon})
+}
+
+func (s *countingStore) SaveBatch(beacons []drandshuffle.Beacon) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fail {
+		return errors.New("disk full")
+	}
+	if s.panics {
+		panic("corrupt index")
+	}
+	s.beacons = append(s.beacons, beacons...)
+	s.batches++
+	return nil
+}
+
+func (s *countingStore) stats() (int, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.beacons), s.batches
+}
+
+// recordingHandler 記錄日誌的 slog.Handler，可以並發使用
+type recordingHandler struct {
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (h *recordingHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *recordingHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, r)
+	return nil
+}
+
+func (h *recordingHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h *recordingHandler) WithGroup(string) slog.Handler { return h }
+
+// count 返回指定級別和消息的日誌數量
+func (h *recordingHandler) count(level slog.Level, msg string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	n := 0
+	for _, r := range h.records {
+		if r.Level == level && r.Message == msg {
+			n++
+		}
+	}
+	return n
+}
+
+// TestWriteBehindStore 測試暫存的信標按批次寫入底層存儲
+func TestWriteBehindStore(t *testing.T) {
+	t.Run("Batches by size", func(t *testing.T) {
+		backing := &countingStore{}
+		store := drandshuffle.NewWriteBehindStore(backing, time.Hour, 50)
+
+		for round := uint64(1); round <= 120; round++ {
+			assert.NoError(t, store.Save(drandshuffle.Beacon{Round: round}))
+		}
+		assert.NoError(t, store.Close())
+
+		saved, batches := backing.stats()
+		assert.Equal(t, 120, saved)
+		assert.LessOrEqual(t, batches, 3, "Beacons should be written in batches")
+		assert.Error(t, store.Save(drandshuffle.Beacon{Round: 121}), "Save after Close should fail")
+
+		beacons, err := backing.Load()
+		assert.NoError(t, err)
+		for i, beacon := range beacons {
+			assert.Equal(t, uint64(i+1), beacon.Round, "Beacons should be written in order")
+		}
+	})
+
+	t.Run("Periodic flush", func(t *testing.T) {
+		backing := &countingStore{}
+		store := drandshuffle.NewWriteBehindStore(backing, 10*time.Millisecond, 100)
+		defer store.Close()
+
+		assert.NoError(t, store.Save(drandshuffle.Beacon{Round: 1}))
+		assert.Eventually(t, func() bool {
+			saved, _ := backing.stats()
+			return saved == 1
+		}, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("Retries failed writes", func(t *testing.T) {
+		backing := &countingStore{fail: true}
+		store := drandshuffle.NewWriteBehindStore(backing, time.Hour, 100)
+
+		assert.NoError(t, store.Save(drandshuffle.Beacon{Round: 1}))
+		assert.Error(t, store.Flush())
+
+		backing.mu.Lock()
+		backing.fail = false
+		backing.mu.Unlock()
+
+		assert.NoError(t, store.Close())
+		saved, _ := backing.stats()
+		assert.Equal(t, 1, saved)
+	})
+
+	t.Run("Logs background flush failures", func(t *testing.T) {
+		backing := &countingStore{fail: true}
+		store := drandshuffle.NewWriteBehindStore(backing, 5*time.Millisecond, 100)
+		handler := &recordingHandler{}
+		_, err := drandshuffle.NewDrandManagerWithClient(newFakeClient(1000),
+			drandshuffle.WithBeaconStore(store), drandshuffle.WithLogger(slog.New(handler)))
+		assert.NoError(t, err)
+
+		assert.NoError(t, store.Save(drandshuffle.Beacon{Round: 1}))
+		assert.Eventually(t, func() bool {
+			return handler.count(slog.LevelWarn, "無法寫入暫存的隨機信標") > 0
+		}, time.Second, 5*time.Millisecond, "Background flush failures should be logged")
+
+		backing.mu.Lock()
+		backing.fail = false
+		backing.mu.Unlock()
+		assert.NoError(t, store.Close())
+	})
+
+	t.Run("Journal survives crashes", func(t *testing.T) {
+		journal := filepath.Join(t.TempDir(), "beacons.journal")
+		crashed, err := drandshuffle.NewJournaledWriteBehindStore(&countingStore{}, journal, time.Hour, 100)
+		if !assert.NoError(t, err) {
+			return
+		}
+		for round := uint64(1); round <= 3; round++ {
+			assert.NoError(t, crashed.Save(drandshuffle.Beacon{Round: round, Randomness: []byte{byte(round)}}))
+		}
+		// 模擬崩潰：不調用 Close，暫存的信標只在日誌中；再留下一行寫入中斷的記錄
+		f, err := os.OpenFile(journal, os.O_WRONLY|os.O_APPEND, 0)
+		if assert.NoError(t, err) {
+			f.WriteString(`{"round":4,"rand`)
+			f.Close()
+		}
+
+		backing := &countingStore{}
+		store, err := drandshuffle.NewJournaledWriteBehindStore(backing, journal, time.Hour, 100)
+		if !assert.NoError(t, err) {
+			return
+		}
+		beacons, err := store.Load()
+		assert.NoError(t, err)
+		if assert.Len(t, beacons, 3, "Journaled beacons should be replayed") {
+			assert.Equal(t, []byte{3}, beacons[2].Randomness)
+		}
+		info, err := os.Stat(journal)
+		if assert.NoError(t, err) {
+			assert.Zero(t, info.Size(), "The journal should be truncated after a successful flush")
+		}
+
+		// 寫入失敗時信標留在日誌中，Close 之後仍可重放
+		backing.mu.Lock()
+		backing.fail = true
+		backing.mu.Unlock()
+		assert.NoError(t, store.Save(drandshuffle.Beacon{Round: 5, Randomness: []byte{5}}))
+		assert.Error(t, store.Close())
+		replayed := &countingStore{}
+		again, err := drandshuffle.NewJournaledWriteBehindStore(replayed, journal, time.Hour, 100)
+		if assert.NoError(t, err) {
+			assert.NoError(t, again.Close())
+			beacons, _ := replayed.Load()
+			if assert.Len(t, beacons, 1) {
+				assert.Equal(t, uint64(5), beacons[0].Round)
+			}
+		}
+	})
+
+	t.Run("Journal syncs once per batch", func(t *testing.T) {
+		journal := filepath.Join(t.TempDir(), "beacons.journal")
+		backing := &countingStore{}
+		store, err := drandshuffle.NewJournaledWriteBehindStore(backing, journal, time.Hour, 50)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		// 多個 goroutine 並發保存，日誌只在每批寫入前 fsync 一次，而不是每個信標一次
+		const workers, perWorker = 8, 250
+		var wg sync.WaitGroup
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				for i := 0; i < perWorker; i++ {
+					assert.NoError(t, store.Save(drandshuffle.Beacon{Round: uint64(w*perWorker + i + 1)}))
+				}
+			}(w)
+		}
+		wg.Wait()
+		assert.NoError(t, store.Close())
+
+		saved, batches := backing.stats()
+		assert.Equal(t, workers*perWorker, saved)
+		syncs := store.JournalSyncs()
+		assert.Positive(t, syncs)
+		assert.Equal(t, uint64(batches), syncs, "The journal should be synced once per batch")
+		assert.LessOrEqual(t, syncs, uint64(workers*perWorker/50+1), "The journal should not be synced per beacon")
+
+		// 未啟用日誌時不做 fsync
+		plain := drandshuffle.NewWriteBehindStore(&countingStore{}, time.Hour, 50)
+		assert.NoError(t, plain.Save(drandshuffle.Beacon{Round: 1}))
+		assert.NoError(t, plain.Close())
+		assert.Zero(t, plain.JournalSyncs())
+	})
+
+	t.Run("Recovers from store panics", func(t *testing.T) {
+		backing := &countingStore{panics: true}
+		store := drandshuffle.NewWriteBehindStore(backing, time.Hour, 100)
+
+		assert.NoError(t, store.Save(drandshuffle.Beacon{Round: 1}))
+		err := store.Flush()
+		var p *drandshuffle.PanicError
+		if assert.ErrorAs(t, err, &p) {
+			assert.Equal(t, "beacon_store", p.Where)
+			assert.Equal(t, "corrupt index", p.Value)
+		}
+
+		backing.mu.Lock()
+		backing.panics = false
+		backing.mu.Unlock()
+
+		assert.NoError(t, store.Close())
+		saved, _ := backing.stats()
+		assert.Equal(t, 1, saved, "The batch should be kept for retry after a panic")
+	})
+
+	t.Run("File store round trip", func(t *testing.T) {
+		store := drandshuffle.NewWriteBehindStore(
+			drandshuffle.NewFileBeaconStore(filepath.Join(t.TempDir(), "beacons.jsonl")), time.Hour, 10)
+		defer store.Close()
+
+		for round := uint64(1); round <= 25; round++ {
+			assert.NoError(t, store.Save(drandshuffle.Beacon{Round: round, Randomness: newFakeResult(round).randomness}))
+		}
+		beacons, err := store.Load()
+		assert.NoError(t, err)
+		assert.Len(t, beacons, 25)
+	})
+}
+
+// TestSentinelErrors 測試錯誤可以用 errors.Is 判斷類別
+func TestSentinelErrors(t *testing.T) {
+	client := newFakeClient(1000)
+	client.fail.Store(true)
+
+	dm, err := drandshuffle.NewDrandManagerWithClient(client)
+	assert.Nil(t, dm)
+	assert.ErrorIs(t, err, drandshuffle.ErrBeaconUnavailable)
+
+	dm, err = drandshuffle.NewDrandManagerWithClient(client, drandshuffle.WithWarmStart())
+	assert.NoError(t, err)
+	defer dm.Close()
+
+	_, _, err = dm.ShuffledDeck("session")
+	assert.ErrorIs(t, err, drandshuffle.ErrBeaconUnavailable)
+
+	_, err = dm.ShuffledDeckByRound(7, "session")
+	assert.ErrorIs(t, err, drandshuffle.ErrRoundNotFound)
+	assert.NotErrorIs(t, err, drandshuffle.ErrBeaconUnavailable)
+}
+
+// TestErrorCategories 測試錯誤類別及其重試語義
+func TestErrorCategories(t *testing.T) {
+	client := newFakeClient(1000)
+	dm, err := drandshuffle.NewDrandManagerWithClient(client)
+	assert.NoError(t, err)
+	defer dm.Close()
+
+	client.fail.Store(true)
+	_, err = dm.GetRandomnessByRound(7)
+	assert.Equal(t, drandshuffle.CategoryNetwork, drandshuffle.Category(err))
+	assert.True(t, drandshuffle.IsTemporary(err))
+	assert.True(t, drandshuffle.IsRetryable(err))
+	assert.ErrorIs(t, err, drandshuffle.ErrRoundNotFound)
+
+	// 調用者主動取消的請求是網絡錯誤，但不應重試
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = drandshuffle.NewClientWithManager(dm).Verify(ctx, 8, "session", nil)
+	assert.True(t, drandshuffle.IsTemporary(err))
+	assert.False(t, drandshuffle.IsRetryable(err))
+
+	_, err = dm.GetRandomnessRange(10, 5)
+	assert.Equal(t, drandshuffle.CategoryInput, drandshuffle.Category(err))
+	assert.False(t, drandshuffle.IsRetryable(err))
+
+	_, err = drandshuffle.StringToCard("bad")
+	assert.Equal(t, drandshuffle.CategoryInput, drandshuffle.Category(err))
+
+	client.fail.Store(false)
+	err = drandshuffle.NewClientWithManager(dm).Verify(context.Background(), 1000, "session", nil)
+	assert.Equal(t, drandshuffle.CategoryVerification, drandshuffle.Category(err))
+	assert.False(t, drandshuffle.IsTemporary(err))
+
+	assert.Equal(t, drandshuffle.CategoryUnknown, drandshuffle.Category(errors.New("other")))
+	assert.Equal(t, "network", drandshuffle.CategoryNetwork.String())
+
+	// 子包和擴展以導出的構造函數返回相同類別的錯誤，文字和哨兵錯誤不變
+	err = drandshuffle.NewInputError(fmt.Errorf("%w: bad seat", drandshuffle.ErrInvalidConfig))
+	assert.Equal(t, drandshuffle.CategoryInput, drandshuffle.Category(err))
+	assert.ErrorIs(t, err, drandshuffle.ErrInvalidConfig)
+	assert.EqualError(t, err, "drandshuffle: invalid config: bad seat")
+	err = drandshuffle.NewVerificationError(drandshuffle.ErrDeckMismatch)
+	assert.Equal(t, drandshuffle.CategoryVerification, drandshuffle.Category(err))
+	assert.False(t, drandshuffle.IsRetryable(err))
+}
+
+// TestRoundBounds 測試輪次 0 和尚未發布的輪次不會發出請求
+func TestRoundBounds(t *testing.T) {
+	client := newFakeClient(1000)
+	genesis := time.Now().Add(-time.Second).Unix() - 999*3
+	client.info = &chain.Info{Period: 3 * time.Second, GenesisTime: genesis}
+
+	dm, err := drandshuffle.NewDrandManagerWithClient(client)
+	assert.NoError(t, err)
+	defer dm.Close()
+
+	_, err = dm.GetRandomnessByRound(0)
+	assert.ErrorIs(t, err, drandshuffle.ErrRoundBeforeGenesis)
+	assert.Equal(t, drandshuffle.CategoryInput, drandshuffle.Category(err))
+
+	_, err = dm.ShuffledDeckByRound(1010, "session")
+	assert.ErrorIs(t, err, drandshuffle.ErrFutureRound)
+	var future *drandshuffle.FutureRoundError
+	if assert.ErrorAs(t, err, &future) {
+		assert.Equal(t, uint64(1010), future.Round)
+		assert.Equal(t, time.Unix(genesis+1009*3, 0), future.AvailableAt)
+	}
+	assert.Equal(t, 0, client.callCount(1010))
+
+	// 已發布的輪次照常獲取
+	_, err = dm.GetRandomnessByRound(1000)
+	assert.NoError(t, err)
+}
+
+// TestLogger 測試日誌記錄器的注入和默認的安靜行為
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	client := newFakeClient(1000)
+	dm, err := drandshuffle.NewDrandManagerWithClient(client, drandshuffle.WithLogger(logger))
+	assert.NoError(t, err)
+
+	client.fail.Store(true)
+	_, _, err = dm.GetLatestRandomnessWithin(context.Background(), 0, 10*time.Millisecond)
+	assert.NoError(t, err)
+
+	var record map[string]any
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "WARN", record["level"])
+	assert.Contains(t, record["error"], "fake network error")
+}
+
+// TestConfigValidate 測試配置檢查和選項對配置的修改
+func TestConfigValidate(t *testing.T) {
+	assert.NoError(t, drandshuffle.DefaultConfig().Validate())
+
+	cfg := drandshuffle.DefaultConfig()
+	cfg.ChainHash = "not-hex"
+	cfg.URLs = []string{"ftp://example.com", ""}
+	cfg.FetchTimeout = 0
+	err := cfg.Validate()
+	assert.ErrorIs(t, err, drandshuffle.ErrInvalidConfig)
+	assert.Contains(t, err.Error(), "鏈哈希")
+	assert.Contains(t, err.Error(), "ftp://example.com")
+	assert.Contains(t, err.Error(), "獲取超時")
+
+	client := newFakeClient(1000)
+	dm, err := drandshuffle.NewDrandManagerWithClient(client, drandshuffle.WithCacheSize(0))
+	assert.Nil(t, dm)
+	assert.ErrorIs(t, err, drandshuffle.ErrInvalidConfig)
+
+	cfg = drandshuffle.DefaultConfig()
+	cfg.CacheSize = 16
+	dm, err = drandshuffle.NewDrandManagerWithClient(client,
+		drandshuffle.WithConfig(cfg),
+		drandshuffle.WithFetchConcurrency(2),
+	)
+	assert.NoError(t, err)
+	defer dm.Close()
+
+	effective := dm.Config()
+	assert.Equal(t, 16, effective.CacheSize)
+	assert.Equal(t, 2, effective.FetchConcurrency)
+	assert.Equal(t, drandshuffle.QuicknetChainHash, effective.ChainHash)
+}
+
+// TestCatchPanic 測試 panic 轉換為 PanicError，panic 的值是錯誤時可以用 errors.Is 匹配
+func TestCatchPanic(t *testing.T) {
+	assert.NoError(t, drandshuffle.CatchPanic("noop", func() {}))
+
+	err := drandshuffle.CatchPanic("webhook", func() { panic(drandshuffle.ErrClosed) })
+	assert.ErrorIs(t, err, drandshuffle.ErrClosed)
+	assert.Equal(t, "webhook 發生 panic: drandshuffle: manager closed", err.Error())
+
+	err = drandshuffle.CatchPanic("nil", func() { panic(nil) })
+	var p *drandshuffle.PanicError
+	if assert.ErrorAs(t, err, &p) {
+		assert.NotEmpty(t, p.Stack)
+		assert.False(t, p.At.IsZero())
+	}
+}
@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/drandshuffletest"
+)
+
+// TestFakeBeaconSource 測試假隨機信標源配合 DrandManager 的腳本化行為
+func TestFakeBeaconSource(t *testing.T) {
+	src := drandshuffletest.NewFakeBeaconSource(1000)
+	src.SetRandomness(999, []byte("scripted randomness for round 999"))
+	dm := drandshuffletest.NewManager(t, src)
+
+	deck, round, err := dm.ShuffledDeck("game_1")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1000), round)
+	drandshuffletest.AssertStandardDeck(t, deck)
+	drandshuffletest.AssertDeck(t, src, round, "game_1", deck)
+
+	deck, err = dm.ShuffledDeckByRound(999, "game_1")
+	assert.NoError(t, err)
+	assert.Equal(t, drandshuffletest.ExpectedDeck(src, 999, "game_1"), deck)
+	assert.Equal(t, 1, src.Calls(999))
+
+	// 尚未發布的輪次和注入的錯誤
+	_, err = dm.ShuffledDeckByRound(1001, "game_1")
+	assert.ErrorIs(t, err, drandshuffle.ErrRoundNotFound)
+
+	injected := errors.New("relay down")
+	src.SetError(injected)
+	_, err = dm.GetRandomnessByRound(998)
+	assert.ErrorIs(t, err, injected)
+}
+
+// TestFakeBeaconSourceClock 測試跟隨時鐘推進的最新輪次和 Watch
+func TestFakeBeaconSourceClock(t *testing.T) {
+	genesis := time.Unix(1_700_000_000, 0)
+	clock := drandshuffletest.NewClock(genesis.Add(-time.Second))
+
+	src := drandshuffletest.NewFakeBeaconSource(0)
+	src.FollowClock(clock, genesis, 3*time.Second)
+	assert.Equal(t, uint64(0), src.Latest())
+
+	clock.Advance(time.Second)
+	assert.Equal(t, uint64(1), src.Latest())
+	clock.Advance(7 * time.Second)
+	assert.Equal(t, uint64(3), src.Latest())
+	assert.Equal(t, uint64(11), src.RoundAt(genesis.Add(30*time.Second)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := src.Watch(ctx)
+	go src.Publish(5)
+	beacon := <-ch
+	assert.Equal(t, uint64(5), beacon.GetRound())
+	assert.Equal(t, src.Randomness(5), beacon.GetRandomness())
+	assert.Equal(t, uint64(5), src.Latest())
+
+	cancel()
+	_, ok := <-ch
+	assert.False(t, ok)
+	assert.NoError(t, src.Close())
+	assert.NoError(t, src.Close())
+}
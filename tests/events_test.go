@@ -0,0 +1,145 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/drandshuffletest"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/events"
+)
+
+// TestShuffleCreatedEvent 測試洗牌完成事件的 CloudEvents 格式
+func TestShuffleCreatedEvent(t *testing.T) {
+	src := drandshuffletest.NewFakeBeaconSource(1000)
+	client := drandshuffle.NewClientWithManager(drandshuffletest.NewManager(t, src))
+	result, err := client.NewShuffle().Session("game_1").Round(990).WithProof().Do(context.Background())
+	if !assert.NoError(t, err) {
+		return
+	}
+	result.RoundTime = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	at := time.Date(2024, 5, 1, 12, 0, 1, 0, time.FixedZone("UTC+8", 8*3600))
+	event, err := events.NewShuffleCreated("/casino/table-7", result, "game_1", at)
+	if !assert.NoError(t, err) {
+		return
+	}
+	again, _ := events.NewShuffleCreated("/casino/table-7", result, "game_1", at.Add(time.Second))
+	assert.Equal(t, event.ID, again.ID, "Redelivered events should keep their ID")
+
+	encoded, err := json.Marshal(event)
+	assert.NoError(t, err)
+	var envelope map[string]any
+	assert.NoError(t, json.Unmarshal(encoded, &envelope))
+	assert.Equal(t, "1.0", envelope["specversion"])
+	assert.Equal(t, events.TypeShuffleCreated, envelope["type"])
+	assert.Equal(t, "game_1", envelope["subject"])
+	assert.Equal(t, "2024-05-01T04:00:01Z", envelope["time"])
+	assert.Equal(t, "application/json", envelope["datacontenttype"])
+	assert.True(t, strings.HasSuffix(envelope["dataschema"].(string), "/shuffle.created.v1.json"))
+	assert.NotContains(t, envelope, "traceparent")
+
+	parsed, err := events.Parse(encoded)
+	if !assert.NoError(t, err) {
+		return
+	}
+	var data events.ShuffleCreated
+	assert.NoError(t, parsed.DecodeData(&data))
+	assert.Equal(t, events.SchemaVersion, data.SchemaVersion)
+	assert.Equal(t, uint64(990), data.Round)
+	assert.Equal(t, 52, data.DeckSize)
+	assert.Equal(t, drandshuffle.DeckDigest(result.Deck), data.DeckDigest)
+	assert.Equal(t, result.Proof.Digest(), data.ProofDigest)
+	assert.True(t, result.RoundTime.Equal(*data.RoundTime))
+
+	schema, ok := events.Schema(events.TypeShuffleCreated)
+	if assert.True(t, ok) {
+		var doc struct {
+			Required   []string       `json:"required"`
+			Properties map[string]any `json:"properties"`
+		}
+		assert.NoError(t, json.Unmarshal(schema, &doc))
+		var fields map[string]any
+		assert.NoError(t, json.Unmarshal(parsed.Data, &fields))
+		for name := range fields {
+			assert.Contains(t, doc.Properties, name, "Every field should be described by the schema")
+		}
+		for _, name := range doc.Required {
+			assert.Contains(t, fields, name)
+		}
+	}
+	_, ok = events.Schema("com.example.unknown")
+	assert.False(t, ok)
+}
+
+// TestBeaconReceivedEvent 測試隨機信標事件和追蹤上下文
+func TestBeaconReceivedEvent(t *testing.T) {
+	signer := newTestSigner(t)
+	beacon := signer.beacon(7)
+	event, err := events.NewBeaconReceived("/casino/beacons", strings.Repeat("ab", 32), beacon, time.Now())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "7", event.Subject)
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1, 2, 3},
+		SpanID:     trace.SpanID{4, 5, 6},
+		TraceFlags: trace.FlagsSampled,
+	})
+	event.WithTraceContext(trace.ContextWithSpanContext(context.Background(), sc))
+	assert.Equal(t, "00-01020300000000000000000000000000-0405060000000000-01", event.TraceParent)
+
+	encoded, _ := json.Marshal(event)
+	parsed, err := events.Parse(encoded)
+	if !assert.NoError(t, err) {
+		return
+	}
+	var data events.BeaconReceived
+	assert.NoError(t, parsed.DecodeData(&data))
+	decoded, err := data.Beacon()
+	assert.NoError(t, err)
+	assert.Equal(t, beacon, decoded)
+	verifier, err := drandshuffle.NewVerifier(signer.info)
+	assert.NoError(t, err)
+	assert.NoError(t, verifier.Verify(decoded))
+}
+
+// TestParseEvent 測試拒絕不符合結構的事件
+func TestParseEvent(t *testing.T) {
+	valid := `{"specversion":"1.0","id":"1","source":"/s","type":"` + events.TypeBeaconReceived + `","time":"2024-05-01T00:00:00Z",` +
+		`"datacontenttype":"application/json","data":{"schema_version":1,"round":1,"randomness":"00","signature":"01","future_field":true}}`
+	_, err := events.Parse([]byte(valid))
+	assert.NoError(t, err, "Unknown fields are allowed within a schema version")
+
+	for name, input := range map[string]string{
+		"Not JSON":        "{",
+		"Spec version":    strings.Replace(valid, `"1.0"`, `"0.3"`, 1),
+		"Missing id":      strings.Replace(valid, `"id":"1",`, "", 1),
+		"Schema version":  strings.Replace(valid, `"schema_version":1`, `"schema_version":2`, 1),
+		"Missing round":   strings.Replace(valid, `"round":1,`, "", 1),
+		"Bad randomness":  strings.Replace(valid, `"randomness":"00"`, `"randomness":"XYZ"`, 1),
+		"Missing data":    strings.Replace(valid, `"data":`, `"other":`, 1),
+		"Bad shuffle":     strings.Replace(valid, events.TypeBeaconReceived, events.TypeShuffleCreated, 1),
+		"Mistyped field":  strings.Replace(valid, `"round":1`, `"round":"1"`, 1),
+		"Negative round":  strings.Replace(valid, `"round":1`, `"round":-1`, 1),
+		"Empty signature": strings.Replace(valid, `"signature":"01"`, `"signature":""`, 1),
+	} {
+		_, err := events.Parse([]byte(input))
+		assert.ErrorIs(t, err, events.ErrInvalidEvent, name)
+		assert.Equal(t, drandshuffle.CategoryInput, drandshuffle.Category(err), name)
+	}
+
+	unknown := strings.Replace(valid, events.TypeBeaconReceived, "com.example.other", 1)
+	_, err = events.Parse([]byte(unknown))
+	assert.NoError(t, err, "Only the envelope of unknown event types is checked")
+
+	_, err = events.NewShuffleCreated("", &drandshuffle.ShuffleResult{Round: 1, Deck: drandshuffle.InitializeDeck()}, "game_1", time.Now())
+	assert.ErrorIs(t, err, events.ErrInvalidEvent)
+}
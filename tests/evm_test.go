@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/drandshuffletest"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/evm"
+)
+
+// solidityShuffle 按參考合約 DrandShuffleVerifier.shuffle 的步驟洗牌
+func solidityShuffle(randomness [32]byte, sessionID string) []byte {
+	sum := sha256.Sum256(append(randomness[:], sessionID...))
+	seed := append(randomness[:], sum[:]...)
+	span := len(seed) - 8
+	deck := make([]byte, 52)
+	for k := range deck {
+		deck[k] = byte(k)
+	}
+	for i := 51; i > 0; i-- {
+		pos := i % span
+		j := binary.BigEndian.Uint64(seed[pos:pos+8]) % uint64(i+1)
+		deck[i], deck[j] = deck[j], deck[i]
+	}
+	return deck
+}
+
+// TestEVMEncoding 測試牌組的鏈上編碼
+func TestEVMEncoding(t *testing.T) {
+	empty := evm.Keccak256(nil)
+	assert.Equal(t, "c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470", hex.EncodeToString(empty[:]))
+
+	deck := drandshuffle.InitializeDeck()
+	encoded, err := evm.EncodeDeck(deck)
+	assert.NoError(t, err)
+	for i, b := range encoded {
+		assert.Equal(t, byte(i), b)
+	}
+	index, err := evm.CardIndex(drandshuffle.Card{Suit: "紅心", Value: "K"})
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(25), index)
+
+	decoded, err := evm.DecodeDeck(encoded)
+	assert.NoError(t, err)
+	assert.Equal(t, deck, decoded)
+
+	_, err = evm.CardIndex(drandshuffle.Card{Suit: "王", Value: "大"})
+	assert.ErrorIs(t, err, drandshuffle.ErrInvalidCard)
+	assert.Equal(t, drandshuffle.CategoryInput, drandshuffle.Category(err))
+	_, err = evm.DecodeDeck([]byte{52})
+	assert.ErrorIs(t, err, drandshuffle.ErrInvalidCard)
+
+	assert.Contains(t, evm.VerifierSource, "function verifyShuffle(uint64 round, bytes32 randomness, string calldata sessionId, bytes calldata deck)")
+}
+
+// TestEVMInputs 測試從洗牌證明產生鏈上驗證的輸入和交易數據
+func TestEVMInputs(t *testing.T) {
+	src := drandshuffletest.NewFakeBeaconSource(1000)
+	client := drandshuffle.NewClientWithManager(drandshuffletest.NewManager(t, src))
+	result, err := client.NewShuffle().Session("table_7").Round(990).WithProof().Do(context.Background())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	inputs, err := evm.NewInputs(result.Proof, result.Deck)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, uint64(990), inputs.Round)
+	assert.Equal(t, src.Randomness(990), inputs.Randomness[:])
+	assert.Equal(t, solidityShuffle(inputs.Randomness, "table_7"), inputs.Deck, "The reference contract should deal the same deck")
+	digest, err := evm.DeckDigest(result.Deck)
+	assert.NoError(t, err)
+	assert.Equal(t, digest, inputs.DeckDigest)
+
+	data := inputs.Calldata()
+	selector := evm.VerifySelector()
+	assert.Equal(t, selector[:], data[:4])
+	args := data[4:]
+	word := func(i int) []byte { return args[i*32 : (i+1)*32] }
+	assert.Equal(t, uint64(990), binary.BigEndian.Uint64(word(0)[24:]))
+	assert.Equal(t, inputs.Randomness[:], word(1))
+	sessionAt := binary.BigEndian.Uint64(word(2)[24:])
+	deckAt := binary.BigEndian.Uint64(word(3)[24:])
+	assert.Equal(t, uint64(128), sessionAt)
+	assert.Equal(t, uint64(len("table_7")), binary.BigEndian.Uint64(args[sessionAt+24:sessionAt+32]))
+	assert.Equal(t, "table_7", string(args[sessionAt+32:sessionAt+32+7]))
+	assert.Equal(t, uint64(52), binary.BigEndian.Uint64(args[deckAt+24:deckAt+32]))
+	assert.Equal(t, inputs.Deck, args[deckAt+32:deckAt+32+52])
+	assert.Len(t, args, 128+64+32+64, "Dynamic arguments are padded to 32 bytes")
+	assert.Equal(t, "0x"+hex.EncodeToString(data), evm.Hex(data))
+
+	t.Run("Errors", func(t *testing.T) {
+		swapped := append([]drandshuffle.Card(nil), result.Deck...)
+		swapped[0], swapped[1] = swapped[1], swapped[0]
+		_, err := evm.NewInputs(result.Proof, swapped)
+		assert.ErrorIs(t, err, drandshuffle.ErrDeckMismatch)
+
+		mixed, err := client.NewShuffle().Session("table_7").Round(990).WithContributions([]byte("player")).WithProof().Do(context.Background())
+		assert.NoError(t, err)
+		_, err = evm.NewInputs(mixed.Proof, mixed.Deck)
+		assert.Equal(t, drandshuffle.CategoryInput, drandshuffle.Category(err))
+
+		_, err = evm.NewInputs(nil, result.Deck)
+		assert.Equal(t, drandshuffle.CategoryInput, drandshuffle.Category(err))
+	})
+}
@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/drandshuffletest"
+)
+
+// TestExplainShuffle 測試洗牌說明與實際洗牌的結果一致，且每一步都可以從記錄的字節重新計算
+func TestExplainShuffle(t *testing.T) {
+	ctx := context.Background()
+	src := drandshuffletest.NewFakeBeaconSource(1000)
+	client := drandshuffle.NewClientWithManager(drandshuffletest.NewManager(t, src))
+
+	trace, err := client.ExplainShuffle(ctx, 990, "game_1")
+	if !assert.NoError(t, err) {
+		return
+	}
+	deck, err := client.ShuffleAtRound(ctx, 990, "game_1")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(990), trace.Round)
+	assert.Equal(t, drandshuffle.ProofAlgorithm, trace.Algorithm)
+	assert.Equal(t, hex.EncodeToString(src.Randomness(990)), trace.Randomness)
+	assert.Equal(t, trace.Randomness+trace.SessionHash, trace.Seed)
+	assert.Len(t, trace.Initial, 52)
+	assert.Len(t, trace.Steps, 51)
+	for i, card := range deck {
+		assert.Equal(t, drandshuffle.CardToString(card), trace.Final[i])
+	}
+
+	// 從初始牌組按記錄的步驟重放，每一步的數值都由記錄的字節決定
+	seed, _ := hex.DecodeString(trace.Seed)
+	replay := append([]string(nil), trace.Initial...)
+	for _, step := range trace.Steps {
+		raw, _ := hex.DecodeString(step.Bytes)
+		assert.Equal(t, seed[step.Offset:step.Offset+8], raw)
+		value := binary.BigEndian.Uint64(raw)
+		assert.Equal(t, strconv.FormatUint(value, 10), step.Value)
+		assert.Equal(t, int(value%uint64(step.I+1)), step.J)
+		assert.Equal(t, replay[step.I], step.CardI)
+		assert.Equal(t, replay[step.J], step.CardJ)
+		replay[step.I], replay[step.J] = replay[step.J], replay[step.I]
+	}
+	assert.Equal(t, trace.Final, replay)
+
+	// 默認每 10 步一個快照，最後一個快照是最終結果
+	if assert.Len(t, trace.Snapshots, 6) {
+		assert.Equal(t, 9, trace.Snapshots[0].AfterStep)
+		assert.Equal(t, 50, trace.Snapshots[5].AfterStep)
+		assert.Equal(t, trace.Final, trace.Snapshots[5].Deck)
+	}
+
+	// JSON 中的 64 位元整數以字符串表示
+	data, err := json.Marshal(trace)
+	assert.NoError(t, err)
+	var decoded map[string]any
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	steps := decoded["steps"].([]any)
+	assert.IsType(t, "", steps[0].(map[string]any)["value"])
+
+	_, err = client.ExplainShuffle(ctx, 990, "bad id")
+	assert.ErrorIs(t, err, drandshuffle.ErrInvalidSessionID)
+}
+
+// TestTraceShuffle 測試不連接網絡的洗牌說明和自定義牌組
+func TestTraceShuffle(t *testing.T) {
+	randomness := []byte("fixed randomness for trace")
+	template := drandshuffle.NewDeckTemplate(drandshuffle.DeckSpec{Suits: []string{"S"}, Values: []string{"1", "2", "3", "4", "5"}})
+
+	trace := drandshuffle.TraceShuffle(randomness, "t", drandshuffle.WithExplainDeck(template), drandshuffle.WithSnapshotEvery(0))
+	want := drandshuffle.NewShuffler(template).Shuffle(randomness, "t")
+	assert.Equal(t, uint64(0), trace.Round)
+	assert.Len(t, trace.Steps, 4)
+	for i, card := range want {
+		assert.Equal(t, drandshuffle.CardToString(card), trace.Final[i])
+	}
+	assert.Len(t, trace.Snapshots, 1, "only the final deck when snapshots are disabled")
+
+	trace = drandshuffle.TraceShuffle(randomness, "t", drandshuffle.WithExplainDeck(template), drandshuffle.WithSnapshotEvery(1))
+	assert.Len(t, trace.Snapshots, 4)
+}
@@ -0,0 +1,138 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/drandshuffletest"
+)
+
+// newRelayManager 創建連接 relays 的 DrandManager，測試結束時關閉
+func newRelayManager(t *testing.T, relays ...*drandshuffletest.FakeRelay) *drandshuffle.DrandManager {
+	t.Helper()
+	urls := make([]string, len(relays))
+	for i, relay := range relays {
+		urls[i] = relay.URL
+	}
+	dm, err := drandshuffle.NewDrandManager(
+		drandshuffle.WithChainInfo(relays[0].Info()),
+		drandshuffle.WithRelayURLs(urls...),
+	)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(dm.Close)
+	return dm
+}
+
+// TestFakeRelay 測試 DrandManager 從 FakeRelay 取得鏈信息並驗證隨機信標
+func TestFakeRelay(t *testing.T) {
+	relay := drandshuffletest.NewFakeRelay(t)
+
+	dm, err := drandshuffle.NewDrandManager(
+		drandshuffle.WithChainHash(relay.ChainHash()),
+		drandshuffle.WithRelayURLs(relay.URL),
+	)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer dm.Close()
+	assert.True(t, relay.Info().Equal(dm.ChainInfo()))
+
+	randomness, err := dm.GetRandomnessByRound(5)
+	assert.NoError(t, err)
+	assert.Equal(t, relay.Beacon(5).Randomness, randomness)
+	assert.Equal(t, 1, relay.Calls(5))
+
+	_, round, err := dm.GetLatestRandomness()
+	assert.NoError(t, err)
+	assert.InDelta(t, relay.CurrentRound(), round, 1, "latest round should follow real time")
+	assert.GreaterOrEqual(t, relay.Calls(drandshuffle.Latest), 1)
+
+	// 同一進程內的 FakeRelay 屬於同一條鏈
+	assert.Equal(t, relay.ChainHash(), drandshuffletest.NewFakeRelay(t).ChainHash())
+}
+
+// TestFakeRelayFailures 測試腳本化的失敗和無效簽名
+func TestFakeRelayFailures(t *testing.T) {
+	relay := drandshuffletest.NewFakeRelay(t)
+	dm := newRelayManager(t, relay)
+
+	// 失敗的請求可以重試
+	relay.FailNext(1, http.StatusServiceUnavailable)
+	_, err := dm.GetRandomnessByRound(7)
+	assert.Error(t, err)
+	assert.True(t, drandshuffle.IsRetryable(err), "relay failure should be retryable")
+	randomness, err := dm.GetRandomnessByRound(7)
+	assert.NoError(t, err)
+	assert.Equal(t, relay.Beacon(7).Randomness, randomness)
+	assert.Equal(t, 2, relay.Calls(7))
+
+	// WaitForRound 自動重試可重試的錯誤
+	relay.FailNext(2, http.StatusBadGateway)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	randomness, err = dm.WaitForRound(ctx, 8)
+	assert.NoError(t, err)
+	assert.Equal(t, relay.Beacon(8).Randomness, randomness)
+	assert.Equal(t, 3, relay.Calls(8))
+
+	// 簽名無效的信標不被接受
+	relay.CorruptRound(9)
+	_, err = dm.GetRandomnessByRound(9)
+	assert.Error(t, err)
+
+	// 尚未發布的輪次返回 404
+	relay.SetLatest(10)
+	assert.Equal(t, uint64(10), relay.CurrentRound())
+	_, err = dm.GetRandomnessByRound(11)
+	assert.Error(t, err)
+}
+
+// TestFakeRelayFailover 測試一個中繼停機或下線時 DrandManager 轉而使用其他中繼
+func TestFakeRelayFailover(t *testing.T) {
+	down := drandshuffletest.NewFakeRelay(t)
+	up := drandshuffletest.NewFakeRelay(t)
+	dm := newRelayManager(t, down, up)
+
+	down.SetStatus(http.StatusServiceUnavailable)
+	randomness, err := dm.GetRandomnessByRound(12)
+	assert.NoError(t, err)
+	assert.Equal(t, up.Beacon(12).Randomness, randomness)
+	assert.Equal(t, 1, up.Calls(12))
+
+	down.Close()
+	randomness, err = dm.GetRandomnessByRound(13)
+	assert.NoError(t, err)
+	assert.Equal(t, up.Beacon(13).Randomness, randomness)
+}
+
+// TestFakeRelayStaleness 測試中繼延遲和停止更新時 GetLatestRandomnessWithin 的處理
+func TestFakeRelayStaleness(t *testing.T) {
+	relay := drandshuffletest.NewFakeRelay(t)
+	stale := relay.CurrentRound() - 100
+	relay.SetLatest(stale)
+	dm := newRelayManager(t, relay)
+
+	// 中繼停止更新，刷新後仍只能取得舊的信標
+	calls := relay.Calls(drandshuffle.Latest)
+	randomness, round, err := dm.GetLatestRandomnessWithin(context.Background(), drandshuffletest.FakeRelayPeriod, 5*time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, stale, round)
+	assert.Equal(t, relay.Beacon(stale).Randomness, randomness)
+	assert.Greater(t, relay.Calls(drandshuffle.Latest), calls, "stale beacon should trigger a refresh")
+
+	// 中繼響應慢於時限時退回緩存的信標
+	relay.SetLatest(0)
+	relay.SetLatency(2 * time.Second)
+	start := time.Now()
+	_, round, err = dm.GetLatestRandomnessWithin(context.Background(), drandshuffletest.FakeRelayPeriod, 200*time.Millisecond)
+	assert.NoError(t, err)
+	assert.Equal(t, stale, round)
+	assert.Less(t, time.Since(start), time.Second, "budget should bound the wait")
+}
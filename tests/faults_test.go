@@ -0,0 +1,93 @@
+//go:build drandshufflefaults
+
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/drandshuffletest"
+)
+
+// newFaultyManager 創建經過 faults 連接 relays 的 DrandManager，測試結束時關閉
+func newFaultyManager(t *testing.T, faults *drandshuffle.Faults, relays ...*drandshuffletest.FakeRelay) *drandshuffle.DrandManager {
+	t.Helper()
+	urls := make([]string, len(relays))
+	for i, relay := range relays {
+		urls[i] = relay.URL
+	}
+	dm, err := drandshuffle.NewDrandManager(
+		drandshuffle.WithChainInfo(relays[0].Info()),
+		drandshuffle.WithRelayURLs(urls...),
+		drandshuffle.WithFaults(faults),
+	)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(dm.Close)
+	return dm
+}
+
+// TestFaultsDrop 測試丟棄的請求不到達中繼，返回可重試的錯誤，有其他中繼時轉而使用其他中繼
+func TestFaultsDrop(t *testing.T) {
+	relay := drandshuffletest.NewFakeRelay(t)
+	faults := &drandshuffle.Faults{}
+	dm := newFaultyManager(t, faults, relay)
+
+	faults.DropNext(1)
+	_, err := dm.GetRandomnessByRound(5)
+	assert.Error(t, err)
+	assert.True(t, drandshuffle.IsRetryable(err), "dropped fetch should be retryable")
+	assert.Equal(t, 0, relay.Calls(5), "dropped fetch should not reach the relay")
+
+	randomness, err := dm.GetRandomnessByRound(5)
+	assert.NoError(t, err)
+	assert.Equal(t, relay.Beacon(5).Randomness, randomness)
+
+	// 有兩個中繼時，丟棄一個請求後由另一個中繼提供
+	backup := drandshuffletest.NewFakeRelay(t)
+	dm = newFaultyManager(t, faults, relay, backup)
+	faults.DropNext(1)
+	randomness, err = dm.GetRandomnessByRound(6)
+	assert.NoError(t, err)
+	assert.Equal(t, relay.Beacon(6).Randomness, randomness)
+	assert.Equal(t, 1, relay.Calls(6)+backup.Calls(6))
+}
+
+// TestFaultsCorrupt 測試被破壞的簽名在驗證時被拒絕，不寫入緩存
+func TestFaultsCorrupt(t *testing.T) {
+	relay := drandshuffletest.NewFakeRelay(t)
+	faults := &drandshuffle.Faults{}
+	dm := newFaultyManager(t, faults, relay)
+
+	faults.CorruptNext(1)
+	_, err := dm.GetRandomnessByRound(7)
+	assert.Error(t, err)
+	assert.Equal(t, 1, relay.Calls(7))
+
+	randomness, err := dm.GetRandomnessByRound(7)
+	assert.NoError(t, err)
+	assert.Equal(t, relay.Beacon(7).Randomness, randomness)
+	assert.Equal(t, 2, relay.Calls(7))
+}
+
+// TestFaultsDelay 測試請求延遲時 GetLatestRandomnessWithin 在時限內退回緩存的信標
+func TestFaultsDelay(t *testing.T) {
+	relay := drandshuffletest.NewFakeRelay(t)
+	faults := &drandshuffle.Faults{}
+	dm := newFaultyManager(t, faults, relay)
+	_, cached, err := dm.GetLatestRandomness()
+	assert.NoError(t, err)
+
+	faults.SetDelay(2 * time.Second)
+	start := time.Now()
+	_, round, err := dm.GetLatestRandomnessWithin(context.Background(), 0, 200*time.Millisecond)
+	assert.NoError(t, err)
+	assert.Equal(t, cached, round)
+	assert.Less(t, time.Since(start), time.Second, "budget should bound the injected delay")
+
+}
@@ -0,0 +1,152 @@
+package tests
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/games/slots"
+)
+
+// 以下模糊測試在 go test 時只運行種子語料，使用 go test -fuzz=FuzzXxx ./tests 進行長時間的模糊測試
+
+// FuzzStringToCard 測試任意字符串都不會導致 StringToCard 崩潰，且解析結果可以往返
+func FuzzStringToCard(f *testing.F) {
+	for _, seed := range []string{"", "黑桃A", "紅心10", "方塊", "梅花Z", "黑", "\xe9\xbb", "黑桃A\x00", "♠A"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		card, err := drandshuffle.StringToCard(s)
+		if err != nil {
+			if !errors.Is(err, drandshuffle.ErrInvalidCard) {
+				t.Fatalf("錯誤應包裝 ErrInvalidCard: %v", err)
+			}
+			return
+		}
+		if got := drandshuffle.CardToString(card); got != s {
+			t.Fatalf("往返結果不一致: %q -> %q", s, got)
+		}
+	})
+}
+
+// FuzzDecodeDeck 測試任意字符串都不會導致 DecodeDeck 崩潰，且解析成功的牌組可以往返
+func FuzzDecodeDeck(f *testing.F) {
+	f.Add("")
+	f.Add("黑桃A")
+	f.Add("黑桃A, 紅心10 ,梅花K")
+	f.Add(",,")
+	f.Add("黑桃A,")
+	f.Add(drandshuffle.EncodeDeck(drandshuffle.InitializeDeck()))
+
+	f.Fuzz(func(t *testing.T, s string) {
+		deck, err := drandshuffle.DecodeDeck(s)
+		if err != nil {
+			if !errors.Is(err, drandshuffle.ErrInvalidCard) {
+				t.Fatalf("錯誤應包裝 ErrInvalidCard: %v", err)
+			}
+			return
+		}
+		again, err := drandshuffle.DecodeDeck(drandshuffle.EncodeDeck(deck))
+		if err != nil || !slices.Equal(deck, again) {
+			t.Fatalf("往返結果不一致: %q: %v", s, err)
+		}
+	})
+}
+
+// FuzzShuffleDeck 測試任意長度的隨機性和牌組都不會導致洗牌崩潰，且結果是確定性的排列
+func FuzzShuffleDeck(f *testing.F) {
+	for _, n := range []int{0, 1, 7, 8, 9, 15, 16, 17, 31, 32, 33, 64} {
+		f.Add(make([]byte, n), uint8(52), "game_1")
+	}
+	f.Add([]byte("short"), uint8(1), "")
+	f.Add([]byte("0123456789"), uint8(255), "\xff")
+
+	f.Fuzz(func(t *testing.T, randomness []byte, size uint8, gameSessionID string) {
+		deck := make([]drandshuffle.Card, size)
+		for i := range deck {
+			deck[i] = drandshuffle.Card{Suit: "S", Value: strconv.Itoa(i)}
+		}
+
+		shuffled := drandshuffle.ShuffleDeck(deck, randomness)
+		assertSamePermutation(t, deck, shuffled)
+		if !slices.Equal(shuffled, drandshuffle.ShuffleDeck(deck, randomness)) {
+			t.Fatal("相同的隨機性應產生相同的結果")
+		}
+
+		standard := drandshuffle.NewShuffler(nil).Shuffle(randomness, gameSessionID)
+		assertSamePermutation(t, drandshuffle.InitializeDeck(), standard)
+	})
+}
+
+// assertSamePermutation 檢查 got 是 want 的一個排列
+func assertSamePermutation(t *testing.T, want, got []drandshuffle.Card) {
+	t.Helper()
+	if len(want) != len(got) {
+		t.Fatalf("長度不一致: %d != %d", len(want), len(got))
+	}
+	counts := make(map[drandshuffle.Card]int, len(want))
+	for _, card := range want {
+		counts[card]++
+	}
+	for _, card := range got {
+		counts[card]--
+	}
+	for card, n := range counts {
+		if n != 0 {
+			t.Fatalf("牌 %v 的數量不一致", card)
+		}
+	}
+}
+
+// TestPanicFreeInputs 測試公開函數在邊界輸入下不會崩潰
+func TestPanicFreeInputs(t *testing.T) {
+	t.Run("Acquire deck after shrinking capacity", func(t *testing.T) {
+		d := drandshuffle.AcquireDeck()
+		d.Cards = d.Cards[:0:0]
+		d.Release()
+
+		for i := 0; i < 4; i++ {
+			d := drandshuffle.AcquireDeck()
+			assert.Len(t, d.Cards, 52)
+			d.Release()
+		}
+	})
+
+	t.Run("Quantile with mismatched snapshot", func(t *testing.T) {
+		s := drandshuffle.HistogramSnapshot{
+			Bounds: []time.Duration{time.Millisecond},
+			Counts: []uint64{0, 0, 5},
+		}
+		assert.Equal(t, time.Duration(-1), s.Quantile(0.5))
+		assert.NotPanics(t, func() {
+			s.Quantile(-1)
+			s.Quantile(2)
+			s.Quantile(math.NaN())
+		})
+	})
+
+	t.Run("Nil template", func(t *testing.T) {
+		var template *drandshuffle.DeckTemplate
+		assert.Equal(t, 0, template.Len())
+		assert.Empty(t, template.NewDeck())
+		assert.Len(t, drandshuffle.NewShuffler(nil).Shuffle(nil, ""), 52)
+	})
+
+	t.Run("Slots window with invalid stops", func(t *testing.T) {
+		m := slots.Machine{Reels: []slots.Reel{{Symbols: []string{"7", "BAR"}}, {}}}
+		assert.NotPanics(t, func() {
+			m.Window(nil, 3)
+			m.Window([]int{math.MinInt, math.MaxInt}, 1)
+			m.Window([]int{1, 0, 5}, -1)
+			slots.Machine{}.Window([]int{0}, 2)
+		})
+		assert.Equal(t, [][]string{{"BAR", "7"}, nil}, m.Window([]int{1, 0}, 2))
+	})
+}
@@ -0,0 +1,124 @@
+package tests
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"math/bits"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/drandshuffletest"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/giveaway"
+)
+
+// rfc6962Root 按 RFC 6962 的遞歸定義計算 Merkle 根，用於核對 giveaway.MerkleRoot
+func rfc6962Root(entries []string) []byte {
+	if len(entries) == 1 {
+		sum := sha256.Sum256(append([]byte{0x00}, entries[0]...))
+		return sum[:]
+	}
+	k := 1 << (bits.Len(uint(len(entries)-1)) - 1)
+	node := append([]byte{0x01}, rfc6962Root(entries[:k])...)
+	sum := sha256.Sum256(append(node, rfc6962Root(entries[k:])...))
+	return sum[:]
+}
+
+// giveawayEntries 返回 n 個測試用的參與項
+func giveawayEntries(n int) []string {
+	entries := make([]string, n)
+	for i := range entries {
+		entries[i] = fmt.Sprintf("user_%05d@example.com", i)
+	}
+	return entries
+}
+
+// TestGiveawayMerkle 測試 Merkle 根與 RFC 6962 一致，以及每個參與項的包含證明
+func TestGiveawayMerkle(t *testing.T) {
+	for n := 1; n <= 33; n++ {
+		entries := giveawayEntries(n)
+		root := giveaway.MerkleRoot(entries)
+		assert.Equal(t, rfc6962Root(entries), root, n)
+		for i := range entries {
+			proof, err := giveaway.Prove(entries, i)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.NoError(t, giveaway.VerifyInclusion(root, n, entries[i], proof), "size %d index %d", n, i)
+			err = giveaway.VerifyInclusion(root, n, "someone_else", proof)
+			assert.ErrorIs(t, err, giveaway.ErrInvalidInclusion)
+		}
+	}
+	_, err := giveaway.Prove(giveawayEntries(3), 3)
+	assert.ErrorIs(t, err, drandshuffle.ErrInvalidConfig)
+}
+
+// TestGiveawayPickWinners 測試以承諾抽出得獎者並驗證，以及名單在承諾後被改動的情況
+func TestGiveawayPickWinners(t *testing.T) {
+	src := drandshuffletest.NewFakeBeaconSource(1000)
+	client := drandshuffle.NewClientWithManager(drandshuffletest.NewManager(t, src))
+	ctx := context.Background()
+	entries := giveawayEntries(1000)
+
+	commitment, err := giveaway.NewCommitment("giveaway_2024_launch", 990, entries)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 1000, commitment.Entries)
+
+	result, err := giveaway.PickWinners(ctx, client, commitment, entries, 5)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, result.Winners, 5)
+	indices := giveaway.WinnerIndices(result.Proof.Seed, commitment.Root, 1000, 5)
+	for i, w := range result.Winners {
+		assert.Equal(t, indices[i], w.Inclusion.Index)
+		assert.Equal(t, entries[w.Inclusion.Index], w.Entry)
+	}
+	assert.NoError(t, giveaway.Verify(commitment, result))
+
+	again, err := giveaway.PickWinners(ctx, client, commitment, entries, 5)
+	if assert.NoError(t, err) {
+		assert.Equal(t, result.Winners, again.Winners)
+	}
+
+	// 抽獎後修改名單，與承諾不符
+	edited := append([]string(nil), entries...)
+	edited[0] = "late_entry@example.com"
+	_, err = giveaway.PickWinners(ctx, client, commitment, edited, 5)
+	assert.ErrorIs(t, err, giveaway.ErrRootMismatch)
+	_, err = giveaway.PickWinners(ctx, client, commitment, entries[:999], 5)
+	assert.ErrorIs(t, err, giveaway.ErrRootMismatch)
+
+	// 替換得獎者或調換順序都無法通過驗證
+	tampered := *result
+	tampered.Winners = append([]giveaway.Winner(nil), result.Winners...)
+	tampered.Winners[0].Entry = "friend@example.com"
+	err = giveaway.Verify(commitment, &tampered)
+	assert.ErrorIs(t, err, giveaway.ErrInvalidInclusion)
+	assert.Equal(t, drandshuffle.CategoryVerification, drandshuffle.Category(err))
+	tampered.Winners[0], tampered.Winners[1] = result.Winners[1], result.Winners[0]
+	assert.ErrorIs(t, giveaway.Verify(commitment, &tampered), giveaway.ErrWinnersMismatch)
+
+	// 以另一個承諾驗證
+	other, err := giveaway.NewCommitment("giveaway_2024_launch", 991, entries)
+	if assert.NoError(t, err) {
+		assert.ErrorIs(t, giveaway.Verify(other, result), giveaway.ErrWinnersMismatch)
+	}
+
+	_, err = giveaway.NewCommitment("giveaway_x", drandshuffle.Latest, entries)
+	assert.ErrorIs(t, err, drandshuffle.ErrExplicitRoundRequired)
+	_, err = giveaway.NewCommitment("giveaway_x", 990, nil)
+	assert.ErrorIs(t, err, drandshuffle.ErrInvalidConfig)
+	_, err = giveaway.PickWinners(ctx, client, commitment, entries, 1001)
+	assert.ErrorIs(t, err, drandshuffle.ErrInvalidConfig)
+
+	future, err := giveaway.NewCommitment("giveaway_future", 5000, entries)
+	if assert.NoError(t, err) {
+		_, err = giveaway.PickWinners(ctx, client, future, entries, 1)
+		assert.ErrorIs(t, err, drandshuffle.ErrRoundNotFound)
+	}
+}
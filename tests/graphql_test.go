@@ -0,0 +1,201 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/drandshuffletest"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/sqlstore"
+)
+
+// graphQLResult 是測試解析的 GraphQL 響應
+type graphQLResult struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// postGraphQL 以 POST 發送查詢並返回狀態碼和響應
+func postGraphQL(t *testing.T, server *httptest.Server, query string, variables map[string]any) (int, graphQLResult) {
+	t.Helper()
+	body, _ := json.Marshal(map[string]any{"query": query, "variables": variables})
+	resp, err := http.Post(server.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var result graphQLResult
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	return resp.StatusCode, result
+}
+
+// TestGraphQL 測試以 GraphQL 查詢牌局、隨機信標、證明和驗證狀態
+func TestGraphQL(t *testing.T) {
+	store, _ := newSQLStore(t)
+	ctx := context.Background()
+	src := drandshuffletest.NewFakeBeaconSource(1000)
+	client := drandshuffle.NewClientWithManager(drandshuffletest.NewManager(t, src))
+
+	for i := 0; i < 4; i++ {
+		id := fmt.Sprintf("game_%d", i)
+		result, err := client.NewShuffle().Session(id).Round(uint64(990 + i/2)).WithProof().Do(ctx)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.NoError(t, store.SaveSession(ctx, sqlstore.Session{
+			SessionID: id, Round: result.Round, Deck: result.Deck, Proof: result.Proof, Tenant: "casino_a",
+		}))
+	}
+	assert.NoError(t, store.SaveBatch([]drandshuffle.Beacon{src.Beacon(990), src.Beacon(991)}))
+	assert.NoError(t, store.VerifySession(ctx, "game_2", nil))
+
+	server := httptest.NewServer(sqlstore.NewGraphQLHandler(store))
+	defer server.Close()
+
+	status, result := postGraphQL(t, server, `
+		query Dashboard($tenant: String, $first: Int = 3) {
+			page: sessions(tenant: $tenant, minRound: 990, first: $first) {
+				nodes {
+					__typename
+					sessionId
+					round
+					verification
+					proof { digest provider }
+					beacon { round randomness }
+				}
+				nextCursor
+			}
+			verified: sessions(verification: VERIFIED) { nodes { sessionId } }
+			beacon(round: 991) { round sessions(first: 10) { nodes { sessionId } } }
+			missing: session(id: "nope") { sessionId }
+		}`, map[string]any{"tenant": "casino_a"})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Empty(t, result.Errors)
+
+	var data struct {
+		Page struct {
+			Nodes []struct {
+				Typename     string `json:"__typename"`
+				SessionID    string `json:"sessionId"`
+				Round        uint64 `json:"round"`
+				Verification string `json:"verification"`
+				Proof        struct {
+					Digest   string `json:"digest"`
+					Provider string `json:"provider"`
+				} `json:"proof"`
+				Beacon *struct {
+					Round      uint64 `json:"round"`
+					Randomness string `json:"randomness"`
+				} `json:"beacon"`
+			} `json:"nodes"`
+			NextCursor *string `json:"nextCursor"`
+		} `json:"page"`
+		Verified struct {
+			Nodes []struct {
+				SessionID string `json:"sessionId"`
+			} `json:"nodes"`
+		} `json:"verified"`
+		Beacon struct {
+			Round    uint64 `json:"round"`
+			Sessions struct {
+				Nodes []struct {
+					SessionID string `json:"sessionId"`
+				} `json:"nodes"`
+			} `json:"sessions"`
+		} `json:"beacon"`
+		Missing *struct{} `json:"missing"`
+	}
+	if !assert.NoError(t, json.Unmarshal(result.Data, &data)) {
+		return
+	}
+	if assert.Len(t, data.Page.Nodes, 3) {
+		node := data.Page.Nodes[0]
+		assert.Equal(t, "Session", node.Typename)
+		assert.Equal(t, "game_0", node.SessionID)
+		assert.Equal(t, "UNVERIFIED", node.Verification)
+		assert.Equal(t, drandshuffle.ProviderDrand, node.Proof.Provider)
+		session, err := store.LoadSession(ctx, "game_0")
+		if assert.NoError(t, err) {
+			assert.Equal(t, session.Proof.Digest(), node.Proof.Digest)
+		}
+		if assert.NotNil(t, node.Beacon) {
+			assert.Equal(t, fmt.Sprintf("%x", src.Randomness(990)), node.Beacon.Randomness)
+		}
+		assert.Equal(t, "VERIFIED", data.Page.Nodes[2].Verification)
+	}
+	assert.NotNil(t, data.Page.NextCursor)
+	if assert.Len(t, data.Verified.Nodes, 1) {
+		assert.Equal(t, "game_2", data.Verified.Nodes[0].SessionID)
+	}
+	assert.Equal(t, uint64(991), data.Beacon.Round)
+	assert.Len(t, data.Beacon.Sessions.Nodes, 2)
+	assert.Nil(t, data.Missing)
+
+	// 響應中的字段按選擇集的順序排列
+	assert.Contains(t, string(result.Data), `{"page":`)
+
+	// GET 請求
+	resp, err := http.Get(server.URL + "?query=" + url.QueryEscape(`{ session(id: "game_1") { round } }`))
+	if assert.NoError(t, err) {
+		var got graphQLResult
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+		resp.Body.Close()
+		assert.JSONEq(t, `{"session":{"round":990}}`, string(got.Data))
+	}
+
+	for query, wantStatus := range map[string]int{
+		`{ sessions { nodes { unknown } } }`:                    http.StatusOK,
+		`{ sessions { nodes } }`:                                http.StatusOK,
+		`{ session(id: "game_1") { round { x } } }`:             http.StatusOK,
+		`{ sessions(verification: MAYBE) { nodes { round } } }`: http.StatusOK,
+		`{ sessions(bogus: 1) { nodes { round } } }`:            http.StatusOK,
+		`query { beacon(round: $r) { round } }`:                 http.StatusOK,
+		`mutation { x }`:                                        http.StatusBadRequest,
+		`{ sessions { ...F } }`:                                 http.StatusBadRequest,
+		`{ sessions { nodes { round }`:                          http.StatusBadRequest,
+		`query A { beacon(round: 1) { round } } query B { beacon(round: 2) { round } }`: http.StatusBadRequest,
+	} {
+		status, result := postGraphQL(t, server, query, nil)
+		assert.Equal(t, wantStatus, status, query)
+		assert.NotEmpty(t, result.Errors, query)
+		assert.Empty(t, result.Data, query)
+	}
+
+	assert.Contains(t, sqlstore.GraphQLSchema, "type Session {")
+
+	t.Run("Fan-out budget", func(t *testing.T) {
+		store, _ := newSQLStore(t)
+		result, err := client.NewShuffle().Session("fan_0").Round(990).Do(ctx)
+		if !assert.NoError(t, err) {
+			return
+		}
+		for i := 0; i < 50; i++ {
+			assert.NoError(t, store.SaveSession(ctx, sqlstore.Session{SessionID: fmt.Sprintf("fan_%d", i), Round: 990, Deck: result.Deck}))
+		}
+		assert.NoError(t, store.Save(src.Beacon(990)))
+		server := httptest.NewServer(sqlstore.NewGraphQLHandler(store))
+		defer server.Close()
+
+		// 一頁牌局各自查詢隨機信標在預算之內
+		_, ok := postGraphQL(t, server, `{ sessions(first: 1000) { nodes { beacon { round } } } }`, nil)
+		assert.Empty(t, ok.Errors)
+
+		// 深度不超過限制，但每層列表都使查詢次數成倍增加
+		status, got := postGraphQL(t, server, `{ sessions(first: 1000) { nodes { beacon { sessions(first: 1000) { nodes { beacon { round } } } } } } }`, nil)
+		assert.Equal(t, http.StatusOK, status)
+		if assert.Len(t, got.Errors, 1) {
+			assert.Contains(t, got.Errors[0].Message, "數據庫查詢")
+		}
+		assert.Empty(t, got.Data)
+	})
+}
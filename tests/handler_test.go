@@ -0,0 +1,218 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/drandshuffletest"
+)
+
+// TestHandlers 測試將洗牌、驗證和信標接口掛在調用者自己的路由和中間件下
+func TestHandlers(t *testing.T) {
+	signer := newTestSigner(t)
+	relay, _ := newTestRelay(t, signer)
+	dm, err := drandshuffle.NewDrandManager(
+		drandshuffle.WithChainInfo(signer.info),
+		drandshuffle.WithRelayURLs(relay.URL),
+	)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer dm.Close()
+	client := drandshuffle.NewClientWithManager(dm)
+
+	// 調用者的路由前綴和認證中間件
+	requireKey := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Api-Key") != "secret" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/api/v1/shuffle", requireKey(drandshuffle.NewShuffleHandler(client)))
+	mux.Handle("/api/v1/verify", drandshuffle.NewVerifyHandler(client))
+	mux.Handle("/api/v1/beacon", drandshuffle.NewBeaconHandler(dm))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	post := func(path string, body any, key string) (*http.Response, []byte) {
+		data, _ := json.Marshal(body)
+		req, _ := http.NewRequest(http.MethodPost, server.URL+path, bytes.NewReader(data))
+		req.Header.Set("X-Api-Key", key)
+		resp, err := http.DefaultClient.Do(req)
+		if !assert.NoError(t, err) {
+			return &http.Response{}, nil
+		}
+		defer resp.Body.Close()
+		got, _ := io.ReadAll(resp.Body)
+		return resp, got
+	}
+
+	resp, _ := post("/api/v1/shuffle", drandshuffle.ShuffleRequest{SessionID: "game_1", Round: 7}, "")
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp, body := post("/api/v1/shuffle", drandshuffle.ShuffleRequest{
+		SessionID: "game_1", Round: 7, Contributions: [][]byte{[]byte("player seed")},
+	}, "secret")
+	if !assert.Equal(t, http.StatusOK, resp.StatusCode, string(body)) {
+		return
+	}
+	var shuffled drandshuffle.ShuffleResponse
+	assert.NoError(t, json.Unmarshal(body, &shuffled))
+	assert.Equal(t, uint64(7), shuffled.Round)
+	assert.Equal(t, signer.beacon(7).Signature, shuffled.Proof.Signature)
+	want, err := client.NewShuffle().Session("game_1").Round(7).WithContributions([]byte("player seed")).Do(context.Background())
+	if assert.NoError(t, err) {
+		assert.Equal(t, drandshuffle.EncodeDeck(want.Deck), shuffled.Deck)
+	}
+
+	// 按證明驗證，包括隨機信標的簽名
+	var verified drandshuffle.VerifyResponse
+	resp, body = post("/api/v1/verify", drandshuffle.VerifyRequest{Deck: shuffled.Deck, Proof: shuffled.Proof}, "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NoError(t, json.Unmarshal(body, &verified))
+	assert.True(t, verified.Valid, verified.Reason)
+
+	forged := *shuffled.Proof
+	forged.Signature = signer.beacon(8).Signature
+	resp, body = post("/api/v1/verify", drandshuffle.VerifyRequest{Deck: shuffled.Deck, Proof: &forged}, "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NoError(t, json.Unmarshal(body, &verified))
+	assert.False(t, verified.Valid)
+	assert.NotEmpty(t, verified.Reason)
+
+	// 不帶證明時按輪次和遊戲局號驗證
+	deck, err := client.ShuffleAtRound(context.Background(), 9, "game_2")
+	if assert.NoError(t, err) {
+		resp, body = post("/api/v1/verify", drandshuffle.VerifyRequest{Deck: drandshuffle.EncodeDeck(deck), Round: 9, SessionID: "game_2"}, "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NoError(t, json.Unmarshal(body, &verified))
+		assert.True(t, verified.Valid, verified.Reason)
+
+		resp, body = post("/api/v1/verify", drandshuffle.VerifyRequest{Deck: drandshuffle.EncodeDeck(deck), Round: 9, SessionID: "game_3"}, "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NoError(t, json.Unmarshal(body, &verified))
+		assert.False(t, verified.Valid)
+	}
+
+	// 輸入錯誤和尚未發布的輪次
+	resp, _ = post("/api/v1/shuffle", drandshuffle.ShuffleRequest{SessionID: ""}, "secret")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp, _ = post("/api/v1/shuffle", map[string]any{"session_id": "game_1", "bogus": 1}, "secret")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp, _ = post("/api/v1/shuffle", drandshuffle.ShuffleRequest{SessionID: "game_1", Round: 1 << 40}, "secret")
+	assert.Equal(t, http.StatusTooEarly, resp.StatusCode)
+	assert.NotEmpty(t, resp.Header.Get("Retry-After"))
+	resp, _ = post("/api/v1/verify", drandshuffle.VerifyRequest{Deck: "not a card"}, "")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = http.Get(server.URL + "/api/v1/shuffle")
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	}
+	resp, err = http.Get(server.URL + "/api/v1/verify")
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+		assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+	}
+
+	// 信標接口的格式與中繼相同
+	resp, err = http.Get(server.URL + "/api/v1/beacon?round=7")
+	if assert.NoError(t, err) {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		beacons, err := drandshuffle.ParseBeaconJSON(body)
+		if assert.NoError(t, err) {
+			assert.Equal(t, signer.beacon(7), beacons[0])
+		}
+	}
+	resp, err = http.Get(server.URL + "/api/v1/beacon")
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	resp, err = http.Get(server.URL + "/api/v1/beacon?round=x")
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	}
+}
+
+// TestVerifyHandlerUnverifiableProof 測試無法驗證隨機信標的證明不會被報告為有效
+func TestVerifyHandlerUnverifiableProof(t *testing.T) {
+	signer := newTestSigner(t)
+	relay, _ := newTestRelay(t, signer)
+	dm, err := drandshuffle.NewDrandManager(
+		drandshuffle.WithChainInfo(signer.info),
+		drandshuffle.WithRelayURLs(relay.URL),
+	)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer dm.Close()
+	client := drandshuffle.NewClientWithManager(dm)
+	result, err := client.NewShuffle().Session("game_1").Round(7).WithProof().Do(context.Background())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	verify := func(client *drandshuffle.Client, deck []drandshuffle.Card, proof *drandshuffle.ShuffleProof) drandshuffle.VerifyResponse {
+		data, _ := json.Marshal(drandshuffle.VerifyRequest{Deck: drandshuffle.EncodeDeck(deck), Proof: proof})
+		rec := httptest.NewRecorder()
+		drandshuffle.NewVerifyHandler(client).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/verify", bytes.NewReader(data)))
+		var resp drandshuffle.VerifyResponse
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		return resp
+	}
+	assert.True(t, verify(client, result.Deck, result.Proof).Valid)
+
+	// 偽造的隨機性和與之一致的牌組，只改動鏈哈希或隨機性來源也不能通過驗證
+	randomness := sha256.Sum256([]byte("forged"))
+	forged := *result.Proof
+	forged.Randomness = randomness[:]
+	deck := drandshuffle.NewShuffler(drandshuffle.Poker52).Shuffle(forged.Randomness, "game_1")
+	resp := verify(client, deck, &forged)
+	assert.False(t, resp.Valid)
+
+	t.Run("Foreign chain", func(t *testing.T) {
+		proof := forged
+		proof.ChainHash = "deadbeef"
+		resp := verify(client, deck, &proof)
+		assert.False(t, resp.Valid)
+		assert.Contains(t, resp.Reason, "deadbeef")
+	})
+
+	t.Run("Foreign provider", func(t *testing.T) {
+		proof := forged
+		proof.Provider = "hsm"
+		resp := verify(client, deck, &proof)
+		assert.False(t, resp.Valid)
+		assert.Contains(t, resp.Reason, "hsm")
+	})
+
+	t.Run("Missing chain info", func(t *testing.T) {
+		// 假信標源不提供鏈信息，即使牌組與證明一致也無法驗證簽名
+		client := drandshuffle.NewClientWithManager(drandshuffletest.NewManager(t, drandshuffletest.NewFakeBeaconSource(10)))
+		result, err := client.NewShuffle().Session("game_1").Round(7).WithProof().Do(context.Background())
+		if !assert.NoError(t, err) {
+			return
+		}
+		resp := verify(client, result.Deck, result.Proof)
+		assert.False(t, resp.Valid)
+		assert.NotEmpty(t, resp.Reason)
+	})
+}
@@ -0,0 +1,147 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/drandshuffletest"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/games/holdem"
+)
+
+// TestCardCode 測試牌與兩字符代碼之間的轉換
+func TestCardCode(t *testing.T) {
+	for card, want := range map[drandshuffle.Card]string{
+		{Suit: "黑桃", Value: "A"}:  "As",
+		{Suit: "紅心", Value: "10"}: "Th",
+		{Suit: "方塊", Value: "2"}:  "2d",
+		{Suit: "梅花", Value: "K"}:  "Kc",
+	} {
+		code, err := drandshuffle.CardCode(card)
+		assert.NoError(t, err)
+		assert.Equal(t, want, code)
+		parsed, err := drandshuffle.ParseCardCode(code)
+		assert.NoError(t, err)
+		assert.Equal(t, card, parsed)
+	}
+
+	_, err := drandshuffle.CardCode(drandshuffle.Card{Suit: "Joker", Value: "1"})
+	assert.ErrorIs(t, err, drandshuffle.ErrInvalidCard)
+	for _, code := range []string{"", "10s", "AS", "Ax"} {
+		_, err := drandshuffle.ParseCardCode(code)
+		assert.ErrorIs(t, err, drandshuffle.ErrInvalidCard, code)
+	}
+}
+
+// TestHoldemHandHistory 測試德州撲克的發牌和手牌歷史的導出
+func TestHoldemHandHistory(t *testing.T) {
+	src := drandshuffletest.NewFakeBeaconSource(1000)
+	client := drandshuffle.NewClientWithManager(drandshuffletest.NewManager(t, src))
+	result, err := client.NewShuffle().Session("table_7").Round(990).WithProof().Do(context.Background())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	game, err := holdem.NewGame(result, "table_7", 3)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, result.Deck[0:2], game.Hands[0])
+	assert.Equal(t, result.Deck[4:6], game.Hands[2])
+	assert.Equal(t, result.Deck[6:11], game.Board)
+	assert.Equal(t, result.Deck[9], game.Turn())
+	// 測試信標源沒有鏈信息，牌局時間由調用者補上
+	assert.True(t, game.RoundTime.IsZero())
+	game.RoundTime = time.Unix(1_700_000_000, 0)
+
+	var buf bytes.Buffer
+	err = game.WriteHandHistory(&buf, holdem.HandHistory{
+		SmallBlind: 5,
+		BigBlind:   10,
+		Players:    []holdem.Player{{Name: "alice", Stack: 1000}, {Name: "bob"}, {Name: "carol", Stack: 250}},
+		Hero:       "bob",
+		Actions: []holdem.Action{
+			{Street: holdem.Preflop, Player: "alice", Text: "calls $0.10"},
+			{Street: holdem.FlopStreet, Player: "bob", Text: "checks"},
+		},
+	})
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+
+	code := func(card drandshuffle.Card) string {
+		c, _ := drandshuffle.CardCode(card)
+		return c
+	}
+	board := strings.Join([]string{code(game.Board[0]), code(game.Board[1]), code(game.Board[2]), code(game.Board[3]), code(game.Board[4])}, " ")
+	bob := code(game.Hands[1][0]) + " " + code(game.Hands[1][1])
+
+	assert.Regexp(t, `^PokerStars Hand #\d+:  Hold'em No Limit \(\$0\.05/\$0\.10 USD\) - 2023/11/14 22:13:20 UTC`, lines[0])
+	assert.Equal(t, []string{
+		"Table 'drand 990' 6-max Seat #1 is the button",
+		"Seat 1: alice ($10 in chips)",
+		"Seat 2: bob ($10 in chips)",
+		"Seat 3: carol ($2.50 in chips)",
+		"bob: posts small blind $0.05",
+		"carol: posts big blind $0.10",
+		"*** HOLE CARDS ***",
+		"Dealt to bob [" + bob + "]",
+		"alice: calls $0.10",
+		"*** FLOP *** [" + board[:8] + "]",
+		"bob: checks",
+		"*** TURN *** [" + board[:8] + "] [" + board[9:11] + "]",
+		"*** RIVER *** [" + board[:11] + "] [" + board[12:] + "]",
+		"*** SHOW DOWN ***",
+	}, lines[1:15])
+	assert.Contains(t, lines, "Board ["+board+"]")
+	assert.Contains(t, lines, "Seat 2: bob (small blind) showed ["+bob+"]")
+	assert.Equal(t, "drand round 990, session table_7", lines[len(lines)-2])
+	assert.Equal(t, "drand proof sha256:"+result.Proof.Digest(), lines[len(lines)-1])
+
+	t.Run("Deterministic defaults", func(t *testing.T) {
+		var a, b bytes.Buffer
+		assert.NoError(t, game.WriteHandHistory(&a, holdem.HandHistory{}))
+		assert.NoError(t, game.WriteHandHistory(&b, holdem.HandHistory{}))
+		assert.Equal(t, a.String(), b.String())
+		assert.Contains(t, a.String(), "Seat 1: Player1 ($2 in chips)")
+		assert.Contains(t, a.String(), "Dealt to Player1 [")
+	})
+
+	t.Run("Heads-up button posts the small blind", func(t *testing.T) {
+		headsUp, err := holdem.NewGame(result, "table_7", 2)
+		assert.NoError(t, err)
+		var out bytes.Buffer
+		assert.NoError(t, headsUp.WriteHandHistory(&out, holdem.HandHistory{Button: 2, Time: game.RoundTime}))
+		assert.Contains(t, out.String(), "Player2: posts small blind $0.01\nPlayer1: posts big blind $0.02\n")
+		assert.Contains(t, out.String(), "Seat 2: Player2 (button) (small blind) showed [")
+	})
+
+	t.Run("Invalid input", func(t *testing.T) {
+		_, err := holdem.NewGame(result, "table_7", 11)
+		assert.ErrorIs(t, err, drandshuffle.ErrInvalidConfig)
+		assert.Equal(t, drandshuffle.CategoryInput, drandshuffle.Category(err))
+
+		_, _, err = holdem.Deal(result.Deck[:8], 2)
+		var short *drandshuffle.NotEnoughCardsError
+		assert.True(t, errors.As(err, &short))
+
+		for _, h := range []holdem.HandHistory{
+			{Players: []holdem.Player{{Name: "alice"}}},
+			{Players: []holdem.Player{{Name: "alice"}, {Name: "alice"}, {Name: "bob"}}},
+			{Players: []holdem.Player{{Name: "a:b"}, {Name: "bob"}, {Name: "carol"}}},
+			{Button: 4},
+			{Hero: "dave"},
+		} {
+			assert.ErrorIs(t, game.WriteHandHistory(&bytes.Buffer{}, h), drandshuffle.ErrInvalidConfig)
+		}
+
+		untimed := *game
+		untimed.RoundTime = time.Time{}
+		assert.ErrorIs(t, untimed.WriteHandHistory(&bytes.Buffer{}, holdem.HandHistory{}), drandshuffle.ErrInvalidConfig)
+	})
+}
@@ -0,0 +1,223 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/drandshuffletest"
+)
+
+// TestLocale 測試語言標籤的解析和環境變量的優先順序
+func TestLocale(t *testing.T) {
+	for tag, want := range map[string]drandshuffle.Locale{
+		"en":          drandshuffle.LocaleEn,
+		"en_US.UTF-8": drandshuffle.LocaleEn,
+		"EN-gb":       drandshuffle.LocaleEn,
+		"zh-TW":       drandshuffle.LocaleZhTW,
+		"zh_TW.UTF-8": drandshuffle.LocaleZhTW,
+		"zh-Hant":     drandshuffle.LocaleZhTW,
+		"zh-HK":       drandshuffle.LocaleZhTW,
+		"zh_CN.UTF-8": drandshuffle.LocaleZhCN,
+		"zh-Hans-CN":  drandshuffle.LocaleZhCN,
+		"zh-SG":       drandshuffle.LocaleZhCN,
+		"ja":          drandshuffle.LocaleJa,
+		"ja_JP.UTF-8": drandshuffle.LocaleJa,
+	} {
+		got, err := drandshuffle.ParseLocale(tag)
+		assert.NoError(t, err, tag)
+		assert.Equal(t, want, got, tag)
+	}
+
+	for _, tag := range []string{"", "C", "fr_FR.UTF-8", "english"} {
+		_, err := drandshuffle.ParseLocale(tag)
+		assert.ErrorIs(t, err, drandshuffle.ErrInvalidConfig, tag)
+	}
+
+	t.Run("Environment", func(t *testing.T) {
+		t.Setenv(drandshuffle.EnvLocale, "")
+		t.Setenv("LC_ALL", "")
+		t.Setenv("LC_MESSAGES", "")
+		t.Setenv("LANG", "C")
+		assert.Equal(t, drandshuffle.DefaultLocale, drandshuffle.LocaleFromEnv(), "Unsupported locales fall back to the default")
+
+		t.Setenv("LANG", "en_US.UTF-8")
+		assert.Equal(t, drandshuffle.LocaleEn, drandshuffle.LocaleFromEnv())
+
+		t.Setenv(drandshuffle.EnvLocale, "zh-TW")
+		assert.Equal(t, drandshuffle.LocaleZhTW, drandshuffle.LocaleFromEnv(), "DRANDSHUFFLE_LANG takes precedence over LANG")
+	})
+}
+
+// TestCardName 測試牌名的本地化，牌本身不受影響
+func TestCardName(t *testing.T) {
+	card := drandshuffle.Card{Suit: "黑桃", Value: "A"}
+	assert.Equal(t, "黑桃A", drandshuffle.CardName(card, drandshuffle.LocaleZhTW))
+	assert.Equal(t, "A of Spades", drandshuffle.CardName(card, drandshuffle.LocaleEn))
+	assert.Equal(t, "10 of Hearts", drandshuffle.CardName(drandshuffle.Card{Suit: "紅心", Value: "10"}, drandshuffle.LocaleEn))
+	assert.Equal(t, "黑桃A", drandshuffle.CardToString(card))
+
+	custom := drandshuffle.Card{Suit: "Joker", Value: "1"}
+	assert.Equal(t, "1 of Joker", drandshuffle.CardName(custom, drandshuffle.LocaleEn), "Unknown suits are shown as-is")
+	assert.Equal(t, "黑桃A", drandshuffle.CardName(card, "fr"), "Unknown locales fall back to the default")
+	assert.Equal(t, "红心10", drandshuffle.CardName(drandshuffle.Card{Suit: "紅心", Value: "10"}, drandshuffle.LocaleZhCN))
+	assert.Equal(t, "スペードのA", drandshuffle.CardName(card, drandshuffle.LocaleJa))
+}
+
+// TestErrorMessage 測試錯誤消息的本地化
+func TestErrorMessage(t *testing.T) {
+	_, err := drandshuffle.StringToCard("invalid")
+	assert.Equal(t, err.Error(), drandshuffle.ErrorMessage(err, drandshuffle.LocaleZhTW), "The default locale keeps the detailed message")
+	assert.Equal(t, "invalid card", drandshuffle.ErrorMessage(err, drandshuffle.LocaleEn))
+
+	wrapped := fmt.Errorf("外層: %w", drandshuffle.ValidateSessionID(""))
+	assert.Equal(t, "invalid session ID", drandshuffle.ErrorMessage(wrapped, drandshuffle.LocaleEn))
+
+	future := &drandshuffle.FutureRoundError{Round: 42, AvailableAt: time.Unix(1_700_000_000, 0).UTC()}
+	assert.Equal(t, "round 42 has not been published yet, available at 2023-11-14T22:13:20Z",
+		drandshuffle.ErrorMessage(future, drandshuffle.LocaleEn))
+
+	assert.Equal(t, "the request was cancelled", drandshuffle.ErrorMessage(context.Canceled, drandshuffle.LocaleEn))
+
+	assert.Equal(t, "无效的牌", drandshuffle.ErrorMessage(err, drandshuffle.LocaleZhCN))
+	assert.Equal(t, "ラウンド 42 はまだ公開されていません（公開予定 2023-11-14T22:13:20Z）",
+		drandshuffle.ErrorMessage(future, drandshuffle.LocaleJa))
+
+	unknown := fmt.Errorf("未分類的錯誤")
+	assert.Equal(t, unknown.Error(), drandshuffle.ErrorMessage(unknown, drandshuffle.LocaleEn))
+	assert.Empty(t, drandshuffle.ErrorMessage(nil, drandshuffle.LocaleEn))
+}
+
+// TestCatalog 測試消息目錄的回退順序
+func TestCatalog(t *testing.T) {
+	catalog := drandshuffle.Catalog{
+		drandshuffle.LocaleZhTW: {"greet": "你好，%s", "only_zh": "只有中文"},
+		drandshuffle.LocaleEn:   {"greet": "Hello, %s"},
+	}
+	assert.Equal(t, "Hello, Ann", catalog.Sprintf(drandshuffle.LocaleEn, "greet", "Ann"))
+	assert.Equal(t, "只有中文", catalog.Sprintf(drandshuffle.LocaleEn, "only_zh"))
+	assert.Equal(t, "missing", catalog.Sprintf(drandshuffle.LocaleEn, "missing"))
+}
+
+// TestLocaleConfig 測試通過選項和環境變量選擇語言
+func TestLocaleConfig(t *testing.T) {
+	src := drandshuffletest.NewFakeBeaconSource(1000)
+
+	client := drandshuffle.NewClientWithManager(drandshuffletest.NewManager(t, src))
+	assert.Equal(t, drandshuffle.LocaleZhTW, client.Manager().Locale())
+
+	client = drandshuffle.NewClientWithManager(drandshuffletest.NewManager(t, src, drandshuffle.WithLocale(drandshuffle.LocaleEn)))
+	assert.Equal(t, "K of Clubs", client.CardName(drandshuffle.Card{Suit: "梅花", Value: "K"}))
+	_, err := client.ShuffleAtRound(context.Background(), 1000, "")
+	assert.Equal(t, "invalid session ID", client.ErrorMessage(err))
+
+	_, err = drandshuffle.NewDrandManagerWithClient(src, drandshuffle.WithLocale("fr"))
+	assert.ErrorIs(t, err, drandshuffle.ErrInvalidConfig)
+
+	t.Setenv(drandshuffle.EnvLocale, "en_US.UTF-8")
+	cfg, err := drandshuffle.LoadConfig("")
+	assert.NoError(t, err)
+	assert.Equal(t, drandshuffle.LocaleEn, cfg.Locale)
+
+	t.Setenv(drandshuffle.EnvLocale, "fr")
+	_, err = drandshuffle.LoadConfig("")
+	assert.ErrorIs(t, err, drandshuffle.ErrInvalidConfig)
+}
+
+// TestBuiltinMessages 測試內置消息目錄的各語言都有英文的所有消息，且格式字符串的參數一致
+func TestBuiltinMessages(t *testing.T) {
+	catalog := drandshuffle.BuiltinMessages()
+	assert.Len(t, catalog, len(drandshuffle.Locales()))
+	for _, locale := range drandshuffle.Locales() {
+		if locale == drandshuffle.DefaultLocale {
+			continue // 繁體中文的錯誤消息使用 err.Error()
+		}
+		for key, en := range catalog[drandshuffle.LocaleEn] {
+			format, ok := catalog[locale][key]
+			if assert.True(t, ok, "%s is missing %s", locale, key) {
+				assert.Equal(t, strings.Count(en, "%"), strings.Count(format, "%"), "%s %s", locale, key)
+			}
+		}
+	}
+
+	// 返回的是副本
+	catalog[drandshuffle.LocaleEn]["error.invalid_card"] = "changed"
+	_, err := drandshuffle.StringToCard("invalid")
+	assert.Equal(t, "invalid card", drandshuffle.ErrorMessage(err, drandshuffle.LocaleEn))
+}
+
+// TestTranslator 測試自定義翻譯優先於內置的消息目錄，以及驗證結果的說明
+func TestTranslator(t *testing.T) {
+	card := drandshuffle.Card{Suit: "黑桃", Value: "A"}
+	assert.Equal(t, "驗證通過：牌組與輪次 990、遊戲局號 game_1 的洗牌結果一致", drandshuffle.VerifyMessage(nil, 990, "game_1", drandshuffle.LocaleZhTW))
+	mismatch := fmt.Errorf("包裝: %w", drandshuffle.ErrDeckMismatch)
+	assert.Equal(t, "Verification failed: the deck does not match the round and session ID",
+		drandshuffle.VerifyMessage(mismatch, 990, "game_1", drandshuffle.LocaleEn))
+	assert.Equal(t, "驗證失敗："+mismatch.Error(), drandshuffle.VerifyMessage(mismatch, 990, "game_1", drandshuffle.LocaleZhTW))
+
+	translator := drandshuffle.Catalog{
+		drandshuffle.LocaleZhTW: {"error.deck_mismatch": "牌組不符"},
+		drandshuffle.LocaleEn:   {"suit.黑桃": "spades", "verify.passed": "OK %[2]s@%[1]d"},
+	}
+	src := drandshuffletest.NewFakeBeaconSource(1000)
+	client := drandshuffle.NewClientWithManager(drandshuffletest.NewManager(t, src, drandshuffle.WithTranslator(translator)))
+	assert.Equal(t, "驗證失敗：牌組不符", client.VerifyMessage(mismatch, 990, "game_1"), "The translator also applies to the default locale")
+	assert.Equal(t, "黑桃A", client.CardName(card))
+
+	client = drandshuffle.NewClientWithManager(drandshuffletest.NewManager(t, src,
+		drandshuffle.WithTranslator(translator), drandshuffle.WithLocale(drandshuffle.LocaleEn)))
+	assert.Equal(t, "A of spades", client.CardName(card))
+	assert.Equal(t, "OK game_1@990", client.VerifyMessage(nil, 990, "game_1"))
+	assert.Equal(t, "invalid session ID", client.ErrorMessage(drandshuffle.ValidateSessionID("")), "Messages missing from the translator use the built-in catalog")
+
+	_, err := drandshuffle.NewDrandManagerWithClient(src, drandshuffle.WithLocale(drandshuffle.LocaleJa))
+	assert.NoError(t, err)
+}
+
+// TestVerifyHandlerLocale 測試驗證接口按 Accept-Language 返回本地化的說明
+func TestVerifyHandlerLocale(t *testing.T) {
+	assert.Equal(t, drandshuffle.LocaleJa, drandshuffle.LocaleFromAcceptLanguage("fr-FR, ja;q=0.8, en;q=0.5", drandshuffle.LocaleEn))
+	assert.Equal(t, drandshuffle.LocaleZhCN, drandshuffle.LocaleFromAcceptLanguage("zh-CN,zh;q=0.9", drandshuffle.LocaleEn))
+	assert.Equal(t, drandshuffle.LocaleEn, drandshuffle.LocaleFromAcceptLanguage("*", drandshuffle.LocaleEn))
+	assert.Equal(t, drandshuffle.LocaleZhTW, drandshuffle.LocaleFromAcceptLanguage("", drandshuffle.LocaleZhTW))
+
+	src := drandshuffletest.NewFakeBeaconSource(1000)
+	client := drandshuffle.NewClientWithManager(drandshuffletest.NewManager(t, src))
+	deck, err := client.ShuffleAtRound(context.Background(), 990, "game_1")
+	if !assert.NoError(t, err) {
+		return
+	}
+	handler := drandshuffle.NewVerifyHandler(client)
+	verify := func(deck []drandshuffle.Card, acceptLanguage string) drandshuffle.VerifyResponse {
+		data, _ := json.Marshal(drandshuffle.VerifyRequest{Deck: drandshuffle.EncodeDeck(deck), Round: 990, SessionID: "game_1"})
+		req := httptest.NewRequest(http.MethodPost, "/verify", bytes.NewReader(data))
+		req.Header.Set("Accept-Language", acceptLanguage)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		var resp drandshuffle.VerifyResponse
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		return resp
+	}
+
+	resp := verify(deck, "ja-JP,ja;q=0.9")
+	assert.True(t, resp.Valid)
+	assert.Equal(t, "検証成功：デッキはラウンド 990、セッション ID game_1 のシャッフル結果と一致しています", resp.Message)
+
+	swapped := slices.Clone(deck)
+	swapped[0], swapped[1] = swapped[1], swapped[0]
+	resp = verify(swapped, "zh-CN")
+	assert.False(t, resp.Valid)
+	assert.Equal(t, "验证失败：牌组与该轮次和游戏局号的洗牌结果不一致", resp.Message)
+	assert.NotEmpty(t, resp.Reason, "Reason keeps the detailed message")
+}
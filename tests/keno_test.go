@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/drandshuffletest"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/games/keno"
+)
+
+// TestKenoDraw 測試基諾抽號的重現和驗證
+func TestKenoDraw(t *testing.T) {
+	src := drandshuffletest.NewFakeBeaconSource(1000)
+	client := drandshuffle.NewClientWithManager(drandshuffletest.NewManager(t, src))
+	ctx := context.Background()
+
+	draw, err := keno.NewDraw(ctx, client, 990, "draw_20240101_001", keno.DefaultPool, keno.DefaultCount)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, uint64(990), draw.Round)
+	assert.Len(t, draw.Numbers, keno.DefaultCount)
+	seen := map[int]bool{}
+	for _, n := range draw.Numbers {
+		assert.True(t, n >= 1 && n <= keno.DefaultPool, "number out of range: %d", n)
+		assert.False(t, seen[n], "duplicate number: %d", n)
+		seen[n] = true
+	}
+	assert.NoError(t, keno.Verify(draw))
+
+	// 相同的輪次和開獎編號總是抽出相同的號碼，不同的開獎編號結果不同
+	again, err := keno.NewDraw(ctx, client, 990, "draw_20240101_001", keno.DefaultPool, keno.DefaultCount)
+	if assert.NoError(t, err) {
+		assert.Equal(t, draw.Numbers, again.Numbers)
+	}
+	other, err := keno.NewDraw(ctx, client, 990, "draw_20240101_002", keno.DefaultPool, keno.DefaultCount)
+	if assert.NoError(t, err) {
+		assert.NotEqual(t, draw.Numbers, other.Numbers)
+	}
+	// 抽出較少的號碼時是較多號碼的前綴
+	assert.Equal(t, draw.Numbers[:5], keno.Numbers(draw.Proof.Seed, keno.DefaultPool, 5))
+
+	assert.Equal(t, 2, draw.Hits([]int{draw.Numbers[0], draw.Numbers[3], draw.Numbers[3], 0}))
+
+	tampered := *draw
+	tampered.Numbers = slices.Clone(draw.Numbers)
+	tampered.Numbers[0], tampered.Numbers[1] = tampered.Numbers[1], tampered.Numbers[0]
+	err = keno.Verify(&tampered)
+	assert.ErrorIs(t, err, keno.ErrDrawMismatch)
+	assert.Equal(t, drandshuffle.CategoryVerification, drandshuffle.Category(err))
+	tampered = *draw
+	tampered.DrawID = "draw_20240101_002"
+	assert.ErrorIs(t, keno.Verify(&tampered), keno.ErrDrawMismatch)
+
+	for _, size := range [][2]int{{0, 1}, {80, 0}, {10, 11}} {
+		_, err := keno.NewDraw(ctx, client, 990, "draw_x", size[0], size[1])
+		assert.ErrorIs(t, err, drandshuffle.ErrInvalidConfig, size)
+	}
+	assert.ErrorIs(t, keno.Verify(nil), drandshuffle.ErrInvalidConfig)
+}
@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/drandshuffletest"
+)
+
+// TestMirrorHandler 測試隔離網段內的服務通過鏡像取得並驗證隨機信標
+func TestMirrorHandler(t *testing.T) {
+	signer := newTestSigner(t)
+	relay, _ := newTestRelay(t, signer)
+	hash := signer.info.HashString()
+
+	// 能夠連接公網的上游服務
+	upstream, err := drandshuffle.NewDrandManager(
+		drandshuffle.WithChainInfo(signer.info),
+		drandshuffle.WithRelayURLs(relay.URL),
+	)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer upstream.Close()
+	mirror := httptest.NewServer(drandshuffle.NewMirrorHandler(upstream))
+	defer mirror.Close()
+
+	// 隔離網段內的服務只能連接鏡像，鏈信息也從鏡像取得並以鏈哈希核對
+	downstream, err := drandshuffle.NewDrandManager(
+		drandshuffle.WithChainHash(hash),
+		drandshuffle.WithRelayURLs(mirror.URL),
+	)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer downstream.Close()
+	assert.True(t, signer.info.Equal(downstream.ChainInfo()))
+	randomness, err := downstream.GetRandomnessByRound(5)
+	assert.NoError(t, err)
+	assert.Equal(t, signer.beacon(5).Randomness, randomness)
+
+	get := func(path string) (*http.Response, []byte) {
+		resp, err := http.Get(mirror.URL + path)
+		if !assert.NoError(t, err) {
+			return &http.Response{}, nil
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp, body
+	}
+
+	resp, body := get("/public/7")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Cache-Control"), "immutable")
+	beacons, err := drandshuffle.ParseBeaconJSON(body)
+	if assert.NoError(t, err) {
+		assert.Equal(t, signer.beacon(7), beacons[0])
+	}
+
+	resp, body = get("/" + hash + "/public/latest")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	_, err = drandshuffle.ParseBeaconJSON(body)
+	assert.NoError(t, err)
+
+	resp, body = get("/chains")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.JSONEq(t, `["`+hash+`"]`, string(body))
+
+	resp, body = get("/info")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	info, err := drandshuffle.ReadChainInfo(bytes.NewReader(body))
+	if assert.NoError(t, err) {
+		assert.Equal(t, hash, info.HashString())
+	}
+
+	for path, status := range map[string]int{
+		"/" + strings.Repeat("ab", 32) + "/info": http.StatusNotFound,
+		"/public/0":                              http.StatusNotFound,
+		"/public/abc":                            http.StatusBadRequest,
+		"/public/99999999":                       http.StatusTooEarly,
+		"/other":                                 http.StatusNotFound,
+	} {
+		resp, _ := get(path)
+		assert.Equal(t, status, resp.StatusCode, path)
+	}
+	resp, _ = get("/public/99999999")
+	assert.NotEmpty(t, resp.Header.Get("Retry-After"))
+
+	post, err := http.Post(mirror.URL+"/public/latest", "application/json", nil)
+	if assert.NoError(t, err) {
+		post.Body.Close()
+		assert.Equal(t, http.StatusMethodNotAllowed, post.StatusCode)
+	}
+}
+
+// TestMirrorWithoutBeacon 測試尚未取得信標和鏈信息時鏡像返回 503
+func TestMirrorWithoutBeacon(t *testing.T) {
+	clock := drandshuffletest.NewClock(time.Unix(1_700_000_000, 0))
+	src := drandshuffletest.NewFakeBeaconSource(0)
+	dm, err := drandshuffle.NewDrandManagerWithClient(src, drandshuffle.WithClock(clock), drandshuffle.WithWarmStart())
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer dm.Close()
+
+	handler := drandshuffle.NewMirrorHandler(dm)
+	for _, path := range []string{"/public/latest", "/info"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code, path)
+	}
+}
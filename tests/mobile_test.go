@@ -0,0 +1,166 @@
+package tests
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/mobile"
+)
+
+// TestMobileVerify 測試移動端在本地驗證證明包
+func TestMobileVerify(t *testing.T) {
+	signer := newTestSigner(t)
+	beacon := signer.beacon(5)
+	result := &drandshuffle.ShuffleResult{
+		Deck:  drandshuffle.NewShuffler(drandshuffle.Poker52).Shuffle(beacon.Randomness, "game_1"),
+		Round: beacon.Round,
+		Proof: &drandshuffle.ShuffleProof{
+			Algorithm:  drandshuffle.ProofAlgorithm,
+			ChainHash:  signer.info.HashString(),
+			Round:      beacon.Round,
+			Randomness: beacon.Randomness,
+			Signature:  beacon.Signature,
+			SessionID:  "game_1",
+			DeckSize:   52,
+		},
+	}
+	bundle, err := result.MarshalCBOR()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.NoError(t, mobile.VerifyProofBundle(bundle))
+	parsed, err := mobile.ParseBundle(bundle)
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(5), parsed.Round)
+		assert.Equal(t, "game_1", parsed.SessionID)
+		assert.Len(t, strings.Split(parsed.Deck, mobile.DeckSeparator), 52)
+		recomputed, err := mobile.RecomputeDeck(beacon.Randomness, "game_1")
+		assert.NoError(t, err)
+		assert.Equal(t, parsed.Deck, recomputed)
+	}
+
+	var info bytes.Buffer
+	assert.NoError(t, signer.info.ToJSON(&info, nil))
+	verifier, err := mobile.NewVerifier(info.Bytes())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, verifier.VerifyProofBundle(bundle))
+
+	t.Run("Tampered bundle", func(t *testing.T) {
+		swapped := *result
+		swapped.Deck = append([]drandshuffle.Card(nil), result.Deck...)
+		swapped.Deck[0], swapped.Deck[1] = swapped.Deck[1], swapped.Deck[0]
+		data, _ := swapped.MarshalCBOR()
+		assert.ErrorIs(t, mobile.VerifyProofBundle(data), drandshuffle.ErrDeckMismatch)
+
+		// 服務器換用另一個隨機性並重新洗牌，只有驗證簽名才能發現
+		forged := *result
+		proof := *result.Proof
+		proof.Randomness = signer.beacon(6).Randomness
+		forged.Proof = &proof
+		forged.Deck = drandshuffle.NewShuffler(drandshuffle.Poker52).Shuffle(proof.Randomness, "game_1")
+		data, _ = forged.MarshalCBOR()
+		assert.NoError(t, mobile.VerifyProofBundle(data))
+		assert.Error(t, verifier.VerifyProofBundle(data))
+
+		proof = *result.Proof
+		proof.ChainHash = strings.Repeat("ab", 32)
+		forged = *result
+		forged.Proof = &proof
+		data, _ = forged.MarshalCBOR()
+		assert.Equal(t, drandshuffle.CategoryVerification, drandshuffle.Category(verifier.VerifyProofBundle(data)))
+
+		noProof := *result
+		noProof.Proof = nil
+		data, _ = noProof.MarshalCBOR()
+		assert.Equal(t, drandshuffle.CategoryInput, drandshuffle.Category(mobile.VerifyProofBundle(data)))
+		assert.ErrorIs(t, mobile.VerifyProofBundle([]byte{0xa0}), drandshuffle.ErrInvalidCBOR)
+	})
+
+	t.Run("Card codes", func(t *testing.T) {
+		code, err := mobile.CardCode("紅心", "10")
+		assert.NoError(t, err)
+		assert.Equal(t, "Th", code)
+		name, err := mobile.CardName("As", "en-US")
+		assert.NoError(t, err)
+		assert.Equal(t, "A of Spades", name)
+		_, err = mobile.CardName("Zz", "en")
+		assert.ErrorIs(t, err, drandshuffle.ErrInvalidCard)
+		_, err = mobile.RecomputeDeck(beacon.Randomness, "")
+		assert.ErrorIs(t, err, drandshuffle.ErrInvalidSessionID)
+	})
+}
+
+// TestMobileBindableAPI 測試 mobile 包的導出 API 只使用 gomobile 支持的類型
+func TestMobileBindableAPI(t *testing.T) {
+	allowed := map[string]bool{
+		"string": true, "[]byte": true, "bool": true, "int": true, "int32": true, "int64": true,
+		"float64": true, "error": true, "*Bundle": true, "*Verifier": true,
+	}
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, "../drandshuffle/mobile", nil, 0)
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				switch decl := decl.(type) {
+				case *ast.FuncDecl:
+					if !decl.Name.IsExported() {
+						continue
+					}
+					for _, list := range []*ast.FieldList{decl.Type.Params, decl.Type.Results} {
+						if list == nil {
+							continue
+						}
+						for _, field := range list.List {
+							typ := exprString(field.Type)
+							assert.True(t, allowed[typ], "%s uses %s, which gomobile cannot bind", decl.Name.Name, typ)
+						}
+					}
+				case *ast.GenDecl:
+					for _, spec := range decl.Specs {
+						ts, ok := spec.(*ast.TypeSpec)
+						if !ok || !ts.Name.IsExported() {
+							continue
+						}
+						st, ok := ts.Type.(*ast.StructType)
+						if !ok {
+							continue
+						}
+						for _, field := range st.Fields.List {
+							if len(field.Names) > 0 && field.Names[0].IsExported() {
+								assert.True(t, allowed[exprString(field.Type)], "%s.%s cannot be bound", ts.Name.Name, field.Names[0].Name)
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// exprString 返回類型表達式的源碼寫法
+func exprString(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.StarExpr:
+		return "*" + exprString(e.X)
+	case *ast.ArrayType:
+		return "[]" + exprString(e.Elt)
+	case *ast.SelectorExpr:
+		return exprString(e.X) + "." + e.Sel.Name
+	}
+	return "?"
+}
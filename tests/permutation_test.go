@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+)
+
+// TestDRBG 測試 DRBG 的輸出與規格一致且可重現
+func TestDRBG(t *testing.T) {
+	seed := []byte("test_seed_for_drbg")
+
+	t.Run("Matches counter-mode specification", func(t *testing.T) {
+		out := make([]byte, 64)
+		drandshuffle.NewDRBG(seed).Read(out)
+
+		for i := uint64(0); i < 2; i++ {
+			block := sha256.Sum256(binary.BigEndian.AppendUint64(append([]byte(nil), seed...), i))
+			assert.Equal(t, block[:], out[i*32:(i+1)*32], "Block %d should be SHA256(seed || counter)", i)
+		}
+	})
+
+	t.Run("Uint64n stays in range", func(t *testing.T) {
+		drbg := drandshuffle.NewDRBG(seed)
+		for i := 0; i < 1000; i++ {
+			assert.Less(t, drbg.Uint64n(7), uint64(7))
+		}
+		assert.Equal(t, 0, drbg.Intn(0))
+		assert.Equal(t, uint64(0), drbg.Uint64n(0))
+	})
+
+	t.Run("Seed is copied", func(t *testing.T) {
+		mutable := append([]byte(nil), seed...)
+		drbg := drandshuffle.NewDRBG(mutable)
+		mutable[0] ^= 0xff
+		assert.Equal(t, drandshuffle.NewDRBG(seed).Uint64(), drbg.Uint64())
+	})
+}
+
+// TestPermutation 測試流式排列生成器
+func TestPermutation(t *testing.T) {
+	seed := []byte("test_seed_for_permutation")
+
+	t.Run("Produces a valid permutation", func(t *testing.T) {
+		const n = 1000
+		perm := drandshuffle.NewPermutation(n, seed)
+		seen := make(map[int]bool, n)
+		for {
+			v, ok := perm.Next()
+			if !ok {
+				break
+			}
+			assert.False(t, seen[v], "Index %d produced twice", v)
+			assert.True(t, v >= 0 && v < n)
+			seen[v] = true
+		}
+		assert.Len(t, seen, n)
+		assert.Equal(t, 0, perm.Remaining())
+	})
+
+	t.Run("Deterministic and consistent with ShuffleSlice", func(t *testing.T) {
+		const n = 500
+		expected := drandshuffle.NewPermutation(n, seed).Take(n)
+		assert.Equal(t, expected, drandshuffle.NewPermutation(n, seed).Take(n))
+
+		items := make([]int, n)
+		for i := range items {
+			items[i] = i
+		}
+		drandshuffle.ShuffleSlice(items, seed)
+		assert.Equal(t, expected, items)
+	})
+
+	t.Run("Partial draw is a prefix of the full permutation", func(t *testing.T) {
+		full := drandshuffle.NewPermutation(100, seed).Take(100)
+		assert.Equal(t, full[:10], drandshuffle.NewPermutation(100, seed).Take(10))
+	})
+
+	t.Run("Edge cases", func(t *testing.T) {
+		assert.Empty(t, drandshuffle.NewPermutation(0, seed).Take(5))
+		assert.Empty(t, drandshuffle.NewPermutation(-1, seed).Take(5))
+		assert.Equal(t, []int{0}, drandshuffle.NewPermutation(1, seed).Take(5))
+	})
+}
+
+// BenchmarkPermutationTake1M 測試從一百萬個項目中抽取前 100 個
+func BenchmarkPermutationTake1M(b *testing.B) {
+	seed := []byte("benchmark_seed")
+	for i := 0; i < b.N; i++ {
+		drandshuffle.NewPermutation(1_000_000, seed).Take(100)
+	}
+}
+
+// BenchmarkShuffleSlice1M 測試原地打亂一百萬個項目
+func BenchmarkShuffleSlice1M(b *testing.B) {
+	seed := []byte("benchmark_seed")
+	items := make([]int32, 1_000_000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		drandshuffle.ShuffleSlice(items, seed)
+	}
+}
@@ -0,0 +1,103 @@
+package tests
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/drandshuffletest"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/games/keno"
+)
+
+// recordingT 記錄性質檢查報告的失敗而不使測試失敗，用於確認檢查能發現問題
+type recordingT struct {
+	testing.TB
+	errors []string
+}
+
+// Helper 不做任何事
+func (r *recordingT) Helper() {}
+
+// Errorf 記錄失敗
+func (r *recordingT) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+// shuffleDeal 以標準牌組洗牌
+func shuffleDeal(randomness []byte, sessionID string) ([]drandshuffle.Card, error) {
+	return drandshuffle.NewShuffler(drandshuffle.Poker52).Shuffle(randomness, sessionID), nil
+}
+
+// kenoDeal 以 RandProof 相同的方式派生種子後抽出基諾號碼
+func kenoDeal(randomness []byte, sessionID string) ([]int, error) {
+	h := sha256.New()
+	h.Write([]byte(drandshuffle.RandAlgorithm))
+	h.Write(randomness)
+	h.Write([]byte(sessionID))
+	return keno.Numbers(h.Sum(nil), keno.DefaultPool, keno.DefaultCount), nil
+}
+
+// TestPropertyHelpers 測試性質檢查對庫的洗牌和遊戲模塊通過
+func TestPropertyHelpers(t *testing.T) {
+	assert.True(t, drandshuffletest.CheckDeterministic(t, shuffleDeal))
+	assert.True(t, drandshuffletest.CheckPermutation(t, drandshuffle.Poker52.NewDeck(), shuffleDeal))
+
+	assert.True(t, drandshuffletest.CheckDeterministic(t, kenoDeal))
+	assert.True(t, drandshuffletest.CheckSessionIndependence(t, kenoDeal))
+
+	pool := make([]int, keno.DefaultPool)
+	for i := range pool {
+		pool[i] = i + 1
+	}
+	fullDraw := func(randomness []byte, sessionID string) ([]int, error) {
+		return keno.Numbers(randomness, keno.DefaultPool, keno.DefaultPool), nil
+	}
+	assert.True(t, drandshuffletest.CheckPermutation(t, pool, fullDraw, drandshuffletest.WithTrials(50)))
+
+	randomness, sessionID := drandshuffletest.PropertyInput(3)
+	again, _ := drandshuffletest.PropertyInput(3)
+	assert.Equal(t, randomness, again)
+	assert.Equal(t, "prop_3", sessionID)
+}
+
+// TestPropertyHelpersDetectViolations 測試性質檢查能發現不確定、不是排列和忽略遊戲局號的發牌邏輯
+func TestPropertyHelpersDetectViolations(t *testing.T) {
+	calls := 0
+	unstable := func(randomness []byte, sessionID string) ([]int, error) {
+		calls++
+		return []int{calls % 2, 1}, nil
+	}
+	r := &recordingT{TB: t}
+	assert.False(t, drandshuffletest.CheckDeterministic(r, unstable))
+	assert.Len(t, r.errors, 1)
+
+	mutating := func(randomness []byte, sessionID string) ([]int, error) {
+		randomness[0] ^= 1
+		return []int{1}, nil
+	}
+	r = &recordingT{TB: t}
+	assert.False(t, drandshuffletest.CheckDeterministic(r, mutating))
+	assert.Len(t, r.errors, 1)
+
+	duplicate := func(randomness []byte, sessionID string) ([]int, error) {
+		return []int{1, 1, 3}, nil
+	}
+	r = &recordingT{TB: t}
+	assert.False(t, drandshuffletest.CheckPermutation(r, []int{1, 2, 3}, duplicate))
+	assert.Len(t, r.errors, 1)
+
+	ignoresSession := func(randomness []byte, sessionID string) ([]int, error) {
+		return kenoDeal(randomness, "")
+	}
+	r = &recordingT{TB: t}
+	assert.False(t, drandshuffletest.CheckSessionIndependence(r, ignoresSession))
+	assert.Len(t, r.errors, 1)
+
+	// drandshuffle/v1 的洗牌在同一隨機性下各局相關，見 CheckSessionIndependence 的說明
+	r = &recordingT{TB: t}
+	assert.False(t, drandshuffletest.CheckSessionIndependence(r, shuffleDeal))
+	assert.Len(t, r.errors, 1)
+}
@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/drandshufflepb"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/drandshuffletest"
+)
+
+// TestProtobuf 測試 Protobuf 消息與原生類型的往返轉換
+func TestProtobuf(t *testing.T) {
+	src := drandshuffletest.NewFakeBeaconSource(1000)
+	client := drandshuffle.NewClientWithManager(drandshuffletest.NewManager(t, src))
+
+	result, err := client.NewShuffle().Session("game_1").Round(999).
+		WithContributions([]byte("seed-a"), []byte("seed-b")).WithProof().Do(context.Background())
+	if !assert.NoError(t, err) {
+		return
+	}
+	result.Proof.RoundTime = time.Unix(1_700_000_000, 0).UTC()
+
+	t.Run("Proof", func(t *testing.T) {
+		data, err := proto.Marshal(drandshufflepb.FromProof(result.Proof))
+		assert.NoError(t, err)
+
+		var msg drandshufflepb.ShuffleProof
+		assert.NoError(t, proto.Unmarshal(data, &msg))
+		proof, err := msg.ToProof()
+		assert.NoError(t, err)
+		assert.Equal(t, result.Proof, proof)
+		assert.NoError(t, drandshuffle.VerifyProof(proof, drandshuffle.Poker52, result.Deck))
+
+		_, err = (*drandshufflepb.ShuffleProof)(nil).ToProof()
+		assert.Error(t, err)
+		assert.Nil(t, drandshufflepb.FromProof(nil))
+
+		msg.RoundTime = &timestamppb.Timestamp{Seconds: -1 << 62}
+		_, err = msg.ToProof()
+		assert.Error(t, err, "Out-of-range timestamps should be rejected")
+	})
+
+	t.Run("Chained proofs", func(t *testing.T) {
+		var proofs []*drandshuffle.ShuffleProof
+		previous := ""
+		for i := 0; i < 3; i++ {
+			chained, err := client.NewShuffle().Session("game_1").Round(uint64(990 + i)).ChainAfter(previous).Do(context.Background())
+			if !assert.NoError(t, err) {
+				return
+			}
+			// 經過 Protobuf 往返後摘要不變，仍能串連
+			data, err := proto.Marshal(drandshufflepb.FromProof(chained.Proof))
+			assert.NoError(t, err)
+			var msg drandshufflepb.ShuffleProof
+			assert.NoError(t, proto.Unmarshal(data, &msg))
+			proof, err := msg.ToProof()
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, previous, proof.PreviousDigest)
+			assert.Equal(t, chained.Proof.Digest(), proof.Digest())
+			proofs = append(proofs, proof)
+			previous = proof.Digest()
+		}
+		assert.NoError(t, drandshuffle.VerifyProofChain(proofs))
+	})
+
+	t.Run("Deck", func(t *testing.T) {
+		data, err := proto.Marshal(drandshufflepb.FromDeck(result.Deck))
+		assert.NoError(t, err)
+
+		var msg drandshufflepb.Deck
+		assert.NoError(t, proto.Unmarshal(data, &msg))
+		assert.Equal(t, result.Deck, msg.ToCards())
+		assert.Empty(t, (*drandshufflepb.Deck)(nil).ToCards())
+	})
+
+	t.Run("Beacon", func(t *testing.T) {
+		beacon := src.Beacon(1000)
+		data, err := proto.Marshal(drandshufflepb.FromBeacon(beacon))
+		assert.NoError(t, err)
+
+		var msg drandshufflepb.Beacon
+		assert.NoError(t, proto.Unmarshal(data, &msg))
+		assert.Equal(t, beacon, msg.ToBeacon())
+	})
+}
@@ -0,0 +1,166 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/drandshufflepb"
+)
+
+// hsmProvider 模擬以 HSM 中的 Ed25519 密鑰簽名輪次號碼的隨機性來源
+type hsmProvider struct {
+	key    ed25519.PrivateKey
+	latest uint64
+	closed atomic.Bool
+}
+
+// newHSMProvider 創建最新輪次為 latest 的模擬來源
+func newHSMProvider(latest uint64) *hsmProvider {
+	seed := sha256.Sum256([]byte("drandshuffle test hsm"))
+	return &hsmProvider{key: ed25519.NewKeyFromSeed(seed[:]), latest: latest}
+}
+
+// Name 返回記錄在證明中的來源標識
+func (p *hsmProvider) Name() string { return "hsm/test" }
+
+// Beacon 以簽名的 SHA-256 作為輪次的隨機性
+func (p *hsmProvider) Beacon(ctx context.Context, round uint64) (drandshuffle.Beacon, error) {
+	if round == 0 {
+		round = p.latest
+	}
+	if round > p.latest {
+		return drandshuffle.Beacon{}, fmt.Errorf("%w: 輪次 %d", drandshuffle.ErrRoundNotFound, round)
+	}
+	sig := ed25519.Sign(p.key, binary.BigEndian.AppendUint64(nil, round))
+	randomness := sha256.Sum256(sig)
+	return drandshuffle.Beacon{Round: round, Randomness: randomness[:], Signature: sig}, nil
+}
+
+// Close 記錄來源已被關閉
+func (p *hsmProvider) Close() error {
+	p.closed.Store(true)
+	return nil
+}
+
+// hsmVerifier 以公鑰驗證 hsmProvider 的隨機信標
+type hsmVerifier ed25519.PublicKey
+
+// Verify 檢查簽名和由簽名派生的隨機性
+func (v hsmVerifier) Verify(beacon drandshuffle.Beacon) error {
+	if !ed25519.Verify(ed25519.PublicKey(v), binary.BigEndian.AppendUint64(nil, beacon.Round), beacon.Signature) {
+		return errors.New("invalid hsm signature")
+	}
+	if randomness := sha256.Sum256(beacon.Signature); !bytes.Equal(randomness[:], beacon.Randomness) {
+		return errors.New("randomness does not match signature")
+	}
+	return nil
+}
+
+// TestBeaconProvider 測試以 drand 之外的隨機性來源洗牌並在證明中記錄來源
+func TestBeaconProvider(t *testing.T) {
+	provider := newHSMProvider(100)
+	dm, err := drandshuffle.NewDrandManagerWithProvider(provider)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "hsm/test", dm.Provider())
+	assert.Empty(t, dm.Config().ChainHash)
+
+	client := drandshuffle.NewClientWithManager(dm)
+	result, err := client.NewShuffle().Session("game_1").Round(42).WithProof().Do(context.Background())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "hsm/test", result.Proof.Provider)
+	assert.Empty(t, result.Proof.ChainHash)
+
+	verifiers := map[string]drandshuffle.BeaconVerifier{
+		"hsm/test": hsmVerifier(provider.key.Public().(ed25519.PublicKey)),
+	}
+	assert.NoError(t, drandshuffle.VerifyProofWith(result.Proof, verifiers, nil, result.Deck))
+
+	// 來源標識隨證明一起編碼
+	encoded, err := result.Proof.MarshalCBOR()
+	assert.NoError(t, err)
+	var decoded drandshuffle.ShuffleProof
+	assert.NoError(t, decoded.UnmarshalCBOR(encoded))
+	assert.Equal(t, "hsm/test", decoded.Provider)
+
+	// Protobuf 往返後來源標識不變，仍由對應的驗證器驗證
+	data, err := proto.Marshal(drandshufflepb.FromProof(result.Proof))
+	assert.NoError(t, err)
+	var msg drandshufflepb.ShuffleProof
+	assert.NoError(t, proto.Unmarshal(data, &msg))
+	assert.Equal(t, "hsm/test", msg.GetProvider())
+	fromPB, err := msg.ToProof()
+	if assert.NoError(t, err) {
+		assert.Equal(t, result.Proof, fromPB)
+		assert.NoError(t, drandshuffle.VerifyProofWith(fromPB, verifiers, nil, result.Deck))
+	}
+
+	_, proof, err := client.NewRandForSession(context.Background(), 42, "slot_1")
+	assert.NoError(t, err)
+	assert.Equal(t, "hsm/test", proof.Provider)
+
+	t.Run("Verification failures", func(t *testing.T) {
+		forged := *result.Proof
+		forged.Randomness = bytes.Repeat([]byte{1}, 32)
+		assert.Error(t, drandshuffle.VerifyProofWith(&forged, verifiers, nil, result.Deck))
+
+		relabeled := *result.Proof
+		relabeled.Provider = ""
+		err := drandshuffle.VerifyProofWith(&relabeled, verifiers, nil, result.Deck)
+		assert.Equal(t, drandshuffle.CategoryInput, drandshuffle.Category(err), "A drand proof needs a drand verifier")
+
+		swapped := append([]drandshuffle.Card(nil), result.Deck...)
+		swapped[0], swapped[1] = swapped[1], swapped[0]
+		assert.ErrorIs(t, drandshuffle.VerifyProofWith(result.Proof, verifiers, nil, swapped), drandshuffle.ErrDeckMismatch)
+	})
+
+	t.Run("Drand proofs", func(t *testing.T) {
+		signer := newTestSigner(t)
+		beacon := signer.beacon(9)
+		proof := &drandshuffle.ShuffleProof{
+			Algorithm:  drandshuffle.ProofAlgorithm,
+			Round:      9,
+			Randomness: beacon.Randomness,
+			Signature:  beacon.Signature,
+			SessionID:  "game_1",
+			DeckSize:   52,
+		}
+		deck := drandshuffle.NewShuffler(drandshuffle.Poker52).Shuffle(beacon.Randomness, "game_1")
+		verifier, err := drandshuffle.NewVerifier(signer.info)
+		assert.NoError(t, err)
+		assert.NoError(t, drandshuffle.VerifyProofWith(proof, map[string]drandshuffle.BeaconVerifier{drandshuffle.ProviderDrand: verifier}, nil, deck))
+	})
+
+	t.Run("Invalid provider", func(t *testing.T) {
+		_, err := drandshuffle.NewDrandManagerWithProvider(nil)
+		assert.ErrorIs(t, err, drandshuffle.ErrInvalidConfig)
+		_, err = drandshuffle.NewDrandManagerWithProvider(namedProvider{newHSMProvider(1), drandshuffle.ProviderDrand})
+		assert.ErrorIs(t, err, drandshuffle.ErrInvalidConfig)
+	})
+
+	dm.Close()
+	assert.True(t, provider.closed.Load(), "Closing the manager should close the provider")
+}
+
+// namedProvider 以指定名稱包裝 hsmProvider
+type namedProvider struct {
+	*hsmProvider
+	name string
+}
+
+// Name 返回指定的名稱
+func (p namedProvider) Name() string { return p.name }
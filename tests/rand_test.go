@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/drandshuffletest"
+)
+
+// TestNewRandForSession 測試為舊遊戲引擎派生可驗證的 *rand.Rand
+func TestNewRandForSession(t *testing.T) {
+	ctx := context.Background()
+	src := drandshuffletest.NewFakeBeaconSource(1000)
+	client := drandshuffle.NewClientWithManager(drandshuffletest.NewManager(t, src))
+
+	r, proof, err := client.NewRandForSession(ctx, 990, "slot_1")
+	if !assert.NoError(t, err) {
+		return
+	}
+	seed := sha256.Sum256(append(append([]byte("drandshuffle/rand/v1"), src.Randomness(990)...), "slot_1"...))
+	assert.Equal(t, seed[:], proof.Seed)
+	assert.Equal(t, drandshuffle.RandAlgorithm, proof.Algorithm)
+	assert.Equal(t, uint64(990), proof.Round)
+
+	// 底層是以證明中的種子初始化的 DRBG
+	drbg := drandshuffle.NewDRBG(proof.Seed)
+	r.Seed(42) // 重新播種不影響輸出
+	assert.Equal(t, drbg.Uint64(), r.Uint64())
+	rolls := make([]int, 10)
+	for i := range rolls {
+		rolls[i] = r.Intn(6)
+	}
+
+	// 審計方從 JSON 證明重放相同的序列
+	data, err := json.Marshal(proof)
+	assert.NoError(t, err)
+	var decoded drandshuffle.RandProof
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.NoError(t, drandshuffle.VerifyRandProof(&decoded))
+	replay := decoded.NewRand()
+	replay.Uint64()
+	for _, want := range rolls {
+		assert.Equal(t, want, replay.Intn(6))
+	}
+
+	again, _, err := client.NewRandForSession(ctx, 990, "slot_1")
+	assert.NoError(t, err)
+	other, _, err := client.NewRandForSession(ctx, 990, "slot_2")
+	assert.NoError(t, err)
+	first := again.Uint64()
+	assert.Equal(t, drandshuffle.NewDRBG(proof.Seed).Uint64(), first)
+	assert.NotEqual(t, first, other.Uint64(), "Different sessions should get different sequences")
+
+	t.Run("Tampered proof", func(t *testing.T) {
+		tampered := decoded
+		tampered.SessionID = "slot_2"
+		assert.ErrorIs(t, drandshuffle.VerifyRandProof(&tampered), drandshuffle.ErrSeedMismatch)
+		assert.Equal(t, drandshuffle.CategoryVerification, drandshuffle.Category(drandshuffle.VerifyRandProof(&tampered)))
+
+		tampered = decoded
+		tampered.Algorithm = "drandshuffle/v1"
+		assert.Equal(t, drandshuffle.CategoryInput, drandshuffle.Category(drandshuffle.VerifyRandProof(&tampered)))
+		assert.Error(t, drandshuffle.VerifyRandProof(nil))
+	})
+
+	t.Run("Invalid input", func(t *testing.T) {
+		_, _, err := client.NewRandForSession(ctx, 990, "")
+		assert.ErrorIs(t, err, drandshuffle.ErrInvalidSessionID)
+		_, _, err = client.NewRandForSession(ctx, 2000, "slot_1")
+		assert.ErrorIs(t, err, drandshuffle.ErrRoundNotFound)
+	})
+}
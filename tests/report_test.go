@@ -0,0 +1,152 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/drandshuffletest"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/report"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/sqlstore"
+)
+
+// TestReport 測試監管報告按時間範圍統計輪次、驗證結果和均勻性，以及簽名的驗證
+func TestReport(t *testing.T) {
+	src := drandshuffletest.NewFakeBeaconSource(1000)
+	client := drandshuffle.NewClientWithManager(drandshuffletest.NewManager(t, src))
+	store, _ := newSQLStore(t)
+	ctx := context.Background()
+
+	from := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	save := func(id string, round uint64, at time.Time, tenant string, edit func(*sqlstore.Session)) {
+		t.Helper()
+		result, err := client.NewShuffle().Session(id).Round(round).WithProof().Do(ctx)
+		if !assert.NoError(t, err) {
+			return
+		}
+		session := sqlstore.Session{SessionID: id, Round: round, Deck: result.Deck, Proof: result.Proof, CreatedAt: at, Tenant: tenant}
+		if edit != nil {
+			edit(&session)
+		}
+		assert.NoError(t, store.SaveSession(ctx, session))
+	}
+
+	// 範圍內：輪次 990 兩局、991 一局、992 兩局，其中一局牌組被改動、一局沒有證明
+	save("game_1", 990, from, "a", nil)
+	save("game_2", 990, from.Add(time.Hour), "a", nil)
+	save("game_3", 991, from.Add(2*time.Hour), "b", nil)
+	save("game_4", 992, from.Add(3*time.Hour), "a", func(s *sqlstore.Session) {
+		s.Deck[0], s.Deck[1] = s.Deck[1], s.Deck[0]
+	})
+	save("game_5", 992, to.Add(-time.Second), "a", func(s *sqlstore.Session) { s.Proof = nil })
+	// 其他遊戲的牌組不核對
+	save("game_6", 993, from.Add(4*time.Hour), "a", func(s *sqlstore.Session) {
+		s.Proof.DeckSize = 54
+	})
+	// 範圍外
+	save("game_7", 980, from.Add(-time.Second), "a", nil)
+	save("game_8", 995, to, "a", nil)
+
+	generatedAt := time.Date(2026, 10, 2, 9, 0, 0, 0, time.UTC)
+	r, err := report.Generate(ctx, store, from, to, report.WithNow(func() time.Time { return generatedAt }))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, report.Format, r.Format)
+	assert.Equal(t, generatedAt, r.GeneratedAt)
+	assert.Equal(t, 6, r.Sessions)
+	assert.Equal(t, report.RoundStats{Used: 4, First: 990, Last: 993, MaxSessionsPerRound: 2}, r.Rounds)
+	assert.Equal(t, 3, r.Verification.Verified)
+	assert.Equal(t, 2, r.Verification.Failed)
+	assert.Equal(t, 1, r.Verification.Skipped)
+	assert.InDelta(t, 0.6, r.Verification.PassRate, 1e-9)
+	if assert.Len(t, r.Verification.Failures, 2) {
+		assert.Equal(t, "game_4", r.Verification.Failures[0].SessionID)
+		assert.Equal(t, "game_5", r.Verification.Failures[1].SessionID)
+	}
+	if assert.NotNil(t, r.Uniformity) {
+		assert.Equal(t, 3, r.Uniformity.Shuffles, "Only verified decks should be tallied")
+	}
+
+	tenant, err := report.Generate(ctx, store, from, to, report.WithTenant("b"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, tenant.Sessions)
+
+	empty, err := report.Generate(ctx, store, to.AddDate(1, 0, 0), to.AddDate(1, 1, 0))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, empty.Sessions)
+	assert.Nil(t, empty.Uniformity)
+
+	_, err = report.Generate(ctx, store, to, from)
+	assert.ErrorIs(t, err, drandshuffle.ErrInvalidConfig)
+
+	t.Run("Handler", func(t *testing.T) {
+		handler := report.NewHandler(store, report.WithNow(func() time.Time { return generatedAt }))
+		get := func(query string) *httptest.ResponseRecorder {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/report?"+query, nil))
+			return rec
+		}
+
+		rec := get("from=2026-09-01&to=2026-10-01")
+		if assert.Equal(t, http.StatusOK, rec.Code) {
+			var got report.Report
+			assert.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+			assert.Equal(t, r.Sessions, got.Sessions)
+			assert.Equal(t, r.Rounds, got.Rounds)
+		}
+		rec = get("from=2026-09-01T00:00:00Z&to=2026-10-01&tenant=b")
+		if assert.Equal(t, http.StatusOK, rec.Code) {
+			var got report.Report
+			assert.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+			assert.Equal(t, 1, got.Sessions)
+			assert.Equal(t, "b", got.Tenant)
+		}
+
+		for _, query := range []string{"", "from=2026-09-01", "from=yesterday&to=2026-10-01", "from=2026-10-01&to=2026-09-01"} {
+			assert.Equal(t, http.StatusBadRequest, get(query).Code, query)
+		}
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/report", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+
+	t.Run("Signature", func(t *testing.T) {
+		pub, priv, err := ed25519.GenerateKey(nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		signed, err := report.Sign(r, priv)
+		if !assert.NoError(t, err) {
+			return
+		}
+		verified, err := report.Verify(signed, pub)
+		assert.NoError(t, err)
+		assert.Equal(t, r, verified)
+
+		// 只改變空白不影響驗證
+		var compact bytes.Buffer
+		assert.NoError(t, json.Compact(&compact, signed))
+		_, err = report.Verify(compact.Bytes(), pub)
+		assert.NoError(t, err)
+
+		tampered := bytes.Replace(signed, []byte(`"game_4"`), []byte(`"game_x"`), 1)
+		assert.NotEqual(t, signed, tampered)
+		_, err = report.Verify(tampered, pub)
+		assert.ErrorIs(t, err, report.ErrBadSignature)
+		assert.Equal(t, drandshuffle.CategoryVerification, drandshuffle.Category(err))
+
+		other, _, _ := ed25519.GenerateKey(nil)
+		_, err = report.Verify(signed, other)
+		assert.ErrorIs(t, err, report.ErrBadSignature)
+	})
+}
@@ -0,0 +1,297 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/audit"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/sqlstore"
+)
+
+// storedBeacons 返回輪次從 from 到 to 的隨機信標
+func storedBeacons(from, to uint64) []drandshuffle.Beacon {
+	var beacons []drandshuffle.Beacon
+	for round := from; round <= to; round++ {
+		beacons = append(beacons, drandshuffle.Beacon{Round: round, Randomness: newFakeResult(round).randomness, Signature: []byte{1}})
+	}
+	return beacons
+}
+
+// beaconRounds 返回隨機信標的輪次
+func beaconRounds(beacons []drandshuffle.Beacon) []uint64 {
+	var rounds []uint64
+	for _, beacon := range beacons {
+		rounds = append(rounds, beacon.Round)
+	}
+	return rounds
+}
+
+// TestRetentionPolicy 測試保留策略按輪次、年齡和大小計算保留的範圍
+func TestRetentionPolicy(t *testing.T) {
+	genesis := time.Unix(1_700_000_000, 0)
+	roundTime := func(round uint64) (time.Time, bool) {
+		return genesis.Add(time.Duration(round-1) * 3 * time.Second), round > 0
+	}
+	now := genesis.Add(999 * 3 * time.Second) // 輪次 1000 的發布時間
+
+	t.Run("Zero policy keeps everything", func(t *testing.T) {
+		var policy drandshuffle.RetentionPolicy
+		assert.Equal(t, uint64(0), policy.KeepFrom(1000, now))
+		assert.Equal(t, 3, policy.KeepLast([]int64{10, 10, 10}))
+		assert.False(t, policy.Expired(genesis, now))
+	})
+
+	t.Run("Rounds", func(t *testing.T) {
+		assert.Equal(t, uint64(500), drandshuffle.RetentionPolicy{MinRound: 500}.KeepFrom(1000, now))
+		assert.Equal(t, uint64(991), drandshuffle.RetentionPolicy{MaxRounds: 10}.KeepFrom(1000, now))
+		assert.Equal(t, uint64(0), drandshuffle.RetentionPolicy{MaxRounds: 10}.KeepFrom(5, now))
+		assert.Equal(t, uint64(995), drandshuffle.RetentionPolicy{MinRound: 995, MaxRounds: 10}.KeepFrom(1000, now))
+	})
+
+	t.Run("Age", func(t *testing.T) {
+		policy := drandshuffle.RetentionPolicy{MaxAge: 30 * time.Second, RoundTime: roundTime}
+		assert.Equal(t, uint64(990), policy.KeepFrom(1000, now))
+		// 沒有 RoundTime 時隨機信標不按年齡刪除
+		assert.Equal(t, uint64(0), drandshuffle.RetentionPolicy{MaxAge: 30 * time.Second}.KeepFrom(1000, now))
+		assert.True(t, policy.Expired(now.Add(-31*time.Second), now))
+		assert.False(t, policy.Expired(now.Add(-30*time.Second), now))
+	})
+
+	t.Run("Size", func(t *testing.T) {
+		sizes := []int64{10, 20, 30, 40}
+		assert.Equal(t, 2, drandshuffle.RetentionPolicy{MaxEntries: 2}.KeepLast(sizes))
+		assert.Equal(t, 2, drandshuffle.RetentionPolicy{MaxBytes: 70}.KeepLast(sizes))
+		assert.Equal(t, 1, drandshuffle.RetentionPolicy{MaxBytes: 69}.KeepLast(sizes))
+		assert.Equal(t, 0, drandshuffle.RetentionPolicy{MaxBytes: 39}.KeepLast(sizes))
+		assert.Equal(t, 1, drandshuffle.RetentionPolicy{MaxEntries: 1, MaxBytes: 100}.KeepLast(sizes))
+	})
+}
+
+// TestFileBeaconStoreCompact 測試壓縮以重命名替換信標文件，並去除重複的輪次
+func TestFileBeaconStoreCompact(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "beacons.jsonl")
+	store := drandshuffle.NewFileBeaconStore(path)
+	ctx := context.Background()
+
+	removed, err := store.Compact(ctx, drandshuffle.RetentionPolicy{MaxRounds: 5}, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, removed)
+
+	assert.NoError(t, store.SaveBatch(storedBeacons(1, 10)))
+	assert.NoError(t, store.Save(storedBeacons(9, 9)[0]))
+
+	removed, err = store.Compact(ctx, drandshuffle.RetentionPolicy{MaxRounds: 5}, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 6, removed)
+	beacons, err := store.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{6, 7, 8, 9, 10}, beaconRounds(beacons))
+	assert.Equal(t, storedBeacons(6, 10), beacons)
+
+	// 沒有需要刪除的記錄時不重寫文件
+	removed, err = store.Compact(ctx, drandshuffle.RetentionPolicy{MaxRounds: 5}, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, removed)
+
+	// 按大小保留最新的記錄
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	removed, err = store.Compact(ctx, drandshuffle.RetentionPolicy{MaxBytes: info.Size() / 2}, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, removed)
+	beacons, err = store.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{9, 10}, beaconRounds(beacons))
+
+	// 壓縮後可以繼續追加，目錄中沒有殘留的臨時文件
+	assert.NoError(t, store.Save(storedBeacons(11, 11)[0]))
+	beacons, err = store.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{9, 10, 11}, beaconRounds(beacons))
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = store.Compact(cancelled, drandshuffle.RetentionPolicy{MaxRounds: 1}, time.Now())
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+// TestReplaceFile 測試原子地替換文件內容，不留下臨時文件
+func TestReplaceFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "records.jsonl")
+
+	assert.NoError(t, drandshuffle.ReplaceFile(path, []byte("first\n")))
+	assert.NoError(t, drandshuffle.ReplaceFile(path, []byte("second\n")))
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "second\n", string(data))
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1, "No temporary file should be left behind")
+
+	// 目錄不存在時返回錯誤
+	assert.Error(t, drandshuffle.ReplaceFile(filepath.Join(dir, "missing", "records.jsonl"), nil))
+}
+
+// TestWriteBehindStoreCompact 測試壓縮前先寫入暫存的信標
+func TestWriteBehindStoreCompact(t *testing.T) {
+	file := drandshuffle.NewFileBeaconStore(filepath.Join(t.TempDir(), "beacons.jsonl"))
+	store := drandshuffle.NewWriteBehindStore(file, time.Hour, 100)
+	defer store.Close()
+	for _, beacon := range storedBeacons(1, 10) {
+		assert.NoError(t, store.Save(beacon))
+	}
+
+	removed, err := store.Compact(context.Background(), drandshuffle.RetentionPolicy{MaxEntries: 4}, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 6, removed)
+	beacons, err := file.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{7, 8, 9, 10}, beaconRounds(beacons))
+
+	// 底層存儲不支持壓縮時返回錯誤
+	plain := drandshuffle.NewWriteBehindStore(&countingStore{}, time.Hour, 100)
+	defer plain.Close()
+	_, err = plain.Compact(context.Background(), drandshuffle.RetentionPolicy{MaxEntries: 4}, time.Now())
+	assert.Error(t, err)
+}
+
+// TestSQLStoreCompact 測試數據庫存儲以 DELETE 刪除舊的隨機信標和牌局
+func TestSQLStoreCompact(t *testing.T) {
+	store, db := newSQLStore(t)
+	ctx := context.Background()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	removed, err := store.Compact(ctx, drandshuffle.RetentionPolicy{MaxEntries: 3, MaxAge: time.Hour}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, removed)
+
+	assert.NoError(t, store.SaveBatch(storedBeacons(1, 10)))
+	for i, round := range []uint64{2, 4, 6, 8} {
+		assert.NoError(t, store.SaveSession(ctx, sqlstore.Session{
+			SessionID: "game_" + string(rune('a'+i)),
+			Round:     round,
+			CreatedAt: now.Add(-time.Duration(4-i) * time.Hour),
+		}))
+	}
+
+	// 輪次 5 之前的信標和牌局被刪除
+	removed, err = store.Compact(ctx, drandshuffle.RetentionPolicy{MinRound: 5}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 4+2, removed)
+	beacons, err := store.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{5, 6, 7, 8, 9, 10}, beaconRounds(beacons))
+	_, err = store.LoadSession(ctx, "game_b")
+	assert.ErrorIs(t, err, sqlstore.ErrSessionNotFound)
+
+	// 只保留最新的三個信標和最近九十分鐘內保存的牌局
+	removed, err = store.Compact(ctx, drandshuffle.RetentionPolicy{MaxEntries: 3, MaxAge: 90 * time.Minute}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 3+1, removed)
+	beacons, err = store.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{8, 9, 10}, beaconRounds(beacons))
+	var sessions int
+	assert.NoError(t, db.QueryRow("SELECT COUNT(*) FROM drandshuffle_sessions").Scan(&sessions))
+	assert.Equal(t, 1, sessions)
+	_, err = store.LoadSession(ctx, "game_d")
+	assert.NoError(t, err)
+
+	// 按數量保留最近保存的牌局
+	assert.NoError(t, store.SaveSession(ctx, sqlstore.Session{SessionID: "game_e", Round: 10, CreatedAt: now}))
+	removed, err = store.Compact(ctx, drandshuffle.RetentionPolicy{MaxEntries: 1}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 2+1, removed)
+	_, err = store.LoadSession(ctx, "game_d")
+	assert.ErrorIs(t, err, sqlstore.ErrSessionNotFound)
+	_, err = store.LoadSession(ctx, "game_e")
+	assert.NoError(t, err)
+}
+
+// TestAuditFileStoreCompact 測試審計記錄文件的壓縮，壓縮後可以繼續追加
+func TestAuditFileStoreCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	store, err := audit.OpenFileStore(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer store.Close()
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var records []audit.Record
+	for i := 0; i < 6; i++ {
+		record := audit.Record{Round: uint64(990 + i), SessionID: "game", DeckDigest: "digest", DealtAt: now.Add(-time.Duration(6-i) * time.Hour)}
+		assert.NoError(t, store.Append(record))
+		records = append(records, record)
+	}
+
+	removed, err := store.Compact(context.Background(), drandshuffle.RetentionPolicy{MaxAge: 3 * time.Hour, MaxEntries: 2}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, removed)
+
+	extra := audit.Record{Round: 996, SessionID: "game", DeckDigest: "digest", DealtAt: now}
+	assert.NoError(t, store.Append(extra))
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	local, err := audit.ReadJSONLines(bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, append(records[4:], extra), local)
+
+	removed, err = store.Compact(context.Background(), drandshuffle.RetentionPolicy{MinRound: 996}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, removed)
+}
+
+// TestWithRetention 測試 DrandManager 在後台按保留策略壓縮隨機信標存儲
+func TestWithRetention(t *testing.T) {
+	store := drandshuffle.NewFileBeaconStore(filepath.Join(t.TempDir(), "beacons.jsonl"))
+	assert.NoError(t, store.SaveBatch(storedBeacons(990, 999)))
+
+	dm, err := drandshuffle.NewDrandManagerWithClient(newFakeClient(1000),
+		drandshuffle.WithBeaconStore(store),
+		drandshuffle.WithRetention(drandshuffle.RetentionPolicy{MaxEntries: 3}, time.Hour),
+	)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Eventually(t, func() bool {
+		beacons, err := store.Load()
+		return err == nil && len(beacons) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+	dm.Close()
+
+	beacons, err := store.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{998, 999, 1000}, beaconRounds(beacons))
+}
+
+// TestStartCompaction 測試不經過 DrandManager 的後台壓縮，stop 可以重複調用
+func TestStartCompaction(t *testing.T) {
+	store := drandshuffle.NewFileBeaconStore(filepath.Join(t.TempDir(), "beacons.jsonl"))
+	assert.NoError(t, store.SaveBatch(storedBeacons(1, 10)))
+
+	handler := &recordingHandler{}
+	stop := drandshuffle.StartCompaction(store, drandshuffle.RetentionPolicy{MaxRounds: 2}, time.Hour, slog.New(handler))
+	assert.Eventually(t, func() bool {
+		return handler.count(slog.LevelInfo, "已壓縮存儲") == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	stop()
+	stop()
+
+	beacons, err := store.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{9, 10}, beaconRounds(beacons))
+}
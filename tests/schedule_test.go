@@ -0,0 +1,232 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/audit"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/drandshuffletest"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/schedule"
+)
+
+// newScheduler 創建使用假時鐘的 Scheduler，時鐘位於輪次 11 發布的時刻，輪次 12 在 3 秒後發布
+func newScheduler(t *testing.T, policy schedule.Policy) (*schedule.Scheduler, *drandshuffle.DrandManager, *drandshuffletest.FakeBeaconSource, *drandshuffletest.Clock) {
+	dm, src, clock, _ := newClockedManager(t)
+	return schedule.New(dm, policy, schedule.WithClock(clock)), dm, src, clock
+}
+
+// waitScheduled 在後台等待牌局的輪次，推進時鐘後返回結果
+func waitScheduled(t *testing.T, s *schedule.Scheduler, clock *drandshuffletest.Clock, sessionID string, advance time.Duration) (schedule.Session, []byte, error) {
+	t.Helper()
+	type result struct {
+		session    schedule.Session
+		randomness []byte
+		err        error
+	}
+	done := make(chan result, 1)
+	go func() {
+		session, randomness, err := s.Wait(context.Background(), sessionID)
+		done <- result{session, randomness, err}
+	}()
+	waitForWaiter(t, clock)
+	clock.Advance(advance)
+	select {
+	case r := <-done:
+		return r.session, r.randomness, r.err
+	case <-time.After(5 * time.Second):
+		t.Fatal("Wait did not return after the round was published")
+		return schedule.Session{}, nil, nil
+	}
+}
+
+// TestScheduler 測試預定牌局在輪次發布前開局時按原輪次發牌
+func TestScheduler(t *testing.T) {
+	s, dm, src, clock := newScheduler(t, schedule.Policy{})
+
+	session, err := s.Schedule("game_1", 13)
+	assert.NoError(t, err)
+	assert.Equal(t, schedule.Scheduled, session.State)
+	assert.Equal(t, clock.Now(), session.ScheduledAt)
+
+	_, _, err = s.Wait(context.Background(), "game_1")
+	assert.ErrorIs(t, err, schedule.ErrInvalidState)
+
+	session, err = s.Start("game_1")
+	assert.NoError(t, err)
+	assert.Equal(t, schedule.Started, session.State)
+	assert.Equal(t, uint64(13), session.Round)
+
+	session, randomness, err := waitScheduled(t, s, clock, "game_1", 7*time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, schedule.Dealt, session.State)
+	assert.Equal(t, src.Randomness(13), randomness)
+
+	client := drandshuffle.NewClientWithManager(dm)
+	result, err := client.NewShuffle().Session(session.ID).Round(session.Round).WithProof().Do(context.Background())
+	assert.NoError(t, err)
+	record := session.Record(result, clock.Now())
+	assert.Equal(t, audit.NewRecord(result, "game_1", clock.Now()), record)
+	assert.Empty(t, record.Note)
+
+	t.Run("Invalid requests", func(t *testing.T) {
+		_, err := s.Schedule("game_2", 5)
+		assert.ErrorIs(t, err, drandshuffle.ErrInvalidConfig)
+		_, err = s.Schedule("game_1", 20)
+		assert.ErrorIs(t, err, schedule.ErrSessionExists)
+		_, err = s.Schedule("game 3", 20)
+		assert.ErrorIs(t, err, drandshuffle.ErrInvalidSessionID)
+		_, err = s.Start("missing")
+		assert.ErrorIs(t, err, schedule.ErrSessionNotFound)
+		_, err = s.Release("game_1")
+		assert.ErrorIs(t, err, schedule.ErrInvalidState)
+		_, err = s.Cancel("game_1", "too late")
+		assert.ErrorIs(t, err, schedule.ErrInvalidState)
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		s.Remove("game_1")
+		_, err := s.Get("game_1")
+		assert.ErrorIs(t, err, schedule.ErrSessionNotFound)
+	})
+}
+
+// TestSchedulerExpire 測試默認策略作廢錯過輪次的牌局
+func TestSchedulerExpire(t *testing.T) {
+	s, _, _, clock := newScheduler(t, schedule.Policy{})
+	_, err := s.Schedule("game_1", 12)
+	assert.NoError(t, err)
+
+	clock.Advance(3 * time.Second)
+	session, err := s.Start("game_1")
+	assert.ErrorIs(t, err, schedule.ErrSessionExpired)
+	assert.Equal(t, schedule.Expired, session.State)
+	assert.Contains(t, session.Reason, "12")
+
+	_, err = s.Start("game_1")
+	assert.ErrorIs(t, err, schedule.ErrSessionExpired)
+	_, _, err = s.Wait(context.Background(), "game_1")
+	assert.ErrorIs(t, err, schedule.ErrInvalidState)
+}
+
+// TestSchedulerRebind 測試改綁到下一個尚未發布的輪次，並在審計記錄中留下說明
+func TestSchedulerRebind(t *testing.T) {
+	s, dm, src, clock := newScheduler(t, schedule.Policy{Action: schedule.Rebind})
+	_, err := s.Schedule("game_1", 12)
+	assert.NoError(t, err)
+
+	clock.Advance(4 * time.Second)
+	rebindAt := clock.Now()
+	session, err := s.Start("game_1")
+	assert.NoError(t, err)
+	assert.Equal(t, schedule.Started, session.State)
+	assert.Equal(t, uint64(13), session.Round)
+	assert.Equal(t, []schedule.Rebinding{{From: 12, To: 13, At: rebindAt}}, session.Rebinds)
+
+	session, randomness, err := waitScheduled(t, s, clock, "game_1", 3*time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, src.Randomness(13), randomness)
+
+	result, err := drandshuffle.NewClientWithManager(dm).NewShuffle().Session("game_1").Round(session.Round).Do(context.Background())
+	assert.NoError(t, err)
+	record := session.Record(result, clock.Now().UTC())
+	assert.Equal(t, uint64(13), record.Round)
+	assert.Equal(t, session.Note(), record.Note)
+	assert.Contains(t, record.Note, "輪次 12")
+	assert.Contains(t, record.Note, "輪次 13")
+
+	// 說明隨審計記錄一起導出
+	data, err := json.Marshal(record)
+	assert.NoError(t, err)
+	records, err := audit.ReadJSONLines(bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, []audit.Record{record}, records)
+	var buf bytes.Buffer
+	assert.NoError(t, audit.WriteParquet(&buf, records))
+	records, err = audit.ReadParquet(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+	assert.Equal(t, record.Note, records[0].Note)
+
+	t.Run("Lead", func(t *testing.T) {
+		s, _, _, clock := newScheduler(t, schedule.Policy{Action: schedule.Rebind, Lead: 10 * time.Second})
+		_, err := s.Schedule("game_1", 12)
+		assert.NoError(t, err)
+		clock.Advance(4 * time.Second)
+		session, err := s.Start("game_1")
+		assert.NoError(t, err)
+		// 當前時間之後 10 秒（+44 秒）之後發布的第一個輪次是 16（+45 秒）
+		assert.Equal(t, uint64(16), session.Round)
+	})
+
+	t.Run("MaxRebinds", func(t *testing.T) {
+		s, _, _, clock := newScheduler(t, schedule.Policy{Action: schedule.Rebind, MaxRebinds: 1})
+		_, err := s.Schedule("game_1", 12)
+		assert.NoError(t, err)
+		_, err = s.Schedule("game_2", 20)
+		assert.NoError(t, err)
+
+		clock.Advance(4 * time.Second)
+		changed, err := s.Sweep()
+		assert.NoError(t, err)
+		if assert.Len(t, changed, 1) {
+			assert.Equal(t, "game_1", changed[0].ID)
+			assert.Equal(t, schedule.Scheduled, changed[0].State)
+			assert.Equal(t, uint64(13), changed[0].Round)
+		}
+
+		clock.Advance(3 * time.Second)
+		changed, err = s.Sweep()
+		assert.NoError(t, err)
+		if assert.Len(t, changed, 1) {
+			assert.Equal(t, schedule.Expired, changed[0].State)
+			assert.Len(t, changed[0].Rebinds, 1)
+		}
+		changed, err = s.Sweep()
+		assert.NoError(t, err)
+		assert.Empty(t, changed)
+	})
+}
+
+// TestSchedulerHold 測試暫停錯過輪次的牌局，等待人工改綁或作廢
+func TestSchedulerHold(t *testing.T) {
+	s, _, _, clock := newScheduler(t, schedule.Policy{Action: schedule.Hold})
+	for _, id := range []string{"game_1", "game_2"} {
+		_, err := s.Schedule(id, 12)
+		assert.NoError(t, err)
+	}
+
+	clock.Advance(4 * time.Second)
+	changed, err := s.Sweep()
+	assert.NoError(t, err)
+	if assert.Len(t, changed, 2) {
+		assert.Equal(t, "game_1", changed[0].ID)
+		assert.Equal(t, schedule.Held, changed[0].State)
+	}
+	_, err = s.Start("game_1")
+	assert.ErrorIs(t, err, schedule.ErrSessionHeld)
+
+	session, err := s.Release("game_1")
+	assert.NoError(t, err)
+	assert.Equal(t, schedule.Scheduled, session.State)
+	assert.Equal(t, uint64(13), session.Round)
+	assert.Empty(t, session.Reason)
+	if assert.Len(t, session.Rebinds, 1) {
+		assert.True(t, session.Rebinds[0].Manual)
+	}
+	assert.Contains(t, session.Note(), "人工")
+	session, err = s.Start("game_1")
+	assert.NoError(t, err)
+	assert.Equal(t, schedule.Started, session.State)
+
+	session, err = s.Cancel("game_2", "operator cancelled")
+	assert.NoError(t, err)
+	assert.Equal(t, schedule.Expired, session.State)
+	_, err = s.Start("game_2")
+	assert.ErrorIs(t, err, schedule.ErrSessionExpired)
+	assert.ErrorContains(t, err, "operator cancelled")
+}
@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/drandshuffletest"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/games/scratch"
+)
+
+// scratchTable 是測試用的獎項表
+var scratchTable = scratch.Table{
+	Prizes: []scratch.Prize{{Name: "頭獎", Count: 1}, {Name: "二獎", Count: 10}, {Name: "安慰獎", Count: 200}},
+	Total:  1000,
+}
+
+// TestScratchTicket 測試按概率抽獎的重現和驗證
+func TestScratchTicket(t *testing.T) {
+	src := drandshuffletest.NewFakeBeaconSource(1000)
+	client := drandshuffle.NewClientWithManager(drandshuffletest.NewManager(t, src))
+	ctx := context.Background()
+
+	counts := map[string]int{}
+	for i := 0; i < 200; i++ {
+		ticket, err := scratch.NewTicket(ctx, client, 990, fmt.Sprintf("ticket_%04d", i), scratchTable)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, scratchTable.Digest(), ticket.TableDigest)
+		assert.Equal(t, scratch.Outcome(ticket.Proof.Seed, scratchTable), ticket.Prize)
+		assert.NoError(t, scratch.Verify(ticket, scratchTable))
+		counts[ticket.Prize]++
+	}
+	// 約 79% 的票不中獎
+	assert.Greater(t, counts[scratch.NoPrize], 120)
+	assert.Greater(t, counts["安慰獎"], 10)
+
+	ticket, err := scratch.NewTicket(ctx, client, 990, "ticket_0001", scratchTable)
+	if !assert.NoError(t, err) {
+		return
+	}
+	again, err := scratch.NewTicket(ctx, client, 990, "ticket_0001", scratchTable)
+	if assert.NoError(t, err) {
+		assert.Equal(t, ticket.Prize, again.Prize)
+	}
+
+	tampered := *ticket
+	tampered.Prize = "頭獎"
+	if ticket.Prize == "頭獎" {
+		tampered.Prize = scratch.NoPrize
+	}
+	err = scratch.Verify(&tampered, scratchTable)
+	assert.ErrorIs(t, err, scratch.ErrOutcomeMismatch)
+	assert.Equal(t, drandshuffle.CategoryVerification, drandshuffle.Category(err))
+
+	// 事後改動獎項表會被摘要發現
+	changed := scratch.Table{Prizes: []scratch.Prize{{Name: "頭獎", Count: 999}}, Total: 1000}
+	assert.ErrorIs(t, scratch.Verify(ticket, changed), scratch.ErrOutcomeMismatch)
+
+	for _, table := range []scratch.Table{
+		{Total: 0},
+		{Prizes: []scratch.Prize{{Name: "", Count: 1}}, Total: 10},
+		{Prizes: []scratch.Prize{{Name: "a", Count: 1}, {Name: "a", Count: 1}}, Total: 10},
+		{Prizes: []scratch.Prize{{Name: "a", Count: -1}}, Total: 10},
+		{Prizes: []scratch.Prize{{Name: "a", Count: 6}, {Name: "b", Count: 5}}, Total: 10},
+	} {
+		_, err := scratch.NewTicket(ctx, client, 990, "ticket_x", table)
+		assert.ErrorIs(t, err, drandshuffle.ErrInvalidConfig, table)
+	}
+}
+
+// TestScratchPool 測試有限獎池不放回地分配獎項
+func TestScratchPool(t *testing.T) {
+	src := drandshuffletest.NewFakeBeaconSource(1000)
+	client := drandshuffle.NewClientWithManager(drandshuffletest.NewManager(t, src))
+	ctx := context.Background()
+
+	pool, err := scratch.NewPool(ctx, client, 990, "batch_2024_01", scratchTable)
+	if !assert.NoError(t, err) {
+		return
+	}
+	prizes := pool.Prizes()
+	assert.Len(t, prizes, scratchTable.Total)
+	counts := map[string]int{}
+	for _, p := range prizes {
+		counts[p]++
+	}
+	assert.Equal(t, map[string]int{"頭獎": 1, "二獎": 10, "安慰獎": 200, scratch.NoPrize: 789}, counts)
+
+	for _, serial := range []int{0, 1, 500, 999} {
+		prize, err := pool.Prize(serial)
+		assert.NoError(t, err)
+		assert.Equal(t, prizes[serial], prize, serial)
+	}
+	_, err = pool.Prize(1000)
+	assert.ErrorIs(t, err, drandshuffle.ErrInvalidConfig)
+
+	assert.NoError(t, scratch.VerifyPool(pool, scratchTable))
+	other, err := scratch.NewPool(ctx, client, 990, "batch_2024_02", scratchTable)
+	if assert.NoError(t, err) {
+		assert.NotEqual(t, prizes, other.Prizes())
+	}
+
+	tampered := *pool
+	tampered.Table = scratch.Table{Prizes: []scratch.Prize{{Name: "頭獎", Count: 1}}, Total: 1000}
+	assert.ErrorIs(t, scratch.VerifyPool(&tampered, scratchTable), scratch.ErrOutcomeMismatch)
+	tampered = *pool
+	tampered.PoolID = "batch_2024_02"
+	assert.ErrorIs(t, scratch.VerifyPool(&tampered, scratchTable), scratch.ErrOutcomeMismatch)
+	assert.ErrorIs(t, scratch.VerifyPool(nil, scratchTable), drandshuffle.ErrInvalidConfig)
+}
@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/drandshuffletest"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/simulate"
+)
+
+// TestSimulateRun 測試重放歷史輪次的頻率統計和報告
+func TestSimulateRun(t *testing.T) {
+	src := drandshuffletest.NewFakeBeaconSource(1000)
+	client := drandshuffle.NewClientWithManager(drandshuffletest.NewManager(t, src))
+	ctx := context.Background()
+
+	report, err := simulate.Run(ctx, client, 1, 1000)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 1000, report.Rounds)
+	assert.Equal(t, 1000, report.Shuffles)
+	assert.Len(t, report.Cards, 52)
+	assert.Equal(t, drandshuffle.CardToString(drandshuffle.InitializeDeck()[0]), report.Cards[0])
+	assert.Equal(t, 51*51, report.DegreesOfFreedom)
+	assert.InDelta(t, 1000.0/52, report.Expected, 1e-9)
+
+	// 每張牌和每個位置的次數之和都等於洗牌次數
+	for card, row := range report.Counts {
+		sum := 0
+		for _, count := range row {
+			sum += count
+		}
+		assert.Equal(t, report.Shuffles, sum, card)
+	}
+	for pos := range report.Cards {
+		sum := 0
+		for _, row := range report.Counts {
+			sum += row[pos]
+		}
+		assert.Equal(t, report.Shuffles, sum, pos)
+	}
+	assert.Greater(t, report.PValue, 0.001)
+	assert.LessOrEqual(t, report.PValue, 1.0)
+	for _, stat := range report.CardStats {
+		assert.True(t, stat.MinCount <= stat.MaxCount)
+		assert.True(t, stat.PValue >= 0 && stat.PValue <= 1, stat.Card)
+	}
+
+	// 洗牌與生產環境相同：只重放一個輪次時，頻率表就是該輪洗出的牌組
+	single, err := simulate.Run(ctx, client, 950, 950, simulate.WithSessionPrefix("table"))
+	deck, err2 := client.ShuffleAtRound(ctx, 950, "table_0")
+	if assert.NoError(t, err) && assert.NoError(t, err2) {
+		for pos, card := range deck {
+			assert.Equal(t, 1, single.Counts[slices.Index(single.Cards, drandshuffle.CardToString(card))][pos])
+		}
+	}
+
+	again, err := simulate.Run(ctx, client, 1, 1000)
+	if assert.NoError(t, err) {
+		assert.Equal(t, report, again)
+	}
+
+	// 同一輪的多局共用隨機信標，並不獨立
+	shared, err := simulate.Run(ctx, client, 901, 1000, simulate.WithSessions(20))
+	if assert.NoError(t, err) {
+		assert.Equal(t, 2000, shared.Shuffles)
+		assert.Less(t, shared.PValue, 0.001)
+	}
+
+	var buf bytes.Buffer
+	if assert.NoError(t, report.WriteCSV(&buf)) {
+		rows, err := csv.NewReader(&buf).ReadAll()
+		assert.NoError(t, err)
+		assert.Len(t, rows, 53)
+		assert.Len(t, rows[0], 55)
+		assert.Equal(t, []string{"card", "pos_0"}, rows[0][:2])
+	}
+
+	_, err = simulate.Run(ctx, client, 0, 10)
+	assert.ErrorIs(t, err, drandshuffle.ErrInvalidConfig)
+	_, err = simulate.Run(ctx, client, 990, 1000, simulate.WithSessions(0))
+	assert.ErrorIs(t, err, drandshuffle.ErrInvalidConfig)
+	_, err = simulate.Run(ctx, client, 990, 1000, simulate.WithSessionPrefix("bad prefix"))
+	assert.ErrorIs(t, err, drandshuffle.ErrInvalidSessionID)
+	double := drandshuffle.NewDeckTemplate(drandshuffle.DeckSpec{Suits: []string{"黑桃"}, Values: []string{"A"}, Extras: []drandshuffle.Card{{Suit: "黑桃", Value: "A"}}})
+	_, err = simulate.Run(ctx, client, 990, 1000, simulate.WithDeck(double))
+	assert.ErrorIs(t, err, drandshuffle.ErrInvalidConfig)
+	_, err = simulate.Run(ctx, client, 990, 1010)
+	assert.Error(t, err)
+}
+
+// TestTally 測試直接統計牌組的頻率表與重放的結果一致，並拒絕不是模板排列的牌組
+func TestTally(t *testing.T) {
+	tally, err := simulate.NewTally(nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	deck := drandshuffle.InitializeDeck()
+	assert.NoError(t, tally.Add(deck))
+	u := tally.Uniformity()
+	assert.Equal(t, 1, u.Shuffles)
+	assert.Equal(t, 1, u.Counts[0][0])
+	assert.Equal(t, 51*51, u.DegreesOfFreedom)
+
+	assert.ErrorIs(t, tally.Add(deck[:51]), drandshuffle.ErrDeckMismatch)
+	dup := append([]drandshuffle.Card(nil), deck...)
+	dup[1] = dup[0]
+	assert.ErrorIs(t, tally.Add(dup), drandshuffle.ErrDeckMismatch)
+	assert.Equal(t, 1, tally.Uniformity().Shuffles, "Rejected decks should not be counted")
+}
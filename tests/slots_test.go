@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/drandshuffletest"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/games/slots"
+)
+
+// slotMachine 是測試用的三軸捲軸配置，第三條捲軸只會停在有權重的位置
+var slotMachine = slots.Machine{Reels: []slots.Reel{
+	{Symbols: []string{"7", "BAR", "櫻桃", "鈴鐺", "檸檬"}},
+	{Symbols: []string{"7", "BAR", "櫻桃", "鈴鐺", "檸檬"}},
+	{Symbols: []string{"7", "BAR", "櫻桃", "鈴鐺"}, Weights: []int{1, 0, 5, 0}},
+}}
+
+// TestSlotsSpin 測試停止位置的重現、權重和驗證
+func TestSlotsSpin(t *testing.T) {
+	src := drandshuffletest.NewFakeBeaconSource(1000)
+	client := drandshuffle.NewClientWithManager(drandshuffletest.NewManager(t, src))
+	ctx := context.Background()
+
+	for i := 0; i < 50; i++ {
+		spin, err := slots.NewSpin(ctx, client, 990, fmt.Sprintf("spin_%04d", i), slotMachine)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Len(t, spin.Stops, 3)
+		assert.True(t, spin.Stops[0] >= 0 && spin.Stops[0] < 5)
+		assert.Contains(t, []int{0, 2}, spin.Stops[2], "zero-weight stop selected")
+		assert.NoError(t, slots.Verify(spin, slotMachine))
+	}
+
+	spin, err := slots.NewSpin(ctx, client, 990, "spin_0001", slotMachine)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, slots.Stops(spin.Proof.Seed, slotMachine), spin.Stops)
+	assert.Equal(t, slotMachine.Digest(), spin.MachineDigest)
+
+	window := slotMachine.Window([]int{4, 0, 3}, 3)
+	assert.Equal(t, [][]string{{"檸檬", "7", "BAR"}, {"7", "BAR", "櫻桃"}, {"鈴鐺", "7", "BAR"}}, window)
+
+	// 停止位置缺少或超出範圍的捲軸返回 nil，不會 panic
+	assert.Equal(t, [][]string{{"檸檬"}, nil, nil}, slotMachine.Window([]int{4, -1}, 1))
+	assert.Equal(t, [][]string{nil, {}, {}}, slotMachine.Window([]int{99, 0, 0}, -2))
+	assert.Equal(t, [][]string{nil, nil, nil}, slotMachine.Window(nil, 3))
+
+	tampered := *spin
+	tampered.Stops = slices.Clone(spin.Stops)
+	tampered.Stops[0] = (tampered.Stops[0] + 1) % 5
+	err = slots.Verify(&tampered, slotMachine)
+	assert.ErrorIs(t, err, slots.ErrSpinMismatch)
+	assert.Equal(t, drandshuffle.CategoryVerification, drandshuffle.Category(err))
+
+	// 事後改動捲軸條會被摘要發現
+	changed := slots.Machine{Reels: slices.Clone(slotMachine.Reels)}
+	changed.Reels[2] = slots.Reel{Symbols: []string{"7", "BAR", "櫻桃", "鈴鐺"}, Weights: []int{5, 0, 1, 0}}
+	assert.ErrorIs(t, slots.Verify(spin, changed), slots.ErrSpinMismatch)
+
+	for _, m := range []slots.Machine{
+		{},
+		{Reels: []slots.Reel{{}}},
+		{Reels: []slots.Reel{{Symbols: []string{"7"}, Weights: []int{1, 2}}}},
+		{Reels: []slots.Reel{{Symbols: []string{"7", "BAR"}, Weights: []int{0, 0}}}},
+		{Reels: []slots.Reel{{Symbols: []string{"7", "BAR"}, Weights: []int{-1, 2}}}},
+	} {
+		_, err := slots.NewSpin(ctx, client, 990, "spin_x", m)
+		assert.ErrorIs(t, err, drandshuffle.ErrInvalidConfig, m)
+	}
+}
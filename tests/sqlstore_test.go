@@ -0,0 +1,319 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	_ "modernc.org/sqlite"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/drandshuffletest"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/sqlstore"
+)
+
+// newSQLStore 創建使用臨時 SQLite 數據庫並已完成遷移的 Store
+func newSQLStore(t *testing.T) (*sqlstore.Store, *sql.DB) {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "drandshuffle.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	store := sqlstore.New(db, sqlstore.SQLite)
+	if err := store.Migrate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return store, db
+}
+
+// TestSQLStoreMigrate 測試遷移可以重複執行
+func TestSQLStoreMigrate(t *testing.T) {
+	store, db := newSQLStore(t)
+	ctx := context.Background()
+	assert.NoError(t, store.Migrate(ctx))
+	version, err := store.Version(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, sqlstore.SchemaVersion, version)
+
+	_, err = db.Exec("INSERT INTO drandshuffle_schema_migrations (version, applied_at) VALUES (?, ?)", sqlstore.SchemaVersion+1, time.Now())
+	assert.NoError(t, err)
+	assert.Error(t, store.Migrate(ctx), "A newer schema should be rejected")
+
+	assert.Equal(t, "postgres", sqlstore.Postgres.String())
+	assert.Equal(t, "mysql", sqlstore.MySQL.String())
+}
+
+// TestSQLBeaconStore 測試在數據庫中保存和恢復隨機信標
+func TestSQLBeaconStore(t *testing.T) {
+	store, _ := newSQLStore(t)
+	src := drandshuffletest.NewFakeBeaconSource(1000)
+
+	assert.NoError(t, store.SaveBatch([]drandshuffle.Beacon{src.Beacon(998), src.Beacon(999)}))
+	assert.NoError(t, store.Save(src.Beacon(1000)))
+	assert.NoError(t, store.Save(src.Beacon(999)), "Saving a round twice should be ignored")
+
+	beacons, err := store.Load()
+	assert.NoError(t, err)
+	if assert.Len(t, beacons, 3) {
+		for i, beacon := range beacons {
+			assert.Equal(t, uint64(998+i), beacon.Round)
+			assert.Equal(t, src.Randomness(beacon.Round), beacon.Randomness)
+		}
+	}
+
+	// 以保存的信標啟動新的管理器，不需要網絡即可使用最新輪次
+	offline := drandshuffletest.NewFakeBeaconSource(1000)
+	offline.SetError(drandshuffle.ErrBeaconUnavailable)
+	dm, err := drandshuffle.NewDrandManagerWithClient(offline, drandshuffle.WithBeaconStore(store), drandshuffle.WithWarmStart())
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer dm.Close()
+	_, round, err := dm.GetLatestRandomness()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1000), round)
+}
+
+// TestSQLSessionStore 測試保存和讀取已發出的牌局
+func TestSQLSessionStore(t *testing.T) {
+	store, _ := newSQLStore(t)
+	ctx := context.Background()
+	src := drandshuffletest.NewFakeBeaconSource(1000)
+	client := drandshuffle.NewClientWithManager(drandshuffletest.NewManager(t, src))
+
+	result, err := client.NewShuffle().Session("game_1").Round(990).WithProof().Do(ctx)
+	assert.NoError(t, err)
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	assert.NoError(t, store.SaveSession(ctx, sqlstore.Session{
+		SessionID: "game_1",
+		Round:     result.Round,
+		Deck:      result.Deck,
+		Proof:     result.Proof,
+		CreatedAt: created,
+	}))
+
+	session, err := store.LoadSession(ctx, "game_1")
+	if assert.NoError(t, err) {
+		assert.Equal(t, uint64(990), session.Round)
+		assert.Equal(t, result.Deck, session.Deck)
+		assert.Equal(t, result.Proof.Digest(), session.Proof.Digest())
+		assert.True(t, created.Equal(session.CreatedAt))
+		assert.NoError(t, drandshuffle.VerifyProof(session.Proof, nil, session.Deck))
+	}
+
+	err = store.SaveSession(ctx, sqlstore.Session{SessionID: "game_1", Round: 991, Deck: result.Deck})
+	assert.ErrorIs(t, err, sqlstore.ErrSessionExists, "A session must not be dealt twice")
+
+	assert.NoError(t, store.SaveSession(ctx, sqlstore.Session{SessionID: "game_2", Round: 991, Deck: result.Deck}))
+	session, err = store.LoadSession(ctx, "game_2")
+	if assert.NoError(t, err) {
+		assert.Nil(t, session.Proof)
+		assert.False(t, session.CreatedAt.IsZero())
+	}
+
+	_, err = store.LoadSession(ctx, "missing")
+	assert.ErrorIs(t, err, sqlstore.ErrSessionNotFound)
+	assert.ErrorIs(t, store.SaveSession(ctx, sqlstore.Session{}), drandshuffle.ErrInvalidSessionID)
+}
+
+// TestSQLStoreSnapshot 測試快照可以恢復到另一個數據庫，重複恢復時不覆蓋已有的項目
+func TestSQLStoreSnapshot(t *testing.T) {
+	from, _ := newSQLStore(t)
+	ctx := context.Background()
+	src := drandshuffletest.NewFakeBeaconSource(1000)
+	client := drandshuffle.NewClientWithManager(drandshuffletest.NewManager(t, src))
+
+	assert.NoError(t, from.SaveBatch([]drandshuffle.Beacon{src.Beacon(991), src.Beacon(990)}))
+	result, err := client.NewShuffle().Session("game_1").Round(990).WithProof().Do(ctx)
+	assert.NoError(t, err)
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	assert.NoError(t, from.SaveSession(ctx, sqlstore.Session{SessionID: "game_1", Round: 990, Deck: result.Deck, Proof: result.Proof, CreatedAt: created, Tenant: "casino-a"}))
+	assert.NoError(t, from.VerifySession(ctx, "game_1", nil))
+	assert.NoError(t, from.SaveSession(ctx, sqlstore.Session{SessionID: "game_2", Round: 991, Deck: result.Deck, CreatedAt: created.Add(time.Second)}))
+
+	snapshot, err := from.Snapshot(ctx)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []uint64{990, 991}, []uint64{snapshot.Beacons[0].Round, snapshot.Beacons[1].Round})
+	if assert.Len(t, snapshot.Sessions, 2) {
+		assert.Equal(t, "game_1", snapshot.Sessions[0].SessionID)
+		assert.Equal(t, sqlstore.Verified, snapshot.Sessions[0].Verification)
+	}
+
+	to, _ := newSQLStore(t)
+	assert.NoError(t, to.SaveSession(ctx, sqlstore.Session{SessionID: "game_2", Round: 999, Deck: result.Deck}))
+	restored, err := to.Restore(ctx, snapshot)
+	assert.NoError(t, err)
+	assert.Equal(t, sqlstore.RestoreResult{Beacons: 2, Sessions: 1}, restored)
+
+	session, err := to.LoadSession(ctx, "game_1")
+	if assert.NoError(t, err) {
+		assert.Equal(t, result.Deck, session.Deck)
+		assert.Equal(t, result.Proof.Digest(), session.Proof.Digest())
+		assert.Equal(t, "casino-a", session.Tenant)
+		assert.Equal(t, sqlstore.Verified, session.Verification)
+		assert.True(t, created.Equal(session.CreatedAt))
+	}
+	session, err = to.LoadSession(ctx, "game_2")
+	if assert.NoError(t, err) {
+		assert.Equal(t, uint64(999), session.Round, "existing sessions should not be overwritten")
+	}
+	beacons, err := to.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, snapshot.Beacons, beacons)
+
+	restored, err = to.Restore(ctx, snapshot)
+	assert.NoError(t, err)
+	assert.Equal(t, sqlstore.RestoreResult{}, restored, "restoring twice should add nothing")
+
+	// 任何一項無效時全部回滾
+	empty, _ := newSQLStore(t)
+	bad := &sqlstore.Snapshot{Beacons: snapshot.Beacons, Sessions: []sqlstore.Session{{SessionID: "bad id"}}}
+	_, err = empty.Restore(ctx, bad)
+	assert.ErrorIs(t, err, drandshuffle.ErrInvalidSessionID)
+	beacons, err = empty.Load()
+	assert.NoError(t, err)
+	assert.Empty(t, beacons)
+}
+
+// TestSQLListSessions 測試按條件篩選和以游標分頁瀏覽牌局
+func TestSQLListSessions(t *testing.T) {
+	store, _ := newSQLStore(t)
+	ctx := context.Background()
+	src := drandshuffletest.NewFakeBeaconSource(1000)
+	client := drandshuffle.NewClientWithManager(drandshuffletest.NewManager(t, src))
+
+	for i := 0; i < 10; i++ {
+		id := fmt.Sprintf("game_%d", i)
+		result, err := client.NewShuffle().Session(id).Round(uint64(990 + i%5)).WithProof().Do(ctx)
+		if !assert.NoError(t, err) {
+			return
+		}
+		tenant := "casino_a"
+		if i%2 == 1 {
+			tenant = "casino_b"
+		}
+		assert.NoError(t, store.SaveSession(ctx, sqlstore.Session{
+			SessionID: id, Round: result.Round, Deck: result.Deck, Proof: result.Proof, Tenant: tenant,
+		}))
+	}
+
+	// 以游標逐頁讀取全部牌局，順序為輪次再遊戲局號
+	var all []sqlstore.Session
+	q := sqlstore.SessionQuery{Limit: 3}
+	for pages := 0; ; pages++ {
+		page, err := store.ListSessions(ctx, q)
+		if !assert.NoError(t, err) || !assert.Less(t, pages, 5) {
+			return
+		}
+		all = append(all, page.Sessions...)
+		if page.NextCursor == "" {
+			break
+		}
+		q.Cursor = page.NextCursor
+	}
+	if assert.Len(t, all, 10) {
+		for i := 1; i < len(all); i++ {
+			prev, cur := all[i-1], all[i]
+			assert.True(t, prev.Round < cur.Round || (prev.Round == cur.Round && prev.SessionID < cur.SessionID), "Sessions should be ordered")
+		}
+		assert.Equal(t, sqlstore.Unverified, all[0].Verification)
+	}
+
+	page, err := store.ListSessions(ctx, sqlstore.SessionQuery{MinRound: 991, MaxRound: 992, Tenant: "casino_a"})
+	if assert.NoError(t, err) {
+		assert.Empty(t, page.NextCursor)
+		for _, s := range page.Sessions {
+			assert.Equal(t, "casino_a", s.Tenant)
+			assert.True(t, s.Round >= 991 && s.Round <= 992)
+		}
+		assert.Len(t, page.Sessions, 2)
+	}
+
+	// 驗證後按驗證狀態篩選
+	assert.NoError(t, store.VerifySession(ctx, "game_3", nil))
+	session, err := store.LoadSession(ctx, "game_4")
+	if assert.NoError(t, err) {
+		session.SessionID = "game_forged"
+		session.Deck[0], session.Deck[1] = session.Deck[1], session.Deck[0]
+		session.Proof.SessionID = "game_forged"
+		assert.NoError(t, store.SaveSession(ctx, *session))
+		assert.ErrorIs(t, store.VerifySession(ctx, "game_forged", nil), drandshuffle.ErrDeckMismatch)
+	}
+	page, err = store.ListSessions(ctx, sqlstore.SessionQuery{Verification: sqlstore.Verified})
+	if assert.NoError(t, err) && assert.Len(t, page.Sessions, 1) {
+		assert.Equal(t, "game_3", page.Sessions[0].SessionID)
+	}
+	page, err = store.ListSessions(ctx, sqlstore.SessionQuery{Verification: sqlstore.VerificationFailed})
+	if assert.NoError(t, err) && assert.Len(t, page.Sessions, 1) {
+		assert.Equal(t, "game_forged", page.Sessions[0].SessionID)
+	}
+	assert.ErrorIs(t, store.SetVerification(ctx, "missing", sqlstore.Verified), sqlstore.ErrSessionNotFound)
+
+	_, err = store.ListSessions(ctx, sqlstore.SessionQuery{Cursor: "not a cursor"})
+	assert.ErrorIs(t, err, sqlstore.ErrInvalidCursor)
+	_, err = store.ListSessions(ctx, sqlstore.SessionQuery{Verification: "maybe"})
+	assert.Error(t, err)
+
+	t.Run("HTTP", func(t *testing.T) {
+		server := httptest.NewServer(sqlstore.NewSessionHandler(store))
+		defer server.Close()
+
+		var body struct {
+			Sessions []struct {
+				SessionID    string                     `json:"session_id"`
+				Tenant       string                     `json:"tenant"`
+				Round        uint64                     `json:"round"`
+				Deck         string                     `json:"deck"`
+				Proof        *drandshuffle.ShuffleProof `json:"proof"`
+				Verification string                     `json:"verification"`
+			} `json:"sessions"`
+			NextCursor string `json:"next_cursor"`
+		}
+		resp, err := http.Get(server.URL + "/sessions?tenant=casino_b&limit=2&min_round=990")
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		resp.Body.Close()
+		if assert.Len(t, body.Sessions, 2) {
+			assert.Equal(t, "casino_b", body.Sessions[0].Tenant)
+			deck, err := drandshuffle.DecodeDeck(body.Sessions[0].Deck)
+			assert.NoError(t, err)
+			assert.NoError(t, drandshuffle.VerifyProof(body.Sessions[0].Proof, nil, deck))
+		}
+		assert.NotEmpty(t, body.NextCursor)
+
+		resp, err = http.Get(server.URL + "/sessions?tenant=casino_b&cursor=" + body.NextCursor)
+		if assert.NoError(t, err) {
+			body.NextCursor = ""
+			assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+			resp.Body.Close()
+			assert.Len(t, body.Sessions, 3)
+			assert.Empty(t, body.NextCursor)
+		}
+
+		for _, query := range []string{"verification=maybe", "min_round=-1", "limit=0", "cursor=%21"} {
+			resp, err := http.Get(server.URL + "/sessions?" + query)
+			if assert.NoError(t, err) {
+				resp.Body.Close()
+				assert.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+			}
+		}
+		resp, err = http.Get(server.URL + "/other")
+		if assert.NoError(t, err) {
+			resp.Body.Close()
+			assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		}
+	})
+}
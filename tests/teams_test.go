@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+)
+
+// TestSplitTeams 測試隨機分隊的規格、重現和參數檢查
+func TestSplitTeams(t *testing.T) {
+	players := []string{"alice", "bob", "carol", "dave", "erin", "frank", "grace", "heidi", "ivan", "judy"}
+	seed := []byte("test_seed_for_teams")
+
+	split, err := drandshuffle.SplitTeams(players, []int{5, 5}, seed)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, split.Teams, 2)
+	assert.ElementsMatch(t, players, slices.Concat(split.Teams...))
+	assert.ElementsMatch(t, []int{0, 1}, split.PickOrder)
+
+	// 分隊與以域分隔的種子做 ShuffleSlice 的結果一致，選邊順序由同一個 DRBG 接續打亂
+	shuffled := slices.Clone(players)
+	drandshuffle.ShuffleSlice(shuffled, labelledSeed("drandshuffle/teams/v1", seed))
+	assert.Equal(t, shuffled[:5], split.Teams[0])
+	assert.Equal(t, shuffled[5:], split.Teams[1])
+	assert.Equal(t, "alice", players[0], "input should not be modified")
+
+	again, err := drandshuffle.SplitTeams(players, []int{5, 5}, seed)
+	if assert.NoError(t, err) {
+		assert.Equal(t, split, again)
+	}
+
+	// 同一種子直接洗牌的結果不同於分隊
+	plain := slices.Clone(players)
+	drandshuffle.ShuffleSlice(plain, seed)
+	assert.NotEqual(t, plain[:5], split.Teams[0], "SplitTeams should be domain-separated from ShuffleSlice")
+
+	uneven, err := drandshuffle.SplitTeams(players, []int{4, 3, 3}, seed)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []int{4, 3, 3}, []int{len(uneven.Teams[0]), len(uneven.Teams[1]), len(uneven.Teams[2])})
+		assert.ElementsMatch(t, []int{0, 1, 2}, uneven.PickOrder)
+	}
+
+	for _, tc := range []struct {
+		players []string
+		sizes   []int
+	}{
+		{players, nil},
+		{players, []int{5, 4}},
+		{players, []int{10, 0}},
+		{[]string{"alice", "alice"}, []int{1, 1}},
+	} {
+		_, err := drandshuffle.SplitTeams(tc.players, tc.sizes, seed)
+		assert.ErrorIs(t, err, drandshuffle.ErrInvalidConfig, tc.sizes)
+	}
+}
@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"crypto/sha256"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+)
+
+// labelledSeed 按規格以域分隔前綴派生種子，即 SHA256(domain || seed)
+func labelledSeed(domain string, seed []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte(domain))
+	h.Write(seed)
+	return h.Sum(nil)
+}
+
+// TestTieBreak 測試平局排序的重現、與輸入順序無關和域分隔
+func TestTieBreak(t *testing.T) {
+	players := []string{"seat_3", "seat_1", "seat_6", "seat_2"}
+	seed := []byte("test_seed_for_tiebreak")
+
+	order := drandshuffle.TieBreak(players, seed)
+	assert.ElementsMatch(t, players, order)
+	assert.Equal(t, "seat_3", players[0], "input should not be modified")
+	assert.Equal(t, order, drandshuffle.TieBreak(players, seed))
+
+	// 與候選者的列出順序無關
+	reversed := slices.Clone(players)
+	slices.Reverse(reversed)
+	assert.Equal(t, order, drandshuffle.TieBreak(reversed, seed))
+
+	// 按規格重新計算：排序後以域分隔的種子做 ShuffleSlice
+	want := slices.Clone(players)
+	slices.Sort(want)
+	drandshuffle.ShuffleSlice(want, labelledSeed("drandshuffle/tiebreak/v1", seed))
+	assert.Equal(t, want, order)
+
+	// 同一種子直接洗牌的結果不同於平局排序
+	sorted := slices.Clone(players)
+	slices.Sort(sorted)
+	different := false
+	for i := range 16 {
+		s := append(slices.Clone(seed), byte(i))
+		plain := slices.Clone(sorted)
+		drandshuffle.ShuffleSlice(plain, s)
+		if !slices.Equal(plain, drandshuffle.TieBreak(players, s)) {
+			different = true
+			break
+		}
+	}
+	assert.True(t, different, "TieBreak should be domain-separated from ShuffleSlice")
+
+	assert.Empty(t, drandshuffle.TieBreak(nil, seed))
+	assert.Equal(t, []string{"only"}, drandshuffle.TieBreak([]string{"only"}, seed))
+	assert.ElementsMatch(t, []string{"a", "a", "b"}, drandshuffle.TieBreak([]string{"a", "b", "a"}, seed))
+}
@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/drandshuffletest"
+)
+
+// spanNames 返回記錄的 span 名稱，按結束的順序排列
+func spanNames(spans []sdktrace.ReadOnlySpan) []string {
+	names := make([]string, len(spans))
+	for i, span := range spans {
+		names[i] = span.Name()
+	}
+	return names
+}
+
+// spanAttr 返回 span 的屬性值
+func spanAttr(span sdktrace.ReadOnlySpan, key string) attribute.Value {
+	for _, kv := range span.Attributes() {
+		if string(kv.Key) == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+// TestTracing 測試獲取和洗牌操作記錄的 OpenTelemetry span
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	src := drandshuffletest.NewFakeBeaconSource(1000)
+	client := drandshuffle.NewClientWithManager(drandshuffletest.NewManager(t, src, drandshuffle.WithTracerProvider(tp)))
+
+	assert.Equal(t, []string{"drandshuffle.fetch_latest"}, spanNames(recorder.Ended()), "The initial fetch is traced")
+	assert.Equal(t, int64(1000), spanAttr(recorder.Ended()[0], "drandshuffle.round").AsInt64())
+
+	// 洗牌的 span 以調用者的 span 為父節點
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "deal")
+	_, err := client.ShuffleAtRound(ctx, 999, "game_1")
+	parent.End()
+	assert.NoError(t, err)
+
+	spans := recorder.Ended()[1:]
+	assert.Equal(t, []string{
+		"drandshuffle.cache_lookup",
+		"drandshuffle.fetch_round",
+		"drandshuffle.derive_seed",
+		"drandshuffle.permute",
+		"drandshuffle.shuffle",
+		"deal",
+	}, spanNames(spans))
+	deal := spans[len(spans)-1]
+	for _, span := range spans[:len(spans)-1] {
+		assert.Equal(t, deal.SpanContext().TraceID(), span.SpanContext().TraceID(), span.Name())
+	}
+	assert.False(t, spanAttr(spans[0], "drandshuffle.cache_hit").AsBool())
+	assert.Equal(t, "game_1", spanAttr(spans[4], "drandshuffle.session_id").AsString())
+
+	// 第二次命中緩存，不再獲取
+	_, err = client.ShuffleAtRound(context.Background(), 999, "game_2")
+	assert.NoError(t, err)
+	spans = recorder.Ended()[len(recorder.Ended())-4:]
+	assert.Equal(t, "drandshuffle.cache_lookup", spans[0].Name())
+	assert.True(t, spanAttr(spans[0], "drandshuffle.cache_hit").AsBool())
+	assert.Equal(t, "drandshuffle.derive_seed", spans[1].Name())
+
+	// 失敗的獲取記錄錯誤和錯誤類別
+	src.SetError(errors.New("relay down"))
+	_, err = client.ShuffleAtRound(context.Background(), 998, "game_1")
+	assert.Error(t, err)
+	spans = recorder.Ended()
+	failed := spans[len(spans)-1]
+	assert.Equal(t, "drandshuffle.fetch_round", failed.Name())
+	assert.Equal(t, codes.Error, failed.Status().Code)
+	assert.Equal(t, "network", spanAttr(failed, "drandshuffle.error_category").AsString())
+}
@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/drandshuffletest"
+)
+
+// TestUsageMeter 測試按租戶和輪次計數、配額、保留範圍和計費週期的結算
+func TestUsageMeter(t *testing.T) {
+	m := drandshuffle.NewUsageMeter(
+		drandshuffle.WithRoundQuota(2),
+		drandshuffle.WithTenantQuota("vip", 0),
+		drandshuffle.WithUsageHistory(10),
+	)
+	assert.NoError(t, m.Charge("a", 100))
+	assert.NoError(t, m.Charge("a", 100))
+	err := m.Charge("a", 100)
+	assert.ErrorIs(t, err, drandshuffle.ErrQuotaExceeded)
+	assert.Equal(t, drandshuffle.CategoryInput, drandshuffle.Category(err))
+	var quota *drandshuffle.QuotaExceededError
+	if assert.True(t, errors.As(err, &quota)) {
+		assert.Equal(t, "a", quota.Tenant)
+		assert.Equal(t, uint64(100), quota.Round)
+		assert.Equal(t, 2, quota.Limit)
+	}
+	assert.NoError(t, m.Charge("a", 101), "The quota should apply per round")
+	for range 5 {
+		assert.NoError(t, m.Charge("vip", 100), "A tenant override of 0 should be unlimited")
+	}
+
+	usage := m.Usage("a")
+	assert.Equal(t, uint64(3), usage.Total)
+	assert.Equal(t, uint64(1), usage.Rejected)
+	assert.Equal(t, 2, usage.MaxPerRound)
+	assert.Equal(t, uint64(101), usage.LastRound)
+	assert.Equal(t, []drandshuffle.RoundUsage{{Round: 100, Count: 2}, {Round: 101, Count: 1}}, usage.Rounds)
+
+	// 早於保留範圍的輪次不保留逐輪用量，設定了配額時拒絕，以免繞過配額
+	assert.NoError(t, m.Charge("a", 120))
+	assert.Equal(t, []drandshuffle.RoundUsage{{Round: 120, Count: 1}}, m.Usage("a").Rounds)
+	assert.ErrorIs(t, m.Charge("a", 100), drandshuffle.ErrQuotaExceeded)
+	assert.NoError(t, m.Charge("a", 111))
+	assert.NoError(t, m.Charge("vip", 1))
+
+	snapshot := m.ResetTotals()
+	if assert.Len(t, snapshot, 2) {
+		assert.Equal(t, "a", snapshot[0].Tenant)
+		assert.Equal(t, uint64(5), snapshot[0].Total)
+		assert.Equal(t, "vip", snapshot[1].Tenant)
+		assert.Equal(t, uint64(6), snapshot[1].Total)
+	}
+	after := m.Usage("a")
+	assert.Zero(t, after.Total)
+	assert.Zero(t, after.Rejected)
+	assert.NotEmpty(t, after.Rounds, "Per-round usage should survive a billing reset")
+	assert.Equal(t, drandshuffle.TenantUsage{Tenant: "new", Quota: 2, Rounds: []drandshuffle.RoundUsage{}}, m.Usage("new"))
+}
+
+// TestUsageMeterIntegration 測試洗牌和派生隨機數按 context 中的租戶計數，驗證不計數，超出配額的請求返回 429
+func TestUsageMeterIntegration(t *testing.T) {
+	src := drandshuffletest.NewFakeBeaconSource(1000)
+	meter := drandshuffle.NewUsageMeter(drandshuffle.WithRoundQuota(3))
+	client := drandshuffle.NewClientWithManager(drandshuffletest.NewManager(t, src, drandshuffle.WithUsageMeter(meter)))
+	alice := drandshuffle.ContextWithTenant(context.Background(), "alice")
+	assert.Equal(t, "alice", drandshuffle.TenantFromContext(alice))
+	assert.Equal(t, "", drandshuffle.TenantFromContext(context.Background()))
+
+	result, err := client.NewShuffle().Session("game_1").Round(990).WithProof().Do(alice)
+	assert.NoError(t, err)
+	_, _, err = client.NewRandForSession(alice, 990, "game_2")
+	assert.NoError(t, err)
+	assert.NoError(t, client.Verify(alice, 990, "game_1", result.Deck))
+	_, err = client.ShuffleAtRound(alice, 990, "game_3")
+	assert.NoError(t, err)
+	_, err = client.ShuffleAtRound(alice, 990, "game_4")
+	assert.ErrorIs(t, err, drandshuffle.ErrQuotaExceeded)
+	_, _, err = client.ShuffleLatest(context.Background(), "game_5")
+	assert.NoError(t, err)
+
+	usage := meter.Usage("alice")
+	assert.Equal(t, uint64(3), usage.Total, "Verification should not be charged")
+	assert.Equal(t, uint64(1), usage.Rejected)
+	assert.Equal(t, uint64(1), meter.Usage("").Total, "Calls without a tenant should be charged to the empty tenant")
+
+	// 調用者的認證中間件設定租戶
+	withTenant := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(drandshuffle.ContextWithTenant(r.Context(), r.Header.Get("X-Tenant"))))
+		})
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/shuffle", withTenant(drandshuffle.NewShuffleHandler(client)))
+	mux.Handle("/admin/usage", drandshuffle.NewUsageHandler(meter))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	shuffle := func(tenant, session string) int {
+		data, _ := json.Marshal(drandshuffle.ShuffleRequest{SessionID: session, Round: 995})
+		req, _ := http.NewRequest(http.MethodPost, server.URL+"/shuffle", bytes.NewReader(data))
+		req.Header.Set("X-Tenant", tenant)
+		resp, err := http.DefaultClient.Do(req)
+		if !assert.NoError(t, err) {
+			return 0
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	for i, session := range []string{"game_a", "game_b", "game_c"} {
+		assert.Equal(t, http.StatusOK, shuffle("bob", session), "request %d", i)
+	}
+	assert.Equal(t, http.StatusTooManyRequests, shuffle("bob", "game_d"))
+	assert.Equal(t, http.StatusOK, shuffle("carol", "game_d"))
+
+	resp, err := http.Get(server.URL + "/admin/usage")
+	if assert.NoError(t, err) {
+		var all []drandshuffle.TenantUsage
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&all))
+		resp.Body.Close()
+		tenants := make(map[string]uint64)
+		for _, u := range all {
+			tenants[u.Tenant] = u.Total
+		}
+		assert.Equal(t, map[string]uint64{"": 1, "alice": 3, "bob": 3, "carol": 1}, tenants)
+	}
+	resp, err = http.Get(server.URL + "/admin/usage?tenant=bob")
+	if assert.NoError(t, err) {
+		var bob drandshuffle.TenantUsage
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&bob))
+		resp.Body.Close()
+		assert.Equal(t, uint64(1), bob.Rejected)
+		assert.Equal(t, []drandshuffle.RoundUsage{{Round: 995, Count: 3}}, bob.Rounds)
+	}
+}
@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/drandshuffletest"
+)
+
+// TestNewVerifiableID 測試由隨機信標派生 ID 的格式、規格、重現和篡改檢查
+func TestNewVerifiableID(t *testing.T) {
+	ctx := context.Background()
+	src := drandshuffletest.NewFakeBeaconSource(1000)
+	client := drandshuffle.NewClientWithManager(drandshuffletest.NewManager(t, src))
+
+	id, err := client.NewVerifiableID(ctx, 990, "orders", 7)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-8[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`), id.ID)
+	assert.Equal(t, drandshuffle.VerifiableIDAlgorithm, id.Algorithm)
+	assert.Equal(t, uint64(990), id.Round)
+	assert.Equal(t, src.Randomness(990), id.Randomness)
+	assert.NoError(t, drandshuffle.ValidateSessionID(id.ID), "IDs should be usable as session IDs")
+
+	// 按規格重新計算
+	msg := []byte("drandshuffle/id/v1")
+	msg = binary.BigEndian.AppendUint64(msg, uint64(len("orders")))
+	msg = append(msg, "orders"...)
+	msg = binary.BigEndian.AppendUint64(msg, 7)
+	msg = append(msg, src.Randomness(990)...)
+	sum := sha256.Sum256(msg)
+	sum[6] = sum[6]&0x0f | 0x80
+	sum[8] = sum[8]&0x3f | 0x80
+	assert.Equal(t, hex.EncodeToString(sum[:16]), strings.ReplaceAll(id.ID, "-", ""))
+
+	// 審計方從 JSON 記錄重新驗證
+	data, err := json.Marshal(id)
+	assert.NoError(t, err)
+	var decoded drandshuffle.VerifiableID
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.NoError(t, drandshuffle.VerifyVerifiableID(&decoded))
+	assert.Equal(t, src.Beacon(990), decoded.Beacon())
+
+	seen := map[string]bool{id.ID: true}
+	for _, other := range []struct {
+		round     uint64
+		namespace string
+		counter   uint64
+	}{{990, "orders", 8}, {990, "tickets", 7}, {991, "orders", 7}, {990, "order", 7}} {
+		got, err := client.NewVerifiableID(ctx, other.round, other.namespace, other.counter)
+		if assert.NoError(t, err) {
+			assert.False(t, seen[got.ID], "IDs should differ: %+v", other)
+			seen[got.ID] = true
+		}
+	}
+	again, err := client.NewVerifiableID(ctx, 990, "orders", 7)
+	if assert.NoError(t, err) {
+		assert.Equal(t, id.ID, again.ID)
+	}
+
+	t.Run("Tampered", func(t *testing.T) {
+		for name, tamper := range map[string]func(*drandshuffle.VerifiableID){
+			"counter":    func(v *drandshuffle.VerifiableID) { v.Counter++ },
+			"namespace":  func(v *drandshuffle.VerifiableID) { v.Namespace = "tickets" },
+			"randomness": func(v *drandshuffle.VerifiableID) { v.Randomness = src.Randomness(991) },
+		} {
+			tampered := decoded
+			tamper(&tampered)
+			err := drandshuffle.VerifyVerifiableID(&tampered)
+			assert.ErrorIs(t, err, drandshuffle.ErrSeedMismatch, name)
+			assert.Equal(t, drandshuffle.CategoryVerification, drandshuffle.Category(err), name)
+		}
+		assert.Error(t, drandshuffle.VerifyVerifiableID(nil))
+		bad := decoded
+		bad.Algorithm = "other"
+		assert.Equal(t, drandshuffle.CategoryInput, drandshuffle.Category(drandshuffle.VerifyVerifiableID(&bad)))
+	})
+
+	t.Run("Invalid namespace", func(t *testing.T) {
+		for _, namespace := range []string{"", "has space", string(make([]byte, drandshuffle.MaxSessionIDLength+1))} {
+			_, err := client.NewVerifiableID(ctx, 990, namespace, 0)
+			assert.ErrorIs(t, err, drandshuffle.ErrInvalidConfig, namespace)
+		}
+	})
+}
@@ -0,0 +1,173 @@
+package tests
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/drand/drand/v2/common"
+	"github.com/drand/drand/v2/common/chain"
+	"github.com/drand/drand/v2/crypto"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+)
+
+// testSigner 使用固定私鑰為測試用的隨機信標簽名
+type testSigner struct {
+	scheme *crypto.Scheme
+	info   *chain.Info
+	sign   func(msg []byte) []byte
+}
+
+// newTestSigner 創建 quicknet 同款簽名方案（簽名在 G1）的測試簽名者
+func newTestSigner(t testing.TB) *testSigner {
+	scheme := crypto.NewPedersenBLSUnchainedG1()
+	seed := sha256.Sum256([]byte("drandshuffle test key"))
+	priv := scheme.KeyGroup.Scalar().SetBytes(seed[:])
+
+	return &testSigner{
+		scheme: scheme,
+		info: &chain.Info{
+			PublicKey:   scheme.KeyGroup.Point().Mul(priv, nil),
+			Period:      3 * time.Second,
+			Scheme:      scheme.Name,
+			GenesisTime: time.Now().Add(-time.Hour).Unix(),
+		},
+		sign: func(msg []byte) []byte {
+			sig, err := scheme.AuthScheme.Sign(priv, msg)
+			assert.NoError(t, err)
+			return sig
+		},
+	}
+}
+
+// beacon 返回指定輪次的有效隨機信標
+func (s *testSigner) beacon(round uint64) drandshuffle.Beacon {
+	sig := s.sign(s.scheme.DigestBeacon(&common.Beacon{Round: round}))
+	return drandshuffle.Beacon{
+		Round:      round,
+		Randomness: crypto.RandomnessFromSignature(sig),
+		Signature:  sig,
+	}
+}
+
+// TestVerifier 測試單個和批量驗證隨機信標
+func TestVerifier(t *testing.T) {
+	signer := newTestSigner(t)
+	verifier, err := drandshuffle.NewVerifier(signer.info, drandshuffle.WithVerifyWorkers(4))
+	assert.NoError(t, err)
+
+	beacons := make([]drandshuffle.Beacon, 20)
+	for i := range beacons {
+		beacons[i] = signer.beacon(uint64(i + 1))
+	}
+	assert.NoError(t, verifier.Verify(beacons[0]))
+
+	// 簽名屬於另一輪次
+	beacons[5].Round = 99
+	// 隨機性被篡改
+	beacons[12].Randomness = sha256.New().Sum(nil)
+
+	errs := verifier.VerifyBatch(context.Background(), beacons)
+	assert.Len(t, errs, len(beacons))
+	for i, err := range errs {
+		if i == 5 || i == 12 {
+			assert.Error(t, err, "Beacon %d should fail verification", i)
+		} else {
+			assert.NoError(t, err, "Beacon %d should pass verification", i)
+		}
+	}
+
+	t.Run("Cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		for _, err := range verifier.VerifyBatch(ctx, beacons) {
+			if err != nil {
+				assert.ErrorIs(t, err, context.Canceled)
+			}
+		}
+	})
+
+	t.Run("Invalid chain info", func(t *testing.T) {
+		_, err := drandshuffle.NewVerifier(nil)
+		assert.Error(t, err)
+		_, err = drandshuffle.NewVerifier(&chain.Info{PublicKey: signer.info.PublicKey, Scheme: "unknown"})
+		assert.Error(t, err)
+	})
+}
+
+// BenchmarkVerifyBatch 測量批量驗證的吞吐量
+func BenchmarkVerifyBatch(b *testing.B) {
+	signer := newTestSigner(b)
+	verifier, err := drandshuffle.NewVerifier(signer.info)
+	assert.NoError(b, err)
+
+	beacons := make([]drandshuffle.Beacon, 64)
+	for i := range beacons {
+		beacons[i] = signer.beacon(uint64(i + 1))
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		verifier.VerifyBatch(context.Background(), beacons)
+	}
+}
+
+// TestBeaconJSON 測試導入和導出 drand 中繼格式的隨機信標
+func TestBeaconJSON(t *testing.T) {
+	signer := newTestSigner(t)
+	verifier, err := drandshuffle.NewVerifier(signer.info)
+	assert.NoError(t, err)
+	b1, b2 := signer.beacon(1), signer.beacon(2)
+
+	data, err := drandshuffle.EncodeBeaconJSON(b1)
+	assert.NoError(t, err)
+	assert.JSONEq(t, fmt.Sprintf(`{"round":1,"randomness":"%x","signature":"%x"}`, b1.Randomness, b1.Signature), string(data))
+
+	t.Run("Relay response", func(t *testing.T) {
+		beacons, err := drandshuffle.ParseBeaconJSON(data)
+		assert.NoError(t, err)
+		assert.Equal(t, []drandshuffle.Beacon{b1}, beacons)
+		assert.NoError(t, verifier.Verify(beacons[0]))
+	})
+
+	t.Run("Array and lines", func(t *testing.T) {
+		second, _ := drandshuffle.EncodeBeaconJSON(b2)
+		array := "[" + string(data) + ",\n" + string(second) + "]"
+		lines := string(data) + "\n" + string(second) + "\n"
+		for _, input := range []string{array, lines} {
+			beacons, err := drandshuffle.ParseBeaconJSON([]byte(input))
+			if assert.NoError(t, err) && assert.Len(t, beacons, 2) {
+				assert.Equal(t, b2, beacons[1])
+			}
+		}
+
+		// 導入的信標可以直接寫入存儲
+		store := drandshuffle.NewFileBeaconStore(filepath.Join(t.TempDir(), "beacons.jsonl"))
+		beacons, _ := drandshuffle.ParseBeaconJSON([]byte(lines))
+		assert.NoError(t, store.SaveBatch(beacons))
+		loaded, err := store.Load()
+		assert.NoError(t, err)
+		assert.Equal(t, beacons, loaded)
+	})
+
+	t.Run("Missing randomness", func(t *testing.T) {
+		beacons, err := drandshuffle.ParseBeaconJSON([]byte(fmt.Sprintf(`{"round":2,"signature":"%x"}`, b2.Signature)))
+		if assert.NoError(t, err) {
+			assert.Equal(t, b2.Randomness, beacons[0].Randomness)
+			assert.NoError(t, verifier.Verify(beacons[0]))
+		}
+	})
+
+	t.Run("Invalid input", func(t *testing.T) {
+		for _, input := range []string{"", "{", `{"round":1,"signature":"zz"}`, `{"signature":"00"}`, `[{"round":0}]`} {
+			_, err := drandshuffle.ParseBeaconJSON([]byte(input))
+			assert.ErrorIs(t, err, drandshuffle.ErrInvalidBeacon, input)
+			assert.Equal(t, drandshuffle.CategoryInput, drandshuffle.Category(err), input)
+		}
+	})
+}
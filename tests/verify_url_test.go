@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+	"github.com/coseto6125/DrandShuffle/drandshuffle/drandshuffletest"
+)
+
+// TestVerifyURL 測試驗證鏈接的生成、簽名、過期和驗證頁面的核對
+func TestVerifyURL(t *testing.T) {
+	src := drandshuffletest.NewFakeBeaconSource(1000)
+	client := drandshuffle.NewClientWithManager(drandshuffletest.NewManager(t, src))
+	ctx := context.Background()
+	deck, err := client.ShuffleAtRound(ctx, 990, "game_1")
+	if !assert.NoError(t, err) {
+		return
+	}
+	digest := drandshuffle.DeckDigest(deck)
+	key := bytes.Repeat([]byte{7}, drandshuffle.MinVerifyURLKeyLength)
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	clock := drandshuffle.WithVerifyURLNow(func() time.Time { return now })
+
+	link, err := drandshuffle.BuildVerifyURL("https://verify.example.com/hand?lang=en", 990, "game_1", digest,
+		drandshuffle.WithVerifyURLKey(key), drandshuffle.WithVerifyURLExpiry(now.Add(time.Hour)))
+	if !assert.NoError(t, err) {
+		return
+	}
+	u, err := url.Parse(link)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "verify.example.com", u.Host)
+		assert.Equal(t, "/hand", u.Path)
+		assert.Equal(t, "en", u.Query().Get("lang"), "Existing query parameters should be kept")
+		assert.Equal(t, "990", u.Query().Get("round"))
+		assert.Equal(t, "game_1", u.Query().Get("session"))
+		assert.Equal(t, digest, u.Query().Get("deck"))
+	}
+
+	parsed, err := drandshuffle.ParseVerifyURL(link, drandshuffle.WithVerifyURLKey(key), clock)
+	if assert.NoError(t, err) {
+		assert.Equal(t, &drandshuffle.VerifyLink{Round: 990, SessionID: "game_1", DeckDigest: digest, Expires: now.Add(time.Hour), Signed: true}, parsed)
+		verified, err := client.VerifyLink(ctx, parsed)
+		assert.NoError(t, err)
+		assert.Equal(t, deck, verified)
+	}
+
+	// 過期、改動參數、改用其他密鑰都拒絕
+	later := drandshuffle.WithVerifyURLNow(func() time.Time { return now.Add(time.Hour) })
+	_, err = drandshuffle.ParseVerifyURL(link, drandshuffle.WithVerifyURLKey(key), later)
+	assert.ErrorIs(t, err, drandshuffle.ErrInvalidLink)
+	assert.Equal(t, drandshuffle.CategoryVerification, drandshuffle.Category(err))
+	for _, tampered := range []string{
+		strings.Replace(link, "round=990", "round=991", 1),
+		strings.Replace(link, "session=game_1", "session=game_2", 1),
+		strings.Replace(link, "exp=", "exp=1", 1),
+	} {
+		assert.NotEqual(t, link, tampered)
+		_, err = drandshuffle.ParseVerifyURL(tampered, drandshuffle.WithVerifyURLKey(key), clock)
+		assert.ErrorIs(t, err, drandshuffle.ErrInvalidLink, tampered)
+	}
+	_, err = drandshuffle.ParseVerifyURL(link, drandshuffle.WithVerifyURLKey(bytes.Repeat([]byte{8}, 32)), clock)
+	assert.ErrorIs(t, err, drandshuffle.ErrInvalidLink)
+	assert.Equal(t, "this verification link is invalid or has expired", drandshuffle.ErrorMessage(err, drandshuffle.LocaleEn))
+
+	// 沒有簽名的鏈接，以及驗證頁面不檢查簽名時
+	unsigned, err := drandshuffle.BuildVerifyURL("https://verify.example.com/", 990, "game_1", digest)
+	if assert.NoError(t, err) {
+		assert.NotContains(t, unsigned, "sig=")
+		parsed, err := drandshuffle.ParseVerifyURL(unsigned)
+		assert.NoError(t, err)
+		assert.False(t, parsed.Signed)
+		_, err = drandshuffle.ParseVerifyURL(unsigned, drandshuffle.WithVerifyURLKey(key))
+		assert.ErrorIs(t, err, drandshuffle.ErrInvalidLink)
+	}
+
+	// 牌組摘要與重新洗牌的結果不符
+	other := *parsed
+	other.SessionID = "game_2"
+	_, err = client.VerifyLink(ctx, &other)
+	assert.ErrorIs(t, err, drandshuffle.ErrDeckMismatch)
+
+	for name, build := range map[string]func() (string, error){
+		"relative base": func() (string, error) { return drandshuffle.BuildVerifyURL("/verify", 990, "game_1", digest) },
+		"round 0": func() (string, error) {
+			return drandshuffle.BuildVerifyURL("https://v.example.com", 0, "game_1", digest)
+		},
+		"bad session": func() (string, error) {
+			return drandshuffle.BuildVerifyURL("https://v.example.com", 990, "game 1", digest)
+		},
+		"bad digest": func() (string, error) {
+			return drandshuffle.BuildVerifyURL("https://v.example.com", 990, "game_1", "abc")
+		},
+		"short key": func() (string, error) {
+			return drandshuffle.BuildVerifyURL("https://v.example.com", 990, "game_1", digest, drandshuffle.WithVerifyURLKey([]byte("short")))
+		},
+		"expiry without key": func() (string, error) {
+			return drandshuffle.BuildVerifyURL("https://v.example.com", 990, "game_1", digest, drandshuffle.WithVerifyURLExpiry(now))
+		},
+	} {
+		_, err := build()
+		assert.Error(t, err, name)
+		assert.Equal(t, drandshuffle.CategoryInput, drandshuffle.Category(err), name)
+	}
+	_, err = drandshuffle.ParseVerifyURL("https://verify.example.com/?round=990&session=game_1")
+	assert.ErrorIs(t, err, drandshuffle.ErrInvalidLink)
+	assert.Equal(t, drandshuffle.CategoryInput, drandshuffle.Category(err))
+}
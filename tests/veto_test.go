@@ -0,0 +1,67 @@
+package tests
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coseto6125/DrandShuffle/drandshuffle"
+)
+
+// TestVetoDraft 測試選圖流程的規格、重現和參數檢查
+func TestVetoDraft(t *testing.T) {
+	maps := []string{"Ancient", "Anubis", "Dust2", "Inferno", "Mirage", "Nuke", "Vertigo"}
+	bo3 := []drandshuffle.VetoAction{drandshuffle.VetoBan, drandshuffle.VetoBan, drandshuffle.VetoPick, drandshuffle.VetoPick, drandshuffle.VetoBan, drandshuffle.VetoBan}
+	seed := []byte("test_seed_for_veto")
+
+	result, err := drandshuffle.VetoDraft(maps, bo3, seed)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, result.Steps, len(maps))
+	assert.Len(t, result.Maps, 3)
+
+	// 按包的說明逐步重現
+	drbg := drandshuffle.NewDRBG(labelledSeed("drandshuffle/veto/v1", seed))
+	first := drbg.Intn(2)
+	assert.Equal(t, first, result.FirstTeam)
+	remaining := slices.Clone(maps)
+	for i, step := range result.Steps[:len(bo3)] {
+		j := drbg.Intn(len(remaining))
+		assert.Equal(t, drandshuffle.VetoStep{Team: (first + i) % 2, Action: bo3[i], Choice: remaining[j]}, step, i)
+		remaining = slices.Delete(remaining, j, j+1)
+	}
+	decider := result.Steps[len(bo3)]
+	assert.Equal(t, drandshuffle.VetoStep{Team: -1, Action: drandshuffle.VetoDecider, Choice: remaining[0]}, decider)
+	assert.Equal(t, []string{result.Steps[2].Choice, result.Steps[3].Choice, decider.Choice}, result.Maps)
+
+	chosen := make([]string, 0, len(maps))
+	for _, step := range result.Steps {
+		chosen = append(chosen, step.Choice)
+	}
+	assert.ElementsMatch(t, maps, chosen)
+
+	again, err := drandshuffle.VetoDraft(maps, bo3, seed)
+	if assert.NoError(t, err) {
+		assert.Equal(t, result, again)
+	}
+
+	single, err := drandshuffle.VetoDraft([]string{"Dust2"}, nil, seed)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"Dust2"}, single.Maps)
+	}
+
+	for _, tc := range []struct {
+		maps   []string
+		format []drandshuffle.VetoAction
+	}{
+		{nil, nil},
+		{[]string{"Dust2", "Dust2"}, bo3[:1]},
+		{maps, bo3[:5]},
+		{[]string{"Dust2", "Nuke"}, []drandshuffle.VetoAction{drandshuffle.VetoDecider}},
+	} {
+		_, err := drandshuffle.VetoDraft(tc.maps, tc.format, seed)
+		assert.ErrorIs(t, err, drandshuffle.ErrInvalidConfig, tc.maps)
+	}
+}
@@ -0,0 +1,63 @@
+// apicheck 檢查庫的導出 API 是否與保存的 v1 API 清單兼容，作為發布前的檢查步驟
+//
+//	go run ./tools/apicheck          # 檢查，有不兼容的修改時以非零狀態退出
+//	go run ./tools/apicheck -write   # 將新增的 API 寫入清單
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/coseto6125/DrandShuffle/internal/apicheck"
+)
+
+// packages 是受兼容性保證的包目錄
+var packages = []string{"drandshuffle", "drandshuffle/drandshuffletest", "drandshuffle/drandshufflepb", "drandshuffle/games/holdem", "drandshuffle/games/bigtwo", "drandshuffle/games/doudizhu", "drandshuffle/games/keno", "drandshuffle/games/bingo", "drandshuffle/games/scratch", "drandshuffle/games/slots", "drandshuffle/giveaway", "drandshuffle/simulate", "drandshuffle/report", "drandshuffle/audit", "drandshuffle/sqlstore", "drandshuffle/archive", "drandshuffle/evm", "drandshuffle/events", "drandshuffle/mobile", "drandshuffle/schedule"}
+
+const header = `# drandshuffle v1 API 清單，由 go run ./tools/apicheck -write 生成
+# 清單中的每一項在 v1 期間都不會被移除或修改，詳見 README 的「版本與兼容性」
+`
+
+func main() {
+	golden := flag.String("golden", "api/v1.txt", "API 清單文件")
+	write := flag.Bool("write", false, "將當前的 API 寫入清單；存在不兼容的修改時拒絕寫入")
+	flag.Parse()
+
+	var current []string
+	for _, dir := range packages {
+		features, err := apicheck.Features(dir)
+		if err != nil {
+			log.Fatal(err)
+		}
+		current = append(current, features...)
+	}
+
+	old, err := apicheck.ReadFile(*golden)
+	if err != nil && !(*write && errors.Is(err, fs.ErrNotExist)) {
+		log.Fatal(err)
+	}
+
+	removed, added := apicheck.Compare(old, current)
+	for _, f := range removed {
+		fmt.Println("-", f)
+	}
+	for _, f := range added {
+		fmt.Println("+", f)
+	}
+
+	if len(removed) > 0 {
+		log.Fatalf("發現 %d 項不兼容的 API 修改", len(removed))
+	}
+	if *write && len(added) > 0 {
+		content := header + strings.Join(current, "\n") + "\n"
+		if err := os.WriteFile(*golden, []byte(content), 0o644); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("已將 %d 項新增的 API 寫入 %s\n", len(added), *golden)
+	}
+}